@@ -0,0 +1,71 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+)
+
+// SetObjectList gives the access rights of the association, e.g. from an
+// object list read before and cached. With Settings.CheckAccessRights, SET
+// and ACTION requests the list doesn't give access to fail locally. The
+// list also gives the class versions to Attribute. A nil list disables the
+// checks.
+func (c *Client) SetObjectList(objectList []*cosem.AssociationObjectListItem) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.setObjectList(objectList)
+}
+
+func (c *Client) setObjectList(objectList []*cosem.AssociationObjectListItem) {
+	if objectList == nil {
+		c.objectList = nil
+		return
+	}
+	c.objectList = make(map[cosem.Obis]*cosem.AssociationObjectListItem, len(objectList))
+	for _, item := range objectList {
+		if item.LogicalName != nil {
+			c.objectList[*item.LogicalName] = item
+		}
+	}
+}
+
+// checkWriteAccess fails with an *exceptions.AccessRightsError when the
+// object list of the association doesn't allow writing the attribute.
+// Attribute 0 stands for all the attributes and is left to the meter.
+func (c *Client) checkWriteAccess(attribute *cosem.CosemAttribute) error {
+	if !c.settings.CheckAccessRights || c.objectList == nil || attribute.Attribute == AllAttributes {
+		return nil
+	}
+	item, ok := c.objectList[*attribute.Instance]
+	if !ok {
+		return exceptions.NewAccessRightsError(fmt.Sprintf("%s is not in the object list of this association", attribute.Instance))
+	}
+	rights, ok := item.AttributeAccessRights[attribute.Attribute]
+	if !ok || !rights.Has(cosem.AccessRightWriteAccess) {
+		if ok && rights.Has(cosem.AccessRightReadAccess) {
+			return exceptions.NewAccessRightsError(fmt.Sprintf("attribute %d of %s is read-only for this association", attribute.Attribute, attribute.Instance))
+		}
+		return exceptions.NewAccessRightsError(fmt.Sprintf("attribute %d of %s is not accessible for this association", attribute.Attribute, attribute.Instance))
+	}
+	return nil
+}
+
+// checkMethodAccess fails with an *exceptions.AccessRightsError when the
+// object list of the association doesn't allow invoking the method
+func (c *Client) checkMethodAccess(method *cosem.CosemMethod) error {
+	if !c.settings.CheckAccessRights || c.objectList == nil {
+		return nil
+	}
+	item, ok := c.objectList[*method.Instance]
+	if !ok {
+		return exceptions.NewAccessRightsError(fmt.Sprintf("%s is not in the object list of this association", method.Instance))
+	}
+	rights, ok := item.MethodAccessRights[method.Method]
+	if !ok || !rights.Has(cosem.AccessRightReadAccess) {
+		return exceptions.NewAccessRightsError(fmt.Sprintf("method %d of %s is not accessible for this association", method.Method, method.Instance))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// registerObjectList is the object list of an association giving read access
+// to attributes 1 and 2, read and write access to attribute 3 and access to
+// method 1 of the register 1.0.1.8.0.255
+const registerObjectList = "0101" +
+	"0204" + "120003" + "1100" + "09060100010800FF" +
+	"0202" +
+	"0103" + "02030F01160100" + "02030F02160100" + "02030F03160300" +
+	"0101" + "02020F011601"
+
+func TestClient_CheckAccessRights(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C1000F0000280000FF0200"), decodeHexString("C401C100"+registerObjectList)),
+		testutil.Expect(decodeHexString("C101C100030100010800FF0300"+"02020FFE161E"), decodeHexString("C501C100")),
+		testutil.Expect(decodeHexString("C301C100030100010800FF0101"+"0F00"), decodeHexString("C701C10000")),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.CheckAccessRights = true
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	_, err := c.GetObjectList()
+	require.NoError(t, err)
+
+	register := mustObis("1.0.1.8.0.255")
+	err = c.Set(cosem.NewCosemAttribute(enumerations.CosemInterfaceRegister, register, 2), decodeHexString("0600000000"))
+	var accessRightsError *exceptions.AccessRightsError
+	require.True(t, errors.As(err, &accessRightsError))
+	assert.Equal(t, "attribute 2 of 1-0:1.8.0.255 is read-only for this association", accessRightsError.Message)
+
+	require.NoError(t, c.Set(cosem.NewCosemAttribute(enumerations.CosemInterfaceRegister, register, 3), decodeHexString("02020FFE161E")))
+
+	_, err = c.Action(cosem.NewCosemMethod(enumerations.CosemInterfaceRegister, register, 2), decodeHexString("0F00"))
+	require.True(t, errors.As(err, &accessRightsError))
+	assert.Equal(t, "method 2 of 1-0:1.8.0.255 is not accessible for this association", accessRightsError.Message)
+
+	_, err = c.Action(cosem.NewCosemMethod(enumerations.CosemInterfaceRegister, register, 1), decodeHexString("0F00"))
+	require.NoError(t, err)
+
+	err = c.Set(cosem.NewCosemAttribute(enumerations.CosemInterfaceData, mustObis("0.0.96.1.0.255"), 2), decodeHexString("0600000000"))
+	require.True(t, errors.As(err, &accessRightsError))
+	assert.Equal(t, "0-0:96.1.0.255 is not in the object list of this association", accessRightsError.Message)
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_SetObjectListWithoutCheck(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C101C100030100010800FF0200"+"0600000000"), decodeHexString("C501C100")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	objectList, err := cosem.ParseObjectList(decodeHexString(registerObjectList), 3)
+	require.NoError(t, err)
+	c.SetObjectList(objectList)
+
+	register := mustObis("1.0.1.8.0.255")
+	require.NoError(t, c.Set(cosem.NewCosemAttribute(enumerations.CosemInterfaceRegister, register, 2), decodeHexString("0600000000")))
+	assert.Equal(t, 0, transport.Remaining())
+}
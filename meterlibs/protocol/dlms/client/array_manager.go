@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// GetArrayManager reads the arrays managed by an Array manager object
+func (c *Client) GetArrayManager(logicalName *cosem.Obis) (*cosem.ArrayManager, error) {
+	manager := cosem.NewArrayManager(logicalName)
+	if err := c.getAttributes(manager, cosem.ArrayManagerAttributeArrayObjectList); err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+// NumberOfEntries returns the number of elements of a managed array
+func (c *Client) NumberOfEntries(logicalName *cosem.Obis, id uint8) (uint32, error) {
+	manager := cosem.NewArrayManager(logicalName)
+	data, err := c.Action(manager.Method(cosem.ArrayManagerMethodNumberOfEntries), cosem.ArrayIDToBytes(id))
+	if err != nil {
+		return 0, err
+	}
+	return cosem.ParseNumberOfEntries(data)
+}
+
+// RetrieveByIndex reads the elements from and to the given indexes of a
+// managed array, and returns them as an A-XDR encoded array
+func (c *Client) RetrieveByIndex(logicalName *cosem.Obis, id uint8, from uint32, to uint32) ([]byte, error) {
+	if from < 1 || to < from {
+		return nil, fmt.Errorf("invalid range %d to %d, indexes start at 1", from, to)
+	}
+	manager := cosem.NewArrayManager(logicalName)
+	return c.Action(manager.Method(cosem.ArrayManagerMethodRetrieveByIndex), cosem.ArrayRangeToBytes(id, from, to))
+}
+
+// UpdateByIndex replaces the elements of a managed array from the given
+// index with the A-XDR encoded entries
+func (c *Client) UpdateByIndex(logicalName *cosem.Obis, id uint8, index uint32, entries ...[]byte) error {
+	if index < 1 {
+		return fmt.Errorf("invalid index %d, indexes start at 1", index)
+	}
+	manager := cosem.NewArrayManager(logicalName)
+	_, err := c.Action(manager.Method(cosem.ArrayManagerMethodUpdateByIndex), cosem.ArrayEntriesToBytes(id, index, entries))
+	return err
+}
+
+// InsertByIndex inserts the A-XDR encoded entries in a managed array after
+// the element at the given index, 0 inserts them first
+func (c *Client) InsertByIndex(logicalName *cosem.Obis, id uint8, index uint32, entries ...[]byte) error {
+	manager := cosem.NewArrayManager(logicalName)
+	_, err := c.Action(manager.Method(cosem.ArrayManagerMethodInsertByIndex), cosem.ArrayEntriesToBytes(id, index, entries))
+	return err
+}
+
+// RemoveByIndex removes the elements from and to the given indexes of a managed array
+func (c *Client) RemoveByIndex(logicalName *cosem.Obis, id uint8, from uint32, to uint32) error {
+	if from < 1 || to < from {
+		return fmt.Errorf("invalid range %d to %d, indexes start at 1", from, to)
+	}
+	manager := cosem.NewArrayManager(logicalName)
+	_, err := c.Action(manager.Method(cosem.ArrayManagerMethodRemoveByIndex), cosem.ArrayRangeToBytes(id, from, to))
+	return err
+}
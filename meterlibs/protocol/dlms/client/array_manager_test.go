@@ -0,0 +1,49 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_ArrayManager(t *testing.T) {
+	manager := mustObis("0.0.18.0.0.255")
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C1007B0000120000FF0200"),
+			// array 1 is the white list, attribute 2 of a data object
+			decodeHexString("C401C100"+"0101"+"0204"+"1101"+"120001"+"0906000080000AFF"+"0F02")),
+		testutil.Expect(decodeHexString("C301C1007B0000120000FF0101"+"1101"),
+			decodeHexString("C701C1000100"+"0600000190")),
+		testutil.Expect(decodeHexString("C301C1007B0000120000FF0201"+"0202"+"1101"+"0202"+"0600000002"+"0600000003"),
+			decodeHexString("C701C1000100"+"0102"+"090101"+"090102")),
+		testutil.Expect(decodeHexString("C301C1007B0000120000FF0301"+"0202"+"1101"+"0202"+"0600000002"+"0101"+"090109"),
+			decodeHexString("C701C10000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	arrays, err := c.GetArrayManager(manager)
+	require.NoError(t, err)
+	array, ok := arrays.Array(1)
+	require.True(t, ok)
+	assert.Equal(t, mustObis("0.0.128.0.10.255"), array.LogicalName)
+	assert.Equal(t, int8(2), array.AttributeIndex)
+
+	count, err := c.NumberOfEntries(manager, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(400), count)
+
+	entries, err := c.RetrieveByIndex(manager, 1, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, decodeHexString("0102090101090102"), entries)
+
+	require.NoError(t, c.UpdateByIndex(manager, 1, 2, decodeHexString("090109")))
+	assert.Error(t, c.RemoveByIndex(manager, 1, 0, 1))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+)
+
+// AsyncQueueSize is the number of asynchronous requests waiting to be sent,
+// GetAsync, SetAsync and ActionAsync block while the queue is full
+const AsyncQueueSize = 16
+
+// AsyncResult is the outcome of an asynchronous request
+type AsyncResult struct {
+	// Data is the A-XDR encoded data of a GET or the return data of an ACTION
+	Data []byte
+	Err  error
+}
+
+type asyncRequest struct {
+	run    func() ([]byte, error)
+	result chan<- *AsyncResult
+}
+
+// GetAsync queues a Get and returns the channel receiving its result. The
+// queued requests are sent one after the other by a single goroutine of the
+// client, in the order they were queued, so callers can wait for them in a
+// select without a goroutine per request.
+func (c *Client) GetAsync(attribute *cosem.CosemAttribute, accessSelection interface{}) <-chan *AsyncResult {
+	return c.enqueue(func() ([]byte, error) {
+		return c.Get(attribute, accessSelection)
+	})
+}
+
+// SetAsync queues a Set and returns the channel receiving its result
+func (c *Client) SetAsync(attribute *cosem.CosemAttribute, data []byte) <-chan *AsyncResult {
+	return c.enqueue(func() ([]byte, error) {
+		return nil, c.Set(attribute, data)
+	})
+}
+
+// ActionAsync queues an Action and returns the channel receiving its result
+func (c *Client) ActionAsync(method *cosem.CosemMethod, data []byte) <-chan *AsyncResult {
+	return c.enqueue(func() ([]byte, error) {
+		return c.Action(method, data)
+	})
+}
+
+// enqueue queues a request, it fails at once when the client is closed
+func (c *Client) enqueue(run func() ([]byte, error)) <-chan *AsyncResult {
+	result := make(chan *AsyncResult, 1)
+	c.startAsync.Do(func() {
+		c.async = make(chan *asyncRequest, AsyncQueueSize)
+		go c.runAsync()
+	})
+
+	select {
+	case c.async <- &asyncRequest{run: run, result: result}:
+	case <-c.closed:
+		result <- &AsyncResult{Err: exceptions.NewCommunicationError("client is closed")}
+	}
+	return result
+}
+
+// runAsync sends the queued requests until the client is closed, the
+// requests still queued then fail
+func (c *Client) runAsync() {
+	for {
+		select {
+		case request := <-c.async:
+			data, err := request.run()
+			request.result <- &AsyncResult{Data: data, Err: err}
+		case <-c.closed:
+			for {
+				select {
+				case request := <-c.async:
+					request.result <- &AsyncResult{Err: exceptions.NewCommunicationError("client is closed")}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func receive(t *testing.T, results <-chan *client.AsyncResult) *client.AsyncResult {
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(time.Second):
+		t.Fatal("no result")
+		return nil
+	}
+}
+
+func TestClient_Async(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+		testutil.Expect(decodeHexString("C101C1000100002A0000FF02001200FF"), decodeHexString("C501C100")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	clock := cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, client.Clock, cosem.ClockAttributeTime)
+	get := c.GetAsync(clock, nil)
+	set := c.SetAsync(cosem.NewCosemAttribute(enumerations.CosemInterfaceData, mustObis("0.0.42.0.0.255"), 2),
+		decodeHexString("1200FF"))
+
+	result := receive(t, get)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, testutil.ClockTimeResponse[4:], result.Data)
+	assert.NoError(t, receive(t, set).Err)
+	assert.Equal(t, 0, transport.Remaining())
+
+	c.Close()
+	assert.Error(t, receive(t, c.GetAsync(clock, nil)).Err)
+}
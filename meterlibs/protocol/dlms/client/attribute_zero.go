@@ -0,0 +1,57 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// AllAttributes is the attribute id referencing all attributes of an object
+const AllAttributes uint8 = 0
+
+// GetAllAttributes reads all attributes of an object at once using attribute 0.
+// Element i of the result is the value of attribute i+1. The server must have
+// accepted attribute_0_supported_with_get in the association.
+func (c *Client) GetAllAttributes(interfaceClass enumerations.CosemInterface, instance *cosem.Obis) ([]interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.requireConformance("attribute_0_supported_with_get"); err != nil {
+		return nil, err
+	}
+
+	attribute := cosem.NewCosemAttribute(interfaceClass, instance, AllAttributes)
+	data, err := c.retry(true, func() ([]byte, error) {
+		return c.get(attribute, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := encoding.DecodeAllAttributes(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		values[i] = c.resolveEnums(cosem.NewCosemAttribute(interfaceClass, instance, uint8(i+1)), value)
+	}
+	return values, nil
+}
+
+// SetAllAttributes writes all attributes of an object at once using attribute 0.
+// data is the A-XDR encoded structure with the values of all attributes. The server
+// must have accepted attribute_0_supported_with_set in the association.
+func (c *Client) SetAllAttributes(interfaceClass enumerations.CosemInterface, instance *cosem.Obis, data []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.requireConformance("attribute_0_supported_with_set"); err != nil {
+		return err
+	}
+
+	attribute := cosem.NewCosemAttribute(interfaceClass, instance, AllAttributes)
+	_, err := c.retry(false, func() ([]byte, error) {
+		return nil, c.set(attribute, data)
+	})
+	return err
+}
@@ -0,0 +1,46 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// associationResponseWithAttribute0 accepts attribute 0 with GET and SET
+var associationResponseWithAttribute0 = decodeHexString("6129A109060760857405080101A203020100A305A103020100BE10040E0800065F1F040000B01D04000007")
+
+func TestClient_GetAllAttributes(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(associationResponseWithAttribute0),
+		testutil.Expect(decodeHexString("C001C100080000010000FF0000"), decodeHexString("C401C100020209060000010000FF10FFC4")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+	assert.True(t, c.NegotiatedConformance().Attribute0SupportedWithGet)
+
+	attributes, err := c.GetAllAttributes(enumerations.CosemInterface(8), mustObis("0.0.1.0.0.255"))
+	assert.NoError(t, err)
+	assert.Len(t, attributes, 2)
+	assert.Equal(t, int16(-60), attributes[1])
+}
+
+func TestClient_GetAllAttributesNotNegotiated(t *testing.T) {
+	transport := testutil.NewScriptedTransport(associate(testutil.AssociationResponse))
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	_, err := c.GetAllAttributes(enumerations.CosemInterface(8), mustObis("0.0.1.0.0.255"))
+	var conformanceError *exceptions.ConformanceError
+	assert.ErrorAs(t, err, &conformanceError)
+
+	err = c.SetAllAttributes(enumerations.CosemInterface(8), mustObis("0.0.1.0.0.255"), []byte{0x02, 0x00})
+	assert.ErrorAs(t, err, &conformanceError)
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// attributeDecoder is an object decoding the values of its attributes
+type attributeDecoder interface {
+	Attribute(attribute uint8) *cosem.CosemAttribute
+	FromAttribute(attribute uint8, data []byte) error
+}
+
+// attributeEncoder is an object encoding the values of its attributes
+type attributeEncoder interface {
+	Attribute(attribute uint8) *cosem.CosemAttribute
+	ToAttribute(attribute uint8) ([]byte, error)
+}
+
+// getAttributes reads the attributes one by one and decodes them into the object
+func (c *Client) getAttributes(object attributeDecoder, attributes ...uint8) error {
+	for _, attribute := range attributes {
+		data, err := c.Get(object.Attribute(attribute), nil)
+		if err != nil {
+			return err
+		}
+		if err = object.FromAttribute(attribute, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setAttributes encodes all attributes first and then writes them one by one,
+// in the given order
+func (c *Client) setAttributes(object attributeEncoder, attributes ...uint8) error {
+	values := make([][]byte, 0, len(attributes))
+	for _, attribute := range attributes {
+		data, err := object.ToAttribute(attribute)
+		if err != nil {
+			return err
+		}
+		values = append(values, data)
+	}
+
+	for i, attribute := range attributes {
+		if err := c.Set(object.Attribute(attribute), values[i]); err != nil {
+			return fmt.Errorf("failed to set attribute %d: %w", attribute, err)
+		}
+	}
+	return nil
+}
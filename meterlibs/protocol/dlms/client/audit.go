@@ -0,0 +1,126 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+)
+
+// AuditEventType is the kind of a security relevant event of a connection
+type AuditEventType int
+
+const (
+	// AuditAssociationAttempt is an association request, accepted or not
+	AuditAssociationAttempt AuditEventType = iota
+	// AuditAuthenticationFailure is an association rejected with the
+	// authentication-failed diagnostic
+	AuditAuthenticationFailure
+	// AuditKeyTransfer is a key_transfer action, successful or not
+	AuditKeyTransfer
+	// AuditDecryptionFailure is a received APDU that could not be decrypted
+	AuditDecryptionFailure
+)
+
+// String implements fmt.Stringer
+func (t AuditEventType) String() string {
+	switch t {
+	case AuditAssociationAttempt:
+		return "association-attempt"
+	case AuditAuthenticationFailure:
+		return "authentication-failure"
+	case AuditKeyTransfer:
+		return "key-transfer"
+	case AuditDecryptionFailure:
+		return "decryption-failure"
+	default:
+		return fmt.Sprintf("AuditEventType(%d)", int(t))
+	}
+}
+
+// AuditEvent is a security relevant event of a connection
+type AuditEvent struct {
+	Time time.Time
+	Type AuditEventType
+	// ClientSystemTitle is the SystemTitle of the settings, nil when unset
+	ClientSystemTitle []byte
+	// ServerSystemTitle is the responding AP title of the last AARE, nil
+	// when the meter did not send one
+	ServerSystemTitle []byte
+	// Detail describes the event, e.g. the association result or the key id
+	Detail string
+	// Err is the error of a failed attempt, nil when it succeeded
+	Err error
+}
+
+// String implements fmt.Stringer
+func (e *AuditEvent) String() string {
+	s := fmt.Sprintf("%s %s client=%x server=%x", e.Time.Format(time.RFC3339), e.Type, e.ClientSystemTitle, e.ServerSystemTitle)
+	if e.Detail != "" {
+		s += " " + e.Detail
+	}
+	if e.Err != nil {
+		s += fmt.Sprintf(": %v", e.Err)
+	}
+	return s
+}
+
+// AuditLog records the security relevant events of a client, to satisfy the
+// audit requirements of the utilities. Record may be called with the client
+// locked, it must not use the client.
+type AuditLog interface {
+	Record(event *AuditEvent)
+}
+
+// AuditLogFunc adapts a function to an AuditLog
+type AuditLogFunc func(event *AuditEvent)
+
+// Record calls the function
+func (f AuditLogFunc) Record(event *AuditEvent) {
+	f(event)
+}
+
+// audit records an event in the AuditLog of the settings, if any
+func (c *Client) audit(eventType AuditEventType, detail string, err error) {
+	if c.settings.AuditLog == nil {
+		return
+	}
+	c.settings.AuditLog.Record(&AuditEvent{
+		Time:              time.Now(),
+		Type:              eventType,
+		ClientSystemTitle: c.settings.SystemTitle,
+		ServerSystemTitle: c.serverSystemTitle,
+		Detail:            detail,
+		Err:               err,
+	})
+}
+
+// auditAssociation records an association attempt, and the authentication
+// failure when the AARE rejects the credentials. aare is nil when no AARE was
+// received.
+func (c *Client) auditAssociation(aare *acse.ApplicationAssociationResponse, err error) {
+	detail := fmt.Sprintf("authentication=%s", c.settings.Authentication)
+	if aare != nil {
+		detail += fmt.Sprintf(" result=%s", aare.Result)
+	}
+	c.audit(AuditAssociationAttempt, detail, err)
+
+	if aare == nil {
+		return
+	}
+	if diagnostics, ok := aare.ResultSourceDiagnostics.(enumerations.AcseServiceUserDiagnostics); ok &&
+		diagnostics == enumerations.AcseServiceUserDiagnosticsAuthenticationFailed {
+		c.audit(AuditAuthenticationFailure, detail, err)
+	}
+}
+
+// auditDecryption records the error of a received APDU if it failed to decrypt
+func (c *Client) auditDecryption(err error) {
+	var decryptionError *exceptions.DecryptionError
+	if errors.As(err, &decryptionError) {
+		c.audit(AuditDecryptionFailure, "", err)
+	}
+}
@@ -0,0 +1,62 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func auditedSettings(events *[]*client.AuditEvent) *client.Settings {
+	settings := client.NewSettings(1, 1)
+	settings.SystemTitle = decodeHexString("4D4D4D0000000001")
+	settings.AuditLog = client.AuditLogFunc(func(event *client.AuditEvent) {
+		*events = append(*events, event)
+	})
+	return settings
+}
+
+func TestClient_AuditAuthenticationFailure(t *testing.T) {
+	// AARE rejecting the association with the authentication-failed diagnostic
+	transport := testutil.NewScriptedTransport(
+		associate(decodeHexString("611FA109060760857405080101A203020101A305A10302010DBE0604040E010601")),
+	)
+	var events []*client.AuditEvent
+	c := client.New(transport, auditedSettings(&events))
+	require.NoError(t, c.Connect())
+	assert.Error(t, c.Associate())
+
+	require.Len(t, events, 2)
+	assert.Equal(t, client.AuditAssociationAttempt, events[0].Type)
+	assert.Error(t, events[0].Err)
+	assert.Equal(t, client.AuditAuthenticationFailure, events[1].Type)
+	assert.Equal(t, decodeHexString("4D4D4D0000000001"), events[1].ClientSystemTitle)
+	assert.False(t, events[1].Time.IsZero())
+}
+
+func TestClient_AuditKeyTransfer(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(
+			decodeHexString("C301C1004000002B0000FF02010101020216020918"+"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"),
+			decodeHexString("C701C10000"),
+		),
+	)
+	var events []*client.AuditEvent
+	settings := auditedSettings(&events)
+	settings.Keys = &security.Keys{KeyEncryptionKey: decodeHexString("000102030405060708090A0B0C0D0E0F")}
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+	require.NoError(t, c.TransferKey(client.SecuritySetup, security.KeyIDAuthentication, decodeHexString("00112233445566778899AABBCCDDEEFF")))
+
+	require.Len(t, events, 2)
+	assert.Equal(t, client.AuditAssociationAttempt, events[0].Type)
+	assert.NoError(t, events[0].Err)
+	assert.Equal(t, client.AuditKeyTransfer, events[1].Type)
+	assert.NoError(t, events[1].Err)
+	assert.Contains(t, events[1].String(), "key-transfer client=4d4d4d0000000001")
+}
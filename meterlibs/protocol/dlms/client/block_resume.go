@@ -0,0 +1,144 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// BlockTransferProgress is the part of a GET block transfer received before
+// the link dropped
+type BlockTransferProgress struct {
+	// BlockNumber is the last block received and confirmed
+	BlockNumber uint32
+	// Data is the raw data of the blocks up to BlockNumber
+	Data []byte
+}
+
+// BlockTransferStore keeps the progress of interrupted GET block transfers,
+// by request fingerprint. A store holds the transfers of a single meter.
+type BlockTransferStore interface {
+	Load(fingerprint string) (*BlockTransferProgress, bool)
+	Save(fingerprint string, progress *BlockTransferProgress)
+	Delete(fingerprint string)
+}
+
+// MemoryBlockTransferStore is a BlockTransferStore in memory, it resumes
+// transfers interrupted during the lifetime of the client
+type MemoryBlockTransferStore struct {
+	mutex     sync.Mutex
+	transfers map[string]*BlockTransferProgress
+}
+
+// NewMemoryBlockTransferStore creates an empty MemoryBlockTransferStore
+func NewMemoryBlockTransferStore() *MemoryBlockTransferStore {
+	return &MemoryBlockTransferStore{
+		transfers: make(map[string]*BlockTransferProgress),
+	}
+}
+
+// Load returns the progress of the transfer of the request
+func (s *MemoryBlockTransferStore) Load(fingerprint string) (*BlockTransferProgress, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	progress, ok := s.transfers[fingerprint]
+	return progress, ok
+}
+
+// Save records the progress of the transfer of the request
+func (s *MemoryBlockTransferStore) Save(fingerprint string, progress *BlockTransferProgress) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.transfers[fingerprint] = progress
+}
+
+// Delete forgets the transfer of the request
+func (s *MemoryBlockTransferStore) Delete(fingerprint string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.transfers, fingerprint)
+}
+
+// Len returns the number of interrupted transfers
+func (s *MemoryBlockTransferStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.transfers)
+}
+
+// RequestFingerprint identifies a GET request by its attribute and access
+// selection, the invoke id is left out as it may change between associations
+func RequestFingerprint(request *xdlms.GetRequestNormal) (string, error) {
+	return request.Fingerprint()
+}
+
+// startGet sends the GET request, or resumes its interrupted block transfer
+// when the store has its progress. If the meter doesn't continue with the
+// next block, the progress is dropped and the request is sent again.
+func (c *Client) startGet(request *xdlms.GetRequestNormal, fingerprint string) (xdlms.Apdu, []byte, error) {
+	store := c.settings.BlockTransferStore
+	if store == nil {
+		response, err := c.request(request)
+		return response, nil, err
+	}
+
+	if progress, ok := store.Load(fingerprint); ok {
+		response, err := c.request(xdlms.NewGetRequestNext(progress.BlockNumber, c.invokeID))
+		if err != nil && c.needsReconnect() {
+			return nil, nil, err
+		}
+
+		blockNumber, ok := dataBlockNumber(response)
+		if ok && blockNumber == progress.BlockNumber+1 {
+			return response, append([]byte{}, progress.Data...), nil
+		}
+
+		if c.logger != nil {
+			if ok {
+				c.logger.Printf("meter resumed the block transfer after block %d with block %d, restarting",
+					progress.BlockNumber, blockNumber)
+			} else {
+				c.logger.Printf("meter refused to resume the block transfer after block %d, restarting: %v",
+					progress.BlockNumber, err)
+			}
+		}
+		store.Delete(fingerprint)
+	}
+
+	response, err := c.request(request)
+	return response, nil, err
+}
+
+// dataBlockNumber returns the block number of a GET response carrying a block
+func dataBlockNumber(response xdlms.Apdu) (uint32, bool) {
+	switch r := response.(type) {
+	case *xdlms.GetResponseWithDataBlock:
+		return r.BlockNumber, true
+	case *xdlms.GetResponseLastBlock:
+		return r.BlockNumber, true
+	default:
+		return 0, false
+	}
+}
+
+// saveProgress records the blocks received so far, if resuming is enabled
+func (c *Client) saveProgress(fingerprint string, blockNumber uint32, data []byte) {
+	if c.settings.BlockTransferStore != nil {
+		c.settings.BlockTransferStore.Save(fingerprint, &BlockTransferProgress{
+			BlockNumber: blockNumber,
+			Data:        append([]byte{}, data...),
+		})
+	}
+}
+
+// endTransfer forgets the progress of a transfer, unless it was interrupted
+// and can be resumed after reconnecting
+func (c *Client) endTransfer(fingerprint string, err error) {
+	if c.settings.BlockTransferStore != nil && (err == nil || !c.needsReconnect()) {
+		c.settings.BlockTransferStore.Delete(fingerprint)
+	}
+}
@@ -0,0 +1,116 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+var profileBufferData = []byte{0x01, 0x02, 0x12, 0x00, 0x01, 0x12, 0x00, 0x02}
+
+func resumingSettings(store client.BlockTransferStore) *client.Settings {
+	settings := client.NewSettings(16, 1)
+	settings.Timeout = 20 * time.Millisecond
+	settings.RetryAfterReconnect = true
+	settings.BlockTransferStore = store
+	return settings
+}
+
+func TestClient_ResumeBlockTransfer(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ProfileBufferRequest, testutil.ProfileBufferFirstBlock),
+		testutil.Expect(testutil.ProfileBufferNextRequest),
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ProfileBufferNextRequest, testutil.ProfileBufferLastBlock),
+	)
+	store := client.NewMemoryBlockTransferStore()
+	c := client.New(transport, resumingSettings(store))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	data, err := c.Get(profileBuffer, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, profileBufferData, data)
+	assert.Equal(t, 0, store.Len())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_ResumeBlockTransferRefused(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ProfileBufferRequest, testutil.ProfileBufferFirstBlock),
+		testutil.Expect(testutil.ProfileBufferNextRequest),
+		associate(testutil.AssociationResponse),
+		// data-block-number-invalid
+		testutil.Expect(testutil.ProfileBufferNextRequest, decodeHexString("C402C101000000010113")),
+		testutil.Expect(testutil.ProfileBufferRequest, testutil.ProfileBufferFirstBlock),
+		testutil.Expect(testutil.ProfileBufferNextRequest, testutil.ProfileBufferLastBlock),
+	)
+	store := client.NewMemoryBlockTransferStore()
+	c := client.New(transport, resumingSettings(store))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	data, err := c.Get(profileBuffer, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, profileBufferData, data)
+	assert.Equal(t, 0, store.Len())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_ResumeBlockTransferWithUnexpectedBlock(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ProfileBufferRequest, testutil.ProfileBufferFirstBlock),
+		testutil.Expect(testutil.ProfileBufferNextRequest),
+		associate(testutil.AssociationResponse),
+		// block 5 instead of block 2
+		testutil.Expect(testutil.ProfileBufferNextRequest, decodeHexString("C402C100000000050003120002")),
+		testutil.Expect(testutil.ProfileBufferRequest, testutil.ProfileBufferFirstBlock),
+		testutil.Expect(testutil.ProfileBufferNextRequest, testutil.ProfileBufferLastBlock),
+	)
+	store := client.NewMemoryBlockTransferStore()
+	c := client.New(transport, resumingSettings(store))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	data, err := c.Get(profileBuffer, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, profileBufferData, data)
+	assert.Equal(t, 0, store.Len())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_InterruptedBlockTransferIsKept(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ProfileBufferRequest, testutil.ProfileBufferFirstBlock),
+		testutil.Expect(testutil.ProfileBufferNextRequest),
+	)
+	store := client.NewMemoryBlockTransferStore()
+	settings := resumingSettings(store)
+	settings.RetryAfterReconnect = false
+	c := client.New(transport, settings)
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	_, err := c.Get(profileBuffer, nil)
+	assert.Error(t, err)
+	if assert.Equal(t, 1, store.Len()) {
+		progress, ok := store.Load("00070100630100ff0200")
+		assert.True(t, ok)
+		assert.Equal(t, uint32(1), progress.BlockNumber)
+		assert.Equal(t, testutil.ProfileBufferFirstBlock[10:], progress.Data)
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+// BroadcastSecuritySuite is the security suite of the broadcast APDUs
+const BroadcastSecuritySuite uint8 = 0
+
+// BroadcastSet writes an attribute of all the meters listening to a broadcast,
+// e.g. the time of the PLC meters of a segment. The SET request is
+// unconfirmed and ciphered with the broadcast encryption key in a
+// general-glo-ciphering APDU, the meters don't answer it. It is sent with
+// SendBroadcast when the transport supports it, with Send otherwise.
+//
+// The Keys, the SystemTitle and the InvocationCounter of the settings are
+// needed, no association is.
+func (c *Client) BroadcastSet(attribute *cosem.CosemAttribute, data []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	request := xdlms.NewSetRequestNormal(attribute, data, nil, &xdlms.InvokeIdAndPriority{
+		InvokeID:     c.invokeID.InvokeID,
+		Confirmed:    false,
+		HighPriority: c.invokeID.HighPriority,
+	})
+	plaintext, err := request.ToBytes()
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", request, err)
+	}
+
+	apdu, err := c.cipherBroadcast(plaintext)
+	if err != nil {
+		return err
+	}
+	encoded, err := apdu.ToBytes()
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", apdu, err)
+	}
+
+	if c.logger != nil {
+		c.logger.Printf("broadcasting %s in %s", request, apdu)
+	}
+
+	if broadcaster, ok := c.transport.(dlms.TransportWithBroadcast); ok {
+		err = broadcaster.SendBroadcast(encoded)
+	} else {
+		err = c.transport.Send(encoded)
+	}
+	if err != nil {
+		return exceptions.NewCommunicationError(fmt.Sprintf("failed to broadcast %s: %v", request, err))
+	}
+	c.invalidateBroadcast(attribute)
+	return nil
+}
+
+// cipherBroadcast ciphers an APDU with the broadcast encryption key
+func (c *Client) cipherBroadcast(plaintext []byte) (*xdlms.GeneralGlobalCipher, error) {
+	securityControl := security.NewSecurityControl(BroadcastSecuritySuite, true, true, true)
+	invocationCounter, ciphered, err := c.cipher("broadcast", securityControl, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return xdlms.NewGeneralGlobalCipher(c.settings.SystemTitle, byte(securityControl), invocationCounter, ciphered), nil
+}
+
+// cipher ciphers an APDU with the global key of the security control and the
+// next invocation counter, which is returned with the ciphered APDU
+func (c *Client) cipher(purpose string, securityControl security.SecurityControl, plaintext []byte) (uint32, []byte, error) {
+	if c.settings.Keys == nil || c.settings.InvocationCounter == nil || c.settings.SystemTitle == nil {
+		return 0, nil, exceptions.NewCipheringError(fmt.Sprintf("%s needs the keys, the system title and the invocation counter of the settings", purpose))
+	}
+	encryptionKey, err := c.settings.Keys.Key(securityControl.EncryptionKeyID())
+	if err != nil {
+		return 0, nil, exceptions.NewCipheringError(err.Error())
+	}
+	authenticationKey, err := c.settings.Keys.Key(security.KeyIDAuthentication)
+	if err != nil {
+		return 0, nil, exceptions.NewCipheringError(err.Error())
+	}
+	invocationCounter, err := c.settings.InvocationCounter.Next()
+	if err != nil {
+		return 0, nil, exceptions.NewCipheringError(err.Error())
+	}
+
+	ciphered, err := security.Encrypt(securityControl, c.settings.SystemTitle, invocationCounter, encryptionKey, authenticationKey, plaintext)
+	if err != nil {
+		return 0, nil, exceptions.NewCipheringError(err.Error())
+	}
+	return invocationCounter, ciphered, nil
+}
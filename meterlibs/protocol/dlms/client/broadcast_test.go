@@ -0,0 +1,63 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_BroadcastSet(t *testing.T) {
+	keys := &security.Keys{
+		GlobalBroadcastEncryptionKey: decodeHexString("000102030405060708090A0B0C0D0E0F"),
+		AuthenticationKey:            decodeHexString("D0D1D2D3D4D5D6D7D8D9DADBDCDDDEDF"),
+	}
+	systemTitle := decodeHexString("4D4D4D0000BC614E")
+	var received *xdlms.GeneralGlobalCipher
+	transport := testutil.NewScriptedTransport(
+		testutil.ExpectTag(xdlms.GeneralGlobalCipherTag, func(request []byte) ([][]byte, error) {
+			var err error
+			received, err = (&xdlms.GeneralGlobalCipher{}).FromBytes(request)
+			return nil, err
+		}),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.Keys = keys
+	settings.SystemTitle = systemTitle
+	settings.InvocationCounter = security.NewInvocationCounter(7)
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+
+	clock := cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, mustObis("0.0.1.0.0.255"), 2)
+	value := decodeHexString("090C07EA0A11FF0C000000800000")
+	require.NoError(t, c.BroadcastSet(clock, value))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+
+	require.NotNil(t, received)
+	assert.Equal(t, systemTitle, received.SystemTitle)
+	assert.Equal(t, byte(0x70), received.SecurityControl)
+	assert.Equal(t, uint32(7), received.InvocationCounter)
+	assert.Equal(t, uint32(8), settings.InvocationCounter.Peek())
+
+	plaintext, err := security.Decrypt(security.SecurityControl(received.SecurityControl), systemTitle, received.InvocationCounter,
+		keys.GlobalBroadcastEncryptionKey, keys.AuthenticationKey, received.CipheredText)
+	require.NoError(t, err)
+	// unconfirmed SET, the service class bit of the invoke id is not set
+	assert.Equal(t, decodeHexString("C1018100080000010000FF0200"+"090C07EA0A11FF0C000000800000"), plaintext)
+}
+
+func TestClient_BroadcastSetWithoutKeys(t *testing.T) {
+	transport := testutil.NewScriptedTransport()
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+
+	err := c.BroadcastSet(cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, mustObis("0.0.1.0.0.255"), 2), nil)
+	assert.Error(t, err)
+}
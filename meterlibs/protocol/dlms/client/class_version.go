@@ -0,0 +1,48 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attribute resolves a named attribute of an object, e.g. "buffer" of a
+// profile generic, from the version of its class in the object list read with
+// GetObjectList or given with SetObjectList
+func (c *Client) Attribute(logicalName *cosem.Obis, name string) (*cosem.CosemAttribute, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.attribute(logicalName, name)
+}
+
+func (c *Client) attribute(logicalName *cosem.Obis, name string) (*cosem.CosemAttribute, error) {
+	if c.objectList == nil {
+		return nil, fmt.Errorf("failed to resolve %s of %s: the object list of the association is unknown", name, logicalName)
+	}
+	item, ok := c.objectList[*logicalName]
+	if !ok {
+		return nil, fmt.Errorf("failed to resolve %s of %s: it is not in the object list of this association", name, logicalName)
+	}
+	return c.classVersions().Attribute(item, name)
+}
+
+// resolveAttribute resolves a named attribute from the object list when it
+// is known, and uses the index of the current version of the class otherwise
+func (c *Client) resolveAttribute(interfaceClass enumerations.CosemInterface, logicalName *cosem.Obis, name string, index uint8) (*cosem.CosemAttribute, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.objectList == nil {
+		return cosem.NewCosemAttribute(interfaceClass, logicalName, index), nil
+	}
+	return c.attribute(logicalName, name)
+}
+
+func (c *Client) classVersions() *cosem.ClassVersionRegistry {
+	if c.settings.ClassVersions == nil {
+		return cosem.NewClassVersionRegistry()
+	}
+	return c.settings.ClassVersions
+}
@@ -0,0 +1,77 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// versionedObjectList has the profile generic 1.0.99.1.0.255 version 1 and
+// the Association LN 0.0.40.0.0.255 version 2, without access rights
+const versionedObjectList = "0102" +
+	"0204" + "120007" + "1101" + "09060100630100FF" + "0202" + "0100" + "0100" +
+	"0204" + "12000F" + "1102" + "09060000280000FF" + "0202" + "0100" + "0100"
+
+func TestClient_Attribute(t *testing.T) {
+	c := client.New(testutil.NewScriptedTransport(), client.NewSettings(16, 1))
+	profile := mustObis("1.0.99.1.0.255")
+
+	_, err := c.Attribute(profile, "buffer")
+	assert.Error(t, err, "the object list is unknown")
+
+	objectList, err := cosem.ParseObjectList(decodeHexString(versionedObjectList), 3)
+	require.NoError(t, err)
+	c.SetObjectList(objectList)
+
+	attribute, err := c.Attribute(profile, "capture_objects")
+	require.NoError(t, err)
+	assert.Equal(t, cosem.NewCosemAttribute(enumerations.CosemInterfaceProfileGeneric, profile, 3), attribute)
+
+	// user_list only exists from version 2 of the Association LN
+	attribute, err = c.Attribute(client.CurrentAssociation, "user_list")
+	require.NoError(t, err)
+	assert.Equal(t, uint8(10), attribute.Attribute)
+
+	_, err = c.Attribute(profile, "user_list")
+	assert.Error(t, err)
+	_, err = c.Attribute(mustObis("1.0.99.2.0.255"), "buffer")
+	assert.Error(t, err, "not in the object list")
+}
+
+func TestClient_AttributeOfRegisteredVersion(t *testing.T) {
+	settings := client.NewSettings(16, 1)
+	// a vendor numbering the buffer of its profiles differently
+	settings.ClassVersions.Register(enumerations.CosemInterfaceProfileGeneric, 1, cosem.ClassAttributes{"buffer": 9})
+	c := client.New(testutil.NewScriptedTransport(), settings)
+
+	objectList, err := cosem.ParseObjectList(decodeHexString(versionedObjectList), 3)
+	require.NoError(t, err)
+	c.SetObjectList(objectList)
+
+	attribute, err := c.Attribute(mustObis("1.0.99.1.0.255"), "buffer")
+	require.NoError(t, err)
+	assert.Equal(t, uint8(9), attribute.Attribute)
+}
+
+func TestClient_ReadProfileOutsideObjectList(t *testing.T) {
+	transport := testutil.NewScriptedTransport(associate(testutil.AssociationResponse))
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	objectList, err := cosem.ParseObjectList(decodeHexString(versionedObjectList), 3)
+	require.NoError(t, err)
+	c.SetObjectList(objectList)
+
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	cursor := client.NewProfileCursor(client.NewMemoryProfileCursorStore(), "LGZ12345678", mustObis("1.0.99.2.0.255"), start)
+	_, err = c.ReadProfileRows(cursor, start.Add(time.Hour))
+	assert.Error(t, err)
+	assert.NoError(t, transport.Err(), "no request is sent")
+}
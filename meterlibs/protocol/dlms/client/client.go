@@ -0,0 +1,592 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/resilience"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+// Settings holds the parameters of a client
+type Settings struct {
+	ClientAddress  int
+	ServerAddress  int
+	Authentication enumerations.AuthenticationMechanism
+	Password       []byte
+	Conformance    *xdlms.Conformance
+	MaxPduSize     uint16
+	Timeout        time.Duration
+	// RetryAfterReconnect reconnects after a timeout and sends the GET
+	// requests once more, on a new association. SET and ACTION requests are
+	// not sent again, as the meter may have executed them, e.g. a credit
+	// update would be applied twice: their error is returned.
+	RetryAfterReconnect bool
+	// BlockTransferStore keeps the progress of GET block transfers, to resume
+	// them after the link dropped. Nil restarts interrupted transfers.
+	BlockTransferStore BlockTransferStore
+	// Keys gives the keys of the security context, from static configuration
+	// or a HSM. Nil when the association does not use keys.
+	Keys security.KeyProvider
+	// ClassVersions gives the attribute numbering of the interface class
+	// versions, to resolve named attributes from the class versions in the
+	// object list read with GetObjectList or given with SetObjectList
+	ClassVersions *cosem.ClassVersionRegistry
+	// EnumResolver names the enum values returned by GetValue and
+	// GetAllAttributes, nil leaves them as numbers
+	EnumResolver *cosem.EnumResolver
+	// SystemTitle is the system title of the client, recorded in the audit
+	// events
+	SystemTitle []byte
+	// AuditLog records the association attempts, authentication failures,
+	// key transfers and decryption failures, nil disables the audit
+	AuditLog AuditLog
+	// RateLimiter paces the requests sent to the meter, nil sends them as
+	// soon as possible. Clients of the same meter may share it.
+	RateLimiter *resilience.RateLimiter
+	// Policy retries the GET requests failing with a transient error with
+	// backoff, resetting the link if it was left half-open, and stops
+	// sending requests to a meter failing consistently. The circuit breaker
+	// is the one of MeterID, or of the server address without it. SET and
+	// ACTION requests go through the circuit breaker but are not retried.
+	// Nil disables it.
+	Policy *resilience.Policy
+	// InvocationCounter gives the invocation counters of the APDUs the client
+	// ciphers, nil when the client doesn't cipher APDUs
+	InvocationCounter *security.InvocationCounter
+	// RecoverInvocationCounter advances the InvocationCounter to the value
+	// expected by the meter after an invocation-counter-error exception and
+	// retries the request once
+	RecoverInvocationCounter bool
+	// ParsingMode tells whether the known deviations of the meters in the
+	// APDUs are tolerated, they are then recorded in Deviations
+	ParsingMode xdlms.ParsingMode
+	// UseRlrqRlre releases the association with a RLRQ, many meters don't
+	// support it and Release then just disconnects the transport
+	UseRlrqRlre bool
+	// ReleaseWithUserInformation sends the InitiateRequest of the AARQ in the
+	// RLRQ, ciphered with the global unicast key when Keys is set. Some meters
+	// need it to release a ciphered association.
+	ReleaseWithUserInformation bool
+	// CheckAccessRights fails SET and ACTION requests locally when the object
+	// list read with GetObjectList, or given with SetObjectList, doesn't give
+	// access to the attribute or method
+	CheckAccessRights bool
+	// GeneralBlockTransferWindow is the number of blocks of a general block
+	// transfer the client accepts before acknowledging them
+	GeneralBlockTransferWindow uint8
+	// ResponseCache keeps the data read with GET, nil reads every attribute
+	// from the meter
+	ResponseCache *ResponseCache
+	// MeterID identifies the meter in the ResponseCache, the clients sharing
+	// a cache need different ids. The cache is not used without it.
+	MeterID string
+}
+
+// NewSettings creates new Settings for an association without authentication
+func NewSettings(clientAddress int, serverAddress int) *Settings {
+	return &Settings{
+		ClientAddress:  clientAddress,
+		ServerAddress:  serverAddress,
+		Authentication: enumerations.AuthenticationMechanismNone,
+		Password:       nil,
+		Conformance: xdlms.NewConformance(
+			false, false, false, false, true, false, true, true, true,
+			true, false, false, true, true, true, false, true,
+		),
+		MaxPduSize:                 65535,
+		Timeout:                    10 * time.Second,
+		RetryAfterReconnect:        false,
+		UseRlrqRlre:                true,
+		ParsingMode:                xdlms.DefaultParsingMode,
+		GeneralBlockTransferWindow: 1,
+		EnumResolver:               cosem.NewDefaultEnumResolver(),
+		ClassVersions:              cosem.NewClassVersionRegistry(),
+	}
+}
+
+// Client talks to a DLMS server (meter) over a transport, keeping track of the
+// connection state
+type Client struct {
+	transport     dlms.Transport
+	settings      *Settings
+	state         *dlms.DlmsConnectionState
+	factory       *xdlms.XDlmsApduFactory
+	dc            dlms.DataChannel
+	dispatcher    *Dispatcher
+	notifications chan *DecodedNotification
+	subscribe     sync.Once
+	invokeID      *xdlms.InvokeIdAndPriority
+	associated    bool
+	negotiated    *xdlms.InitiateResponse
+	logger        *log.Logger
+	mutex         sync.Mutex
+
+	// blocks puts together the APDUs received with general block transfer
+	blocks *xdlms.GeneralBlockTransferReassembler
+
+	// async queues the requests of GetAsync, SetAsync and ActionAsync
+	async      chan *asyncRequest
+	startAsync sync.Once
+	closed     chan struct{}
+	closeOnce  sync.Once
+
+	// serverSystemTitle is the responding AP title of the last AARE
+	serverSystemTitle []byte
+
+	// objectList are the items of the object list of the association by
+	// logical name, nil until an object list is read
+	objectList map[cosem.Obis]*cosem.AssociationObjectListItem
+}
+
+// New creates a new Client
+func New(transport dlms.Transport, settings *Settings) *Client {
+	c := &Client{
+		transport:  transport,
+		settings:   settings,
+		state:      dlms.NewDlmsConnectionState(),
+		factory:    acse.NewApduFactory(),
+		dc:         make(dlms.DataChannel, 10),
+		dispatcher: NewDispatcher(),
+		invokeID: &xdlms.InvokeIdAndPriority{
+			InvokeID:     1,
+			Confirmed:    true,
+			HighPriority: true,
+		},
+		logger: nil,
+		blocks: xdlms.NewGeneralBlockTransferReassembler(settings.GeneralBlockTransferWindow),
+		closed: make(chan struct{}),
+	}
+
+	c.factory.Mode = settings.ParsingMode
+	transport.SetAddress(settings.ClientAddress, settings.ServerAddress)
+	transport.SetReception(c.dc)
+
+	return c
+}
+
+// Deviations returns the non-conformances of the received APDUs tolerated
+// in lenient parsing mode
+func (c *Client) Deviations() []*xdlms.Deviation {
+	return c.factory.Deviations()
+}
+
+// RegisterApduParser registers the parser of the manufacturer specific APDUs
+// with the given tag that the meter sends. The tags of the standard APDUs
+// are rejected.
+func (c *Client) RegisterApduParser(tag uint8, parser xdlms.ApduParser) error {
+	return c.factory.Register(tag, parser)
+}
+
+// State returns the connection state
+func (c *Client) State() *dlms.DlmsConnectionState {
+	return c.state
+}
+
+// NegotiatedConformance returns the conformance agreed in the association,
+// nil if the client is not associated
+func (c *Client) NegotiatedConformance() *xdlms.Conformance {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.negotiatedConformance()
+}
+
+// SetLogger sets the logger of the client and the transport
+func (c *Client) SetLogger(logger *log.Logger) {
+	c.logger = logger
+	c.transport.SetLogger(logger)
+}
+
+// Connect connects the transport
+func (c *Client) Connect() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.transport.Connect()
+}
+
+// Disconnect disconnects the transport, the association is lost
+func (c *Client) Disconnect() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.resetState()
+	return c.transport.Disconnect()
+}
+
+// Close closes the transport, the asynchronous requests not sent yet fail
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.resetState()
+	c.transport.Close()
+}
+
+// Associate sets up an application association with the server
+func (c *Client) Associate() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.associate()
+}
+
+// Release releases the application association. Without RLRQ/RLRE the
+// transport is disconnected instead, which ends the association.
+func (c *Client) Release() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.settings.UseRlrqRlre {
+		c.resetState()
+		return c.transport.Disconnect()
+	}
+
+	rlrq, err := c.releaseRequest()
+	if err != nil {
+		return err
+	}
+	response, err := c.request(rlrq)
+	c.associated = false
+	if err != nil {
+		return err
+	}
+
+	rlre, ok := response.(*acse.ReleaseResponse)
+	if !ok {
+		return exceptions.NewDlmsClientException(fmt.Sprintf("release failed: %s", response))
+	}
+	if _, err = c.releaseInitiateResponse(rlre); err != nil {
+		c.auditDecryption(err)
+		return fmt.Errorf("failed to parse the user information of %s: %w", rlre, err)
+	}
+	return nil
+}
+
+// Get reads an attribute, following block transfers, and returns the A-XDR encoded data
+func (c *Client) Get(attribute *cosem.CosemAttribute, accessSelection interface{}) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.retry(true, func() ([]byte, error) {
+		return c.get(attribute, accessSelection)
+	})
+}
+
+// GetValue reads an attribute and decodes its value, enums are named by the
+// EnumResolver of the settings
+func (c *Client) GetValue(attribute *cosem.CosemAttribute, accessSelection interface{}) (interface{}, error) {
+	data, err := c.Get(attribute, accessSelection)
+	if err != nil {
+		return nil, err
+	}
+	value, err := encoding.DecodeValue(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", attribute.Instance, err)
+	}
+	return c.resolveEnums(attribute, value), nil
+}
+
+func (c *Client) resolveEnums(attribute *cosem.CosemAttribute, value interface{}) interface{} {
+	if c.settings.EnumResolver == nil {
+		return value
+	}
+	return c.settings.EnumResolver.Resolve(attribute, value)
+}
+
+// Set writes the A-XDR encoded data to an attribute
+func (c *Client) Set(attribute *cosem.CosemAttribute, data []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, err := c.retry(false, func() ([]byte, error) {
+		return nil, c.set(attribute, data)
+	})
+	return err
+}
+
+// Action invokes a method and returns the A-XDR encoded return data, if any.
+// Return data sent in several blocks is reassembled.
+func (c *Client) Action(method *cosem.CosemMethod, data []byte) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.retry(false, func() ([]byte, error) {
+		return c.action(method, data)
+	})
+}
+
+func (c *Client) associate() error {
+	userInformation := acse.NewUserInformation(c.initiateRequest())
+
+	var aarq *acse.ApplicationAssociationRequest
+	switch c.settings.Authentication {
+	case enumerations.AuthenticationMechanismNone:
+		aarq = acse.NewApplicationAssociationRequest(userInformation, nil, nil, nil, false, nil, nil)
+	case enumerations.AuthenticationMechanismLLS:
+		aarq = acse.NewLlsApplicationAssociationRequest(string(c.settings.Password), userInformation)
+	default:
+		return exceptions.NewApplicationAssociationError(
+			fmt.Sprintf("authentication mechanism %s is not supported by the client", c.settings.Authentication))
+	}
+
+	response, err := c.request(aarq)
+	if err != nil {
+		c.auditAssociation(nil, err)
+		return err
+	}
+
+	aare, ok := response.(*acse.ApplicationAssociationResponse)
+	if !ok {
+		err = exceptions.NewApplicationAssociationError(fmt.Sprintf("association failed: %s", response))
+		c.auditAssociation(nil, err)
+		return err
+	}
+	c.serverSystemTitle = aare.SystemTitle
+	if aare.Result != enumerations.AssociationResultAccepted {
+		c.resetState()
+		err = exceptions.NewApplicationAssociationError(fmt.Sprintf("association rejected: %s", aare))
+		c.auditAssociation(aare, err)
+		return err
+	}
+	c.auditAssociation(aare, nil)
+
+	if aare.UserInformation != nil {
+		if initiateResponse, ok := aare.UserInformation.Content.(*xdlms.InitiateResponse); ok {
+			c.negotiated = initiateResponse
+		}
+	}
+
+	c.associated = true
+	return nil
+}
+
+func (c *Client) get(attribute *cosem.CosemAttribute, accessSelection interface{}) (result []byte, err error) {
+	request := xdlms.NewGetRequestNormal(attribute, c.invokeID, accessSelection)
+	fingerprint := ""
+	cache := c.responseCache()
+	if c.settings.BlockTransferStore != nil || cache != nil {
+		if fingerprint, err = RequestFingerprint(request); err != nil {
+			return nil, err
+		}
+	}
+	if cache != nil {
+		if data, ok := cache.Load(c.settings.MeterID, fingerprint); ok {
+			return data, nil
+		}
+		defer func() {
+			if err == nil {
+				cache.Save(c.settings.MeterID, attribute.Interface, fingerprint, result)
+			}
+		}()
+	}
+	if c.settings.BlockTransferStore != nil {
+		defer func() { c.endTransfer(fingerprint, err) }()
+	}
+
+	response, data, err := c.startGet(request, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch r := response.(type) {
+		case *xdlms.GetResponseNormal:
+			return r.Data, nil
+		case *xdlms.GetResponseNormalWithError:
+			return nil, exceptions.NewDlmsClientException(fmt.Sprintf("get failed: %s", r))
+		case *xdlms.GetResponseLastBlock:
+			return append(data, r.RawData...), nil
+		case *xdlms.GetResponseLastBlockWithError:
+			return nil, exceptions.NewDlmsClientException(fmt.Sprintf("get failed: %s", r))
+		case *xdlms.GetResponseWithDataBlock:
+			data = append(data, r.RawData...)
+			c.saveProgress(fingerprint, r.BlockNumber, data)
+			response, err = c.request(xdlms.NewGetRequestNext(r.BlockNumber, c.invokeID))
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, exceptions.NewDlmsClientException(fmt.Sprintf("get failed: %s", response))
+		}
+	}
+}
+
+func (c *Client) set(attribute *cosem.CosemAttribute, data []byte) error {
+	if err := c.checkWriteAccess(attribute); err != nil {
+		return err
+	}
+	response, err := c.request(xdlms.NewSetRequestNormal(attribute, data, nil, c.invokeID))
+	c.invalidateAttribute(attribute)
+	if err != nil {
+		return err
+	}
+
+	r, ok := response.(*xdlms.SetResponseNormal)
+	if !ok {
+		return exceptions.NewDlmsClientException(fmt.Sprintf("set failed: %s", response))
+	}
+	if r.Result != enumerations.DataAccessSuccess {
+		return exceptions.NewDlmsClientException(fmt.Sprintf("set failed: %s", r))
+	}
+	return nil
+}
+
+func (c *Client) action(method *cosem.CosemMethod, data []byte) ([]byte, error) {
+	if err := c.checkMethodAccess(method); err != nil {
+		return nil, err
+	}
+	response, err := c.request(xdlms.NewActionRequestNormal(method, data, c.invokeID))
+	c.invalidateMeter()
+	if err != nil {
+		return nil, err
+	}
+
+	var returned []byte
+	for {
+		switch r := response.(type) {
+		case *xdlms.ActionResponseNormal:
+			if r.Status != enumerations.ActionResultStatusSuccess {
+				return nil, exceptions.NewDlmsClientException(fmt.Sprintf("action failed: %s", r))
+			}
+			return nil, nil
+		case *xdlms.ActionResponseNormalWithData:
+			if r.Status != enumerations.ActionResultStatusSuccess {
+				return nil, exceptions.NewDlmsClientException(fmt.Sprintf("action failed: %s", r))
+			}
+			return r.Data, nil
+		case *xdlms.ActionResponseLastPBlock:
+			return append(returned, r.RawData...), nil
+		case *xdlms.ActionResponseWithPBlock:
+			returned = append(returned, r.RawData...)
+			response, err = c.request(xdlms.NewActionRequestNextPBlock(r.BlockNumber, c.invokeID))
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, exceptions.NewDlmsClientException(fmt.Sprintf("action failed: %s", response))
+		}
+	}
+}
+
+// request sends an APDU and waits for the response, updating the connection
+// state. Notifications received meanwhile are routed to the subscriber and
+// an ExceptionResponse or ConfirmedServiceError is returned as ServiceError.
+func (c *Client) request(apdu xdlms.Apdu) (xdlms.Apdu, error) {
+	kind, err := RequestKindOf(apdu)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := apdu.(*acse.ReleaseRequest); ok && !c.settings.UseRlrqRlre {
+		return nil, exceptions.NewNoRlrqRlreError("the connection doesn't use RLRQ/RLRE")
+	}
+
+	if err = c.state.ProcessApdu(apdu); err != nil {
+		return nil, err
+	}
+
+	data, err := apdu.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", apdu, err)
+	}
+
+	if c.settings.RateLimiter != nil {
+		if err = c.settings.RateLimiter.Wait(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.logger != nil {
+		c.logger.Printf("sending %s", apdu)
+	}
+
+	if err = c.transport.Send(data); err != nil {
+		return nil, exceptions.NewCommunicationError(fmt.Sprintf("failed to send %s: %v", apdu, err))
+	}
+
+	timeout := time.After(c.settings.Timeout)
+	for {
+		select {
+		case received, ok := <-c.dc:
+			if !ok {
+				return nil, exceptions.NewCommunicationError("reception channel closed")
+			}
+
+			response, err := c.receive(received)
+			if err != nil {
+				c.auditDecryption(err)
+				return nil, fmt.Errorf("failed to parse response %x: %w", acse.RedactAuthenticationValue(received), err)
+			}
+			if response == nil {
+				continue
+			}
+
+			if c.logger != nil {
+				c.logger.Printf("received %s", response)
+			}
+
+			class := c.dispatcher.Classify(kind, response)
+			switch class {
+			case ResponseNotification:
+				if !c.dispatcher.Route(response) && c.logger != nil {
+					c.logger.Printf("dropped %s, no subscriber is ready", response)
+				}
+				continue
+			case ResponseUnexpected:
+				return nil, exceptions.NewLocalDlmsProtocolError(
+					fmt.Sprintf("received %s while waiting for the %s response", response, kind))
+			}
+
+			if err = c.state.ProcessApdu(response); err != nil {
+				return nil, err
+			}
+			if class == ResponseError {
+				return nil, &ServiceError{Request: kind, Response: response}
+			}
+			return response, nil
+		case <-timeout:
+			return nil, exceptions.NewCommunicationError(
+				fmt.Sprintf("timeout waiting for response to %s when state=%s", apdu, c.state.CurrentState()))
+		}
+	}
+}
+
+func (c *Client) negotiatedConformance() *xdlms.Conformance {
+	if !c.associated || c.negotiated == nil {
+		return nil
+	}
+	return c.negotiated.NegotiatedConformance
+}
+
+// requireConformance fails with a *xdlms.ConformanceError when the service
+// is not in the negotiated conformance
+func (c *Client) requireConformance(service string) error {
+	conformance := c.negotiatedConformance()
+	if conformance == nil || !conformance.Has(service) {
+		return xdlms.NewConformanceError(service, conformance)
+	}
+	return nil
+}
+
+func (c *Client) resetState() {
+	c.associated = false
+	c.negotiated = nil
+	c.blocks.Reset()
+	c.state.Reset()
+}
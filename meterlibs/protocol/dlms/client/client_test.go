@@ -0,0 +1,357 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"log"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/resilience"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/tcp"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+var (
+	clockTime     = cosem.NewCosemAttribute(enumerations.CosemInterface(8), mustObis("0.0.1.0.0.255"), 2)
+	profileBuffer = cosem.NewCosemAttribute(enumerations.CosemInterface(7), mustObis("1.0.99.1.0.255"), 2)
+)
+
+func decodeHexString(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+func mustObis(s string) *cosem.Obis {
+	obis, err := cosem.FromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return obis
+}
+
+func associate(responses ...[]byte) testutil.Step {
+	return testutil.ExpectTag(0x60, func([]byte) ([][]byte, error) {
+		return responses, nil
+	})
+}
+
+func TestClient_GetWithBlockTransfer(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ProfileBufferRequest, testutil.ProfileBufferFirstBlock),
+		testutil.Expect(testutil.ProfileBufferNextRequest, testutil.ProfileBufferLastBlock),
+		testutil.Expect(testutil.ReleaseRequest, testutil.ReleaseResponse),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+	assert.Equal(t, dlms.Ready, c.State().CurrentState())
+
+	data, err := c.Get(profileBuffer, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02, 0x12, 0x00, 0x01, 0x12, 0x00, 0x02}, data)
+	assert.Equal(t, dlms.Ready, c.State().CurrentState())
+
+	assert.NoError(t, c.Release())
+	assert.Equal(t, dlms.NoAssociation, c.State().CurrentState())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_ReleaseWithoutRlrqRlre(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.UseRlrqRlre = false
+	c := client.New(transport, settings)
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	assert.NoError(t, c.Release())
+	assert.Equal(t, dlms.NoAssociation, c.State().CurrentState())
+	assert.False(t, transport.IsConnected())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_ReconnectAfterTimeout(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest),
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.Timeout = 20 * time.Millisecond
+	settings.RetryAfterReconnect = true
+	c := client.New(transport, settings)
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	data, err := c.Get(clockTime, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testutil.ClockTimeResponse[4:], data)
+	assert.False(t, c.NeedsReconnect())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_SetIsNotReplayedAfterReconnect(t *testing.T) {
+	clockData := testutil.ClockTimeResponse[4:]
+	setClock := append(decodeHexString("C101C100080000010000FF0200"), clockData...)
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(setClock),
+		associate(testutil.AssociationResponse),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.Timeout = 20 * time.Millisecond
+	settings.RetryAfterReconnect = true
+	c := client.New(transport, settings)
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	assert.Error(t, c.Set(clockTime, clockData))
+	assert.False(t, c.NeedsReconnect())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_NeedsReconnect(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest),
+		associate(testutil.AssociationResponse),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.Timeout = 20 * time.Millisecond
+	c := client.New(transport, settings)
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	_, err := c.Get(clockTime, nil)
+	assert.Error(t, err)
+	assert.True(t, c.NeedsReconnect())
+	assert.Equal(t, dlms.AwaitingGetResponse, c.State().CurrentState())
+
+	assert.NoError(t, c.Reconnect())
+	assert.False(t, c.NeedsReconnect())
+	assert.Equal(t, dlms.Ready, c.State().CurrentState())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_ActionWithPBlock(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C301C1000F0000280000FF0100"), decodeHexString("C702C1000000000103010212")),
+		testutil.Expect(decodeHexString("C302C100000001"), decodeHexString("C702C10100000002020001")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	method := cosem.NewCosemMethod(enumerations.CosemInterface(15), mustObis("0.0.40.0.0.255"), 1)
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	data, err := c.Action(method, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02, 0x12, 0x00, 0x01}, data)
+	assert.Equal(t, dlms.Ready, c.State().CurrentState())
+	assert.NoError(t, transport.Err())
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ProfileBufferRequest, testutil.ProfileBufferFirstBlock),
+		testutil.Expect(testutil.ProfileBufferNextRequest, testutil.ProfileBufferLastBlock),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.RateLimiter = resilience.NewRateLimiter(20*time.Millisecond, 1)
+	c := client.New(transport, settings)
+
+	start := time.Now()
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+	_, err := c.Get(profileBuffer, nil)
+	assert.NoError(t, err)
+
+	// The GET and the GET next wait an interval each after the AARQ
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_PolicyRetriesGet(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest),
+		// the link is reset before the retry
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.Timeout = 20 * time.Millisecond
+	settings.Policy = resilience.NewPolicy(3, time.Hour)
+	settings.Policy.Backoff.Initial = time.Millisecond
+	settings.Policy.Backoff.Jitter = 0
+	c := client.New(transport, settings)
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	data, err := c.Get(clockTime, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testutil.ClockTimeResponse[4:], data)
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+	assert.Equal(t, resilience.CircuitClosed, settings.Policy.Breaker("1").State())
+}
+
+func TestClient_PolicyOpensCircuit(t *testing.T) {
+	clockData := testutil.ClockTimeResponse[4:]
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(append(decodeHexString("C101C100080000010000FF0200"), clockData...)),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.Timeout = 20 * time.Millisecond
+	settings.MeterID = "meter"
+	settings.Policy = resilience.NewPolicy(1, time.Hour)
+	c := client.New(transport, settings)
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	// the SET is not sent again
+	assert.Error(t, c.Set(clockTime, clockData))
+	assert.Equal(t, resilience.CircuitOpen, settings.Policy.Breaker("meter").State())
+
+	_, err := c.Get(clockTime, nil)
+	assert.ErrorIs(t, err, resilience.ErrCircuitOpen)
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_PasswordIsNotLogged(t *testing.T) {
+	aare := decodeHexString("6136A109060760857405080101A203020100A305A10302010088020780890760857405080201" +
+		"BE10040E0800065F1F0400001E1D04C80007")
+	// the AARE is cut in the user information
+	truncated := decodeHexString("6133A109060760857405080101A203020100A305A103020100AA0A80083132333435363738" + "BE10")
+	settings := client.NewSettings(16, 1)
+	settings.Authentication = enumerations.AuthenticationMechanismLLS
+	settings.Password = []byte("12345678")
+	var logged bytes.Buffer
+
+	transport := testutil.NewScriptedTransport(associate(truncated))
+	c := client.New(transport, settings)
+	c.SetLogger(log.New(&logged, "", 0))
+	assert.NoError(t, c.Connect())
+	err := c.Associate()
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "3132333435363738")
+
+	transport = testutil.NewScriptedTransport(associate(aare))
+	c = client.New(transport, settings)
+	c.SetLogger(log.New(&logged, "", 0))
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	assert.NotEmpty(t, logged.String())
+	assert.NotContains(t, logged.String(), "12345678")
+	assert.NotContains(t, logged.String(), "3132333435363738")
+	assert.NoError(t, transport.Err())
+}
+
+// lockedBuffer collects the log of the transports, written from their
+// reception goroutines
+type lockedBuffer struct {
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+// serveHdlc answers SNRM and DISC with UA and the AARQ with the AARE, one
+// frame at a time, like a meter behind a TCP gateway
+func serveHdlc(t *testing.T, listener net.Listener, aare []byte) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+	server, err := hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+	information, err := hdlc.NewInformationFrame(client, server, aare, 0, 1, false, true)
+	require.NoError(t, err)
+	information.LlcHeader = []byte(hdlc.LLCResponseHeader)
+	ua := hdlc.NewUnNumberedAcknowledgmentFrame(client, server, nil).ToBytes()
+
+	var received []byte
+	buffer := make([]byte, 256)
+	for _, reply := range [][]byte{ua, information.ToBytes(), ua} {
+		// a frame is complete with its closing flag
+		for len(received) < 2 || received[len(received)-1] != 0x7E {
+			n, err := conn.Read(buffer)
+			if err != nil {
+				return
+			}
+			received = append(received, buffer[:n]...)
+		}
+		received = nil
+
+		if _, err := conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+func TestClient_PasswordIsNotLoggedOverHdlc(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go serveHdlc(t, listener, decodeHexString("6136A109060760857405080101A203020100A305A10302010088020780890760857405080201"+
+		"BE10040E0800065F1F0400001E1D04C80007"))
+
+	settings := client.NewSettings(16, 1)
+	settings.Authentication = enumerations.AuthenticationMechanismLLS
+	settings.Password = []byte("12345678")
+	port := listener.Addr().(*net.TCPAddr).Port
+	c := client.New(hdlc.New(tcp.New(port, "127.0.0.1", time.Second), 16, 1), settings)
+	var logged lockedBuffer
+	c.SetLogger(log.New(&logged, "", 0))
+
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+	require.NoError(t, c.Disconnect())
+
+	assert.Contains(t, logged.String(), "TX APDU: 60")
+	assert.NotContains(t, logged.String(), "12345678")
+	assert.NotContains(t, logged.String(), "3132333435363738")
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// TimeShiftLimit is the logical name of the register holding the largest
+// clock adjustment that doesn't raise a time shift event
+var TimeShiftLimit = &cosem.Obis{A: 1, B: 0, C: 0, D: 9, E: 11, F: 255}
+
+// GetClock reads the time zone, the status, the daylight savings settings and
+// the clock base of a clock. The time is parsed with cosem.ParseClockTime.
+func (c *Client) GetClock(logicalName *cosem.Obis) (*cosem.Clock, error) {
+	clock := cosem.NewClock(logicalName)
+	err := c.getAttributes(clock,
+		cosem.ClockAttributeTimeZone,
+		cosem.ClockAttributeStatus,
+		cosem.ClockAttributeDaylightSavingsBegin,
+		cosem.ClockAttributeDaylightSavingsEnd,
+		cosem.ClockAttributeDaylightSavingsDeviation,
+		cosem.ClockAttributeDaylightSavingsEnabled,
+		cosem.ClockAttributeClockBase,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return clock, nil
+}
+
+// SetDaylightSavings writes the time zone and the daylight savings settings
+// of a clock, e.g. computed with cosem.EuropeanDaylightSavings
+func (c *Client) SetDaylightSavings(logicalName *cosem.Obis, settings *cosem.DaylightSavings) error {
+	clock := cosem.NewClock(logicalName)
+	clock.SetDaylightSavings(settings)
+	return c.setAttributes(clock,
+		cosem.ClockAttributeTimeZone,
+		cosem.ClockAttributeDaylightSavingsBegin,
+		cosem.ClockAttributeDaylightSavingsEnd,
+		cosem.ClockAttributeDaylightSavingsDeviation,
+		cosem.ClockAttributeDaylightSavingsEnabled,
+	)
+}
+
+// GetTimeShiftLimit reads the time shift limit register
+func (c *Client) GetTimeShiftLimit() (time.Duration, error) {
+	seconds, _, err := c.GetScaledValue(enumerations.CosemInterfaceRegister, TimeShiftLimit)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// ClockDriftOptions tells how MeasureClockDrift reads the clock
+type ClockDriftOptions struct {
+	// Reads is the number of clock reads, at least 2 to estimate a drift rate
+	Reads int
+	// Interval is the time waited between two reads
+	Interval time.Duration
+	// Now returns the reference time, time.Now when nil
+	Now func() time.Time
+}
+
+func (o *ClockDriftOptions) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+	return o.Now()
+}
+
+// ClockSample is one read of the clock
+type ClockSample struct {
+	// Time is the reference time in the middle of the request and the
+	// response, when the meter is assumed to have read its clock
+	Time time.Time
+	// MeterTime is the time read from the meter
+	MeterTime time.Time
+	// RoundTrip is the time between the request and the response
+	RoundTrip time.Duration
+}
+
+// Offset is the meter time minus the reference time of the sample
+func (s *ClockSample) Offset() time.Duration {
+	return s.MeterTime.Sub(s.Time)
+}
+
+// ClockDriftMeasurement is the outcome of MeasureClockDrift
+type ClockDriftMeasurement struct {
+	Samples []*ClockSample
+	// Offset is the offset of the sample with the shortest round trip, the
+	// latest one when several have the same. Meters give the time to the
+	// second or the hundredth of second, which bounds the accuracy.
+	Offset time.Duration
+	// DriftRate is the time the meter clock gains per second of reference
+	// time, by least squares over the samples. Multiply by 1e6 for ppm.
+	DriftRate float64
+}
+
+// MeasureClockDrift reads the clock several times, compensating the round
+// trip time of each read, and estimates the offset and the drift rate of the
+// meter clock for time quality reports. Nil options read the clock once.
+func (c *Client) MeasureClockDrift(options *ClockDriftOptions) (*ClockDriftMeasurement, error) {
+	if options == nil {
+		options = &ClockDriftOptions{Reads: 1}
+	}
+	if options.Reads < 1 {
+		return nil, fmt.Errorf("at least one clock read is needed, got %d", options.Reads)
+	}
+
+	attribute := cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, Clock, cosem.ClockAttributeTime)
+	measurement := &ClockDriftMeasurement{}
+	for i := 0; i < options.Reads; i++ {
+		if i > 0 && options.Interval > 0 {
+			time.Sleep(options.Interval)
+		}
+
+		sent := options.now()
+		data, err := c.Get(attribute, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the clock: %w", err)
+		}
+		received := options.now()
+		meterTime, err := cosem.ParseClockTime(data)
+		if err != nil {
+			return nil, err
+		}
+
+		roundTrip := received.Sub(sent)
+		measurement.Samples = append(measurement.Samples, &ClockSample{
+			Time:      sent.Add(roundTrip / 2),
+			MeterTime: meterTime,
+			RoundTrip: roundTrip,
+		})
+	}
+
+	best := measurement.Samples[0]
+	for _, sample := range measurement.Samples[1:] {
+		if sample.RoundTrip <= best.RoundTrip {
+			best = sample
+		}
+	}
+	measurement.Offset = best.Offset()
+	measurement.DriftRate = driftRate(measurement.Samples)
+	return measurement, nil
+}
+
+// driftRate is the slope of the offsets over the reference time, 0 without
+// two samples at different times
+func driftRate(samples []*ClockSample) float64 {
+	origin := samples[0].Time
+	var sumX, sumY, sumXX, sumXY float64
+	for _, sample := range samples {
+		x := sample.Time.Sub(origin).Seconds()
+		y := sample.Offset().Seconds()
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
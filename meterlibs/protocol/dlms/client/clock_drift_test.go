@@ -0,0 +1,61 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_MeasureClockDrift(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		// offsets of 30.00, 30.01 and 30.02 seconds
+		testutil.Expect(clockTimeRequest, clockTimeResponse("0C001E19")),
+		testutil.Expect(clockTimeRequest, clockTimeResponse("0C001F1A")),
+		testutil.Expect(clockTimeRequest, clockTimeResponse("0C00201B")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	// every read takes 500 ms and starts a second after the previous one
+	calls := 0
+	options := &client.ClockDriftOptions{
+		Reads: 3,
+		Now: func() time.Time {
+			calls++
+			return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC).Add(time.Duration(calls-1) * 500 * time.Millisecond)
+		},
+	}
+	measurement, err := c.MeasureClockDrift(options)
+	require.NoError(t, err)
+	require.Len(t, measurement.Samples, 3)
+	assert.Equal(t, 500*time.Millisecond, measurement.Samples[0].RoundTrip)
+	assert.Equal(t, 30*time.Second, measurement.Samples[0].Offset())
+	assert.Equal(t, 30020*time.Millisecond, measurement.Offset)
+	assert.InDelta(t, 0.01, measurement.DriftRate, 1e-9)
+	assert.Equal(t, 0, transport.Remaining())
+
+	_, err = c.MeasureClockDrift(&client.ClockDriftOptions{})
+	assert.Error(t, err)
+}
+
+func TestClient_MeasureClockDriftWithoutOptions(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(clockTimeRequest, clockTimeResponse("0C001E19")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	measurement, err := c.MeasureClockDrift(nil)
+	require.NoError(t, err)
+	require.Len(t, measurement.Samples, 1)
+	assert.Equal(t, 0.0, measurement.DriftRate)
+	assert.Equal(t, 0, transport.Remaining())
+}
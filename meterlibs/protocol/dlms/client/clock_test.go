@@ -0,0 +1,159 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+const (
+	dstBegin = "090CFFFF03FE07020000008000FF"
+	dstEnd   = "090CFFFF0AFE07030000008000FF"
+)
+
+func TestClient_GetClock(t *testing.T) {
+	get := func(attribute string) []byte { return decodeHexString("C001C100080000010000FF" + attribute + "00") }
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(get("03"), decodeHexString("C401C10010FFC4")),
+		testutil.Expect(get("04"), decodeHexString("C401C1001180")),
+		testutil.Expect(get("05"), decodeHexString("C401C100"+dstBegin)),
+		testutil.Expect(get("06"), decodeHexString("C401C100"+dstEnd)),
+		testutil.Expect(get("07"), decodeHexString("C401C1000F3C")),
+		testutil.Expect(get("08"), decodeHexString("C401C1000301")),
+		testutil.Expect(get("09"), decodeHexString("C401C1001601")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	clock, err := c.GetClock(client.Clock)
+	require.NoError(t, err)
+	assert.Equal(t, int16(-60), clock.TimeZone)
+	assert.True(t, clock.Status.DaylightSavingActive)
+	assert.Equal(t, "*-03-* 02:00:00.00", clock.DaylightSavingsBegin.String())
+	assert.Equal(t, int8(60), clock.DaylightSavingsDeviation)
+	assert.True(t, clock.DaylightSavingsEnabled)
+	assert.Equal(t, cosem.ClockBaseInternalCrystal, clock.ClockBase)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	begin, err := clock.DaylightSavingsBegin.In(2026, paris)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 29, 2, 0, 0, 0, paris), begin)
+	end, err := clock.DaylightSavingsEnd.In(2026, paris)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 25, 3, 0, 0, 0, paris), end)
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_SetDaylightSavings(t *testing.T) {
+	set := func(attribute string, data string) testutil.Step {
+		return testutil.Expect(decodeHexString("C101C100080000010000FF"+attribute+"00"+data), decodeHexString("C501C100"))
+	}
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		set("03", "10FFC4"),
+		set("05", dstBegin),
+		set("06", dstEnd),
+		set("07", "0F3C"),
+		set("08", "0301"),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	require.NoError(t, c.SetDaylightSavings(client.Clock, cosem.EuropeanDaylightSavings(paris, 2026)))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestEuropeanDaylightSavings(t *testing.T) {
+	lisbon, err := time.LoadLocation("Europe/Lisbon")
+	require.NoError(t, err)
+	settings := cosem.EuropeanDaylightSavings(lisbon, 2026)
+	assert.Equal(t, int16(0), settings.TimeZone)
+	assert.Equal(t, "*-03-* 01:00:00.00", settings.Begin.String())
+	assert.Equal(t, "*-10-* 02:00:00.00", settings.End.String())
+	assert.True(t, settings.Enabled)
+
+	settings = cosem.EuropeanDaylightSavings(time.FixedZone("", 3*3600), 2026)
+	assert.Equal(t, int16(-180), settings.TimeZone)
+	assert.Equal(t, int8(0), settings.Deviation)
+	assert.False(t, settings.Enabled)
+}
+
+func TestSeasonDateTime_In(t *testing.T) {
+	// first Sunday from the 25th on
+	season := cosem.NewSeasonDateTime(
+		dlmsdata.NewCosemDate(dlmsdata.YearNotSpecified, 3, 25, 7),
+		dlmsdata.NewCosemTime(2, 0, dlmsdata.NotSpecified, dlmsdata.NotSpecified),
+	)
+	begin, err := season.In(2027, time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2027, 3, 28, 2, 0, 0, 0, time.UTC), begin)
+
+	season.Date.DayOfMonth = dlmsdata.DaySecondLastOfMonth
+	begin, err = season.In(2027, time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2027, 3, 21, 2, 0, 0, 0, time.UTC), begin)
+
+	season.Date.Month = dlmsdata.MonthDaylightSavingsBegin
+	_, err = season.In(2027, time.UTC)
+	assert.Error(t, err)
+}
+
+func TestParseClockDateTime(t *testing.T) {
+	// 2022-10-17 12:00:00 with deviation -60 and daylight savings active
+	dateTime, err := cosem.ParseClockDateTime(decodeHexString("090C07E60A11010C000000FFC480"))
+	require.NoError(t, err)
+	assert.True(t, dateTime.DeviationSpecified())
+	assert.Equal(t, int16(-60), dateTime.Deviation)
+	require.NotNil(t, dateTime.ClockStatus)
+	assert.True(t, dateTime.ClockStatus.DaylightSavingActive)
+	assert.Equal(t, "2022-10-17 12:00:00.00 deviation=-60 status=0x80", dateTime.String())
+	converted, err := dateTime.ToTime()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 10, 17, 11, 0, 0, 0, time.UTC), converted.UTC())
+	assert.Equal(t, decodeHexString("07E60A11010C000000FFC480"), dateTime.ToBytes())
+
+	// local time without status and with wildcards
+	dateTime, err = cosem.ParseClockDateTime(decodeHexString("090CFFFF0A11FF0C00FFFF8000FF"))
+	require.NoError(t, err)
+	assert.False(t, dateTime.DeviationSpecified())
+	assert.Nil(t, dateTime.ClockStatus)
+	assert.Equal(t, "*-10-17 12:00:*.* deviation=local status=none", dateTime.String())
+	_, err = dateTime.ToTime()
+	assert.Error(t, err)
+	assert.Equal(t, decodeHexString("FFFF0A11FF0C00FFFF8000FF"), dateTime.ToBytes())
+
+	dateTime.Date.Year = 2022
+	location := time.FixedZone("", 2*3600)
+	converted, err = dateTime.ToTimeIn(location)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 10, 17, 12, 0, 0, 0, location), converted)
+}
+
+func TestClient_GetTimeShiftLimit(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C10003010000090BFF0300"), decodeHexString("C401C10002020F001607")),
+		testutil.Expect(decodeHexString("C001C10003010000090BFF0200"), decodeHexString("C401C10012003C")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	limit, err := c.GetTimeShiftLimit()
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, limit)
+}
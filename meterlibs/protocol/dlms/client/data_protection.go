@@ -0,0 +1,43 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// GetProtectedAttributes reads attributes protected end-to-end with the
+// get_protected_attributes method of a data protection object. The server
+// protects them as the parameters ask, the returned protected attributes are
+// checked and deciphered by the end party holding the keys.
+func (c *Client) GetProtectedAttributes(
+	logicalName *cosem.Obis,
+	objects []*cosem.DataProtectionObject,
+	parameters []*cosem.DataProtectionParameters,
+) ([]*cosem.DataProtectionParameters, []byte, error) {
+	data, err := cosem.DataProtectionGetProtectedAttributesToBytes(objects, parameters)
+	if err != nil {
+		return nil, nil, err
+	}
+	returned, err := c.Action(cosem.NewDataProtection(logicalName).Method(cosem.DataProtectionMethodGetProtectedAttributes), data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cosem.ParseDataProtectionGetProtectedAttributesResponse(returned)
+}
+
+// SetProtectedAttributes writes attributes protected end-to-end with the
+// set_protected_attributes method of a data protection object. The protected
+// attributes are built by the end party holding the keys, as the parameters
+// tell.
+func (c *Client) SetProtectedAttributes(
+	logicalName *cosem.Obis,
+	objects []*cosem.DataProtectionObject,
+	parameters []*cosem.DataProtectionParameters,
+	protectedAttributes []byte,
+) error {
+	data, err := cosem.DataProtectionSetProtectedAttributesToBytes(objects, parameters, protectedAttributes)
+	if err != nil {
+		return err
+	}
+	_, err = c.Action(cosem.NewDataProtection(logicalName).Method(cosem.DataProtectionMethodSetProtectedAttributes), data)
+	return err
+}
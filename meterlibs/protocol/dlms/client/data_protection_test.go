@@ -0,0 +1,51 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_GetProtectedAttributes(t *testing.T) {
+	// authenticated and encrypted with the global unicast encryption key
+	parameters := "0101" + "0202" + "1603" + "0205" +
+		"09080102030405060708" + "09084D4D4D0000000001" + "09084D4D4D0000BC614E" + "0900" +
+		"0202" + "1600" + "1600"
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(
+			decodeHexString("C301C1001E00002B0200FF0101"+"0202"+
+				"0101"+"0204"+"12000309060100010800FF"+"0F02"+"120000"+parameters),
+			decodeHexString("C701C1000100"+"0202"+parameters+"0904DEADBEEF"),
+		),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	objects := []*cosem.DataProtectionObject{{
+		Interface:      enumerations.CosemInterfaceRegister,
+		LogicalName:    mustObis("1.0.1.8.0.255"),
+		AttributeIndex: 2,
+	}}
+	requested := []*cosem.DataProtectionParameters{{
+		Type:                  cosem.DataProtectionAuthenticationAndEncryption,
+		TransactionID:         decodeHexString("0102030405060708"),
+		OriginatorSystemTitle: decodeHexString("4D4D4D0000000001"),
+		RecipientSystemTitle:  decodeHexString("4D4D4D0000BC614E"),
+		OtherInformation:      []byte{},
+		KeyInfo:               &cosem.DataProtectionKeyInfo{Type: cosem.DataProtectionKeyIdentified},
+	}}
+
+	applied, protectedAttributes, err := c.GetProtectedAttributes(&cosem.Obis{A: 0, B: 0, C: 43, D: 2, E: 0, F: 255}, objects, requested)
+	require.NoError(t, err)
+	assert.Equal(t, requested, applied)
+	assert.Equal(t, decodeHexString("DEADBEEF"), protectedAttributes)
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+var (
+	// GSMDiagnostics is the logical name of the GSM diagnostic object
+	GSMDiagnostics = &cosem.Obis{A: 0, B: 0, C: 25, D: 6, E: 0, F: 255}
+	// LTEMonitoring is the logical name of the LTE monitoring object
+	LTEMonitoring = &cosem.Obis{A: 0, B: 0, C: 25, D: 11, E: 0, F: 255}
+)
+
+// GetGSMDiagnostics reads the operator, the registration and attachment
+// status, the cell info, the adjacent cells and the capture time of a GSM
+// diagnostic object
+func (c *Client) GetGSMDiagnostics(logicalName *cosem.Obis) (*cosem.GSMDiagnostics, error) {
+	diagnostics := cosem.NewGSMDiagnostics(logicalName)
+	err := c.getAttributes(diagnostics,
+		cosem.GSMDiagnosticsAttributeOperator,
+		cosem.GSMDiagnosticsAttributeStatus,
+		cosem.GSMDiagnosticsAttributeCSAttachment,
+		cosem.GSMDiagnosticsAttributePSStatus,
+		cosem.GSMDiagnosticsAttributeCellInfo,
+		cosem.GSMDiagnosticsAttributeAdjacentCells,
+		cosem.GSMDiagnosticsAttributeCaptureTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return diagnostics, nil
+}
+
+// GetLTEMonitoring reads the network parameters and the quality of service
+// of a LTE monitoring object
+func (c *Client) GetLTEMonitoring(logicalName *cosem.Obis) (*cosem.LTEMonitoring, error) {
+	monitoring := cosem.NewLTEMonitoring(logicalName)
+	err := c.getAttributes(monitoring,
+		cosem.LTEMonitoringAttributeNetworkParameters,
+		cosem.LTEMonitoringAttributeQualityOfService,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return monitoring, nil
+}
@@ -0,0 +1,92 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_GetGSMDiagnostics(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C1002F0000190600FF0200"), decodeHexString("C401C1000A084F70657261746F72")),
+		testutil.Expect(decodeHexString("C001C1002F0000190600FF0300"), decodeHexString("C401C1001605")),
+		testutil.Expect(decodeHexString("C001C1002F0000190600FF0400"), decodeHexString("C401C1001600")),
+		testutil.Expect(decodeHexString("C001C1002F0000190600FF0500"), decodeHexString("C401C1001605")),
+		// cell 0x01020304 in area 0x1234 at -81 dBm, ber 0, MCC 262, MNC 1, channel 6300
+		testutil.Expect(decodeHexString("C001C1002F0000190600FF0600"), decodeHexString("C401C1000207060102030412123411101100120106120001060000189C")),
+		testutil.Expect(decodeHexString("C001C1002F0000190600FF0700"), decodeHexString("C401C1000101020206010203051163")),
+		testutil.Expect(decodeHexString("C001C1002F0000190600FF0800"), decodeHexString("C401C100090C07EA0A110608000000000000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	diagnostics, err := c.GetGSMDiagnostics(client.GSMDiagnostics)
+	require.NoError(t, err)
+	assert.Equal(t, "Operator", diagnostics.Operator)
+	assert.True(t, diagnostics.Status.Registered())
+	assert.Equal(t, "registered, roaming", diagnostics.Status.String())
+	assert.Equal(t, cosem.GSMCircuitSwitchedStatusInactive, diagnostics.CSAttachment)
+	assert.Equal(t, "LTE", diagnostics.PSStatus.String())
+	assert.Equal(t, &cosem.GSMCellInfo{
+		CellID:            0x01020304,
+		LocationID:        0x1234,
+		SignalQuality:     16,
+		BitErrorRate:      0,
+		MobileCountryCode: 262,
+		MobileNetworkCode: 1,
+		ChannelNumber:     6300,
+	}, diagnostics.CellInfo)
+	assert.Equal(t, "-81 dBm", diagnostics.CellInfo.SignalQuality.String())
+	require.Len(t, diagnostics.AdjacentCells, 1)
+	_, known := diagnostics.AdjacentCells[0].SignalQuality.DBm()
+	assert.False(t, known)
+	assert.True(t, diagnostics.CaptureTime.Equal(time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_GetLTEMonitoring(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C100970000190B00FF0200"),
+			decodeHexString("C401C10002091202D0120BB80600000E1012003C060000001412000A0F8C0F8C0F8C")),
+		testutil.Expect(decodeHexString("C001C100970000190B00FF0300"), decodeHexString("C401C10002040FF60FA60F0C1601")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	monitoring, err := c.GetLTEMonitoring(client.LTEMonitoring)
+	require.NoError(t, err)
+	assert.Equal(t, &cosem.LTENetworkParameters{
+		T3402:        720,
+		T3412:        3000,
+		T3412Ext2:    3600,
+		T3324:        60,
+		TeDRX:        20,
+		TPTW:         10,
+		QRxLevMin:    -116,
+		QRxLevMinCE:  -116,
+		QRxLevMinCE1: -116,
+	}, monitoring.NetworkParameters)
+	assert.Equal(t, &cosem.LTEQualityOfService{
+		SignalQuality:       -10,
+		SignalLevel:         -90,
+		SignalToNoiseRatio:  12,
+		CoverageEnhancement: cosem.LTECoverageEnhancementLevel1,
+	}, monitoring.QualityOfService)
+}
+
+func TestGSMDiagnostics_CellInfoVersion0(t *testing.T) {
+	diagnostics := cosem.NewGSMDiagnostics(client.GSMDiagnostics)
+	require.NoError(t, diagnostics.FromAttribute(cosem.GSMDiagnosticsAttributeCellInfo, decodeHexString("020412010212123411631163")))
+	assert.Equal(t, &cosem.GSMCellInfo{CellID: 0x0102, LocationID: 0x1234, SignalQuality: 99, BitErrorRate: 99}, diagnostics.CellInfo)
+
+	assert.Error(t, diagnostics.FromAttribute(cosem.GSMDiagnosticsAttributeCellInfo, decodeHexString("0203120102121234116300")))
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// RequestKind is the kind of the request the client waits a response for
+type RequestKind int
+
+const (
+	RequestAssociation RequestKind = iota
+	RequestRelease
+	RequestGet
+	RequestSet
+	RequestAction
+)
+
+// String implements fmt.Stringer
+func (k RequestKind) String() string {
+	switch k {
+	case RequestAssociation:
+		return "association"
+	case RequestRelease:
+		return "release"
+	case RequestGet:
+		return "get"
+	case RequestSet:
+		return "set"
+	case RequestAction:
+		return "action"
+	default:
+		return fmt.Sprintf("RequestKind(%d)", int(k))
+	}
+}
+
+// RequestKindOf returns the kind of a request APDU sent by the client
+func RequestKindOf(apdu xdlms.Apdu) (RequestKind, error) {
+	switch apdu.Tag() {
+	case acse.AARQTag:
+		return RequestAssociation, nil
+	case acse.RLRQTag:
+		return RequestRelease, nil
+	case xdlms.GetRequestTag:
+		return RequestGet, nil
+	case xdlms.SetRequestTag:
+		return RequestSet, nil
+	case xdlms.ActionRequestTag:
+		return RequestAction, nil
+	default:
+		return 0, fmt.Errorf("%s is not a request awaiting a response", apdu)
+	}
+}
+
+// ResponseClass is how an APDU received while a request is pending is handled
+type ResponseClass int
+
+const (
+	// ResponseUnexpected is an APDU that can't answer the pending request
+	ResponseUnexpected ResponseClass = iota
+	// ResponseExpected is a response of the service of the pending request
+	ResponseExpected
+	// ResponseError is an ExceptionResponse or a ConfirmedServiceError, the
+	// server refused the pending request
+	ResponseError
+	// ResponseNotification is an unsolicited DataNotification or
+	// EventNotification, the pending request is still awaiting its response
+	ResponseNotification
+)
+
+// String implements fmt.Stringer
+func (c ResponseClass) String() string {
+	switch c {
+	case ResponseUnexpected:
+		return "unexpected"
+	case ResponseExpected:
+		return "expected"
+	case ResponseError:
+		return "error"
+	case ResponseNotification:
+		return "notification"
+	default:
+		return fmt.Sprintf("ResponseClass(%d)", int(c))
+	}
+}
+
+// ServiceError is returned when the server answers a request with an
+// ExceptionResponse or a ConfirmedServiceError
+type ServiceError struct {
+	Request  RequestKind
+	Response xdlms.Apdu
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("%s request failed: %s", e.Request, e.Response)
+}
+
+// Unwrap returns the error of the exceptions package of a
+// ConfirmedServiceError, so errors.As finds its category, nil for an
+// ExceptionResponse
+func (e *ServiceError) Unwrap() error {
+	if serviceError, ok := e.Response.(*xdlms.ConfirmedServiceError); ok {
+		return serviceError.Err()
+	}
+	return nil
+}
+
+// Dispatcher classifies the APDUs received while a request is pending and
+// routes the notifications to a subscriber instead of failing the request
+type Dispatcher struct {
+	mutex      sync.Mutex
+	subscriber chan<- *DecodedNotification
+	filter     *xdlms.LongInvokeIDFilter
+	dropped    int
+	duplicates int
+}
+
+// NewDispatcher creates a new Dispatcher without subscriber, notifications are
+// dropped until one subscribes
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Subscribe sets the channel receiving the notifications, nil unsubscribes.
+// The dispatcher never blocks on the channel: notifications that don't fit
+// in it are dropped.
+func (d *Dispatcher) Subscribe(subscriber chan<- *DecodedNotification) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.subscriber = subscriber
+}
+
+// Deduplicate drops the notifications with a long invoke id the filter
+// already accepted, nil routes all notifications
+func (d *Dispatcher) Deduplicate(filter *xdlms.LongInvokeIDFilter) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.filter = filter
+}
+
+// Duplicates returns the number of notifications dropped because they were
+// received before
+func (d *Dispatcher) Duplicates() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.duplicates
+}
+
+// Dropped returns the number of notifications that couldn't be routed
+func (d *Dispatcher) Dropped() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.dropped
+}
+
+// Classify tells how the response received while the pending request awaits
+// its answer is handled
+func (d *Dispatcher) Classify(pending RequestKind, response xdlms.Apdu) ResponseClass {
+	if isNotification(response) {
+		return ResponseNotification
+	}
+	switch response.Tag() {
+	case xdlms.ExceptionResponseTag, xdlms.ConfirmedServiceErrorTag:
+		return ResponseError
+	}
+
+	var expected uint8
+	switch pending {
+	case RequestAssociation:
+		expected = acse.AARETag
+	case RequestRelease:
+		expected = acse.RLRETag
+	case RequestGet:
+		expected = xdlms.GetResponseTag
+	case RequestSet:
+		expected = xdlms.SetResponseTag
+	case RequestAction:
+		expected = xdlms.ActionResponseTag
+	default:
+		return ResponseUnexpected
+	}
+	if response.Tag() != expected {
+		return ResponseUnexpected
+	}
+	return ResponseExpected
+}
+
+// Route decodes a notification and sends it to the subscriber, it returns
+// false when the notification was dropped or is a duplicate
+func (d *Dispatcher) Route(notification xdlms.Apdu) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	decoded, err := DecodeNotification(notification)
+	if err == nil && d.filter != nil && !d.filter.Accept(decoded.LongInvokeID) {
+		d.duplicates++
+		return false
+	}
+	if err == nil && d.subscriber != nil {
+		select {
+		case d.subscriber <- decoded:
+			return true
+		default:
+		}
+	}
+	d.dropped++
+	return false
+}
+
+func isNotification(apdu xdlms.Apdu) bool {
+	return apdu.Tag() == xdlms.DataNotificationTag || apdu.Tag() == xdlms.EventNotificationTag
+}
@@ -0,0 +1,167 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// dataNotification is a DataNotification without date-time carrying a long-unsigned
+var dataNotification = decodeHexString("0F40000001001200FF")
+
+func TestClient_NotificationDuringGet(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, dataNotification, testutil.ClockTimeResponse),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	notifications := c.Notifications()
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	data, err := c.Get(clockTime, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testutil.ClockTimeResponse[4:], data)
+	assert.Equal(t, dlms.Ready, c.State().CurrentState())
+
+	if assert.Len(t, notifications, 1) {
+		notification := <-notifications
+		assert.Equal(t, client.NotificationData, notification.Kind)
+		assert.Equal(t, uint32(1), notification.LongInvokeID)
+		assert.Equal(t, []byte{0x12, 0x00, 0xFF}, notification.Body)
+	}
+	assert.NoError(t, transport.Err())
+}
+
+func TestClient_NotificationWithoutSubscriberIsDropped(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, dataNotification, testutil.ClockTimeResponse),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	_, err := c.Get(clockTime, nil)
+	assert.NoError(t, err)
+}
+
+func TestClient_ServiceErrors(t *testing.T) {
+	for name, response := range map[string][]byte{
+		"exception response":      decodeHexString("D80102"),
+		"confirmed service error": decodeHexString("0E050501"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			transport := testutil.NewScriptedTransport(
+				associate(testutil.AssociationResponse),
+				testutil.Expect(testutil.ClockTimeRequest, response),
+			)
+			c := client.New(transport, client.NewSettings(16, 1))
+
+			assert.NoError(t, c.Connect())
+			assert.NoError(t, c.Associate())
+
+			_, err := c.Get(clockTime, nil)
+			var serviceError *client.ServiceError
+			if assert.True(t, errors.As(err, &serviceError)) {
+				assert.Equal(t, client.RequestGet, serviceError.Request)
+				assert.Equal(t, response[0], serviceError.Response.Tag())
+			}
+			assert.Equal(t, dlms.Ready, c.State().CurrentState())
+		})
+	}
+}
+
+func TestClient_ConfirmedServiceErrorCategory(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		// read, access error scope-of-access-violated
+		testutil.Expect(testutil.ClockTimeRequest, decodeHexString("0E050501")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	_, err := c.Get(clockTime, nil)
+	var accessRightsError *exceptions.AccessRightsError
+	assert.ErrorAs(t, err, &accessRightsError)
+	var serviceError *client.ServiceError
+	assert.ErrorAs(t, err, &serviceError)
+}
+
+func TestClient_UnexpectedResponse(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, decodeHexString("C501C100")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	_, err := c.Get(clockTime, nil)
+	var protocolError *exceptions.LocalDlmsProtocolError
+	assert.True(t, errors.As(err, &protocolError))
+}
+
+func TestDispatcher_Classify(t *testing.T) {
+	d := client.NewDispatcher()
+	factory := xdlms.NewXDlmsApduFactory()
+	parse := func(s string) xdlms.Apdu {
+		apdu, err := factory.APDUFromBytes(decodeHexString(s))
+		assert.NoError(t, err)
+		return apdu
+	}
+
+	assert.Equal(t, client.ResponseExpected, d.Classify(client.RequestGet, parse("C401C10000090102")))
+	assert.Equal(t, client.ResponseExpected, d.Classify(client.RequestSet, parse("C501C100")))
+	assert.Equal(t, client.ResponseUnexpected, d.Classify(client.RequestAction, parse("C501C100")))
+	assert.Equal(t, client.ResponseError, d.Classify(client.RequestSet, parse("D80102")))
+	assert.Equal(t, client.ResponseNotification, d.Classify(client.RequestAction, parse("0F40000001001200FF")))
+	assert.Equal(t, client.ResponseNotification, d.Classify(client.RequestGet, parse("C24000000100001200FF")))
+}
+
+func TestDispatcher_Route(t *testing.T) {
+	d := client.NewDispatcher()
+	notification := xdlms.NewDataNotification(xdlms.NewLongInvokeIdAndPriority(1, false, false, false, false), nil, nil)
+
+	assert.False(t, d.Route(notification))
+
+	subscriber := make(chan *client.DecodedNotification, 1)
+	d.Subscribe(subscriber)
+	assert.True(t, d.Route(notification))
+	assert.False(t, d.Route(notification))
+	assert.Equal(t, 2, d.Dropped())
+	assert.Equal(t, uint32(1), (<-subscriber).LongInvokeID)
+}
+
+func TestDispatcher_Deduplicate(t *testing.T) {
+	d := client.NewDispatcher()
+	filter, err := xdlms.NewLongInvokeIDFilter(2)
+	assert.NoError(t, err)
+	d.Deduplicate(filter)
+	subscriber := make(chan *client.DecodedNotification, 4)
+	d.Subscribe(subscriber)
+
+	notification := func(id uint32) xdlms.Apdu {
+		return xdlms.NewDataNotification(xdlms.NewLongInvokeIdAndPriority(id, false, false, false, false), nil, nil)
+	}
+	assert.True(t, d.Route(notification(1)))
+	assert.False(t, d.Route(notification(1)))
+	assert.True(t, d.Route(notification(2)))
+	assert.True(t, d.Route(notification(3)))
+	// 1 left the window
+	assert.True(t, d.Route(notification(1)))
+	assert.Equal(t, 1, d.Duplicates())
+	assert.Equal(t, 0, d.Dropped())
+	assert.Len(t, subscriber, 4)
+}
@@ -0,0 +1,51 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_GetValueNamesEnum(t *testing.T) {
+	controlState := cosem.NewCosemAttribute(enumerations.CosemInterfaceDisconnectControl, mustObis("0.0.96.3.10.255"), cosem.DisconnectControlAttributeControlState)
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C10046000060030AFF0300"), decodeHexString("C401C1001601")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	value, err := c.GetValue(controlState, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &cosem.NamedEnum{Value: 1, Name: "connected"}, value)
+	assert.Equal(t, "connected(1)", value.(*cosem.NamedEnum).String())
+}
+
+func TestEnumResolver_Register(t *testing.T) {
+	resolver := cosem.NewDefaultEnumResolver()
+	outputState := cosem.NewCosemAttribute(enumerations.CosemInterfaceDisconnectControl, mustObis("0.0.96.3.10.255"), cosem.DisconnectControlAttributeOutputState)
+	controlState := cosem.NewCosemAttribute(enumerations.CosemInterfaceDisconnectControl, mustObis("0.0.96.3.10.255"), cosem.DisconnectControlAttributeControlState)
+
+	// not registered
+	assert.Equal(t, dlmsdata.Enum(1), resolver.Resolve(outputState, dlmsdata.Enum(1)))
+
+	// unknown value
+	assert.Equal(t, dlmsdata.Enum(7), resolver.Resolve(controlState, dlmsdata.Enum(7)))
+
+	// the instance wins over the interface class, in arrays too
+	resolver.Register(controlState, cosem.EnumNames{0: "open", 1: "closed"})
+	assert.Equal(t,
+		[]interface{}{&cosem.NamedEnum{Value: 1, Name: "closed"}, uint8(3)},
+		resolver.Resolve(controlState, []interface{}{dlmsdata.Enum(1), uint8(3)}),
+	)
+
+	other := cosem.NewCosemAttribute(enumerations.CosemInterfaceDisconnectControl, mustObis("0.1.96.3.10.255"), cosem.DisconnectControlAttributeControlState)
+	assert.Equal(t, &cosem.NamedEnum{Value: 2, Name: "ready_for_reconnection"}, resolver.Resolve(other, dlmsdata.Enum(2)))
+}
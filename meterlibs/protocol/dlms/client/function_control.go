@@ -0,0 +1,30 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// FunctionControl is the logical name of the Function control object
+var FunctionControl = &cosem.Obis{A: 0, B: 0, C: 44, D: 1, E: 0, F: 255}
+
+// GetFunctionControl reads the activation status and the functions of a
+// Function control object
+func (c *Client) GetFunctionControl(logicalName *cosem.Obis) (*cosem.FunctionControl, error) {
+	control := cosem.NewFunctionControl(logicalName)
+	err := c.getAttributes(control,
+		cosem.FunctionControlAttributeActivationStatus,
+		cosem.FunctionControlAttributeFunctionList,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return control, nil
+}
+
+// SetFunctionStatus enables or disables functions of the meter with the
+// set_function_status method of a Function control object
+func (c *Client) SetFunctionStatus(logicalName *cosem.Obis, statuses ...*cosem.FunctionStatus) error {
+	control := cosem.NewFunctionControl(logicalName)
+	_, err := c.Action(control.Method(cosem.FunctionControlMethodSetFunctionStatus), cosem.FunctionStatusesToBytes(statuses))
+	return err
+}
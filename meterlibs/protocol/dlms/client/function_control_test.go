@@ -0,0 +1,42 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_FunctionControl(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C1007A00002C0100FF0200"),
+			// the local readout "LR" is enabled
+			decodeHexString("C401C100"+"0101"+"0202"+"09024C52"+"0301")),
+		testutil.Expect(decodeHexString("C001C1007A00002C0100FF0300"),
+			// the local readout is the optical port setup
+			decodeHexString("C401C100"+"0101"+"0202"+"09024C52"+"0101"+"0202"+"12001709060000140000FF")),
+		testutil.Expect(decodeHexString("C301C1007A00002C0100FF0101"+"0101"+"0202"+"09024C52"+"0300"),
+			decodeHexString("C701C10000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	control, err := c.GetFunctionControl(client.FunctionControl)
+	require.NoError(t, err)
+	status, ok := control.Status([]byte("LR"))
+	require.True(t, ok)
+	assert.True(t, status.Enabled)
+	require.Len(t, control.FunctionList, 1)
+	assert.Equal(t, enumerations.CosemInterface(23), control.FunctionList[0].Objects[0].Interface)
+	assert.Equal(t, mustObis("0.0.20.0.0.255"), control.FunctionList[0].Objects[0].LogicalName)
+
+	require.NoError(t, c.SetFunctionStatus(client.FunctionControl, &cosem.FunctionStatus{Name: []byte("LR"), Enabled: false}))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// receive parses a received APDU. The blocks of a general block transfer are
+// put together, the windows acknowledged, and nil is returned until the last
+// block gives the APDU carried.
+func (c *Client) receive(received []byte) (xdlms.Apdu, error) {
+	apdu, err := c.factory.APDUFromBytes(received)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := apdu.(*xdlms.GeneralBlockTransfer)
+	if !ok {
+		return apdu, nil
+	}
+	if c.logger != nil {
+		c.logger.Printf("received %s", block)
+	}
+
+	data, ack := c.blocks.Add(block)
+	if ack != nil {
+		encoded, err := ack.ToBytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", ack, err)
+		}
+		if err = c.transport.Send(encoded); err != nil {
+			return nil, exceptions.NewCommunicationError(fmt.Sprintf("failed to send %s: %v", ack, err))
+		}
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return c.factory.APDUFromBytes(data)
+}
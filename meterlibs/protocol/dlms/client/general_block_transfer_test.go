@@ -0,0 +1,35 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_GetWithGeneralBlockTransfer(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C100030100010800FF0200"),
+			decodeHexString("E04200010000"+"04C401C100"),
+			decodeHexString("E00200020000"+"0406000000"),
+		),
+		testutil.Expect(decodeHexString("E00200010002"+"00"),
+			decodeHexString("E08200030000"+"0101"),
+		),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.GeneralBlockTransferWindow = 2
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	data, err := c.Get(cosem.NewCosemAttribute(enumerations.CosemInterfaceRegister, mustObis("1.0.1.8.0.255"), 2), nil)
+	require.NoError(t, err)
+	assert.Equal(t, decodeHexString("0600000001"), data)
+	assert.Equal(t, 0, transport.Remaining())
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// GetWithList reads several attributes with one GET request with list,
+// following block transfers. There is a result per attribute, in order: an
+// attribute the meter can't read gives an error result and doesn't fail the
+// others. accessSelections is nil or has an entry per attribute. The server
+// must have accepted multiple_references in the association.
+func (c *Client) GetWithList(attributes []*cosem.CosemAttribute, accessSelections []interface{}) ([]*xdlms.GetDataResult, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.requireConformance("multiple_references"); err != nil {
+		return nil, err
+	}
+	if accessSelections != nil && len(accessSelections) != len(attributes) {
+		return nil, fmt.Errorf("%d access selections for %d attributes", len(accessSelections), len(attributes))
+	}
+
+	var results []*xdlms.GetDataResult
+	_, err := c.retry(true, func() (_ []byte, err error) {
+		results, err = c.getWithList(attributes, accessSelections)
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetWithListOfDescriptors reads several attributes, or elements of them, with
+// one GET request with list. The descriptors with a data index read the whole
+// attribute and their result holds the element only.
+func (c *Client) GetWithListOfDescriptors(descriptors []*cosem.CosemAttributeWithSelection) ([]*xdlms.GetDataResult, error) {
+	attributes := make([]*cosem.CosemAttribute, len(descriptors))
+	accessSelections := make([]interface{}, len(descriptors))
+	for i, descriptor := range descriptors {
+		attributes[i] = descriptor.Attribute
+		accessSelections[i] = descriptor.AccessSelection
+	}
+
+	results, err := c.GetWithList(attributes, accessSelections)
+	if err != nil {
+		return nil, err
+	}
+	for i, descriptor := range descriptors {
+		if descriptor.DataIndex == 0 || results[i].IsError() {
+			continue
+		}
+		element, err := descriptor.Element(results[i].Data())
+		if err != nil {
+			return nil, exceptions.NewLocalDlmsProtocolError(fmt.Sprintf("attribute %d: %s", i, err))
+		}
+		results[i] = xdlms.NewGetDataResult(element)
+	}
+	return results, nil
+}
+
+func (c *Client) getWithList(attributes []*cosem.CosemAttribute, accessSelections []interface{}) ([]*xdlms.GetDataResult, error) {
+	response, err := c.request(xdlms.NewGetRequestWithList(c.invokeID, attributes, accessSelections))
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for {
+		switch r := response.(type) {
+		case *xdlms.GetResponseWithList:
+			return checkResultCount(r.Results, len(attributes))
+		case *xdlms.GetResponseLastBlock:
+			results, err := xdlms.GetDataResultsFromBytes(append(data, r.RawData...))
+			if err != nil {
+				return nil, exceptions.NewLocalDlmsProtocolError(err.Error())
+			}
+			return checkResultCount(results, len(attributes))
+		case *xdlms.GetResponseWithDataBlock:
+			data = append(data, r.RawData...)
+			response, err = c.request(xdlms.NewGetRequestNext(r.BlockNumber, c.invokeID))
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, exceptions.NewDlmsClientException(fmt.Sprintf("get with list failed: %s", response))
+		}
+	}
+}
+
+// checkResultCount checks there is a result per requested attribute
+func checkResultCount(results []*xdlms.GetDataResult, count int) ([]*xdlms.GetDataResult, error) {
+	if len(results) != count {
+		return nil, exceptions.NewLocalDlmsProtocolError(fmt.Sprintf("%d results for %d attributes", len(results), count))
+	}
+	return results, nil
+}
@@ -0,0 +1,136 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// associationResponseWithList accepts multiple references
+var associationResponseWithList = decodeHexString("6129A109060760857405080101A203020100A305A103020100BE10040E0800065F1F040000121D04000007")
+
+const getWithListRequest = "C003C102" + "00080000010000FF0200" + "00030100010800FF0200"
+
+func getWithListAttributes() []*cosem.CosemAttribute {
+	return []*cosem.CosemAttribute{
+		cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, mustObis("0.0.1.0.0.255"), 2),
+		cosem.NewCosemAttribute(enumerations.CosemInterfaceRegister, mustObis("1.0.1.8.0.255"), 2),
+	}
+}
+
+func TestClient_GetWithList(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(associationResponseWithList),
+		testutil.Expect(decodeHexString(getWithListRequest), decodeHexString("C403C102"+"0012003C"+"0104")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	results, err := c.GetWithList(getWithListAttributes(), nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.False(t, results[0].IsError())
+	assert.Equal(t, enumerations.DataAccessSuccess, results[0].Error())
+	assert.Equal(t, decodeHexString("12003C"), results[0].Data())
+	value, err := results[0].DecodedData()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(60), value)
+
+	assert.True(t, results[1].IsError())
+	assert.Equal(t, enumerations.DataAccessObjectUndefined, results[1].Error())
+	assert.Nil(t, results[1].Data())
+	_, err = results[1].DecodedData()
+	assert.Error(t, err)
+}
+
+func TestClient_GetWithListInBlocks(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(associationResponseWithList),
+		testutil.Expect(decodeHexString(getWithListRequest), decodeHexString("C402C10000000001"+"0003"+"020012")),
+		testutil.Expect(decodeHexString("C002C100000001"), decodeHexString("C402C10100000002"+"0004"+"003C0104")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	results, err := c.GetWithList(getWithListAttributes(), nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, decodeHexString("12003C"), results[0].Data())
+	assert.Equal(t, enumerations.DataAccessObjectUndefined, results[1].Error())
+}
+
+func TestClient_GetWithListNotNegotiated(t *testing.T) {
+	transport := testutil.NewScriptedTransport(associate(testutil.AssociationResponse))
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	_, err := c.GetWithList(getWithListAttributes(), nil)
+	var conformanceError *exceptions.ConformanceError
+	assert.ErrorAs(t, err, &conformanceError)
+
+	var missing *xdlms.ConformanceError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "multiple_references", missing.Service)
+	assert.Equal(t, 9, missing.Bit())
+	assert.Equal(t, c.NegotiatedConformance(), missing.Negotiated)
+}
+
+func TestClient_GetWithListOfDescriptors(t *testing.T) {
+	scalerUnit := cosem.NewCosemAttribute(enumerations.CosemInterfaceRegister, mustObis("1.0.1.8.0.255"), 3)
+	transport := testutil.NewScriptedTransport(
+		associate(associationResponseWithList),
+		// the descriptors go out without the data index
+		testutil.Expect(decodeHexString("C003C102"+"00030100010800FF0300"+"00030100010800FF0300"),
+			decodeHexString("C403C102"+"0002020FFD161E"+"0002020FFD161E")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	results, err := c.GetWithListOfDescriptors([]*cosem.CosemAttributeWithSelection{
+		cosem.NewCosemAttributeWithDataIndex(scalerUnit, 2),
+		cosem.NewCosemAttributeWithDataIndex(scalerUnit, 0),
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, decodeHexString("161E"), results[0].Data())
+	assert.Equal(t, decodeHexString("02020FFD161E"), results[1].Data())
+	assert.NoError(t, transport.Err())
+}
+
+func TestCosemAttributeWithSelection_Element(t *testing.T) {
+	attribute := cosem.NewCosemAttribute(enumerations.CosemInterfaceRegister, mustObis("1.0.1.8.0.255"), 3)
+	value := decodeHexString("02020FFD161E")
+
+	element, err := cosem.NewCosemAttributeWithDataIndex(attribute, 1).Element(value)
+	require.NoError(t, err)
+	assert.Equal(t, decodeHexString("0FFD"), element)
+
+	_, err = cosem.NewCosemAttributeWithDataIndex(attribute, 3).Element(value)
+	assert.Error(t, err)
+	_, err = cosem.NewCosemAttributeWithDataIndex(attribute, 1).Element(decodeHexString("12003C"))
+	assert.Error(t, err)
+
+	// the data index is not encoded
+	encoded, err := cosem.NewCosemAttributeWithDataIndex(attribute, 2).ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, decodeHexString("00030100010800FF0300"), encoded)
+
+	capture := cosem.NewCaptureObject(attribute, 2)
+	assert.Equal(t, uint16(2), capture.AttributeWithSelection().DataIndex)
+}
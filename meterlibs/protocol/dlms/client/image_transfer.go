@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/resilience"
+)
+
+// ImageTransfer is the logical name of the Image transfer object
+var ImageTransfer = &cosem.Obis{A: 0, B: 0, C: 44, D: 0, E: 0, F: 255}
+
+// VerificationError is returned by WaitForVerification when the meter
+// failed to verify the image. It tells which blocks the meter is missing.
+type VerificationError struct {
+	BlockSize                uint32
+	FirstNotTransferredBlock uint32
+	// MissingBlocks are the numbers of the blocks not marked as received by
+	// the transferred_blocks_status
+	MissingBlocks []uint32
+}
+
+// Error implements error
+func (e *VerificationError) Error() string {
+	if len(e.MissingBlocks) == 0 {
+		return "image verification failed, all blocks were received"
+	}
+	return fmt.Sprintf("image verification failed, %d blocks missing, first not transferred block %d: %v",
+		len(e.MissingBlocks), e.FirstNotTransferredBlock, e.MissingBlocks)
+}
+
+// GetImageTransfer reads the block size, the transfer state and the images
+// to activate of an Image transfer object
+func (c *Client) GetImageTransfer(logicalName *cosem.Obis) (*cosem.ImageTransfer, error) {
+	transfer := cosem.NewImageTransfer(logicalName)
+	err := c.getAttributes(transfer,
+		cosem.ImageTransferAttributeBlockSize,
+		cosem.ImageTransferAttributeTransferredBlocksStatus,
+		cosem.ImageTransferAttributeFirstNotTransferredBlock,
+		cosem.ImageTransferAttributeEnabled,
+		cosem.ImageTransferAttributeStatus,
+		cosem.ImageTransferAttributeImageToActivateInfo,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetImageTransferStatus reads the image_transfer_status of an Image transfer object
+func (c *Client) GetImageTransferStatus(logicalName *cosem.Obis) (cosem.ImageTransferStatus, error) {
+	transfer := cosem.NewImageTransfer(logicalName)
+	if err := c.getAttributes(transfer, cosem.ImageTransferAttributeStatus); err != nil {
+		return 0, err
+	}
+	return transfer.Status, nil
+}
+
+// GetImageToActivateInfo reads the images verified and ready to be activated
+func (c *Client) GetImageToActivateInfo(logicalName *cosem.Obis) ([]*cosem.ImageToActivateInfo, error) {
+	transfer := cosem.NewImageTransfer(logicalName)
+	if err := c.getAttributes(transfer, cosem.ImageTransferAttributeImageToActivateInfo); err != nil {
+		return nil, err
+	}
+	return transfer.ImageToActivateInfo, nil
+}
+
+// VerifyImage starts the verification of the transferred image. Meters
+// verifying the image in the background return temporary-failure, the
+// result is then polled with WaitForVerification.
+func (c *Client) VerifyImage(logicalName *cosem.Obis) error {
+	transfer := cosem.NewImageTransfer(logicalName)
+	_, err := c.Action(transfer.Method(cosem.ImageTransferMethodVerify), cosem.IntegerZeroToBytes())
+	return err
+}
+
+// WaitForVerification polls the image_transfer_status until the verification
+// of an image of the given size ends, waiting the delays of backoff between
+// the reads. The number of reads is only bounded by ctx, the MaxRetries of
+// backoff is not used. A failed verification returns a *VerificationError
+// with the blocks the meter is missing.
+func (c *Client) WaitForVerification(ctx context.Context, logicalName *cosem.Obis, imageSize uint32, backoff *resilience.Backoff) error {
+	if backoff == nil {
+		backoff = resilience.NewBackoff()
+	}
+
+	for retry := 0; ; retry++ {
+		status, err := c.GetImageTransferStatus(logicalName)
+		if err != nil {
+			return err
+		}
+
+		switch status {
+		case cosem.ImageVerificationSuccessful:
+			return nil
+		case cosem.ImageVerificationFailed:
+			return c.verificationError(logicalName, imageSize)
+		case cosem.ImageTransferInitiated, cosem.ImageVerificationInitiated:
+		default:
+			return fmt.Errorf("image transfer status is %s while waiting for the verification", status)
+		}
+
+		timer := time.NewTimer(backoff.Delay(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// verificationError reads the transfer state to report the missing blocks
+func (c *Client) verificationError(logicalName *cosem.Obis, imageSize uint32) error {
+	transfer := cosem.NewImageTransfer(logicalName)
+	err := c.getAttributes(transfer,
+		cosem.ImageTransferAttributeBlockSize,
+		cosem.ImageTransferAttributeTransferredBlocksStatus,
+		cosem.ImageTransferAttributeFirstNotTransferredBlock,
+	)
+	if err != nil {
+		return fmt.Errorf("image verification failed, the missing blocks can't be read: %w", err)
+	}
+	return &VerificationError{
+		BlockSize:                transfer.BlockSize,
+		FirstNotTransferredBlock: transfer.FirstNotTransferredBlock,
+		MissingBlocks:            transfer.MissingBlocks(imageSize),
+	}
+}
@@ -0,0 +1,39 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/resilience"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_WaitForVerification(t *testing.T) {
+	statusRequest := decodeHexString("C001C1001200002C0000FF0600")
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(statusRequest, decodeHexString("C401C1001602")),
+		testutil.Expect(statusRequest, decodeHexString("C401C1001604")),
+		testutil.Expect(decodeHexString("C001C1001200002C0000FF0200"), decodeHexString("C401C1000600000040")),
+		// blocks 2 and 4 of 5 were not received
+		testutil.Expect(decodeHexString("C001C1001200002C0000FF0300"), decodeHexString("C401C1000405D0")),
+		testutil.Expect(decodeHexString("C001C1001200002C0000FF0400"), decodeHexString("C401C1000600000002")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	backoff := &resilience.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 2}
+	err := c.WaitForVerification(context.Background(), client.ImageTransfer, 320, backoff)
+	var verificationError *client.VerificationError
+	require.True(t, errors.As(err, &verificationError))
+	assert.Equal(t, []uint32{2, 4}, verificationError.MissingBlocks)
+	assert.Equal(t, uint32(2), verificationError.FirstNotTransferredBlock)
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// ExpectedInvocationCounter returns the invocation counter a meter expects
+// when it rejected a request with an invocation-counter-error exception
+func ExpectedInvocationCounter(err error) (uint32, bool) {
+	var serviceError *ServiceError
+	if !errors.As(err, &serviceError) {
+		return 0, false
+	}
+	exception, ok := serviceError.Response.(*xdlms.ExceptionResponse)
+	if !ok || exception.ServiceError != enumerations.ServiceExceptionInvocationCounterError ||
+		exception.InvocationCounterData == nil {
+		return 0, false
+	}
+	return *exception.InvocationCounterData, true
+}
+
+// recoverInvocationCounter advances the invocation counter of the settings
+// to the one expected by the meter when RecoverInvocationCounter is set. It
+// tells if the request should be retried.
+func (c *Client) recoverInvocationCounter(err error) bool {
+	if !c.settings.RecoverInvocationCounter || c.settings.InvocationCounter == nil {
+		return false
+	}
+	expected, ok := ExpectedInvocationCounter(err)
+	if !ok || !c.settings.InvocationCounter.Advance(expected) {
+		return false
+	}
+	if c.logger != nil {
+		c.logger.Printf("meter expects invocation counter %d, retrying", expected)
+	}
+	return true
+}
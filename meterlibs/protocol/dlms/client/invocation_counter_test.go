@@ -0,0 +1,55 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+var invocationCounterSetRequest = decodeHexString("C101C1000100002A0000FF0200" + "1200FF")
+
+func TestClient_RecoverInvocationCounter(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(invocationCounterSetRequest, decodeHexString("D8010600000064")),
+		testutil.Expect(invocationCounterSetRequest, decodeHexString("C501C100")),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.InvocationCounter = security.NewInvocationCounter(7)
+	settings.RecoverInvocationCounter = true
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	attribute := cosem.NewCosemAttribute(enumerations.CosemInterfaceData, mustObis("0.0.42.0.0.255"), 2)
+	require.NoError(t, c.Set(attribute, decodeHexString("1200FF")))
+	assert.Equal(t, uint32(100), settings.InvocationCounter.Peek())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_InvocationCounterErrorWithoutRecovery(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(invocationCounterSetRequest, decodeHexString("D8010600000064")),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.InvocationCounter = security.NewInvocationCounter(7)
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	attribute := cosem.NewCosemAttribute(enumerations.CosemInterfaceData, mustObis("0.0.42.0.0.255"), 2)
+	err := c.Set(attribute, decodeHexString("1200FF"))
+	expected, ok := client.ExpectedInvocationCounter(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(100), expected)
+	assert.Equal(t, uint32(7), settings.InvocationCounter.Peek())
+	assert.Equal(t, 0, transport.Remaining())
+}
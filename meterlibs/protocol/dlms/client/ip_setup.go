@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+var (
+	// IPv4Setup is the logical name of the IPv4 setup object
+	IPv4Setup = &cosem.Obis{A: 0, B: 0, C: 25, D: 1, E: 0, F: 255}
+	// IPv6Setup is the logical name of the IPv6 setup object
+	IPv6Setup = &cosem.Obis{A: 0, B: 0, C: 25, D: 7, E: 0, F: 255}
+)
+
+// GetIPv4Setup reads all attributes of an IPv4 setup object
+func (c *Client) GetIPv4Setup(logicalName *cosem.Obis) (*cosem.IPv4Setup, error) {
+	setup := cosem.NewIPv4Setup(logicalName)
+	err := c.getAttributes(setup,
+		cosem.IPv4SetupAttributeDLReference,
+		cosem.IPv4SetupAttributeIPAddress,
+		cosem.IPv4SetupAttributeMulticastAddresses,
+		cosem.IPv4SetupAttributeIPOptions,
+		cosem.IPv4SetupAttributeSubnetMask,
+		cosem.IPv4SetupAttributeGateway,
+		cosem.IPv4SetupAttributeUseDHCP,
+		cosem.IPv4SetupAttributePrimaryDNS,
+		cosem.IPv4SetupAttributeSecondaryDNS,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return setup, nil
+}
+
+// SetIPv4Setup writes the given attributes of an IPv4 setup object, in the
+// given order. Nothing is written when an attribute can't be encoded.
+func (c *Client) SetIPv4Setup(setup *cosem.IPv4Setup, attributes ...uint8) error {
+	return c.setAttributes(setup, attributes...)
+}
+
+// AddMulticastAddress adds a multicast address to an IPv4 setup object
+func (c *Client) AddMulticastAddress(logicalName *cosem.Obis, address net.IP) error {
+	data, err := cosem.MulticastAddressToBytes(address)
+	if err != nil {
+		return err
+	}
+	_, err = c.Action(cosem.NewIPv4Setup(logicalName).Method(cosem.IPv4SetupMethodAddMulticastAddress), data)
+	return err
+}
+
+// GetIPv6Setup reads all attributes of an IPv6 setup object
+func (c *Client) GetIPv6Setup(logicalName *cosem.Obis) (*cosem.IPv6Setup, error) {
+	setup := cosem.NewIPv6Setup(logicalName)
+	err := c.getAttributes(setup,
+		cosem.IPv6SetupAttributeDLReference,
+		cosem.IPv6SetupAttributeAddressConfigMode,
+		cosem.IPv6SetupAttributeUnicastAddresses,
+		cosem.IPv6SetupAttributeMulticastAddresses,
+		cosem.IPv6SetupAttributeGatewayAddresses,
+		cosem.IPv6SetupAttributePrimaryDNS,
+		cosem.IPv6SetupAttributeSecondaryDNS,
+		cosem.IPv6SetupAttributeTrafficClass,
+		cosem.IPv6SetupAttributeNeighborDiscoverySetup,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return setup, nil
+}
+
+// SetIPv6Setup writes the given attributes of an IPv6 setup object, in the
+// given order. Nothing is written when an attribute can't be encoded.
+func (c *Client) SetIPv6Setup(setup *cosem.IPv6Setup, attributes ...uint8) error {
+	return c.setAttributes(setup, attributes...)
+}
+
+// AddIPv6Address adds an address to one of the address lists of an IPv6 setup object
+func (c *Client) AddIPv6Address(logicalName *cosem.Obis, addressType cosem.IPv6AddressType, address net.IP) error {
+	data, err := cosem.IPv6AddressToBytes(addressType, address)
+	if err != nil {
+		return err
+	}
+	_, err = c.Action(cosem.NewIPv6Setup(logicalName).Method(cosem.IPv6SetupMethodAddAddress), data)
+	return err
+}
+
+// RemoveIPv6Address removes an address from one of the address lists of an IPv6 setup object
+func (c *Client) RemoveIPv6Address(logicalName *cosem.Obis, addressType cosem.IPv6AddressType, address net.IP) error {
+	data, err := cosem.IPv6AddressToBytes(addressType, address)
+	if err != nil {
+		return err
+	}
+	_, err = c.Action(cosem.NewIPv6Setup(logicalName).Method(cosem.IPv6SetupMethodRemoveAddress), data)
+	return err
+}
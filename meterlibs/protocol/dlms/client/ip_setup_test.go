@@ -0,0 +1,88 @@
+package client_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_SetIPv4Setup(t *testing.T) {
+	setResponse := decodeHexString("C501C100")
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C101C1002A0000190100FF0300060A000005"), setResponse),
+		testutil.Expect(decodeHexString("C101C1002A0000190100FF060006FFFFFF00"), setResponse),
+		testutil.Expect(decodeHexString("C101C1002A0000190100FF0700060A000001"), setResponse),
+		testutil.Expect(decodeHexString("C101C1002A0000190100FF08000300"), setResponse),
+		testutil.Expect(decodeHexString("C101C1002A0000190100FF09000608080808"), setResponse),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	setup := cosem.NewIPv4Setup(client.IPv4Setup)
+	setup.IPAddress = net.ParseIP("10.0.0.5")
+	setup.SubnetMask = net.IPv4(255, 255, 255, 0)
+	setup.Gateway = net.ParseIP("10.0.0.1")
+	setup.PrimaryDNS = net.ParseIP("8.8.8.8")
+
+	assert.NoError(t, c.SetIPv4Setup(setup,
+		cosem.IPv4SetupAttributeIPAddress,
+		cosem.IPv4SetupAttributeSubnetMask,
+		cosem.IPv4SetupAttributeGateway,
+		cosem.IPv4SetupAttributeUseDHCP,
+		cosem.IPv4SetupAttributePrimaryDNS,
+	))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+
+	// nothing is written when an attribute is invalid
+	setup.SecondaryDNS = net.ParseIP("2001:db8::1")
+	assert.Error(t, c.SetIPv4Setup(setup, cosem.IPv4SetupAttributePrimaryDNS, cosem.IPv4SetupAttributeSecondaryDNS))
+	assert.NoError(t, transport.Err())
+}
+
+func TestClient_AddIPv6Address(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C301C100300000190700FF010102021600091020010DB8000000000000000000000001"), decodeHexString("C701C10000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	assert.NoError(t, c.AddIPv6Address(client.IPv6Setup, cosem.IPv6AddressTypeUnicast, net.ParseIP("2001:db8::1")))
+	assert.Error(t, c.AddIPv6Address(client.IPv6Setup, cosem.IPv6AddressTypeUnicast, net.ParseIP("10.0.0.5")))
+	assert.NoError(t, transport.Err())
+}
+
+func TestIPv6Setup_Attributes(t *testing.T) {
+	setup := cosem.NewIPv6Setup(client.IPv6Setup)
+	setup.UnicastAddresses = []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("fe80::1")}
+	setup.NeighborDiscoverySetup = []*cosem.NeighborDiscoverySetup{{MaxRetry: 3, RetryWaitTime: 10, SendPeriod: 3600}}
+
+	for _, attribute := range []uint8{
+		cosem.IPv6SetupAttributeAddressConfigMode,
+		cosem.IPv6SetupAttributeUnicastAddresses,
+		cosem.IPv6SetupAttributePrimaryDNS,
+		cosem.IPv6SetupAttributeNeighborDiscoverySetup,
+	} {
+		data, err := setup.ToAttribute(attribute)
+		require.NoError(t, err)
+
+		decoded := cosem.NewIPv6Setup(client.IPv6Setup)
+		require.NoError(t, decoded.FromAttribute(attribute, data))
+		again, err := decoded.ToAttribute(attribute)
+		require.NoError(t, err)
+		assert.Equal(t, data, again, "attribute %d", attribute)
+	}
+
+	data, err := setup.ToAttribute(cosem.IPv6SetupAttributeNeighborDiscoverySetup)
+	require.NoError(t, err)
+	assert.Equal(t, decodeHexString("01010203110312000A120E10"), data)
+}
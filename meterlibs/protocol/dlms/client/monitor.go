@@ -0,0 +1,63 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// GetRegisterMonitor reads the thresholds, the monitored value and the
+// actions of a Register monitor
+func (c *Client) GetRegisterMonitor(logicalName *cosem.Obis) (*cosem.RegisterMonitor, error) {
+	monitor := cosem.NewRegisterMonitor(logicalName)
+	err := c.getAttributes(monitor,
+		cosem.RegisterMonitorAttributeThresholds,
+		cosem.RegisterMonitorAttributeMonitoredValue,
+		cosem.RegisterMonitorAttributeActions,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return monitor, nil
+}
+
+// SetRegisterMonitor writes the monitored value, the thresholds and the
+// actions of a Register monitor. There must be one action set per
+// threshold, it is checked before anything is written.
+func (c *Client) SetRegisterMonitor(monitor *cosem.RegisterMonitor) error {
+	if err := monitor.Validate(); err != nil {
+		return err
+	}
+	return c.setAttributes(monitor,
+		cosem.RegisterMonitorAttributeMonitoredValue,
+		cosem.RegisterMonitorAttributeThresholds,
+		cosem.RegisterMonitorAttributeActions,
+	)
+}
+
+// GetParameterMonitor reads the last changed parameter, its capture time and
+// the list of monitored parameters of a Parameter monitor
+func (c *Client) GetParameterMonitor(logicalName *cosem.Obis) (*cosem.ParameterMonitor, error) {
+	monitor := cosem.NewParameterMonitor(logicalName)
+	err := c.getAttributes(monitor,
+		cosem.ParameterMonitorAttributeChangedParameter,
+		cosem.ParameterMonitorAttributeCaptureTime,
+		cosem.ParameterMonitorAttributeParameterList,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return monitor, nil
+}
+
+// AddMonitoredParameter adds a parameter to the list of a Parameter monitor
+func (c *Client) AddMonitoredParameter(logicalName *cosem.Obis, parameter *cosem.ValueDefinition) error {
+	monitor := cosem.NewParameterMonitor(logicalName)
+	_, err := c.Action(monitor.Method(cosem.ParameterMonitorMethodAddParameter), parameter.ToBytes())
+	return err
+}
+
+// DeleteMonitoredParameter removes a parameter from the list of a Parameter monitor
+func (c *Client) DeleteMonitoredParameter(logicalName *cosem.Obis, parameter *cosem.ValueDefinition) error {
+	monitor := cosem.NewParameterMonitor(logicalName)
+	_, err := c.Action(monitor.Method(cosem.ParameterMonitorMethodDeleteParameter), parameter.ToBytes())
+	return err
+}
@@ -0,0 +1,67 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_SetRegisterMonitor(t *testing.T) {
+	setResponse := decodeHexString("C501C100")
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C101C100150000100100FF0300"+"0203120003090601002007"+"00FF0F02"), setResponse),
+		testutil.Expect(decodeHexString("C101C100150000100100FF0200"+"01011200FD"), setResponse),
+		testutil.Expect(decodeHexString("C101C100150000100100FF0400"+"0101"+"0202"+
+			"0202090600000A0064FF120001"+"0202090600000A0064FF120002"), setResponse),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	script := mustObis("0.0.10.0.100.255")
+	monitor := cosem.NewRegisterMonitor(mustObis("0.0.16.1.0.255"))
+	monitor.MonitoredValue = cosem.NewValueDefinition(enumerations.CosemInterfaceRegister, mustObis("1.0.32.7.0.255"), 2)
+	monitor.Thresholds = [][]byte{decodeHexString("1200FD")}
+	assert.Error(t, c.SetRegisterMonitor(monitor))
+
+	monitor.Actions = []*cosem.ActionSet{{
+		Up:   cosem.NewScriptReference(script, 1),
+		Down: cosem.NewScriptReference(script, 2),
+	}}
+	require.NoError(t, c.SetRegisterMonitor(monitor))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_GetParameterMonitor(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C100410000100200FF0200"),
+			decodeHexString("C401C100"+"0204"+"120008"+"09060000010000FF"+"0F02"+"090C07EA0A11FF0C000000800000")),
+		testutil.Expect(decodeHexString("C001C100410000100200FF0300"),
+			decodeHexString("C401C100090C07EA0A110608000000000000")),
+		testutil.Expect(decodeHexString("C001C100410000100200FF0400"),
+			decodeHexString("C401C100"+"0101"+"0203"+"120008"+"09060000010000FF"+"0F02")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	monitor, err := c.GetParameterMonitor(mustObis("0.0.16.2.0.255"))
+	require.NoError(t, err)
+	require.NotNil(t, monitor.ChangedParameter)
+	assert.Equal(t, enumerations.CosemInterfaceClock, monitor.ChangedParameter.Parameter.Interface)
+	assert.Equal(t, *mustObis("0.0.1.0.0.255"), *monitor.ChangedParameter.Parameter.LogicalName)
+	assert.Equal(t, decodeHexString("090C07EA0A11FF0C000000800000"), monitor.ChangedParameter.Value)
+	assert.Equal(t, 2026, monitor.CaptureTime.Year())
+	require.Len(t, monitor.ParameterList, 1)
+	assert.Equal(t, int8(2), monitor.ParameterList[0].AttributeIndex)
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// NotificationBufferSize is the capacity of the Notifications channel
+const NotificationBufferSize = 16
+
+// NotificationKind is the APDU a notification was received in
+type NotificationKind int
+
+const (
+	NotificationData NotificationKind = iota
+	NotificationEvent
+)
+
+// String implements fmt.Stringer
+func (k NotificationKind) String() string {
+	switch k {
+	case NotificationData:
+		return "data"
+	case NotificationEvent:
+		return "event"
+	default:
+		return fmt.Sprintf("NotificationKind(%d)", int(k))
+	}
+}
+
+// DecodedNotification is an unsolicited DataNotification or EventNotification
+// sent by the server
+type DecodedNotification struct {
+	Kind         NotificationKind
+	LongInvokeID uint32
+	// Timestamp is the date-time of the notification, nil when the server
+	// didn't send one
+	Timestamp *time.Time
+	// Attribute is the attribute an event notification reports, nil for data
+	// notifications
+	Attribute *cosem.CosemAttribute
+	// Body is the A-XDR encoded value
+	Body []byte
+	// Value is the decoded body, nil when it can't be decoded
+	Value interface{}
+}
+
+// DecodeNotification decodes the body of a DataNotification or an
+// EventNotification
+func DecodeNotification(apdu xdlms.Apdu) (*DecodedNotification, error) {
+	result := &DecodedNotification{}
+
+	switch n := apdu.(type) {
+	case *xdlms.DataNotification:
+		result.Kind = NotificationData
+		result.LongInvokeID = n.LongInvokeIDAndPriority.LongInvokeID
+		result.Timestamp = n.DateTime
+		result.Body = n.Body
+	case *xdlms.EventNotification:
+		result.Kind = NotificationEvent
+		result.LongInvokeID = n.LongInvokeIDAndPriority.LongInvokeID
+		result.Timestamp = n.DateTime
+		if len(n.Body) < cosem.CosemAttributeLength {
+			return nil, fmt.Errorf("event notification body is too short for the attribute descriptor: %x", n.Body)
+		}
+		attribute, err := (&cosem.CosemAttribute{}).FromBytes(n.Body[:cosem.CosemAttributeLength])
+		if err != nil {
+			return nil, fmt.Errorf("event notification attribute: %w", err)
+		}
+		result.Attribute = attribute
+		result.Body = n.Body[cosem.CosemAttributeLength:]
+	default:
+		return nil, fmt.Errorf("%s is not a notification", apdu)
+	}
+
+	if value, err := encoding.DecodeValue(result.Body); err == nil {
+		result.Value = value
+	}
+	return result, nil
+}
+
+// Notifications returns the channel receiving the notifications the server
+// sends, both while the client waits for a response and during Listen.
+// Notifications that don't fit in the channel are dropped.
+func (c *Client) Notifications() <-chan *DecodedNotification {
+	c.subscribe.Do(func() {
+		c.notifications = make(chan *DecodedNotification, NotificationBufferSize)
+		c.dispatcher.Subscribe(c.notifications)
+	})
+	return c.notifications
+}
+
+// Listen waits for unsolicited notifications on the open association during
+// the given duration and sends them to the Notifications channel
+func (c *Client) Listen(duration time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if state := c.state.CurrentState(); state != dlms.Ready {
+		return exceptions.NewLocalDlmsProtocolError(
+			fmt.Sprintf("notifications are only received on an open association, state=%s", state))
+	}
+
+	timeout := time.After(duration)
+	for {
+		select {
+		case received, ok := <-c.dc:
+			if !ok {
+				return exceptions.NewCommunicationError("reception channel closed")
+			}
+
+			notification, err := c.receive(received)
+			if err != nil {
+				return fmt.Errorf("failed to parse notification %x: %w", received, err)
+			}
+			if notification == nil {
+				continue
+			}
+
+			if c.logger != nil {
+				c.logger.Printf("received %s", notification)
+			}
+
+			if !isNotification(notification) {
+				return exceptions.NewLocalDlmsProtocolError(
+					fmt.Sprintf("received %s while listening for notifications", notification))
+			}
+			if err = c.state.ProcessApdu(notification); err != nil {
+				return err
+			}
+			if !c.dispatcher.Route(notification) && c.logger != nil {
+				c.logger.Printf("dropped %s, no subscriber is ready", notification)
+			}
+		case <-timeout:
+			return nil
+		}
+	}
+}
+
+// DeduplicateNotifications drops the notifications with one of the last
+// window long invoke ids received, for servers repeating their pushes
+func (c *Client) DeduplicateNotifications(window int) error {
+	filter, err := xdlms.NewLongInvokeIDFilter(window)
+	if err != nil {
+		return err
+	}
+	c.dispatcher.Deduplicate(filter)
+	return nil
+}
@@ -0,0 +1,76 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// eventNotification reports the value 255 of the attribute 2 of the clock
+var eventNotification = decodeHexString("C2400000020000080000010000FF021200FF")
+
+func TestClient_Listen(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse, dataNotification, eventNotification),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	notifications := c.Notifications()
+
+	assert.Error(t, c.Listen(time.Millisecond))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+	_, err := c.Get(clockTime, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, notifications)
+
+	assert.NoError(t, c.Listen(20*time.Millisecond))
+	assert.Equal(t, dlms.Ready, c.State().CurrentState())
+
+	if assert.Len(t, notifications, 2) {
+		data := <-notifications
+		assert.Equal(t, client.NotificationData, data.Kind)
+		assert.Nil(t, data.Timestamp)
+		assert.Nil(t, data.Attribute)
+		assert.Equal(t, uint16(255), data.Value)
+
+		event := <-notifications
+		assert.Equal(t, client.NotificationEvent, event.Kind)
+		assert.Equal(t, uint32(2), event.LongInvokeID)
+		assert.Equal(t, clockTime.Interface, event.Attribute.Interface)
+		assert.Equal(t, clockTime.Instance.ToBytes(), event.Attribute.Instance.ToBytes())
+		assert.Equal(t, uint8(2), event.Attribute.Attribute)
+		assert.Equal(t, []byte{0x12, 0x00, 0xFF}, event.Body)
+		assert.Equal(t, uint16(255), event.Value)
+	}
+}
+
+func TestDecodeNotification(t *testing.T) {
+	timestamp := time.Date(2022, 10, 17, 12, 0, 0, 0, time.UTC)
+	decoded, err := client.DecodeNotification(xdlms.NewDataNotification(
+		xdlms.NewLongInvokeIdAndPriority(7, false, false, false, false), &timestamp, []byte{0x09, 0x02, 0xAB, 0xCD}))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(7), decoded.LongInvokeID)
+	assert.True(t, timestamp.Equal(*decoded.Timestamp))
+	assert.Equal(t, []byte{0xAB, 0xCD}, decoded.Value)
+
+	decoded, err = client.DecodeNotification(xdlms.NewDataNotification(
+		xdlms.NewLongInvokeIdAndPriority(7, false, false, false, false), nil, []byte{0x16}))
+	assert.NoError(t, err)
+	assert.Nil(t, decoded.Value)
+
+	_, err = client.DecodeNotification(xdlms.NewEventNotification(
+		xdlms.NewLongInvokeIdAndPriority(7, false, false, false, false), nil, []byte{0x00, 0x08}))
+	assert.Error(t, err)
+
+	_, err = client.DecodeNotification(xdlms.NewExceptionResponse(
+		enumerations.StateExceptionServiceNotAllowed, enumerations.ServiceExceptionOtherReason, nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// CurrentAssociation is the logical name of the Association LN object of the
+// current association
+var CurrentAssociation = &cosem.Obis{A: 0, B: 0, C: 40, D: 0, E: 0, F: 255}
+
+// GetObjectList reads the object list of the current association. The access
+// modes are decoded for the version of the association object found in the list.
+// The list is kept to check the access rights with Settings.CheckAccessRights
+// and to resolve the attributes of the objects from their class version.
+func (c *Client) GetObjectList() (objectList []*cosem.AssociationObjectListItem, err error) {
+	defer func() {
+		if err == nil {
+			c.mutex.Lock()
+			c.setObjectList(objectList)
+			c.mutex.Unlock()
+		}
+	}()
+
+	data, err := c.Get(cosem.NewCosemAttribute(enumerations.CosemInterfaceAssociationLN, CurrentAssociation, 2), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	objectList, err = cosem.ParseObjectList(data, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	association := cosem.FindObjectListItem(objectList, CurrentAssociation)
+	if association != nil && association.Version < 3 {
+		return cosem.ParseObjectList(data, association.Version)
+	}
+	return objectList, nil
+}
@@ -0,0 +1,37 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func paddedAssociationResponse() []byte {
+	return append(append([]byte{}, testutil.AssociationResponse...), 0x00, 0x00)
+}
+
+func TestClient_StrictParsing(t *testing.T) {
+	transport := testutil.NewScriptedTransport(associate(paddedAssociationResponse()))
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+
+	assert.Error(t, c.Associate())
+	assert.Empty(t, c.Deviations())
+}
+
+func TestClient_LenientParsing(t *testing.T) {
+	transport := testutil.NewScriptedTransport(associate(paddedAssociationResponse()))
+	settings := client.NewSettings(16, 1)
+	settings.ParsingMode = xdlms.ParsingLenient
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+
+	require.NoError(t, c.Associate())
+	deviations := c.Deviations()
+	require.Len(t, deviations, 1)
+	assert.Equal(t, "APDU 0x61: 2 bytes after the end of the APDU", deviations[0].String())
+}
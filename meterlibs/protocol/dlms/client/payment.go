@@ -0,0 +1,177 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// Logical names of the first Account, Credit and Charge objects, the others
+// have the next values of E
+var (
+	Account = &cosem.Obis{A: 0, B: 0, C: 19, D: 0, E: 0, F: 255}
+	Credit  = &cosem.Obis{A: 0, B: 0, C: 19, D: 10, E: 0, F: 255}
+	Charge  = &cosem.Obis{A: 0, B: 0, C: 19, D: 20, E: 0, F: 255}
+)
+
+// GetAccount reads the status, the balance and the configuration of an Account
+func (c *Client) GetAccount(logicalName *cosem.Obis) (*cosem.Account, error) {
+	account := cosem.NewAccount(logicalName)
+	err := c.getAttributes(account,
+		cosem.AccountAttributeModeAndStatus,
+		cosem.AccountAttributeCurrentCreditInUse,
+		cosem.AccountAttributeCurrentCreditStatus,
+		cosem.AccountAttributeAvailableCredit,
+		cosem.AccountAttributeAmountToClear,
+		cosem.AccountAttributeClearanceThreshold,
+		cosem.AccountAttributeAggregatedDebt,
+		cosem.AccountAttributeCreditReferenceList,
+		cosem.AccountAttributeChargeReferenceList,
+		cosem.AccountAttributeCreditChargeConfiguration,
+		cosem.AccountAttributeTokenGatewayConfiguration,
+		cosem.AccountAttributeActivationTime,
+		cosem.AccountAttributeClosureTime,
+		cosem.AccountAttributeCurrency,
+		cosem.AccountAttributeLowCreditThreshold,
+		cosem.AccountAttributeNextCreditAvailableThreshold,
+		cosem.AccountAttributeMaxProvision,
+		cosem.AccountAttributeMaxProvisionPeriod,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ActivateAccount moves an Account from new to active
+func (c *Client) ActivateAccount(logicalName *cosem.Obis) error {
+	return c.accountAction(logicalName, cosem.AccountMethodActivateAccount)
+}
+
+// CloseAccount moves an Account to closed
+func (c *Client) CloseAccount(logicalName *cosem.Obis) error {
+	return c.accountAction(logicalName, cosem.AccountMethodCloseAccount)
+}
+
+// ResetAccount moves an Account back to new and clears its balance
+func (c *Client) ResetAccount(logicalName *cosem.Obis) error {
+	return c.accountAction(logicalName, cosem.AccountMethodResetAccount)
+}
+
+func (c *Client) accountAction(logicalName *cosem.Obis, method uint8) error {
+	account := cosem.NewAccount(logicalName)
+	_, err := c.Action(account.Method(method), cosem.IntegerZeroToBytes())
+	return err
+}
+
+// GetCredit reads the amount, the status and the configuration of a Credit
+func (c *Client) GetCredit(logicalName *cosem.Obis) (*cosem.Credit, error) {
+	credit := cosem.NewCredit(logicalName)
+	err := c.getAttributes(credit,
+		cosem.CreditAttributeCurrentCreditAmount,
+		cosem.CreditAttributeType,
+		cosem.CreditAttributePriority,
+		cosem.CreditAttributeWarningThreshold,
+		cosem.CreditAttributeLimit,
+		cosem.CreditAttributeConfiguration,
+		cosem.CreditAttributeStatus,
+		cosem.CreditAttributePresetCreditAmount,
+		cosem.CreditAttributeCreditAvailableThreshold,
+		cosem.CreditAttributePeriod,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
+
+// GetCreditAmount reads the current amount of a Credit
+func (c *Client) GetCreditAmount(logicalName *cosem.Obis) (int32, error) {
+	credit := cosem.NewCredit(logicalName)
+	if err := c.getAttributes(credit, cosem.CreditAttributeCurrentCreditAmount); err != nil {
+		return 0, err
+	}
+	return credit.CurrentCreditAmount, nil
+}
+
+// UpdateCreditAmount adds an amount, possibly negative, to the current
+// amount of a Credit
+func (c *Client) UpdateCreditAmount(logicalName *cosem.Obis, amount int32) error {
+	credit := cosem.NewCredit(logicalName)
+	_, err := c.Action(credit.Method(cosem.CreditMethodUpdateAmount), cosem.AmountToBytes(amount))
+	return err
+}
+
+// SetCreditAmount sets the current amount of a Credit
+func (c *Client) SetCreditAmount(logicalName *cosem.Obis, amount int32) error {
+	credit := cosem.NewCredit(logicalName)
+	_, err := c.Action(credit.Method(cosem.CreditMethodSetAmountToValue), cosem.AmountToBytes(amount))
+	return err
+}
+
+// InvokeCredit selects a selectable Credit, e.g. the emergency credit
+func (c *Client) InvokeCredit(logicalName *cosem.Obis) error {
+	credit := cosem.NewCredit(logicalName)
+	_, err := c.Action(credit.Method(cosem.CreditMethodInvokeCredit), cosem.IntegerZeroToBytes())
+	return err
+}
+
+// GetCharge reads the unit charges, the collection state and the
+// configuration of a Charge
+func (c *Client) GetCharge(logicalName *cosem.Obis) (*cosem.Charge, error) {
+	charge := cosem.NewCharge(logicalName)
+	err := c.getAttributes(charge,
+		cosem.ChargeAttributeTotalAmountPaid,
+		cosem.ChargeAttributeChargeType,
+		cosem.ChargeAttributePriority,
+		cosem.ChargeAttributeUnitChargeActive,
+		cosem.ChargeAttributeUnitChargePassive,
+		cosem.ChargeAttributeActivationTime,
+		cosem.ChargeAttributePeriod,
+		cosem.ChargeAttributeConfiguration,
+		cosem.ChargeAttributeLastCollectionTime,
+		cosem.ChargeAttributeLastCollectionAmount,
+		cosem.ChargeAttributeTotalAmountRemaining,
+		cosem.ChargeAttributeProportion,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+// UpdateUnitCharge writes the passive unit charge of a Charge, it is used
+// once ActivatePassiveUnitCharge is called or the activation time is reached
+func (c *Client) UpdateUnitCharge(logicalName *cosem.Obis, unitCharge *cosem.UnitCharge) error {
+	charge := cosem.NewCharge(logicalName)
+	_, err := c.Action(charge.Method(cosem.ChargeMethodUpdateUnitCharge), unitCharge.ToBytes())
+	return err
+}
+
+// ActivatePassiveUnitCharge copies the passive unit charge of a Charge to
+// the active one
+func (c *Client) ActivatePassiveUnitCharge(logicalName *cosem.Obis) error {
+	charge := cosem.NewCharge(logicalName)
+	_, err := c.Action(charge.Method(cosem.ChargeMethodActivatePassiveUnitCharge), cosem.IntegerZeroToBytes())
+	return err
+}
+
+// Collect collects a Charge from the credits now
+func (c *Client) Collect(logicalName *cosem.Obis) error {
+	charge := cosem.NewCharge(logicalName)
+	_, err := c.Action(charge.Method(cosem.ChargeMethodCollect), cosem.IntegerZeroToBytes())
+	return err
+}
+
+// UpdateTotalAmountRemaining adds an amount, possibly negative, to the
+// amount remaining to be collected by a Charge
+func (c *Client) UpdateTotalAmountRemaining(logicalName *cosem.Obis, amount int32) error {
+	charge := cosem.NewCharge(logicalName)
+	_, err := c.Action(charge.Method(cosem.ChargeMethodUpdateTotalAmountRemaining), cosem.AmountToBytes(amount))
+	return err
+}
+
+// SetTotalAmountRemaining sets the amount remaining to be collected by a Charge
+func (c *Client) SetTotalAmountRemaining(logicalName *cosem.Obis, amount int32) error {
+	charge := cosem.NewCharge(logicalName)
+	_, err := c.Action(charge.Method(cosem.ChargeMethodSetTotalAmountRemaining), cosem.AmountToBytes(amount))
+	return err
+}
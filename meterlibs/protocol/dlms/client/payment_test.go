@@ -0,0 +1,33 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_PaymentMethods(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C301C1006F0000130000FF0101"+"0F00"),
+			decodeHexString("C701C10000")),
+		testutil.Expect(decodeHexString("C301C100700000130A00FF0101"+"05FFFFFFFB"),
+			decodeHexString("C701C10000")),
+		testutil.Expect(decodeHexString("C001C100700000130A00FF0200"),
+			decodeHexString("C401C100"+"05000003E3")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	require.NoError(t, c.ActivateAccount(client.Account))
+	require.NoError(t, c.UpdateCreditAmount(client.Credit, -5))
+	amount, err := c.GetCreditAmount(client.Credit)
+	require.NoError(t, err)
+	assert.Equal(t, int32(995), amount)
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// PublicClientAddress is the address of the public client, which associates
+// without authentication and may read a few objects of every meter
+const PublicClientAddress = 16
+
+// LogicalDeviceName is the logical name of the Data holding the COSEM logical
+// device name
+var LogicalDeviceName = &cosem.Obis{A: 0, B: 0, C: 42, D: 0, E: 0, F: 255}
+
+// PingResult is the answer of a meter to Ping
+type PingResult struct {
+	// LogicalDeviceName is nil when the meter only gave its time
+	LogicalDeviceName []byte
+	// Time is the time of the meter, zero when the logical device name was read
+	Time time.Time
+	// Latency is the duration of the exchange, from connect to release
+	Latency time.Duration
+}
+
+// Ping checks that a meter answers, e.g. for the reachability monitoring of
+// a network management system. It connects the transport, associates as the
+// public client without authentication and reads the COSEM logical device
+// name, or the time of the clock when the name can't be read. The
+// association is then released and the transport disconnected, it is not
+// closed. No credentials are needed.
+func Ping(transport dlms.Transport, serverAddress int, timeout time.Duration) (*PingResult, error) {
+	settings := NewSettings(PublicClientAddress, serverAddress)
+	settings.Timeout = timeout
+	c := New(transport, settings)
+
+	start := time.Now()
+	if err := c.Connect(); err != nil {
+		return nil, err
+	}
+	defer c.Disconnect()
+	if err := c.Associate(); err != nil {
+		return nil, err
+	}
+
+	result := &PingResult{}
+	name := cosem.NewCosemAttribute(enumerations.CosemInterfaceData, LogicalDeviceName, 2)
+	value, err := c.GetValue(name, nil)
+	if err == nil {
+		bytes, ok := value.([]byte)
+		if !ok {
+			err = fmt.Errorf("logical device name is not an octet-string: %T", value)
+		}
+		result.LogicalDeviceName = bytes
+	}
+	if err != nil {
+		data, clockErr := c.Get(cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, Clock, cosem.ClockAttributeTime), nil)
+		if clockErr != nil {
+			return nil, err
+		}
+		if result.Time, clockErr = cosem.ParseClockTime(data); clockErr != nil {
+			return nil, clockErr
+		}
+	}
+
+	if err = c.Release(); err != nil {
+		return nil, err
+	}
+	result.Latency = time.Since(start)
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package client_test
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+var logicalDeviceNameRequest = decodeHexString("C001C1000100002A0000FF0200")
+
+func TestPing(t *testing.T) {
+	name := "LGZ1234567890123"
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(logicalDeviceNameRequest, decodeHexString("C401C100"+"0910"+hex.EncodeToString([]byte(name)))),
+		testutil.Expect(testutil.ReleaseRequest, testutil.ReleaseResponse),
+	)
+
+	result, err := client.Ping(transport, 1, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(name), result.LogicalDeviceName)
+	assert.True(t, result.Time.IsZero())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+	assert.False(t, transport.IsConnected())
+
+	clientAddress, serverAddress := transport.Address()
+	assert.Equal(t, client.PublicClientAddress, clientAddress)
+	assert.Equal(t, 1, serverAddress)
+}
+
+func TestPing_Clock(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		// read-write-denied
+		testutil.Expect(logicalDeviceNameRequest, decodeHexString("C401C10103")),
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+		testutil.Expect(testutil.ReleaseRequest, testutil.ReleaseResponse),
+	)
+
+	result, err := client.Ping(transport, 1, time.Second)
+	require.NoError(t, err)
+	assert.Nil(t, result.LogicalDeviceName)
+	assert.Equal(t, time.Date(2022, 10, 17, 11, 0, 0, 0, time.UTC), result.Time.UTC())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
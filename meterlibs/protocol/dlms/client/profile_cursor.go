@@ -0,0 +1,203 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// ProfileCursorStore keeps the capture time of the last entry read from each
+// profile of each meter, so that the next read only asks for newer entries
+type ProfileCursorStore interface {
+	Load(meter string, profile *cosem.Obis) (time.Time, bool)
+	Save(meter string, profile *cosem.Obis, last time.Time)
+}
+
+// MemoryProfileCursorStore is a ProfileCursorStore in memory
+type MemoryProfileCursorStore struct {
+	mutex   sync.Mutex
+	cursors map[string]time.Time
+}
+
+// NewMemoryProfileCursorStore creates an empty MemoryProfileCursorStore
+func NewMemoryProfileCursorStore() *MemoryProfileCursorStore {
+	return &MemoryProfileCursorStore{
+		cursors: make(map[string]time.Time),
+	}
+}
+
+// Load returns the capture time of the last entry read from the profile
+func (s *MemoryProfileCursorStore) Load(meter string, profile *cosem.Obis) (time.Time, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	last, ok := s.cursors[profileCursorKey(meter, profile)]
+	return last, ok
+}
+
+// Save records the capture time of the last entry read from the profile
+func (s *MemoryProfileCursorStore) Save(meter string, profile *cosem.Obis, last time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cursors[profileCursorKey(meter, profile)] = last
+}
+
+func profileCursorKey(meter string, profile *cosem.Obis) string {
+	return meter + "/" + profile.String()
+}
+
+// ProfileCursor reads the buffer of a profile generic incrementally: each
+// read asks for the entries captured after the last entry read before
+type ProfileCursor struct {
+	Store   ProfileCursorStore
+	Meter   string
+	Profile *cosem.Obis
+	// RestrictingObject is the capture object the range applies to, the
+	// time of the clock by default
+	RestrictingObject *cosem.CaptureObject
+	// Column is the index of the capture time in the entries
+	Column int
+	// Start is the beginning of the first read, when the store has no
+	// entry for the profile
+	Start time.Time
+}
+
+// NewProfileCursor creates a ProfileCursor restricting the reads on the clock
+// time, found in the first column of the entries
+func NewProfileCursor(store ProfileCursorStore, meter string, profile *cosem.Obis, start time.Time) *ProfileCursor {
+	clock := cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, &cosem.Obis{A: 0, B: 0, C: 1, D: 0, E: 0, F: 255}, 2)
+	return &ProfileCursor{
+		Store:             store,
+		Meter:             meter,
+		Profile:           profile,
+		RestrictingObject: cosem.NewCaptureObject(clock, 0),
+		Column:            0,
+		Start:             start,
+	}
+}
+
+// Range returns the range of the entries captured after the last entry read,
+// up to to. The bounds of a range are included and capture times have a
+// resolution of a second, so the range starts a second after the last entry.
+func (p *ProfileCursor) Range(to time.Time) *cosem.RangeDescriptor {
+	from := p.Start
+	if last, ok := p.Store.Load(p.Meter, p.Profile); ok {
+		from = last.Add(time.Second)
+	}
+	return cosem.NewRangeDescriptor(p.RestrictingObject, from, to, nil)
+}
+
+// Advance records the capture time of the last entry read, times before the
+// recorded one are ignored
+func (p *ProfileCursor) Advance(last time.Time) {
+	if recorded, ok := p.Store.Load(p.Meter, p.Profile); ok && !last.After(recorded) {
+		return
+	}
+	p.Store.Save(p.Meter, p.Profile, last)
+}
+
+// ProfileRow is an entry of a profile buffer with the capture time decoded,
+// so that the intervals captured with a suspect clock can be flagged
+type ProfileRow struct {
+	// Values are the columns of the entry decoded as by GetValue
+	Values []interface{}
+	// CaptureTime is zero when the meter sent a null capture time
+	CaptureTime time.Time
+	// ClockStatus is the clock status of the capture time, nil when it is
+	// null or not specified
+	ClockStatus *dlmsdata.ClockStatus
+}
+
+// Suspect tells whether the clock status flags the capture time as invalid,
+// doubtful or measured from a different clock base
+func (r *ProfileRow) Suspect() bool {
+	return r.ClockStatus != nil &&
+		(r.ClockStatus.Invalid || r.ClockStatus.Doubtful || r.ClockStatus.DifferentBase || r.ClockStatus.InvalidStatus)
+}
+
+// ProfileRows decodes the capture time in the given column of the entries of
+// a profile buffer decoded by GetValue
+func ProfileRows(entries []interface{}, column int) ([]*ProfileRow, error) {
+	rows := make([]*ProfileRow, 0, len(entries))
+	for i, entry := range entries {
+		columns, ok := entry.([]interface{})
+		if !ok || column >= len(columns) {
+			return nil, fmt.Errorf("entry %d has no column %d", i, column)
+		}
+		row := &ProfileRow{Values: columns}
+		rows = append(rows, row)
+
+		captured, ok := columns[column].([]byte)
+		if !ok {
+			continue
+		}
+		captureTime, status, err := dlmsdata.DateTimeFromBytes(captured)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: capture time: %w", i, err)
+		}
+		row.CaptureTime = captureTime
+		if captured[len(captured)-1] != dlmsdata.ClockStatusNotSpecified {
+			row.ClockStatus = status
+		}
+	}
+	return rows, nil
+}
+
+// ReadProfile reads the entries of the profile of the cursor captured since
+// the last read, up to to, and advances the cursor to the latest capture
+// time. Each entry is a []interface{} decoded as by GetValue. Entries whose
+// capture time is null, as sent by meters compressing the buffer, don't move
+// the cursor.
+func (c *Client) ReadProfile(cursor *ProfileCursor, to time.Time) ([]interface{}, error) {
+	rows, err := c.ReadProfileRows(cursor, to)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]interface{}, len(rows))
+	for i, row := range rows {
+		entries[i] = row.Values
+	}
+	return entries, nil
+}
+
+// ReadProfileRows reads the profile like ReadProfile and returns the entries
+// with their capture time and its clock status
+func (c *Client) ReadProfileRows(cursor *ProfileCursor, to time.Time) ([]*ProfileRow, error) {
+	attribute, err := c.resolveAttribute(enumerations.CosemInterfaceProfileGeneric, cursor.Profile, "buffer", ProfileBufferAttribute)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.Get(attribute, cursor.Range(to))
+	if err != nil {
+		return nil, err
+	}
+	value, err := encoding.DecodeValue(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", cursor.Profile, err)
+	}
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("buffer of %s is not an array: %T", cursor.Profile, value)
+	}
+	rows, err := ProfileRows(entries, cursor.Column)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cursor.Profile, err)
+	}
+
+	var latest time.Time
+	for _, row := range rows {
+		if row.CaptureTime.After(latest) {
+			latest = row.CaptureTime
+		}
+	}
+	if !latest.IsZero() {
+		cursor.Advance(latest)
+	}
+	return rows, nil
+}
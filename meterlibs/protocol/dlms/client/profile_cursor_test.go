@@ -0,0 +1,86 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func profileRequest(t *testing.T, cursor *client.ProfileCursor, to time.Time) []byte {
+	request, err := xdlms.NewGetRequestNormal(profileBuffer, &xdlms.InvokeIdAndPriority{
+		InvokeID: 1, Confirmed: true, HighPriority: true,
+	}, cursor.Range(to)).ToBytes()
+	require.NoError(t, err)
+	return request
+}
+
+func TestClient_ReadProfile(t *testing.T) {
+	store := client.NewMemoryProfileCursorStore()
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC)
+	cursor := client.NewProfileCursor(store, "LGZ12345678", profileBuffer.Instance, start)
+	first := profileRequest(t, cursor, to)
+
+	// two entries, 00:15 and 00:30
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(first, decodeHexString("C401C100"+"0102"+
+			"0202090C07EA0A1106000F0000800000"+"0600000001"+
+			"0202090C07EA0A1106001E0000800000"+"0600000002")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	entries, err := c.ReadProfile(cursor, to)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.NoError(t, transport.Err())
+
+	last, ok := store.Load("LGZ12345678", profileBuffer.Instance)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 30, 0, 0, time.UTC), last)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 30, 1, 0, time.UTC), cursor.Range(to).FromValue)
+
+	// an older capture time doesn't move the cursor back
+	cursor.Advance(start)
+	last, _ = store.Load("LGZ12345678", profileBuffer.Instance)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 30, 0, 0, time.UTC), last)
+}
+
+func TestProfileRows(t *testing.T) {
+	entries := []interface{}{
+		[]interface{}{decodeHexString("07EA0A1106000F0000800000"), uint32(1)},
+		// doubtful, e.g. after a power failure
+		[]interface{}{decodeHexString("07EA0A1106001E0000800002"), uint32(2)},
+		[]interface{}{decodeHexString("07EA0A1106002D00008000FF"), uint32(3)},
+		// null capture time of a compressed buffer
+		[]interface{}{nil, uint32(4)},
+	}
+	rows, err := client.ProfileRows(entries, 0)
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 15, 0, 0, time.UTC), rows[0].CaptureTime)
+	require.NotNil(t, rows[0].ClockStatus)
+	assert.False(t, rows[0].Suspect())
+
+	require.NotNil(t, rows[1].ClockStatus)
+	assert.True(t, rows[1].ClockStatus.Doubtful)
+	assert.True(t, rows[1].Suspect())
+
+	assert.Nil(t, rows[2].ClockStatus)
+	assert.False(t, rows[2].Suspect())
+
+	assert.True(t, rows[3].CaptureTime.IsZero())
+	assert.Nil(t, rows[3].ClockStatus)
+	assert.Equal(t, uint32(4), rows[3].Values[1])
+
+	_, err = client.ProfileRows(entries, 2)
+	assert.Error(t, err)
+}
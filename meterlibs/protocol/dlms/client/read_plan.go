@@ -0,0 +1,171 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// ProfileBufferAttribute is the buffer attribute of the profile generic interface class
+const ProfileBufferAttribute uint8 = 2
+
+const (
+	// getHeaderSize is the tag, the request or response type and the invoke id
+	getHeaderSize = 3
+	// getResultHeaderSize is the choice of a GET response, or of a result of a
+	// GET response with list
+	getResultHeaderSize = 1
+	// blockHeaderSize is the last block flag, the block number, the choice
+	// and the length of the raw data of a GET response with data block
+	blockHeaderSize = 1 + 4 + 1 + 3
+)
+
+// ReadBatch is a set of attributes read with one GET request
+type ReadBatch struct {
+	Attributes []*cosem.CosemAttribute
+	// RequestSize is the size of the encoded GET request
+	RequestSize int
+	// RoundTrips is the estimated number of requests, more than one when the
+	// response is sent in blocks
+	RoundTrips int
+}
+
+// WithList tells whether the batch is read with a GET request with list
+func (b *ReadBatch) WithList() bool {
+	return len(b.Attributes) > 1
+}
+
+// ReadPlan is the order and the grouping of the GET requests reading a list
+// of attributes
+type ReadPlan struct {
+	Batches []*ReadBatch
+	// RoundTrips is the estimated number of requests of the whole plan
+	RoundTrips int
+}
+
+// ReadPlanner groups attribute reads into GET requests with list
+type ReadPlanner struct {
+	// MaxRequestSize bounds the encoded requests, it is the max receive PDU
+	// size of the server
+	MaxRequestSize int
+	// MaxResponseSize is the max receive PDU size of the client, larger
+	// responses are sent in blocks
+	MaxResponseSize int
+	// WithList tells whether the server accepts GET requests with list, each
+	// attribute is read alone otherwise
+	WithList bool
+	// ValueSize is the estimated encoded size of an attribute value
+	ValueSize int
+	// ProfileSize is the estimated encoded size of a profile buffer, 0 when
+	// unknown: the profile read is then counted as one round trip
+	ProfileSize int
+}
+
+// NewReadPlanner creates a ReadPlanner estimating values at 16 bytes and
+// profile buffers as unknown
+func NewReadPlanner(maxRequestSize int, maxResponseSize int, withList bool) *ReadPlanner {
+	return &ReadPlanner{
+		MaxRequestSize:  maxRequestSize,
+		MaxResponseSize: maxResponseSize,
+		WithList:        withList,
+		ValueSize:       16,
+	}
+}
+
+// Plan groups the attributes into batches in their order, bounded by the PDU
+// sizes. Profile buffers are read alone, after all other attributes, as they
+// are the largest and the slowest reads.
+func (p *ReadPlanner) Plan(attributes []*cosem.CosemAttribute) *ReadPlan {
+	plan := &ReadPlan{}
+	var profiles []*cosem.CosemAttribute
+	var batch []*cosem.CosemAttribute
+
+	for _, attribute := range attributes {
+		if isProfileBuffer(attribute) {
+			profiles = append(profiles, attribute)
+			continue
+		}
+		if len(batch) > 0 && (!p.WithList || !p.fits(len(batch)+1)) {
+			plan.add(p.batch(batch, p.ValueSize))
+			batch = nil
+		}
+		batch = append(batch, attribute)
+	}
+	if len(batch) > 0 {
+		plan.add(p.batch(batch, p.ValueSize))
+	}
+
+	for _, profile := range profiles {
+		plan.add(p.batch([]*cosem.CosemAttribute{profile}, p.ProfileSize))
+	}
+	return plan
+}
+
+func (p *ReadPlan) add(batch *ReadBatch) {
+	p.Batches = append(p.Batches, batch)
+	p.RoundTrips += batch.RoundTrips
+}
+
+// fits tells whether count attributes can be read with one request, without
+// splitting the response in blocks
+func (p *ReadPlanner) fits(count int) bool {
+	return requestSize(count) <= p.MaxRequestSize && responseSize(count, p.ValueSize) <= p.MaxResponseSize
+}
+
+func (p *ReadPlanner) batch(attributes []*cosem.CosemAttribute, valueSize int) *ReadBatch {
+	result := &ReadBatch{
+		Attributes:  attributes,
+		RequestSize: requestSize(len(attributes)),
+		RoundTrips:  1,
+	}
+
+	size := responseSize(len(attributes), valueSize)
+	if size > p.MaxResponseSize && p.MaxResponseSize > blockHeaderSize+getHeaderSize {
+		blockSize := p.MaxResponseSize - blockHeaderSize - getHeaderSize
+		result.RoundTrips = (size + blockSize - 1) / blockSize
+	}
+	return result
+}
+
+// requestSize is the size of a GET request normal, or with list, of count
+// attributes without selective access
+func requestSize(count int) int {
+	item := cosem.CosemAttributeLength + 1
+	if count == 1 {
+		return getHeaderSize + item
+	}
+	return getHeaderSize + len(dlmsdata.EncodeVariableInteger(count)) + count*item
+}
+
+// responseSize is the estimated size of the response of a GET request of
+// count attributes
+func responseSize(count int, valueSize int) int {
+	item := getResultHeaderSize + valueSize
+	if count == 1 {
+		return getHeaderSize + item
+	}
+	return getHeaderSize + len(dlmsdata.EncodeVariableInteger(count)) + count*item
+}
+
+func isProfileBuffer(attribute *cosem.CosemAttribute) bool {
+	return attribute.Interface == enumerations.CosemInterfaceProfileGeneric && attribute.Attribute == ProfileBufferAttribute
+}
+
+// PlanRead plans the GET requests reading the attributes with the PDU sizes
+// and the conformance of the association, or of the settings when the client
+// is not associated. The plan only estimates the round trips: the sizes of the
+// values are unknown until they are read.
+func (c *Client) PlanRead(attributes []*cosem.CosemAttribute) *ReadPlan {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	maxRequestSize := int(c.settings.MaxPduSize)
+	conformance := c.settings.Conformance
+	if c.associated && c.negotiated != nil {
+		maxRequestSize = int(c.negotiated.ServerMaxReceivePDUSize)
+		conformance = c.negotiated.NegotiatedConformance
+	}
+	withList := conformance != nil && conformance.MultipleReferences
+
+	return NewReadPlanner(maxRequestSize, int(c.settings.MaxPduSize), withList).Plan(attributes)
+}
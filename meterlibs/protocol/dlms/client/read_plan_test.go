@@ -0,0 +1,79 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func registerValues(count int) []*cosem.CosemAttribute {
+	result := make([]*cosem.CosemAttribute, 0, count)
+	for i := 0; i < count; i++ {
+		obis := &cosem.Obis{A: 1, B: 0, C: 1, D: 8, E: i, F: 255}
+		result = append(result, cosem.NewCosemAttribute(enumerations.CosemInterfaceRegister, obis, 2))
+	}
+	return result
+}
+
+func TestReadPlanner_Plan(t *testing.T) {
+	registers := registerValues(5)
+	attributes := append([]*cosem.CosemAttribute{registers[0], profileBuffer}, registers[1:]...)
+
+	// a request of 3 attributes is 34 bytes, of 4 attributes 44 bytes
+	plan := client.NewReadPlanner(40, 1024, true).Plan(attributes)
+	if assert.Len(t, plan.Batches, 3) {
+		assert.Equal(t, registers[:3], plan.Batches[0].Attributes)
+		assert.Equal(t, 34, plan.Batches[0].RequestSize)
+		assert.True(t, plan.Batches[0].WithList())
+		assert.Equal(t, registers[3:], plan.Batches[1].Attributes)
+		assert.Equal(t, []*cosem.CosemAttribute{profileBuffer}, plan.Batches[2].Attributes)
+		assert.Equal(t, 13, plan.Batches[2].RequestSize)
+		assert.False(t, plan.Batches[2].WithList())
+	}
+	assert.Equal(t, 3, plan.RoundTrips)
+
+	plan = client.NewReadPlanner(40, 1024, false).Plan(attributes)
+	assert.Len(t, plan.Batches, 6)
+	assert.Equal(t, profileBuffer, plan.Batches[5].Attributes[0])
+	assert.Equal(t, 6, plan.RoundTrips)
+}
+
+func TestReadPlanner_BlockTransfers(t *testing.T) {
+	planner := client.NewReadPlanner(1024, 200, true)
+	planner.ProfileSize = 2000
+
+	// the blocks carry 188 bytes of the 2004 bytes response
+	plan := planner.Plan([]*cosem.CosemAttribute{profileBuffer})
+	assert.Equal(t, 11, plan.RoundTrips)
+
+	// 13 values of 17 bytes don't fit in 200 bytes responses
+	plan = planner.Plan(registerValues(13))
+	if assert.Len(t, plan.Batches, 2) {
+		assert.Len(t, plan.Batches[0].Attributes, 11)
+		assert.Len(t, plan.Batches[1].Attributes, 2)
+	}
+	assert.Equal(t, 2, plan.RoundTrips)
+
+	assert.Empty(t, planner.Plan(nil).Batches)
+}
+
+func TestClient_PlanRead(t *testing.T) {
+	settings := client.NewSettings(16, 1)
+	settings.MaxPduSize = 40
+	settings.Conformance.MultipleReferences = true
+	c := client.New(testutil.NewScriptedTransport(), settings)
+
+	// the responses of 3 values, 55 bytes, are larger than the PDU size
+	plan := c.PlanRead(registerValues(4))
+	if assert.Len(t, plan.Batches, 2) {
+		assert.Len(t, plan.Batches[0].Attributes, 2)
+		assert.Len(t, plan.Batches[1].Attributes, 2)
+	}
+
+	settings.Conformance.MultipleReferences = false
+	assert.Len(t, c.PlanRead(registerValues(4)).Batches, 4)
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+)
+
+// NeedsReconnect tells if the connection is half-open: a request was sent but no
+// valid response was processed, e.g. after a timeout in AwaitingGetResponse.
+// The client and the server may disagree about the state, so the link has to be reset.
+func (c *Client) NeedsReconnect() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.needsReconnect()
+}
+
+// Reconnect resets the link and replays the association if there was one.
+// The transport is disconnected and connected again, which sends DISC and SNRM
+// on HDLC transports and closes and reopens the socket on TCP transports.
+func (c *Client) Reconnect() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.reconnect()
+}
+
+func (c *Client) needsReconnect() bool {
+	state := c.state.CurrentState()
+	return state != dlms.NoAssociation && state != dlms.Ready
+}
+
+func (c *Client) reconnect() error {
+	associated := c.associated
+	c.resetState()
+
+	if err := c.transport.Disconnect(); err != nil && c.logger != nil {
+		c.logger.Printf("disconnect before reconnect failed: %v", err)
+	}
+
+	// Responses that arrived after the timeout belong to the old link
+	c.drain()
+
+	if err := c.transport.Connect(); err != nil {
+		return fmt.Errorf("reconnect failed: %w", err)
+	}
+
+	if associated {
+		if err := c.associate(); err != nil {
+			return fmt.Errorf("re-association failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// retry runs a request through the Policy of the settings, if any: GET
+// requests are retried with backoff on transient errors, SET and ACTION
+// requests are run once, the circuit of the meter being closed.
+func (c *Client) retry(idempotent bool, request func() ([]byte, error)) ([]byte, error) {
+	policy := c.settings.Policy
+	if policy == nil {
+		return c.attempt(idempotent, request)
+	}
+
+	var data []byte
+	if !idempotent {
+		err := policy.DoOnce(c.meter(), func() (err error) {
+			data, err = c.attempt(false, request)
+			return err
+		})
+		return data, err
+	}
+
+	err := policy.Do(context.Background(), c.meter(), func() (err error) {
+		// A previous attempt may have left the link half-open
+		if c.needsReconnect() {
+			if err = c.reconnect(); err != nil {
+				return err
+			}
+		}
+		data, err = c.attempt(true, request)
+		return err
+	})
+	return data, err
+}
+
+// meter identifies the meter in the Policy
+func (c *Client) meter() string {
+	if c.settings.MeterID != "" {
+		return c.settings.MeterID
+	}
+	return strconv.Itoa(c.settings.ServerAddress)
+}
+
+// attempt runs a request and, if it left the connection half-open and
+// RetryAfterReconnect is set, reconnects and runs it once more when it is
+// idempotent. A SET or an ACTION may have been executed by the meter before
+// the timeout, so it is not replayed: the link is reset and the error is
+// returned. A request rejected for its invocation counter was not executed,
+// it is first retried once with the counter expected by the meter, when
+// RecoverInvocationCounter is set.
+func (c *Client) attempt(idempotent bool, request func() ([]byte, error)) ([]byte, error) {
+	data, err := request()
+	if c.recoverInvocationCounter(err) {
+		data, err = request()
+	}
+	if err == nil || !c.settings.RetryAfterReconnect || !c.associated || !c.needsReconnect() {
+		return data, err
+	}
+
+	if c.logger != nil {
+		c.logger.Printf("request failed in state %s, reconnecting: %v", c.state.CurrentState(), err)
+	}
+
+	if reconnectErr := c.reconnect(); reconnectErr != nil {
+		return nil, fmt.Errorf("%w (after: %v)", reconnectErr, err)
+	}
+	if !idempotent {
+		return nil, err
+	}
+
+	return request()
+}
+
+func (c *Client) drain() {
+	for {
+		select {
+		case _, ok := <-c.dc:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
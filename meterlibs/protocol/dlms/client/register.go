@@ -0,0 +1,70 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// GetScaledValue reads the scaler_unit and the value of a register, extended
+// register or demand register and returns the scaled value. Integer and float
+// values are supported. The value of a demand register is its
+// current_average_value.
+func (c *Client) GetScaledValue(interfaceClass enumerations.CosemInterface, logicalName *cosem.Obis) (float64, *cosem.ScalerUnit, error) {
+	scalerUnitAttribute := cosem.RegisterAttributeScalerUnit
+	if interfaceClass == enumerations.CosemInterfaceDemandRegister {
+		scalerUnitAttribute = cosem.DemandRegisterAttributeScalerUnit
+	}
+	data, err := c.Get(cosem.NewCosemAttribute(interfaceClass, logicalName, scalerUnitAttribute), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	scalerUnit, err := cosem.ParseScalerUnit(data)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", logicalName, err)
+	}
+
+	data, err = c.Get(cosem.NewCosemAttribute(interfaceClass, logicalName, cosem.RegisterAttributeValue), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	value, err := encoding.DecodeValue(data)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to decode %s: %w", logicalName, err)
+	}
+	scaled, err := scalerUnit.Apply(value)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", logicalName, err)
+	}
+	return scaled, scalerUnit, nil
+}
+
+// GetDemandRegister reads the averages, the scaler_unit, the status and the
+// periods of a demand register
+func (c *Client) GetDemandRegister(logicalName *cosem.Obis) (*cosem.DemandRegister, error) {
+	register := cosem.NewDemandRegister(logicalName)
+	err := c.getAttributes(register,
+		cosem.DemandRegisterAttributeCurrentAverageValue,
+		cosem.DemandRegisterAttributeLastAverageValue,
+		cosem.DemandRegisterAttributeScalerUnit,
+		cosem.DemandRegisterAttributeStatus,
+		cosem.DemandRegisterAttributeCaptureTime,
+		cosem.DemandRegisterAttributeStartTimeCurrent,
+		cosem.DemandRegisterAttributePeriod,
+		cosem.DemandRegisterAttributeNumberOfPeriods,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return register, nil
+}
+
+// ResetDemandRegister resets a demand register, the averages are cleared and
+// a new period starts
+func (c *Client) ResetDemandRegister(logicalName *cosem.Obis) error {
+	register := cosem.NewDemandRegister(logicalName)
+	_, err := c.Action(register.Method(cosem.DemandRegisterMethodReset), cosem.IntegerZeroToBytes())
+	return err
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// TariffRegisterActivation is the logical name of the Register activation of
+// the energy tariff registers
+var TariffRegisterActivation = &cosem.Obis{A: 0, B: 0, C: 14, D: 0, E: 1, F: 255}
+
+// GetRegisterActivation reads the register assignment, the masks and the
+// active mask of a Register activation
+func (c *Client) GetRegisterActivation(logicalName *cosem.Obis) (*cosem.RegisterActivation, error) {
+	activation := cosem.NewRegisterActivation(logicalName, nil, nil, nil)
+
+	data, err := c.Get(activation.Attribute(cosem.RegisterActivationAttributeRegisterAssignment), nil)
+	if err != nil {
+		return nil, err
+	}
+	if activation.RegisterAssignment, err = cosem.ParseRegisterAssignment(data); err != nil {
+		return nil, err
+	}
+
+	data, err = c.Get(activation.Attribute(cosem.RegisterActivationAttributeMaskList), nil)
+	if err != nil {
+		return nil, err
+	}
+	if activation.MaskList, err = cosem.ParseMaskList(data); err != nil {
+		return nil, err
+	}
+
+	data, err = c.Get(activation.Attribute(cosem.RegisterActivationAttributeActiveMask), nil)
+	if err != nil {
+		return nil, err
+	}
+	if activation.ActiveMask, err = cosem.ParseActiveMask(data); err != nil {
+		return nil, err
+	}
+
+	return activation, nil
+}
+
+// SetMaskList writes the masks of a Register activation and then the active
+// mask when set. The masks are checked against the register assignment first.
+func (c *Client) SetMaskList(activation *cosem.RegisterActivation) error {
+	maskList, err := activation.MaskListToBytes()
+	if err != nil {
+		return err
+	}
+	if activation.ActiveMask != nil && activation.Mask(activation.ActiveMask) == nil {
+		return fmt.Errorf("active mask %x is not in the mask list", activation.ActiveMask)
+	}
+
+	if err = c.Set(activation.Attribute(cosem.RegisterActivationAttributeMaskList), maskList); err != nil {
+		return fmt.Errorf("failed to set the mask list: %w", err)
+	}
+	if activation.ActiveMask != nil {
+		if err = c.Set(activation.Attribute(cosem.RegisterActivationAttributeActiveMask), activation.ActiveMaskToBytes()); err != nil {
+			return fmt.Errorf("failed to set the active mask: %w", err)
+		}
+	}
+	return nil
+}
+
+// AddRegister adds a register to the register assignment of a Register activation
+func (c *Client) AddRegister(logicalName *cosem.Obis, register *cosem.ObjectDefinition) error {
+	activation := cosem.NewRegisterActivation(logicalName, nil, nil, nil)
+	_, err := c.Action(activation.Method(cosem.RegisterActivationMethodAddRegister), register.ToBytes())
+	return err
+}
+
+// AddMask adds a mask to a Register activation, a mask with the same name is replaced
+func (c *Client) AddMask(logicalName *cosem.Obis, mask *cosem.RegisterActivationMask) error {
+	activation := cosem.NewRegisterActivation(logicalName, nil, nil, nil)
+	_, err := c.Action(activation.Method(cosem.RegisterActivationMethodAddMask), mask.ToBytes())
+	return err
+}
+
+// DeleteMask deletes the mask with the given name from a Register activation
+func (c *Client) DeleteMask(logicalName *cosem.Obis, name []byte) error {
+	activation := cosem.NewRegisterActivation(logicalName, nil, nil, nil)
+	_, err := c.Action(activation.Method(cosem.RegisterActivationMethodDeleteMask), cosem.MaskNameToBytes(name))
+	return err
+}
@@ -0,0 +1,89 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+const (
+	// +A rate 1 and rate 2
+	registerAssignment = "0102020212000309060100010801FF020212000309060100010802FF"
+	// mask T1 selects rate 1, mask T2 selects rate 2
+	maskList   = "01020202090254310101110102020902543201011102"
+	activeMask = "09025432"
+)
+
+func TestClient_GetRegisterActivation(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C1000600000E0001FF0200"), decodeHexString("C401C100"+registerAssignment)),
+		testutil.Expect(decodeHexString("C001C1000600000E0001FF0300"), decodeHexString("C401C100"+maskList)),
+		testutil.Expect(decodeHexString("C001C1000600000E0001FF0400"), decodeHexString("C401C100"+activeMask)),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	activation, err := c.GetRegisterActivation(client.TariffRegisterActivation)
+	require.NoError(t, err)
+	assert.Equal(t, []*cosem.ObjectDefinition{
+		cosem.NewObjectDefinition(enumerations.CosemInterfaceRegister, mustObis("1.0.1.8.1.255")),
+		cosem.NewObjectDefinition(enumerations.CosemInterfaceRegister, mustObis("1.0.1.8.2.255")),
+	}, activation.RegisterAssignment)
+	assert.Equal(t, []*cosem.RegisterActivationMask{
+		cosem.NewRegisterActivationMask([]byte("T1"), []uint8{1}),
+		cosem.NewRegisterActivationMask([]byte("T2"), []uint8{2}),
+	}, activation.MaskList)
+
+	registers, err := activation.ActiveRegisters()
+	require.NoError(t, err)
+	assert.Equal(t, []*cosem.ObjectDefinition{activation.RegisterAssignment[1]}, registers)
+
+	assert.Equal(t, decodeHexString(registerAssignment), activation.RegisterAssignmentToBytes())
+	encoded, err := activation.MaskListToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, decodeHexString(maskList), encoded)
+}
+
+func TestClient_RegisterActivationActions(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C301C1000600000E0001FF0101020212000309060100010803FF"), decodeHexString("C701C10000")),
+		testutil.Expect(decodeHexString("C301C1000600000E0001FF0201020209025433010211011103"), decodeHexString("C701C10000")),
+		testutil.Expect(decodeHexString("C301C1000600000E0001FF030109025433"), decodeHexString("C701C10000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	register := cosem.NewObjectDefinition(enumerations.CosemInterfaceRegister, mustObis("1.0.1.8.3.255"))
+	assert.NoError(t, c.AddRegister(client.TariffRegisterActivation, register))
+	assert.NoError(t, c.AddMask(client.TariffRegisterActivation, cosem.NewRegisterActivationMask([]byte("T3"), []uint8{1, 3})))
+	assert.NoError(t, c.DeleteMask(client.TariffRegisterActivation, []byte("T3")))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestRegisterActivation_Validate(t *testing.T) {
+	activation := cosem.NewRegisterActivation(client.TariffRegisterActivation,
+		[]*cosem.ObjectDefinition{cosem.NewObjectDefinition(enumerations.CosemInterfaceRegister, mustObis("1.0.1.8.1.255"))},
+		[]*cosem.RegisterActivationMask{cosem.NewRegisterActivationMask([]byte("T1"), []uint8{2})},
+		[]byte("T1"),
+	)
+	assert.Error(t, activation.Validate(), "index out of the register assignment")
+
+	activation.MaskList = []*cosem.RegisterActivationMask{
+		cosem.NewRegisterActivationMask([]byte("T1"), []uint8{1}),
+		cosem.NewRegisterActivationMask([]byte("T1"), []uint8{}),
+	}
+	assert.Error(t, activation.Validate(), "duplicate mask name")
+
+	activation.MaskList = activation.MaskList[:1]
+	assert.NoError(t, activation.Validate())
+}
@@ -0,0 +1,105 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_GetScaledValue(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		// scaler_unit {-1, V}
+		testutil.Expect(decodeHexString("C001C100030100200700FF0300"), decodeHexString("C401C10002020FFF1623")),
+		// value float32 230.5
+		testutil.Expect(decodeHexString("C001C100030100200700FF0200"), decodeHexString("C401C1001743668000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	value, scalerUnit, err := c.GetScaledValue(enumerations.CosemInterfaceRegister, mustObis("1.0.32.7.0.255"))
+	assert.NoError(t, err)
+	assert.Equal(t, &cosem.ScalerUnit{Scaler: -1, Unit: 35}, scalerUnit)
+	assert.InDelta(t, 23.05, value, 1e-9)
+}
+
+func TestScalerUnit_Apply(t *testing.T) {
+	scalerUnit, err := cosem.ParseScalerUnit(decodeHexString("02020F02161E"))
+	assert.NoError(t, err)
+	assert.Equal(t, &cosem.ScalerUnit{Scaler: 2, Unit: 30}, scalerUnit)
+	assert.Equal(t, decodeHexString("02020F02161E"), scalerUnit.ToBytes())
+
+	value, err := scalerUnit.Apply(uint32(12))
+	assert.NoError(t, err)
+	assert.Equal(t, 1200.0, value)
+
+	value, err = scalerUnit.Apply(float64(1.5))
+	assert.NoError(t, err)
+	assert.Equal(t, 150.0, value)
+
+	_, err = scalerUnit.Apply("12")
+	assert.Error(t, err)
+
+	_, err = cosem.ParseScalerUnit(decodeHexString("02021102161E"))
+	assert.Error(t, err)
+}
+
+func TestClient_GetDemandRegister(t *testing.T) {
+	get := func(attribute string) string { return "C001C100050100010400FF" + attribute + "00" }
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString(get("02")), decodeHexString("C401C10006000004D2")),
+		testutil.Expect(decodeHexString(get("03")), decodeHexString("C401C1000600000FA0")),
+		// scaler_unit {-1, W}
+		testutil.Expect(decodeHexString(get("04")), decodeHexString("C401C10002020FFF161B")),
+		testutil.Expect(decodeHexString(get("05")), decodeHexString("C401C1001100")),
+		testutil.Expect(decodeHexString(get("06")), decodeHexString("C401C100090C07EA0A11FF0C0F0000800000")),
+		testutil.Expect(decodeHexString(get("07")), decodeHexString("C401C100090C07EA0A11FF0C0F0000800000")),
+		testutil.Expect(decodeHexString(get("08")), decodeHexString("C401C1000600000384")),
+		testutil.Expect(decodeHexString(get("09")), decodeHexString("C401C100120001")),
+		testutil.Expect(decodeHexString("C301C100050100010400FF01010F00"), decodeHexString("C701C10000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	register, err := c.GetDemandRegister(mustObis("1.0.1.4.0.255"))
+	require.NoError(t, err)
+	current, err := register.CurrentAverage()
+	assert.NoError(t, err)
+	assert.InDelta(t, 123.4, current, 1e-9)
+	last, err := register.LastAverage()
+	assert.NoError(t, err)
+	assert.InDelta(t, 400.0, last, 1e-9)
+	assert.Equal(t, uint8(0), register.Status)
+	assert.Equal(t, time.Date(2026, 10, 17, 12, 15, 0, 0, time.UTC), register.CaptureTime)
+	assert.Equal(t, 15*time.Minute, register.PeriodDuration())
+	assert.Equal(t, uint16(1), register.NumberOfPeriods)
+
+	assert.NoError(t, c.ResetDemandRegister(mustObis("1.0.1.4.0.255")))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_GetScaledValueOfDemandRegister(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C100050100010400FF0400"), decodeHexString("C401C10002020FFF161B")),
+		testutil.Expect(decodeHexString("C001C100050100010400FF0200"), decodeHexString("C401C10006000004D2")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	assert.NoError(t, c.Connect())
+	assert.NoError(t, c.Associate())
+
+	value, _, err := c.GetScaledValue(enumerations.CosemInterfaceDemandRegister, mustObis("1.0.1.4.0.255"))
+	assert.NoError(t, err)
+	assert.InDelta(t, 123.4, value, 1e-9)
+}
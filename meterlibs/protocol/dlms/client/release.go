@@ -0,0 +1,87 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+// ReleaseSecuritySuite is the security suite of the InitiateRequest ciphered
+// in the RLRQ
+const ReleaseSecuritySuite uint8 = 0
+
+// initiateRequest returns the InitiateRequest proposing the conformance and
+// the PDU size of the settings
+func (c *Client) initiateRequest() *xdlms.InitiateRequest {
+	return xdlms.NewInitiateRequest(c.settings.Conformance, c.settings.MaxPduSize, 6, true, nil, nil)
+}
+
+// releaseRequest returns the RLRQ. With ReleaseWithUserInformation it carries
+// the InitiateRequest of the AARQ, ciphered with the global unicast key when
+// the settings have keys.
+func (c *Client) releaseRequest() (*acse.ReleaseRequest, error) {
+	reason := enumerations.ReleaseRequestReasonNormal
+	if !c.settings.ReleaseWithUserInformation {
+		return acse.NewReleaseRequest(&reason, nil), nil
+	}
+
+	initiateRequest := c.initiateRequest()
+	if c.settings.Keys == nil {
+		return acse.NewReleaseRequest(&reason, acse.NewUserInformation(initiateRequest)), nil
+	}
+
+	plaintext, err := initiateRequest.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", initiateRequest, err)
+	}
+	securityControl := security.NewSecurityControl(ReleaseSecuritySuite, true, true, false)
+	invocationCounter, ciphered, err := c.cipher("a ciphered release", securityControl, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return acse.NewCipheredReleaseRequest(
+		xdlms.NewGlobalCipherInitiateRequest(byte(securityControl), invocationCounter, ciphered),
+	), nil
+}
+
+// releaseInitiateResponse returns the InitiateResponse in the user
+// information of the RLRE, deciphered with the global unicast key and the
+// system title of the server. It is nil when the RLRE has none.
+func (c *Client) releaseInitiateResponse(rlre *acse.ReleaseResponse) (*xdlms.InitiateResponse, error) {
+	if rlre.UserInformation == nil {
+		return nil, nil
+	}
+
+	switch content := rlre.UserInformation.Content.(type) {
+	case *xdlms.InitiateResponse:
+		return content, nil
+	case *xdlms.GlobalCipherInitiateResponse:
+		securityControl, ok := content.SecurityControl.(byte)
+		if !ok {
+			return nil, exceptions.NewDecryptionError(fmt.Sprintf("unexpected security control %v", content.SecurityControl))
+		}
+		if c.settings.Keys == nil || c.serverSystemTitle == nil {
+			return nil, exceptions.NewDecryptionError("a ciphered RLRE needs the keys of the settings and the system title of the server")
+		}
+		control := security.SecurityControl(securityControl)
+		encryptionKey, err := c.settings.Keys.Key(control.EncryptionKeyID())
+		if err != nil {
+			return nil, exceptions.NewDecryptionError(err.Error())
+		}
+		authenticationKey, err := c.settings.Keys.Key(security.KeyIDAuthentication)
+		if err != nil {
+			return nil, exceptions.NewDecryptionError(err.Error())
+		}
+		plaintext, err := security.Decrypt(control, c.serverSystemTitle, content.InvocationCounter, encryptionKey, authenticationKey, content.CipheredText)
+		if err != nil {
+			return nil, exceptions.NewDecryptionError(err.Error())
+		}
+		return (&xdlms.InitiateResponse{}).FromBytes(plaintext)
+	default:
+		return nil, exceptions.NewDlmsClientException(fmt.Sprintf("unexpected user information in RLRE: %s", rlre.UserInformation))
+	}
+}
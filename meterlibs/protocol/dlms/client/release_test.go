@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// releaseResponse is a RLRE carrying the InitiateResponse of the AARE
+func releaseResponse(t *testing.T, content interface{}) []byte {
+	reason := enumerations.ReleaseResponseReasonNormal
+	rlre, err := acse.NewReleaseResponse(&reason, acse.NewUserInformation(content)).ToBytes()
+	require.NoError(t, err)
+	return rlre
+}
+
+func TestClient_ReleaseWithUserInformation(t *testing.T) {
+	initiateResponse, err := (&xdlms.InitiateResponse{}).FromBytes(decodeHexString("0800065F1F040000101D04000007"))
+	require.NoError(t, err)
+
+	var received *acse.ReleaseRequest
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.ExpectTag(acse.RLRQTag, func(request []byte) ([][]byte, error) {
+			var err error
+			received, err = (&acse.ReleaseRequest{}).FromBytes(request)
+			return [][]byte{releaseResponse(t, initiateResponse)}, err
+		}),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.ReleaseWithUserInformation = true
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	require.NoError(t, c.Release())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+
+	require.NotNil(t, received)
+	require.NotNil(t, received.UserInformation)
+	initiateRequest, ok := received.UserInformation.Content.(*xdlms.InitiateRequest)
+	require.True(t, ok)
+	assert.Equal(t, settings.MaxPduSize, initiateRequest.ClientMaxReceivePDUSize)
+}
+
+func TestClient_ReleaseWithCipheredUserInformation(t *testing.T) {
+	keys := &security.Keys{
+		GlobalUnicastEncryptionKey: decodeHexString("000102030405060708090A0B0C0D0E0F"),
+		AuthenticationKey:          decodeHexString("D0D1D2D3D4D5D6D7D8D9DADBDCDDDEDF"),
+	}
+	systemTitle := decodeHexString("4D4D4D0000BC614E")
+
+	var received *xdlms.GlobalCipherInitiateRequest
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.ExpectTag(acse.RLRQTag, func(request []byte) ([][]byte, error) {
+			rlrq, err := (&acse.ReleaseRequest{}).FromBytes(request)
+			if err != nil {
+				return nil, err
+			}
+			received, _ = rlrq.UserInformation.Content.(*xdlms.GlobalCipherInitiateRequest)
+			// the AARE gave no system title to decipher the answer with
+			return [][]byte{releaseResponse(t, xdlms.NewGlobalCipherInitiateResponse(byte(0x30), 1, make([]byte, 24)))}, nil
+		}),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.ReleaseWithUserInformation = true
+	settings.Keys = keys
+	settings.SystemTitle = systemTitle
+	settings.InvocationCounter = security.NewInvocationCounter(7)
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	err := c.Release()
+	var decryptionError *exceptions.DecryptionError
+	assert.ErrorAs(t, err, &decryptionError)
+	assert.NoError(t, transport.Err())
+
+	require.NotNil(t, received)
+	assert.Equal(t, byte(0x30), received.SecurityControl)
+	assert.Equal(t, uint32(7), received.InvocationCounter)
+	plaintext, err := security.Decrypt(security.SecurityControl(0x30), systemTitle, received.InvocationCounter,
+		keys.GlobalUnicastEncryptionKey, keys.AuthenticationKey, received.CipheredText)
+	require.NoError(t, err)
+	initiateRequest, err := (&xdlms.InitiateRequest{}).FromBytes(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, settings.MaxPduSize, initiateRequest.ClientMaxReceivePDUSize)
+}
+
+func TestClient_ReleaseWithoutRlrqRlreDoesNotCipher(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+	)
+	settings := client.NewSettings(16, 1)
+	settings.UseRlrqRlre = false
+	settings.ReleaseWithUserInformation = true
+	settings.Keys = &security.Keys{
+		GlobalUnicastEncryptionKey: decodeHexString("000102030405060708090A0B0C0D0E0F"),
+		AuthenticationKey:          decodeHexString("D0D1D2D3D4D5D6D7D8D9DADBDCDDDEDF"),
+	}
+	settings.SystemTitle = decodeHexString("4D4D4D0000BC614E")
+	settings.InvocationCounter = security.NewInvocationCounter(7)
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	require.NoError(t, c.Release())
+	assert.False(t, transport.IsConnected())
+	assert.Equal(t, uint32(7), settings.InvocationCounter.Peek(), "no RLRQ was ciphered")
+	assert.NoError(t, transport.Err())
+
+	// without an invocation counter the release doesn't fail on ciphering
+	settings = client.NewSettings(16, 1)
+	settings.UseRlrqRlre = false
+	settings.ReleaseWithUserInformation = true
+	settings.Keys = &security.Keys{}
+	transport = testutil.NewScriptedTransport(associate(testutil.AssociationResponse))
+	c = client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+	require.NoError(t, c.Release())
+	assert.False(t, transport.IsConnected())
+}
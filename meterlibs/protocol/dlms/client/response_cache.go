@@ -0,0 +1,189 @@
+package client
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// cachedResponse is the data of a GET request and when it expires
+type cachedResponse struct {
+	data    []byte
+	expires time.Time
+}
+
+// ResponseCache keeps the data read with GET for a time depending on the
+// interface class of the attribute, so that management UIs reading the same
+// attributes again don't reach the meter. The clients of several meters may
+// share it, the entries are kept by Settings.MeterID and request fingerprint.
+// A SET drops the entries of its attribute and an ACTION those of the meter.
+type ResponseCache struct {
+	// TTLs are the times the data of the interface classes are kept, the
+	// classes without a TTL are not cached
+	TTLs map[enumerations.CosemInterface]time.Duration
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]map[string]*cachedResponse
+}
+
+// NewResponseCache creates an empty ResponseCache with the given TTLs
+func NewResponseCache(ttls map[enumerations.CosemInterface]time.Duration) *ResponseCache {
+	return &ResponseCache{
+		TTLs:    ttls,
+		entries: make(map[string]map[string]*cachedResponse),
+	}
+}
+
+// DefaultResponseCacheTTLs keeps the object lists of the associations for a
+// day, the values that change, like the clock, are not cached
+func DefaultResponseCacheTTLs() map[enumerations.CosemInterface]time.Duration {
+	return map[enumerations.CosemInterface]time.Duration{
+		enumerations.CosemInterfaceAssociationLN: 24 * time.Hour,
+		enumerations.CosemInterfaceAssociationSN: 24 * time.Hour,
+	}
+}
+
+func (r *ResponseCache) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// Load returns the data of the request to the meter if it has not expired
+func (r *ResponseCache) Load(meter string, fingerprint string) ([]byte, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry, ok := r.entries[meter][fingerprint]
+	if !ok {
+		return nil, false
+	}
+	if !r.now().Before(entry.expires) {
+		delete(r.entries[meter], fingerprint)
+		return nil, false
+	}
+	return append([]byte{}, entry.data...), true
+}
+
+// Save keeps the data of the request to the meter, if the interface class
+// has a TTL
+func (r *ResponseCache) Save(meter string, interfaceClass enumerations.CosemInterface, fingerprint string, data []byte) {
+	ttl, ok := r.TTLs[interfaceClass]
+	if !ok || ttl <= 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.entries[meter] == nil {
+		r.entries[meter] = make(map[string]*cachedResponse)
+	}
+	r.entries[meter][fingerprint] = &cachedResponse{
+		data:    append([]byte{}, data...),
+		expires: r.now().Add(ttl),
+	}
+}
+
+// InvalidateAttribute drops the data of the attribute of the meter, with any
+// access selection. Attribute 0 drops all the attributes of the object.
+func (r *ResponseCache) InvalidateAttribute(meter string, attribute *cosem.CosemAttribute) {
+	prefix := attributePrefix(attribute)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.invalidatePrefix(meter, prefix)
+}
+
+// InvalidateAttributeOfAllMeters drops the data of the attribute of all the
+// meters, after a broadcast SET
+func (r *ResponseCache) InvalidateAttributeOfAllMeters(attribute *cosem.CosemAttribute) {
+	prefix := attributePrefix(attribute)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for meter := range r.entries {
+		r.invalidatePrefix(meter, prefix)
+	}
+}
+
+// invalidatePrefix drops the entries of the meter whose fingerprint starts
+// with prefix
+func (r *ResponseCache) invalidatePrefix(meter string, prefix string) {
+	for fingerprint := range r.entries[meter] {
+		if strings.HasPrefix(fingerprint, prefix) {
+			delete(r.entries[meter], fingerprint)
+		}
+	}
+}
+
+// attributePrefix is the start of the fingerprints of the GET requests of the
+// attribute, the class and the OBIS code only for attribute 0
+func attributePrefix(attribute *cosem.CosemAttribute) string {
+	descriptor := attribute.ToBytes()
+	if attribute.Attribute == 0 {
+		descriptor = descriptor[:cosem.CosemAttributeLength-1]
+	}
+	return hex.EncodeToString(descriptor)
+}
+
+// Invalidate drops the data of the meter
+func (r *ResponseCache) Invalidate(meter string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.entries, meter)
+}
+
+// Len returns the number of entries, expired ones included
+func (r *ResponseCache) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	n := 0
+	for _, entries := range r.entries {
+		n += len(entries)
+	}
+	return n
+}
+
+// responseCache returns the cache of the settings, nil without a MeterID as
+// the entries of different meters would be mixed
+func (c *Client) responseCache() *ResponseCache {
+	if c.settings.MeterID == "" {
+		return nil
+	}
+	return c.settings.ResponseCache
+}
+
+// invalidateAttribute drops the cached data of an attribute that was set
+func (c *Client) invalidateAttribute(attribute *cosem.CosemAttribute) {
+	if cache := c.responseCache(); cache != nil {
+		cache.InvalidateAttribute(c.settings.MeterID, attribute)
+	}
+}
+
+// invalidateBroadcast drops the cached data of an attribute that was set on
+// all the meters
+func (c *Client) invalidateBroadcast(attribute *cosem.CosemAttribute) {
+	if c.settings.ResponseCache != nil {
+		c.settings.ResponseCache.InvalidateAttributeOfAllMeters(attribute)
+	}
+}
+
+// invalidateMeter drops the cached data of the meter after an action, which
+// may change any attribute
+func (c *Client) invalidateMeter() {
+	if cache := c.responseCache(); cache != nil {
+		cache.Invalidate(c.settings.MeterID)
+	}
+}
@@ -0,0 +1,156 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_ResponseCache(t *testing.T) {
+	clockData := testutil.ClockTimeResponse[4:]
+	setClock := append(decodeHexString("C101C100080000010000FF0200"), clockData...)
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+		// expired
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+		testutil.Expect(setClock, decodeHexString("C501C100")),
+		// dropped by the SET
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+	)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cache := client.NewResponseCache(map[enumerations.CosemInterface]time.Duration{
+		enumerations.CosemInterfaceClock: time.Minute,
+	})
+	cache.Now = func() time.Time { return now }
+	settings := client.NewSettings(16, 1)
+	settings.ResponseCache = cache
+	settings.MeterID = "meter-1"
+	c := client.New(transport, settings)
+
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	for i := 0; i < 2; i++ {
+		data, err := c.Get(clockTime, nil)
+		require.NoError(t, err)
+		assert.Equal(t, clockData, data)
+	}
+	assert.Equal(t, 3, transport.Remaining())
+
+	now = now.Add(time.Minute)
+	_, err := c.Get(clockTime, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, transport.Remaining())
+
+	require.NoError(t, c.Set(clockTime, clockData))
+	assert.Equal(t, 0, cache.Len())
+	_, err = c.Get(clockTime, nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestResponseCache_TTLs(t *testing.T) {
+	cache := client.NewResponseCache(client.DefaultResponseCacheTTLs())
+	cache.Save("meter-1", enumerations.CosemInterfaceClock, "clock", []byte{1})
+	cache.Save("meter-1", enumerations.CosemInterfaceAssociationLN, "objects", []byte{2})
+	cache.Save("meter-2", enumerations.CosemInterfaceAssociationLN, "objects", []byte{3})
+
+	_, ok := cache.Load("meter-1", "clock")
+	assert.False(t, ok)
+	data, ok := cache.Load("meter-2", "objects")
+	assert.True(t, ok)
+	assert.Equal(t, []byte{3}, data)
+
+	cache.Invalidate("meter-2")
+	_, ok = cache.Load("meter-2", "objects")
+	assert.False(t, ok)
+	_, ok = cache.Load("meter-1", "objects")
+	assert.True(t, ok)
+}
+
+func TestClient_ResponseCacheWithoutMeterID(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+		testutil.Expect(testutil.ClockTimeRequest, testutil.ClockTimeResponse),
+	)
+	cache := client.NewResponseCache(map[enumerations.CosemInterface]time.Duration{
+		enumerations.CosemInterfaceClock: time.Minute,
+	})
+	settings := client.NewSettings(16, 1)
+	settings.ResponseCache = cache
+	c := client.New(transport, settings)
+
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Get(clockTime, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 0, cache.Len())
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestResponseCache_InvalidateAttributeZero(t *testing.T) {
+	clock := mustObis("0.0.1.0.0.255")
+	fingerprint := func(obis string, attribute uint8) string {
+		request := xdlms.NewGetRequestNormal(cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, mustObis(obis), attribute), nil, nil)
+		fingerprint, err := request.Fingerprint()
+		require.NoError(t, err)
+		return fingerprint
+	}
+	cache := client.NewResponseCache(map[enumerations.CosemInterface]time.Duration{
+		enumerations.CosemInterfaceClock: time.Minute,
+	})
+	for _, attribute := range []uint8{2, 3} {
+		cache.Save("meter-1", enumerations.CosemInterfaceClock, fingerprint("0.0.1.0.0.255", attribute), []byte{attribute})
+	}
+	cache.Save("meter-1", enumerations.CosemInterfaceClock, fingerprint("0.0.1.0.1.255", 2), []byte{2})
+
+	cache.InvalidateAttribute("meter-1", cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, clock, 0))
+	assert.Equal(t, 1, cache.Len())
+	_, ok := cache.Load("meter-1", fingerprint("0.0.1.0.1.255", 2))
+	assert.True(t, ok)
+}
+
+func TestClient_BroadcastSetInvalidatesResponseCache(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		testutil.ExpectTag(xdlms.GeneralGlobalCipherTag, func([]byte) ([][]byte, error) { return nil, nil }),
+	)
+	clock := cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, mustObis("0.0.1.0.0.255"), 2)
+	fingerprint, err := xdlms.NewGetRequestNormal(clock, nil, nil).Fingerprint()
+	require.NoError(t, err)
+	cache := client.NewResponseCache(map[enumerations.CosemInterface]time.Duration{
+		enumerations.CosemInterfaceClock: time.Minute,
+	})
+	cache.Save("meter-1", enumerations.CosemInterfaceClock, fingerprint, []byte{1})
+	cache.Save("meter-2", enumerations.CosemInterfaceClock, fingerprint, []byte{2})
+
+	settings := client.NewSettings(16, 1)
+	settings.Keys = &security.Keys{
+		GlobalBroadcastEncryptionKey: decodeHexString("000102030405060708090A0B0C0D0E0F"),
+		AuthenticationKey:            decodeHexString("D0D1D2D3D4D5D6D7D8D9DADBDCDDDEDF"),
+	}
+	settings.SystemTitle = decodeHexString("4D4D4D0000BC614E")
+	settings.InvocationCounter = security.NewInvocationCounter(7)
+	settings.ResponseCache = cache
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+
+	require.NoError(t, c.BroadcastSet(clock, decodeHexString("090C07EA0A11FF0C000000800000")))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, cache.Len())
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// SAPAssignment is the logical name of the SAP assignment object of the
+// management logical device
+var SAPAssignment = &cosem.Obis{A: 0, B: 0, C: 41, D: 0, E: 0, F: 255}
+
+// GetSAPAssignment reads the logical devices of the server and their
+// addresses. The client must be associated with the management logical device.
+func (c *Client) GetSAPAssignment(logicalName *cosem.Obis) (*cosem.SAPAssignment, error) {
+	assignment := cosem.NewSAPAssignment(logicalName)
+	if err := c.getAttributes(assignment, cosem.SAPAssignmentAttributeList); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// SelectLogicalDevice addresses another logical device of the server, e.g.
+// one found with GetSAPAssignment. The client must not be associated. A
+// connected transport is connected again, as the server address is part of
+// the HDLC link. The ServerAddress of the settings is updated.
+func (c *Client) SelectLogicalDevice(sap uint16) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.associated {
+		return fmt.Errorf("can't select logical device %d while associated, release the association first", sap)
+	}
+
+	connected := c.transport.IsConnected()
+	if connected {
+		if err := c.transport.Disconnect(); err != nil {
+			return fmt.Errorf("failed to disconnect from logical device %d: %w", c.settings.ServerAddress, err)
+		}
+		c.drain()
+	}
+
+	c.settings.ServerAddress = int(sap)
+	c.transport.SetAddress(c.settings.ClientAddress, c.settings.ServerAddress)
+
+	if connected {
+		if err := c.transport.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to logical device %d: %w", sap, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_DiscoverLogicalDevices(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C100110000290000FF0200"),
+			// management logical device at 1 and the electricity meter at 17
+			decodeHexString("C401C100"+"0102"+
+				"020212000109044D414E54"+
+				"020212001109054D45544552")),
+		testutil.Expect(testutil.ReleaseRequest, testutil.ReleaseResponse),
+	)
+	c := client.New(transport, client.NewSettings(16, cosem.ManagementLogicalDeviceSAP))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	assignment, err := c.GetSAPAssignment(client.SAPAssignment)
+	require.NoError(t, err)
+	require.Len(t, assignment.List, 2)
+	assert.Equal(t, &cosem.SAPAssignmentEntry{SAP: 1, LogicalDeviceName: []byte("MANT")}, assignment.List[0])
+	sap, ok := assignment.SAP("METER")
+	require.True(t, ok)
+	assert.Equal(t, uint16(17), sap)
+
+	assert.Error(t, c.SelectLogicalDevice(sap))
+	require.NoError(t, c.Release())
+	require.NoError(t, c.SelectLogicalDevice(sap))
+	clientAddress, serverAddress := transport.Address()
+	assert.Equal(t, 16, clientAddress)
+	assert.Equal(t, 17, serverAddress)
+	assert.True(t, transport.IsConnected())
+	assert.Equal(t, 0, transport.Remaining())
+}
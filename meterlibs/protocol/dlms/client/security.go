@@ -0,0 +1,34 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+// SecuritySetup is the logical name of the security setup object of the management association
+var SecuritySetup = &cosem.Obis{A: 0, B: 0, C: 43, D: 0, E: 0, F: 255}
+
+// TransferKey changes a key of the meter with the key_transfer method of a
+// security setup object. The new key is wrapped with the KEK of the Keys of
+// the settings. The Keys are not changed, the caller updates its key source
+// once the meter accepted the new key.
+func (c *Client) TransferKey(logicalName *cosem.Obis, id security.KeyID, key []byte) error {
+	if c.settings.Keys == nil {
+		return fmt.Errorf("key transfer needs the KEK, the settings have no keys")
+	}
+	if err := security.ValidateKey(key); err != nil {
+		return fmt.Errorf("new %s: %w", id, err)
+	}
+	wrapped, err := security.WrapKeyWith(c.settings.Keys, key)
+	if err != nil {
+		return fmt.Errorf("failed to wrap the new %s: %w", id, err)
+	}
+
+	keys := []*cosem.SecuritySetupKeyTransfer{{KeyID: uint8(id), WrappedKey: wrapped}}
+	_, err = c.Action(cosem.NewSecuritySetup(logicalName).Method(cosem.SecuritySetupMethodKeyTransfer),
+		cosem.SecuritySetupKeyTransferToBytes(keys))
+	c.audit(AuditKeyTransfer, fmt.Sprintf("%s of %s", id, logicalName), err)
+	return err
+}
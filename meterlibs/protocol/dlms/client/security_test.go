@@ -0,0 +1,43 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_TransferKey(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		// key_transfer of the authentication key wrapped with the KEK, RFC 3394 test vector
+		testutil.Expect(
+			decodeHexString("C301C1004000002B0000FF02010101020216020918"+"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"),
+			decodeHexString("C701C10000"),
+		),
+	)
+	settings := client.NewSettings(1, 1)
+	settings.Keys = &security.Keys{KeyEncryptionKey: decodeHexString("000102030405060708090A0B0C0D0E0F")}
+	c := client.New(transport, settings)
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	err := c.TransferKey(client.SecuritySetup, security.KeyIDAuthentication, decodeHexString("00112233445566778899AABBCCDDEEFF"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_TransferKeyWithoutKek(t *testing.T) {
+	c := client.New(testutil.NewScriptedTransport(), client.NewSettings(1, 1))
+	err := c.TransferKey(client.SecuritySetup, security.KeyIDAuthentication, make([]byte, 16))
+	assert.Error(t, err)
+
+	settings := client.NewSettings(1, 1)
+	settings.Keys = &security.Keys{}
+	c = client.New(testutil.NewScriptedTransport(), settings)
+	assert.ErrorIs(t, c.TransferKey(client.SecuritySetup, security.KeyIDAuthentication, make([]byte, 16)), security.ErrKeyNotAvailable)
+	assert.Error(t, c.TransferKey(client.SecuritySetup, security.KeyIDAuthentication, make([]byte, 10)))
+}
@@ -0,0 +1,281 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+)
+
+// DefaultMaxJobsPerSession is the number of jobs of a meter run in one
+// association before the other meters get their turn
+const DefaultMaxJobsPerSession = 4
+
+// ErrSessionManagerClosed is returned for the jobs not run when the
+// SessionManager is closed
+var ErrSessionManagerClosed = errors.New("session manager closed")
+
+// SessionManager shares one physical transport, e.g. the link to a PLC data
+// concentrator serializing the access to the meters behind it, between
+// callers working on several meters at once. The jobs are run one at a time:
+// the manager associates with the meter of a job, runs it with the client of
+// the association, and releases.
+//
+// The job with the highest priority runs first. Among the meters whose next
+// job has the same priority, the one served the longest time ago goes first,
+// so a meter with many jobs doesn't hold off the others. Up to
+// MaxJobsPerSession queued jobs of the meter run in the same association.
+type SessionManager struct {
+	// MaxJobsPerSession is the number of jobs run in one association
+	MaxJobsPerSession int
+
+	transport dlms.Transport
+	meters    map[string]*sessionMeter
+	served    uint64
+	sequence  uint64
+	logger    *log.Logger
+	wake      chan struct{}
+	closed    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	mutex     sync.Mutex
+}
+
+type sessionMeter struct {
+	settings *Settings
+	jobs     []*sessionJob
+	// lastServed orders the meters served, 0 when never served
+	lastServed uint64
+}
+
+type sessionJob struct {
+	ctx      context.Context
+	priority int
+	sequence uint64
+	run      func(c *Client) error
+	result   chan error
+}
+
+// NewSessionManager creates a SessionManager owning the transport and starts
+// running the jobs
+func NewSessionManager(transport dlms.Transport) *SessionManager {
+	m := &SessionManager{
+		MaxJobsPerSession: DefaultMaxJobsPerSession,
+		transport:         transport,
+		meters:            make(map[string]*sessionMeter),
+		wake:              make(chan struct{}, 1),
+		closed:            make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+	go m.worker()
+	return m
+}
+
+// SetLogger sets the logger of the manager and of the clients it creates
+func (m *SessionManager) SetLogger(logger *log.Logger) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.logger = logger
+}
+
+// AddMeter adds a meter reachable over the transport, the settings give its
+// addresses and the association to open
+func (m *SessionManager) AddMeter(meter string, settings *Settings) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if existing, ok := m.meters[meter]; ok {
+		existing.settings = settings
+		return
+	}
+	m.meters[meter] = &sessionMeter{settings: settings}
+}
+
+// Run runs a job on the association with the meter and returns its error,
+// or the error of the association. It waits for the jobs queued before with
+// a higher or the same priority. A job whose context is done before it
+// started is not run.
+func (m *SessionManager) Run(ctx context.Context, meter string, priority int, job func(c *Client) error) error {
+	result := make(chan error, 1)
+
+	m.mutex.Lock()
+	queue, ok := m.meters[meter]
+	if !ok {
+		m.mutex.Unlock()
+		return fmt.Errorf("meter %s is not managed", meter)
+	}
+	m.sequence++
+	queue.add(&sessionJob{ctx: ctx, priority: priority, sequence: m.sequence, run: job, result: result})
+	m.mutex.Unlock()
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.done:
+		// the job may have been run just before closing
+		select {
+		case err := <-result:
+			return err
+		default:
+			return ErrSessionManagerClosed
+		}
+	}
+}
+
+// Pending returns the number of jobs waiting to be run
+func (m *SessionManager) Pending() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	pending := 0
+	for _, queue := range m.meters {
+		pending += len(queue.jobs)
+	}
+	return pending
+}
+
+// Close stops running jobs once the current session ends and closes the
+// transport. The jobs waiting fail with ErrSessionManagerClosed.
+func (m *SessionManager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+	<-m.done
+}
+
+func (m *SessionManager) worker() {
+	defer func() {
+		m.transport.Close()
+		close(m.done)
+	}()
+
+	for {
+		meter, settings, jobs := m.next()
+		if jobs == nil {
+			select {
+			case <-m.wake:
+				continue
+			case <-m.closed:
+				return
+			}
+		}
+		m.session(meter, settings, jobs)
+
+		select {
+		case <-m.closed:
+			return
+		default:
+		}
+	}
+}
+
+// next takes the jobs of the next session, nil when no job is waiting
+func (m *SessionManager) next() (string, *Settings, []*sessionJob) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var chosen string
+	var best *sessionMeter
+	for meter, queue := range m.meters {
+		queue.dropDone()
+		if len(queue.jobs) == 0 {
+			continue
+		}
+		if best == nil || queue.before(best) {
+			chosen, best = meter, queue
+		}
+	}
+	if best == nil {
+		return "", nil, nil
+	}
+
+	count := min(max(m.MaxJobsPerSession, 1), len(best.jobs))
+	jobs := best.jobs[:count:count]
+	best.jobs = best.jobs[count:]
+	m.served++
+	best.lastServed = m.served
+	return chosen, best.settings, jobs
+}
+
+// session associates with the meter and runs the jobs
+func (m *SessionManager) session(meter string, settings *Settings, jobs []*sessionJob) {
+	m.mutex.Lock()
+	logger := m.logger
+	m.mutex.Unlock()
+
+	c := New(m.transport, settings)
+	if logger != nil {
+		c.SetLogger(logger)
+	}
+
+	err := c.Connect()
+	if err == nil {
+		if err = c.Associate(); err != nil {
+			c.Disconnect()
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("meter %s: %w", meter, err)
+		for _, job := range jobs {
+			job.result <- err
+		}
+		return
+	}
+
+	for _, job := range jobs {
+		if job.ctx.Err() != nil {
+			job.result <- job.ctx.Err()
+			continue
+		}
+		job.result <- job.run(c)
+	}
+
+	if err = c.Release(); err != nil && logger != nil {
+		logger.Printf("failed to release the association with meter %s: %v", meter, err)
+	}
+	c.Disconnect()
+}
+
+// add queues a job after the jobs of the same or a higher priority
+func (q *sessionMeter) add(job *sessionJob) {
+	i := len(q.jobs)
+	for i > 0 && q.jobs[i-1].priority < job.priority {
+		i--
+	}
+	q.jobs = append(q.jobs, nil)
+	copy(q.jobs[i+1:], q.jobs[i:])
+	q.jobs[i] = job
+}
+
+// dropDone removes the jobs whose context is done, their caller returned
+func (q *sessionMeter) dropDone() {
+	kept := q.jobs[:0]
+	for _, job := range q.jobs {
+		if job.ctx.Err() == nil {
+			kept = append(kept, job)
+		}
+	}
+	q.jobs = kept
+}
+
+// before tells whether the next job of q runs before the one of other
+func (q *sessionMeter) before(other *sessionMeter) bool {
+	if q.jobs[0].priority != other.jobs[0].priority {
+		return q.jobs[0].priority > other.jobs[0].priority
+	}
+	if q.lastServed != other.lastServed {
+		return q.lastServed < other.lastServed
+	}
+	return q.jobs[0].sequence < other.jobs[0].sequence
+}
@@ -0,0 +1,120 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// session is the association and the release of a session of the manager
+func session() []testutil.Step {
+	return []testutil.Step{
+		associate(testutil.AssociationResponse),
+		testutil.Expect(testutil.ReleaseRequest, testutil.ReleaseResponse),
+	}
+}
+
+func TestSessionManager(t *testing.T) {
+	var steps []testutil.Step
+	for i := 0; i < 5; i++ {
+		steps = append(steps, session()...)
+	}
+	transport := testutil.NewScriptedTransport(steps...)
+	manager := client.NewSessionManager(transport)
+	manager.MaxJobsPerSession = 2
+	for server, meter := range []string{"A", "B", "C"} {
+		manager.AddMeter(meter, client.NewSettings(16, server+1))
+	}
+
+	var mutex sync.Mutex
+	var order []string
+	record := func(name string) func(c *client.Client) error {
+		return func(*client.Client) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	// hold the link while the other jobs are queued
+	blocked := make(chan struct{})
+	started := make(chan struct{})
+	var wait sync.WaitGroup
+	wait.Add(1)
+	go func() {
+		defer wait.Done()
+		assert.NoError(t, manager.Run(context.Background(), "C", 0, func(*client.Client) error {
+			close(started)
+			<-blocked
+			return nil
+		}))
+	}()
+	<-started
+
+	for i, job := range []struct {
+		meter    string
+		priority int
+		name     string
+	}{
+		{"A", 0, "A1"},
+		{"A", 0, "A2"},
+		{"A", 0, "A3"},
+		{"B", 0, "B1"},
+		{"C", 1, "C1"},
+	} {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			assert.NoError(t, manager.Run(context.Background(), job.meter, job.priority, record(job.name)))
+		}()
+		// queued one after the other
+		require.Eventually(t, func() bool { return manager.Pending() == i+1 }, time.Second, time.Millisecond)
+	}
+
+	close(blocked)
+	wait.Wait()
+	manager.Close()
+
+	// the priority job first, then A and B take turns, two jobs at a time
+	assert.Equal(t, []string{"C1", "A1", "A2", "B1", "A3"}, order)
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+
+	err := manager.Run(context.Background(), "A", 0, record("A4"))
+	assert.ErrorIs(t, err, client.ErrSessionManagerClosed)
+	assert.Error(t, manager.Run(context.Background(), "D", 0, record("D1")))
+}
+
+func TestSessionManager_Canceled(t *testing.T) {
+	transport := testutil.NewScriptedTransport(session()...)
+	manager := client.NewSessionManager(transport)
+	defer manager.Close()
+	manager.AddMeter("A", client.NewSettings(16, 1))
+
+	blocked := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = manager.Run(context.Background(), "A", 0, func(*client.Client) error {
+			close(started)
+			<-blocked
+			return nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := manager.Run(ctx, "A", 0, func(*client.Client) error {
+		t.Error("canceled job run")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	close(blocked)
+}
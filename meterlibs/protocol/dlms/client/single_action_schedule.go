@@ -0,0 +1,76 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+var (
+	// DisconnectorControlSchedule is the logical name of the Single action
+	// schedule executing the disconnector scripts
+	DisconnectorControlSchedule = &cosem.Obis{A: 0, B: 0, C: 15, D: 0, E: 1, F: 255}
+	// DisconnectorScriptTable is the logical name of the Script table of the disconnector
+	DisconnectorScriptTable = &cosem.Obis{A: 0, B: 0, C: 10, D: 0, E: 106, F: 255}
+)
+
+// Scripts of the disconnector script table
+const (
+	ScriptDisconnect uint16 = 1
+	ScriptReconnect  uint16 = 2
+)
+
+// GetSingleActionSchedule reads the executed script, the type and the
+// execution times of a Single action schedule
+func (c *Client) GetSingleActionSchedule(logicalName *cosem.Obis) (*cosem.SingleActionSchedule, error) {
+	schedule := cosem.NewSingleActionSchedule(logicalName, nil, 0, nil)
+
+	data, err := c.Get(schedule.Attribute(cosem.SingleActionScheduleAttributeExecutedScript), nil)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.ExecutedScript, err = (&cosem.ScriptReference{}).FromBytes(data); err != nil {
+		return nil, err
+	}
+
+	data, err = c.Get(schedule.Attribute(cosem.SingleActionScheduleAttributeType), nil)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Type, err = cosem.ParseSingleActionScheduleType(data); err != nil {
+		return nil, err
+	}
+
+	data, err = c.Get(schedule.Attribute(cosem.SingleActionScheduleAttributeExecutionTime), nil)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.ExecutionTimes, err = cosem.ParseExecutionTime(data); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// SetSingleActionSchedule writes the executed script, the type and the
+// execution times of a Single action schedule. The execution times are
+// checked against the type before anything is written.
+func (c *Client) SetSingleActionSchedule(schedule *cosem.SingleActionSchedule) error {
+	executionTime, err := schedule.ExecutionTimeToBytes()
+	if err != nil {
+		return err
+	}
+
+	if schedule.ExecutedScript != nil {
+		if err = c.Set(schedule.Attribute(cosem.SingleActionScheduleAttributeExecutedScript), schedule.ExecutedScript.ToBytes()); err != nil {
+			return fmt.Errorf("failed to set the executed script: %w", err)
+		}
+	}
+	if err = c.Set(schedule.Attribute(cosem.SingleActionScheduleAttributeType), schedule.TypeToBytes()); err != nil {
+		return fmt.Errorf("failed to set the type: %w", err)
+	}
+	if err = c.Set(schedule.Attribute(cosem.SingleActionScheduleAttributeExecutionTime), executionTime); err != nil {
+		return fmt.Errorf("failed to set the execution time: %w", err)
+	}
+	return nil
+}
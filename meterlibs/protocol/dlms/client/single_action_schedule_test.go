@@ -0,0 +1,89 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+const (
+	executedScript = "0202090600000A006AFF120001"
+	scheduleType   = "1601"
+	// 12:00:00.00 on October 17 of every year
+	executionTime = "0101020209040C0000000905FFFF0A11FF"
+)
+
+func TestClient_SetSingleActionSchedule(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C101C1001600000F0001FF0200"+executedScript), decodeHexString("C501C100")),
+		testutil.Expect(decodeHexString("C101C1001600000F0001FF0300"+scheduleType), decodeHexString("C501C100")),
+		testutil.Expect(decodeHexString("C101C1001600000F0001FF0400"+executionTime), decodeHexString("C501C100")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	schedule := cosem.NewSingleActionSchedule(
+		client.DisconnectorControlSchedule,
+		cosem.NewScriptReference(client.DisconnectorScriptTable, client.ScriptDisconnect),
+		cosem.SingleActionScheduleTypeSingleTime,
+		[]*cosem.ExecutionTime{cosem.NewExecutionTime(
+			dlmsdata.NewCosemTime(12, 0, 0, 0),
+			dlmsdata.NewCosemDate(dlmsdata.YearNotSpecified, 10, 17, dlmsdata.NotSpecified),
+		)},
+	)
+	assert.NoError(t, c.SetSingleActionSchedule(schedule))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_GetSingleActionSchedule(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C001C1001600000F0001FF0200"), decodeHexString("C401C100"+executedScript)),
+		testutil.Expect(decodeHexString("C001C1001600000F0001FF0300"), decodeHexString("C401C100"+scheduleType)),
+		testutil.Expect(decodeHexString("C001C1001600000F0001FF0400"), decodeHexString("C401C100"+executionTime)),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	schedule, err := c.GetSingleActionSchedule(client.DisconnectorControlSchedule)
+	require.NoError(t, err)
+	assert.Equal(t, cosem.NewScriptReference(client.DisconnectorScriptTable, client.ScriptDisconnect), schedule.ExecutedScript)
+	assert.Equal(t, cosem.SingleActionScheduleTypeSingleTime, schedule.Type)
+	require.Len(t, schedule.ExecutionTimes, 1)
+	assert.Equal(t, "*-10-17 12:00:00.00", schedule.ExecutionTimes[0].String())
+	assert.True(t, schedule.ExecutionTimes[0].Date.HasWildcard())
+}
+
+func TestSingleActionSchedule_Validate(t *testing.T) {
+	noon := dlmsdata.NewCosemTime(12, 0, 0, 0)
+	everyDay := dlmsdata.NewCosemDate(dlmsdata.YearNotSpecified, dlmsdata.NotSpecified, dlmsdata.NotSpecified, dlmsdata.NotSpecified)
+	fixedDay := dlmsdata.NewCosemDate(2026, 10, 17, 6)
+
+	schedule := cosem.NewSingleActionSchedule(client.DisconnectorControlSchedule, nil, cosem.SingleActionScheduleTypeSameTime,
+		[]*cosem.ExecutionTime{cosem.NewExecutionTime(noon, everyDay)})
+	assert.Error(t, schedule.Validate(), "wildcard date")
+
+	schedule.ExecutionTimes = []*cosem.ExecutionTime{
+		cosem.NewExecutionTime(noon, fixedDay),
+		cosem.NewExecutionTime(dlmsdata.NewCosemTime(13, 0, 0, 0), fixedDay),
+	}
+	assert.Error(t, schedule.Validate(), "different times")
+
+	schedule.Type = cosem.SingleActionScheduleTypeDifferentTimes
+	assert.NoError(t, schedule.Validate())
+
+	schedule.Type = cosem.SingleActionScheduleTypeSingleTime
+	assert.Error(t, schedule.Validate(), "more than one execution time")
+
+	_, err := cosem.ParseExecutionTime(decodeHexString("010102020904180000000905FFFF0A11FF"))
+	assert.Error(t, err, "hour 24")
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+var (
+	// Clock is the logical name of the clock
+	Clock = &cosem.Obis{A: 0, B: 0, C: 1, D: 0, E: 0, F: 255}
+	// NTPSetup is the logical name of the NTP setup object
+	NTPSetup = &cosem.Obis{A: 0, B: 0, C: 25, D: 10, E: 0, F: 255}
+)
+
+// GetNTPSetup reads all attributes of a NTP setup object
+func (c *Client) GetNTPSetup(logicalName *cosem.Obis) (*cosem.NTPSetup, error) {
+	setup := cosem.NewNTPSetup(logicalName)
+	err := c.getAttributes(setup,
+		cosem.NTPSetupAttributeActivated,
+		cosem.NTPSetupAttributeServerAddress,
+		cosem.NTPSetupAttributeServerPort,
+		cosem.NTPSetupAttributeAuthenticationMethod,
+		cosem.NTPSetupAttributeAuthenticationKeys,
+		cosem.NTPSetupAttributeClientKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return setup, nil
+}
+
+// SetNTPSetup writes the given attributes of a NTP setup object, in the given order
+func (c *Client) SetNTPSetup(setup *cosem.NTPSetup, attributes ...uint8) error {
+	return c.setAttributes(setup, attributes...)
+}
+
+// SynchronizeNTP makes the meter synchronize its clock with the NTP server
+func (c *Client) SynchronizeNTP(logicalName *cosem.Obis) error {
+	_, err := c.Action(cosem.NewNTPSetup(logicalName).Method(cosem.NTPSetupMethodSynchronize), cosem.NTPSynchronizeToBytes())
+	return err
+}
+
+// AddNTPAuthenticationKey adds a symmetric key to a NTP setup object
+func (c *Client) AddNTPAuthenticationKey(logicalName *cosem.Obis, key *cosem.NTPAuthenticationKey) error {
+	_, err := c.Action(cosem.NewNTPSetup(logicalName).Method(cosem.NTPSetupMethodAddAuthenticationKey), key.ToBytes())
+	return err
+}
+
+// DeleteNTPAuthenticationKey deletes the symmetric key with the given id from a NTP setup object
+func (c *Client) DeleteNTPAuthenticationKey(logicalName *cosem.Obis, id uint32) error {
+	_, err := c.Action(cosem.NewNTPSetup(logicalName).Method(cosem.NTPSetupMethodDeleteAuthenticationKey), cosem.NTPKeyIDToBytes(id))
+	return err
+}
+
+// TimeSyncAction is what SyncTime did to correct the clock of the meter
+type TimeSyncAction string
+
+const (
+	TimeSyncNone  TimeSyncAction = "none"
+	TimeSyncShift TimeSyncAction = "shift_time"
+	TimeSyncSet   TimeSyncAction = "set_time"
+	TimeSyncNTP   TimeSyncAction = "ntp"
+)
+
+// TimeSyncPolicy gives the drift thresholds of SyncTime
+type TimeSyncPolicy struct {
+	// Tolerance is the drift that is not corrected
+	Tolerance time.Duration
+	// MaxShift is the largest drift corrected with shift_time, at most
+	// cosem.MaxShiftTime. Larger drifts are corrected with NTP or set_time.
+	MaxShift time.Duration
+	// NTPSetup is the NTP setup object used for large drifts when it is
+	// activated, nil to always use set_time
+	NTPSetup *cosem.Obis
+	// Now returns the reference time, time.Now when nil
+	Now func() time.Time
+}
+
+// NewTimeSyncPolicy creates a TimeSyncPolicy ignoring drifts up to 10
+// seconds, shifting the time up to 15 minutes and setting it above
+func NewTimeSyncPolicy() *TimeSyncPolicy {
+	return &TimeSyncPolicy{
+		Tolerance: 10 * time.Second,
+		MaxShift:  cosem.MaxShiftTime,
+	}
+}
+
+// Choose returns the action correcting the drift. ntpActivated tells whether
+// the meter can synchronize with NTP.
+func (p *TimeSyncPolicy) Choose(drift time.Duration, ntpActivated bool) TimeSyncAction {
+	if drift < 0 {
+		drift = -drift
+	}
+	switch {
+	case drift <= p.Tolerance:
+		return TimeSyncNone
+	case drift <= min(p.MaxShift, cosem.MaxShiftTime):
+		return TimeSyncShift
+	case ntpActivated:
+		return TimeSyncNTP
+	default:
+		return TimeSyncSet
+	}
+}
+
+func (p *TimeSyncPolicy) now() time.Time {
+	if p.Now == nil {
+		return time.Now()
+	}
+	return p.Now()
+}
+
+// TimeSyncResult is the outcome of SyncTime
+type TimeSyncResult struct {
+	Action TimeSyncAction
+	// MeterTime is the time read from the meter
+	MeterTime time.Time
+	// Drift is the meter time minus the reference time
+	Drift time.Duration
+}
+
+// SyncTime compares the clock of the meter with the reference time of the
+// policy and corrects it. Small drifts are shifted, large drifts are
+// corrected by NTP when the NTP setup of the policy is activated and set
+// otherwise.
+func (c *Client) SyncTime(policy *TimeSyncPolicy) (*TimeSyncResult, error) {
+	ntpActivated := false
+	if policy.NTPSetup != nil {
+		setup := cosem.NewNTPSetup(policy.NTPSetup)
+		if err := c.getAttributes(setup, cosem.NTPSetupAttributeActivated); err != nil {
+			return nil, fmt.Errorf("failed to read the NTP setup: %w", err)
+		}
+		ntpActivated = setup.Activated
+	}
+
+	data, err := c.Get(cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, Clock, cosem.ClockAttributeTime), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the clock: %w", err)
+	}
+	meterTime, err := cosem.ParseClockTime(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TimeSyncResult{MeterTime: meterTime, Drift: meterTime.Sub(policy.now())}
+	result.Action = policy.Choose(result.Drift, ntpActivated)
+
+	switch result.Action {
+	case TimeSyncShift:
+		data, err = cosem.ShiftTimeToBytes(-result.Drift)
+		if err == nil {
+			_, err = c.Action(cosem.NewCosemMethod(enumerations.CosemInterfaceClock, Clock, cosem.ClockMethodShiftTime), data)
+		}
+	case TimeSyncSet:
+		now := policy.now()
+		err = c.Set(cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, Clock, cosem.ClockAttributeTime),
+			cosem.ClockTimeToBytes(now.In(meterTime.Location())))
+	case TimeSyncNTP:
+		err = c.SynchronizeNTP(policy.NTPSetup)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to correct the clock with %s: %w", result.Action, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+var (
+	clockTimeRequest = decodeHexString("C001C100080000010000FF0200")
+	ntpActivated     = testutil.Expect(decodeHexString("C001C100640000190A00FF0200"), decodeHexString("C401C1000301"))
+)
+
+// clockTimeResponse returns 2026-10-17 with the given time and no deviation
+func clockTimeResponse(time string) []byte {
+	return decodeHexString("C401C100090C07EA0A1106" + time + "800000")
+}
+
+func timeSyncPolicy() *client.TimeSyncPolicy {
+	policy := client.NewTimeSyncPolicy()
+	policy.Now = func() time.Time {
+		return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	}
+	return policy
+}
+
+func TestClient_SyncTimeShift(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(clockTimeRequest, clockTimeResponse("0C001E00")),
+		// shift_time by -30 seconds
+		testutil.Expect(decodeHexString("C301C100080000010000FF060110FFE2"), decodeHexString("C701C10000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	result, err := c.SyncTime(timeSyncPolicy())
+	require.NoError(t, err)
+	assert.Equal(t, client.TimeSyncShift, result.Action)
+	assert.Equal(t, 30*time.Second, result.Drift)
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_SyncTimeNTP(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		ntpActivated,
+		testutil.Expect(clockTimeRequest, clockTimeResponse("0C1E0000")),
+		testutil.Expect(decodeHexString("C301C100640000190A00FF01010F00"), decodeHexString("C701C10000")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	policy := timeSyncPolicy()
+	policy.NTPSetup = client.NTPSetup
+	result, err := c.SyncTime(policy)
+	require.NoError(t, err)
+	assert.Equal(t, client.TimeSyncNTP, result.Action)
+	assert.Equal(t, 30*time.Minute, result.Drift)
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestClient_SyncTimeSet(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(clockTimeRequest, clockTimeResponse("0A000000")),
+		testutil.Expect(decodeHexString("C101C100080000010000FF0200090C07EA0A11FF0C000000800000"), decodeHexString("C501C100")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	result, err := c.SyncTime(timeSyncPolicy())
+	require.NoError(t, err)
+	assert.Equal(t, client.TimeSyncSet, result.Action)
+	assert.Equal(t, -2*time.Hour, result.Drift)
+	assert.NoError(t, transport.Err())
+}
+
+func TestTimeSyncPolicy_Choose(t *testing.T) {
+	policy := client.NewTimeSyncPolicy()
+	assert.Equal(t, client.TimeSyncNone, policy.Choose(-5*time.Second, true))
+	assert.Equal(t, client.TimeSyncShift, policy.Choose(-5*time.Minute, true))
+	assert.Equal(t, client.TimeSyncSet, policy.Choose(20*time.Minute, false))
+	assert.Equal(t, client.TimeSyncNTP, policy.Choose(20*time.Minute, true))
+
+	policy.MaxShift = time.Hour
+	assert.Equal(t, client.TimeSyncSet, policy.Choose(20*time.Minute, false), "shift_time is limited to 15 minutes")
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// TokenGateway is the logical name of the Token gateway object
+var TokenGateway = &cosem.Obis{A: 0, B: 0, C: 19, D: 40, E: 0, F: 255}
+
+// GetTokenGateway reads the last token received by a Token gateway and the
+// result of its processing
+func (c *Client) GetTokenGateway(logicalName *cosem.Obis) (*cosem.TokenGateway, error) {
+	gateway := cosem.NewTokenGateway(logicalName)
+	err := c.getAttributes(gateway,
+		cosem.TokenGatewayAttributeToken,
+		cosem.TokenGatewayAttributeTokenTime,
+		cosem.TokenGatewayAttributeTokenDescription,
+		cosem.TokenGatewayAttributeDeliveryMethod,
+		cosem.TokenGatewayAttributeStatus,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return gateway, nil
+}
+
+// EnterToken delivers a prepayment token with the enter method of a Token
+// gateway and returns the token status read right after. The meter may
+// still be processing the token, the status is read again with
+// GetTokenStatus while it is pending.
+func (c *Client) EnterToken(logicalName *cosem.Obis, token []byte) (*cosem.TokenStatus, error) {
+	gateway := cosem.NewTokenGateway(logicalName)
+	if _, err := c.Action(gateway.Method(cosem.TokenGatewayMethodEnter), cosem.TokenToBytes(token)); err != nil {
+		return nil, err
+	}
+	return c.GetTokenStatus(logicalName)
+}
+
+// GetTokenStatus reads the result of the processing of the last token
+func (c *Client) GetTokenStatus(logicalName *cosem.Obis) (*cosem.TokenStatus, error) {
+	gateway := cosem.NewTokenGateway(logicalName)
+	if err := c.getAttributes(gateway, cosem.TokenGatewayAttributeStatus); err != nil {
+		return nil, err
+	}
+	return gateway.Status, nil
+}
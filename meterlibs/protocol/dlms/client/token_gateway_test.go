@@ -0,0 +1,35 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestClient_EnterToken(t *testing.T) {
+	token := decodeHexString("0102030405060708090A")
+	transport := testutil.NewScriptedTransport(
+		associate(testutil.AssociationResponse),
+		testutil.Expect(decodeHexString("C301C100730000132800FF0101"+"090A0102030405060708090A"),
+			decodeHexString("C701C10000")),
+		testutil.Expect(decodeHexString("C001C100730000132800FF0600"),
+			// validation failure, the second reason bit is set
+			decodeHexString("C401C100"+"0202"+"1606"+"040840")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	status, err := c.EnterToken(client.TokenGateway, token)
+	require.NoError(t, err)
+	assert.Equal(t, cosem.TokenValidationFailure, status.Code)
+	assert.True(t, status.Failed())
+	assert.False(t, status.Pending())
+	assert.True(t, status.Data.Bit(1))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
@@ -0,0 +1,372 @@
+// Command apdudecode decodes hex dumps of HDLC frames, wrapper messages or
+// bare APDUs, e.g. pasted from a meter log, and prints the decoded
+// structures. The DLMS data carried by the APDUs is printed as a tree, the
+// logical names are annotated with the names of the IDIS object model.
+//
+//	apdudecode -hdlc 7E A0 1E 03 21 52 ... 7E
+//	echo C401C100060000303A | apdudecode
+//
+// Without -wrapper, -hdlc or -apdu the format is guessed from the first bytes.
+// The dump is read from the arguments, or from the standard input.
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/idis"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// mode is the format of the dump
+type mode int
+
+const (
+	modeAuto mode = iota
+	modeWrapper
+	modeHdlc
+	modeApdu
+)
+
+// wrapperHeaderLength is the length of the header of the wrapper: version,
+// source, destination and length, two bytes each
+const wrapperHeaderLength = 8
+
+func main() {
+	wrapper := flag.Bool("wrapper", false, "the dump is wrapper messages")
+	hdlcFrames := flag.Bool("hdlc", false, "the dump is HDLC frames")
+	apdu := flag.Bool("apdu", false, "the dump is a bare APDU")
+	flag.Parse()
+
+	m := modeAuto
+	selected := 0
+	for flagMode, set := range map[mode]bool{modeWrapper: *wrapper, modeHdlc: *hdlcFrames, modeApdu: *apdu} {
+		if set {
+			m = flagMode
+			selected++
+		}
+	}
+	if selected > 1 {
+		fmt.Fprintln(os.Stderr, "apdudecode: give only one of -wrapper, -hdlc and -apdu")
+		os.Exit(2)
+	}
+
+	dump := strings.Join(flag.Args(), " ")
+	if dump == "" {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "apdudecode: %v\n", err)
+			os.Exit(1)
+		}
+		dump = string(input)
+	}
+
+	data, err := parseHex(dump)
+	if err == nil {
+		err = decode(os.Stdout, m, data)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apdudecode: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseHex reads a hex dump, the bytes may be separated by spaces, colons or
+// new lines and prefixed with 0x
+func parseHex(dump string) ([]byte, error) {
+	dump = strings.ReplaceAll(dump, "0x", "")
+	dump = strings.ReplaceAll(dump, "0X", "")
+	dump = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', ':', '-', ',':
+			return -1
+		}
+		return r
+	}, dump)
+	data, err := hex.DecodeString(dump)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex dump: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty hex dump")
+	}
+	return data, nil
+}
+
+// guessMode finds the format of a dump from its first bytes
+func guessMode(data []byte) mode {
+	if data[0] == hdlc.HDLCFlag {
+		return modeHdlc
+	}
+	if len(data) >= wrapperHeaderLength && binary.BigEndian.Uint16(data[0:2]) == 1 &&
+		int(binary.BigEndian.Uint16(data[6:8])) <= len(data)-wrapperHeaderLength {
+		return modeWrapper
+	}
+	return modeApdu
+}
+
+func decode(w io.Writer, m mode, data []byte) error {
+	if m == modeAuto {
+		m = guessMode(data)
+	}
+	switch m {
+	case modeWrapper:
+		return decodeWrapper(w, data)
+	case modeHdlc:
+		return decodeHdlc(w, data)
+	default:
+		return decodeApdu(w, data)
+	}
+}
+
+func decodeWrapper(w io.Writer, data []byte) error {
+	for len(data) > 0 {
+		if len(data) < wrapperHeaderLength {
+			return fmt.Errorf("wrapper header of %d bytes, need %d", len(data), wrapperHeaderLength)
+		}
+		length := int(binary.BigEndian.Uint16(data[6:8]))
+		if len(data) < wrapperHeaderLength+length {
+			return fmt.Errorf("wrapper message of %d bytes, %d bytes left", length, len(data)-wrapperHeaderLength)
+		}
+		fmt.Fprintf(w, "wrapper: version=%d source=%d destination=%d length=%d\n",
+			binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4]),
+			binary.BigEndian.Uint16(data[4:6]), length)
+		if err := decodeApdu(w, data[wrapperHeaderLength:wrapperHeaderLength+length]); err != nil {
+			return err
+		}
+		data = data[wrapperHeaderLength+length:]
+	}
+	return nil
+}
+
+// decodeHdlc decodes the frames of the dump, the information of segmented
+// frames is put together before the APDU is decoded
+func decodeHdlc(w io.Writer, data []byte) error {
+	var information []byte
+	for len(data) > 0 {
+		format, err := hdlc.ExtractFormatFieldFromBytes(data)
+		if err != nil {
+			return err
+		}
+		length := int(format.Length) + 2
+		if len(data) < length {
+			return fmt.Errorf("HDLC frame of %d bytes, %d bytes left", length, len(data))
+		}
+
+		frame, err := hdlc.FrameFromBytes(data[:length])
+		if err != nil {
+			return fmt.Errorf("HDLC frame %x: %w", data[:length], err)
+		}
+		data = data[length:]
+
+		destination, source := frame.Addresses()
+		fmt.Fprintf(w, "HDLC %s: destination=%s source=%s\n",
+			strings.TrimPrefix(fmt.Sprintf("%T", frame), "*hdlc."), addressString(destination), addressString(source))
+
+		var segmented bool
+		switch f := frame.(type) {
+		case *hdlc.InformationFrame:
+			information = append(information, f.Information()...)
+			segmented = f.Segmented
+		case *hdlc.UnnumberedInformationFrame:
+			information = append(information, f.Information()...)
+			segmented = f.Segmented
+		default:
+			continue
+		}
+		if segmented {
+			continue
+		}
+
+		apdu := information
+		information = nil
+		for _, header := range []string{hdlc.LLCCommandHeader, hdlc.LLCResponseHeader} {
+			if strings.HasPrefix(string(apdu), header) {
+				fmt.Fprintf(w, "LLC: %x\n", apdu[:len(header)])
+				apdu = apdu[len(header):]
+				break
+			}
+		}
+		if err = decodeApdu(w, apdu); err != nil {
+			return err
+		}
+	}
+	if len(information) > 0 {
+		fmt.Fprintf(w, "incomplete segmented information: %x\n", information)
+	}
+	return nil
+}
+
+// addressString formats an HDLC address as logical/physical
+func addressString(address *hdlc.HdlcAddress) string {
+	if address == nil {
+		return "none"
+	}
+	if address.PhysicalAddress == nil {
+		return fmt.Sprint(address.LogicalAddress)
+	}
+	return fmt.Sprintf("%d/%d", address.LogicalAddress, *address.PhysicalAddress)
+}
+
+func decodeApdu(w io.Writer, data []byte) error {
+	apdu, err := acse.NewApduFactory().APDUFromBytes(data)
+	if err != nil {
+		return fmt.Errorf("APDU %x: %w", data, err)
+	}
+	fmt.Fprintf(w, "APDU: %s\n", apdu)
+
+	switch a := apdu.(type) {
+	case *xdlms.GetRequestNormal:
+		printAttribute(w, a.CosemAttribute)
+	case *xdlms.GetResponseNormal:
+		return printData(w, a.Data)
+	case *xdlms.SetRequestNormal:
+		printAttribute(w, a.CosemAttribute)
+		return printData(w, a.Data)
+	case *xdlms.ActionRequestNormal:
+		if a.CosemMethod != nil && a.CosemMethod.Instance != nil {
+			fmt.Fprintf(w, "  method: %d/%s/%d%s\n", a.CosemMethod.Interface,
+				a.CosemMethod.Instance.ToString("."), a.CosemMethod.Method, objectName(a.CosemMethod.Instance))
+		}
+		return printData(w, a.Data)
+	case *xdlms.ActionResponseNormalWithData:
+		return printData(w, a.Data)
+	case *xdlms.DataNotification:
+		return printData(w, a.Body)
+	}
+	return nil
+}
+
+func printAttribute(w io.Writer, attribute *cosem.CosemAttribute) {
+	if attribute == nil || attribute.Instance == nil {
+		return
+	}
+	fmt.Fprintf(w, "  attribute: %d/%s/%d%s\n", attribute.Interface,
+		attribute.Instance.ToString("."), attribute.Attribute, objectName(attribute.Instance))
+}
+
+// printData prints the DLMS data values as a tree
+func printData(w io.Writer, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "  data:")
+	for len(data) > 0 {
+		length, err := encoding.ValueLength(data)
+		if err != nil {
+			return fmt.Errorf("data %x: %w", data, err)
+		}
+		if err = printValue(w, data[:length], 2); err != nil {
+			return err
+		}
+		data = data[length:]
+	}
+	return nil
+}
+
+func printValue(w io.Writer, value []byte, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	tag := dlmsdata.DlmsDataTag(value[0])
+
+	if tag == dlmsdata.TagArray || tag == dlmsdata.TagStructure {
+		count, rest, err := encoding.GetAXdrLength(value[1:])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s%s[%d]\n", indent, tagName(tag), count)
+		for i := 0; i < count; i++ {
+			length, err := encoding.ValueLength(rest)
+			if err != nil {
+				return err
+			}
+			if err = printValue(w, rest[:length], depth+1); err != nil {
+				return err
+			}
+			rest = rest[length:]
+		}
+		return nil
+	}
+
+	decoded, err := encoding.DecodeValue(value)
+	if err != nil {
+		return err
+	}
+	if octets, ok := decoded.([]byte); ok {
+		fmt.Fprintf(w, "%s%s %x%s\n", indent, tagName(tag), octets, annotate(octets))
+		return nil
+	}
+	fmt.Fprintf(w, "%s%s %v\n", indent, tagName(tag), decoded)
+	return nil
+}
+
+// annotate describes octet strings that look like a logical name or a date-time
+func annotate(octets []byte) string {
+	switch len(octets) {
+	case 6:
+		logicalName, err := cosem.FromBytes(octets)
+		if err != nil {
+			return ""
+		}
+		return " (" + logicalName.ToString(".") + objectName(logicalName) + ")"
+	case 12:
+		dateTime, _, err := dlmsdata.DateTimeFromBytes(octets)
+		if err != nil {
+			return ""
+		}
+		return " (" + dateTime.String() + ")"
+	}
+	return ""
+}
+
+// objectName returns the name of the object in the IDIS object model
+func objectName(logicalName *cosem.Obis) string {
+	if descriptor := idis.Find(logicalName); descriptor != nil {
+		return " " + descriptor.Name
+	}
+	return ""
+}
+
+var tagNames = map[dlmsdata.DlmsDataTag]string{
+	dlmsdata.TagNull:               "null-data",
+	dlmsdata.TagArray:              "array",
+	dlmsdata.TagStructure:          "structure",
+	dlmsdata.TagBoolean:            "boolean",
+	dlmsdata.TagBitString:          "bit-string",
+	dlmsdata.TagDoubleLong:         "double-long",
+	dlmsdata.TagDoubleLongUnsigned: "double-long-unsigned",
+	dlmsdata.TagOctetString:        "octet-string",
+	dlmsdata.TagVisibleString:      "visible-string",
+	dlmsdata.TagUTF8String:         "utf8-string",
+	dlmsdata.TagBCD:                "bcd",
+	dlmsdata.TagInteger:            "integer",
+	dlmsdata.TagLong:               "long",
+	dlmsdata.TagUnsigned:           "unsigned",
+	dlmsdata.TagLongUnsigned:       "long-unsigned",
+	dlmsdata.TagCompactArray:       "compact-array",
+	dlmsdata.TagLong64:             "long64",
+	dlmsdata.TagLong64Unsigned:     "long64-unsigned",
+	dlmsdata.TagEnum:               "enum",
+	dlmsdata.TagFloat32:            "float32",
+	dlmsdata.TagFloat64:            "float64",
+	dlmsdata.TagDateTime:           "date-time",
+	dlmsdata.TagDate:               "date",
+	dlmsdata.TagTime:               "time",
+	dlmsdata.TagDontCare:           "dont-care",
+}
+
+func tagName(tag dlmsdata.DlmsDataTag) string {
+	if name, ok := tagNames[tag]; ok {
+		return name
+	}
+	return fmt.Sprintf("tag-%d", tag)
+}
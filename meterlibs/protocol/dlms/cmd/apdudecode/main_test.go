@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+)
+
+// getResponse returns a structure of the logical name of the active energy
+// import register and 1234
+const getResponse = "C401C100" + "0202" + "09060100010800FF" + "06000004D2"
+
+const getResponseTree = `  data:
+    structure[2]
+      octet-string 0100010800ff (1.0.1.8.0.255 Active energy import (+A))
+      double-long-unsigned 1234
+`
+
+func TestParseHex(t *testing.T) {
+	data, err := parseHex("0xC4 01:c1\n00")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xC4, 0x01, 0xC1, 0x00}, data)
+
+	_, err = parseHex("C4 0")
+	assert.Error(t, err)
+	_, err = parseHex(" ")
+	assert.Error(t, err)
+}
+
+func TestDecode_Apdu(t *testing.T) {
+	data, err := parseHex(getResponse)
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	require.NoError(t, decode(&output, modeAuto, data))
+	assert.Equal(t, "APDU: GetResponseNormal(invoke_id=1, data=020209060100010800ff06000004d2)\n"+getResponseTree, output.String())
+}
+
+func TestDecode_Wrapper(t *testing.T) {
+	data, err := parseHex("0001 0001 0010 0013" + getResponse)
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	require.NoError(t, decode(&output, modeAuto, data))
+	assert.Contains(t, output.String(), "wrapper: version=1 source=1 destination=16 length=19\nAPDU: GetResponseNormal")
+	assert.Contains(t, output.String(), getResponseTree)
+}
+
+func TestDecode_SegmentedHdlc(t *testing.T) {
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+	server, err := hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+
+	apdu, err := hex.DecodeString(getResponse)
+	require.NoError(t, err)
+	first, err := hdlc.NewInformationFrame(client, server, apdu[:8], 0, 1, true, false)
+	require.NoError(t, err)
+	first.LlcHeader = []byte(hdlc.LLCResponseHeader)
+	last, err := hdlc.NewInformationFrame(client, server, apdu[8:], 1, 1, false, true)
+	require.NoError(t, err)
+	last.LlcHeader = nil
+
+	var output bytes.Buffer
+	require.NoError(t, decode(&output, modeAuto, append(first.ToBytes(), last.ToBytes()...)))
+	assert.Contains(t, output.String(), "HDLC InformationFrame: destination=16 source=1\nHDLC InformationFrame: destination=16 source=1\nLLC: e6e700\nAPDU: GetResponseNormal")
+	assert.Contains(t, output.String(), getResponseTree)
+}
@@ -0,0 +1,299 @@
+// Command dlmsread reads objects or a profile from a meter and prints them as
+// JSON. It is the reference use of the client for field engineers:
+//
+//	dlmsread -host 10.0.0.7 -password 12345678 3/1.0.1.8.0.255/2 0.0.1.0.0.255
+//	dlmsread -device /dev/ttyUSB0 -serial-number 12345678 \
+//	    -profile 1.0.99.1.0.255 -from 2026-10-01T00:00:00Z -to 2026-10-02T00:00:00Z
+//
+// An object is class/logical name/attribute, or a logical name of the IDIS
+// object model whose attribute 2 is read.
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/idis"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/serialport"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/tcp"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/wrapper"
+)
+
+// result is the JSON printed by dlmsread
+type result struct {
+	Objects []objectResult `json:"objects,omitempty"`
+	Profile *profileResult `json:"profile,omitempty"`
+}
+
+// objectResult is the value read from an attribute, or the error
+type objectResult struct {
+	Object string      `json:"object"`
+	Value  interface{} `json:"value,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// profileResult is the entries of a profile captured in a range
+type profileResult struct {
+	Profile string        `json:"profile"`
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Entries []interface{} `json:"entries"`
+}
+
+func main() {
+	host := flag.String("host", "", "host of the meter, over TCP with the wrapper")
+	port := flag.Int("port", 4059, "TCP port of the meter")
+	device := flag.String("device", "", "serial port of the meter, over HDLC")
+	baudRate := flag.Int("baud", 9600, "baud rate of the serial port")
+	useHdlc := flag.Bool("hdlc", false, "use HDLC over TCP instead of the wrapper")
+	clientAddress := flag.Int("client", 16, "client address")
+	serverAddress := flag.Int("server", 1, "logical address of the server")
+	serialNumber := flag.String("serial-number", "", "serial number giving the HDLC physical address")
+	password := flag.String("password", "", "password of low level security, none when empty")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of a request")
+	profile := flag.String("profile", "", "logical name of a profile generic to read")
+	from := flag.String("from", "", "start of the profile range, RFC 3339")
+	to := flag.String("to", "", "end of the profile range, RFC 3339, now by default")
+	verbose := flag.Bool("v", false, "log the APDUs")
+	flag.Parse()
+
+	if err := run(&options{
+		host:          *host,
+		port:          *port,
+		device:        *device,
+		baudRate:      *baudRate,
+		hdlc:          *useHdlc,
+		clientAddress: *clientAddress,
+		serverAddress: *serverAddress,
+		serialNumber:  *serialNumber,
+		password:      *password,
+		timeout:       *timeout,
+		profile:       *profile,
+		from:          *from,
+		to:            *to,
+		verbose:       *verbose,
+		objects:       flag.Args(),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "dlmsread: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type options struct {
+	host          string
+	port          int
+	device        string
+	baudRate      int
+	hdlc          bool
+	clientAddress int
+	serverAddress int
+	serialNumber  string
+	password      string
+	timeout       time.Duration
+	profile       string
+	from          string
+	to            string
+	verbose       bool
+	objects       []string
+}
+
+func run(o *options) error {
+	attributes := make([]*cosem.CosemAttribute, 0, len(o.objects))
+	for _, object := range o.objects {
+		attribute, err := parseObject(object)
+		if err != nil {
+			return err
+		}
+		attributes = append(attributes, attribute)
+	}
+	if len(attributes) == 0 && o.profile == "" {
+		return fmt.Errorf("nothing to read, give objects or a profile")
+	}
+
+	transport, err := newTransport(o)
+	if err != nil {
+		return err
+	}
+
+	settings := client.NewSettings(o.clientAddress, o.serverAddress)
+	settings.Timeout = o.timeout
+	if o.password != "" {
+		settings.Authentication = enumerations.AuthenticationMechanismLLS
+		settings.Password = []byte(o.password)
+	}
+	c := client.New(transport, settings)
+	defer c.Close()
+	if o.verbose {
+		c.SetLogger(log.New(os.Stderr, "", log.LstdFlags))
+	}
+
+	if err = c.Connect(); err != nil {
+		return err
+	}
+	defer c.Disconnect()
+	if err = c.Associate(); err != nil {
+		return err
+	}
+	defer c.Release()
+
+	output := &result{}
+	for _, attribute := range attributes {
+		object := objectResult{Object: objectString(attribute)}
+		value, err := c.GetValue(attribute, nil)
+		if err != nil {
+			object.Error = err.Error()
+		} else {
+			object.Value = jsonValue(value)
+		}
+		output.Objects = append(output.Objects, object)
+	}
+
+	if o.profile != "" {
+		if output.Profile, err = readProfile(c, o); err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func newTransport(o *options) (dlms.Transport, error) {
+	var physical *int
+	if o.serialNumber != "" {
+		serialNumber, err := hdlc.ParseSerialNumber(o.serialNumber)
+		if err != nil {
+			return nil, err
+		}
+		address, err := hdlc.DefaultPhysicalAddress.PhysicalAddress(serialNumber)
+		if err != nil {
+			return nil, err
+		}
+		physical = &address
+	}
+
+	var transport dlms.Transport
+	switch {
+	case o.host != "" && o.device != "":
+		return nil, fmt.Errorf("give either a host or a device")
+	case o.host != "":
+		transport = tcp.New(o.port, o.host, o.timeout)
+		if !o.hdlc {
+			if physical != nil {
+				return nil, fmt.Errorf("the serial number gives an HDLC address, use -hdlc")
+			}
+			return wrapper.New(transport, o.clientAddress, o.serverAddress), nil
+		}
+	case o.device != "":
+		transport = serialport.New(o.device, o.baudRate)
+	default:
+		return nil, fmt.Errorf("give the host or the device of the meter")
+	}
+
+	framed := hdlc.New(transport, o.clientAddress, o.serverAddress)
+	if physical != nil {
+		framed.SetPhysicalAddress(*physical)
+	}
+	return framed, nil
+}
+
+func readProfile(c *client.Client, o *options) (*profileResult, error) {
+	profile, err := cosem.FromString(o.profile)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", o.profile, err)
+	}
+	if o.from == "" {
+		return nil, fmt.Errorf("the profile range needs -from")
+	}
+	from, err := time.Parse(time.RFC3339, o.from)
+	if err != nil {
+		return nil, fmt.Errorf("-from: %w", err)
+	}
+	to := time.Now()
+	if o.to != "" {
+		if to, err = time.Parse(time.RFC3339, o.to); err != nil {
+			return nil, fmt.Errorf("-to: %w", err)
+		}
+	}
+
+	cursor := client.NewProfileCursor(client.NewMemoryProfileCursorStore(), "", profile, from)
+	entries, err := c.ReadProfile(cursor, to)
+	if err != nil {
+		return nil, err
+	}
+	return &profileResult{
+		Profile: profile.ToString("."),
+		From:    from,
+		To:      to,
+		Entries: jsonValue(entries).([]interface{}),
+	}, nil
+}
+
+// parseObject parses class/logical name/attribute. A logical name alone is
+// attribute 2 of the object of the IDIS object model.
+func parseObject(object string) (*cosem.CosemAttribute, error) {
+	parts := strings.Split(object, "/")
+	if len(parts) == 1 {
+		logicalName, err := cosem.FromString(object)
+		if err != nil {
+			return nil, fmt.Errorf("object %q: %w", object, err)
+		}
+		descriptor := idis.Find(logicalName)
+		if descriptor == nil {
+			return nil, fmt.Errorf("object %q is not in the IDIS object model, give class/logical name/attribute", object)
+		}
+		return cosem.NewCosemAttribute(descriptor.Interface, logicalName, 2), nil
+	}
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("object %q is not class/logical name/attribute", object)
+	}
+
+	class, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("class of object %q: %w", object, err)
+	}
+	logicalName, err := cosem.FromString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("logical name of object %q: %w", object, err)
+	}
+	attribute, err := strconv.ParseUint(parts[2], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("attribute of object %q: %w", object, err)
+	}
+	return cosem.NewCosemAttribute(enumerations.CosemInterface(class), logicalName, uint8(attribute)), nil
+}
+
+// objectString formats an attribute as parseObject reads it
+func objectString(attribute *cosem.CosemAttribute) string {
+	return fmt.Sprintf("%d/%s/%d", attribute.Interface, attribute.Instance.ToString("."), attribute.Attribute)
+}
+
+// jsonValue converts a decoded value for JSON: octet strings are printed in
+// hex rather than base64
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return hex.EncodeToString(v)
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = jsonValue(item)
+		}
+		return converted
+	default:
+		return value
+	}
+}
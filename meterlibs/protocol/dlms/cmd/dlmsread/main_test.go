@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+func TestParseObject(t *testing.T) {
+	attribute, err := parseObject("3/1.0.1.8.0.255/2")
+	require.NoError(t, err)
+	assert.Equal(t, enumerations.CosemInterfaceRegister, attribute.Interface)
+	assert.Equal(t, uint8(2), attribute.Attribute)
+	assert.Equal(t, "3/1.0.1.8.0.255/2", objectString(attribute))
+
+	attribute, err = parseObject("0.0.1.0.0.255")
+	require.NoError(t, err)
+	assert.Equal(t, "8/0.0.1.0.0.255/2", objectString(attribute))
+
+	_, err = parseObject("1.2.3.4.5.6")
+	assert.Error(t, err)
+	_, err = parseObject("3/1.0.1.8.0.255")
+	assert.Error(t, err)
+	_, err = parseObject("3/1.0.1.8.0.255/256")
+	assert.Error(t, err)
+}
+
+func TestJSONValue(t *testing.T) {
+	value := jsonValue([]interface{}{[]byte{0x01, 0xAB}, uint32(7), []interface{}{[]byte{}}})
+	assert.Equal(t, []interface{}{"01ab", uint32(7), []interface{}{""}}, value)
+}
@@ -0,0 +1,73 @@
+// Package conformance holds the example APDUs of the DLMS UA Green Book as
+// byte fixtures. The tests of the package check that they are decoded and
+// encoded again byte for byte, so that parser changes can't drift from the
+// standard encodings.
+package conformance
+
+import (
+	"encoding/hex"
+)
+
+// Vector is an example APDU
+type Vector struct {
+	Name string
+	APDU []byte
+}
+
+// Vectors are the example exchanges with logical name referencing and
+// invoke id 0xC1: associations with the different authentication levels,
+// release, GET, SET and ACTION
+var Vectors = []Vector{
+	{"AARQ without authentication", mustDecodeHex("601DA109060760857405080101BE10040E01000000065F1F0400001E1DFFFF")},
+	{"AARQ with low level security", mustDecodeHex("6036A1090607608574050801018A0207808B0760857405080201AC0A80083132333435363738BE10040E01000000065F1F0400001E1DFFFF")},
+	{"AARE accepted", mustDecodeHex("6129A109060760857405080101A203020100A305A103020100BE10040E0800065F1F040000501F01F40007")},
+	{"AARE rejected, authentication failure", mustDecodeHex("6129A109060760857405080101A203020101A305A10302010DBE10040E0800065F1F040000501F01F40007")},
+	{"RLRQ normal", mustDecodeHex("6203800100")},
+	{"RLRE normal", mustDecodeHex("6303800100")},
+	{"GET request of the clock time", mustDecodeHex("C001C100080000010000FF0200")},
+	{"GET response with the clock time", mustDecodeHex("C401C100090C07DE0C0A030C1E00FF800000")},
+	{"GET response with object-unavailable", mustDecodeHex("C401C1010B")},
+	{"GET response with the first data block", mustDecodeHex("C402C1000000000100050102120001")},
+	{"GET request of the next data block", mustDecodeHex("C002C100000001")},
+	{"SET request of the clock time", mustDecodeHex("C101C100080000010000FF0200090C07DE0C0A030C1E00FF800000")},
+	{"SET response success", mustDecodeHex("C501C100")},
+	{"SET response with read-write-denied", mustDecodeHex("C501C103")},
+	{"ACTION request executing a script", mustDecodeHex("C301C1000900000A0000FF0101120001")},
+	{"ACTION response success", mustDecodeHex("C701C10000")},
+	{"ACTION response with return data", mustDecodeHex("C701C10001000600000001")},
+	{"exception response", mustDecodeHex("D80103")},
+	{"data notification", mustDecodeHex("0F0000000100090C07DE0C0A030C1E00FF800000")},
+}
+
+// CipheringExample is the example of an authenticated and encrypted
+// glo-get-request of the Green Book
+var CipheringExample = struct {
+	SystemTitle       []byte
+	InvocationCounter uint32
+	SecurityControl   byte
+	EncryptionKey     []byte
+	AuthenticationKey []byte
+	// Plaintext is the GET request of the clock time
+	Plaintext []byte
+	// Ciphertext is the ciphered request followed by the authentication tag
+	Ciphertext []byte
+	// APDU is the glo-get-request: tag, length, security header, ciphertext
+	APDU []byte
+}{
+	SystemTitle:       mustDecodeHex("4D4D4D0000BC614E"),
+	InvocationCounter: 0x01234567,
+	SecurityControl:   0x30,
+	EncryptionKey:     mustDecodeHex("000102030405060708090A0B0C0D0E0F"),
+	AuthenticationKey: mustDecodeHex("D0D1D2D3D4D5D6D7D8D9DADBDCDDDEDF"),
+	Plaintext:         mustDecodeHex("C0010000080000010000FF0200"),
+	Ciphertext:        mustDecodeHex("411312FF935A47566827C467BC7D825C3BE4A77C3FCC056B6B"),
+	APDU:              mustDecodeHex("C81E3001234567411312FF935A47566827C467BC7D825C3BE4A77C3FCC056B6B"),
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,91 @@
+package conformance_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/conformance"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+func TestVectors_RoundTrip(t *testing.T) {
+	for _, vector := range conformance.Vectors {
+		t.Run(vector.Name, func(t *testing.T) {
+			apdu, err := acse.NewApduFactory().APDUFromBytes(vector.APDU)
+			require.NoError(t, err)
+			assert.Equal(t, vector.APDU[0], apdu.Tag())
+
+			encoded, err := apdu.ToBytes()
+			require.NoError(t, err)
+			assert.Equal(t, vector.APDU, encoded)
+		})
+	}
+}
+
+func TestVectors_Encode(t *testing.T) {
+	invokeID, err := xdlms.NewInvokeIdAndPriority(1, true, true)
+	require.NoError(t, err)
+	clock := cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, &cosem.Obis{A: 0, B: 0, C: 1, D: 0, E: 0, F: 255}, 2)
+	dateTime := []byte{0x09, 0x0C, 0x07, 0xDE, 0x0C, 0x0A, 0x03, 0x0C, 0x1E, 0x00, 0xFF, 0x80, 0x00, 0x00}
+	script := cosem.NewCosemMethod(enumerations.CosemInterfaceScriptTable, &cosem.Obis{A: 0, B: 0, C: 10, D: 0, E: 0, F: 255}, 1)
+
+	conformanceBits, err := (&xdlms.Conformance{}).FromBytes([]byte{0x00, 0x00, 0x1E, 0x1D})
+	require.NoError(t, err)
+	initiate := xdlms.NewInitiateRequest(conformanceBits, 0xFFFF, 6, true, nil, nil)
+
+	encoded := map[string]xdlms.Apdu{
+		"AARQ without authentication": acse.NewApplicationAssociationRequest(
+			acse.NewUserInformation(initiate), nil, nil, nil, false, nil, nil),
+		"AARQ with low level security":      acse.NewLlsApplicationAssociationRequest("12345678", acse.NewUserInformation(initiate)),
+		"GET request of the clock time":     xdlms.NewGetRequestNormal(clock, invokeID, nil),
+		"SET request of the clock time":     xdlms.NewSetRequestNormal(clock, dateTime, nil, invokeID),
+		"ACTION request executing a script": xdlms.NewActionRequestNormal(script, []byte{0x12, 0x00, 0x01}, invokeID),
+	}
+	for _, vector := range conformance.Vectors {
+		apdu, ok := encoded[vector.Name]
+		if !ok {
+			continue
+		}
+		delete(encoded, vector.Name)
+		data, err := apdu.ToBytes()
+		require.NoError(t, err, vector.Name)
+		assert.Equal(t, vector.APDU, data, vector.Name)
+	}
+	assert.Empty(t, encoded, "vectors not found")
+}
+
+func TestCipheringExample(t *testing.T) {
+	example := conformance.CipheringExample
+	securityControl := security.SecurityControl(example.SecurityControl)
+	assert.True(t, securityControl.Authenticated())
+	assert.True(t, securityControl.Encrypted())
+
+	ciphertext, err := security.Encrypt(securityControl, example.SystemTitle, example.InvocationCounter,
+		example.EncryptionKey, example.AuthenticationKey, example.Plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, example.Ciphertext, ciphertext)
+
+	// glo-get-request: tag 200, length, security control, invocation counter
+	apdu := []byte{200, byte(5 + len(ciphertext)), example.SecurityControl}
+	apdu = binary.BigEndian.AppendUint32(apdu, example.InvocationCounter)
+	apdu = append(apdu, ciphertext...)
+	assert.Equal(t, example.APDU, apdu)
+
+	plaintext, err := security.Decrypt(securityControl, example.SystemTitle, example.InvocationCounter,
+		example.EncryptionKey, example.AuthenticationKey, example.Ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, example.Plaintext, plaintext)
+
+	tampered := bytes.Clone(example.Ciphertext)
+	tampered[0] ^= 0x01
+	_, err = security.Decrypt(securityControl, example.SystemTitle, example.InvocationCounter,
+		example.EncryptionKey, example.AuthenticationKey, tampered)
+	assert.Error(t, err)
+}
@@ -0,0 +1,302 @@
+package cosem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Account interface class (111)
+const (
+	AccountAttributeModeAndStatus                uint8 = 2
+	AccountAttributeCurrentCreditInUse           uint8 = 3
+	AccountAttributeCurrentCreditStatus          uint8 = 4
+	AccountAttributeAvailableCredit              uint8 = 5
+	AccountAttributeAmountToClear                uint8 = 6
+	AccountAttributeClearanceThreshold           uint8 = 7
+	AccountAttributeAggregatedDebt               uint8 = 8
+	AccountAttributeCreditReferenceList          uint8 = 9
+	AccountAttributeChargeReferenceList          uint8 = 10
+	AccountAttributeCreditChargeConfiguration    uint8 = 11
+	AccountAttributeTokenGatewayConfiguration    uint8 = 12
+	AccountAttributeActivationTime               uint8 = 13
+	AccountAttributeClosureTime                  uint8 = 14
+	AccountAttributeCurrency                     uint8 = 15
+	AccountAttributeLowCreditThreshold           uint8 = 16
+	AccountAttributeNextCreditAvailableThreshold uint8 = 17
+	AccountAttributeMaxProvision                 uint8 = 18
+	AccountAttributeMaxProvisionPeriod           uint8 = 19
+)
+
+// Methods of the Account interface class (111), they take no data
+const (
+	AccountMethodActivateAccount uint8 = 1
+	AccountMethodCloseAccount    uint8 = 2
+	AccountMethodResetAccount    uint8 = 3
+)
+
+// AccountMode is how the account is paid
+type AccountMode uint8
+
+const (
+	AccountModeCredit     AccountMode = 1
+	AccountModePrepayment AccountMode = 2
+)
+
+// AccountStatus is the life cycle state of an account
+type AccountStatus uint8
+
+const (
+	AccountStatusNew    AccountStatus = 1
+	AccountStatusActive AccountStatus = 2
+	AccountStatusClosed AccountStatus = 3
+)
+
+// CreditChargeConfiguration links a credit to a charge it pays, the bits
+// of the collection configuration tell when the charge is collected from
+// the credit
+type CreditChargeConfiguration struct {
+	CreditReference         *Obis
+	ChargeReference         *Obis
+	CollectionConfiguration *dlmsdata.BitString
+}
+
+// TokenGatewayConfiguration is the percentage of the token amounts given to
+// a credit
+type TokenGatewayConfiguration struct {
+	CreditReference *Obis
+	TokenProportion uint8
+}
+
+// Currency is the currency of the amounts of an account, the amounts are
+// multiplied by 10^Scale to get a value in the currency
+type Currency struct {
+	Name  string
+	Scale int8
+	// Unit is the currency_unit enum, 0 time, 1 consumption, 2 monetary
+	Unit uint8
+}
+
+// Account is an Account object (class 111) of payment metering. It holds
+// the mode and the status of the account and the balance of its credits and
+// charges. All the amounts are in the Currency.
+type Account struct {
+	LogicalName                  *Obis
+	Mode                         AccountMode
+	Status                       AccountStatus
+	CurrentCreditInUse           uint8
+	CurrentCreditStatus          *dlmsdata.BitString
+	AvailableCredit              int32
+	AmountToClear                int32
+	ClearanceThreshold           int32
+	AggregatedDebt               int32
+	CreditReferenceList          []*Obis
+	ChargeReferenceList          []*Obis
+	CreditChargeConfiguration    []*CreditChargeConfiguration
+	TokenGatewayConfiguration    []*TokenGatewayConfiguration
+	ActivationTime               time.Time
+	ClosureTime                  time.Time
+	Currency                     *Currency
+	LowCreditThreshold           int32
+	NextCreditAvailableThreshold int32
+	MaxProvision                 uint16
+	MaxProvisionPeriod           uint32
+}
+
+// NewAccount creates a new Account, the attributes are filled in with
+// FromAttribute or set directly before writing them
+func NewAccount(logicalName *Obis) *Account {
+	return &Account{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the account
+func (a *Account) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceAccount, a.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the account
+func (a *Account) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceAccount, a.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the Account
+func (a *Account) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case AccountAttributeModeAndStatus:
+		err = r.accountModeAndStatus(a)
+	case AccountAttributeCurrentCreditInUse:
+		value, err = r.unsigned(dlmsdata.TagUnsigned, 1)
+		a.CurrentCreditInUse = uint8(value)
+	case AccountAttributeCurrentCreditStatus:
+		a.CurrentCreditStatus, err = r.bitString()
+	case AccountAttributeAvailableCredit:
+		a.AvailableCredit, err = r.doubleLong()
+	case AccountAttributeAmountToClear:
+		a.AmountToClear, err = r.doubleLong()
+	case AccountAttributeClearanceThreshold:
+		a.ClearanceThreshold, err = r.doubleLong()
+	case AccountAttributeAggregatedDebt:
+		a.AggregatedDebt, err = r.doubleLong()
+	case AccountAttributeCreditReferenceList:
+		a.CreditReferenceList, err = r.obisList()
+	case AccountAttributeChargeReferenceList:
+		a.ChargeReferenceList, err = r.obisList()
+	case AccountAttributeCreditChargeConfiguration:
+		a.CreditChargeConfiguration, err = r.creditChargeConfiguration()
+	case AccountAttributeTokenGatewayConfiguration:
+		a.TokenGatewayConfiguration, err = r.tokenGatewayConfiguration()
+	case AccountAttributeActivationTime:
+		a.ActivationTime, err = r.dateTime()
+	case AccountAttributeClosureTime:
+		a.ClosureTime, err = r.dateTime()
+	case AccountAttributeCurrency:
+		a.Currency, err = r.currency()
+	case AccountAttributeLowCreditThreshold:
+		a.LowCreditThreshold, err = r.doubleLong()
+	case AccountAttributeNextCreditAvailableThreshold:
+		a.NextCreditAvailableThreshold, err = r.doubleLong()
+	case AccountAttributeMaxProvision:
+		value, err = r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		a.MaxProvision = uint16(value)
+	case AccountAttributeMaxProvisionPeriod:
+		value, err = r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+		a.MaxProvisionPeriod = uint32(value)
+	default:
+		return fmt.Errorf("account has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("account attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of an attribute of the Account. Only the
+// thresholds and the provision limits can be written, the balances change
+// with the credits and charges.
+func (a *Account) ToAttribute(attribute uint8) ([]byte, error) {
+	switch attribute {
+	case AccountAttributeClearanceThreshold:
+		return encodeDoubleLong(a.ClearanceThreshold), nil
+	case AccountAttributeLowCreditThreshold:
+		return encodeDoubleLong(a.LowCreditThreshold), nil
+	case AccountAttributeNextCreditAvailableThreshold:
+		return encodeDoubleLong(a.NextCreditAvailableThreshold), nil
+	case AccountAttributeMaxProvision:
+		return encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(a.MaxProvision)), nil
+	case AccountAttributeMaxProvisionPeriod:
+		return encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, uint64(a.MaxProvisionPeriod)), nil
+	default:
+		return nil, fmt.Errorf("account attribute %d can't be written", attribute)
+	}
+}
+
+func (r *dataReader) accountModeAndStatus(a *Account) error {
+	if _, err := r.structure(2); err != nil {
+		return err
+	}
+	mode, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err != nil {
+		return fmt.Errorf("account_mode: %w", err)
+	}
+	status, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err != nil {
+		return fmt.Errorf("account_status: %w", err)
+	}
+	a.Mode = AccountMode(mode)
+	a.Status = AccountStatus(status)
+	return nil
+}
+
+// obisList reads an array of logical names
+func (r *dataReader) obisList() ([]*Obis, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Obis, 0, count)
+	for i := 0; i < count; i++ {
+		logicalName, err := r.obis()
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		result = append(result, logicalName)
+	}
+	return result, nil
+}
+
+func (r *dataReader) creditChargeConfiguration() ([]*CreditChargeConfiguration, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*CreditChargeConfiguration, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(3); err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		configuration := &CreditChargeConfiguration{}
+		if configuration.CreditReference, err = r.obis(); err != nil {
+			return nil, fmt.Errorf("element %d: credit_reference: %w", i, err)
+		}
+		if configuration.ChargeReference, err = r.obis(); err != nil {
+			return nil, fmt.Errorf("element %d: charge_reference: %w", i, err)
+		}
+		if configuration.CollectionConfiguration, err = r.bitString(); err != nil {
+			return nil, fmt.Errorf("element %d: collection_configuration: %w", i, err)
+		}
+		result = append(result, configuration)
+	}
+	return result, nil
+}
+
+func (r *dataReader) tokenGatewayConfiguration() ([]*TokenGatewayConfiguration, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*TokenGatewayConfiguration, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		creditReference, err := r.obis()
+		if err != nil {
+			return nil, fmt.Errorf("element %d: credit_reference: %w", i, err)
+		}
+		proportion, err := r.unsigned(dlmsdata.TagUnsigned, 1)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: token_proportion: %w", i, err)
+		}
+		result = append(result, &TokenGatewayConfiguration{CreditReference: creditReference, TokenProportion: uint8(proportion)})
+	}
+	return result, nil
+}
+
+func (r *dataReader) currency() (*Currency, error) {
+	if _, err := r.structure(3); err != nil {
+		return nil, err
+	}
+	name, err := r.utf8String()
+	if err != nil {
+		return nil, fmt.Errorf("currency_name: %w", err)
+	}
+	scale, err := r.signed(dlmsdata.TagInteger)
+	if err != nil {
+		return nil, fmt.Errorf("currency_scale: %w", err)
+	}
+	unit, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err != nil {
+		return nil, fmt.Errorf("currency_unit: %w", err)
+	}
+	return &Currency{Name: name, Scale: scale, Unit: uint8(unit)}, nil
+}
@@ -0,0 +1,178 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Array manager interface class (123)
+const (
+	ArrayManagerAttributeArrayObjectList uint8 = 2
+)
+
+// Methods of the Array manager interface class (123). The indexes of the
+// methods start at 1 for the first element of the array.
+const (
+	ArrayManagerMethodNumberOfEntries uint8 = 1
+	ArrayManagerMethodRetrieveByIndex uint8 = 2
+	ArrayManagerMethodUpdateByIndex   uint8 = 3
+	ArrayManagerMethodInsertByIndex   uint8 = 4
+	ArrayManagerMethodRemoveByIndex   uint8 = 5
+)
+
+// ManagedArray is an element of the array_object_list, an array attribute
+// managed by the Array manager under an id
+type ManagedArray struct {
+	ID             uint8
+	Interface      enumerations.CosemInterface
+	LogicalName    *Obis
+	AttributeIndex int8
+}
+
+// String implements fmt.Stringer
+func (a *ManagedArray) String() string {
+	return fmt.Sprintf("%d: %d/%s/%d", a.ID, a.Interface, a.LogicalName, a.AttributeIndex)
+}
+
+// ArrayManager is an Array manager object (class 123). It reads and edits
+// some elements of large array attributes, e.g. white lists, without
+// transferring the whole attribute.
+type ArrayManager struct {
+	LogicalName     *Obis
+	ArrayObjectList []*ManagedArray
+}
+
+// NewArrayManager creates a new ArrayManager, the list is filled in with
+// FromAttribute
+func NewArrayManager(logicalName *Obis) *ArrayManager {
+	return &ArrayManager{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the array manager
+func (m *ArrayManager) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceArrayManager, m.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the array manager
+func (m *ArrayManager) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceArrayManager, m.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the ArrayManager
+func (m *ArrayManager) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+
+	switch attribute {
+	case ArrayManagerAttributeArrayObjectList:
+		m.ArrayObjectList, err = r.managedArrays()
+	default:
+		return fmt.Errorf("array manager has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("array manager attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// Array returns the managed array with the given id
+func (m *ArrayManager) Array(id uint8) (*ManagedArray, bool) {
+	for _, array := range m.ArrayObjectList {
+		if array.ID == id {
+			return array, true
+		}
+	}
+	return nil, false
+}
+
+// ArrayIDToBytes encodes the parameter of the number_of_entries method
+func ArrayIDToBytes(id uint8) []byte {
+	return encodeUnsigned(dlmsdata.TagUnsigned, 1, uint64(id))
+}
+
+// ArrayRangeToBytes encodes the parameter of the retrieve_by_index and
+// remove_by_index methods, the elements from and to the given indexes
+func ArrayRangeToBytes(id uint8, from uint32, to uint32) []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeUnsigned(dlmsdata.TagUnsigned, 1, uint64(id))...)
+	result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+	result = append(result, encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, uint64(from))...)
+	return append(result, encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, uint64(to))...)
+}
+
+// ArrayEntriesToBytes encodes the parameter of the update_by_index and
+// insert_by_index methods, the A-XDR encoded entries written from the given
+// index
+func ArrayEntriesToBytes(id uint8, index uint32, entries [][]byte) []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeUnsigned(dlmsdata.TagUnsigned, 1, uint64(id))...)
+	result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+	result = append(result, encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, uint64(index))...)
+	result = append(result, encodeHeader(dlmsdata.TagArray, len(entries))...)
+	for _, entry := range entries {
+		result = append(result, entry...)
+	}
+	return result
+}
+
+// ParseNumberOfEntries decodes the return data of the number_of_entries
+// method. Meters send it as long-unsigned or double-long-unsigned.
+func ParseNumberOfEntries(data []byte) (uint32, error) {
+	r := &dataReader{data: data}
+	var value uint64
+	var err error
+	if r.next(dlmsdata.TagLongUnsigned) {
+		value, err = r.unsigned(dlmsdata.TagLongUnsigned, 2)
+	} else {
+		value, err = r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+	}
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("number of entries: %w", err)
+	}
+	return uint32(value), nil
+}
+
+func (r *dataReader) managedArrays() ([]*ManagedArray, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ManagedArray, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(4); err != nil {
+			return nil, fmt.Errorf("array %d: %w", i, err)
+		}
+		id, err := r.unsigned(dlmsdata.TagUnsigned, 1)
+		if err != nil {
+			return nil, fmt.Errorf("array %d: id: %w", i, err)
+		}
+		classID, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		if err != nil {
+			return nil, fmt.Errorf("array %d: class_id: %w", i, err)
+		}
+		logicalName, err := r.obis()
+		if err != nil {
+			return nil, fmt.Errorf("array %d: logical_name: %w", i, err)
+		}
+		attributeIndex, err := r.signed(dlmsdata.TagInteger)
+		if err != nil {
+			return nil, fmt.Errorf("array %d: attribute_index: %w", i, err)
+		}
+		result = append(result, &ManagedArray{
+			ID:             uint8(id),
+			Interface:      enumerations.CosemInterface(classID),
+			LogicalName:    logicalName,
+			AttributeIndex: attributeIndex,
+		})
+	}
+	return result, nil
+}
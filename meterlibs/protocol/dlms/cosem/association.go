@@ -37,6 +37,16 @@ func NewAttributeAccessRights(attribute uint8, accessRights []AccessRight, acces
 	}
 }
 
+// Has tells if the right is granted for the attribute
+func (a *AttributeAccessRights) Has(right AccessRight) bool {
+	for _, granted := range a.AccessRights {
+		if granted == right {
+			return true
+		}
+	}
+	return false
+}
+
 // MethodAccessRights represents access rights for a method
 type MethodAccessRights struct {
 	Method       uint8
@@ -51,6 +61,17 @@ func NewMethodAccessRights(method uint8, accessRights []AccessRight) *MethodAcce
 	}
 }
 
+// Has tells if the right is granted for the method, access to the method is
+// given by AccessRightReadAccess
+func (m *MethodAccessRights) Has(right AccessRight) bool {
+	for _, granted := range m.AccessRights {
+		if granted == right {
+			return true
+		}
+	}
+	return false
+}
+
 // AssociationObjectListItem represents an item in the association object list
 type AssociationObjectListItem struct {
 	Interface            enumerations.CosemInterface
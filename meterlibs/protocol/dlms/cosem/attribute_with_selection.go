@@ -1,11 +1,21 @@
 package cosem
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+)
 
 // CosemAttributeWithSelection represents a COSEM attribute with optional access selection
 type CosemAttributeWithSelection struct {
 	Attribute      *CosemAttribute
 	AccessSelection interface{} // *RangeDescriptor or *EntryDescriptor
+	// DataIndex selects an element of a structure or array attribute, 1 the
+	// first, as the data_index of a capture object. 0 is the whole attribute.
+	// The descriptor on the wire has no data index: the attribute is read and
+	// Element takes the element from its value.
+	DataIndex uint16
 }
 
 // NewCosemAttributeWithSelection creates a new CosemAttributeWithSelection
@@ -19,6 +29,16 @@ func NewCosemAttributeWithSelection(
 	}
 }
 
+// NewCosemAttributeWithDataIndex creates a CosemAttributeWithSelection of an
+// element of a structure or array attribute, e.g. a member of a structure read
+// in a GET with list
+func NewCosemAttributeWithDataIndex(attribute *CosemAttribute, dataIndex uint16) *CosemAttributeWithSelection {
+	return &CosemAttributeWithSelection{
+		Attribute: attribute,
+		DataIndex: dataIndex,
+	}
+}
+
 // FromBytes creates a CosemAttributeWithSelection from bytes and returns the number of bytes consumed
 func (c *CosemAttributeWithSelection) FromBytes(sourceBytes []byte) (*CosemAttributeWithSelection, int, error) {
 	if len(sourceBytes) < 9 {
@@ -62,23 +82,61 @@ func (c *CosemAttributeWithSelection) FromBytes(sourceBytes []byte) (*CosemAttri
 }
 
 // ToBytes converts CosemAttributeWithSelection to bytes
-func (c *CosemAttributeWithSelection) ToBytes() []byte {
+func (c *CosemAttributeWithSelection) ToBytes() ([]byte, error) {
 	result := c.Attribute.ToBytes()
 	
 	if c.AccessSelection != nil {
 		result = append(result, 1)
-		switch sel := c.AccessSelection.(type) {
-		case *RangeDescriptor:
-			result = append(result, sel.ToBytes()...)
-		case *EntryDescriptor:
-			result = append(result, sel.ToBytes()...)
-		default:
-			panic("unknown access selection type")
+		selectionBytes, err := AccessSelectionToBytes(c.AccessSelection)
+		if err != nil {
+			return nil, err
 		}
+		result = append(result, selectionBytes...)
 	} else {
 		result = append(result, 0)
 	}
 	
-	return result
+	return result, nil
+}
+
+// Element returns the A-XDR encoded element of the attribute value selected
+// by DataIndex, the whole value when DataIndex is 0
+func (c *CosemAttributeWithSelection) Element(value []byte) ([]byte, error) {
+	if c.DataIndex == 0 {
+		return value, nil
+	}
+	if len(value) == 0 || (value[0] != byte(dlmsdata.TagStructure) && value[0] != byte(dlmsdata.TagArray)) {
+		return nil, fmt.Errorf("data index %d of a value that is not a structure or an array", c.DataIndex)
+	}
+	count, data, err := dlmsdata.DecodeVariableInteger(value[1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the number of elements: %w", err)
+	}
+	if int(c.DataIndex) > count {
+		return nil, fmt.Errorf("data index %d out of the %d elements", c.DataIndex, count)
+	}
+	for i := uint16(1); ; i++ {
+		length, err := encoding.ValueLength(data)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		if i == c.DataIndex {
+			return data[:length], nil
+		}
+		data = data[length:]
+	}
+}
+
+// AccessSelectionToBytes encodes an access selection, a *RangeDescriptor or
+// an *EntryDescriptor
+func AccessSelectionToBytes(accessSelection interface{}) ([]byte, error) {
+	switch sel := accessSelection.(type) {
+	case *RangeDescriptor:
+		return sel.ToBytes()
+	case *EntryDescriptor:
+		return sel.ToBytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown access selection type: %T", accessSelection)
+	}
 }
 
@@ -0,0 +1,243 @@
+package cosem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+)
+
+// dataReader reads A-XDR encoded attribute values element by element. It is
+// used for the attributes the generic decoder can't handle, like the ones
+// containing enums.
+type dataReader struct {
+	data []byte
+}
+
+// end checks that all data was read
+func (r *dataReader) end() error {
+	if len(r.data) > 0 {
+		return fmt.Errorf("%d bytes left after the last element", len(r.data))
+	}
+	return nil
+}
+
+func (r *dataReader) structure(expected int) (int, error) {
+	count, err := r.header(dlmsdata.TagStructure)
+	if err != nil {
+		return 0, err
+	}
+	if count != expected {
+		return 0, fmt.Errorf("structure should have %d elements, got %d", expected, count)
+	}
+	return count, nil
+}
+
+// header reads the tag and the number of elements of an array or structure
+func (r *dataReader) header(tag dlmsdata.DlmsDataTag) (int, error) {
+	if err := r.tag(tag); err != nil {
+		return 0, err
+	}
+	count, rest, err := dlmsdata.DecodeVariableInteger(r.data)
+	if err != nil {
+		return 0, err
+	}
+	r.data = rest
+	return count, nil
+}
+
+func (r *dataReader) octetString() ([]byte, error) {
+	length, err := r.header(dlmsdata.TagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	return r.take(length)
+}
+
+func (r *dataReader) unsigned(tag dlmsdata.DlmsDataTag, size int) (uint64, error) {
+	if err := r.tag(tag); err != nil {
+		return 0, err
+	}
+	value, err := r.take(size)
+	if err != nil {
+		return 0, err
+	}
+	result := uint64(0)
+	for _, b := range value {
+		result = result<<8 | uint64(b)
+	}
+	return result, nil
+}
+
+func (r *dataReader) signed(tag dlmsdata.DlmsDataTag) (int8, error) {
+	value, err := r.unsigned(tag, 1)
+	return int8(value), err
+}
+
+// next returns true when the next element has the given tag
+func (r *dataReader) next(tag dlmsdata.DlmsDataTag) bool {
+	return len(r.data) > 0 && dlmsdata.DlmsDataTag(r.data[0]) == tag
+}
+
+func (r *dataReader) tag(tag dlmsdata.DlmsDataTag) error {
+	if len(r.data) == 0 {
+		return fmt.Errorf("expected tag %d, no data left", tag)
+	}
+	if dlmsdata.DlmsDataTag(r.data[0]) != tag {
+		return fmt.Errorf("expected tag %d, got %d", tag, r.data[0])
+	}
+	r.data = r.data[1:]
+	return nil
+}
+
+func (r *dataReader) take(length int) ([]byte, error) {
+	if len(r.data) < length {
+		return nil, fmt.Errorf("need %d bytes, %d left", length, len(r.data))
+	}
+	value := r.data[:length]
+	r.data = r.data[length:]
+	return value, nil
+}
+
+func (r *dataReader) visibleString() (string, error) {
+	length, err := r.header(dlmsdata.TagVisibleString)
+	if err != nil {
+		return "", err
+	}
+	value, err := r.take(length)
+	return string(value), err
+}
+
+// dateTime reads a date-time sent as octet-string or date-time. A date-time
+// without date is returned as the zero time.
+func (r *dataReader) dateTime() (time.Time, error) {
+	value, err := r.dateTimeBytes()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(value) == 12 && value[0] == 0xFF && value[1] == 0xFF && value[2] == 0xFF && value[3] == 0xFF {
+		return time.Time{}, nil
+	}
+	result, _, err := dlmsdata.DateTimeFromBytes(value)
+	return result, err
+}
+
+// dateTimeBytes reads the 12 bytes of a date-time sent as octet-string or
+// date-time
+func (r *dataReader) dateTimeBytes() ([]byte, error) {
+	if r.next(dlmsdata.TagDateTime) {
+		r.data = r.data[1:]
+		return r.take(12)
+	}
+	return r.octetString()
+}
+
+// bitString reads a bit-string, the length of the header is the number of bits
+func (r *dataReader) bitString() (*dlmsdata.BitString, error) {
+	length, err := r.header(dlmsdata.TagBitString)
+	if err != nil {
+		return nil, err
+	}
+	value, err := r.take((length + 7) / 8)
+	if err != nil {
+		return nil, err
+	}
+	return dlmsdata.NewBitString(length, value)
+}
+
+// long reads a long, a signed 16-bit integer
+func (r *dataReader) long() (int16, error) {
+	value, err := r.unsigned(dlmsdata.TagLong, 2)
+	return int16(value), err
+}
+
+// doubleLong reads a double-long, a signed 32-bit integer
+func (r *dataReader) doubleLong() (int32, error) {
+	value, err := r.unsigned(dlmsdata.TagDoubleLong, 4)
+	return int32(value), err
+}
+
+func (r *dataReader) utf8String() (string, error) {
+	length, err := r.header(dlmsdata.TagUTF8String)
+	if err != nil {
+		return "", err
+	}
+	value, err := r.take(length)
+	return string(value), err
+}
+
+// octetStrings reads an array of octet-strings
+func (r *dataReader) octetStrings() ([][]byte, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		value, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		result = append(result, append([]byte{}, value...))
+	}
+	return result, nil
+}
+
+// value reads a value of any type and returns it still A-XDR encoded
+func (r *dataReader) value() ([]byte, error) {
+	length, err := encoding.ValueLength(r.data)
+	if err != nil {
+		return nil, err
+	}
+	value, err := r.take(length)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, value...), nil
+}
+
+// obis reads a logical name sent as octet-string
+func (r *dataReader) obis() (*Obis, error) {
+	value, err := r.octetString()
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(value)
+}
+
+// encodeHeader encodes the tag and the number of elements of an array or structure
+func encodeHeader(tag dlmsdata.DlmsDataTag, count int) []byte {
+	return append([]byte{byte(tag)}, dlmsdata.EncodeVariableInteger(count)...)
+}
+
+func encodeOctetString(value []byte) []byte {
+	return append(encodeHeader(dlmsdata.TagOctetString, len(value)), value...)
+}
+
+// encodeUnsigned encodes an unsigned value of the given size in bytes
+func encodeUnsigned(tag dlmsdata.DlmsDataTag, size int, value uint64) []byte {
+	result := []byte{byte(tag)}
+	for i := size - 1; i >= 0; i-- {
+		result = append(result, byte(value>>(8*i)))
+	}
+	return result
+}
+
+// encodeDoubleLong encodes a signed 32-bit integer
+func encodeDoubleLong(value int32) []byte {
+	return encodeUnsigned(dlmsdata.TagDoubleLong, 4, uint64(uint32(value)))
+}
+
+// IntegerZeroToBytes encodes integer 0, the parameter of the methods
+// without data like activate_account
+func IntegerZeroToBytes() []byte {
+	return []byte{byte(dlmsdata.TagInteger), 0x00}
+}
+
+func encodeBoolean(value bool) []byte {
+	if value {
+		return []byte{byte(dlmsdata.TagBoolean), 0x01}
+	}
+	return []byte{byte(dlmsdata.TagBoolean), 0x00}
+}
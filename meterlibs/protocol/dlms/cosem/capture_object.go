@@ -17,6 +17,12 @@ func NewCaptureObject(cosemAttribute *CosemAttribute, dataIndex uint16) *Capture
 	}
 }
 
+// AttributeWithSelection returns the descriptor reading the captured value,
+// the element of the attribute selected by the data index
+func (c *CaptureObject) AttributeWithSelection() *CosemAttributeWithSelection {
+	return NewCosemAttributeWithDataIndex(c.CosemAttribute, c.DataIndex)
+}
+
 // ToBytes converts CaptureObject to bytes
 // Returns a structure of 4 elements:
 // - interface (UnsignedLong)
@@ -0,0 +1,252 @@
+package cosem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Charge interface class (113)
+const (
+	ChargeAttributeTotalAmountPaid      uint8 = 2
+	ChargeAttributeChargeType           uint8 = 3
+	ChargeAttributePriority             uint8 = 4
+	ChargeAttributeUnitChargeActive     uint8 = 5
+	ChargeAttributeUnitChargePassive    uint8 = 6
+	ChargeAttributeActivationTime       uint8 = 7
+	ChargeAttributePeriod               uint8 = 8
+	ChargeAttributeConfiguration        uint8 = 9
+	ChargeAttributeLastCollectionTime   uint8 = 10
+	ChargeAttributeLastCollectionAmount uint8 = 11
+	ChargeAttributeTotalAmountRemaining uint8 = 12
+	ChargeAttributeProportion           uint8 = 13
+)
+
+// Methods of the Charge interface class (113)
+const (
+	ChargeMethodUpdateUnitCharge           uint8 = 1
+	ChargeMethodActivatePassiveUnitCharge  uint8 = 2
+	ChargeMethodCollect                    uint8 = 3
+	ChargeMethodUpdateTotalAmountRemaining uint8 = 4
+	ChargeMethodSetTotalAmountRemaining    uint8 = 5
+)
+
+// ChargeType is how a charge is collected
+type ChargeType uint8
+
+const (
+	ChargeTypeConsumptionBasedCollection  ChargeType = 0
+	ChargeTypeTimeBasedCollection         ChargeType = 1
+	ChargeTypePaymentEventBasedCollection ChargeType = 2
+)
+
+// ChargeTableElement is the price per unit for an index, the index being
+// e.g. the active tariff
+type ChargeTableElement struct {
+	Index         []byte
+	ChargePerUnit int16
+}
+
+// CommodityReference is the attribute a charge based on consumption is
+// computed from
+type CommodityReference struct {
+	Interface      enumerations.CosemInterface
+	LogicalName    *Obis
+	AttributeIndex int8
+}
+
+// ToBytes converts CommodityReference to a structure of class id, logical
+// name and attribute index
+func (c *CommodityReference) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 3)
+	result = append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(c.Interface))...)
+	result = append(result, encodeOctetString(c.LogicalName.ToBytes())...)
+	return append(result, encodeUnsigned(dlmsdata.TagInteger, 1, uint64(uint8(c.AttributeIndex)))...)
+}
+
+// UnitCharge is the unit_charge_active and unit_charge_passive attributes,
+// and the parameter of the update_unit_charge method. The charge of a unit
+// of the commodity attribute is ChargePerUnit*10^PriceScale for
+// 10^CommodityScale units.
+type UnitCharge struct {
+	CommodityScale int8
+	PriceScale     int8
+	// Commodity is the attribute the charge is computed from, it is empty
+	// for the charges not based on consumption
+	Commodity   *CommodityReference
+	ChargeTable []*ChargeTableElement
+}
+
+// ToBytes converts UnitCharge to a structure of charge scaling, commodity
+// reference and charge table
+func (u *UnitCharge) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 3)
+	result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+	result = append(result, encodeUnsigned(dlmsdata.TagInteger, 1, uint64(uint8(u.CommodityScale)))...)
+	result = append(result, encodeUnsigned(dlmsdata.TagInteger, 1, uint64(uint8(u.PriceScale)))...)
+	commodity := u.Commodity
+	if commodity == nil {
+		commodity = &CommodityReference{LogicalName: &Obis{}}
+	}
+	result = append(result, commodity.ToBytes()...)
+	result = append(result, encodeHeader(dlmsdata.TagArray, len(u.ChargeTable))...)
+	for _, element := range u.ChargeTable {
+		result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+		result = append(result, encodeOctetString(element.Index)...)
+		result = append(result, encodeUnsigned(dlmsdata.TagLong, 2, uint64(uint16(element.ChargePerUnit)))...)
+	}
+	return result
+}
+
+// Charge is a Charge object (class 113) of payment metering, a charge
+// collected from the credits of an account
+type Charge struct {
+	LogicalName          *Obis
+	TotalAmountPaid      int32
+	ChargeType           ChargeType
+	Priority             uint8
+	UnitChargeActive     *UnitCharge
+	UnitChargePassive    *UnitCharge
+	ActivationTime       time.Time
+	Period               uint32
+	Configuration        *dlmsdata.BitString
+	LastCollectionTime   time.Time
+	LastCollectionAmount int32
+	TotalAmountRemaining int32
+	Proportion           uint16
+}
+
+// NewCharge creates a new Charge, the attributes are filled in with
+// FromAttribute or set directly before writing them
+func NewCharge(logicalName *Obis) *Charge {
+	return &Charge{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the charge
+func (c *Charge) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceCharge, c.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the charge
+func (c *Charge) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceCharge, c.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the Charge
+func (c *Charge) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case ChargeAttributeTotalAmountPaid:
+		c.TotalAmountPaid, err = r.doubleLong()
+	case ChargeAttributeChargeType:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		c.ChargeType = ChargeType(value)
+	case ChargeAttributePriority:
+		value, err = r.unsigned(dlmsdata.TagUnsigned, 1)
+		c.Priority = uint8(value)
+	case ChargeAttributeUnitChargeActive:
+		c.UnitChargeActive, err = r.unitCharge()
+	case ChargeAttributeUnitChargePassive:
+		c.UnitChargePassive, err = r.unitCharge()
+	case ChargeAttributeActivationTime:
+		c.ActivationTime, err = r.dateTime()
+	case ChargeAttributePeriod:
+		value, err = r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+		c.Period = uint32(value)
+	case ChargeAttributeConfiguration:
+		c.Configuration, err = r.bitString()
+	case ChargeAttributeLastCollectionTime:
+		c.LastCollectionTime, err = r.dateTime()
+	case ChargeAttributeLastCollectionAmount:
+		c.LastCollectionAmount, err = r.doubleLong()
+	case ChargeAttributeTotalAmountRemaining:
+		c.TotalAmountRemaining, err = r.doubleLong()
+	case ChargeAttributeProportion:
+		value, err = r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		c.Proportion = uint16(value)
+	default:
+		return fmt.Errorf("charge has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("charge attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+func (r *dataReader) unitCharge() (*UnitCharge, error) {
+	if _, err := r.structure(3); err != nil {
+		return nil, err
+	}
+	if _, err := r.structure(2); err != nil {
+		return nil, fmt.Errorf("charge_per_unit_scaling: %w", err)
+	}
+	commodityScale, err := r.signed(dlmsdata.TagInteger)
+	if err != nil {
+		return nil, fmt.Errorf("commodity_scale: %w", err)
+	}
+	priceScale, err := r.signed(dlmsdata.TagInteger)
+	if err != nil {
+		return nil, fmt.Errorf("price_scale: %w", err)
+	}
+	result := &UnitCharge{CommodityScale: commodityScale, PriceScale: priceScale}
+	if result.Commodity, err = r.commodityReference(); err != nil {
+		return nil, fmt.Errorf("commodity: %w", err)
+	}
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, fmt.Errorf("charge_table: %w", err)
+	}
+	result.ChargeTable = make([]*ChargeTableElement, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, fmt.Errorf("charge_table %d: %w", i, err)
+		}
+		index, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("charge_table %d: index: %w", i, err)
+		}
+		chargePerUnit, err := r.long()
+		if err != nil {
+			return nil, fmt.Errorf("charge_table %d: charge_per_unit: %w", i, err)
+		}
+		result.ChargeTable = append(result.ChargeTable, &ChargeTableElement{Index: append([]byte{}, index...), ChargePerUnit: chargePerUnit})
+	}
+	return result, nil
+}
+
+// commodityReference reads the commodity of a unit charge, class id 0 means
+// there's no commodity
+func (r *dataReader) commodityReference() (*CommodityReference, error) {
+	if _, err := r.structure(3); err != nil {
+		return nil, err
+	}
+	classID, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+	if err != nil {
+		return nil, fmt.Errorf("class_id: %w", err)
+	}
+	logicalName, err := r.obis()
+	if err != nil {
+		return nil, fmt.Errorf("logical_name: %w", err)
+	}
+	attributeIndex, err := r.signed(dlmsdata.TagInteger)
+	if err != nil {
+		return nil, fmt.Errorf("attribute_index: %w", err)
+	}
+	if classID == 0 {
+		return nil, nil
+	}
+	return &CommodityReference{
+		Interface:      enumerations.CosemInterface(classID),
+		LogicalName:    logicalName,
+		AttributeIndex: attributeIndex,
+	}, nil
+}
@@ -0,0 +1,186 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// ClassAttributes maps attribute names to attribute indices for one version of an interface class
+type ClassAttributes map[string]uint8
+
+// ClassVersionRegistry knows the attribute numbering of the different versions of
+// the interface classes, so attribute indices can be resolved from the class version
+// reported in the association object list instead of being hardcoded.
+type ClassVersionRegistry struct {
+	classes map[enumerations.CosemInterface]map[uint8]ClassAttributes
+}
+
+// profileGenericAttributes is the attribute numbering shared by versions 0 and 1 of Profile generic
+var profileGenericAttributes = ClassAttributes{
+	"logical_name":    1,
+	"buffer":          2,
+	"capture_objects": 3,
+	"capture_period":  4,
+	"sort_method":     5,
+	"sort_object":     6,
+	"entries_in_use":  7,
+	"profile_entries": 8,
+}
+
+// pushAttributes is the attribute numbering shared by versions 0 and 1 of Push setup
+var pushAttributes = ClassAttributes{
+	"logical_name":                 1,
+	"push_object_list":             2,
+	"send_destination_and_method":  3,
+	"communication_window":         4,
+	"randomisation_start_interval": 5,
+	"number_of_retries":            6,
+	"repetition_delay":             7,
+}
+
+// defaultClassVersions holds the attribute numbering from the Blue Book for
+// common classes where the available attributes depend on the class version
+var defaultClassVersions = map[enumerations.CosemInterface]map[uint8]ClassAttributes{
+	// versions 0 and 1 only differ in their methods
+	enumerations.CosemInterfaceProfileGeneric: {
+		0: profileGenericAttributes,
+		1: profileGenericAttributes,
+	},
+	enumerations.CosemInterfaceAssociationLN: {
+		0: {
+			"logical_name":                  1,
+			"object_list":                   2,
+			"associated_partners_id":        3,
+			"application_context_name":      4,
+			"xdlms_context_info":            5,
+			"authentication_mechanism_name": 6,
+			"secret":                        7,
+			"association_status":            8,
+		},
+		1: {
+			"logical_name":                  1,
+			"object_list":                   2,
+			"associated_partners_id":        3,
+			"application_context_name":      4,
+			"xdlms_context_info":            5,
+			"authentication_mechanism_name": 6,
+			"secret":                        7,
+			"association_status":            8,
+			"security_setup_reference":      9,
+		},
+		2: {
+			"logical_name":                  1,
+			"object_list":                   2,
+			"associated_partners_id":        3,
+			"application_context_name":      4,
+			"xdlms_context_info":            5,
+			"authentication_mechanism_name": 6,
+			"secret":                        7,
+			"association_status":            8,
+			"security_setup_reference":      9,
+			"user_list":                     10,
+			"current_user":                  11,
+		},
+	},
+	enumerations.CosemInterfaceSecuritySetup: {
+		0: {
+			"logical_name":        1,
+			"security_policy":     2,
+			"security_suite":      3,
+			"client_system_title": 4,
+			"server_system_title": 5,
+		},
+		1: {
+			"logical_name":        1,
+			"security_policy":     2,
+			"security_suite":      3,
+			"client_system_title": 4,
+			"server_system_title": 5,
+			"certificates":        6,
+		},
+	},
+	// versions 0 and 1 only differ in the type of push_object_list
+	enumerations.CosemInterfacePush: {
+		0: pushAttributes,
+		1: pushAttributes,
+		2: {
+			"logical_name":                 1,
+			"push_object_list":             2,
+			"send_destination_and_method":  3,
+			"communication_window":         4,
+			"randomisation_start_interval": 5,
+			"number_of_retries":            6,
+			"repetition_delay":             7,
+			"port_reference":               8,
+			"push_client_sap":              9,
+			"push_protection_parameters":   10,
+			"push_operation_method":        11,
+			"confirmation_parameters":      12,
+			"last_confirmation_date_time":  13,
+		},
+	},
+}
+
+// NewClassVersionRegistry creates a new ClassVersionRegistry with the default class versions
+func NewClassVersionRegistry() *ClassVersionRegistry {
+	registry := &ClassVersionRegistry{
+		classes: make(map[enumerations.CosemInterface]map[uint8]ClassAttributes),
+	}
+	for interfaceClass, versions := range defaultClassVersions {
+		for version, attributes := range versions {
+			registry.Register(interfaceClass, version, attributes)
+		}
+	}
+	return registry
+}
+
+// Register adds or replaces the attribute numbering of a class version
+func (r *ClassVersionRegistry) Register(interfaceClass enumerations.CosemInterface, version uint8, attributes ClassAttributes) {
+	versions, ok := r.classes[interfaceClass]
+	if !ok {
+		versions = make(map[uint8]ClassAttributes)
+		r.classes[interfaceClass] = versions
+	}
+	copied := make(ClassAttributes, len(attributes))
+	for name, index := range attributes {
+		copied[name] = index
+	}
+	versions[version] = copied
+}
+
+// AttributeIndex returns the attribute index of a named attribute for a class version
+func (r *ClassVersionRegistry) AttributeIndex(interfaceClass enumerations.CosemInterface, version uint8, name string) (uint8, error) {
+	versions, ok := r.classes[interfaceClass]
+	if !ok {
+		return 0, fmt.Errorf("no class versions registered for interface %d", interfaceClass)
+	}
+	attributes, ok := versions[version]
+	if !ok {
+		return 0, fmt.Errorf("version %d of interface %d is not registered", version, interfaceClass)
+	}
+	index, ok := attributes[name]
+	if !ok {
+		return 0, fmt.Errorf("attribute %s does not exist in version %d of interface %d", name, version, interfaceClass)
+	}
+	return index, nil
+}
+
+// Attribute resolves a named attribute of an object from the association object list
+func (r *ClassVersionRegistry) Attribute(item *AssociationObjectListItem, name string) (*CosemAttribute, error) {
+	index, err := r.AttributeIndex(item.Interface, item.Version, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s of %s: %w", name, item.LogicalName, err)
+	}
+	return NewCosemAttribute(item.Interface, item.LogicalName, index), nil
+}
+
+// FindObjectListItem returns the object list item with the given logical name, or nil
+func FindObjectListItem(objectList []*AssociationObjectListItem, logicalName *Obis) *AssociationObjectListItem {
+	for _, item := range objectList {
+		if item.LogicalName != nil && logicalName != nil && *item.LogicalName == *logicalName {
+			return item
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package cosem_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+func TestClassVersionRegistry_AttributeIndex(t *testing.T) {
+	registry := cosem.NewClassVersionRegistry()
+	for _, test := range []struct {
+		interfaceClass enumerations.CosemInterface
+		version        uint8
+		name           string
+		index          uint8
+	}{
+		{enumerations.CosemInterfaceProfileGeneric, 0, "buffer", 2},
+		{enumerations.CosemInterfaceProfileGeneric, 1, "profile_entries", 8},
+		{enumerations.CosemInterfaceAssociationLN, 1, "security_setup_reference", 9},
+		{enumerations.CosemInterfaceAssociationLN, 2, "current_user", 11},
+		{enumerations.CosemInterfaceSecuritySetup, 1, "certificates", 6},
+		{enumerations.CosemInterfacePush, 0, "repetition_delay", 7},
+		{enumerations.CosemInterfacePush, 2, "last_confirmation_date_time", 13},
+	} {
+		index, err := registry.AttributeIndex(test.interfaceClass, test.version, test.name)
+		require.NoError(t, err, "%s v%d", test.name, test.version)
+		assert.Equal(t, test.index, index, "%s v%d", test.name, test.version)
+	}
+}
+
+func TestClassVersionRegistry_AttributeIndexErrors(t *testing.T) {
+	registry := cosem.NewClassVersionRegistry()
+	for _, test := range []struct {
+		name           string
+		interfaceClass enumerations.CosemInterface
+		version        uint8
+		attribute      string
+	}{
+		{"unknown class", enumerations.CosemInterfaceData, 0, "value"},
+		{"unknown version", enumerations.CosemInterfaceProfileGeneric, 2, "buffer"},
+		{"attribute of a later version", enumerations.CosemInterfaceAssociationLN, 0, "security_setup_reference"},
+		{"attribute of a later version", enumerations.CosemInterfaceSecuritySetup, 0, "certificates"},
+		{"unknown attribute", enumerations.CosemInterfacePush, 2, "unknown"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := registry.AttributeIndex(test.interfaceClass, test.version, test.attribute)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestClassVersionRegistry_Register(t *testing.T) {
+	registry := cosem.NewClassVersionRegistry()
+	attributes := cosem.ClassAttributes{"logical_name": 1, "value": 2}
+	registry.Register(enumerations.CosemInterfaceData, 0, attributes)
+	// the registry keeps its own copy
+	attributes["value"] = 3
+
+	index, err := registry.AttributeIndex(enumerations.CosemInterfaceData, 0, "value")
+	require.NoError(t, err)
+	assert.Equal(t, uint8(2), index)
+
+	// other registries keep the defaults
+	_, err = cosem.NewClassVersionRegistry().AttributeIndex(enumerations.CosemInterfaceData, 0, "value")
+	assert.Error(t, err)
+}
+
+func TestClassVersionRegistry_Attribute(t *testing.T) {
+	logicalName, err := cosem.NewObis(1, 0, 99, 1, 0, 255)
+	require.NoError(t, err)
+	item := &cosem.AssociationObjectListItem{
+		Interface:   enumerations.CosemInterfaceProfileGeneric,
+		LogicalName: logicalName,
+		Version:     1,
+	}
+
+	registry := cosem.NewClassVersionRegistry()
+	attribute, err := registry.Attribute(item, "capture_objects")
+	require.NoError(t, err)
+	assert.Equal(t, enumerations.CosemInterfaceProfileGeneric, attribute.Interface)
+	assert.Equal(t, logicalName, attribute.Instance)
+	assert.Equal(t, uint8(3), attribute.Attribute)
+
+	_, err = registry.Attribute(item, "unknown")
+	assert.Error(t, err)
+}
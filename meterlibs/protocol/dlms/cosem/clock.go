@@ -0,0 +1,340 @@
+package cosem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Clock interface class (8)
+const (
+	ClockAttributeTime                     uint8 = 2
+	ClockAttributeTimeZone                 uint8 = 3
+	ClockAttributeStatus                   uint8 = 4
+	ClockAttributeDaylightSavingsBegin     uint8 = 5
+	ClockAttributeDaylightSavingsEnd       uint8 = 6
+	ClockAttributeDaylightSavingsDeviation uint8 = 7
+	ClockAttributeDaylightSavingsEnabled   uint8 = 8
+	ClockAttributeClockBase                uint8 = 9
+)
+
+// Methods of the Clock interface class (8)
+const (
+	ClockMethodShiftTime uint8 = 6
+)
+
+// MaxShiftTime is the largest shift accepted by the shift_time method
+const MaxShiftTime = 900 * time.Second
+
+// ParseClockTime parses the time attribute of a Clock
+func ParseClockTime(data []byte) (time.Time, error) {
+	r := &dataReader{data: data}
+	result, err := r.dateTime()
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time: %w", err)
+	}
+	return result, nil
+}
+
+// ParseClockDateTime parses the time attribute of a Clock keeping the
+// deviation, the clock status and the wildcards as sent
+func ParseClockDateTime(data []byte) (*dlmsdata.CosemDateTime, error) {
+	r := &dataReader{data: data}
+	value, err := r.dateTimeBytes()
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("time: %w", err)
+	}
+	result, err := (&dlmsdata.CosemDateTime{}).FromBytes(value)
+	if err != nil {
+		return nil, fmt.Errorf("time: %w", err)
+	}
+	return result, nil
+}
+
+// ClockTimeToBytes converts a time to the time attribute of a Clock
+func ClockTimeToBytes(t time.Time) []byte {
+	return encodeOctetString(dlmsdata.DateTimeToBytes(t, nil))
+}
+
+// ShiftTimeToBytes encodes the parameter of the shift_time method. The shift
+// is rounded to seconds and has to be within MaxShiftTime.
+func ShiftTimeToBytes(shift time.Duration) ([]byte, error) {
+	if shift < -MaxShiftTime || shift > MaxShiftTime {
+		return nil, fmt.Errorf("time can only be shifted by %s, got %s", MaxShiftTime, shift)
+	}
+	seconds := int16(shift.Round(time.Second) / time.Second)
+	return encodeUnsigned(dlmsdata.TagLong, 2, uint64(uint16(seconds))), nil
+}
+
+// ClockBase is the source of the time of a clock
+type ClockBase uint8
+
+const (
+	ClockBaseNotDefined      ClockBase = 0
+	ClockBaseInternalCrystal ClockBase = 1
+	ClockBaseMains50Hz       ClockBase = 2
+	ClockBaseMains60Hz       ClockBase = 3
+	ClockBaseGPS             ClockBase = 4
+	ClockBaseRadioControlled ClockBase = 5
+)
+
+// DeviationNotSpecified is the deviation of a date-time without time zone
+const DeviationNotSpecified = dlmsdata.DeviationNotSpecified
+
+// Clock is a Clock object (class 8) without its time, which is read with
+// ParseClockTime. The deviations are in minutes from local time to UTC, as
+// in the date-times: -60 for CET.
+type Clock struct {
+	LogicalName *Obis
+	// TimeZone is the deviation of the local standard time
+	TimeZone                 int16
+	Status                   *dlmsdata.ClockStatus
+	DaylightSavingsBegin     *SeasonDateTime
+	DaylightSavingsEnd       *SeasonDateTime
+	DaylightSavingsDeviation int8
+	DaylightSavingsEnabled   bool
+	ClockBase                ClockBase
+}
+
+// NewClock creates a new Clock, the attributes are filled in with FromAttribute
+func NewClock(logicalName *Obis) *Clock {
+	return &Clock{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the clock
+func (c *Clock) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceClock, c.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the clock
+func (c *Clock) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceClock, c.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the Clock
+func (c *Clock) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case ClockAttributeTimeZone:
+		c.TimeZone, err = r.long()
+	case ClockAttributeStatus:
+		value, err = r.unsigned(dlmsdata.TagUnsigned, 1)
+		if err == nil {
+			c.Status, err = (&dlmsdata.ClockStatus{}).FromBytes([]byte{byte(value)})
+		}
+	case ClockAttributeDaylightSavingsBegin:
+		c.DaylightSavingsBegin, err = r.seasonDateTime()
+	case ClockAttributeDaylightSavingsEnd:
+		c.DaylightSavingsEnd, err = r.seasonDateTime()
+	case ClockAttributeDaylightSavingsDeviation:
+		c.DaylightSavingsDeviation, err = r.signed(dlmsdata.TagInteger)
+	case ClockAttributeDaylightSavingsEnabled:
+		value, err = r.unsigned(dlmsdata.TagBoolean, 1)
+		c.DaylightSavingsEnabled = value != 0
+	case ClockAttributeClockBase:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		c.ClockBase = ClockBase(value)
+	default:
+		return fmt.Errorf("clock has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("clock attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of a writable attribute of the Clock, the
+// time is encoded with ClockTimeToBytes
+func (c *Clock) ToAttribute(attribute uint8) ([]byte, error) {
+	switch attribute {
+	case ClockAttributeTimeZone:
+		return encodeUnsigned(dlmsdata.TagLong, 2, uint64(uint16(c.TimeZone))), nil
+	case ClockAttributeDaylightSavingsBegin:
+		return c.DaylightSavingsBegin.toAttribute("daylight_savings_begin")
+	case ClockAttributeDaylightSavingsEnd:
+		return c.DaylightSavingsEnd.toAttribute("daylight_savings_end")
+	case ClockAttributeDaylightSavingsDeviation:
+		return encodeUnsigned(dlmsdata.TagInteger, 1, uint64(uint8(c.DaylightSavingsDeviation))), nil
+	case ClockAttributeDaylightSavingsEnabled:
+		return encodeBoolean(c.DaylightSavingsEnabled), nil
+	default:
+		return nil, fmt.Errorf("clock attribute %d is not writable", attribute)
+	}
+}
+
+// SetDaylightSavings copies the time zone and the daylight savings settings
+func (c *Clock) SetDaylightSavings(settings *DaylightSavings) {
+	c.TimeZone = settings.TimeZone
+	c.DaylightSavingsBegin = settings.Begin
+	c.DaylightSavingsEnd = settings.End
+	c.DaylightSavingsDeviation = settings.Deviation
+	c.DaylightSavingsEnabled = settings.Enabled
+}
+
+// SeasonDateTime is a date-time with wildcards, like the daylight savings
+// begin and end of a clock: the last Sunday of March at 02:00 of every year
+type SeasonDateTime struct {
+	Date *dlmsdata.CosemDate
+	Time *dlmsdata.CosemTime
+	// Deviation is DeviationNotSpecified for a local time
+	Deviation int16
+	Status    uint8
+}
+
+// NewSeasonDateTime creates a SeasonDateTime in local time without status
+func NewSeasonDateTime(date *dlmsdata.CosemDate, time *dlmsdata.CosemTime) *SeasonDateTime {
+	return &SeasonDateTime{
+		Date:      date,
+		Time:      time,
+		Deviation: DeviationNotSpecified,
+		Status:    dlmsdata.NotSpecified,
+	}
+}
+
+// ParseSeasonDateTime decodes the 12 bytes of a date-time with wildcards
+func ParseSeasonDateTime(data []byte) (*SeasonDateTime, error) {
+	if len(data) != 12 {
+		return nil, fmt.Errorf("date-time is represented by 12 bytes, but got %d", len(data))
+	}
+	date, err := (&dlmsdata.CosemDate{}).FromBytes(data[:5])
+	if err != nil {
+		return nil, fmt.Errorf("date: %w", err)
+	}
+	time, err := (&dlmsdata.CosemTime{}).FromBytes(data[5:9])
+	if err != nil {
+		return nil, fmt.Errorf("time: %w", err)
+	}
+	return &SeasonDateTime{
+		Date:      date,
+		Time:      time,
+		Deviation: int16(binary.BigEndian.Uint16(data[9:11])),
+		Status:    data[11],
+	}, nil
+}
+
+// ToBytes encodes the 12 bytes of the date-time
+func (s *SeasonDateTime) ToBytes() []byte {
+	result := append(s.Date.ToBytes(), s.Time.ToBytes()...)
+	result = binary.BigEndian.AppendUint16(result, uint16(s.Deviation))
+	return append(result, s.Status)
+}
+
+// In returns the time of the season date-time in the given year. The day of
+// week selects the first such day from the day of month on, or the last one
+// of the month with DayLastOfMonth and the one before with
+// DaySecondLastOfMonth. Seconds and hundredths not specified are 0.
+func (s *SeasonDateTime) In(year int, location *time.Location) (time.Time, error) {
+	if s.Date.Month < 1 || s.Date.Month > 12 {
+		return time.Time{}, fmt.Errorf("%s has no month", s)
+	}
+	if s.Time.Hour == dlmsdata.NotSpecified || s.Time.Minute == dlmsdata.NotSpecified {
+		return time.Time{}, fmt.Errorf("%s has no hour and minute", s)
+	}
+	if s.Date.Year != dlmsdata.YearNotSpecified && int(s.Date.Year) != year {
+		return time.Time{}, fmt.Errorf("%s is not in %d", s, year)
+	}
+
+	month := time.Month(s.Date.Month)
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	var day int
+	switch {
+	case s.Date.DayOfMonth == dlmsdata.DayLastOfMonth:
+		day = lastDay
+	case s.Date.DayOfMonth == dlmsdata.DaySecondLastOfMonth:
+		day = lastDay - 1
+		if s.Date.DayOfWeek >= 1 && s.Date.DayOfWeek <= 7 {
+			day = lastDay - 7
+		}
+	case s.Date.DayOfMonth >= 1 && int(s.Date.DayOfMonth) <= lastDay:
+		day = int(s.Date.DayOfMonth)
+	default:
+		return time.Time{}, fmt.Errorf("%s has no day of month in %d", s, year)
+	}
+
+	if s.Date.DayOfWeek >= 1 && s.Date.DayOfWeek <= 7 {
+		weekday := int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()+6)%7 + 1
+		shift := (int(s.Date.DayOfWeek) - weekday + 7) % 7
+		if s.Date.DayOfMonth == dlmsdata.DayLastOfMonth || s.Date.DayOfMonth == dlmsdata.DaySecondLastOfMonth {
+			shift = -((weekday - int(s.Date.DayOfWeek) + 7) % 7)
+		}
+		day += shift
+	}
+
+	second := int(s.Time.Second)
+	if s.Time.Second == dlmsdata.NotSpecified {
+		second = 0
+	}
+	return time.Date(year, month, day, int(s.Time.Hour), int(s.Time.Minute), second, 0, location), nil
+}
+
+// String implements fmt.Stringer
+func (s *SeasonDateTime) String() string {
+	return fmt.Sprintf("%s %s", s.Date, s.Time)
+}
+
+func (s *SeasonDateTime) toAttribute(name string) ([]byte, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%s is not set", name)
+	}
+	return encodeOctetString(s.ToBytes()), nil
+}
+
+func (r *dataReader) seasonDateTime() (*SeasonDateTime, error) {
+	value, err := r.octetString()
+	if err != nil {
+		return nil, err
+	}
+	return ParseSeasonDateTime(value)
+}
+
+// DaylightSavings is the time zone and the daylight savings settings of a
+// clock
+type DaylightSavings struct {
+	TimeZone  int16
+	Begin     *SeasonDateTime
+	End       *SeasonDateTime
+	Deviation int8
+	Enabled   bool
+}
+
+// EuropeanDaylightSavings computes the clock settings of a location applying
+// the European rules in the given year: the daylight savings begin the last
+// Sunday of March and end the last Sunday of October, at 01:00 UTC. The
+// offsets are the ones of the location in January and July, the daylight
+// savings are disabled when they are the same.
+func EuropeanDaylightSavings(location *time.Location, year int) *DaylightSavings {
+	_, standard := time.Date(year, time.January, 1, 0, 0, 0, 0, location).Zone()
+	_, summer := time.Date(year, time.July, 1, 0, 0, 0, 0, location).Zone()
+
+	seasonChange := func(month uint8, offset int) *SeasonDateTime {
+		local := time.Date(2000, time.January, 1, 1, 0, 0, 0, time.UTC).Add(time.Duration(offset) * time.Second)
+		return NewSeasonDateTime(
+			dlmsdata.NewCosemDate(dlmsdata.YearNotSpecified, month, dlmsdata.DayLastOfMonth, 7),
+			dlmsdata.NewCosemTime(uint8(local.Hour()), uint8(local.Minute()), 0, 0),
+		)
+	}
+
+	return &DaylightSavings{
+		TimeZone:  int16(-standard / 60),
+		Begin:     seasonChange(3, standard),
+		End:       seasonChange(10, summer),
+		Deviation: int8((summer - standard) / 60),
+		Enabled:   summer != standard,
+	}
+}
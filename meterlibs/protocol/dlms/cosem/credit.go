@@ -0,0 +1,169 @@
+package cosem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Credit interface class (112)
+const (
+	CreditAttributeCurrentCreditAmount      uint8 = 2
+	CreditAttributeType                     uint8 = 3
+	CreditAttributePriority                 uint8 = 4
+	CreditAttributeWarningThreshold         uint8 = 5
+	CreditAttributeLimit                    uint8 = 6
+	CreditAttributeConfiguration            uint8 = 7
+	CreditAttributeStatus                   uint8 = 8
+	CreditAttributePresetCreditAmount       uint8 = 9
+	CreditAttributeCreditAvailableThreshold uint8 = 10
+	CreditAttributePeriod                   uint8 = 11
+)
+
+// Methods of the Credit interface class (112)
+const (
+	CreditMethodUpdateAmount     uint8 = 1
+	CreditMethodSetAmountToValue uint8 = 2
+	CreditMethodInvokeCredit     uint8 = 3
+)
+
+// CreditType is the kind of a credit
+type CreditType uint8
+
+const (
+	CreditTypeToken            CreditType = 0
+	CreditTypeReserved         CreditType = 1
+	CreditTypeEmergency        CreditType = 2
+	CreditTypeTimeBased        CreditType = 3
+	CreditTypeConsumptionBased CreditType = 4
+)
+
+// CreditStatus is the state of a credit
+type CreditStatus uint8
+
+const (
+	CreditStatusEnabled    CreditStatus = 0
+	CreditStatusSelectable CreditStatus = 1
+	CreditStatusSelected   CreditStatus = 2
+	CreditStatusInUse      CreditStatus = 3
+	CreditStatusExhausted  CreditStatus = 4
+)
+
+// String implements fmt.Stringer
+func (s CreditStatus) String() string {
+	switch s {
+	case CreditStatusEnabled:
+		return "enabled"
+	case CreditStatusSelectable:
+		return "selectable"
+	case CreditStatusSelected:
+		return "selected"
+	case CreditStatusInUse:
+		return "in-use"
+	case CreditStatusExhausted:
+		return "exhausted"
+	default:
+		return fmt.Sprintf("CreditStatus(%d)", uint8(s))
+	}
+}
+
+// Credit is a Credit object (class 112) of payment metering, an amount of
+// credit available to an account
+type Credit struct {
+	LogicalName              *Obis
+	CurrentCreditAmount      int32
+	Type                     CreditType
+	Priority                 uint8
+	WarningThreshold         int32
+	Limit                    int32
+	Configuration            *dlmsdata.BitString
+	Status                   CreditStatus
+	PresetCreditAmount       int32
+	CreditAvailableThreshold int32
+	Period                   time.Time
+}
+
+// NewCredit creates a new Credit, the attributes are filled in with
+// FromAttribute or set directly before writing them
+func NewCredit(logicalName *Obis) *Credit {
+	return &Credit{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the credit
+func (c *Credit) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceCredit, c.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the credit
+func (c *Credit) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceCredit, c.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the Credit
+func (c *Credit) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case CreditAttributeCurrentCreditAmount:
+		c.CurrentCreditAmount, err = r.doubleLong()
+	case CreditAttributeType:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		c.Type = CreditType(value)
+	case CreditAttributePriority:
+		value, err = r.unsigned(dlmsdata.TagUnsigned, 1)
+		c.Priority = uint8(value)
+	case CreditAttributeWarningThreshold:
+		c.WarningThreshold, err = r.doubleLong()
+	case CreditAttributeLimit:
+		c.Limit, err = r.doubleLong()
+	case CreditAttributeConfiguration:
+		c.Configuration, err = r.bitString()
+	case CreditAttributeStatus:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		c.Status = CreditStatus(value)
+	case CreditAttributePresetCreditAmount:
+		c.PresetCreditAmount, err = r.doubleLong()
+	case CreditAttributeCreditAvailableThreshold:
+		c.CreditAvailableThreshold, err = r.doubleLong()
+	case CreditAttributePeriod:
+		c.Period, err = r.dateTime()
+	default:
+		return fmt.Errorf("credit has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("credit attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of an attribute of the Credit. Only the
+// thresholds, the limit and the preset amount can be written, the amount
+// itself is changed with the methods.
+func (c *Credit) ToAttribute(attribute uint8) ([]byte, error) {
+	switch attribute {
+	case CreditAttributeWarningThreshold:
+		return encodeDoubleLong(c.WarningThreshold), nil
+	case CreditAttributeLimit:
+		return encodeDoubleLong(c.Limit), nil
+	case CreditAttributePresetCreditAmount:
+		return encodeDoubleLong(c.PresetCreditAmount), nil
+	case CreditAttributeCreditAvailableThreshold:
+		return encodeDoubleLong(c.CreditAvailableThreshold), nil
+	default:
+		return nil, fmt.Errorf("credit attribute %d can't be written", attribute)
+	}
+}
+
+// AmountToBytes encodes the parameter of the methods taking an amount, like
+// update_amount of the credit or update_total_amount_remaining of the charge
+func AmountToBytes(amount int32) []byte {
+	return encodeDoubleLong(amount)
+}
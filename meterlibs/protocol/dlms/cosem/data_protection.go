@@ -0,0 +1,415 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Data protection interface class (30)
+const (
+	DataProtectionAttributeProtectionBuffer        uint8 = 2
+	DataProtectionAttributeProtectionObjectList    uint8 = 3
+	DataProtectionAttributeProtectionParametersGet uint8 = 4
+	DataProtectionAttributeProtectionParametersSet uint8 = 5
+	DataProtectionAttributeRequiredProtection      uint8 = 6
+)
+
+// Methods of the Data protection interface class (30)
+const (
+	DataProtectionMethodGetProtectedAttributes uint8 = 1
+	DataProtectionMethodSetProtectedAttributes uint8 = 2
+)
+
+// DataProtectionType is the protection applied to the protected attributes
+type DataProtectionType uint8
+
+const (
+	DataProtectionAuthentication              DataProtectionType = 1
+	DataProtectionEncryption                  DataProtectionType = 2
+	DataProtectionAuthenticationAndEncryption DataProtectionType = 3
+	DataProtectionDigitalSignature            DataProtectionType = 4
+)
+
+// DataProtectionKeyInfoType is how the key of the protection is given
+type DataProtectionKeyInfoType uint8
+
+const (
+	DataProtectionKeyIdentified DataProtectionKeyInfoType = 0
+	DataProtectionKeyWrapped    DataProtectionKeyInfoType = 1
+	DataProtectionKeyAgreed     DataProtectionKeyInfoType = 2
+)
+
+// DataProtectionKeyInfo is the key_info of the protection options. The
+// fields used depend on the Type.
+type DataProtectionKeyInfo struct {
+	Type DataProtectionKeyInfoType
+	// KeyID of an identified key, 0 global unicast encryption key and 1
+	// global broadcast encryption key
+	KeyID uint8
+	// KekID of a wrapped key, 0 master key
+	KekID uint8
+	// KeyParameters of an agreed key, the key agreement scheme
+	KeyParameters []byte
+	// KeyCipheredData is the wrapped key, or the data of the key agreement
+	KeyCipheredData []byte
+}
+
+// DataProtectionParameters is a protection_parameters_element, the
+// protection applied to the protected attributes and its options
+type DataProtectionParameters struct {
+	Type                  DataProtectionType
+	TransactionID         []byte
+	OriginatorSystemTitle []byte
+	RecipientSystemTitle  []byte
+	OtherInformation      []byte
+	// KeyInfo is nil for the protections without a key, it is encoded as
+	// null-data
+	KeyInfo *DataProtectionKeyInfo
+}
+
+// ToBytes converts DataProtectionParameters to a protection_parameters_element structure
+func (p *DataProtectionParameters) ToBytes() ([]byte, error) {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeUnsigned(dlmsdata.TagEnum, 1, uint64(p.Type))...)
+	result = append(result, encodeHeader(dlmsdata.TagStructure, 5)...)
+	result = append(result, encodeOctetString(p.TransactionID)...)
+	result = append(result, encodeOctetString(p.OriginatorSystemTitle)...)
+	result = append(result, encodeOctetString(p.RecipientSystemTitle)...)
+	result = append(result, encodeOctetString(p.OtherInformation)...)
+	if p.KeyInfo == nil {
+		return append(result, byte(dlmsdata.TagNull)), nil
+	}
+
+	k := p.KeyInfo
+	result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+	result = append(result, encodeUnsigned(dlmsdata.TagEnum, 1, uint64(k.Type))...)
+	switch k.Type {
+	case DataProtectionKeyIdentified:
+		result = append(result, encodeUnsigned(dlmsdata.TagEnum, 1, uint64(k.KeyID))...)
+	case DataProtectionKeyWrapped:
+		result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+		result = append(result, encodeUnsigned(dlmsdata.TagEnum, 1, uint64(k.KekID))...)
+		result = append(result, encodeOctetString(k.KeyCipheredData)...)
+	case DataProtectionKeyAgreed:
+		result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+		result = append(result, encodeOctetString(k.KeyParameters)...)
+		result = append(result, encodeOctetString(k.KeyCipheredData)...)
+	default:
+		return nil, fmt.Errorf("unknown key info type %d", k.Type)
+	}
+	return result, nil
+}
+
+// DataProtectionObject is an attribute of the protection_object_list, or of
+// the object_list of the methods. DataIndex selects an element of the
+// attribute, 0 the whole attribute.
+type DataProtectionObject struct {
+	Interface      enumerations.CosemInterface
+	LogicalName    *Obis
+	AttributeIndex int8
+	DataIndex      uint16
+}
+
+// ToBytes converts DataProtectionObject to an object_definition structure
+func (o *DataProtectionObject) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 4)
+	result = append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(o.Interface))...)
+	result = append(result, encodeOctetString(o.LogicalName.ToBytes())...)
+	result = append(result, encodeUnsigned(dlmsdata.TagInteger, 1, uint64(uint8(o.AttributeIndex)))...)
+	return append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(o.DataIndex))...)
+}
+
+// String implements fmt.Stringer
+func (o *DataProtectionObject) String() string {
+	return fmt.Sprintf("%d/%s/%d/%d", o.Interface, o.LogicalName, o.AttributeIndex, o.DataIndex)
+}
+
+// DataProtection is a Data protection object (class 30). It reads and writes
+// attributes of other objects protected end-to-end, e.g. through a gateway,
+// with the protection parameters given for the transfer.
+type DataProtection struct {
+	LogicalName             *Obis
+	ProtectionBuffer        []byte
+	ProtectionObjectList    []*DataProtectionObject
+	ProtectionParametersGet []*DataProtectionParameters
+	ProtectionParametersSet []*DataProtectionParameters
+	RequiredProtection      DataProtectionType
+}
+
+// NewDataProtection creates a new DataProtection, the attributes are filled
+// in with FromAttribute or set directly before writing them
+func NewDataProtection(logicalName *Obis) *DataProtection {
+	return &DataProtection{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the data protection
+func (d *DataProtection) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceCosemDataProtection, d.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the data protection
+func (d *DataProtection) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceCosemDataProtection, d.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the DataProtection
+func (d *DataProtection) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+	var octets []byte
+
+	switch attribute {
+	case DataProtectionAttributeProtectionBuffer:
+		octets, err = r.octetString()
+		d.ProtectionBuffer = append([]byte{}, octets...)
+	case DataProtectionAttributeProtectionObjectList:
+		d.ProtectionObjectList, err = r.dataProtectionObjects()
+	case DataProtectionAttributeProtectionParametersGet:
+		d.ProtectionParametersGet, err = r.dataProtectionParametersList()
+	case DataProtectionAttributeProtectionParametersSet:
+		d.ProtectionParametersSet, err = r.dataProtectionParametersList()
+	case DataProtectionAttributeRequiredProtection:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		d.RequiredProtection = DataProtectionType(value)
+	default:
+		return fmt.Errorf("data protection has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("data protection attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of an attribute of the DataProtection. The
+// protection buffer can't be written.
+func (d *DataProtection) ToAttribute(attribute uint8) ([]byte, error) {
+	switch attribute {
+	case DataProtectionAttributeProtectionObjectList:
+		return dataProtectionObjectsToBytes(d.ProtectionObjectList), nil
+	case DataProtectionAttributeProtectionParametersGet:
+		return dataProtectionParametersListToBytes(d.ProtectionParametersGet)
+	case DataProtectionAttributeProtectionParametersSet:
+		return dataProtectionParametersListToBytes(d.ProtectionParametersSet)
+	case DataProtectionAttributeRequiredProtection:
+		return encodeUnsigned(dlmsdata.TagEnum, 1, uint64(d.RequiredProtection)), nil
+	default:
+		return nil, fmt.Errorf("data protection attribute %d can't be written", attribute)
+	}
+}
+
+// DataProtectionGetProtectedAttributesToBytes encodes the parameter of the
+// get_protected_attributes method, the attributes to read and the protection
+// the server applies to them
+func DataProtectionGetProtectedAttributesToBytes(objects []*DataProtectionObject, parameters []*DataProtectionParameters) ([]byte, error) {
+	encodedParameters, err := dataProtectionParametersListToBytes(parameters)
+	if err != nil {
+		return nil, err
+	}
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, dataProtectionObjectsToBytes(objects)...)
+	return append(result, encodedParameters...), nil
+}
+
+// DataProtectionSetProtectedAttributesToBytes encodes the parameter of the
+// set_protected_attributes method, the attributes to write, the protection
+// applied to them and the protected attributes
+func DataProtectionSetProtectedAttributesToBytes(objects []*DataProtectionObject, parameters []*DataProtectionParameters, protectedAttributes []byte) ([]byte, error) {
+	encodedParameters, err := dataProtectionParametersListToBytes(parameters)
+	if err != nil {
+		return nil, err
+	}
+	result := encodeHeader(dlmsdata.TagStructure, 3)
+	result = append(result, dataProtectionObjectsToBytes(objects)...)
+	result = append(result, encodedParameters...)
+	return append(result, encodeOctetString(protectedAttributes)...), nil
+}
+
+// ParseDataProtectionGetProtectedAttributesResponse decodes the return data
+// of the get_protected_attributes method, the protection applied by the
+// server and the protected attributes
+func ParseDataProtectionGetProtectedAttributesResponse(data []byte) ([]*DataProtectionParameters, []byte, error) {
+	r := &dataReader{data: data}
+	if _, err := r.structure(2); err != nil {
+		return nil, nil, fmt.Errorf("get_protected_attributes response: %w", err)
+	}
+	parameters, err := r.dataProtectionParametersList()
+	if err != nil {
+		return nil, nil, fmt.Errorf("protection_parameters: %w", err)
+	}
+	protectedAttributes, err := r.octetString()
+	if err != nil {
+		return nil, nil, fmt.Errorf("protected_attributes: %w", err)
+	}
+	if err := r.end(); err != nil {
+		return nil, nil, err
+	}
+	return parameters, append([]byte{}, protectedAttributes...), nil
+}
+
+func dataProtectionObjectsToBytes(objects []*DataProtectionObject) []byte {
+	result := encodeHeader(dlmsdata.TagArray, len(objects))
+	for _, object := range objects {
+		result = append(result, object.ToBytes()...)
+	}
+	return result
+}
+
+func dataProtectionParametersListToBytes(parameters []*DataProtectionParameters) ([]byte, error) {
+	result := encodeHeader(dlmsdata.TagArray, len(parameters))
+	for i, p := range parameters {
+		encoded, err := p.ToBytes()
+		if err != nil {
+			return nil, fmt.Errorf("protection parameters %d: %w", i, err)
+		}
+		result = append(result, encoded...)
+	}
+	return result, nil
+}
+
+func (r *dataReader) dataProtectionObjects() ([]*DataProtectionObject, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*DataProtectionObject, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(4); err != nil {
+			return nil, fmt.Errorf("object %d: %w", i, err)
+		}
+		classID, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: class_id: %w", i, err)
+		}
+		logicalName, err := r.obis()
+		if err != nil {
+			return nil, fmt.Errorf("object %d: logical_name: %w", i, err)
+		}
+		attributeIndex, err := r.signed(dlmsdata.TagInteger)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: attribute_index: %w", i, err)
+		}
+		dataIndex, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: data_index: %w", i, err)
+		}
+		result = append(result, &DataProtectionObject{
+			Interface:      enumerations.CosemInterface(classID),
+			LogicalName:    logicalName,
+			AttributeIndex: attributeIndex,
+			DataIndex:      uint16(dataIndex),
+		})
+	}
+	return result, nil
+}
+
+func (r *dataReader) dataProtectionParametersList() ([]*DataProtectionParameters, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*DataProtectionParameters, 0, count)
+	for i := 0; i < count; i++ {
+		parameters, err := r.dataProtectionParameters()
+		if err != nil {
+			return nil, fmt.Errorf("protection parameters %d: %w", i, err)
+		}
+		result = append(result, parameters)
+	}
+	return result, nil
+}
+
+func (r *dataReader) dataProtectionParameters() (*DataProtectionParameters, error) {
+	if _, err := r.structure(2); err != nil {
+		return nil, err
+	}
+	protectionType, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err != nil {
+		return nil, fmt.Errorf("protection_type: %w", err)
+	}
+	if _, err = r.structure(5); err != nil {
+		return nil, fmt.Errorf("protection_options: %w", err)
+	}
+	p := &DataProtectionParameters{Type: DataProtectionType(protectionType)}
+	for _, field := range []struct {
+		name  string
+		value *[]byte
+	}{
+		{"transaction_id", &p.TransactionID},
+		{"originator_system_title", &p.OriginatorSystemTitle},
+		{"recipient_system_title", &p.RecipientSystemTitle},
+		{"other_information", &p.OtherInformation},
+	} {
+		octets, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		*field.value = append([]byte{}, octets...)
+	}
+	if r.next(dlmsdata.TagNull) {
+		r.data = r.data[1:]
+		return p, nil
+	}
+	if p.KeyInfo, err = r.dataProtectionKeyInfo(); err != nil {
+		return nil, fmt.Errorf("key_info: %w", err)
+	}
+	return p, nil
+}
+
+func (r *dataReader) dataProtectionKeyInfo() (*DataProtectionKeyInfo, error) {
+	if _, err := r.structure(2); err != nil {
+		return nil, err
+	}
+	keyInfoType, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err != nil {
+		return nil, fmt.Errorf("key_info_type: %w", err)
+	}
+	k := &DataProtectionKeyInfo{Type: DataProtectionKeyInfoType(keyInfoType)}
+	switch k.Type {
+	case DataProtectionKeyIdentified:
+		keyID, err := r.unsigned(dlmsdata.TagEnum, 1)
+		if err != nil {
+			return nil, fmt.Errorf("key_id: %w", err)
+		}
+		k.KeyID = uint8(keyID)
+	case DataProtectionKeyWrapped:
+		if _, err = r.structure(2); err != nil {
+			return nil, err
+		}
+		kekID, err := r.unsigned(dlmsdata.TagEnum, 1)
+		if err != nil {
+			return nil, fmt.Errorf("kek_id: %w", err)
+		}
+		k.KekID = uint8(kekID)
+		if k.KeyCipheredData, err = r.copiedOctetString(); err != nil {
+			return nil, fmt.Errorf("key_ciphered_data: %w", err)
+		}
+	case DataProtectionKeyAgreed:
+		if _, err = r.structure(2); err != nil {
+			return nil, err
+		}
+		if k.KeyParameters, err = r.copiedOctetString(); err != nil {
+			return nil, fmt.Errorf("key_parameters: %w", err)
+		}
+		if k.KeyCipheredData, err = r.copiedOctetString(); err != nil {
+			return nil, fmt.Errorf("key_ciphered_data: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown key info type %d", k.Type)
+	}
+	return k, nil
+}
+
+func (r *dataReader) copiedOctetString() ([]byte, error) {
+	octets, err := r.octetString()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, octets...), nil
+}
@@ -0,0 +1,143 @@
+package cosem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Demand register interface class (5)
+const (
+	DemandRegisterAttributeCurrentAverageValue uint8 = 2
+	DemandRegisterAttributeLastAverageValue    uint8 = 3
+	DemandRegisterAttributeScalerUnit          uint8 = 4
+	DemandRegisterAttributeStatus              uint8 = 5
+	DemandRegisterAttributeCaptureTime         uint8 = 6
+	DemandRegisterAttributeStartTimeCurrent    uint8 = 7
+	DemandRegisterAttributePeriod              uint8 = 8
+	DemandRegisterAttributeNumberOfPeriods     uint8 = 9
+)
+
+// Methods of the Demand register interface class (5)
+const (
+	DemandRegisterMethodReset      uint8 = 1
+	DemandRegisterMethodNextPeriod uint8 = 2
+)
+
+// DemandRegister is a Demand register object (class 5), the average of a
+// quantity over sliding periods. The averages and the status are CHOICEs,
+// they are kept decoded as by encoding.DecodeValue.
+type DemandRegister struct {
+	LogicalName         *Obis
+	CurrentAverageValue interface{}
+	LastAverageValue    interface{}
+	ScalerUnit          *ScalerUnit
+	Status              interface{}
+	// CaptureTime is the end of the last completed period
+	CaptureTime time.Time
+	// StartTimeCurrent is the start of the current period
+	StartTimeCurrent time.Time
+	// Period is the length of a period in seconds
+	Period          uint32
+	NumberOfPeriods uint16
+}
+
+// NewDemandRegister creates a new DemandRegister, the attributes are filled in
+// with FromAttribute
+func NewDemandRegister(logicalName *Obis) *DemandRegister {
+	return &DemandRegister{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the demand register
+func (d *DemandRegister) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceDemandRegister, d.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the demand register
+func (d *DemandRegister) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceDemandRegister, d.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the DemandRegister
+func (d *DemandRegister) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case DemandRegisterAttributeCurrentAverageValue:
+		d.CurrentAverageValue, err = r.decodedValue()
+	case DemandRegisterAttributeLastAverageValue:
+		d.LastAverageValue, err = r.decodedValue()
+	case DemandRegisterAttributeScalerUnit:
+		d.ScalerUnit, err = r.scalerUnit()
+	case DemandRegisterAttributeStatus:
+		d.Status, err = r.decodedValue()
+	case DemandRegisterAttributeCaptureTime:
+		d.CaptureTime, err = r.dateTime()
+	case DemandRegisterAttributeStartTimeCurrent:
+		d.StartTimeCurrent, err = r.dateTime()
+	case DemandRegisterAttributePeriod:
+		value, err = r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+		d.Period = uint32(value)
+	case DemandRegisterAttributeNumberOfPeriods:
+		value, err = r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		d.NumberOfPeriods = uint16(value)
+	default:
+		return fmt.Errorf("demand register has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("demand register attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of a writable attribute of the DemandRegister
+func (d *DemandRegister) ToAttribute(attribute uint8) ([]byte, error) {
+	switch attribute {
+	case DemandRegisterAttributePeriod:
+		return encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, uint64(d.Period)), nil
+	case DemandRegisterAttributeNumberOfPeriods:
+		return encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(d.NumberOfPeriods)), nil
+	default:
+		return nil, fmt.Errorf("demand register attribute %d is not writable", attribute)
+	}
+}
+
+// CurrentAverage returns the scaled current_average_value
+func (d *DemandRegister) CurrentAverage() (float64, error) {
+	return d.scaled(d.CurrentAverageValue)
+}
+
+// LastAverage returns the scaled last_average_value
+func (d *DemandRegister) LastAverage() (float64, error) {
+	return d.scaled(d.LastAverageValue)
+}
+
+// PeriodDuration returns the length of a period
+func (d *DemandRegister) PeriodDuration() time.Duration {
+	return time.Duration(d.Period) * time.Second
+}
+
+func (d *DemandRegister) scaled(value interface{}) (float64, error) {
+	if d.ScalerUnit == nil {
+		return 0, fmt.Errorf("demand register %s: scaler_unit not read", d.LogicalName)
+	}
+	return d.ScalerUnit.Apply(value)
+}
+
+// decodedValue reads a value of any type and decodes it
+func (r *dataReader) decodedValue() (interface{}, error) {
+	value, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return encoding.DecodeValue(value)
+}
@@ -0,0 +1,132 @@
+package cosem
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// EnumNames are the names of the values of an enum attribute
+type EnumNames map[dlmsdata.Enum]string
+
+// NamedEnum is a decoded enum value with its name
+type NamedEnum struct {
+	Value dlmsdata.Enum
+	Name  string
+}
+
+// String implements fmt.Stringer
+func (e *NamedEnum) String() string {
+	return fmt.Sprintf("%s(%d)", e.Name, e.Value)
+}
+
+// Attributes of the disconnect control interface class (70)
+const (
+	DisconnectControlAttributeOutputState  uint8 = 2
+	DisconnectControlAttributeControlState uint8 = 3
+	DisconnectControlAttributeControlMode  uint8 = 4
+)
+
+// enumKey identifies an attribute of any instance of an interface class when
+// instance is empty, of a single instance otherwise
+type enumKey struct {
+	iface     enumerations.CosemInterface
+	instance  string
+	attribute uint8
+}
+
+// EnumResolver gives the names of the values of enum attributes, so decoded
+// values are readable. Names are registered for an attribute of all the
+// instances of an interface class, or for the attribute of a single instance
+// which wins over the class. It is safe for concurrent use.
+type EnumResolver struct {
+	mutex sync.RWMutex
+	names map[enumKey]EnumNames
+}
+
+// NewEnumResolver creates a new EnumResolver without names
+func NewEnumResolver() *EnumResolver {
+	return &EnumResolver{names: make(map[enumKey]EnumNames)}
+}
+
+// NewDefaultEnumResolver creates a new EnumResolver with the names of the
+// enum attributes of the Blue Book interface classes
+func NewDefaultEnumResolver() *EnumResolver {
+	r := NewEnumResolver()
+	r.RegisterInterface(enumerations.CosemInterfaceDisconnectControl, DisconnectControlAttributeControlState, EnumNames{
+		0: "disconnected",
+		1: "connected",
+		2: "ready_for_reconnection",
+	})
+	r.RegisterInterface(enumerations.CosemInterfaceClock, ClockAttributeClockBase, EnumNames{
+		0: "not_defined",
+		1: "internal_crystal",
+		2: "mains_frequency_50_hz",
+		3: "mains_frequency_60_hz",
+		4: "gps",
+		5: "radio_controlled",
+	})
+	return r
+}
+
+// RegisterInterface sets the names of an attribute of all the instances of an interface class
+func (r *EnumResolver) RegisterInterface(iface enumerations.CosemInterface, attribute uint8, names EnumNames) {
+	r.register(enumKey{iface: iface, attribute: attribute}, names)
+}
+
+// Register sets the names of an attribute of a single instance, e.g. a
+// manufacturer specific object
+func (r *EnumResolver) Register(attribute *CosemAttribute, names EnumNames) {
+	r.register(enumKey{iface: attribute.Interface, instance: attribute.Instance.String(), attribute: attribute.Attribute}, names)
+}
+
+func (r *EnumResolver) register(key enumKey, names EnumNames) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.names[key] = names
+}
+
+// Names returns the names of the values of an attribute, nil when none are registered
+func (r *EnumResolver) Names(attribute *CosemAttribute) EnumNames {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if attribute.Instance != nil {
+		if names, ok := r.names[enumKey{attribute.Interface, attribute.Instance.String(), attribute.Attribute}]; ok {
+			return names
+		}
+	}
+	return r.names[enumKey{iface: attribute.Interface, attribute: attribute.Attribute}]
+}
+
+// Resolve replaces the enum values of a decoded attribute value by a
+// *NamedEnum when their name is registered. The enums inside arrays and
+// structures are replaced too, with the same names.
+func (r *EnumResolver) Resolve(attribute *CosemAttribute, value interface{}) interface{} {
+	names := r.Names(attribute)
+	if names == nil {
+		return value
+	}
+	return resolveEnums(names, value)
+}
+
+func resolveEnums(names EnumNames, value interface{}) interface{} {
+	switch v := value.(type) {
+	case dlmsdata.Enum:
+		if name, ok := names[v]; ok {
+			return &NamedEnum{Value: v, Name: name}
+		}
+		return v
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, element := range v {
+			result[i] = resolveEnums(names, element)
+		}
+		return result
+	default:
+		return value
+	}
+}
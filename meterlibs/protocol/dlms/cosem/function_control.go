@@ -0,0 +1,183 @@
+package cosem
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Function control interface class (122)
+const (
+	FunctionControlAttributeActivationStatus uint8 = 2
+	FunctionControlAttributeFunctionList     uint8 = 3
+)
+
+// Methods of the Function control interface class (122)
+const (
+	FunctionControlMethodSetFunctionStatus uint8 = 1
+	FunctionControlMethodAddFunction       uint8 = 2
+	FunctionControlMethodRemoveFunction    uint8 = 3
+)
+
+// FunctionStatus is an element of the activation_status attribute, and of
+// the parameter of the set_function_status method
+type FunctionStatus struct {
+	Name    []byte
+	Enabled bool
+}
+
+// ToBytes converts FunctionStatus to a structure of function name and status
+func (s *FunctionStatus) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeOctetString(s.Name)...)
+	return append(result, encodeBoolean(s.Enabled)...)
+}
+
+// Function is an element of the function_list attribute, a function and the
+// objects implementing it. It is the parameter of the add_function method.
+type Function struct {
+	Name    []byte
+	Objects []*ObjectDefinition
+}
+
+// ToBytes converts Function to a structure of function name and function specification
+func (f *Function) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeOctetString(f.Name)...)
+	result = append(result, encodeHeader(dlmsdata.TagArray, len(f.Objects))...)
+	for _, object := range f.Objects {
+		result = append(result, object.ToBytes()...)
+	}
+	return result
+}
+
+// FunctionControl is a Function control object (class 122). It enables and
+// disables functions of the meter, e.g. the local readout in IDIS meters.
+type FunctionControl struct {
+	LogicalName      *Obis
+	ActivationStatus []*FunctionStatus
+	FunctionList     []*Function
+}
+
+// NewFunctionControl creates a new FunctionControl, the attributes are
+// filled in with FromAttribute
+func NewFunctionControl(logicalName *Obis) *FunctionControl {
+	return &FunctionControl{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the function control
+func (f *FunctionControl) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceFunctionControl, f.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the function control
+func (f *FunctionControl) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceFunctionControl, f.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the FunctionControl
+func (f *FunctionControl) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+
+	switch attribute {
+	case FunctionControlAttributeActivationStatus:
+		f.ActivationStatus, err = r.functionStatuses()
+	case FunctionControlAttributeFunctionList:
+		f.FunctionList, err = r.functions()
+	default:
+		return fmt.Errorf("function control has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("function control attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// Status returns the status of the function with the given name
+func (f *FunctionControl) Status(name []byte) (*FunctionStatus, bool) {
+	for _, status := range f.ActivationStatus {
+		if bytes.Equal(status.Name, name) {
+			return status, true
+		}
+	}
+	return nil, false
+}
+
+// FunctionStatusesToBytes encodes the parameter of the set_function_status method
+func FunctionStatusesToBytes(statuses []*FunctionStatus) []byte {
+	result := encodeHeader(dlmsdata.TagArray, len(statuses))
+	for _, status := range statuses {
+		result = append(result, status.ToBytes()...)
+	}
+	return result
+}
+
+// FunctionNamesToBytes encodes the parameter of the remove_function method
+func FunctionNamesToBytes(names [][]byte) []byte {
+	result := encodeHeader(dlmsdata.TagArray, len(names))
+	for _, name := range names {
+		result = append(result, encodeOctetString(name)...)
+	}
+	return result
+}
+
+func (r *dataReader) functionStatuses() ([]*FunctionStatus, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*FunctionStatus, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		name, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("function %d: function_name: %w", i, err)
+		}
+		enabled, err := r.unsigned(dlmsdata.TagBoolean, 1)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: status: %w", i, err)
+		}
+		result = append(result, &FunctionStatus{Name: append([]byte{}, name...), Enabled: enabled != 0})
+	}
+	return result, nil
+}
+
+func (r *dataReader) functions() ([]*Function, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Function, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		name, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("function %d: function_name: %w", i, err)
+		}
+		objectCount, err := r.header(dlmsdata.TagArray)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: function_specification: %w", i, err)
+		}
+		function := &Function{Name: append([]byte{}, name...), Objects: make([]*ObjectDefinition, 0, objectCount)}
+		for j := 0; j < objectCount; j++ {
+			object, err := r.objectDefinition()
+			if err != nil {
+				return nil, fmt.Errorf("function %d: object %d: %w", i, j, err)
+			}
+			function.Objects = append(function.Objects, object)
+		}
+		result = append(result, function)
+	}
+	return result, nil
+}
@@ -0,0 +1,287 @@
+package cosem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the GSM diagnostic interface class (47)
+const (
+	GSMDiagnosticsAttributeOperator      uint8 = 2
+	GSMDiagnosticsAttributeStatus        uint8 = 3
+	GSMDiagnosticsAttributeCSAttachment  uint8 = 4
+	GSMDiagnosticsAttributePSStatus      uint8 = 5
+	GSMDiagnosticsAttributeCellInfo      uint8 = 6
+	GSMDiagnosticsAttributeAdjacentCells uint8 = 7
+	GSMDiagnosticsAttributeCaptureTime   uint8 = 8
+)
+
+// GSMRegistrationStatus is the registration status of the modem
+type GSMRegistrationStatus uint8
+
+const (
+	GSMRegistrationStatusNotRegistered          GSMRegistrationStatus = 0
+	GSMRegistrationStatusRegisteredHome         GSMRegistrationStatus = 1
+	GSMRegistrationStatusNotRegisteredSearching GSMRegistrationStatus = 2
+	GSMRegistrationStatusDenied                 GSMRegistrationStatus = 3
+	GSMRegistrationStatusUnknown                GSMRegistrationStatus = 4
+	GSMRegistrationStatusRegisteredRoaming      GSMRegistrationStatus = 5
+)
+
+// String implements fmt.Stringer
+func (s GSMRegistrationStatus) String() string {
+	switch s {
+	case GSMRegistrationStatusNotRegistered:
+		return "not registered"
+	case GSMRegistrationStatusRegisteredHome:
+		return "registered, home network"
+	case GSMRegistrationStatusNotRegisteredSearching:
+		return "not registered, searching"
+	case GSMRegistrationStatusDenied:
+		return "registration denied"
+	case GSMRegistrationStatusUnknown:
+		return "unknown"
+	case GSMRegistrationStatusRegisteredRoaming:
+		return "registered, roaming"
+	default:
+		return fmt.Sprintf("reserved (%d)", uint8(s))
+	}
+}
+
+// Registered returns true when the modem is registered in the home network or roaming
+func (s GSMRegistrationStatus) Registered() bool {
+	return s == GSMRegistrationStatusRegisteredHome || s == GSMRegistrationStatusRegisteredRoaming
+}
+
+// GSMCircuitSwitchedStatus is the circuit switched attachment of the modem
+type GSMCircuitSwitchedStatus uint8
+
+const (
+	GSMCircuitSwitchedStatusInactive     GSMCircuitSwitchedStatus = 0
+	GSMCircuitSwitchedStatusIncomingCall GSMCircuitSwitchedStatus = 1
+	GSMCircuitSwitchedStatusActive       GSMCircuitSwitchedStatus = 2
+)
+
+// GSMPacketSwitchedStatus is the packet switched technology the modem is attached with
+type GSMPacketSwitchedStatus uint8
+
+const (
+	GSMPacketSwitchedStatusInactive GSMPacketSwitchedStatus = 0
+	GSMPacketSwitchedStatusGPRS     GSMPacketSwitchedStatus = 1
+	GSMPacketSwitchedStatusEDGE     GSMPacketSwitchedStatus = 2
+	GSMPacketSwitchedStatusUMTS     GSMPacketSwitchedStatus = 3
+	GSMPacketSwitchedStatusHSDPA    GSMPacketSwitchedStatus = 4
+	GSMPacketSwitchedStatusLTE      GSMPacketSwitchedStatus = 5
+	GSMPacketSwitchedStatusCDMA     GSMPacketSwitchedStatus = 6
+	GSMPacketSwitchedStatusLTEM     GSMPacketSwitchedStatus = 7
+	GSMPacketSwitchedStatusNBIoT    GSMPacketSwitchedStatus = 8
+)
+
+// String implements fmt.Stringer
+func (s GSMPacketSwitchedStatus) String() string {
+	names := []string{"inactive", "GPRS", "EDGE", "UMTS", "HSDPA", "LTE", "CDMA", "LTE-M", "NB-IoT"}
+	if int(s) < len(names) {
+		return names[s]
+	}
+	return fmt.Sprintf("reserved (%d)", uint8(s))
+}
+
+// SignalQualityUnknown is the signal quality when it is not known or not detectable
+const SignalQualityUnknown = 99
+
+// SignalQuality is the received signal strength of a cell, as the RSSI value
+// of 3GPP TS 27.007: 0 for -113 dBm or less up to 31 for -51 dBm or more
+type SignalQuality uint8
+
+// DBm returns the signal strength in dBm, false when the signal quality is unknown
+func (q SignalQuality) DBm() (int, bool) {
+	if q > 31 {
+		return 0, false
+	}
+	return -113 + 2*int(q), true
+}
+
+// String implements fmt.Stringer
+func (q SignalQuality) String() string {
+	dbm, ok := q.DBm()
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d dBm", dbm)
+}
+
+// GSMCellInfo is the cell_info attribute: the cell the modem is registered in
+type GSMCellInfo struct {
+	CellID        uint32
+	LocationID    uint16
+	SignalQuality SignalQuality
+	// BitErrorRate is the RXQUAL value 0-7, 99 when not known
+	BitErrorRate      uint8
+	MobileCountryCode uint16
+	MobileNetworkCode uint16
+	ChannelNumber     uint32
+}
+
+// GSMAdjacentCell is an element of the adjacent_cells attribute
+type GSMAdjacentCell struct {
+	CellID        uint32
+	SignalQuality SignalQuality
+}
+
+// GSMDiagnostics is a GSM diagnostic object (class 47). It shows the
+// network the modem is registered in and the quality of the connection.
+type GSMDiagnostics struct {
+	LogicalName   *Obis
+	Operator      string
+	Status        GSMRegistrationStatus
+	CSAttachment  GSMCircuitSwitchedStatus
+	PSStatus      GSMPacketSwitchedStatus
+	CellInfo      *GSMCellInfo
+	AdjacentCells []*GSMAdjacentCell
+	// CaptureTime is when the values were captured, the zero time when not specified
+	CaptureTime time.Time
+}
+
+// NewGSMDiagnostics creates a new GSMDiagnostics, the attributes are filled
+// in with FromAttribute
+func NewGSMDiagnostics(logicalName *Obis) *GSMDiagnostics {
+	return &GSMDiagnostics{
+		LogicalName: logicalName,
+	}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the GSM diagnostic
+func (g *GSMDiagnostics) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceGSMDiagnostics, g.LogicalName, attribute)
+}
+
+// FromAttribute decodes the value of an attribute into the GSMDiagnostics
+func (g *GSMDiagnostics) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case GSMDiagnosticsAttributeOperator:
+		g.Operator, err = r.visibleString()
+	case GSMDiagnosticsAttributeStatus:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		g.Status = GSMRegistrationStatus(value)
+	case GSMDiagnosticsAttributeCSAttachment:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		g.CSAttachment = GSMCircuitSwitchedStatus(value)
+	case GSMDiagnosticsAttributePSStatus:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		g.PSStatus = GSMPacketSwitchedStatus(value)
+	case GSMDiagnosticsAttributeCellInfo:
+		g.CellInfo, err = r.gsmCellInfo()
+	case GSMDiagnosticsAttributeAdjacentCells:
+		g.AdjacentCells, err = r.gsmAdjacentCells()
+	case GSMDiagnosticsAttributeCaptureTime:
+		g.CaptureTime, err = r.dateTime()
+	default:
+		return fmt.Errorf("GSM diagnostic has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("GSM diagnostic attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// cellID reads a cell id, sent as long-unsigned by version 0 and as
+// double-long-unsigned by the later versions
+func (r *dataReader) cellID() (uint32, error) {
+	var value uint64
+	var err error
+	if r.next(dlmsdata.TagLongUnsigned) {
+		value, err = r.unsigned(dlmsdata.TagLongUnsigned, 2)
+	} else {
+		value, err = r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cell_ID: %w", err)
+	}
+	return uint32(value), nil
+}
+
+// cellInfoFields are the elements of cell_info after the cell id
+var cellInfoFields = []struct {
+	name string
+	tag  dlmsdata.DlmsDataTag
+	size int
+}{
+	{"location_ID", dlmsdata.TagLongUnsigned, 2},
+	{"signal_quality", dlmsdata.TagUnsigned, 1},
+	{"ber", dlmsdata.TagUnsigned, 1},
+	{"mcc", dlmsdata.TagLongUnsigned, 2},
+	{"mnc", dlmsdata.TagLongUnsigned, 2},
+	{"channel_number", dlmsdata.TagDoubleLongUnsigned, 4},
+}
+
+func (r *dataReader) gsmCellInfo() (*GSMCellInfo, error) {
+	// version 0 has no mcc, mnc and channel_number
+	count, err := r.header(dlmsdata.TagStructure)
+	if err != nil {
+		return nil, err
+	}
+	if count != 4 && count != 7 {
+		return nil, fmt.Errorf("cell_info should have 4 or 7 elements, got %d", count)
+	}
+
+	cellID, err := r.cellID()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]uint64, 0, len(cellInfoFields))
+	for _, field := range cellInfoFields[:count-1] {
+		value, err := r.unsigned(field.tag, field.size)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		values = append(values, value)
+	}
+
+	result := &GSMCellInfo{
+		CellID:        cellID,
+		LocationID:    uint16(values[0]),
+		SignalQuality: SignalQuality(values[1]),
+		BitErrorRate:  uint8(values[2]),
+	}
+	if count == 7 {
+		result.MobileCountryCode = uint16(values[3])
+		result.MobileNetworkCode = uint16(values[4])
+		result.ChannelNumber = uint32(values[5])
+	}
+	return result, nil
+}
+
+func (r *dataReader) gsmAdjacentCells() ([]*GSMAdjacentCell, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*GSMAdjacentCell, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, fmt.Errorf("adjacent cell %d: %w", i, err)
+		}
+		cellID, err := r.cellID()
+		if err != nil {
+			return nil, fmt.Errorf("adjacent cell %d: %w", i, err)
+		}
+		signalQuality, err := r.unsigned(dlmsdata.TagUnsigned, 1)
+		if err != nil {
+			return nil, fmt.Errorf("adjacent cell %d: signal_quality: %w", i, err)
+		}
+		result = append(result, &GSMAdjacentCell{CellID: cellID, SignalQuality: SignalQuality(signalQuality)})
+	}
+	return result, nil
+}
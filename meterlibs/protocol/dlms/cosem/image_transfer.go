@@ -0,0 +1,192 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Image transfer interface class (18)
+const (
+	ImageTransferAttributeBlockSize                uint8 = 2
+	ImageTransferAttributeTransferredBlocksStatus  uint8 = 3
+	ImageTransferAttributeFirstNotTransferredBlock uint8 = 4
+	ImageTransferAttributeEnabled                  uint8 = 5
+	ImageTransferAttributeStatus                   uint8 = 6
+	ImageTransferAttributeImageToActivateInfo      uint8 = 7
+)
+
+// Methods of the Image transfer interface class (18)
+const (
+	ImageTransferMethodInitiate      uint8 = 1
+	ImageTransferMethodBlockTransfer uint8 = 2
+	ImageTransferMethodVerify        uint8 = 3
+	ImageTransferMethodActivate      uint8 = 4
+)
+
+// ImageTransferStatus is the state of the image transfer process
+type ImageTransferStatus uint8
+
+const (
+	ImageTransferNotInitiated   ImageTransferStatus = 0
+	ImageTransferInitiated      ImageTransferStatus = 1
+	ImageVerificationInitiated  ImageTransferStatus = 2
+	ImageVerificationSuccessful ImageTransferStatus = 3
+	ImageVerificationFailed     ImageTransferStatus = 4
+	ImageActivationInitiated    ImageTransferStatus = 5
+	ImageActivationSuccessful   ImageTransferStatus = 6
+	ImageActivationFailed       ImageTransferStatus = 7
+)
+
+// String implements fmt.Stringer
+func (s ImageTransferStatus) String() string {
+	switch s {
+	case ImageTransferNotInitiated:
+		return "transfer-not-initiated"
+	case ImageTransferInitiated:
+		return "transfer-initiated"
+	case ImageVerificationInitiated:
+		return "verification-initiated"
+	case ImageVerificationSuccessful:
+		return "verification-successful"
+	case ImageVerificationFailed:
+		return "verification-failed"
+	case ImageActivationInitiated:
+		return "activation-initiated"
+	case ImageActivationSuccessful:
+		return "activation-successful"
+	case ImageActivationFailed:
+		return "activation-failed"
+	default:
+		return fmt.Sprintf("ImageTransferStatus(%d)", uint8(s))
+	}
+}
+
+// ImageToActivateInfo is an element of the image_to_activate_info attribute,
+// an image verified and ready to be activated
+type ImageToActivateInfo struct {
+	Size           uint32
+	Identification []byte
+	Signature      []byte
+}
+
+// String implements fmt.Stringer
+func (i *ImageToActivateInfo) String() string {
+	return fmt.Sprintf("%X (%d bytes)", i.Identification, i.Size)
+}
+
+// ImageTransfer is an Image transfer object (class 18), used to transfer
+// and activate firmware images block by block
+type ImageTransfer struct {
+	LogicalName              *Obis
+	BlockSize                uint32
+	TransferredBlocksStatus  *dlmsdata.BitString
+	FirstNotTransferredBlock uint32
+	Enabled                  bool
+	Status                   ImageTransferStatus
+	ImageToActivateInfo      []*ImageToActivateInfo
+}
+
+// NewImageTransfer creates a new ImageTransfer, the attributes are filled in
+// with FromAttribute
+func NewImageTransfer(logicalName *Obis) *ImageTransfer {
+	return &ImageTransfer{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the image transfer
+func (t *ImageTransfer) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceImageTransfer, t.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the image transfer
+func (t *ImageTransfer) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceImageTransfer, t.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the ImageTransfer
+func (t *ImageTransfer) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case ImageTransferAttributeBlockSize:
+		value, err = r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+		t.BlockSize = uint32(value)
+	case ImageTransferAttributeTransferredBlocksStatus:
+		t.TransferredBlocksStatus, err = r.bitString()
+	case ImageTransferAttributeFirstNotTransferredBlock:
+		value, err = r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+		t.FirstNotTransferredBlock = uint32(value)
+	case ImageTransferAttributeEnabled:
+		value, err = r.unsigned(dlmsdata.TagBoolean, 1)
+		t.Enabled = value != 0
+	case ImageTransferAttributeStatus:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		t.Status = ImageTransferStatus(value)
+	case ImageTransferAttributeImageToActivateInfo:
+		t.ImageToActivateInfo, err = r.imagesToActivate()
+	default:
+		return fmt.Errorf("image transfer has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("image transfer attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// BlockCount returns the number of blocks of an image of the given size
+func (t *ImageTransfer) BlockCount(imageSize uint32) uint32 {
+	if t.BlockSize == 0 {
+		return 0
+	}
+	return (imageSize + t.BlockSize - 1) / t.BlockSize
+}
+
+// MissingBlocks returns the numbers of the blocks of an image of the given
+// size that the transferred_blocks_status doesn't mark as received
+func (t *ImageTransfer) MissingBlocks(imageSize uint32) []uint32 {
+	result := make([]uint32, 0)
+	for block := uint32(0); block < t.BlockCount(imageSize); block++ {
+		if t.TransferredBlocksStatus == nil || !t.TransferredBlocksStatus.Bit(int(block)) {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+func (r *dataReader) imagesToActivate() ([]*ImageToActivateInfo, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ImageToActivateInfo, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(3); err != nil {
+			return nil, fmt.Errorf("image %d: %w", i, err)
+		}
+		size, err := r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: image_to_activate_size: %w", i, err)
+		}
+		identification, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("image %d: image_to_activate_identification: %w", i, err)
+		}
+		signature, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("image %d: image_to_activate_signature: %w", i, err)
+		}
+		result = append(result, &ImageToActivateInfo{
+			Size:           uint32(size),
+			Identification: append([]byte{}, identification...),
+			Signature:      append([]byte{}, signature...),
+		})
+	}
+	return result, nil
+}
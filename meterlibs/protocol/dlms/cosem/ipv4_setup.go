@@ -0,0 +1,232 @@
+package cosem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the IPv4 setup interface class (42)
+const (
+	IPv4SetupAttributeDLReference        uint8 = 2
+	IPv4SetupAttributeIPAddress          uint8 = 3
+	IPv4SetupAttributeMulticastAddresses uint8 = 4
+	IPv4SetupAttributeIPOptions          uint8 = 5
+	IPv4SetupAttributeSubnetMask         uint8 = 6
+	IPv4SetupAttributeGateway            uint8 = 7
+	IPv4SetupAttributeUseDHCP            uint8 = 8
+	IPv4SetupAttributePrimaryDNS         uint8 = 9
+	IPv4SetupAttributeSecondaryDNS       uint8 = 10
+)
+
+// Methods of the IPv4 setup interface class (42)
+const (
+	IPv4SetupMethodAddMulticastAddress    uint8 = 1
+	IPv4SetupMethodDeleteMulticastAddress uint8 = 2
+)
+
+// IPOption is an element of the IP_options attribute
+type IPOption struct {
+	Type uint8
+	Data []byte
+}
+
+// IPv4Setup is an IPv4 setup object (class 42). It holds the IP address,
+// the routing and the DNS configuration of an IPv4 interface.
+type IPv4Setup struct {
+	LogicalName *Obis
+	// DLReference is the logical name of the data link layer setup object
+	DLReference        *Obis
+	IPAddress          net.IP
+	MulticastAddresses []net.IP
+	IPOptions          []*IPOption
+	SubnetMask         net.IP
+	Gateway            net.IP
+	UseDHCP            bool
+	PrimaryDNS         net.IP
+	SecondaryDNS       net.IP
+}
+
+// NewIPv4Setup creates a new IPv4Setup, the attributes are filled in with
+// FromAttribute or set directly before writing them
+func NewIPv4Setup(logicalName *Obis) *IPv4Setup {
+	return &IPv4Setup{
+		LogicalName: logicalName,
+	}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the IPv4 setup
+func (s *IPv4Setup) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceIPv4Setup, s.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the IPv4 setup
+func (s *IPv4Setup) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceIPv4Setup, s.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the IPv4Setup
+func (s *IPv4Setup) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case IPv4SetupAttributeDLReference:
+		s.DLReference, err = r.obis()
+	case IPv4SetupAttributeIPAddress:
+		s.IPAddress, err = r.ipv4Address()
+	case IPv4SetupAttributeMulticastAddresses:
+		s.MulticastAddresses, err = r.ipv4Addresses()
+	case IPv4SetupAttributeIPOptions:
+		s.IPOptions, err = r.ipOptions()
+	case IPv4SetupAttributeSubnetMask:
+		s.SubnetMask, err = r.ipv4Address()
+	case IPv4SetupAttributeGateway:
+		s.Gateway, err = r.ipv4Address()
+	case IPv4SetupAttributeUseDHCP:
+		value, err = r.unsigned(dlmsdata.TagBoolean, 1)
+		s.UseDHCP = value != 0
+	case IPv4SetupAttributePrimaryDNS:
+		s.PrimaryDNS, err = r.ipv4Address()
+	case IPv4SetupAttributeSecondaryDNS:
+		s.SecondaryDNS, err = r.ipv4Address()
+	default:
+		return fmt.Errorf("IPv4 setup has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("IPv4 setup attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of an attribute of the IPv4Setup
+func (s *IPv4Setup) ToAttribute(attribute uint8) ([]byte, error) {
+	var result []byte
+	var err error
+
+	switch attribute {
+	case IPv4SetupAttributeDLReference:
+		if s.DLReference == nil {
+			return nil, fmt.Errorf("IPv4 setup has no DL reference")
+		}
+		result = encodeOctetString(s.DLReference.ToBytes())
+	case IPv4SetupAttributeIPAddress:
+		result, err = encodeIPv4Address(s.IPAddress)
+	case IPv4SetupAttributeMulticastAddresses:
+		result = encodeHeader(dlmsdata.TagArray, len(s.MulticastAddresses))
+		for _, address := range s.MulticastAddresses {
+			encoded, err := encodeIPv4Address(address)
+			if err != nil {
+				return nil, fmt.Errorf("IPv4 setup attribute %d: %w", attribute, err)
+			}
+			result = append(result, encoded...)
+		}
+	case IPv4SetupAttributeIPOptions:
+		result = encodeHeader(dlmsdata.TagArray, len(s.IPOptions))
+		for _, option := range s.IPOptions {
+			result = append(result, encodeHeader(dlmsdata.TagStructure, 3)...)
+			result = append(result, encodeUnsigned(dlmsdata.TagUnsigned, 1, uint64(option.Type))...)
+			result = append(result, encodeUnsigned(dlmsdata.TagUnsigned, 1, uint64(len(option.Data)))...)
+			result = append(result, encodeOctetString(option.Data)...)
+		}
+	case IPv4SetupAttributeSubnetMask:
+		result, err = encodeIPv4Address(s.SubnetMask)
+	case IPv4SetupAttributeGateway:
+		result, err = encodeIPv4Address(s.Gateway)
+	case IPv4SetupAttributeUseDHCP:
+		result = encodeBoolean(s.UseDHCP)
+	case IPv4SetupAttributePrimaryDNS:
+		result, err = encodeIPv4Address(s.PrimaryDNS)
+	case IPv4SetupAttributeSecondaryDNS:
+		result, err = encodeIPv4Address(s.SecondaryDNS)
+	default:
+		return nil, fmt.Errorf("IPv4 setup has no attribute %d", attribute)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("IPv4 setup attribute %d: %w", attribute, err)
+	}
+	return result, nil
+}
+
+// MulticastAddressToBytes encodes the parameter of the add_mc_IP_address and
+// delete_mc_IP_address methods
+func MulticastAddressToBytes(address net.IP) ([]byte, error) {
+	if !address.IsMulticast() {
+		return nil, fmt.Errorf("%s is not a multicast address", address)
+	}
+	return encodeIPv4Address(address)
+}
+
+// encodeIPv4Address encodes an IPv4 address as double-long-unsigned, a nil
+// address is sent as 0.0.0.0
+func encodeIPv4Address(address net.IP) ([]byte, error) {
+	if address == nil {
+		return encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, 0), nil
+	}
+	ipv4 := address.To4()
+	if ipv4 == nil {
+		return nil, fmt.Errorf("%s is not an IPv4 address", address)
+	}
+	return encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, uint64(binary.BigEndian.Uint32(ipv4))), nil
+}
+
+func (r *dataReader) ipv4Address() (net.IP, error) {
+	value, err := r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+	if err != nil {
+		return nil, err
+	}
+	address := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(address, uint32(value))
+	return address, nil
+}
+
+func (r *dataReader) ipv4Addresses() ([]net.IP, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]net.IP, 0, count)
+	for i := 0; i < count; i++ {
+		address, err := r.ipv4Address()
+		if err != nil {
+			return nil, fmt.Errorf("address %d: %w", i, err)
+		}
+		result = append(result, address)
+	}
+	return result, nil
+}
+
+func (r *dataReader) ipOptions() ([]*IPOption, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*IPOption, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(3); err != nil {
+			return nil, fmt.Errorf("IP option %d: %w", i, err)
+		}
+		optionType, err := r.unsigned(dlmsdata.TagUnsigned, 1)
+		if err != nil {
+			return nil, fmt.Errorf("IP option %d: IP_Option_Type: %w", i, err)
+		}
+		if _, err = r.unsigned(dlmsdata.TagUnsigned, 1); err != nil {
+			return nil, fmt.Errorf("IP option %d: IP_Option_Length: %w", i, err)
+		}
+		data, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("IP option %d: IP_Option_Data: %w", i, err)
+		}
+		result = append(result, &IPOption{Type: uint8(optionType), Data: append([]byte{}, data...)})
+	}
+	return result, nil
+}
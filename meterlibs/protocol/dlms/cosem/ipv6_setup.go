@@ -0,0 +1,271 @@
+package cosem
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the IPv6 setup interface class (48)
+const (
+	IPv6SetupAttributeDLReference            uint8 = 2
+	IPv6SetupAttributeAddressConfigMode      uint8 = 3
+	IPv6SetupAttributeUnicastAddresses       uint8 = 4
+	IPv6SetupAttributeMulticastAddresses     uint8 = 5
+	IPv6SetupAttributeGatewayAddresses       uint8 = 6
+	IPv6SetupAttributePrimaryDNS             uint8 = 7
+	IPv6SetupAttributeSecondaryDNS           uint8 = 8
+	IPv6SetupAttributeTrafficClass           uint8 = 9
+	IPv6SetupAttributeNeighborDiscoverySetup uint8 = 10
+)
+
+// Methods of the IPv6 setup interface class (48)
+const (
+	IPv6SetupMethodAddAddress    uint8 = 1
+	IPv6SetupMethodRemoveAddress uint8 = 2
+)
+
+// IPv6AddressConfigMode is how the IPv6 addresses are configured
+type IPv6AddressConfigMode uint8
+
+const (
+	IPv6AddressConfigModeAuto              IPv6AddressConfigMode = 0
+	IPv6AddressConfigModeDHCPv6            IPv6AddressConfigMode = 1
+	IPv6AddressConfigModeManual            IPv6AddressConfigMode = 2
+	IPv6AddressConfigModeNeighborDiscovery IPv6AddressConfigMode = 3
+)
+
+// IPv6AddressType selects the address list of the add_IPv6_address and
+// remove_IPv6_address methods
+type IPv6AddressType uint8
+
+const (
+	IPv6AddressTypeUnicast   IPv6AddressType = 0
+	IPv6AddressTypeMulticast IPv6AddressType = 1
+	IPv6AddressTypeGateway   IPv6AddressType = 2
+)
+
+// NeighborDiscoverySetup is an element of the neighbor_discovery_setup attribute
+type NeighborDiscoverySetup struct {
+	MaxRetry uint8
+	// RetryWaitTime and SendPeriod are in seconds
+	RetryWaitTime uint16
+	SendPeriod    uint16
+}
+
+// IPv6Setup is an IPv6 setup object (class 48). It holds the addresses,
+// the DNS and the neighbor discovery configuration of an IPv6 interface.
+type IPv6Setup struct {
+	LogicalName *Obis
+	// DLReference is the logical name of the data link layer setup object
+	DLReference            *Obis
+	AddressConfigMode      IPv6AddressConfigMode
+	UnicastAddresses       []net.IP
+	MulticastAddresses     []net.IP
+	GatewayAddresses       []net.IP
+	PrimaryDNS             net.IP
+	SecondaryDNS           net.IP
+	TrafficClass           uint8
+	NeighborDiscoverySetup []*NeighborDiscoverySetup
+}
+
+// NewIPv6Setup creates a new IPv6Setup, the attributes are filled in with
+// FromAttribute or set directly before writing them
+func NewIPv6Setup(logicalName *Obis) *IPv6Setup {
+	return &IPv6Setup{
+		LogicalName: logicalName,
+	}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the IPv6 setup
+func (s *IPv6Setup) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceIPv6Setup, s.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the IPv6 setup
+func (s *IPv6Setup) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceIPv6Setup, s.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the IPv6Setup
+func (s *IPv6Setup) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+
+	switch attribute {
+	case IPv6SetupAttributeDLReference:
+		s.DLReference, err = r.obis()
+	case IPv6SetupAttributeAddressConfigMode:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		s.AddressConfigMode = IPv6AddressConfigMode(value)
+	case IPv6SetupAttributeUnicastAddresses:
+		s.UnicastAddresses, err = r.ipv6Addresses()
+	case IPv6SetupAttributeMulticastAddresses:
+		s.MulticastAddresses, err = r.ipv6Addresses()
+	case IPv6SetupAttributeGatewayAddresses:
+		s.GatewayAddresses, err = r.ipv6Addresses()
+	case IPv6SetupAttributePrimaryDNS:
+		s.PrimaryDNS, err = r.ipv6Address()
+	case IPv6SetupAttributeSecondaryDNS:
+		s.SecondaryDNS, err = r.ipv6Address()
+	case IPv6SetupAttributeTrafficClass:
+		value, err = r.unsigned(dlmsdata.TagUnsigned, 1)
+		s.TrafficClass = uint8(value)
+	case IPv6SetupAttributeNeighborDiscoverySetup:
+		s.NeighborDiscoverySetup, err = r.neighborDiscoverySetup()
+	default:
+		return fmt.Errorf("IPv6 setup has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("IPv6 setup attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of an attribute of the IPv6Setup
+func (s *IPv6Setup) ToAttribute(attribute uint8) ([]byte, error) {
+	var result []byte
+	var err error
+
+	switch attribute {
+	case IPv6SetupAttributeDLReference:
+		if s.DLReference == nil {
+			return nil, fmt.Errorf("IPv6 setup has no DL reference")
+		}
+		result = encodeOctetString(s.DLReference.ToBytes())
+	case IPv6SetupAttributeAddressConfigMode:
+		result = encodeUnsigned(dlmsdata.TagEnum, 1, uint64(s.AddressConfigMode))
+	case IPv6SetupAttributeUnicastAddresses:
+		result, err = encodeIPv6Addresses(s.UnicastAddresses)
+	case IPv6SetupAttributeMulticastAddresses:
+		result, err = encodeIPv6Addresses(s.MulticastAddresses)
+	case IPv6SetupAttributeGatewayAddresses:
+		result, err = encodeIPv6Addresses(s.GatewayAddresses)
+	case IPv6SetupAttributePrimaryDNS:
+		result, err = encodeIPv6Address(s.PrimaryDNS)
+	case IPv6SetupAttributeSecondaryDNS:
+		result, err = encodeIPv6Address(s.SecondaryDNS)
+	case IPv6SetupAttributeTrafficClass:
+		result = encodeUnsigned(dlmsdata.TagUnsigned, 1, uint64(s.TrafficClass))
+	case IPv6SetupAttributeNeighborDiscoverySetup:
+		result = encodeHeader(dlmsdata.TagArray, len(s.NeighborDiscoverySetup))
+		for _, setup := range s.NeighborDiscoverySetup {
+			result = append(result, encodeHeader(dlmsdata.TagStructure, 3)...)
+			result = append(result, encodeUnsigned(dlmsdata.TagUnsigned, 1, uint64(setup.MaxRetry))...)
+			result = append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(setup.RetryWaitTime))...)
+			result = append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(setup.SendPeriod))...)
+		}
+	default:
+		return nil, fmt.Errorf("IPv6 setup has no attribute %d", attribute)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("IPv6 setup attribute %d: %w", attribute, err)
+	}
+	return result, nil
+}
+
+// IPv6AddressToBytes encodes the parameter of the add_IPv6_address and
+// remove_IPv6_address methods
+func IPv6AddressToBytes(addressType IPv6AddressType, address net.IP) ([]byte, error) {
+	encoded, err := encodeIPv6Address(address)
+	if err != nil {
+		return nil, err
+	}
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeUnsigned(dlmsdata.TagEnum, 1, uint64(addressType))...)
+	return append(result, encoded...), nil
+}
+
+// encodeIPv6Address encodes an IPv6 address as octet-string, a nil address is
+// sent as an empty octet-string
+func encodeIPv6Address(address net.IP) ([]byte, error) {
+	if address == nil {
+		return encodeOctetString(nil), nil
+	}
+	if address.To4() != nil || len(address) != net.IPv6len {
+		return nil, fmt.Errorf("%s is not an IPv6 address", address)
+	}
+	return encodeOctetString(address), nil
+}
+
+func encodeIPv6Addresses(addresses []net.IP) ([]byte, error) {
+	result := encodeHeader(dlmsdata.TagArray, len(addresses))
+	for _, address := range addresses {
+		encoded, err := encodeIPv6Address(address)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, encoded...)
+	}
+	return result, nil
+}
+
+func (r *dataReader) ipv6Address() (net.IP, error) {
+	value, err := r.octetString()
+	if err != nil {
+		return nil, err
+	}
+	switch len(value) {
+	case 0:
+		return nil, nil
+	case net.IPv6len:
+		return append(net.IP{}, value...), nil
+	default:
+		return nil, fmt.Errorf("IPv6 address is %d bytes, got %d", net.IPv6len, len(value))
+	}
+}
+
+func (r *dataReader) ipv6Addresses() ([]net.IP, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]net.IP, 0, count)
+	for i := 0; i < count; i++ {
+		address, err := r.ipv6Address()
+		if err != nil {
+			return nil, fmt.Errorf("address %d: %w", i, err)
+		}
+		result = append(result, address)
+	}
+	return result, nil
+}
+
+func (r *dataReader) neighborDiscoverySetup() ([]*NeighborDiscoverySetup, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*NeighborDiscoverySetup, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(3); err != nil {
+			return nil, fmt.Errorf("neighbor discovery setup %d: %w", i, err)
+		}
+		maxRetry, err := r.unsigned(dlmsdata.TagUnsigned, 1)
+		if err != nil {
+			return nil, fmt.Errorf("neighbor discovery setup %d: max_retry: %w", i, err)
+		}
+		retryWaitTime, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		if err != nil {
+			return nil, fmt.Errorf("neighbor discovery setup %d: retry_wait_time: %w", i, err)
+		}
+		sendPeriod, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		if err != nil {
+			return nil, fmt.Errorf("neighbor discovery setup %d: send_period: %w", i, err)
+		}
+		result = append(result, &NeighborDiscoverySetup{
+			MaxRetry:      uint8(maxRetry),
+			RetryWaitTime: uint16(retryWaitTime),
+			SendPeriod:    uint16(sendPeriod),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the LTE monitoring interface class (151)
+const (
+	LTEMonitoringAttributeNetworkParameters uint8 = 2
+	LTEMonitoringAttributeQualityOfService  uint8 = 3
+)
+
+// LTENetworkParameters is the LTE_network_parameters attribute: the timers
+// and the minimum receive levels set by the network
+type LTENetworkParameters struct {
+	T3402        uint16
+	T3412        uint16
+	T3412Ext2    uint32
+	T3324        uint16
+	TeDRX        uint32
+	TPTW         uint16
+	QRxLevMin    int8
+	QRxLevMinCE  int8
+	QRxLevMinCE1 int8
+}
+
+// LTECoverageEnhancement is the coverage enhancement level of LTE-M and NB-IoT
+type LTECoverageEnhancement uint8
+
+const (
+	LTECoverageEnhancementLevel0 LTECoverageEnhancement = 0
+	LTECoverageEnhancementLevel1 LTECoverageEnhancement = 1
+	LTECoverageEnhancementLevel2 LTECoverageEnhancement = 2
+	LTECoverageEnhancementLevel3 LTECoverageEnhancement = 3
+)
+
+// LTEQualityOfService is the LTE_quality_of_service attribute
+type LTEQualityOfService struct {
+	// SignalQuality is the RSRQ
+	SignalQuality int8
+	// SignalLevel is the RSRP
+	SignalLevel         int8
+	SignalToNoiseRatio  int8
+	CoverageEnhancement LTECoverageEnhancement
+}
+
+// LTEMonitoring is a LTE monitoring object (class 151). It shows the network
+// parameters and the quality of the LTE connection.
+type LTEMonitoring struct {
+	LogicalName       *Obis
+	NetworkParameters *LTENetworkParameters
+	QualityOfService  *LTEQualityOfService
+}
+
+// NewLTEMonitoring creates a new LTEMonitoring, the attributes are filled
+// in with FromAttribute
+func NewLTEMonitoring(logicalName *Obis) *LTEMonitoring {
+	return &LTEMonitoring{
+		LogicalName: logicalName,
+	}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the LTE monitoring
+func (l *LTEMonitoring) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceLTEMonitoring, l.LogicalName, attribute)
+}
+
+// FromAttribute decodes the value of an attribute into the LTEMonitoring
+func (l *LTEMonitoring) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+
+	switch attribute {
+	case LTEMonitoringAttributeNetworkParameters:
+		l.NetworkParameters, err = r.lteNetworkParameters()
+	case LTEMonitoringAttributeQualityOfService:
+		l.QualityOfService, err = r.lteQualityOfService()
+	default:
+		return fmt.Errorf("LTE monitoring has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("LTE monitoring attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+func (r *dataReader) lteNetworkParameters() (*LTENetworkParameters, error) {
+	if _, err := r.structure(9); err != nil {
+		return nil, err
+	}
+
+	timers := make([]uint64, 0, 6)
+	for _, timer := range []struct {
+		name string
+		tag  dlmsdata.DlmsDataTag
+		size int
+	}{
+		{"T3402", dlmsdata.TagLongUnsigned, 2},
+		{"T3412", dlmsdata.TagLongUnsigned, 2},
+		{"T3412ext2", dlmsdata.TagDoubleLongUnsigned, 4},
+		{"T3324", dlmsdata.TagLongUnsigned, 2},
+		{"TeDRX", dlmsdata.TagDoubleLongUnsigned, 4},
+		{"TPTW", dlmsdata.TagLongUnsigned, 2},
+	} {
+		value, err := r.unsigned(timer.tag, timer.size)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", timer.name, err)
+		}
+		timers = append(timers, value)
+	}
+
+	levels := make([]int8, 0, 3)
+	for _, name := range []string{"qRxlevMin", "qRxlevMinCE-r13", "qRxlevMinCE1-r13"} {
+		value, err := r.signed(dlmsdata.TagInteger)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		levels = append(levels, value)
+	}
+
+	return &LTENetworkParameters{
+		T3402:        uint16(timers[0]),
+		T3412:        uint16(timers[1]),
+		T3412Ext2:    uint32(timers[2]),
+		T3324:        uint16(timers[3]),
+		TeDRX:        uint32(timers[4]),
+		TPTW:         uint16(timers[5]),
+		QRxLevMin:    levels[0],
+		QRxLevMinCE:  levels[1],
+		QRxLevMinCE1: levels[2],
+	}, nil
+}
+
+func (r *dataReader) lteQualityOfService() (*LTEQualityOfService, error) {
+	if _, err := r.structure(4); err != nil {
+		return nil, err
+	}
+
+	values := make([]int8, 0, 3)
+	for _, name := range []string{"signal_quality", "signal_level", "signal_to_noise_ratio"} {
+		value, err := r.signed(dlmsdata.TagInteger)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		values = append(values, value)
+	}
+	coverageEnhancement, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err != nil {
+		return nil, fmt.Errorf("coverage_enhancement: %w", err)
+	}
+
+	return &LTEQualityOfService{
+		SignalQuality:       values[0],
+		SignalLevel:         values[1],
+		SignalToNoiseRatio:  values[2],
+		CoverageEnhancement: LTECoverageEnhancement(coverageEnhancement),
+	}, nil
+}
@@ -0,0 +1,173 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the NTP setup interface class (100)
+const (
+	NTPSetupAttributeActivated            uint8 = 2
+	NTPSetupAttributeServerAddress        uint8 = 3
+	NTPSetupAttributeServerPort           uint8 = 4
+	NTPSetupAttributeAuthenticationMethod uint8 = 5
+	NTPSetupAttributeAuthenticationKeys   uint8 = 6
+	NTPSetupAttributeClientKey            uint8 = 7
+)
+
+// Methods of the NTP setup interface class (100)
+const (
+	NTPSetupMethodSynchronize             uint8 = 1
+	NTPSetupMethodAddAuthenticationKey    uint8 = 2
+	NTPSetupMethodDeleteAuthenticationKey uint8 = 3
+)
+
+// DefaultNTPPort is the UDP port of NTP servers
+const DefaultNTPPort = 123
+
+// NTPAuthenticationMethod is how the NTP messages are authenticated
+type NTPAuthenticationMethod uint8
+
+const (
+	NTPAuthenticationMethodNone          NTPAuthenticationMethod = 0
+	NTPAuthenticationMethodSharedSecrets NTPAuthenticationMethod = 1
+	NTPAuthenticationMethodAutoKeyIFF    NTPAuthenticationMethod = 2
+)
+
+// NTPAuthenticationKey is a symmetric key of the authentication_keys attribute
+type NTPAuthenticationKey struct {
+	ID  uint32
+	Key []byte
+}
+
+// ToBytes converts NTPAuthenticationKey to a structure of key id and key, it
+// is the parameter of the add_authentication_key method
+func (k *NTPAuthenticationKey) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, uint64(k.ID))...)
+	return append(result, encodeOctetString(k.Key)...)
+}
+
+// NTPSetup is a NTP setup object (class 100). It configures the NTP server
+// the meter synchronizes its clock with.
+type NTPSetup struct {
+	LogicalName *Obis
+	Activated   bool
+	// ServerAddress is the host name or the IP address of the server
+	ServerAddress        string
+	ServerPort           uint16
+	AuthenticationMethod NTPAuthenticationMethod
+	AuthenticationKeys   []*NTPAuthenticationKey
+	ClientKey            []byte
+}
+
+// NewNTPSetup creates a new NTPSetup, the attributes are filled in with
+// FromAttribute or set directly before writing them
+func NewNTPSetup(logicalName *Obis) *NTPSetup {
+	return &NTPSetup{
+		LogicalName: logicalName,
+		ServerPort:  DefaultNTPPort,
+	}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the NTP setup
+func (s *NTPSetup) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceNTPSetup, s.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the NTP setup
+func (s *NTPSetup) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceNTPSetup, s.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the NTPSetup
+func (s *NTPSetup) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+	var octets []byte
+
+	switch attribute {
+	case NTPSetupAttributeActivated:
+		value, err = r.unsigned(dlmsdata.TagBoolean, 1)
+		s.Activated = value != 0
+	case NTPSetupAttributeServerAddress:
+		octets, err = r.octetString()
+		s.ServerAddress = string(octets)
+	case NTPSetupAttributeServerPort:
+		value, err = r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		s.ServerPort = uint16(value)
+	case NTPSetupAttributeAuthenticationMethod:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		s.AuthenticationMethod = NTPAuthenticationMethod(value)
+	case NTPSetupAttributeAuthenticationKeys:
+		s.AuthenticationKeys, err = r.ntpAuthenticationKeys()
+	case NTPSetupAttributeClientKey:
+		octets, err = r.octetString()
+		s.ClientKey = append([]byte{}, octets...)
+	default:
+		return fmt.Errorf("NTP setup has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("NTP setup attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of an attribute of the NTPSetup. The
+// authentication keys can only be changed with the methods.
+func (s *NTPSetup) ToAttribute(attribute uint8) ([]byte, error) {
+	switch attribute {
+	case NTPSetupAttributeActivated:
+		return encodeBoolean(s.Activated), nil
+	case NTPSetupAttributeServerAddress:
+		return encodeOctetString([]byte(s.ServerAddress)), nil
+	case NTPSetupAttributeServerPort:
+		return encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(s.ServerPort)), nil
+	case NTPSetupAttributeAuthenticationMethod:
+		return encodeUnsigned(dlmsdata.TagEnum, 1, uint64(s.AuthenticationMethod)), nil
+	case NTPSetupAttributeClientKey:
+		return encodeOctetString(s.ClientKey), nil
+	default:
+		return nil, fmt.Errorf("NTP setup attribute %d can't be written", attribute)
+	}
+}
+
+// NTPSynchronizeToBytes encodes the parameter of the synchronize method
+func NTPSynchronizeToBytes() []byte {
+	return []byte{byte(dlmsdata.TagInteger), 0x00}
+}
+
+// NTPKeyIDToBytes encodes the parameter of the delete_authentication_key method
+func NTPKeyIDToBytes(id uint32) []byte {
+	return encodeUnsigned(dlmsdata.TagDoubleLongUnsigned, 4, uint64(id))
+}
+
+func (r *dataReader) ntpAuthenticationKeys() ([]*NTPAuthenticationKey, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*NTPAuthenticationKey, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, fmt.Errorf("authentication key %d: %w", i, err)
+		}
+		id, err := r.unsigned(dlmsdata.TagDoubleLongUnsigned, 4)
+		if err != nil {
+			return nil, fmt.Errorf("authentication key %d: key_id: %w", i, err)
+		}
+		key, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("authentication key %d: key: %w", i, err)
+		}
+		result = append(result, &NTPAuthenticationKey{ID: uint32(id), Key: append([]byte{}, key...)})
+	}
+	return result, nil
+}
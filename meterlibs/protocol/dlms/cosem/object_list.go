@@ -0,0 +1,225 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// ParseObjectList parses the object_list attribute (2) of an Association LN
+// object of the given version. Access modes of association versions before 3
+// are converted to the access rights bits used from version 3 on.
+//
+//	object_list ::= array object_list_element
+//	object_list_element ::= structure {
+//	    class_id long-unsigned, version unsigned, logical_name octet-string,
+//	    access_rights structure { attribute_access array, method_access array }
+//	}
+func ParseObjectList(data []byte, associationVersion uint8) ([]*AssociationObjectListItem, error) {
+	r := &objectListReader{dataReader: dataReader{data: data}, version: associationVersion}
+
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, fmt.Errorf("object list: %w", err)
+	}
+
+	result := make([]*AssociationObjectListItem, 0, count)
+	for i := 0; i < count; i++ {
+		item, err := r.element()
+		if err != nil {
+			return nil, fmt.Errorf("object list element %d: %w", i, err)
+		}
+		result = append(result, item)
+	}
+
+	if len(r.data) > 0 {
+		return nil, fmt.Errorf("object list: %d bytes left after the last element", len(r.data))
+	}
+	return result, nil
+}
+
+// objectListReader reads the A-XDR encoded object list
+type objectListReader struct {
+	dataReader
+	// version of the Association LN object, it gives the access mode encoding
+	version uint8
+}
+
+func (r *objectListReader) element() (*AssociationObjectListItem, error) {
+	if _, err := r.structure(4); err != nil {
+		return nil, err
+	}
+
+	classID, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+	if err != nil {
+		return nil, fmt.Errorf("class_id: %w", err)
+	}
+	version, err := r.unsigned(dlmsdata.TagUnsigned, 1)
+	if err != nil {
+		return nil, fmt.Errorf("version: %w", err)
+	}
+	logicalNameBytes, err := r.octetString()
+	if err != nil {
+		return nil, fmt.Errorf("logical_name: %w", err)
+	}
+	logicalName, err := FromBytes(logicalNameBytes)
+	if err != nil {
+		return nil, fmt.Errorf("logical_name: %w", err)
+	}
+
+	if _, err = r.structure(2); err != nil {
+		return nil, fmt.Errorf("access_rights: %w", err)
+	}
+	attributeAccess, err := r.attributeAccess()
+	if err != nil {
+		return nil, fmt.Errorf("attribute_access of %s: %w", logicalName, err)
+	}
+	methodAccess, err := r.methodAccess()
+	if err != nil {
+		return nil, fmt.Errorf("method_access of %s: %w", logicalName, err)
+	}
+
+	return NewAssociationObjectListItem(
+		enumerations.CosemInterface(classID),
+		logicalName,
+		uint8(version),
+		attributeAccess,
+		methodAccess,
+	), nil
+}
+
+func (r *objectListReader) attributeAccess() (map[uint8]*AttributeAccessRights, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint8]*AttributeAccessRights, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(3); err != nil {
+			return nil, err
+		}
+		attribute, err := r.signed(dlmsdata.TagInteger)
+		if err != nil {
+			return nil, fmt.Errorf("attribute_id: %w", err)
+		}
+		mode, err := r.unsigned(dlmsdata.TagEnum, 1)
+		if err != nil {
+			return nil, fmt.Errorf("access_mode of attribute %d: %w", attribute, err)
+		}
+		selectors, err := r.accessSelectors()
+		if err != nil {
+			return nil, fmt.Errorf("access_selectors of attribute %d: %w", attribute, err)
+		}
+
+		result[uint8(attribute)] = NewAttributeAccessRights(uint8(attribute), attributeAccessRights(r.version, uint8(mode)), selectors)
+	}
+	return result, nil
+}
+
+func (r *objectListReader) methodAccess() (map[uint8]*MethodAccessRights, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint8]*MethodAccessRights, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, err
+		}
+		method, err := r.signed(dlmsdata.TagInteger)
+		if err != nil {
+			return nil, fmt.Errorf("method_id: %w", err)
+		}
+
+		// version 0 uses a boolean for the method access
+		var mode uint64
+		if len(r.data) > 0 && dlmsdata.DlmsDataTag(r.data[0]) == dlmsdata.TagBoolean {
+			mode, err = r.unsigned(dlmsdata.TagBoolean, 1)
+		} else {
+			mode, err = r.unsigned(dlmsdata.TagEnum, 1)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("access_mode of method %d: %w", method, err)
+		}
+
+		result[uint8(method)] = NewMethodAccessRights(uint8(method), methodAccessRights(r.version, uint8(mode)))
+	}
+	return result, nil
+}
+
+// accessSelectors reads the optional array of access selectors, sent as null-data when absent
+func (r *objectListReader) accessSelectors() ([]uint8, error) {
+	if len(r.data) > 0 && dlmsdata.DlmsDataTag(r.data[0]) == dlmsdata.TagNull {
+		r.data = r.data[1:]
+		return nil, nil
+	}
+
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	selectors := make([]uint8, 0, count)
+	for i := 0; i < count; i++ {
+		selector, err := r.signed(dlmsdata.TagInteger)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, uint8(selector))
+	}
+	return selectors, nil
+}
+
+// accessRightsFromBits returns the access rights of the bits set in mode
+func accessRightsFromBits(mode uint8) []AccessRight {
+	rights := make([]AccessRight, 0)
+	for bit := AccessRightReadAccess; bit <= AccessRightDigitallySignedResponse; bit++ {
+		if mode&(1<<bit) != 0 {
+			rights = append(rights, bit)
+		}
+	}
+	return rights
+}
+
+// attributeAccessRights converts an attribute access mode. Before version 3
+// the mode is an enum: no_access, read_only, write_only, read_and_write and
+// the same three with authentication.
+func attributeAccessRights(version uint8, mode uint8) []AccessRight {
+	if version >= 3 {
+		return accessRightsFromBits(mode)
+	}
+	switch mode {
+	case 1:
+		return []AccessRight{AccessRightReadAccess}
+	case 2:
+		return []AccessRight{AccessRightWriteAccess}
+	case 3:
+		return []AccessRight{AccessRightReadAccess, AccessRightWriteAccess}
+	case 4:
+		return []AccessRight{AccessRightReadAccess, AccessRightAuthenticatedRequest}
+	case 5:
+		return []AccessRight{AccessRightWriteAccess, AccessRightAuthenticatedRequest}
+	case 6:
+		return []AccessRight{AccessRightReadAccess, AccessRightWriteAccess, AccessRightAuthenticatedRequest}
+	default:
+		return []AccessRight{}
+	}
+}
+
+// methodAccessRights converts a method access mode. Before version 3 the mode
+// is no_access, access or authenticated_access, access is given as the read bit.
+func methodAccessRights(version uint8, mode uint8) []AccessRight {
+	if version >= 3 {
+		return accessRightsFromBits(mode)
+	}
+	switch mode {
+	case 1:
+		return []AccessRight{AccessRightReadAccess}
+	case 2:
+		return []AccessRight{AccessRightReadAccess, AccessRightAuthenticatedRequest}
+	default:
+		return []AccessRight{}
+	}
+}
@@ -0,0 +1,150 @@
+package cosem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Parameter monitor interface class (65)
+const (
+	ParameterMonitorAttributeChangedParameter uint8 = 2
+	ParameterMonitorAttributeCaptureTime      uint8 = 3
+	ParameterMonitorAttributeParameterList    uint8 = 4
+)
+
+// Methods of the Parameter monitor interface class (65), they take a
+// ValueDefinition
+const (
+	ParameterMonitorMethodAddParameter    uint8 = 1
+	ParameterMonitorMethodDeleteParameter uint8 = 2
+)
+
+// ChangedParameter is the last change of a monitored parameter: the
+// parameter and its new value, still A-XDR encoded
+type ChangedParameter struct {
+	Parameter *ValueDefinition
+	Value     []byte
+}
+
+// String implements fmt.Stringer
+func (c *ChangedParameter) String() string {
+	return fmt.Sprintf("%s = %X", c.Parameter, c.Value)
+}
+
+// ParameterMonitor is a Parameter monitor object (class 65). It watches a
+// list of parameters and captures the last one changed, the change being
+// usually logged in a profile and signalled by an event.
+type ParameterMonitor struct {
+	LogicalName      *Obis
+	ChangedParameter *ChangedParameter
+	CaptureTime      time.Time
+	ParameterList    []*ValueDefinition
+}
+
+// NewParameterMonitor creates a new ParameterMonitor, the attributes are
+// filled in with FromAttribute
+func NewParameterMonitor(logicalName *Obis) *ParameterMonitor {
+	return &ParameterMonitor{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the parameter monitor
+func (m *ParameterMonitor) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceParameterMonitor, m.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the parameter monitor
+func (m *ParameterMonitor) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceParameterMonitor, m.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the ParameterMonitor
+func (m *ParameterMonitor) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+
+	switch attribute {
+	case ParameterMonitorAttributeChangedParameter:
+		m.ChangedParameter, err = r.changedParameter()
+	case ParameterMonitorAttributeCaptureTime:
+		m.CaptureTime, err = r.dateTime()
+	case ParameterMonitorAttributeParameterList:
+		m.ParameterList, err = r.valueDefinitions()
+	default:
+		return fmt.Errorf("parameter monitor has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("parameter monitor attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of an attribute of the ParameterMonitor,
+// only the parameter list can be written
+func (m *ParameterMonitor) ToAttribute(attribute uint8) ([]byte, error) {
+	switch attribute {
+	case ParameterMonitorAttributeParameterList:
+		result := encodeHeader(dlmsdata.TagArray, len(m.ParameterList))
+		for _, parameter := range m.ParameterList {
+			result = append(result, parameter.ToBytes()...)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("parameter monitor attribute %d can't be written", attribute)
+	}
+}
+
+// ParseChangedParameter decodes a changed_parameter value, e.g. captured in
+// a profile buffer or sent in a push notification. A parameter with class
+// id 0 means that nothing changed yet and is returned as nil.
+func ParseChangedParameter(data []byte) (*ChangedParameter, error) {
+	r := &dataReader{data: data}
+	result, err := r.changedParameter()
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("changed_parameter: %w", err)
+	}
+	return result, nil
+}
+
+func (r *dataReader) changedParameter() (*ChangedParameter, error) {
+	if _, err := r.structure(4); err != nil {
+		return nil, err
+	}
+	parameter, err := r.valueDefinitionFields()
+	if err != nil {
+		return nil, err
+	}
+	value, err := r.value()
+	if err != nil {
+		return nil, fmt.Errorf("data_value: %w", err)
+	}
+	if parameter.Interface == 0 {
+		return nil, nil
+	}
+	return &ChangedParameter{Parameter: parameter, Value: value}, nil
+}
+
+func (r *dataReader) valueDefinitions() ([]*ValueDefinition, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ValueDefinition, 0, count)
+	for i := 0; i < count; i++ {
+		definition, err := r.valueDefinition()
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i, err)
+		}
+		result = append(result, definition)
+	}
+	return result, nil
+}
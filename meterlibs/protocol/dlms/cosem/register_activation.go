@@ -0,0 +1,283 @@
+package cosem
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Register activation interface class (6)
+const (
+	RegisterActivationAttributeRegisterAssignment uint8 = 2
+	RegisterActivationAttributeMaskList           uint8 = 3
+	RegisterActivationAttributeActiveMask         uint8 = 4
+)
+
+// Methods of the Register activation interface class (6)
+const (
+	RegisterActivationMethodAddRegister uint8 = 1
+	RegisterActivationMethodAddMask     uint8 = 2
+	RegisterActivationMethodDeleteMask  uint8 = 3
+)
+
+// ObjectDefinition is a register of the register_assignment attribute
+type ObjectDefinition struct {
+	Interface   enumerations.CosemInterface
+	LogicalName *Obis
+}
+
+// NewObjectDefinition creates a new ObjectDefinition
+func NewObjectDefinition(interfaceClass enumerations.CosemInterface, logicalName *Obis) *ObjectDefinition {
+	return &ObjectDefinition{
+		Interface:   interfaceClass,
+		LogicalName: logicalName,
+	}
+}
+
+// ToBytes converts ObjectDefinition to a structure of class id and logical name,
+// it is the parameter of the add_register method
+func (o *ObjectDefinition) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(o.Interface))...)
+	return append(result, encodeOctetString(o.LogicalName.ToBytes())...)
+}
+
+// String implements fmt.Stringer
+func (o *ObjectDefinition) String() string {
+	return fmt.Sprintf("%d/%s", o.Interface, o.LogicalName)
+}
+
+func (r *dataReader) objectDefinition() (*ObjectDefinition, error) {
+	if _, err := r.structure(2); err != nil {
+		return nil, err
+	}
+	classID, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+	if err != nil {
+		return nil, fmt.Errorf("class_id: %w", err)
+	}
+	logicalName, err := r.obis()
+	if err != nil {
+		return nil, fmt.Errorf("logical_name: %w", err)
+	}
+	return NewObjectDefinition(enumerations.CosemInterface(classID), logicalName), nil
+}
+
+// RegisterActivationMask is a mask of the mask_list attribute. The indexes
+// select the active registers, starting at 1 for the first register of the
+// register_assignment.
+type RegisterActivationMask struct {
+	Name    []byte
+	Indexes []uint8
+}
+
+// NewRegisterActivationMask creates a new RegisterActivationMask
+func NewRegisterActivationMask(name []byte, indexes []uint8) *RegisterActivationMask {
+	return &RegisterActivationMask{
+		Name:    name,
+		Indexes: indexes,
+	}
+}
+
+// ToBytes converts RegisterActivationMask to a structure of mask name and
+// index list, it is the parameter of the add_mask method
+func (m *RegisterActivationMask) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeOctetString(m.Name)...)
+	result = append(result, encodeHeader(dlmsdata.TagArray, len(m.Indexes))...)
+	for _, index := range m.Indexes {
+		result = append(result, encodeUnsigned(dlmsdata.TagUnsigned, 1, uint64(index))...)
+	}
+	return result
+}
+
+func (r *dataReader) registerActivationMask() (*RegisterActivationMask, error) {
+	if _, err := r.structure(2); err != nil {
+		return nil, err
+	}
+	name, err := r.octetString()
+	if err != nil {
+		return nil, fmt.Errorf("mask_name: %w", err)
+	}
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, fmt.Errorf("index_list: %w", err)
+	}
+	indexes := make([]uint8, 0, count)
+	for i := 0; i < count; i++ {
+		index, err := r.unsigned(dlmsdata.TagUnsigned, 1)
+		if err != nil {
+			return nil, fmt.Errorf("index_list: %w", err)
+		}
+		indexes = append(indexes, uint8(index))
+	}
+	return NewRegisterActivationMask(append([]byte{}, name...), indexes), nil
+}
+
+// RegisterActivation is a Register activation object (class 6). It selects
+// the registers of the tariffs active with each mask.
+type RegisterActivation struct {
+	LogicalName        *Obis
+	RegisterAssignment []*ObjectDefinition
+	MaskList           []*RegisterActivationMask
+	ActiveMask         []byte
+}
+
+// NewRegisterActivation creates a new RegisterActivation
+func NewRegisterActivation(logicalName *Obis, registerAssignment []*ObjectDefinition, maskList []*RegisterActivationMask, activeMask []byte) *RegisterActivation {
+	return &RegisterActivation{
+		LogicalName:        logicalName,
+		RegisterAssignment: registerAssignment,
+		MaskList:           maskList,
+		ActiveMask:         activeMask,
+	}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the register activation
+func (a *RegisterActivation) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceRegisterActivation, a.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the register activation
+func (a *RegisterActivation) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceRegisterActivation, a.LogicalName, method)
+}
+
+// Mask returns the mask with the given name, or nil
+func (a *RegisterActivation) Mask(name []byte) *RegisterActivationMask {
+	for _, mask := range a.MaskList {
+		if bytes.Equal(mask.Name, name) {
+			return mask
+		}
+	}
+	return nil
+}
+
+// ActiveRegisters returns the registers selected by the active mask
+func (a *RegisterActivation) ActiveRegisters() ([]*ObjectDefinition, error) {
+	mask := a.Mask(a.ActiveMask)
+	if mask == nil {
+		return nil, fmt.Errorf("active mask %x is not in the mask list", a.ActiveMask)
+	}
+	return a.Registers(mask)
+}
+
+// Registers returns the registers selected by a mask
+func (a *RegisterActivation) Registers(mask *RegisterActivationMask) ([]*ObjectDefinition, error) {
+	result := make([]*ObjectDefinition, 0, len(mask.Indexes))
+	for _, index := range mask.Indexes {
+		if index < 1 || int(index) > len(a.RegisterAssignment) {
+			return nil, fmt.Errorf("mask %x selects register %d, but %d registers are assigned", mask.Name, index, len(a.RegisterAssignment))
+		}
+		result = append(result, a.RegisterAssignment[index-1])
+	}
+	return result, nil
+}
+
+// Validate checks that the masks only select assigned registers and that
+// the mask names are unique
+func (a *RegisterActivation) Validate() error {
+	for i, mask := range a.MaskList {
+		if _, err := a.Registers(mask); err != nil {
+			return err
+		}
+		for _, other := range a.MaskList[:i] {
+			if bytes.Equal(mask.Name, other.Name) {
+				return fmt.Errorf("mask %x is defined twice", mask.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// RegisterAssignmentToBytes converts the registers to the register_assignment attribute
+func (a *RegisterActivation) RegisterAssignmentToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagArray, len(a.RegisterAssignment))
+	for _, register := range a.RegisterAssignment {
+		result = append(result, register.ToBytes()...)
+	}
+	return result
+}
+
+// MaskListToBytes converts the masks to the mask_list attribute
+func (a *RegisterActivation) MaskListToBytes() ([]byte, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	result := encodeHeader(dlmsdata.TagArray, len(a.MaskList))
+	for _, mask := range a.MaskList {
+		result = append(result, mask.ToBytes()...)
+	}
+	return result, nil
+}
+
+// ActiveMaskToBytes converts the name of the active mask to the active_mask attribute
+func (a *RegisterActivation) ActiveMaskToBytes() []byte {
+	return MaskNameToBytes(a.ActiveMask)
+}
+
+// MaskNameToBytes converts a mask name to an octet-string, as used by the
+// active_mask attribute and the delete_mask method
+func MaskNameToBytes(name []byte) []byte {
+	return encodeOctetString(name)
+}
+
+// ParseRegisterAssignment parses the register_assignment attribute
+func ParseRegisterAssignment(data []byte) ([]*ObjectDefinition, error) {
+	r := &dataReader{data: data}
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, fmt.Errorf("register_assignment: %w", err)
+	}
+
+	result := make([]*ObjectDefinition, 0, count)
+	for i := 0; i < count; i++ {
+		register, err := r.objectDefinition()
+		if err != nil {
+			return nil, fmt.Errorf("register_assignment element %d: %w", i, err)
+		}
+		result = append(result, register)
+	}
+
+	if err = r.end(); err != nil {
+		return nil, fmt.Errorf("register_assignment: %w", err)
+	}
+	return result, nil
+}
+
+// ParseMaskList parses the mask_list attribute
+func ParseMaskList(data []byte) ([]*RegisterActivationMask, error) {
+	r := &dataReader{data: data}
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, fmt.Errorf("mask_list: %w", err)
+	}
+
+	result := make([]*RegisterActivationMask, 0, count)
+	for i := 0; i < count; i++ {
+		mask, err := r.registerActivationMask()
+		if err != nil {
+			return nil, fmt.Errorf("mask_list element %d: %w", i, err)
+		}
+		result = append(result, mask)
+	}
+
+	if err = r.end(); err != nil {
+		return nil, fmt.Errorf("mask_list: %w", err)
+	}
+	return result, nil
+}
+
+// ParseActiveMask parses the active_mask attribute
+func ParseActiveMask(data []byte) ([]byte, error) {
+	r := &dataReader{data: data}
+	name, err := r.octetString()
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("active_mask: %w", err)
+	}
+	return append([]byte{}, name...), nil
+}
@@ -0,0 +1,214 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Register monitor interface class (21)
+const (
+	RegisterMonitorAttributeThresholds     uint8 = 2
+	RegisterMonitorAttributeMonitoredValue uint8 = 3
+	RegisterMonitorAttributeActions        uint8 = 4
+)
+
+// ValueDefinition is an attribute of an object, the monitored_value of a
+// Register monitor or an element of the parameter_list of a Parameter monitor
+type ValueDefinition struct {
+	Interface      enumerations.CosemInterface
+	LogicalName    *Obis
+	AttributeIndex int8
+}
+
+// NewValueDefinition creates a new ValueDefinition
+func NewValueDefinition(interfaceClass enumerations.CosemInterface, logicalName *Obis, attributeIndex int8) *ValueDefinition {
+	return &ValueDefinition{
+		Interface:      interfaceClass,
+		LogicalName:    logicalName,
+		AttributeIndex: attributeIndex,
+	}
+}
+
+// ToBytes converts ValueDefinition to a structure of class id, logical name
+// and attribute index
+func (v *ValueDefinition) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 3)
+	result = append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(v.Interface))...)
+	result = append(result, encodeOctetString(v.LogicalName.ToBytes())...)
+	return append(result, encodeUnsigned(dlmsdata.TagInteger, 1, uint64(uint8(v.AttributeIndex)))...)
+}
+
+// String implements fmt.Stringer
+func (v *ValueDefinition) String() string {
+	return fmt.Sprintf("%d/%s/%d", v.Interface, v.LogicalName, v.AttributeIndex)
+}
+
+// ActionSet is an element of the actions attribute, the scripts executed when
+// the monitored value crosses the threshold with the same index upwards or
+// downwards
+type ActionSet struct {
+	Up   *ScriptReference
+	Down *ScriptReference
+}
+
+// ToBytes converts ActionSet to a structure of action up and action down
+func (a *ActionSet) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, a.Up.ToBytes()...)
+	return append(result, a.Down.ToBytes()...)
+}
+
+// RegisterMonitor is a Register monitor object (class 21). It compares an
+// attribute to thresholds and executes scripts when a threshold is crossed,
+// e.g. to raise an alarm on over voltage.
+type RegisterMonitor struct {
+	LogicalName *Obis
+	// Thresholds are A-XDR encoded values, of the type of the monitored value
+	Thresholds     [][]byte
+	MonitoredValue *ValueDefinition
+	Actions        []*ActionSet
+}
+
+// NewRegisterMonitor creates a new RegisterMonitor, the attributes are
+// filled in with FromAttribute or set directly before writing them
+func NewRegisterMonitor(logicalName *Obis) *RegisterMonitor {
+	return &RegisterMonitor{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the register monitor
+func (m *RegisterMonitor) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceRegisterMonitor, m.LogicalName, attribute)
+}
+
+// Validate checks that there is one action set per threshold
+func (m *RegisterMonitor) Validate() error {
+	if m.MonitoredValue == nil {
+		return fmt.Errorf("register monitor %s has no monitored value", m.LogicalName)
+	}
+	if len(m.Actions) != len(m.Thresholds) {
+		return fmt.Errorf("register monitor %s has %d thresholds and %d action sets", m.LogicalName, len(m.Thresholds), len(m.Actions))
+	}
+	for i, action := range m.Actions {
+		if action.Up == nil || action.Down == nil {
+			return fmt.Errorf("register monitor %s: action set %d has no script", m.LogicalName, i)
+		}
+	}
+	return nil
+}
+
+// FromAttribute decodes the value of an attribute into the RegisterMonitor
+func (m *RegisterMonitor) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+
+	switch attribute {
+	case RegisterMonitorAttributeThresholds:
+		m.Thresholds, err = r.values()
+	case RegisterMonitorAttributeMonitoredValue:
+		m.MonitoredValue, err = r.valueDefinition()
+	case RegisterMonitorAttributeActions:
+		m.Actions, err = r.actionSets()
+	default:
+		return fmt.Errorf("register monitor has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("register monitor attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// ToAttribute encodes the value of an attribute of the RegisterMonitor
+func (m *RegisterMonitor) ToAttribute(attribute uint8) ([]byte, error) {
+	switch attribute {
+	case RegisterMonitorAttributeThresholds:
+		result := encodeHeader(dlmsdata.TagArray, len(m.Thresholds))
+		for _, threshold := range m.Thresholds {
+			result = append(result, threshold...)
+		}
+		return result, nil
+	case RegisterMonitorAttributeMonitoredValue:
+		if m.MonitoredValue == nil {
+			return nil, fmt.Errorf("register monitor %s has no monitored value", m.LogicalName)
+		}
+		return m.MonitoredValue.ToBytes(), nil
+	case RegisterMonitorAttributeActions:
+		result := encodeHeader(dlmsdata.TagArray, len(m.Actions))
+		for _, action := range m.Actions {
+			result = append(result, action.ToBytes()...)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("register monitor has no attribute %d", attribute)
+	}
+}
+
+// values reads an array of values of any type
+func (r *dataReader) values() ([][]byte, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		value, err := r.value()
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+func (r *dataReader) valueDefinition() (*ValueDefinition, error) {
+	if _, err := r.structure(3); err != nil {
+		return nil, err
+	}
+	return r.valueDefinitionFields()
+}
+
+// valueDefinitionFields reads the class id, logical name and attribute index
+// of a structure whose header has been read
+func (r *dataReader) valueDefinitionFields() (*ValueDefinition, error) {
+	classID, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+	if err != nil {
+		return nil, fmt.Errorf("class_id: %w", err)
+	}
+	logicalName, err := r.obis()
+	if err != nil {
+		return nil, fmt.Errorf("logical_name: %w", err)
+	}
+	attributeIndex, err := r.signed(dlmsdata.TagInteger)
+	if err != nil {
+		return nil, fmt.Errorf("attribute_index: %w", err)
+	}
+	return NewValueDefinition(enumerations.CosemInterface(classID), logicalName, attributeIndex), nil
+}
+
+func (r *dataReader) actionSets() ([]*ActionSet, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ActionSet, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, fmt.Errorf("action set %d: %w", i, err)
+		}
+		up, err := r.scriptReference()
+		if err != nil {
+			return nil, fmt.Errorf("action set %d: action_up: %w", i, err)
+		}
+		down, err := r.scriptReference()
+		if err != nil {
+			return nil, fmt.Errorf("action set %d: action_down: %w", i, err)
+		}
+		result = append(result, &ActionSet{Up: up, Down: down})
+	}
+	return result, nil
+}
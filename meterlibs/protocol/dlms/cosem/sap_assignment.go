@@ -0,0 +1,120 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the SAP assignment interface class (17)
+const (
+	SAPAssignmentAttributeList uint8 = 2
+)
+
+// Methods of the SAP assignment interface class (17)
+const (
+	SAPAssignmentMethodConnectLogicalDevice uint8 = 1
+)
+
+// ManagementLogicalDeviceSAP is the address of the management logical
+// device, every server has it and it holds the SAP assignment object
+const ManagementLogicalDeviceSAP = 1
+
+// SAPAssignmentEntry is a logical device of the server and its address
+type SAPAssignmentEntry struct {
+	SAP uint16
+	// LogicalDeviceName is the name of the logical device, 16 octets
+	// starting with the manufacturer FLAG ID in IDIS meters
+	LogicalDeviceName []byte
+}
+
+// ToBytes converts SAPAssignmentEntry to a structure of SAP and logical
+// device name, it is the parameter of the connect_logical_device method
+func (e *SAPAssignmentEntry) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(e.SAP))...)
+	return append(result, encodeOctetString(e.LogicalDeviceName)...)
+}
+
+// String implements fmt.Stringer
+func (e *SAPAssignmentEntry) String() string {
+	return fmt.Sprintf("%d %q", e.SAP, e.LogicalDeviceName)
+}
+
+// SAPAssignment is a SAP assignment object (class 17). It lists the logical
+// devices of the server with their addresses, the SAPs.
+type SAPAssignment struct {
+	LogicalName *Obis
+	List        []*SAPAssignmentEntry
+}
+
+// NewSAPAssignment creates a new SAPAssignment, the list is filled in with
+// FromAttribute
+func NewSAPAssignment(logicalName *Obis) *SAPAssignment {
+	return &SAPAssignment{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the SAP assignment
+func (s *SAPAssignment) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceSAPAssignment, s.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the SAP assignment
+func (s *SAPAssignment) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceSAPAssignment, s.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the SAPAssignment
+func (s *SAPAssignment) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+
+	switch attribute {
+	case SAPAssignmentAttributeList:
+		s.List, err = r.sapAssignmentList()
+	default:
+		return fmt.Errorf("SAP assignment has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("SAP assignment attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// SAP returns the address of a logical device by name
+func (s *SAPAssignment) SAP(logicalDeviceName string) (uint16, bool) {
+	for _, entry := range s.List {
+		if string(entry.LogicalDeviceName) == logicalDeviceName {
+			return entry.SAP, true
+		}
+	}
+	return 0, false
+}
+
+func (r *dataReader) sapAssignmentList() ([]*SAPAssignmentEntry, error) {
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SAPAssignmentEntry, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err = r.structure(2); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		sap, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: SAP: %w", i, err)
+		}
+		name, err := r.octetString()
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: logical_device_name: %w", i, err)
+		}
+		result = append(result, &SAPAssignmentEntry{SAP: uint16(sap), LogicalDeviceName: append([]byte{}, name...)})
+	}
+	return result, nil
+}
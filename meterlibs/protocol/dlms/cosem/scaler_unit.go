@@ -0,0 +1,96 @@
+package cosem
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+)
+
+// Attributes of the register interface class (3) and the extended register
+// (4) use the same numbers for the value and the scaler_unit. The demand
+// register (5) has its scaler_unit in DemandRegisterAttributeScalerUnit.
+const (
+	RegisterAttributeValue      uint8 = 2
+	RegisterAttributeScalerUnit uint8 = 3
+)
+
+// ScalerUnit is the scaler_unit attribute of a register, the value of the
+// register is multiplied by 10^Scaler to get a quantity in Unit
+type ScalerUnit struct {
+	Scaler int8
+	// Unit is the unit enum of the Blue Book, e.g. 27 W or 30 Wh
+	Unit dlmsdata.Enum
+}
+
+// ParseScalerUnit decodes a scaler_unit structure of an integer and an enum
+func ParseScalerUnit(data []byte) (*ScalerUnit, error) {
+	r := &dataReader{data: data}
+	scalerUnit, err := r.scalerUnit()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.end(); err != nil {
+		return nil, err
+	}
+	return scalerUnit, nil
+}
+
+func (r *dataReader) scalerUnit() (*ScalerUnit, error) {
+	if _, err := r.structure(2); err != nil {
+		return nil, fmt.Errorf("scaler_unit: %w", err)
+	}
+	scaler, err := r.signed(dlmsdata.TagInteger)
+	if err != nil {
+		return nil, fmt.Errorf("scaler: %w", err)
+	}
+	unit, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err != nil {
+		return nil, fmt.Errorf("unit: %w", err)
+	}
+	return &ScalerUnit{Scaler: scaler, Unit: dlmsdata.Enum(unit)}, nil
+}
+
+// ToBytes encodes the scaler_unit structure
+func (s *ScalerUnit) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeUnsigned(dlmsdata.TagInteger, 1, uint64(uint8(s.Scaler)))...)
+	return append(result, encodeUnsigned(dlmsdata.TagEnum, 1, uint64(s.Unit))...)
+}
+
+// Apply scales a decoded register value. The integer types and the float32
+// and float64 values of the meters reporting instantaneous values as floats
+// are supported.
+func (s *ScalerUnit) Apply(value interface{}) (float64, error) {
+	var number float64
+	switch v := value.(type) {
+	case int8:
+		number = float64(v)
+	case uint8:
+		number = float64(v)
+	case int16:
+		number = float64(v)
+	case uint16:
+		number = float64(v)
+	case int32:
+		number = float64(v)
+	case uint32:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	case uint64:
+		number = float64(v)
+	case float32:
+		number = float64(v)
+	case float64:
+		number = v
+	default:
+		return 0, fmt.Errorf("can't scale a value of type %T", value)
+	}
+	return number * math.Pow10(int(s.Scaler)), nil
+}
+
+// String implements fmt.Stringer
+func (s *ScalerUnit) String() string {
+	return fmt.Sprintf("10^%d unit %d", s.Scaler, s.Unit)
+}
@@ -0,0 +1,54 @@
+package cosem
+
+import (
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Methods of the security setup interface class (64), version 1
+const (
+	SecuritySetupMethodSecurityActivate uint8 = 1
+	SecuritySetupMethodKeyTransfer      uint8 = 2
+)
+
+// SecuritySetupKeyTransfer is a key of the key_transfer method, wrapped with
+// the master key (KEK)
+type SecuritySetupKeyTransfer struct {
+	// KeyID is the key_id enum, 0 unicast encryption key, 1 broadcast
+	// encryption key, 2 authentication key and 3 master key
+	KeyID      uint8
+	WrappedKey []byte
+}
+
+// SecuritySetup is a security setup object (class 64). It holds the security
+// policy and the keys of an association.
+type SecuritySetup struct {
+	LogicalName *Obis
+}
+
+// NewSecuritySetup creates a new SecuritySetup
+func NewSecuritySetup(logicalName *Obis) *SecuritySetup {
+	return &SecuritySetup{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the security setup
+func (s *SecuritySetup) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceSecuritySetup, s.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the security setup
+func (s *SecuritySetup) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceSecuritySetup, s.LogicalName, method)
+}
+
+// SecuritySetupKeyTransferToBytes encodes the parameter of the key_transfer
+// method, an array of key_id and key_wrapped structures
+func SecuritySetupKeyTransferToBytes(keys []*SecuritySetupKeyTransfer) []byte {
+	result := encodeHeader(dlmsdata.TagArray, len(keys))
+	for _, key := range keys {
+		result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+		result = append(result, encodeUnsigned(dlmsdata.TagEnum, 1, uint64(key.KeyID))...)
+		result = append(result, encodeOctetString(key.WrappedKey)...)
+	}
+	return result
+}
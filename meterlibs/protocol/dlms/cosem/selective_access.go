@@ -1,7 +1,6 @@
 package cosem
 
 import (
-	"encoding/binary"
 	"fmt"
 	"time"
 
@@ -41,8 +40,9 @@ func NewRangeDescriptor(
 	}
 }
 
-// ToBytes converts RangeDescriptor to bytes
-func (r *RangeDescriptor) ToBytes() []byte {
+// ToBytes converts RangeDescriptor to bytes, selected values are not
+// supported yet
+func (r *RangeDescriptor) ToBytes() ([]byte, error) {
 	result := []byte{byte(AccessDescriptorTypeRange)}
 	
 	// Structure of 4 elements
@@ -70,10 +70,10 @@ func (r *RangeDescriptor) ToBytes() []byte {
 		result = append(result, 0x01, 0x00) // Array tag + length 0
 	} else {
 		// TODO: Implement selected values
-		panic("selected values not yet implemented")
+		return nil, fmt.Errorf("selected values not yet implemented")
 	}
 	
-	return result
+	return result, nil
 }
 
 // FromBytes creates RangeDescriptor from bytes and returns the number of bytes consumed
@@ -0,0 +1,233 @@
+package cosem
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Single action schedule interface class (22)
+const (
+	SingleActionScheduleAttributeExecutedScript uint8 = 2
+	SingleActionScheduleAttributeType           uint8 = 3
+	SingleActionScheduleAttributeExecutionTime  uint8 = 4
+)
+
+// SingleActionScheduleType restricts the execution times of a Single action schedule
+type SingleActionScheduleType uint8
+
+const (
+	// SingleActionScheduleTypeSingleTime has one execution time, wildcards in the date are allowed
+	SingleActionScheduleTypeSingleTime SingleActionScheduleType = 1
+	// SingleActionScheduleTypeSameTime has execution times with the same time, wildcards in the date are not allowed
+	SingleActionScheduleTypeSameTime SingleActionScheduleType = 2
+	// SingleActionScheduleTypeSameTimeWildcardDate has execution times with the same time, wildcards in the date are allowed
+	SingleActionScheduleTypeSameTimeWildcardDate SingleActionScheduleType = 3
+	// SingleActionScheduleTypeDifferentTimes has execution times with any time, wildcards in the date are not allowed
+	SingleActionScheduleTypeDifferentTimes SingleActionScheduleType = 4
+	// SingleActionScheduleTypeDifferentTimesWildcardDate has execution times with any time, wildcards in the date are allowed
+	SingleActionScheduleTypeDifferentTimesWildcardDate SingleActionScheduleType = 5
+)
+
+// ScriptReference is a script of a Script table: its logical name and script identifier
+type ScriptReference struct {
+	LogicalName *Obis
+	Selector    uint16
+}
+
+// NewScriptReference creates a new ScriptReference
+func NewScriptReference(logicalName *Obis, selector uint16) *ScriptReference {
+	return &ScriptReference{
+		LogicalName: logicalName,
+		Selector:    selector,
+	}
+}
+
+// FromBytes creates a ScriptReference from the executed_script attribute
+func (s *ScriptReference) FromBytes(data []byte) (*ScriptReference, error) {
+	r := &dataReader{data: data}
+	result, err := r.scriptReference()
+	if err != nil {
+		return nil, fmt.Errorf("executed_script: %w", err)
+	}
+	if err = r.end(); err != nil {
+		return nil, fmt.Errorf("executed_script: %w", err)
+	}
+	return result, nil
+}
+
+// ToBytes converts ScriptReference to a structure of logical name and selector
+func (s *ScriptReference) ToBytes() []byte {
+	result := encodeHeader(dlmsdata.TagStructure, 2)
+	result = append(result, encodeOctetString(s.LogicalName.ToBytes())...)
+	return append(result, encodeUnsigned(dlmsdata.TagLongUnsigned, 2, uint64(s.Selector))...)
+}
+
+func (r *dataReader) scriptReference() (*ScriptReference, error) {
+	if _, err := r.structure(2); err != nil {
+		return nil, err
+	}
+	logicalName, err := r.obis()
+	if err != nil {
+		return nil, fmt.Errorf("script_logical_name: %w", err)
+	}
+	selector, err := r.unsigned(dlmsdata.TagLongUnsigned, 2)
+	if err != nil {
+		return nil, fmt.Errorf("script_selector: %w", err)
+	}
+	return NewScriptReference(logicalName, uint16(selector)), nil
+}
+
+// ExecutionTime is an entry of the execution_time attribute
+type ExecutionTime struct {
+	Time *dlmsdata.CosemTime
+	Date *dlmsdata.CosemDate
+}
+
+// NewExecutionTime creates a new ExecutionTime
+func NewExecutionTime(time *dlmsdata.CosemTime, date *dlmsdata.CosemDate) *ExecutionTime {
+	return &ExecutionTime{
+		Time: time,
+		Date: date,
+	}
+}
+
+// String implements fmt.Stringer
+func (e *ExecutionTime) String() string {
+	return fmt.Sprintf("%s %s", e.Date, e.Time)
+}
+
+// SingleActionSchedule is a Single action schedule object (class 22). It
+// executes a script at the given execution times, IDIS meters use them for
+// the disconnector control and the image activation.
+type SingleActionSchedule struct {
+	LogicalName    *Obis
+	ExecutedScript *ScriptReference
+	Type           SingleActionScheduleType
+	ExecutionTimes []*ExecutionTime
+}
+
+// NewSingleActionSchedule creates a new SingleActionSchedule
+func NewSingleActionSchedule(logicalName *Obis, executedScript *ScriptReference, scheduleType SingleActionScheduleType, executionTimes []*ExecutionTime) *SingleActionSchedule {
+	return &SingleActionSchedule{
+		LogicalName:    logicalName,
+		ExecutedScript: executedScript,
+		Type:           scheduleType,
+		ExecutionTimes: executionTimes,
+	}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the schedule
+func (s *SingleActionSchedule) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceSingleActionSchedule, s.LogicalName, attribute)
+}
+
+// Validate checks the execution times against the type of the schedule
+func (s *SingleActionSchedule) Validate() error {
+	if s.Type < SingleActionScheduleTypeSingleTime || s.Type > SingleActionScheduleTypeDifferentTimesWildcardDate {
+		return fmt.Errorf("invalid single action schedule type %d", s.Type)
+	}
+	if s.Type == SingleActionScheduleTypeSingleTime && len(s.ExecutionTimes) > 1 {
+		return fmt.Errorf("single action schedule of type %d has one execution time, got %d", s.Type, len(s.ExecutionTimes))
+	}
+
+	wildcardDate := s.Type == SingleActionScheduleTypeSingleTime ||
+		s.Type == SingleActionScheduleTypeSameTimeWildcardDate ||
+		s.Type == SingleActionScheduleTypeDifferentTimesWildcardDate
+	sameTime := s.Type == SingleActionScheduleTypeSameTime || s.Type == SingleActionScheduleTypeSameTimeWildcardDate
+
+	for i, executionTime := range s.ExecutionTimes {
+		if executionTime.Time == nil || executionTime.Date == nil {
+			return fmt.Errorf("execution time %d needs a time and a date", i)
+		}
+		if !wildcardDate && executionTime.Date.HasWildcard() {
+			return fmt.Errorf("single action schedule of type %d doesn't allow wildcards in the date, got %s", s.Type, executionTime.Date)
+		}
+		if sameTime && *executionTime.Time != *s.ExecutionTimes[0].Time {
+			return fmt.Errorf("single action schedule of type %d has the same time for all execution times, got %s and %s",
+				s.Type, s.ExecutionTimes[0].Time, executionTime.Time)
+		}
+	}
+	return nil
+}
+
+// TypeToBytes converts the type to the type attribute
+func (s *SingleActionSchedule) TypeToBytes() []byte {
+	return encodeUnsigned(dlmsdata.TagEnum, 1, uint64(s.Type))
+}
+
+// ExecutionTimeToBytes converts the execution times to the execution_time
+// attribute, an array of structures of time and date octet-strings
+func (s *SingleActionSchedule) ExecutionTimeToBytes() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := encodeHeader(dlmsdata.TagArray, len(s.ExecutionTimes))
+	for _, executionTime := range s.ExecutionTimes {
+		result = append(result, encodeHeader(dlmsdata.TagStructure, 2)...)
+		result = append(result, encodeOctetString(executionTime.Time.ToBytes())...)
+		result = append(result, encodeOctetString(executionTime.Date.ToBytes())...)
+	}
+	return result, nil
+}
+
+// ParseSingleActionScheduleType parses the type attribute
+func ParseSingleActionScheduleType(data []byte) (SingleActionScheduleType, error) {
+	r := &dataReader{data: data}
+	value, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("type: %w", err)
+	}
+	return SingleActionScheduleType(value), nil
+}
+
+// ParseExecutionTime parses the execution_time attribute
+func ParseExecutionTime(data []byte) ([]*ExecutionTime, error) {
+	r := &dataReader{data: data}
+	count, err := r.header(dlmsdata.TagArray)
+	if err != nil {
+		return nil, fmt.Errorf("execution_time: %w", err)
+	}
+
+	result := make([]*ExecutionTime, 0, count)
+	for i := 0; i < count; i++ {
+		executionTime, err := r.executionTime()
+		if err != nil {
+			return nil, fmt.Errorf("execution_time element %d: %w", i, err)
+		}
+		result = append(result, executionTime)
+	}
+
+	if err = r.end(); err != nil {
+		return nil, fmt.Errorf("execution_time: %w", err)
+	}
+	return result, nil
+}
+
+func (r *dataReader) executionTime() (*ExecutionTime, error) {
+	if _, err := r.structure(2); err != nil {
+		return nil, err
+	}
+	timeBytes, err := r.octetString()
+	if err != nil {
+		return nil, fmt.Errorf("time: %w", err)
+	}
+	time, err := (&dlmsdata.CosemTime{}).FromBytes(timeBytes)
+	if err != nil {
+		return nil, fmt.Errorf("time: %w", err)
+	}
+	dateBytes, err := r.octetString()
+	if err != nil {
+		return nil, fmt.Errorf("date: %w", err)
+	}
+	date, err := (&dlmsdata.CosemDate{}).FromBytes(dateBytes)
+	if err != nil {
+		return nil, fmt.Errorf("date: %w", err)
+	}
+	return NewExecutionTime(time, date), nil
+}
@@ -0,0 +1,185 @@
+package cosem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Attributes of the Token gateway interface class (115)
+const (
+	TokenGatewayAttributeToken            uint8 = 2
+	TokenGatewayAttributeTokenTime        uint8 = 3
+	TokenGatewayAttributeTokenDescription uint8 = 4
+	TokenGatewayAttributeDeliveryMethod   uint8 = 5
+	TokenGatewayAttributeStatus           uint8 = 6
+)
+
+// Methods of the Token gateway interface class (115)
+const (
+	TokenGatewayMethodEnter uint8 = 1
+)
+
+// TokenDeliveryMethod is how the last token reached the meter
+type TokenDeliveryMethod uint8
+
+const (
+	TokenDeliveryRemote TokenDeliveryMethod = 0
+	TokenDeliveryLocal  TokenDeliveryMethod = 1
+	TokenDeliveryManual TokenDeliveryMethod = 2
+)
+
+// TokenStatusCode is the result of the processing of the last token
+type TokenStatusCode uint8
+
+const (
+	TokenFormatOK                TokenStatusCode = 0
+	TokenAuthenticationOK        TokenStatusCode = 1
+	TokenValidationOK            TokenStatusCode = 2
+	TokenExecutionOK             TokenStatusCode = 3
+	TokenFormatFailure           TokenStatusCode = 4
+	TokenAuthenticationFailure   TokenStatusCode = 5
+	TokenValidationFailure       TokenStatusCode = 6
+	TokenExecutionFailure        TokenStatusCode = 7
+	TokenReceivedNotYetProcessed TokenStatusCode = 8
+)
+
+// String implements fmt.Stringer
+func (c TokenStatusCode) String() string {
+	switch c {
+	case TokenFormatOK:
+		return "format-ok"
+	case TokenAuthenticationOK:
+		return "authentication-ok"
+	case TokenValidationOK:
+		return "validation-ok"
+	case TokenExecutionOK:
+		return "execution-ok"
+	case TokenFormatFailure:
+		return "format-failure"
+	case TokenAuthenticationFailure:
+		return "authentication-failure"
+	case TokenValidationFailure:
+		return "validation-failure"
+	case TokenExecutionFailure:
+		return "execution-failure"
+	case TokenReceivedNotYetProcessed:
+		return "not-yet-processed"
+	default:
+		return fmt.Sprintf("TokenStatusCode(%d)", uint8(c))
+	}
+}
+
+// TokenStatus is the token_status attribute. Data gives the reasons of a
+// failure, one bit per reason as defined by the token standard in use.
+type TokenStatus struct {
+	Code TokenStatusCode
+	Data *dlmsdata.BitString
+}
+
+// Failed tells if the token was rejected
+func (s *TokenStatus) Failed() bool {
+	return s.Code >= TokenFormatFailure && s.Code <= TokenExecutionFailure
+}
+
+// Pending tells if the token is still being processed, the status is read
+// again later to know the result
+func (s *TokenStatus) Pending() bool {
+	return s.Code < TokenExecutionOK || s.Code == TokenReceivedNotYetProcessed
+}
+
+// String implements fmt.Stringer
+func (s *TokenStatus) String() string {
+	if s.Data == nil {
+		return s.Code.String()
+	}
+	return fmt.Sprintf("%s %s", s.Code, s.Data)
+}
+
+// TokenGateway is a Token gateway object (class 115). It receives the
+// prepayment tokens and tells how their processing went.
+type TokenGateway struct {
+	LogicalName *Obis
+	// Token is the last token received
+	Token []byte
+	// TokenTime is when the last token was received
+	TokenTime        time.Time
+	TokenDescription [][]byte
+	DeliveryMethod   TokenDeliveryMethod
+	Status           *TokenStatus
+}
+
+// NewTokenGateway creates a new TokenGateway, the attributes are filled in
+// with FromAttribute
+func NewTokenGateway(logicalName *Obis) *TokenGateway {
+	return &TokenGateway{LogicalName: logicalName}
+}
+
+// Attribute returns the attribute descriptor of the given attribute of the token gateway
+func (g *TokenGateway) Attribute(attribute uint8) *CosemAttribute {
+	return NewCosemAttribute(enumerations.CosemInterfaceTokenGateway, g.LogicalName, attribute)
+}
+
+// Method returns the method descriptor of the given method of the token gateway
+func (g *TokenGateway) Method(method uint8) *CosemMethod {
+	return NewCosemMethod(enumerations.CosemInterfaceTokenGateway, g.LogicalName, method)
+}
+
+// FromAttribute decodes the value of an attribute into the TokenGateway
+func (g *TokenGateway) FromAttribute(attribute uint8, data []byte) error {
+	r := &dataReader{data: data}
+	var err error
+	var value uint64
+	var octets []byte
+
+	switch attribute {
+	case TokenGatewayAttributeToken:
+		octets, err = r.octetString()
+		g.Token = append([]byte{}, octets...)
+	case TokenGatewayAttributeTokenTime:
+		g.TokenTime, err = r.dateTime()
+	case TokenGatewayAttributeTokenDescription:
+		g.TokenDescription, err = r.octetStrings()
+	case TokenGatewayAttributeDeliveryMethod:
+		value, err = r.unsigned(dlmsdata.TagEnum, 1)
+		g.DeliveryMethod = TokenDeliveryMethod(value)
+	case TokenGatewayAttributeStatus:
+		g.Status, err = r.tokenStatus()
+	default:
+		return fmt.Errorf("token gateway has no attribute %d", attribute)
+	}
+
+	if err == nil {
+		err = r.end()
+	}
+	if err != nil {
+		return fmt.Errorf("token gateway attribute %d: %w", attribute, err)
+	}
+	return nil
+}
+
+// TokenToBytes encodes the parameter of the enter method
+func TokenToBytes(token []byte) []byte {
+	return encodeOctetString(token)
+}
+
+func (r *dataReader) tokenStatus() (*TokenStatus, error) {
+	if _, err := r.structure(2); err != nil {
+		return nil, err
+	}
+	code, err := r.unsigned(dlmsdata.TagEnum, 1)
+	if err != nil {
+		return nil, fmt.Errorf("status_code: %w", err)
+	}
+	status := &TokenStatus{Code: TokenStatusCode(code)}
+	if r.next(dlmsdata.TagNull) {
+		r.data = r.data[1:]
+		return status, nil
+	}
+	if status.Data, err = r.bitString(); err != nil {
+		return nil, fmt.Errorf("data_value: %w", err)
+	}
+	return status, nil
+}
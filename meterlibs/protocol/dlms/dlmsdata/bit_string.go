@@ -0,0 +1,179 @@
+package dlmsdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BitString is a string of bits of any length. The bits are packed most
+// significant bit first, the unused bits of the last byte are zero.
+type BitString struct {
+	// Length is the number of bits
+	Length int
+	Bytes  []byte
+}
+
+// NewBitString creates a new BitString of the given number of bits
+func NewBitString(length int, data []byte) (*BitString, error) {
+	if length < 0 {
+		return nil, fmt.Errorf("bit string length can't be negative, got %d", length)
+	}
+	if len(data) != bitStringByteLength(length) {
+		return nil, fmt.Errorf("bit string of %d bits is %d bytes, got %d", length, bitStringByteLength(length), len(data))
+	}
+	bytes := make([]byte, len(data))
+	copy(bytes, data)
+	if unused := len(bytes)*8 - length; unused > 0 {
+		bytes[len(bytes)-1] &^= byte(1<<unused - 1)
+	}
+	return &BitString{Length: length, Bytes: bytes}, nil
+}
+
+// BitStringFromBools creates a BitString with a bit per value, the first
+// value is the first bit
+func BitStringFromBools(bits []bool) *BitString {
+	bytes := make([]byte, bitStringByteLength(len(bits)))
+	for i, bit := range bits {
+		if bit {
+			bytes[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return &BitString{Length: len(bits), Bytes: bytes}
+}
+
+// BitStringFromUint64 creates a BitString of the given number of bits from
+// the low bits of value, the most significant of them is the first bit
+func BitStringFromUint64(value uint64, length int) (*BitString, error) {
+	if length < 0 || length > 64 {
+		return nil, fmt.Errorf("bit string length must be between 0 and 64, got %d", length)
+	}
+	if length < 64 && value>>length != 0 {
+		return nil, fmt.Errorf("value %d does not fit in %d bits", value, length)
+	}
+	bits := make([]bool, length)
+	for i := range bits {
+		bits[i] = value&(1<<(length-1-i)) != 0
+	}
+	return BitStringFromBools(bits), nil
+}
+
+// Bit returns the bit at the given index, the first bit is 0
+func (b *BitString) Bit(index int) bool {
+	if index < 0 || index >= b.Length {
+		return false
+	}
+	return b.Bytes[index/8]&(0x80>>(index%8)) != 0
+}
+
+// Bools returns a value per bit
+func (b *BitString) Bools() []bool {
+	bits := make([]bool, b.Length)
+	for i := range bits {
+		bits[i] = b.Bit(i)
+	}
+	return bits
+}
+
+// Uint64 returns the bits as an unsigned integer, the first bit is the most
+// significant. Bit strings longer than 64 bits don't fit.
+func (b *BitString) Uint64() (uint64, error) {
+	if b.Length > 64 {
+		return 0, fmt.Errorf("bit string of %d bits does not fit in 64 bits", b.Length)
+	}
+	var value uint64
+	for i := 0; i < b.Length; i++ {
+		value <<= 1
+		if b.Bit(i) {
+			value |= 1
+		}
+	}
+	return value, nil
+}
+
+// String returns the bits as 0 and 1
+func (b *BitString) String() string {
+	var builder strings.Builder
+	for i := 0; i < b.Length; i++ {
+		if b.Bit(i) {
+			builder.WriteByte('1')
+		} else {
+			builder.WriteByte('0')
+		}
+	}
+	return builder.String()
+}
+
+// bitStringByteLength returns the number of bytes holding the given number of bits
+func bitStringByteLength(bits int) int {
+	return (bits + 7) / 8
+}
+
+// DecodeBitString decodes the number of bits and the bits following the tag
+// of a bit-string, it returns the number of bytes read
+func DecodeBitString(data []byte) (*BitString, int, error) {
+	length, remaining, err := DecodeVariableInteger(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode bit string length: %w", err)
+	}
+	byteLength := bitStringByteLength(length)
+	if len(remaining) < byteLength {
+		return nil, 0, fmt.Errorf("insufficient data for bit string of %d bits", length)
+	}
+	bitString, err := NewBitString(length, remaining[:byteLength])
+	if err != nil {
+		return nil, 0, err
+	}
+	return bitString, len(data) - len(remaining) + byteLength, nil
+}
+
+// BitStringData represents bit string data
+type BitStringData struct {
+	*BaseDlmsData
+}
+
+// NewBitStringData creates a new BitStringData
+func NewBitStringData(value *BitString) *BitStringData {
+	if value == nil {
+		value = &BitString{}
+	}
+	return &BitStringData{
+		BaseDlmsData: &BaseDlmsData{
+			Tag:    TagBitString,
+			Length: VariableLength,
+			Value:  value,
+		},
+	}
+}
+
+// FromBytes creates BitStringData from the number of bits and the bits
+func (b *BitStringData) FromBytes(data []byte) (DlmsData, error) {
+	bitString, consumed, err := DecodeBitString(data)
+	if err != nil {
+		return nil, err
+	}
+	if consumed != len(data) {
+		return nil, fmt.Errorf("%d bytes left after bit string", len(data)-consumed)
+	}
+	return NewBitStringData(bitString), nil
+}
+
+// ValueToBytes returns the number of bits and the bits
+func (b *BitStringData) ValueToBytes() ([]byte, error) {
+	bitString := b.Value.(*BitString)
+	return append(EncodeVariableInteger(bitString.Length), bitString.Bytes...), nil
+}
+
+// ToBytes converts BitStringData to bytes, the length is the number of bits
+// instead of the number of bytes
+func (b *BitStringData) ToBytes() ([]byte, error) {
+	value, err := b.ValueToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(TagBitString)}, value...), nil
+}
+
+// String returns string representation
+func (b *BitStringData) String() string {
+	return b.Value.(*BitString).String()
+}
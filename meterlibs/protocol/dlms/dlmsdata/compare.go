@@ -0,0 +1,91 @@
+package dlmsdata
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Equal returns true if both DLMS data values have the same type and content,
+// comparing arrays and structures element by element
+func Equal(a, b DlmsData) bool {
+	return len(diff(a, b, "", nil)) == 0
+}
+
+// Diff returns a readable report of where two DLMS data values differ.
+// Every difference is on its own line prefixed with the path to the element,
+// e.g. "[2][0]: tag 9 != 17". An empty string means the values are equal.
+func Diff(a, b DlmsData) string {
+	return strings.Join(diff(a, b, "", nil), "\n")
+}
+
+// diff collects the differences between a and b below the given path
+func diff(a, b DlmsData, path string, differences []string) []string {
+	location := path
+	if location == "" {
+		location = "root"
+	}
+
+	if a == nil || b == nil {
+		if a != nil || b != nil {
+			differences = append(differences, fmt.Sprintf("%s: %s != %s", location, describe(a), describe(b)))
+		}
+		return differences
+	}
+
+	if a.GetTag() != b.GetTag() {
+		return append(differences, fmt.Sprintf("%s: tag %d != %d", location, a.GetTag(), b.GetTag()))
+	}
+
+	aItems, aIsContainer := containerItems(a)
+	bItems, _ := containerItems(b)
+	if aIsContainer {
+		if len(aItems) != len(bItems) {
+			differences = append(differences, fmt.Sprintf("%s: length %d != %d", location, len(aItems), len(bItems)))
+		}
+		count := len(aItems)
+		if len(bItems) < count {
+			count = len(bItems)
+		}
+		for i := 0; i < count; i++ {
+			differences = diff(aItems[i], bItems[i], fmt.Sprintf("%s[%d]", path, i), differences)
+		}
+		return differences
+	}
+
+	aBytes, aErr := valueBytes(a)
+	bBytes, bErr := valueBytes(b)
+	if aErr != nil || bErr != nil || !bytes.Equal(aBytes, bBytes) {
+		differences = append(differences, fmt.Sprintf("%s: %s != %s", location, describe(a), describe(b)))
+	}
+	return differences
+}
+
+// containerItems returns the items of arrays and structures
+func containerItems(data DlmsData) ([]DlmsData, bool) {
+	switch d := data.(type) {
+	case *DataArray:
+		items, _ := d.Value.([]DlmsData)
+		return items, true
+	case *DataStructure:
+		items, _ := d.Value.([]DlmsData)
+		return items, true
+	}
+	return nil, false
+}
+
+// valueBytes returns the encoded value of a simple data type without tag and length
+func valueBytes(data DlmsData) ([]byte, error) {
+	if encoder, ok := data.(interface{ ValueToBytes() ([]byte, error) }); ok {
+		return encoder.ValueToBytes()
+	}
+	return data.ToBytes()
+}
+
+// describe formats a value for the diff report
+func describe(data DlmsData) string {
+	if data == nil {
+		return "<nil>"
+	}
+	return data.String()
+}
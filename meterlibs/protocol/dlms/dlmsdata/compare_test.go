@@ -0,0 +1,79 @@
+package dlmsdata_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+)
+
+func TestEqual(t *testing.T) {
+	nested := func(value uint8) dlmsdata.DlmsData {
+		return dlmsdata.NewDataArray([]dlmsdata.DlmsData{
+			dlmsdata.NewDataStructure([]dlmsdata.DlmsData{
+				dlmsdata.NewOctetStringData([]byte{1, 0, 1, 8, 0, 255}),
+				dlmsdata.NewUnsignedIntegerData(value),
+			}),
+		})
+	}
+
+	for _, test := range []struct {
+		name  string
+		a, b  dlmsdata.DlmsData
+		equal bool
+	}{
+		{"both nil", nil, nil, true},
+		{"nil operand", nil, dlmsdata.NewNullData(), false},
+		{"nil operand", dlmsdata.NewNullData(), nil, false},
+		{"same simple value", dlmsdata.NewUnsignedLongData(42), dlmsdata.NewUnsignedLongData(42), true},
+		{"different simple value", dlmsdata.NewUnsignedLongData(42), dlmsdata.NewUnsignedLongData(43), false},
+		{"type mismatch", dlmsdata.NewUnsignedIntegerData(42), dlmsdata.NewUnsignedLongData(42), false},
+		{"array and structure", dlmsdata.NewDataArray(nil), dlmsdata.NewDataStructure(nil), false},
+		{"same nested value", nested(1), nested(1), true},
+		{"different nested value", nested(1), nested(2), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.equal, dlmsdata.Equal(test.a, test.b))
+			assert.Equal(t, test.equal, dlmsdata.Diff(test.a, test.b) == "")
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := dlmsdata.NewDataStructure([]dlmsdata.DlmsData{
+		dlmsdata.NewVisibleStringData("meter"),
+		dlmsdata.NewDataArray([]dlmsdata.DlmsData{
+			dlmsdata.NewUnsignedIntegerData(1),
+			dlmsdata.NewUnsignedIntegerData(2),
+		}),
+		dlmsdata.NewDataArray([]dlmsdata.DlmsData{
+			dlmsdata.NewOctetStringData([]byte{0x01, 0x02}),
+			dlmsdata.NewUnsignedLongData(7),
+		}),
+	})
+	b := dlmsdata.NewDataStructure([]dlmsdata.DlmsData{
+		dlmsdata.NewVisibleStringData("meter"),
+		dlmsdata.NewDataArray([]dlmsdata.DlmsData{
+			dlmsdata.NewUnsignedIntegerData(1),
+		}),
+		dlmsdata.NewDataArray([]dlmsdata.DlmsData{
+			dlmsdata.NewOctetStringData([]byte{0x01, 0x03}),
+			dlmsdata.NewUnsignedIntegerData(7),
+		}),
+	})
+
+	assert.Equal(t, "[1]: length 2 != 1\n"+
+		"[2][0]: 0x0102 != 0x0103\n"+
+		"[2][1]: tag 18 != 17", dlmsdata.Diff(a, b))
+}
+
+func TestDiff_Root(t *testing.T) {
+	assert.Equal(t, "root: tag 17 != 18",
+		dlmsdata.Diff(dlmsdata.NewUnsignedIntegerData(1), dlmsdata.NewUnsignedLongData(1)))
+	assert.Equal(t, "root: 1 != 2",
+		dlmsdata.Diff(dlmsdata.NewUnsignedIntegerData(1), dlmsdata.NewUnsignedIntegerData(2)))
+	assert.Equal(t, "root: <nil> != null", dlmsdata.Diff(nil, dlmsdata.NewNullData()))
+	assert.Equal(t, "[0]: null != <nil>", dlmsdata.Diff(
+		dlmsdata.NewDataArray([]dlmsdata.DlmsData{dlmsdata.NewNullData()}),
+		dlmsdata.NewDataArray([]dlmsdata.DlmsData{nil})))
+}
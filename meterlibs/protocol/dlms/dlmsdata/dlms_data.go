@@ -3,6 +3,12 @@ package dlmsdata
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
 )
 
 const VariableLength = -1
@@ -130,6 +136,47 @@ func (n *NullData) String() string {
 	return "null"
 }
 
+// DontCare is the decoded value of a don't-care element, a placeholder for
+// a value the server doesn't send, e.g. in selective access replies. It is
+// told apart from null-data.
+type DontCare struct{}
+
+// DontCareData represents a don't-care element, it has no value
+type DontCareData struct {
+	*BaseDlmsData
+}
+
+// NewDontCareData creates a new DontCareData
+func NewDontCareData() *DontCareData {
+	return &DontCareData{
+		BaseDlmsData: &BaseDlmsData{
+			Tag:    TagDontCare,
+			Length: 0,
+			Value:  DontCare{},
+		},
+	}
+}
+
+// FromBytes creates DontCareData, there are no bytes to read
+func (d *DontCareData) FromBytes(data []byte) (DlmsData, error) {
+	return NewDontCareData(), nil
+}
+
+// ToBytes returns the tag only
+func (d *DontCareData) ToBytes() ([]byte, error) {
+	return []byte{byte(TagDontCare)}, nil
+}
+
+// ValueToBytes returns empty bytes
+func (d *DontCareData) ValueToBytes() ([]byte, error) {
+	return []byte{}, nil
+}
+
+// String returns string representation
+func (d *DontCareData) String() string {
+	return "dont-care"
+}
+
 // BooleanData represents boolean data
 type BooleanData struct {
 	*BaseDlmsData
@@ -387,6 +434,190 @@ func (d *DoubleLongUnsignedData) String() string {
 	return fmt.Sprintf("%d", d.Value.(uint32))
 }
 
+// Long64Data represents 64-bit signed integer
+type Long64Data struct {
+	*BaseDlmsData
+}
+
+// NewLong64Data creates a new Long64Data
+func NewLong64Data(value int64) *Long64Data {
+	return &Long64Data{
+		BaseDlmsData: &BaseDlmsData{
+			Tag:    TagLong64,
+			Length: 8,
+			Value:  value,
+		},
+	}
+}
+
+// FromBytes creates Long64Data from bytes
+func (l *Long64Data) FromBytes(data []byte) (DlmsData, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("insufficient data for Long64Data")
+	}
+	value := int64(binary.BigEndian.Uint64(data))
+	return NewLong64Data(value), nil
+}
+
+// ValueToBytes converts int64 to bytes
+func (l *Long64Data) ValueToBytes() ([]byte, error) {
+	result := make([]byte, 8)
+	binary.BigEndian.PutUint64(result, uint64(l.Value.(int64)))
+	return result, nil
+}
+
+// String returns string representation
+func (l *Long64Data) String() string {
+	return fmt.Sprintf("%d", l.Value.(int64))
+}
+
+// UnsignedLong64Data represents 64-bit unsigned integer
+type UnsignedLong64Data struct {
+	*BaseDlmsData
+}
+
+// NewUnsignedLong64Data creates a new UnsignedLong64Data
+func NewUnsignedLong64Data(value uint64) *UnsignedLong64Data {
+	return &UnsignedLong64Data{
+		BaseDlmsData: &BaseDlmsData{
+			Tag:    TagLong64Unsigned,
+			Length: 8,
+			Value:  value,
+		},
+	}
+}
+
+// FromBytes creates UnsignedLong64Data from bytes
+func (u *UnsignedLong64Data) FromBytes(data []byte) (DlmsData, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("insufficient data for UnsignedLong64Data")
+	}
+	value := binary.BigEndian.Uint64(data)
+	return NewUnsignedLong64Data(value), nil
+}
+
+// ValueToBytes converts uint64 to bytes
+func (u *UnsignedLong64Data) ValueToBytes() ([]byte, error) {
+	result := make([]byte, 8)
+	binary.BigEndian.PutUint64(result, u.Value.(uint64))
+	return result, nil
+}
+
+// String returns string representation
+func (u *UnsignedLong64Data) String() string {
+	return fmt.Sprintf("%d", u.Value.(uint64))
+}
+
+// Enum is the value of an enum, it is decoded apart from the unsigned
+// values so it can be given a name
+type Enum uint8
+
+// EnumData represents enum data
+type EnumData struct {
+	*BaseDlmsData
+}
+
+// NewEnumData creates a new EnumData
+func NewEnumData(value Enum) *EnumData {
+	return &EnumData{
+		BaseDlmsData: &BaseDlmsData{
+			Tag:    TagEnum,
+			Length: 1,
+			Value:  value,
+		},
+	}
+}
+
+// FromBytes creates EnumData from bytes
+func (e *EnumData) FromBytes(data []byte) (DlmsData, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("insufficient data for EnumData")
+	}
+	return NewEnumData(Enum(data[0])), nil
+}
+
+// ValueToBytes converts the enum to bytes
+func (e *EnumData) ValueToBytes() ([]byte, error) {
+	return []byte{byte(e.Value.(Enum))}, nil
+}
+
+// String returns string representation
+func (e *EnumData) String() string {
+	return fmt.Sprintf("%d", e.Value.(Enum))
+}
+
+// Float32Data represents a 32-bit IEEE 754 floating point number
+type Float32Data struct {
+	*BaseDlmsData
+}
+
+// NewFloat32Data creates a new Float32Data
+func NewFloat32Data(value float32) *Float32Data {
+	return &Float32Data{
+		BaseDlmsData: &BaseDlmsData{
+			Tag:    TagFloat32,
+			Length: 4,
+			Value:  value,
+		},
+	}
+}
+
+// FromBytes creates Float32Data from bytes
+func (f *Float32Data) FromBytes(data []byte) (DlmsData, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("insufficient data for Float32Data")
+	}
+	return NewFloat32Data(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+}
+
+// ValueToBytes converts float32 to bytes
+func (f *Float32Data) ValueToBytes() ([]byte, error) {
+	result := make([]byte, 4)
+	binary.BigEndian.PutUint32(result, math.Float32bits(f.Value.(float32)))
+	return result, nil
+}
+
+// String returns string representation
+func (f *Float32Data) String() string {
+	return strconv.FormatFloat(float64(f.Value.(float32)), 'g', -1, 32)
+}
+
+// Float64Data represents a 64-bit IEEE 754 floating point number
+type Float64Data struct {
+	*BaseDlmsData
+}
+
+// NewFloat64Data creates a new Float64Data
+func NewFloat64Data(value float64) *Float64Data {
+	return &Float64Data{
+		BaseDlmsData: &BaseDlmsData{
+			Tag:    TagFloat64,
+			Length: 8,
+			Value:  value,
+		},
+	}
+}
+
+// FromBytes creates Float64Data from bytes
+func (f *Float64Data) FromBytes(data []byte) (DlmsData, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("insufficient data for Float64Data")
+	}
+	return NewFloat64Data(math.Float64frombits(binary.BigEndian.Uint64(data))), nil
+}
+
+// ValueToBytes converts float64 to bytes
+func (f *Float64Data) ValueToBytes() ([]byte, error) {
+	result := make([]byte, 8)
+	binary.BigEndian.PutUint64(result, math.Float64bits(f.Value.(float64)))
+	return result, nil
+}
+
+// String returns string representation
+func (f *Float64Data) String() string {
+	return strconv.FormatFloat(f.Value.(float64), 'g', -1, 64)
+}
+
 // OctetStringData represents octet string data
 type OctetStringData struct {
 	*BaseDlmsData
@@ -480,7 +711,7 @@ func (d *DataArray) ToBytes() ([]byte, error) {
 	lengthBytes := EncodeVariableInteger(len(items))
 	result = append(result, lengthBytes...)
 	for _, item := range items {
-		itemBytes, err := item.ToBytes()
+		itemBytes, err := encodeItem(item)
 		if err != nil {
 			return nil, err
 		}
@@ -489,6 +720,25 @@ func (d *DataArray) ToBytes() ([]byte, error) {
 	return result, nil
 }
 
+// encodeItem encodes an element of an array or a structure. The ToBytes of
+// BaseDlmsData can't reach the ValueToBytes of the type embedding it, so the
+// simple types are encoded here from their value.
+func encodeItem(item DlmsData) ([]byte, error) {
+	switch item.(type) {
+	case *DataArray, *DataStructure, *BitStringData:
+		return item.ToBytes()
+	}
+	value, err := valueBytes(item)
+	if err != nil {
+		return nil, err
+	}
+	result := []byte{byte(item.GetTag())}
+	if item.GetLength() == VariableLength {
+		result = append(result, EncodeVariableInteger(len(value))...)
+	}
+	return append(result, value...), nil
+}
+
 // ToPython converts to Python-like list
 func (d *DataArray) ToPython() interface{} {
 	items := d.Value.([]DlmsData)
@@ -517,6 +767,12 @@ func (d *DataArray) FromBytes(data []byte) (DlmsData, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode array length: %w", err)
 	}
+	// Every item is at least one byte, more items than bytes can't be valid
+	if length > len(remaining) {
+		return nil, exceptions.NewTruncatedLengthError(
+			fmt.Sprintf("array declares %d items but only %d bytes remain", length, len(remaining)),
+			length, len(remaining), MaxDeclaredLength())
+	}
 	
 	items := make([]DlmsData, 0, length)
 	pos := 0
@@ -527,8 +783,7 @@ func (d *DataArray) FromBytes(data []byte) (DlmsData, error) {
 		}
 		
 		tag := DlmsDataTag(remaining[pos])
-		factory := NewDlmsDataFactory()
-		itemFactory, err := factory.GetDataClass(tag)
+		itemFactory, err := DataClass(tag)
 		if err != nil {
 			return nil, fmt.Errorf("unknown data tag in array: %d", tag)
 		}
@@ -591,7 +846,7 @@ func (d *DataStructure) ToBytes() ([]byte, error) {
 	lengthBytes := EncodeVariableInteger(len(items))
 	result = append(result, lengthBytes...)
 	for _, item := range items {
-		itemBytes, err := item.ToBytes()
+		itemBytes, err := encodeItem(item)
 		if err != nil {
 			return nil, err
 		}
@@ -628,6 +883,12 @@ func (d *DataStructure) FromBytes(data []byte) (DlmsData, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode structure length: %w", err)
 	}
+	// Every item is at least one byte, more items than bytes can't be valid
+	if length > len(remaining) {
+		return nil, exceptions.NewTruncatedLengthError(
+			fmt.Sprintf("structure declares %d items but only %d bytes remain", length, len(remaining)),
+			length, len(remaining), MaxDeclaredLength())
+	}
 	
 	items := make([]DlmsData, 0, length)
 	pos := 0
@@ -638,8 +899,7 @@ func (d *DataStructure) FromBytes(data []byte) (DlmsData, error) {
 		}
 		
 		tag := DlmsDataTag(remaining[pos])
-		factory := NewDlmsDataFactory()
-		itemFactory, err := factory.GetDataClass(tag)
+		itemFactory, err := DataClass(tag)
 		if err != nil {
 			return nil, fmt.Errorf("unknown data tag in structure: %d", tag)
 		}
@@ -710,6 +970,39 @@ func EncodeVariableInteger(length int) []byte {
 	return result
 }
 
+// DefaultMaxDeclaredLength is the largest length or item count accepted when
+// decoding variable length integers, unless SetMaxDeclaredLength changed it
+const DefaultMaxDeclaredLength = 16 * 1024 * 1024
+
+// maxDeclaredLength is the limit of the decoded lengths. Lengths come from the
+// meter and are not trusted.
+var maxDeclaredLength atomic.Int64
+
+func init() {
+	maxDeclaredLength.Store(DefaultMaxDeclaredLength)
+}
+
+// MaxDeclaredLength returns the largest length or item count accepted when
+// decoding
+func MaxDeclaredLength() int {
+	return int(maxDeclaredLength.Load())
+}
+
+// SetMaxDeclaredLength changes the largest length or item count accepted when
+// decoding, e.g. for the large profile buffers of some meters, and returns
+// the previous limit so that it can be restored
+func SetMaxDeclaredLength(length int) (int, error) {
+	if length < 1 {
+		return 0, fmt.Errorf("maximum declared length should be positive, got %d", length)
+	}
+	return int(maxDeclaredLength.Swap(int64(length))), nil
+}
+
+// maxLengthBytes is the number of length bytes accepted once the leading
+// zeros are dropped. The length is checked against MaxDeclaredLength before
+// it is converted to an int, so it doesn't overflow on 32-bit platforms.
+const maxLengthBytes = 4
+
 // DecodeVariableInteger decodes a variable length integer
 func DecodeVariableInteger(data []byte) (int, []byte, error) {
 	if len(data) == 0 {
@@ -725,48 +1018,130 @@ func DecodeVariableInteger(data []byte) (int, []byte, error) {
 	}
 	
 	lengthLength := int(firstByte & 0x7F)
+	if lengthLength == 0 {
+		return 0, nil, exceptions.NewDeclaredLengthError("length of length is zero", 0, MaxDeclaredLength())
+	}
 	if len(data) < lengthLength+1 {
 		return 0, nil, fmt.Errorf("insufficient data for variable integer length")
 	}
 	
 	lengthBytes := data[1 : lengthLength+1]
-	length := 0
+	// Leading zero bytes are allowed, but the value must fit in maxLengthBytes
+	for len(lengthBytes) > maxLengthBytes && lengthBytes[0] == 0 {
+		lengthBytes = lengthBytes[1:]
+	}
+	if len(lengthBytes) > maxLengthBytes {
+		return 0, nil, exceptions.NewDeclaredLengthError(
+			fmt.Sprintf("length encoded in %d bytes does not fit", lengthLength), -1, MaxDeclaredLength())
+	}
+	var length uint64
 	for _, b := range lengthBytes {
-		length = (length << 8) | int(b)
+		length = (length << 8) | uint64(b)
+	}
+	if maxLength := MaxDeclaredLength(); length > uint64(maxLength) {
+		declared := -1
+		if length <= math.MaxInt32 {
+			declared = int(length)
+		}
+		return 0, nil, exceptions.NewDeclaredLengthError(
+			fmt.Sprintf("length %d exceeds maximum %d", length, maxLength), declared, maxLength)
 	}
 	
-	return length, data[lengthLength+1:], nil
+	return int(length), data[lengthLength+1:], nil
 }
 
 // DlmsDataFactory creates DLMS data instances from tags
 type DlmsDataFactory struct{}
 
-var dataFactoryMap = map[DlmsDataTag]func() DlmsData{
+// standardDataClasses is the dispatch table of the data types by tag, indexed
+// by the tag so that decoding large buffers doesn't hash every element's tag.
+// Tags without a data type are nil.
+var standardDataClasses = [256]func() DlmsData{
 	TagNull:               func() DlmsData { return NewNullData() },
 	TagArray:              func() DlmsData { return NewDataArray(nil) },
 	TagStructure:          func() DlmsData { return NewDataStructure(nil) },
 	TagBoolean:            func() DlmsData { return NewBooleanData(false) },
+	TagBitString:          func() DlmsData { return NewBitStringData(nil) },
 	TagInteger:            func() DlmsData { return NewIntegerData(0) },
 	TagUnsigned:           func() DlmsData { return NewUnsignedIntegerData(0) },
 	TagLong:               func() DlmsData { return NewLongData(0) },
 	TagLongUnsigned:       func() DlmsData { return NewUnsignedLongData(0) },
 	TagDoubleLong:         func() DlmsData { return NewDoubleLongData(0) },
 	TagDoubleLongUnsigned: func() DlmsData { return NewDoubleLongUnsignedData(0) },
+	TagLong64:             func() DlmsData { return NewLong64Data(0) },
+	TagLong64Unsigned:     func() DlmsData { return NewUnsignedLong64Data(0) },
+	TagEnum:               func() DlmsData { return NewEnumData(0) },
+	TagFloat32:            func() DlmsData { return NewFloat32Data(0) },
+	TagFloat64:            func() DlmsData { return NewFloat64Data(0) },
 	TagOctetString:        func() DlmsData { return NewOctetStringData(nil) },
 	TagVisibleString:      func() DlmsData { return NewVisibleStringData("") },
+	TagDontCare:           func() DlmsData { return NewDontCareData() },
 }
 
-// GetDataClass returns a factory function for the given tag
-func (f *DlmsDataFactory) GetDataClass(tag DlmsDataTag) (func() DlmsData, error) {
-	factory, ok := dataFactoryMap[tag]
-	if !ok {
+// dataClasses is the dispatch table in use, the standard one with the
+// registered data types. It is replaced as a whole on registration, so that
+// decoding reads it without locking.
+var (
+	dataClasses      atomic.Pointer[[256]func() DlmsData]
+	dataClassesMutex sync.Mutex
+)
+
+func init() {
+	dataClasses.Store(&standardDataClasses)
+}
+
+// RegisterDataClass registers the data type of a manufacturer specific tag,
+// so that structures and arrays containing it can be decoded. The A-XDR
+// decoder reads as many bytes as GetLength of the data type tells, or a
+// length first for VariableLength. The standard data types can't be replaced.
+func RegisterDataClass(tag DlmsDataTag, factory func() DlmsData) error {
+	if factory == nil {
+		return fmt.Errorf("no factory for DLMS data tag %d", tag)
+	}
+	if standardDataClasses[tag] != nil {
+		return fmt.Errorf("DLMS data tag %d is a standard data type", tag)
+	}
+
+	dataClassesMutex.Lock()
+	defer dataClassesMutex.Unlock()
+
+	classes := *dataClasses.Load()
+	classes[tag] = factory
+	dataClasses.Store(&classes)
+	return nil
+}
+
+// UnregisterDataClass removes the data type registered for a tag, e.g. when
+// the meter it was registered for is no longer read. Standard data types are
+// kept.
+func UnregisterDataClass(tag DlmsDataTag) {
+	if standardDataClasses[tag] != nil {
+		return
+	}
+
+	dataClassesMutex.Lock()
+	defer dataClassesMutex.Unlock()
+
+	classes := *dataClasses.Load()
+	classes[tag] = nil
+	dataClasses.Store(&classes)
+}
+
+// DataClass returns a factory function for the given tag
+func DataClass(tag DlmsDataTag) (func() DlmsData, error) {
+	factory := dataClasses.Load()[tag]
+	if factory == nil {
 		return nil, fmt.Errorf("unknown DLMS data tag: %d", tag)
 	}
 	return factory, nil
 }
 
+// GetDataClass returns a factory function for the given tag
+func (f *DlmsDataFactory) GetDataClass(tag DlmsDataTag) (func() DlmsData, error) {
+	return DataClass(tag)
+}
+
 // NewDlmsDataFactory creates a new DlmsDataFactory
 func NewDlmsDataFactory() *DlmsDataFactory {
 	return &DlmsDataFactory{}
 }
-
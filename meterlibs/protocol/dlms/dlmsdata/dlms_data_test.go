@@ -0,0 +1,112 @@
+package dlmsdata_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+)
+
+func decodeHexString(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+func TestDecodeVariableInteger(t *testing.T) {
+	for _, test := range []struct {
+		encoded string
+		length  int
+		rest    string
+	}{
+		{"7F01", 127, "01"},
+		{"8180", 128, ""},
+		{"820100", 256, ""},
+		// leading zeros that fit once dropped
+		{"850000000102", 258, ""},
+	} {
+		t.Run(test.encoded, func(t *testing.T) {
+			length, rest, err := dlmsdata.DecodeVariableInteger(decodeHexString(test.encoded))
+			require.NoError(t, err)
+			assert.Equal(t, test.length, length)
+			assert.Equal(t, decodeHexString(test.rest), rest)
+		})
+	}
+}
+
+func TestDecodeVariableInteger_DeclaredLengthErrors(t *testing.T) {
+	previous, err := dlmsdata.SetMaxDeclaredLength(1024)
+	require.NoError(t, err)
+	t.Cleanup(func() { dlmsdata.SetMaxDeclaredLength(previous) })
+
+	for _, test := range []struct {
+		name    string
+		encoded string
+	}{
+		{"length of length 0", "80"},
+		{"length of length above 4 bytes", "850100000000"},
+		{"length above the limit", "820401"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := dlmsdata.DecodeVariableInteger(decodeHexString(test.encoded))
+			var declaredLengthError *exceptions.DeclaredLengthError
+			require.ErrorAs(t, err, &declaredLengthError)
+			assert.Equal(t, 1024, declaredLengthError.MaxLength)
+		})
+	}
+
+	length, _, err := dlmsdata.DecodeVariableInteger(decodeHexString("820400"))
+	require.NoError(t, err)
+	assert.Equal(t, 1024, length)
+}
+
+func TestDecodeVariableInteger_FourByteLengths(t *testing.T) {
+	for _, test := range []struct {
+		encoded  string
+		declared int
+	}{
+		// negative once converted to a 32-bit int
+		{"8480000000", -1},
+		{"84FFFFFFFF", -1},
+		{"8401000001", 0x01000001},
+	} {
+		t.Run(test.encoded, func(t *testing.T) {
+			_, _, err := dlmsdata.DecodeVariableInteger(decodeHexString(test.encoded))
+			var declaredLengthError *exceptions.DeclaredLengthError
+			require.ErrorAs(t, err, &declaredLengthError)
+			assert.Equal(t, test.declared, declaredLengthError.Length)
+			assert.Equal(t, dlmsdata.DefaultMaxDeclaredLength, declaredLengthError.MaxLength)
+			assert.False(t, declaredLengthError.Truncated)
+		})
+	}
+}
+
+func TestDecode_ItemCountAboveRemainingBytes(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		encoded string
+		data    dlmsdata.DlmsData
+	}{
+		{"array", "01031101", &dlmsdata.DataArray{}},
+		{"structure", "02031101", &dlmsdata.DataStructure{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.data.FromBytes(decodeHexString(test.encoded))
+			var declaredLengthError *exceptions.DeclaredLengthError
+			require.ErrorAs(t, err, &declaredLengthError)
+			assert.Equal(t, 3, declaredLengthError.Length)
+			assert.True(t, declaredLengthError.Truncated)
+			assert.Equal(t, 2, declaredLengthError.Remaining)
+			assert.Equal(t, dlmsdata.DefaultMaxDeclaredLength, declaredLengthError.MaxLength)
+		})
+	}
+}
+
+func TestSetMaxDeclaredLength(t *testing.T) {
+	assert.Equal(t, dlmsdata.DefaultMaxDeclaredLength, dlmsdata.MaxDeclaredLength())
+	_, err := dlmsdata.SetMaxDeclaredLength(0)
+	assert.Error(t, err)
+	assert.Equal(t, dlmsdata.DefaultMaxDeclaredLength, dlmsdata.MaxDeclaredLength())
+}
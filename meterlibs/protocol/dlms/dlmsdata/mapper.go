@@ -0,0 +1,343 @@
+package dlmsdata
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The mapper converts structures to Go structs and back, so the payloads of
+// the object configurations can be defined as plain structs. The fields are
+// mapped with a dlms tag giving the index of the element in the structure,
+// starting at 1, and optionally its data type when the Go type is ambiguous:
+//
+//	type PushDestination struct {
+//		TransportService Enum   `dlms:"1"`
+//		Destination      []byte `dlms:"2"`
+//		Message          uint8  `dlms:"3,enum"`
+//	}
+//
+// The Go types map to the data types as follows: bool boolean, int8 integer,
+// int16 long, int32 double-long, int64 long64, uint8 unsigned, uint16
+// long-unsigned, uint32 double-long-unsigned, uint64 long64-unsigned, Enum
+// enum, float32 float32, float64 float64, string visible-string, []byte
+// octet-string, *BitString bit-string, structs structure and other slices
+// array. A nil pointer is null-data. The only data type given in the tag is
+// enum, for an unsigned integer field.
+
+// mapperTag is the tag of the mapped struct fields
+const mapperTag = "dlms"
+
+// mappedField is a field of a struct with its element index and data type
+type mappedField struct {
+	name     string
+	index    int
+	dataType string
+	field    int
+}
+
+// mappedFields returns the tagged fields of a struct type ordered by index.
+// The indexes must start at 1 and have no gaps.
+func mappedFields(t reflect.Type) ([]mappedField, error) {
+	var fields []mappedField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup(mapperTag)
+		if !ok || tag == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			return nil, fmt.Errorf("%s.%s is not exported", t.Name(), field.Name)
+		}
+		indexPart, dataType, _ := strings.Cut(tag, ",")
+		index, err := strconv.Atoi(indexPart)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: invalid index %q", t.Name(), field.Name, indexPart)
+		}
+		fields = append(fields, mappedField{name: field.Name, index: index, dataType: dataType, field: i})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].index < fields[j].index })
+	for i, field := range fields {
+		if field.index != i+1 {
+			return nil, fmt.Errorf("%s.%s has index %d, expected %d", t.Name(), field.name, field.index, i+1)
+		}
+	}
+	return fields, nil
+}
+
+var (
+	enumType      = reflect.TypeOf(Enum(0))
+	bytesType     = reflect.TypeOf([]byte(nil))
+	bitStringType = reflect.TypeOf((*BitString)(nil))
+)
+
+// Marshal converts a struct, or a pointer to a struct, with dlms tags to a structure
+func Marshal(v interface{}) (*DataStructure, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, fmt.Errorf("can't marshal a nil %T", v)
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't marshal %T, expected a struct", v)
+	}
+	return marshalStruct(value)
+}
+
+func marshalStruct(value reflect.Value) (*DataStructure, error) {
+	fields, err := mappedFields(value.Type())
+	if err != nil {
+		return nil, err
+	}
+	items := make([]DlmsData, 0, len(fields))
+	for _, field := range fields {
+		item, err := marshalValue(value.Field(field.field), field.dataType)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		items = append(items, item)
+	}
+	return NewDataStructure(items), nil
+}
+
+func marshalValue(value reflect.Value, dataType string) (DlmsData, error) {
+	switch dataType {
+	case "":
+	case "enum":
+		switch value.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+			if value.Uint() > 0xFF {
+				return nil, fmt.Errorf("enum value %d is larger than 255", value.Uint())
+			}
+			return NewEnumData(Enum(value.Uint())), nil
+		}
+		return nil, fmt.Errorf("enum needs an unsigned integer, got %s", value.Type())
+	default:
+		return nil, fmt.Errorf("unknown data type %q", dataType)
+	}
+
+	switch value.Type() {
+	case enumType:
+		return NewEnumData(Enum(value.Uint())), nil
+	case bytesType:
+		return NewOctetStringData(value.Bytes()), nil
+	case bitStringType:
+		if value.IsNil() {
+			return NewNullData(), nil
+		}
+		return NewBitStringData(value.Interface().(*BitString)), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return NewBooleanData(value.Bool()), nil
+	case reflect.Int8:
+		return NewIntegerData(int8(value.Int())), nil
+	case reflect.Int16:
+		return NewLongData(int16(value.Int())), nil
+	case reflect.Int32:
+		return NewDoubleLongData(int32(value.Int())), nil
+	case reflect.Int64:
+		return NewLong64Data(value.Int()), nil
+	case reflect.Uint8:
+		return NewUnsignedIntegerData(uint8(value.Uint())), nil
+	case reflect.Uint16:
+		return NewUnsignedLongData(uint16(value.Uint())), nil
+	case reflect.Uint32:
+		return NewDoubleLongUnsignedData(uint32(value.Uint())), nil
+	case reflect.Uint64:
+		return NewUnsignedLong64Data(value.Uint()), nil
+	case reflect.Float32:
+		return NewFloat32Data(float32(value.Float())), nil
+	case reflect.Float64:
+		return NewFloat64Data(value.Float()), nil
+	case reflect.String:
+		return NewVisibleStringData(value.String()), nil
+	case reflect.Struct:
+		return marshalStruct(value)
+	case reflect.Ptr:
+		if value.IsNil() {
+			return NewNullData(), nil
+		}
+		return marshalValue(value.Elem(), "")
+	case reflect.Slice:
+		items := make([]DlmsData, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			item, err := marshalValue(value.Index(i), "")
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			items = append(items, item)
+		}
+		return NewDataArray(items), nil
+	}
+	return nil, fmt.Errorf("can't marshal %s", value.Type())
+}
+
+// Unmarshal fills a struct with dlms tags from a structure. The structure is
+// a DlmsData or a decoded value, e.g. the []interface{} of
+// encoding.DecodeValue. The elements of the structure are checked against
+// the types of the fields, integers must fit. Null and don't-care elements
+// leave their field unchanged.
+func Unmarshal(data interface{}, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("can't unmarshal into %T, expected a pointer to a struct", v)
+	}
+	if d, ok := data.(DlmsData); ok {
+		data = d.ToPython()
+	}
+	return unmarshalStruct(data, value.Elem())
+}
+
+func unmarshalStruct(data interface{}, value reflect.Value) error {
+	items, ok := data.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected a structure for %s, got %T", value.Type(), data)
+	}
+	fields, err := mappedFields(value.Type())
+	if err != nil {
+		return err
+	}
+	if len(items) != len(fields) {
+		return fmt.Errorf("%s has %d fields, the structure has %d elements", value.Type(), len(fields), len(items))
+	}
+	for i, field := range fields {
+		if err := unmarshalValue(items[i], value.Field(field.field)); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+func unmarshalValue(data interface{}, value reflect.Value) error {
+	switch data.(type) {
+	case nil, DontCare:
+		return nil
+	}
+
+	switch value.Type() {
+	case bytesType:
+		b, ok := data.([]byte)
+		if !ok {
+			return fmt.Errorf("expected an octet-string, got %T", data)
+		}
+		value.SetBytes(append([]byte(nil), b...))
+		return nil
+	case bitStringType:
+		b, ok := data.(*BitString)
+		if !ok {
+			return fmt.Errorf("expected a bit-string, got %T", data)
+		}
+		value.Set(reflect.ValueOf(b))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		b, ok := data.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean, got %T", data)
+		}
+		value.SetBool(b)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		n, ok := integerValue(data)
+		if !ok || !n.negative && n.value > 1<<63-1 || value.OverflowInt(n.int64()) {
+			return fmt.Errorf("%v (%T) does not fit in %s", data, data, value.Type())
+		}
+		value.SetInt(n.int64())
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		n, ok := integerValue(data)
+		if !ok || n.negative || value.OverflowUint(n.value) {
+			return fmt.Errorf("%v (%T) does not fit in %s", data, data, value.Type())
+		}
+		value.SetUint(n.value)
+	case reflect.Float32, reflect.Float64:
+		switch f := data.(type) {
+		case float32:
+			value.SetFloat(float64(f))
+		case float64:
+			value.SetFloat(f)
+		default:
+			return fmt.Errorf("expected a float, got %T", data)
+		}
+	case reflect.String:
+		s, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %T", data)
+		}
+		value.SetString(s)
+	case reflect.Struct:
+		return unmarshalStruct(data, value)
+	case reflect.Ptr:
+		element := reflect.New(value.Type().Elem())
+		if err := unmarshalValue(data, element.Elem()); err != nil {
+			return err
+		}
+		value.Set(element)
+	case reflect.Slice:
+		items, ok := data.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected an array for %s, got %T", value.Type(), data)
+		}
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := unmarshalValue(item, slice.Index(i)); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		value.Set(slice)
+	default:
+		return fmt.Errorf("can't unmarshal into %s", value.Type())
+	}
+	return nil
+}
+
+// integer is a decoded integer of any size, value is the absolute value of
+// the negative integers
+type integer struct {
+	value    uint64
+	negative bool
+}
+
+func (n integer) int64() int64 {
+	if n.negative {
+		return -int64(n.value)
+	}
+	return int64(n.value)
+}
+
+// integerValue returns the decoded integer types and enums as an integer
+func integerValue(data interface{}) (integer, bool) {
+	var signedValue int64
+	switch v := data.(type) {
+	case uint8:
+		return integer{value: uint64(v)}, true
+	case uint16:
+		return integer{value: uint64(v)}, true
+	case uint32:
+		return integer{value: uint64(v)}, true
+	case uint64:
+		return integer{value: v}, true
+	case Enum:
+		return integer{value: uint64(v)}, true
+	case int8:
+		signedValue = int64(v)
+	case int16:
+		signedValue = int64(v)
+	case int32:
+		signedValue = int64(v)
+	case int64:
+		signedValue = v
+	default:
+		return integer{}, false
+	}
+	if signedValue < 0 {
+		return integer{value: uint64(-signedValue), negative: true}, true
+	}
+	return integer{value: uint64(signedValue)}, true
+}
@@ -115,7 +115,7 @@ func TimeFromBytes(data []byte) (time.Time, error) {
 	}
 	
 	// Use a reference date (2000-01-01) for time-only values
-	refDate := time.Date(2000, 1, 1, int(hour), int(minute), int(second), int(hundredths)*10000, time.UTC)
+	refDate := time.Date(2000, 1, 1, int(hour), int(minute), int(second), int(hundredths)*10000000, time.UTC)
 	return refDate, nil
 }
 
@@ -186,7 +186,7 @@ func DateTimeFromBytes(data []byte) (time.Time, *ClockStatus, error) {
 		int(hour),
 		int(minute),
 		int(second),
-		int(hundredths)*10000,
+		int(hundredths)*10000000,
 		tz,
 	)
 	
@@ -253,3 +253,275 @@ func DateTimeToBytes(dt time.Time, clockStatus *ClockStatus) []byte {
 	return result
 }
 
+
+const (
+	// NotSpecified is the wildcard of the date and time fields
+	NotSpecified = 0xFF
+	// YearNotSpecified is the wildcard of the year
+	YearNotSpecified = 0xFFFF
+
+	// MonthDaylightSavingsEnd is the month daylight savings end
+	MonthDaylightSavingsEnd = 0xFD
+	// MonthDaylightSavingsBegin is the month daylight savings begin
+	MonthDaylightSavingsBegin = 0xFE
+	// DaySecondLastOfMonth is the 2nd last day of the month
+	DaySecondLastOfMonth = 0xFD
+	// DayLastOfMonth is the last day of the month
+	DayLastOfMonth = 0xFE
+)
+
+// CosemDate is a date as sent in octet-strings, where any field can be a
+// wildcard. Unlike DateFromBytes it keeps the fields as they are.
+type CosemDate struct {
+	Year       uint16
+	Month      uint8
+	DayOfMonth uint8
+	// DayOfWeek is 1 for Monday to 7 for Sunday
+	DayOfWeek uint8
+}
+
+// NewCosemDate creates a new CosemDate
+func NewCosemDate(year uint16, month, dayOfMonth, dayOfWeek uint8) *CosemDate {
+	return &CosemDate{
+		Year:       year,
+		Month:      month,
+		DayOfMonth: dayOfMonth,
+		DayOfWeek:  dayOfWeek,
+	}
+}
+
+// NewCosemDateFromTime creates a CosemDate with all fields of the date of t
+func NewCosemDateFromTime(t time.Time) *CosemDate {
+	dayOfWeek := uint8(t.Weekday())
+	if dayOfWeek == 0 {
+		dayOfWeek = 7
+	}
+	return NewCosemDate(uint16(t.Year()), uint8(t.Month()), uint8(t.Day()), dayOfWeek)
+}
+
+// FromBytes creates a CosemDate from 5 bytes
+func (d *CosemDate) FromBytes(data []byte) (*CosemDate, error) {
+	if len(data) != 5 {
+		return nil, fmt.Errorf("date is represented by 5 bytes, but got %d", len(data))
+	}
+	result := NewCosemDate(binary.BigEndian.Uint16(data[:2]), data[2], data[3], data[4])
+	if err := result.validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ToBytes converts CosemDate to 5 bytes
+func (d *CosemDate) ToBytes() []byte {
+	result := make([]byte, 5)
+	binary.BigEndian.PutUint16(result[:2], d.Year)
+	result[2] = d.Month
+	result[3] = d.DayOfMonth
+	result[4] = d.DayOfWeek
+	return result
+}
+
+// HasWildcard returns true when the year, the month or the day of month is
+// not a fixed value. The day of week is not taken into account.
+func (d *CosemDate) HasWildcard() bool {
+	return d.Year == YearNotSpecified || d.Month > 12 || d.DayOfMonth > 31
+}
+
+// String implements fmt.Stringer, wildcards are shown as *
+func (d *CosemDate) String() string {
+	year := "*"
+	if d.Year != YearNotSpecified {
+		year = fmt.Sprintf("%04d", d.Year)
+	}
+	return fmt.Sprintf("%s-%s-%s", year, dateField(d.Month, 12), dateField(d.DayOfMonth, 31))
+}
+
+func (d *CosemDate) validate() error {
+	if d.Month == 0 || (d.Month > 12 && d.Month < MonthDaylightSavingsEnd) {
+		return fmt.Errorf("invalid month %d", d.Month)
+	}
+	if d.DayOfMonth == 0 || (d.DayOfMonth > 31 && d.DayOfMonth < DaySecondLastOfMonth) {
+		return fmt.Errorf("invalid day of month %d", d.DayOfMonth)
+	}
+	if d.DayOfWeek == 0 || (d.DayOfWeek > 7 && d.DayOfWeek != NotSpecified) {
+		return fmt.Errorf("invalid day of week %d", d.DayOfWeek)
+	}
+	return nil
+}
+
+// CosemTime is a time as sent in octet-strings, where any field can be a
+// wildcard. Unlike TimeFromBytes it keeps the fields as they are.
+type CosemTime struct {
+	Hour       uint8
+	Minute     uint8
+	Second     uint8
+	Hundredths uint8
+}
+
+// NewCosemTime creates a new CosemTime
+func NewCosemTime(hour, minute, second, hundredths uint8) *CosemTime {
+	return &CosemTime{
+		Hour:       hour,
+		Minute:     minute,
+		Second:     second,
+		Hundredths: hundredths,
+	}
+}
+
+// FromBytes creates a CosemTime from 4 bytes
+func (t *CosemTime) FromBytes(data []byte) (*CosemTime, error) {
+	if len(data) != 4 {
+		return nil, fmt.Errorf("time is represented by 4 bytes, but got %d", len(data))
+	}
+	result := NewCosemTime(data[0], data[1], data[2], data[3])
+	if err := result.validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ToBytes converts CosemTime to 4 bytes
+func (t *CosemTime) ToBytes() []byte {
+	return []byte{t.Hour, t.Minute, t.Second, t.Hundredths}
+}
+
+// HasWildcard returns true when any field is not specified
+func (t *CosemTime) HasWildcard() bool {
+	return t.Hour == NotSpecified || t.Minute == NotSpecified || t.Second == NotSpecified || t.Hundredths == NotSpecified
+}
+
+// String implements fmt.Stringer, wildcards are shown as *
+func (t *CosemTime) String() string {
+	return fmt.Sprintf("%s:%s:%s.%s",
+		dateField(t.Hour, 23), dateField(t.Minute, 59), dateField(t.Second, 59), dateField(t.Hundredths, 99))
+}
+
+func (t *CosemTime) validate() error {
+	fields := []struct {
+		name  string
+		value uint8
+		limit uint8
+	}{
+		{"hour", t.Hour, 23},
+		{"minute", t.Minute, 59},
+		{"second", t.Second, 59},
+		{"hundredths", t.Hundredths, 99},
+	}
+	for _, field := range fields {
+		if field.value > field.limit && field.value != NotSpecified {
+			return fmt.Errorf("invalid %s %d", field.name, field.value)
+		}
+	}
+	return nil
+}
+
+// DeviationNotSpecified is the deviation of a date-time in local time
+const DeviationNotSpecified int16 = -0x8000
+
+// ClockStatusNotSpecified is the clock status byte of a date-time without status
+const ClockStatusNotSpecified = 0xFF
+
+// CosemDateTime is a date-time as sent in octet-strings. Unlike
+// DateTimeFromBytes it keeps the wildcards, whether the deviation was given
+// and the clock status.
+type CosemDateTime struct {
+	Date *CosemDate
+	Time *CosemTime
+	// Deviation is the difference in minutes from local time to UTC, or
+	// DeviationNotSpecified
+	Deviation int16
+	// ClockStatus is nil when not specified
+	ClockStatus *ClockStatus
+}
+
+// NewCosemDateTime creates a new CosemDateTime
+func NewCosemDateTime(date *CosemDate, time *CosemTime, deviation int16, clockStatus *ClockStatus) *CosemDateTime {
+	return &CosemDateTime{
+		Date:        date,
+		Time:        time,
+		Deviation:   deviation,
+		ClockStatus: clockStatus,
+	}
+}
+
+// FromBytes creates a CosemDateTime from 12 bytes
+func (d *CosemDateTime) FromBytes(data []byte) (*CosemDateTime, error) {
+	if len(data) != 12 {
+		return nil, fmt.Errorf("datetime is represented by 12 bytes, but got %d", len(data))
+	}
+	date, err := (&CosemDate{}).FromBytes(data[:5])
+	if err != nil {
+		return nil, fmt.Errorf("date: %w", err)
+	}
+	time, err := (&CosemTime{}).FromBytes(data[5:9])
+	if err != nil {
+		return nil, fmt.Errorf("time: %w", err)
+	}
+	var status *ClockStatus
+	if data[11] != ClockStatusNotSpecified {
+		status, _ = (&ClockStatus{}).FromBytes(data[11:12])
+	}
+	return NewCosemDateTime(date, time, int16(binary.BigEndian.Uint16(data[9:11])), status), nil
+}
+
+// ToBytes converts CosemDateTime to 12 bytes
+func (d *CosemDateTime) ToBytes() []byte {
+	result := append(d.Date.ToBytes(), d.Time.ToBytes()...)
+	result = binary.BigEndian.AppendUint16(result, uint16(d.Deviation))
+	if d.ClockStatus == nil {
+		return append(result, ClockStatusNotSpecified)
+	}
+	return append(result, d.ClockStatus.ToBytes()...)
+}
+
+// DeviationSpecified tells whether the date-time gives its deviation from UTC
+func (d *CosemDateTime) DeviationSpecified() bool {
+	return d.Deviation != DeviationNotSpecified
+}
+
+// ToTime converts the date-time like DateTimeFromBytes: the time fields not
+// specified are 0 and a date-time without deviation is taken as UTC
+func (d *CosemDateTime) ToTime() (time.Time, error) {
+	return d.ToTimeIn(time.UTC)
+}
+
+// ToTimeIn converts the date-time, taking it in location when it has no
+// deviation. The time fields not specified are 0.
+func (d *CosemDateTime) ToTimeIn(location *time.Location) (time.Time, error) {
+	if d.Date.HasWildcard() {
+		return time.Time{}, fmt.Errorf("date %s contains unspecified values", d.Date)
+	}
+	if d.DeviationSpecified() {
+		location = time.FixedZone("", -int(d.Deviation)*60)
+	}
+	field := func(value uint8) int {
+		if value == NotSpecified {
+			return 0
+		}
+		return int(value)
+	}
+	return time.Date(int(d.Date.Year), time.Month(d.Date.Month), int(d.Date.DayOfMonth),
+		field(d.Time.Hour), field(d.Time.Minute), field(d.Time.Second), field(d.Time.Hundredths)*10000000, location), nil
+}
+
+// String implements fmt.Stringer, wildcards are shown as *
+func (d *CosemDateTime) String() string {
+	deviation := "local"
+	if d.DeviationSpecified() {
+		deviation = fmt.Sprintf("%+d", d.Deviation)
+	}
+	status := "none"
+	if d.ClockStatus != nil {
+		status = fmt.Sprintf("0x%02x", d.ClockStatus.ToBytes()[0])
+	}
+	return fmt.Sprintf("%s %s deviation=%s status=%s", d.Date, d.Time, deviation, status)
+}
+
+// dateField formats a field of a date or time, values above limit are special
+// values or wildcards
+func dateField(value uint8, limit uint8) string {
+	if value > limit {
+		return "*"
+	}
+	return fmt.Sprintf("%02d", value)
+}
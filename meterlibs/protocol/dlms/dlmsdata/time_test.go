@@ -0,0 +1,45 @@
+package dlmsdata_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+)
+
+func TestTimeFromBytes_Hundredths(t *testing.T) {
+	for _, test := range []struct {
+		encoded    string
+		nanosecond int
+	}{
+		{"0C001E00", 0},
+		{"0C001E01", 10 * int(time.Millisecond)},
+		{"0C001E19", 250 * int(time.Millisecond)},
+		{"0C001E63", 990 * int(time.Millisecond)},
+		// not specified
+		{"0C001EFF", 0},
+	} {
+		t.Run(test.encoded, func(t *testing.T) {
+			parsed, err := dlmsdata.TimeFromBytes(decodeHexString(test.encoded))
+			require.NoError(t, err)
+			assert.Equal(t, 30, parsed.Second())
+			assert.Equal(t, test.nanosecond, parsed.Nanosecond())
+		})
+	}
+
+	encoded := decodeHexString("0C001E19")
+	parsed, err := dlmsdata.TimeFromBytes(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, encoded, dlmsdata.TimeToBytes(parsed))
+}
+
+func TestDateTimeFromBytes_Hundredths(t *testing.T) {
+	encoded := decodeHexString("07EA0A1106" + "0C001E19" + "8000" + "00")
+	parsed, _, err := dlmsdata.DateTimeFromBytes(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, 30, parsed.Second())
+	assert.Equal(t, 250*int(time.Millisecond), parsed.Nanosecond())
+	assert.Equal(t, decodeHexString("0C001E19"), dlmsdata.TimeToBytes(parsed))
+}
@@ -4,36 +4,19 @@ import (
 	"fmt"
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
 )
 
 const VariableLength = -1
 
 // GetAXdrLength finds the length of an XADR element assuming the length is the first bytes
 // Works with bytearray and will remove element from the array as it finds the variable length.
+// Lengths above dlmsdata.MaxDeclaredLength() are rejected.
 func GetAXdrLength(data []byte) (int, []byte, error) {
 	if len(data) == 0 {
 		return 0, nil, fmt.Errorf("insufficient data for AXDR length")
 	}
-	
-	firstByte := data[0]
-	lengthIsMultipleBytes := (firstByte & 0b10000000) != 0
-	
-	if !lengthIsMultipleBytes {
-		return int(firstByte), data[1:], nil
-	}
-	
-	numberOfBytes := int(firstByte & 0b01111111)
-	if len(data) < numberOfBytes+1 {
-		return 0, nil, fmt.Errorf("insufficient data for AXDR length: need %d bytes, got %d", numberOfBytes+1, len(data))
-	}
-	
-	lengthBytes := data[1 : numberOfBytes+1]
-	length := 0
-	for _, b := range lengthBytes {
-		length = (length << 8) | int(b)
-	}
-	
-	return length, data[numberOfBytes+1:], nil
+	return dlmsdata.DecodeVariableInteger(data)
 }
 
 // Attribute represents an attribute in encoding configuration
@@ -58,9 +41,19 @@ type Choice struct {
 	Choices map[byte]interface{} // byte -> Attribute or Sequence
 }
 
+// DlmsDataChoice represents a single DLMS data element where the type is selected
+// by the data tag in the buffer. Fixed attributes can follow it in the same config.
+// Raw keeps the encoded bytes of the element instead of its value, the element
+// is still decoded to find its end and check it.
+type DlmsDataChoice struct {
+	AttributeName string
+	Optional      bool
+	Raw           bool
+}
+
 // EncodingConf represents encoding configuration
 type EncodingConf struct {
-	Attributes []interface{} // Attribute, Sequence, or Choice
+	Attributes []interface{} // Attribute, Sequence, Choice or DlmsDataChoice
 }
 
 // AXdrDecoder decodes A-XDR encoded data
@@ -139,6 +132,12 @@ func (a *AXdrDecoder) DecodeSingle(dataType interface{}, index int) (map[string]
 		return a.DecodeSingle(choice, index)
 	case *Sequence:
 		return a.DecodeSequence(t)
+	case *DlmsDataChoice:
+		value, err := a.DecodeDlmsDataChoice(t)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{t.AttributeName: value}, nil
 	default:
 		return nil, fmt.Errorf("no valid class type")
 	}
@@ -185,7 +184,7 @@ func (a *AXdrDecoder) DecodeAttribute(attribute *Attribute, index int) (interfac
 	}
 	
 	// We know how to create the instance (just not how long it is)
-	length, _, err := GetAXdrLength(a.GetBufferTail())
+	length, err := a.GetAXdrLength()
 	if err != nil {
 		return nil, err
 	}
@@ -196,6 +195,31 @@ func (a *AXdrDecoder) DecodeAttribute(attribute *Attribute, index int) (interfac
 	return attribute.CreateInstance(data)
 }
 
+// DecodeDlmsDataChoice decodes a DLMS data element by dispatching on its tag
+func (a *AXdrDecoder) DecodeDlmsDataChoice(choice *DlmsDataChoice) (interface{}, error) {
+	if choice.Optional {
+		indicator, err := a.GetBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		if indicator[0] == 0x00 {
+			// Not used
+			return nil, nil
+		}
+	}
+	start := a.Pointer
+	value, err := a.DecodeSequenceOf()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", choice.AttributeName, err)
+	}
+	if choice.Raw {
+		raw := make([]byte, a.Pointer-start)
+		copy(raw, a.Buffer[start:a.Pointer])
+		return raw, nil
+	}
+	return value, nil
+}
+
 // DecodeSequence decodes a sequence
 func (a *AXdrDecoder) DecodeSequence(seq *Sequence) (map[string]interface{}, error) {
 	parsedData := make([]interface{}, 0)
@@ -206,7 +230,7 @@ func (a *AXdrDecoder) DecodeSequence(seq *Sequence) (map[string]interface{}, err
 			return nil, err
 		}
 		
-		dataClass, err := dlmsdata.NewDlmsDataFactory().GetDataClass(dlmsdata.DlmsDataTag(tag[0]))
+		dataClass, err := dlmsdata.DataClass(dlmsdata.DlmsDataTag(tag[0]))
 		if err != nil {
 			return nil, err
 		}
@@ -230,33 +254,11 @@ func (a *AXdrDecoder) DecodeSequence(seq *Sequence) (map[string]interface{}, err
 			continue
 		}
 		
-		if instance.GetLength() != VariableLength {
-			data, err := a.GetBytes(instance.GetLength())
-			if err != nil {
-				return nil, err
-			}
-			decoded, err := instance.FromBytes(data)
-			if err != nil {
-				return nil, err
-			}
-			parsedData = append(parsedData, decoded.ToPython())
-			continue
-		}
-		
-		// Variable length
-		length, _, err := GetAXdrLength(a.GetBufferTail())
+		value, err := a.DecodeData(dataClass)
 		if err != nil {
 			return nil, err
 		}
-		data, err := a.GetBytes(length)
-		if err != nil {
-			return nil, err
-		}
-		decoded, err := instance.FromBytes(data)
-		if err != nil {
-			return nil, err
-		}
-		parsedData = append(parsedData, decoded.ToPython())
+		parsedData = append(parsedData, value)
 	}
 	
 	if len(parsedData) == 1 {
@@ -268,10 +270,16 @@ func (a *AXdrDecoder) DecodeSequence(seq *Sequence) (map[string]interface{}, err
 
 // DecodeArray decodes an array
 func (a *AXdrDecoder) DecodeArray() ([]interface{}, error) {
-	itemCount, _, err := GetAXdrLength(a.GetBufferTail())
+	itemCount, err := a.GetAXdrLength()
 	if err != nil {
 		return nil, err
 	}
+	// Every element is at least one byte
+	if itemCount > len(a.GetBufferTail()) {
+		return nil, exceptions.NewTruncatedLengthError(
+			fmt.Sprintf("%d elements declared but only %d bytes remain", itemCount, len(a.GetBufferTail())),
+			itemCount, len(a.GetBufferTail()), dlmsdata.MaxDeclaredLength())
+	}
 	
 	elements := make([]interface{}, 0, itemCount)
 	for i := 0; i < itemCount; i++ {
@@ -286,10 +294,16 @@ func (a *AXdrDecoder) DecodeArray() ([]interface{}, error) {
 
 // DecodeStructure decodes a structure
 func (a *AXdrDecoder) DecodeStructure() ([]interface{}, error) {
-	itemCount, _, err := GetAXdrLength(a.GetBufferTail())
+	itemCount, err := a.GetAXdrLength()
 	if err != nil {
 		return nil, err
 	}
+	// Every element is at least one byte
+	if itemCount > len(a.GetBufferTail()) {
+		return nil, exceptions.NewTruncatedLengthError(
+			fmt.Sprintf("%d elements declared but only %d bytes remain", itemCount, len(a.GetBufferTail())),
+			itemCount, len(a.GetBufferTail()), dlmsdata.MaxDeclaredLength())
+	}
 	
 	elements := make([]interface{}, 0, itemCount)
 	for i := 0; i < itemCount; i++ {
@@ -309,29 +323,36 @@ func (a *AXdrDecoder) DecodeSequenceOf() (interface{}, error) {
 		return nil, err
 	}
 	
-	dataClass, err := dlmsdata.NewDlmsDataFactory().GetDataClass(dlmsdata.DlmsDataTag(tag[0]))
-	if err != nil {
-		return nil, err
-	}
-	
-	instance := dataClass()
-	
-	switch instance.GetTag() {
+	switch dlmsdata.DlmsDataTag(tag[0]) {
 	case dlmsdata.TagArray:
 		return a.DecodeArray()
 	case dlmsdata.TagStructure:
 		return a.DecodeStructure()
-	default:
-		return a.DecodeData(instance)
 	}
+
+	dataClass, err := dlmsdata.DataClass(dlmsdata.DlmsDataTag(tag[0]))
+	if err != nil {
+		return nil, err
+	}
+	return a.DecodeData(dataClass)
 }
 
 // DecodeData decodes a single data element
 func (a *AXdrDecoder) DecodeData(dataClass func() dlmsdata.DlmsData) (interface{}, error) {
 	instance := dataClass()
 	
+	// the length of a bit-string is its number of bits
+	if instance.GetTag() == dlmsdata.TagBitString {
+		bitString, consumed, err := dlmsdata.DecodeBitString(a.GetBufferTail())
+		if err != nil {
+			return nil, err
+		}
+		a.Pointer += consumed
+		return bitString, nil
+	}
+
 	if instance.GetLength() == VariableLength {
-		length, _, err := GetAXdrLength(a.GetBufferTail())
+		length, err := a.GetAXdrLength()
 		if err != nil {
 			return nil, err
 		}
@@ -383,3 +404,55 @@ func (a *AXdrDecoder) GetAXdrLength() (int, error) {
 	return length, nil
 }
 
+// DecodeAllAttributes decodes the result of a GET of attribute 0, a structure with
+// the values of all attributes of an object. Element i is the value of attribute i+1.
+func DecodeAllAttributes(data []byte) ([]interface{}, error) {
+	if len(data) == 0 || dlmsdata.DlmsDataTag(data[0]) != dlmsdata.TagStructure {
+		return nil, fmt.Errorf("attribute 0 data is not a structure")
+	}
+
+	decoder := NewAXdrDecoder(&EncodingConf{
+		Attributes: []interface{}{&DlmsDataChoice{AttributeName: "attributes"}},
+	})
+	result, err := decoder.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode attribute 0: %w", err)
+	}
+	if !decoder.BufferEmpty() {
+		return nil, fmt.Errorf("%d bytes left after attribute 0 structure", len(decoder.GetBufferTail()))
+	}
+
+	attributes, ok := result["attributes"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("attribute 0 data is not a structure")
+	}
+	return attributes, nil
+}
+
+// ValueLength returns the length of the A-XDR encoded DLMS data value at the
+// start of data, e.g. to split a list of values of unknown types
+func ValueLength(data []byte) (int, error) {
+	decoder := NewAXdrDecoder(&EncodingConf{
+		Attributes: []interface{}{&DlmsDataChoice{AttributeName: "value"}},
+	})
+	if _, err := decoder.Decode(data); err != nil {
+		return 0, fmt.Errorf("failed to decode value: %w", err)
+	}
+	return decoder.Pointer, nil
+}
+
+// DecodeValue decodes a single A-XDR encoded DLMS data value, e.g. the body of
+// a DataNotification. Structures and arrays are returned as []interface{}.
+func DecodeValue(data []byte) (interface{}, error) {
+	decoder := NewAXdrDecoder(&EncodingConf{
+		Attributes: []interface{}{&DlmsDataChoice{AttributeName: "value"}},
+	})
+	result, err := decoder.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode value: %w", err)
+	}
+	if !decoder.BufferEmpty() {
+		return nil, fmt.Errorf("%d bytes left after value", len(decoder.GetBufferTail()))
+	}
+	return result["value"], nil
+}
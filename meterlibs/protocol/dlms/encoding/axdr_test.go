@@ -0,0 +1,326 @@
+package encoding_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+)
+
+var clock = []byte{0x07, 0xE6, 0x01, 0x01, 0x06, 0x00, 0x0F, 0x00, 0x00, 0x80, 0x00, 0x00}
+
+// profileBuffer encodes a profile buffer of rows with a clock and three values
+func profileBuffer(rows int) []byte {
+	data := append([]byte{byte(dlmsdata.TagArray)}, dlmsdata.EncodeVariableInteger(rows)...)
+	for i := 0; i < rows; i++ {
+		data = append(data, byte(dlmsdata.TagStructure), 4)
+		data = append(data, byte(dlmsdata.TagOctetString), byte(len(clock)))
+		data = append(data, clock...)
+		data = append(data, byte(dlmsdata.TagDoubleLongUnsigned))
+		data = binary.BigEndian.AppendUint32(data, uint32(i))
+		data = append(data, byte(dlmsdata.TagLongUnsigned))
+		data = binary.BigEndian.AppendUint16(data, uint16(i))
+		data = append(data, byte(dlmsdata.TagUnsigned), byte(i))
+	}
+	return data
+}
+
+func TestDecodeValue_ProfileBuffer(t *testing.T) {
+	value, err := encoding.DecodeValue(profileBuffer(1000))
+	require.NoError(t, err)
+
+	rows := value.([]interface{})
+	require.Len(t, rows, 1000)
+	assert.Equal(t, []interface{}{clock, uint32(999), uint16(999), uint8(231)}, rows[999])
+}
+
+func TestDecodeValue_Integer64(t *testing.T) {
+	// energy register above the 32 bit range, and a negative 64 bit value
+	value, err := encoding.DecodeValue([]byte{
+		byte(dlmsdata.TagStructure), 2,
+		byte(dlmsdata.TagLong64Unsigned), 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		byte(dlmsdata.TagLong64), 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{uint64(1) << 32, int64(-2)}, value)
+
+	encoded, err := dlmsdata.NewUnsignedLong64Data(1 << 32).ValueToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}, encoded)
+	encoded, err = dlmsdata.NewLong64Data(-2).ValueToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}, encoded)
+
+	_, err = encoding.DecodeValue([]byte{byte(dlmsdata.TagLong64), 0x00, 0x01})
+	assert.Error(t, err)
+}
+
+func TestDecodeValue_Float(t *testing.T) {
+	// instantaneous voltage as float32 and energy as float64
+	value, err := encoding.DecodeValue([]byte{
+		byte(dlmsdata.TagStructure), 2,
+		byte(dlmsdata.TagFloat32), 0x43, 0x66, 0x80, 0x00,
+		byte(dlmsdata.TagFloat64), 0xBF, 0xF8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{float32(230.5), float64(-1.5)}, value)
+
+	encoded, err := dlmsdata.NewFloat32Data(230.5).ValueToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x43, 0x66, 0x80, 0x00}, encoded)
+	encoded, err = dlmsdata.NewFloat64Data(-1.5).ValueToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xBF, 0xF8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, encoded)
+	assert.Equal(t, "230.5", dlmsdata.NewFloat32Data(230.5).String())
+
+	_, err = encoding.DecodeValue([]byte{byte(dlmsdata.TagFloat32), 0x43, 0x66})
+	assert.Error(t, err)
+}
+
+func TestDecodeValue_DontCare(t *testing.T) {
+	// a profile entry with the second column left out, and a trailing value
+	value, err := encoding.DecodeValue([]byte{
+		byte(dlmsdata.TagArray), 1,
+		byte(dlmsdata.TagStructure), 3,
+		byte(dlmsdata.TagUnsigned), 0x01,
+		byte(dlmsdata.TagDontCare),
+		byte(dlmsdata.TagNull),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{uint8(1), dlmsdata.DontCare{}, nil}}, value)
+
+	encoded, err := dlmsdata.NewDontCareData().ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{byte(dlmsdata.TagDontCare)}, encoded)
+}
+
+type mappedScript struct {
+	LogicalName []byte `dlms:"1"`
+	Selector    uint16 `dlms:"2"`
+}
+
+type mappedAction struct {
+	Script   mappedScript        `dlms:"2"`
+	Type     uint8               `dlms:"1,enum"`
+	Times    []int16             `dlms:"3"`
+	Status   *dlmsdata.BitString `dlms:"4"`
+	Optional *uint32             `dlms:"5"`
+	Name     string              `dlms:"6"`
+	Ignored  string
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	action := mappedAction{
+		Type:   3,
+		Script: mappedScript{LogicalName: []byte{0, 0, 10, 0, 1, 255}, Selector: 2},
+		Times:  []int16{-1, 300},
+		Status: dlmsdata.BitStringFromBools([]bool{true, false, true}),
+		Name:   "tariff",
+	}
+	structure, err := dlmsdata.Marshal(&action)
+	require.NoError(t, err)
+	encoded, err := structure.ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		byte(dlmsdata.TagStructure), 6,
+		byte(dlmsdata.TagEnum), 3,
+		byte(dlmsdata.TagStructure), 2,
+		byte(dlmsdata.TagOctetString), 6, 0, 0, 10, 0, 1, 255,
+		byte(dlmsdata.TagLongUnsigned), 0x00, 0x02,
+		byte(dlmsdata.TagArray), 2,
+		byte(dlmsdata.TagLong), 0xFF, 0xFF,
+		byte(dlmsdata.TagLong), 0x01, 0x2C,
+		byte(dlmsdata.TagBitString), 3, 0b10100000,
+		byte(dlmsdata.TagNull),
+		byte(dlmsdata.TagVisibleString), 6, 't', 'a', 'r', 'i', 'f', 'f',
+	}, encoded)
+
+	value, err := encoding.DecodeValue(encoded)
+	require.NoError(t, err)
+	var decoded mappedAction
+	require.NoError(t, dlmsdata.Unmarshal(value, &decoded))
+	assert.Equal(t, action, decoded)
+
+	var fromData mappedAction
+	require.NoError(t, dlmsdata.Unmarshal(structure, &fromData))
+	assert.Equal(t, action, fromData)
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	var script mappedScript
+	// selector doesn't fit in 16 bits
+	err := dlmsdata.Unmarshal([]interface{}{[]byte{1}, uint32(1 << 16)}, &script)
+	assert.ErrorContains(t, err, "Selector")
+	// negative selector
+	err = dlmsdata.Unmarshal([]interface{}{[]byte{1}, int8(-1)}, &script)
+	assert.Error(t, err)
+	// wrong number of elements
+	err = dlmsdata.Unmarshal([]interface{}{[]byte{1}}, &script)
+	assert.Error(t, err)
+	// not a pointer
+	err = dlmsdata.Unmarshal([]interface{}{[]byte{1}, uint16(1)}, script)
+	assert.Error(t, err)
+
+	type gap struct {
+		A uint8 `dlms:"1"`
+		B uint8 `dlms:"3"`
+	}
+	_, err = dlmsdata.Marshal(gap{})
+	assert.Error(t, err)
+}
+
+func TestDecodeValue_BitString(t *testing.T) {
+	// a 12 bits status word followed by a value, the bit-string length is in bits
+	value, err := encoding.DecodeValue([]byte{
+		byte(dlmsdata.TagStructure), 2,
+		byte(dlmsdata.TagBitString), 12, 0b10100000, 0b00010000,
+		byte(dlmsdata.TagUnsigned), 7,
+	})
+	require.NoError(t, err)
+	elements := value.([]interface{})
+	require.Len(t, elements, 2)
+	assert.Equal(t, uint8(7), elements[1])
+
+	status := elements[0].(*dlmsdata.BitString)
+	assert.Equal(t, 12, status.Length)
+	assert.Equal(t, "101000000001", status.String())
+	assert.Equal(t, []bool{true, false, true, false, false, false, false, false, false, false, false, true}, status.Bools())
+	number, err := status.Uint64()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0b101000000001), number)
+
+	fromNumber, err := dlmsdata.BitStringFromUint64(0b101000000001, 12)
+	require.NoError(t, err)
+	assert.Equal(t, status, fromNumber)
+	encoded, err := dlmsdata.NewBitStringData(fromNumber).ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{byte(dlmsdata.TagBitString), 12, 0b10100000, 0b00010000}, encoded)
+
+	_, err = dlmsdata.BitStringFromUint64(0x1000, 12)
+	assert.Error(t, err)
+	long, err := dlmsdata.NewBitString(72, make([]byte, 9))
+	require.NoError(t, err)
+	_, err = long.Uint64()
+	assert.Error(t, err)
+
+	_, err = encoding.DecodeValue([]byte{byte(dlmsdata.TagBitString), 12, 0xFF})
+	assert.Error(t, err)
+}
+
+func TestDecodeValue_UnknownTag(t *testing.T) {
+	_, err := encoding.DecodeValue([]byte{byte(dlmsdata.TagArray), 1, 0xFE})
+	assert.Error(t, err)
+}
+
+func TestDecodeDlmsDataChoice(t *testing.T) {
+	conf := &encoding.EncodingConf{
+		Attributes: []interface{}{
+			&encoding.DlmsDataChoice{AttributeName: "value", Optional: true},
+			&encoding.Attribute{
+				AttributeName:  "trailer",
+				Length:         1,
+				CreateInstance: func(data []byte) (interface{}, error) { return data[0], nil },
+			},
+		},
+	}
+	for _, test := range []struct {
+		name  string
+		data  []byte
+		value interface{}
+	}{
+		{"present", []byte{0x01, byte(dlmsdata.TagLongUnsigned), 0x00, 0xFF, 0x07}, uint16(255)},
+		{"absent", []byte{0x00, 0x07}, nil},
+		{"structure", []byte{0x01, byte(dlmsdata.TagStructure), 1, byte(dlmsdata.TagUnsigned), 3, 0x07}, []interface{}{uint8(3)}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := encoding.NewAXdrDecoder(conf).Decode(test.data)
+			require.NoError(t, err)
+			assert.Equal(t, test.value, result["value"])
+			assert.Equal(t, uint8(7), result["trailer"])
+		})
+	}
+
+	_, err := encoding.NewAXdrDecoder(conf).Decode([]byte{0x01, 0xFE, 0x00, 0x07})
+	assert.Error(t, err)
+}
+
+func TestDecodeDlmsDataChoice_Raw(t *testing.T) {
+	data := []byte{byte(dlmsdata.TagOctetString), 2, 0xAB, 0xCD, byte(dlmsdata.TagUnsigned), 9}
+	result, err := encoding.NewAXdrDecoder(&encoding.EncodingConf{
+		Attributes: []interface{}{
+			&encoding.DlmsDataChoice{AttributeName: "first", Raw: true},
+			&encoding.DlmsDataChoice{AttributeName: "second"},
+		},
+	}).Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, data[:4], result["first"])
+	assert.Equal(t, uint8(9), result["second"])
+}
+
+func TestDecodeValue_ScalarsInStructure(t *testing.T) {
+	// variable and fixed length types decoded with their own data class
+	value, err := encoding.DecodeValue([]byte{
+		byte(dlmsdata.TagStructure), 3,
+		byte(dlmsdata.TagOctetString), 2, 0x01, 0x02,
+		byte(dlmsdata.TagVisibleString), 2, 'O', 'K',
+		byte(dlmsdata.TagDoubleLongUnsigned), 0x00, 0x01, 0x00, 0x00,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]byte{0x01, 0x02}, "OK", uint32(65536)}, value)
+}
+
+// vendorCounter is a manufacturer specific data type of 3 bytes
+type vendorCounter struct {
+	*dlmsdata.BaseDlmsData
+}
+
+func newVendorCounter() dlmsdata.DlmsData {
+	return &vendorCounter{&dlmsdata.BaseDlmsData{Tag: 0x80, Length: 3}}
+}
+
+func (v *vendorCounter) FromBytes(data []byte) (dlmsdata.DlmsData, error) {
+	if len(data) != 3 {
+		return nil, fmt.Errorf("vendor counter is 3 bytes, got %d", len(data))
+	}
+	return &vendorCounter{&dlmsdata.BaseDlmsData{Tag: 0x80, Length: 3, Value: int(data[0])<<16 | int(data[1])<<8 | int(data[2])}}, nil
+}
+
+func (v *vendorCounter) String() string {
+	return fmt.Sprintf("VendorCounter(%v)", v.Value)
+}
+
+func TestDecodeValue_RegisteredTag(t *testing.T) {
+	data := []byte{byte(dlmsdata.TagStructure), 2, 0x80, 0x01, 0x00, 0x02, byte(dlmsdata.TagUnsigned), 7}
+	_, err := encoding.DecodeValue(data)
+	assert.Error(t, err)
+
+	require.NoError(t, dlmsdata.RegisterDataClass(0x80, newVendorCounter))
+	t.Cleanup(func() { dlmsdata.UnregisterDataClass(0x80) })
+	value, err := encoding.DecodeValue(data)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{0x010002, uint8(7)}, value)
+
+	assert.Error(t, dlmsdata.RegisterDataClass(dlmsdata.TagUnsigned, newVendorCounter))
+	assert.Error(t, dlmsdata.RegisterDataClass(0x81, nil))
+
+	// standard types can't be removed
+	dlmsdata.UnregisterDataClass(dlmsdata.TagUnsigned)
+	_, err = dlmsdata.DataClass(dlmsdata.TagUnsigned)
+	assert.NoError(t, err)
+}
+
+func BenchmarkDecodeValue_ProfileBuffer(b *testing.B) {
+	data := profileBuffer(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := encoding.DecodeValue(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package encoding
 
 import (
 	"fmt"
+	"math"
 )
 
 // BER provides Basic Encoding Rules encoding/decoding
@@ -33,36 +34,97 @@ func (b *BER) Encode(tag interface{}, data []byte) ([]byte, error) {
 		return []byte{}, nil
 	}
 
-	length := byte(len(data))
-	result := make([]byte, 0, len(tagBytes)+1+len(data))
+	length := EncodeLength(len(data))
+	result := make([]byte, 0, len(tagBytes)+len(length)+len(data))
 	result = append(result, tagBytes...)
-	result = append(result, length)
+	result = append(result, length...)
 	result = append(result, data...)
 
 	return result, nil
 }
 
+// EncodeLength encodes a BER length, in the short form below 128 and in the
+// long form otherwise
+func EncodeLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+	var octets []byte
+	for l := length; l > 0; l >>= 8 {
+		octets = append([]byte{byte(l)}, octets...)
+	}
+	return append([]byte{0x80 | byte(len(octets))}, octets...)
+}
+
+// DecodeLength decodes a definite BER length in the short or the long form.
+// It returns the length and the number of bytes of the length itself.
+func DecodeLength(data []byte) (int, int, error) {
+	if len(data) == 0 {
+		return 0, 0, fmt.Errorf("insufficient data for BER length")
+	}
+	if data[0] < 0x80 {
+		return int(data[0]), 1, nil
+	}
+	octets := int(data[0] & 0x7F)
+	if octets == 0 {
+		return 0, 0, fmt.Errorf("indefinite BER length is not supported")
+	}
+	if octets > 4 {
+		return 0, 0, fmt.Errorf("BER length of %d bytes is too large", octets)
+	}
+	if len(data) < 1+octets {
+		return 0, 0, fmt.Errorf("insufficient data for BER length of %d bytes", octets)
+	}
+	// Accumulated in 64 bits so the check works where int is 32 bits
+	var length uint64
+	for _, b := range data[1 : 1+octets] {
+		length = length<<8 | uint64(b)
+	}
+	if length > math.MaxInt32 {
+		return 0, 0, fmt.Errorf("BER length %d is too large", length)
+	}
+	return int(length), 1 + octets, nil
+}
+
+// NextTLV reads the tag, length and value at the start of data, the tag is
+// one byte as all the ACSE tags. rest is the data after the value, so a
+// sequence of TLVs is read by calling NextTLV until rest is empty.
+func (b *BER) NextTLV(data []byte) (tag byte, value []byte, rest []byte, err error) {
+	if len(data) == 0 {
+		return 0, nil, nil, fmt.Errorf("insufficient data for BER tag")
+	}
+	length, consumed, err := DecodeLength(data[1:])
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("tag 0x%02x: %w", data[0], err)
+	}
+	tag = data[0]
+	data = data[1+consumed:]
+	if len(data) < length {
+		return 0, nil, nil, fmt.Errorf("insufficient data for tag 0x%02x, need %d bytes, got %d", tag, length, len(data))
+	}
+	return tag, data[:length], data[length:], nil
+}
+
 // Decode decodes BER encoded data
 // Returns tag, length, and data
-func (b *BER) Decode(data []byte, tagLength int) ([]byte, uint8, []byte, error) {
+func (b *BER) Decode(data []byte, tagLength int) ([]byte, int, []byte, error) {
 	if len(data) < tagLength+1 {
 		return nil, 0, nil, fmt.Errorf("insufficient data for BER decoding")
 	}
 
-	input := make([]byte, len(data))
-	copy(input, data)
-
 	tag := make([]byte, tagLength)
-	for i := 0; i < tagLength; i++ {
-		tag[i] = input[0]
-		input = input[1:]
+	copy(tag, data[:tagLength])
+
+	length, consumed, err := DecodeLength(data[tagLength:])
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("BER-decoding failed: %w", err)
 	}
 
-	length := input[0]
-	input = input[1:]
+	input := make([]byte, len(data)-tagLength-consumed)
+	copy(input, data[tagLength+consumed:])
 
-	if len(input) != int(length) {
-		return nil, 0, nil, fmt.Errorf("BER-decoding failed. Length byte %d does not correspond to length of data %d", length, len(input))
+	if len(input) != length {
+		return nil, 0, nil, fmt.Errorf("BER-decoding failed. Length %d does not correspond to length of data %d", length, len(input))
 	}
 
 	return tag, length, input, nil
@@ -0,0 +1,55 @@
+package encoding_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+)
+
+func TestDecodeLength(t *testing.T) {
+	for _, test := range []struct {
+		encoded  string
+		length   int
+		consumed int
+	}{
+		{"00", 0, 1},
+		{"7F", 127, 1},
+		{"8180", 128, 2},
+		{"820100", 256, 3},
+		{"847FFFFFFF", 0x7FFFFFFF, 5},
+	} {
+		t.Run(test.encoded, func(t *testing.T) {
+			data, err := hex.DecodeString(test.encoded)
+			require.NoError(t, err)
+			length, consumed, err := encoding.DecodeLength(data)
+			require.NoError(t, err)
+			assert.Equal(t, test.length, length)
+			assert.Equal(t, test.consumed, consumed)
+		})
+	}
+}
+
+func TestDecodeLength_Errors(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		encoded string
+	}{
+		{"empty", ""},
+		{"indefinite length", "80"},
+		{"more than 4 length bytes", "850000000001"},
+		{"truncated length", "8201"},
+		// would be negative in a 32-bit int
+		{"length above 2^31-1", "8480000000"},
+		{"largest 4 byte length", "84FFFFFFFF"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := hex.DecodeString(test.encoded)
+			require.NoError(t, err)
+			_, _, err = encoding.DecodeLength(data)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -76,6 +76,16 @@ const (
 	ActionWithPBlock          ActionType = 6
 )
 
+// ActionResponseType represents the type of ACTION response
+type ActionResponseType uint8
+
+const (
+	ActionResponseNormal     ActionResponseType = 1
+	ActionResponseWithPBlock ActionResponseType = 2
+	ActionResponseWithList   ActionResponseType = 3
+	ActionResponseNextPBlock ActionResponseType = 4
+)
+
 // StateException represents state exception types
 type StateException uint8
 
@@ -436,3 +446,46 @@ const (
 	ActionResultStatusOtherReason            ActionResultStatus = 250
 )
 
+
+// ConfirmedServiceErrorChoice is the service a ConfirmedServiceError answers
+type ConfirmedServiceErrorChoice uint8
+
+const (
+	ConfirmedServiceErrorInitiate             ConfirmedServiceErrorChoice = 1
+	ConfirmedServiceErrorGetStatus            ConfirmedServiceErrorChoice = 2
+	ConfirmedServiceErrorGetNameList          ConfirmedServiceErrorChoice = 3
+	ConfirmedServiceErrorGetVariableAttribute ConfirmedServiceErrorChoice = 4
+	ConfirmedServiceErrorRead                 ConfirmedServiceErrorChoice = 5
+	ConfirmedServiceErrorWrite                ConfirmedServiceErrorChoice = 6
+	ConfirmedServiceErrorGetDataSetAttribute  ConfirmedServiceErrorChoice = 7
+	ConfirmedServiceErrorGetTIAttribute       ConfirmedServiceErrorChoice = 8
+	ConfirmedServiceErrorChangeScope          ConfirmedServiceErrorChoice = 9
+	ConfirmedServiceErrorStart                ConfirmedServiceErrorChoice = 10
+	ConfirmedServiceErrorStop                 ConfirmedServiceErrorChoice = 11
+	ConfirmedServiceErrorResume               ConfirmedServiceErrorChoice = 12
+	ConfirmedServiceErrorMakeUsable           ConfirmedServiceErrorChoice = 13
+	ConfirmedServiceErrorInitiateLoad         ConfirmedServiceErrorChoice = 14
+	ConfirmedServiceErrorLoadSegment          ConfirmedServiceErrorChoice = 15
+	ConfirmedServiceErrorTerminateLoad        ConfirmedServiceErrorChoice = 16
+	ConfirmedServiceErrorInitiateUpLoad       ConfirmedServiceErrorChoice = 17
+	ConfirmedServiceErrorUpLoadSegment        ConfirmedServiceErrorChoice = 18
+	ConfirmedServiceErrorTerminateUpLoad      ConfirmedServiceErrorChoice = 19
+)
+
+// ServiceErrorType is the kind of error of a ConfirmedServiceError, it tells
+// which enumeration the error value belongs to
+type ServiceErrorType uint8
+
+const (
+	ServiceErrorTypeApplicationReference ServiceErrorType = 0
+	ServiceErrorTypeHardwareResource     ServiceErrorType = 1
+	ServiceErrorTypeVdeStateError        ServiceErrorType = 2
+	ServiceErrorTypeService              ServiceErrorType = 3
+	ServiceErrorTypeDefinition           ServiceErrorType = 4
+	ServiceErrorTypeAccess               ServiceErrorType = 5
+	ServiceErrorTypeInitiate             ServiceErrorType = 6
+	ServiceErrorTypeLoadDataSet          ServiceErrorType = 7
+	ServiceErrorTypeChangeScope          ServiceErrorType = 8
+	ServiceErrorTypeTask                 ServiceErrorType = 9
+	ServiceErrorTypeOther                ServiceErrorType = 10
+)
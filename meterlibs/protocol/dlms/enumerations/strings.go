@@ -0,0 +1,170 @@
+package enumerations
+
+import "fmt"
+
+// The String methods give the names of the values as in the ASN.1 of the
+// Green Book, e.g. read-write-denied, and the number of the unknown values
+
+// String implements fmt.Stringer
+func (v DataAccessResult) String() string {
+	switch v {
+	case DataAccessSuccess:
+		return "success"
+	case DataAccessHardwareFault:
+		return "hardware-fault"
+	case DataAccessTemporaryFailure:
+		return "temporary-failure"
+	case DataAccessReadWriteDenied:
+		return "read-write-denied"
+	case DataAccessObjectUndefined:
+		return "object-undefined"
+	case DataAccessObjectClassInconsistent:
+		return "object-class-inconsistent"
+	case DataAccessObjectUnavailable:
+		return "object-unavailable"
+	case DataAccessTypeUnmatched:
+		return "type-unmatched"
+	case DataAccessScopeOfAccessViolated:
+		return "scope-of-access-violated"
+	case DataAccessDataBlockUnavailable:
+		return "data-block-unavailable"
+	case DataAccessLongGetAborted:
+		return "long-get-aborted"
+	case DataAccessNoLongGetInProgress:
+		return "no-long-get-in-progress"
+	case DataAccessLongSetAborted:
+		return "long-set-aborted"
+	case DataAccessNoLongSetInProgress:
+		return "no-long-set-in-progress"
+	case DataAccessDataBlockNumberInvalid:
+		return "data-block-number-invalid"
+	case DataAccessOtherReason:
+		return "other-reason"
+	default:
+		return fmt.Sprintf("DataAccessResult(%d)", uint8(v))
+	}
+}
+
+// String implements fmt.Stringer
+func (v ActionResultStatus) String() string {
+	switch v {
+	case ActionResultStatusSuccess:
+		return "success"
+	case ActionResultStatusHardwareFault:
+		return "hardware-fault"
+	case ActionResultStatusTemporaryFailure:
+		return "temporary-failure"
+	case ActionResultStatusReadWriteDenied:
+		return "read-write-denied"
+	case ActionResultStatusObjectUndefined:
+		return "object-undefined"
+	case ActionResultStatusObjectClassInconsistent:
+		return "object-class-inconsistent"
+	case ActionResultStatusObjectUnavailable:
+		return "object-unavailable"
+	case ActionResultStatusTypeUnmatched:
+		return "type-unmatched"
+	case ActionResultStatusScopeOfAccessViolated:
+		return "scope-of-access-violated"
+	case ActionResultStatusDataBlockUnavailable:
+		return "data-block-unavailable"
+	case ActionResultStatusLongActionAborted:
+		return "long-action-aborted"
+	case ActionResultStatusNoLongActionInProgress:
+		return "no-long-action-in-progress"
+	case ActionResultStatusOtherReason:
+		return "other-reason"
+	default:
+		return fmt.Sprintf("ActionResultStatus(%d)", uint8(v))
+	}
+}
+
+// String implements fmt.Stringer
+func (v AssociationResult) String() string {
+	switch v {
+	case AssociationResultAccepted:
+		return "accepted"
+	case AssociationResultRejectedPermanent:
+		return "rejected-permanent"
+	case AssociationResultRejectedTransient:
+		return "rejected-transient"
+	default:
+		return fmt.Sprintf("AssociationResult(%d)", uint8(v))
+	}
+}
+
+// String implements fmt.Stringer
+func (v AcseServiceUserDiagnostics) String() string {
+	switch v {
+	case AcseServiceUserDiagnosticsNull:
+		return "null"
+	case AcseServiceUserDiagnosticsNoReasonGiven:
+		return "no-reason-given"
+	case AcseServiceUserDiagnosticsApplicationContextNameNotSupported:
+		return "application-context-name-not-supported"
+	case AcseServiceUserDiagnosticsCallingAPTitleNotRecognized:
+		return "calling-AP-title-not-recognized"
+	case AcseServiceUserDiagnosticsCallingAPInvocationIdentifierNotRecognized:
+		return "calling-AP-invocation-identifier-not-recognized"
+	case AcseServiceUserDiagnosticsCallingAEQualifierNotRecognized:
+		return "calling-AE-qualifier-not-recognized"
+	case AcseServiceUserDiagnosticsCallingAEInvocationIdentifierNotRecognized:
+		return "calling-AE-invocation-identifier-not-recognized"
+	case AcseServiceUserDiagnosticsCalledAPTitleNotRecognized:
+		return "called-AP-title-not-recognized"
+	case AcseServiceUserDiagnosticsCalledAPInvocationIdentifierNotRecognized:
+		return "called-AP-invocation-identifier-not-recognized"
+	case AcseServiceUserDiagnosticsCalledAEQualifierNotRecognized:
+		return "called-AE-qualifier-not-recognized"
+	case AcseServiceUserDiagnosticsCalledAEInvocationIdentifierNotRecognized:
+		return "called-AE-invocation-identifier-not-recognized"
+	case AcseServiceUserDiagnosticsAuthenticationMechanismNameNotRecognized:
+		return "authentication-mechanism-name-not-recognised"
+	case AcseServiceUserDiagnosticsAuthenticationMechanismNameRequired:
+		return "authentication-mechanism-name-required"
+	case AcseServiceUserDiagnosticsAuthenticationFailed:
+		return "authentication-failure"
+	case AcseServiceUserDiagnosticsAuthenticationRequired:
+		return "authentication-required"
+	default:
+		return fmt.Sprintf("AcseServiceUserDiagnostics(%d)", uint8(v))
+	}
+}
+
+// String implements fmt.Stringer
+func (v AcseServiceProviderDiagnostics) String() string {
+	switch v {
+	case AcseServiceProviderDiagnosticsNull:
+		return "null"
+	case AcseServiceProviderDiagnosticsNoReasonGiven:
+		return "no-reason-given"
+	case AcseServiceProviderDiagnosticsNoCommonACSEVersion:
+		return "no-common-acse-version"
+	default:
+		return fmt.Sprintf("AcseServiceProviderDiagnostics(%d)", uint8(v))
+	}
+}
+
+// String implements fmt.Stringer
+func (v AuthenticationMechanism) String() string {
+	switch v {
+	case AuthenticationMechanismNone:
+		return "lowest-level"
+	case AuthenticationMechanismLLS:
+		return "low-level"
+	case AuthenticationMechanismHLS:
+		return "high-level"
+	case AuthenticationMechanismHLSMD5:
+		return "high-level-md5"
+	case AuthenticationMechanismHLSSHA1:
+		return "high-level-sha1"
+	case AuthenticationMechanismHLSGMAC:
+		return "high-level-gmac"
+	case AuthenticationMechanismHLSSHA256:
+		return "high-level-sha256"
+	case AuthenticationMechanismHLSECDSA:
+		return "high-level-ecdsa"
+	default:
+		return fmt.Sprintf("AuthenticationMechanism(%d)", uint8(v))
+	}
+}
@@ -0,0 +1,94 @@
+package enumerations_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+func TestEnumerationStrings(t *testing.T) {
+	for _, test := range []struct {
+		value    fmt.Stringer
+		expected string
+	}{
+		{enumerations.DataAccessReadWriteDenied, "read-write-denied"},
+		{enumerations.DataAccessOtherReason, "other-reason"},
+		{enumerations.DataAccessResult(5), "DataAccessResult(5)"},
+		{enumerations.ActionResultStatusLongActionAborted, "long-action-aborted"},
+		{enumerations.AssociationResultRejectedPermanent, "rejected-permanent"},
+		{enumerations.AcseServiceUserDiagnosticsAuthenticationFailed, "authentication-failure"},
+		{enumerations.AcseServiceProviderDiagnosticsNoCommonACSEVersion, "no-common-acse-version"},
+		{enumerations.AuthenticationMechanismHLSGMAC, "high-level-gmac"},
+		{enumerations.AuthenticationMechanism(9), "AuthenticationMechanism(9)"},
+	} {
+		assert.Equal(t, test.expected, test.value.String())
+	}
+}
+
+// TestEnumerationStrings_Complete checks every value has a name
+func TestEnumerationStrings_Complete(t *testing.T) {
+	for _, value := range []fmt.Stringer{
+		enumerations.DataAccessSuccess,
+		enumerations.DataAccessHardwareFault,
+		enumerations.DataAccessTemporaryFailure,
+		enumerations.DataAccessReadWriteDenied,
+		enumerations.DataAccessObjectUndefined,
+		enumerations.DataAccessObjectClassInconsistent,
+		enumerations.DataAccessObjectUnavailable,
+		enumerations.DataAccessTypeUnmatched,
+		enumerations.DataAccessScopeOfAccessViolated,
+		enumerations.DataAccessDataBlockUnavailable,
+		enumerations.DataAccessLongGetAborted,
+		enumerations.DataAccessNoLongGetInProgress,
+		enumerations.DataAccessLongSetAborted,
+		enumerations.DataAccessNoLongSetInProgress,
+		enumerations.DataAccessDataBlockNumberInvalid,
+		enumerations.DataAccessOtherReason,
+		enumerations.ActionResultStatusSuccess,
+		enumerations.ActionResultStatusHardwareFault,
+		enumerations.ActionResultStatusTemporaryFailure,
+		enumerations.ActionResultStatusReadWriteDenied,
+		enumerations.ActionResultStatusObjectUndefined,
+		enumerations.ActionResultStatusObjectClassInconsistent,
+		enumerations.ActionResultStatusObjectUnavailable,
+		enumerations.ActionResultStatusTypeUnmatched,
+		enumerations.ActionResultStatusScopeOfAccessViolated,
+		enumerations.ActionResultStatusDataBlockUnavailable,
+		enumerations.ActionResultStatusLongActionAborted,
+		enumerations.ActionResultStatusNoLongActionInProgress,
+		enumerations.ActionResultStatusOtherReason,
+		enumerations.AssociationResultAccepted,
+		enumerations.AssociationResultRejectedPermanent,
+		enumerations.AssociationResultRejectedTransient,
+		enumerations.AcseServiceUserDiagnosticsNull,
+		enumerations.AcseServiceUserDiagnosticsNoReasonGiven,
+		enumerations.AcseServiceUserDiagnosticsApplicationContextNameNotSupported,
+		enumerations.AcseServiceUserDiagnosticsCallingAPTitleNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsCallingAPInvocationIdentifierNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsCallingAEQualifierNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsCallingAEInvocationIdentifierNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsCalledAPTitleNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsCalledAPInvocationIdentifierNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsCalledAEQualifierNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsCalledAEInvocationIdentifierNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsAuthenticationMechanismNameNotRecognized,
+		enumerations.AcseServiceUserDiagnosticsAuthenticationMechanismNameRequired,
+		enumerations.AcseServiceUserDiagnosticsAuthenticationFailed,
+		enumerations.AcseServiceUserDiagnosticsAuthenticationRequired,
+		enumerations.AcseServiceProviderDiagnosticsNull,
+		enumerations.AcseServiceProviderDiagnosticsNoReasonGiven,
+		enumerations.AcseServiceProviderDiagnosticsNoCommonACSEVersion,
+		enumerations.AuthenticationMechanismNone,
+		enumerations.AuthenticationMechanismLLS,
+		enumerations.AuthenticationMechanismHLS,
+		enumerations.AuthenticationMechanismHLSMD5,
+		enumerations.AuthenticationMechanismHLSSHA1,
+		enumerations.AuthenticationMechanismHLSGMAC,
+		enumerations.AuthenticationMechanismHLSSHA256,
+		enumerations.AuthenticationMechanismHLSECDSA,
+	} {
+		assert.NotContains(t, value.String(), "(", "%T %d has no name", value, value)
+	}
+}
@@ -1,6 +1,9 @@
 package exceptions
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // LocalDlmsProtocolError represents a protocol error
 type LocalDlmsProtocolError struct {
@@ -146,3 +149,72 @@ func NewNoRlrqRlreError(message string) *NoRlrqRlreError {
 	return &NoRlrqRlreError{Message: message}
 }
 
+// AccessRightsError is returned before a request the object list of the
+// association doesn't give access to, e.g. a SET of a read-only attribute
+type AccessRightsError struct {
+	Message string
+}
+
+func (e *AccessRightsError) Error() string {
+	return fmt.Sprintf("Access rights error: %s", e.Message)
+}
+
+// NewAccessRightsError creates a new AccessRightsError
+func NewAccessRightsError(message string) *AccessRightsError {
+	return &AccessRightsError{Message: message}
+}
+
+// DeclaredLengthError is returned when an encoded length is malformed or larger
+// than the configured maximum, to avoid huge allocations from untrusted data
+type DeclaredLengthError struct {
+	Message   string
+	Length    int
+	MaxLength int
+	// Truncated tells that the data ends before the declared length, with
+	// Remaining bytes left
+	Truncated bool
+	Remaining int
+}
+
+func (e *DeclaredLengthError) Error() string {
+	return fmt.Sprintf("Declared length error: %s", e.Message)
+}
+
+// NewDeclaredLengthError creates a new DeclaredLengthError
+func NewDeclaredLengthError(message string, length int, maxLength int) *DeclaredLengthError {
+	return &DeclaredLengthError{Message: message, Length: length, MaxLength: maxLength}
+}
+
+// NewTruncatedLengthError creates a new DeclaredLengthError for a declared
+// length larger than the remaining data
+func NewTruncatedLengthError(message string, length int, remaining int, maxLength int) *DeclaredLengthError {
+	return &DeclaredLengthError{Message: message, Length: length, MaxLength: maxLength, Truncated: true, Remaining: remaining}
+}
+
+// ErrInsufficientData is the cause of an ApduParseError when the APDU ends
+// before one of its fields
+var ErrInsufficientData = errors.New("insufficient data")
+
+// ApduParseError is returned when a field of an APDU can't be parsed. It
+// tells the APDU, the field and the byte offset of the field in the APDU, so
+// the failures of real meters can be found from the logs alone.
+type ApduParseError struct {
+	Apdu   string
+	Field  string
+	Offset int
+	Err    error
+}
+
+func (e *ApduParseError) Error() string {
+	return fmt.Sprintf("failed to parse %s: %s at byte %d: %v", e.Apdu, e.Field, e.Offset, e.Err)
+}
+
+// Unwrap returns the cause of the error
+func (e *ApduParseError) Unwrap() error {
+	return e.Err
+}
+
+// NewApduParseError creates a new ApduParseError
+func NewApduParseError(apdu string, field string, offset int, err error) *ApduParseError {
+	return &ApduParseError{Apdu: apdu, Field: field, Offset: offset, Err: err}
+}
@@ -30,15 +30,20 @@ type HdlcAddress struct {
 	ExtendedAddressing bool
 }
 
-// NewHdlcAddress creates a new HDLC address
+// NewHdlcAddress creates a new HDLC address. Without extended addressing the
+// address is sent on one byte, or on two bytes with a physical address, and
+// each part is up to 127. With extended addressing the logical and the
+// physical address are sent on two bytes each, and are up to 16383.
 func NewHdlcAddress(logicalAddress int, physicalAddress *int, addressType AddressType, extendedAddressing bool) (*HdlcAddress, error) {
-	if err := validateHdlcAddress(logicalAddress); err != nil {
+	if err := validateHdlcAddress(logicalAddress, extendedAddressing); err != nil {
 		return nil, fmt.Errorf("invalid logical address: %w", err)
 	}
 	if physicalAddress != nil {
-		if err := validateHdlcAddress(*physicalAddress); err != nil {
+		if err := validateHdlcAddress(*physicalAddress, extendedAddressing); err != nil {
 			return nil, fmt.Errorf("invalid physical address: %w", err)
 		}
+	} else if extendedAddressing {
+		return nil, fmt.Errorf("invalid physical address: extended addressing needs a physical address")
 	}
 	if err := validateHdlcAddressType(addressType); err != nil {
 		return nil, fmt.Errorf("invalid address type: %w", err)
@@ -58,57 +63,47 @@ func (a *HdlcAddress) Length() int {
 	return len(bytes)
 }
 
-// ToBytes converts the HDLC address to bytes
+// ToBytes converts the HDLC address to bytes, the LSB of the last byte is set
+// to mark the end of the address
 func (a *HdlcAddress) ToBytes() []byte {
 	var out []byte
 
-	if a.AddressType == AddressTypeClient {
-		// shift left 1 bit and set the lsb to mark end of address
-		out = append(out, byte((a.LogicalAddress<<1)|0b00000001))
-	} else {
-		// server address type
+	switch {
+	case a.PhysicalAddress == nil:
+		out = []byte{byte(a.LogicalAddress << 1)}
+	case a.ExtendedAddressing:
 		logicalHigher, logicalLower := a.splitAddress(a.LogicalAddress)
-
-		if a.PhysicalAddress != nil {
-			physicalHigher, physicalLower := a.splitAddress(*a.PhysicalAddress)
-			// mark physical lower as end
-			physicalLower = physicalLower | 0b00000001
-			out = append(out, logicalHigher, logicalLower, physicalHigher, physicalLower)
-		} else {
-			// no physical address so mark the logical as end
-			logicalLower = logicalLower | 0b00000001
-			out = append(out, logicalHigher, logicalLower)
-		}
+		physicalHigher, physicalLower := a.splitAddress(*a.PhysicalAddress)
+		out = []byte{logicalHigher, logicalLower, physicalHigher, physicalLower}
+	default:
+		out = []byte{byte(a.LogicalAddress << 1), byte(*a.PhysicalAddress << 1)}
 	}
+	out[len(out)-1] |= 0b00000001
 
-	var outBytes []byte
-	for _, addr := range out {
-		if addr == 0 {
-			if a.ExtendedAddressing {
-				outBytes = append(outBytes, addr)
-			}
-			// else skip
-		} else {
-			outBytes = append(outBytes, addr)
-		}
-	}
-
-	return outBytes
+	return out
 }
 
-// splitAddress splits an address into higher and lower parts
-func (a *HdlcAddress) splitAddress(address int) (byte, byte) {
-	var higher byte
-	var lower byte
-
-	if address > 0b01111111 {
-		lower = byte((address & 0b0000000001111111) << 1)
-		higher = byte((address & 0b0011111110000000) >> 6)
-	} else {
-		lower = byte(address << 1)
-		higher = 0
+// Equal tells whether both addresses are the same and are encoded the same way
+func (a *HdlcAddress) Equal(other *HdlcAddress) bool {
+	if a == nil || other == nil {
+		return a == other
 	}
+	if (a.PhysicalAddress == nil) != (other.PhysicalAddress == nil) {
+		return false
+	}
+	if a.PhysicalAddress != nil && *a.PhysicalAddress != *other.PhysicalAddress {
+		return false
+	}
+	return a.LogicalAddress == other.LogicalAddress &&
+		a.AddressType == other.AddressType &&
+		a.ExtendedAddressing == other.ExtendedAddressing
+}
 
+// splitAddress splits an address into the higher and the lower 7 bits, each
+// shifted left to leave the LSB free
+func (a *HdlcAddress) splitAddress(address int) (byte, byte) {
+	higher := byte((address & 0b0011111110000000) >> 6)
+	lower := byte((address & 0b0000000001111111) << 1)
 	return higher, lower
 }
 
@@ -118,14 +113,7 @@ func DestinationFromBytes(frameBytes []byte, addressType AddressType) (*HdlcAddr
 	if err != nil {
 		return nil, err
 	}
-
-	destLogical, destPhysical, _ := destData
-	var physicalAddr *int
-	if destPhysical != nil {
-		physicalAddr = destPhysical
-	}
-
-	return NewHdlcAddress(destLogical, physicalAddr, addressType, false)
+	return destData.Address(addressType)
 }
 
 // SourceFromBytes creates an HDLC address from frame bytes (source address)
@@ -134,16 +122,7 @@ func SourceFromBytes(frameBytes []byte, addressType AddressType) (*HdlcAddress,
 	if err != nil {
 		return nil, err
 	}
-
-	sourceLogical, sourcePhysical, sourceLength := sourceData
-	extendedAddress := sourceLength == 4
-
-	var physicalAddr *int
-	if sourcePhysical != nil {
-		physicalAddr = sourcePhysical
-	}
-
-	return NewHdlcAddress(sourceLogical, physicalAddr, addressType, extendedAddress)
+	return sourceData.Address(addressType)
 }
 
 // ExtractAddressBytes extracts address bytes from input data
@@ -178,142 +157,74 @@ func ExtractAddressBytes(inData []byte) ([]byte, []byte, error) {
 type AddressData struct {
 	Logical  int
 	Physical *int
-	Length   int
+	// Length is the number of bytes of the address in the frame
+	Length int
+}
+
+// Address creates the HDLC address, extended when it was sent on 4 bytes
+func (d AddressData) Address(addressType AddressType) (*HdlcAddress, error) {
+	return NewHdlcAddress(d.Logical, d.Physical, addressType, d.Length == 4)
 }
 
 // FindAddressInFrameBytes finds destination and source addresses in HDLC frame bytes
 // Address can be 1, 2 or 4 bytes long. The end byte is indicated by the
 // last byte LSB being 1
 // The first address is the destination address and the second is the source address.
+// The source address starts right after the end of the destination address.
 func FindAddressInFrameBytes(hdlcFrameBytes []byte) (AddressData, AddressData, error) {
 	if len(hdlcFrameBytes) < 4 {
 		return AddressData{}, AddressData{}, fmt.Errorf("frame too short")
 	}
 
-	// Find destination address
-	destinationLength := 1
-	destinationPositions := [][]int{{3, 1}, {4, 2}, {6, 4}}
-
-	for _, pos := range destinationPositions {
-		posIdx, length := pos[0], pos[1]
-		if posIdx >= len(hdlcFrameBytes) {
-			continue
-		}
-		endByte := hdlcFrameBytes[posIdx]
-		if endByte&0b00000001 != 0 {
-			destinationLength = length
-			break
-		}
-	}
-
-	var destinationLogical int
-	var destinationPhysical *int
-
-	switch destinationLength {
-	case 1:
-		if len(hdlcFrameBytes) < 4 {
-			return AddressData{}, AddressData{}, fmt.Errorf("frame too short for destination address")
-		}
-		destinationLogical = int(hdlcFrameBytes[3] >> 1)
-		destinationPhysical = nil
-
-	case 2:
-		if len(hdlcFrameBytes) < 5 {
-			return AddressData{}, AddressData{}, fmt.Errorf("frame too short for 2-byte destination address")
-		}
-		destinationLogical = int(hdlcFrameBytes[3] >> 1)
-		physical := int(hdlcFrameBytes[4] >> 1)
-		destinationPhysical = &physical
-
-	case 4:
-		if len(hdlcFrameBytes) < 7 {
-			return AddressData{}, AddressData{}, fmt.Errorf("frame too short for 4-byte destination address")
-		}
-		destBytes := hdlcFrameBytes[3:7]
-		destinationLogical = parseTwoByteAddress(destBytes[:2])
-		physical := parseTwoByteAddress(destBytes[2:])
-		destinationPhysical = &physical
+	destination, rest, err := readAddressData(hdlcFrameBytes[3:])
+	if err != nil {
+		return AddressData{}, AddressData{}, fmt.Errorf("destination address: %w", err)
 	}
-
-	// Find source address
-	sourceLength := 1
-	sourceStartPos := 3 + destinationLength
-	sourcePositions := [][]int{
-		{sourceStartPos, 1},
-		{sourceStartPos + 1, 2},
-		{sourceStartPos + 3, 4},
+	source, _, err := readAddressData(rest)
+	if err != nil {
+		return AddressData{}, AddressData{}, fmt.Errorf("source address: %w", err)
 	}
+	return destination, source, nil
+}
 
-	for _, pos := range sourcePositions {
-		posIdx, length := pos[0], pos[1]
-		if posIdx >= len(hdlcFrameBytes) {
-			continue
-		}
-		endByte := hdlcFrameBytes[posIdx]
-		if endByte&0b00000001 != 0 {
-			sourceLength = length
-			break
-		}
+// readAddressData reads an address at the start of data and returns the
+// bytes following it
+func readAddressData(data []byte) (AddressData, []byte, error) {
+	address, rest, err := ExtractAddressBytes(data)
+	if err != nil {
+		return AddressData{}, nil, err
 	}
 
-	var sourceLogical int
-	var sourcePhysical *int
-
-	switch sourceLength {
+	switch len(address) {
 	case 1:
-		if len(hdlcFrameBytes) < sourceStartPos+1 {
-			return AddressData{}, AddressData{}, fmt.Errorf("frame too short for source address")
-		}
-		sourceLogical = int(hdlcFrameBytes[sourceStartPos] >> 1)
-		sourcePhysical = nil
-
+		return AddressData{Logical: int(address[0] >> 1), Length: 1}, rest, nil
 	case 2:
-		if len(hdlcFrameBytes) < sourceStartPos+2 {
-			return AddressData{}, AddressData{}, fmt.Errorf("frame too short for 2-byte source address")
-		}
-		sourceLogical = int(hdlcFrameBytes[sourceStartPos] >> 1)
-		physical := int(hdlcFrameBytes[sourceStartPos+1] >> 1)
-		sourcePhysical = &physical
-
+		physical := int(address[1] >> 1)
+		return AddressData{Logical: int(address[0] >> 1), Physical: &physical, Length: 2}, rest, nil
 	case 4:
-		if len(hdlcFrameBytes) < sourceStartPos+4 {
-			return AddressData{}, AddressData{}, fmt.Errorf("frame too short for 4-byte source address")
-		}
-		sourceBytes := hdlcFrameBytes[sourceStartPos : sourceStartPos+4]
-		sourceLogical = parseTwoByteAddress(sourceBytes[:2])
-		physical := parseTwoByteAddress(sourceBytes[2:])
-		sourcePhysical = &physical
+		physical := parseTwoByteAddress(address[2], address[3])
+		return AddressData{Logical: parseTwoByteAddress(address[0], address[1]), Physical: &physical, Length: 4}, rest, nil
+	default:
+		return AddressData{}, nil, fmt.Errorf("an HDLC address is 1, 2 or 4 bytes long, got %d", len(address))
 	}
-
-	destData := AddressData{
-		Logical:  destinationLogical,
-		Physical: destinationPhysical,
-		Length:   destinationLength,
-	}
-
-	sourceData := AddressData{
-		Logical:  sourceLogical,
-		Physical: sourcePhysical,
-		Length:   sourceLength,
-	}
-
-	return destData, sourceData, nil
 }
 
-// parseTwoByteAddress parses a two-byte address
-func parseTwoByteAddress(addressBytes []byte) int {
-	if len(addressBytes) != 2 {
-		panic("can only parse 2 bytes for address")
-	}
-	upper := addressBytes[0] >> 1
-	lower := addressBytes[1] >> 1
+// parseTwoByteAddress parses an address sent on two bytes, 7 bits in each
+func parseTwoByteAddress(upperByte, lowerByte byte) int {
+	upper := upperByte >> 1
+	lower := lowerByte >> 1
 	return int(lower) + (int(upper) << 7)
 }
 
-// validateHdlcAddress validates an HDLC address value
-func validateHdlcAddress(value int) error {
-	if value < 0 || value > 127 {
-		return fmt.Errorf("HDLC address must be between 0 and 127, got %d", value)
+// validateHdlcAddress validates an HDLC address value, it is sent on 7 bits,
+// or on 14 bits with extended addressing
+func validateHdlcAddress(value int, extendedAddressing bool) error {
+	maximum := 0b01111111
+	if extendedAddressing {
+		maximum = 0b0011111111111111
+	}
+	if value < 0 || value > maximum {
+		return fmt.Errorf("HDLC address must be between 0 and %d, got %d", maximum, value)
 	}
 	return nil
 }
@@ -0,0 +1,121 @@
+package hdlc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+)
+
+func physical(address int) *int {
+	return &address
+}
+
+func TestHdlcAddress_ToBytes(t *testing.T) {
+	for _, test := range []struct {
+		logical  int
+		physical *int
+		extended bool
+		expected []byte
+	}{
+		{logical: 1, expected: []byte{0x03}},
+		{logical: 0, expected: []byte{0x01}},
+		{logical: 1, physical: physical(17), expected: []byte{0x02, 0x23}},
+		{logical: 0, physical: physical(17), expected: []byte{0x00, 0x23}},
+		{logical: 1, physical: physical(0), expected: []byte{0x02, 0x01}},
+		{logical: 1, physical: physical(17), extended: true, expected: []byte{0x00, 0x02, 0x00, 0x23}},
+		{logical: 0, physical: physical(0), extended: true, expected: []byte{0x00, 0x00, 0x00, 0x01}},
+		{logical: 0x3FFF, physical: physical(0x1234), extended: true, expected: []byte{0xFE, 0xFE, 0x48, 0x69}},
+	} {
+		address, err := hdlc.NewHdlcAddress(test.logical, test.physical, hdlc.AddressTypeServer, test.extended)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, address.ToBytes(), "%+v", test)
+		assert.Equal(t, len(test.expected), address.Length())
+	}
+}
+
+func TestHdlcAddress_RoundTrip(t *testing.T) {
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		logical  int
+		physical *int
+		extended bool
+	}{
+		{logical: 0},
+		{logical: 1},
+		{logical: 0, physical: physical(17)},
+		{logical: 1, physical: physical(0)},
+		{logical: 0, physical: physical(0), extended: true},
+		{logical: 1, physical: physical(17), extended: true},
+		{logical: 0x3FFF, physical: physical(0x1234), extended: true},
+	} {
+		server, err := hdlc.NewHdlcAddress(test.logical, test.physical, hdlc.AddressTypeServer, test.extended)
+		require.NoError(t, err)
+
+		frameBytes := hdlc.NewDisconnectModeFrame(client, server, nil).ToBytes()
+		parsed, err := hdlc.FrameFromBytes(frameBytes)
+		require.NoError(t, err, "%+v", test)
+		destination, source := parsed.Addresses()
+		assert.True(t, client.Equal(destination))
+		assert.True(t, server.Equal(source), "%+v", test)
+		assert.Equal(t, frameBytes, parsed.ToBytes())
+
+		// the server address as destination
+		frameBytes = hdlc.NewDisconnectFrame(server, client).ToBytes()
+		parsed, err = hdlc.FrameFromBytes(frameBytes)
+		require.NoError(t, err, "%+v", test)
+		destination, _ = parsed.Addresses()
+		assert.True(t, server.Equal(destination), "%+v", test)
+	}
+}
+
+func TestHdlcAddress_ExtendedClientAddress(t *testing.T) {
+	client, err := hdlc.NewHdlcAddress(16, physical(300), hdlc.AddressTypeClient, true)
+	require.NoError(t, err)
+	server, err := hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+
+	frame, err := hdlc.NewReceiveReadyFrame(client, server, 2)
+	require.NoError(t, err)
+	parsed, err := hdlc.FrameFromBytes(frame.ToBytes())
+	require.NoError(t, err)
+	destination, source := parsed.Addresses()
+	assert.True(t, client.Equal(destination))
+	assert.True(t, server.Equal(source))
+}
+
+func TestHdlcAddress_Equal(t *testing.T) {
+	address, err := hdlc.NewHdlcAddress(1, physical(17), hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+	same, err := hdlc.NewHdlcAddress(1, physical(17), hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+	assert.True(t, address.Equal(same))
+
+	for _, other := range []*hdlc.HdlcAddress{
+		{LogicalAddress: 1, AddressType: hdlc.AddressTypeServer},
+		{LogicalAddress: 1, PhysicalAddress: physical(18), AddressType: hdlc.AddressTypeServer},
+		{LogicalAddress: 2, PhysicalAddress: physical(17), AddressType: hdlc.AddressTypeServer},
+		{LogicalAddress: 1, PhysicalAddress: physical(17), AddressType: hdlc.AddressTypeClient},
+		{LogicalAddress: 1, PhysicalAddress: physical(17), AddressType: hdlc.AddressTypeServer, ExtendedAddressing: true},
+		nil,
+	} {
+		assert.False(t, address.Equal(other), "%+v", other)
+	}
+	assert.True(t, (*hdlc.HdlcAddress)(nil).Equal(nil))
+}
+
+func TestNewHdlcAddress_Validation(t *testing.T) {
+	_, err := hdlc.NewHdlcAddress(128, nil, hdlc.AddressTypeServer, false)
+	assert.Error(t, err)
+	_, err = hdlc.NewHdlcAddress(1, physical(128), hdlc.AddressTypeServer, false)
+	assert.Error(t, err)
+	_, err = hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, true)
+	assert.Error(t, err)
+	_, err = hdlc.NewHdlcAddress(0x4000, physical(1), hdlc.AddressTypeServer, true)
+	assert.Error(t, err)
+	_, err = hdlc.NewHdlcAddress(0x3FFF, physical(128), hdlc.AddressTypeServer, true)
+	assert.NoError(t, err)
+}
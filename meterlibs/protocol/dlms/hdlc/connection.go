@@ -0,0 +1,371 @@
+package hdlc
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultWindowSize is the window size used when nothing else is agreed, it
+	// gives stop-and-wait operation: one I-frame, then wait for the answer
+	DefaultWindowSize = 1
+	// MaxWindowSize is the largest window possible with 3 bit sequence numbers
+	MaxWindowSize = 7
+	// DefaultMaxInformationLength is the default maximum length of the information field
+	DefaultMaxInformationLength = 128
+)
+
+// segment is a part of an APDU to send in one I-frame
+type segment struct {
+	payload []byte
+	last    bool
+}
+
+// HdlcConnection keeps track of an HDLC connection between the client and the
+// server (meter). It does no I/O: frames to send are returned as bytes and
+// received bytes are passed to ReceiveData.
+//
+// Up to WindowSizeTransmit I-frames are sent before the server has to
+// acknowledge them, only the last frame of a window has the poll bit set. The
+// server may send up to WindowSizeReceive I-frames in a row, the client only
+// answers when the final bit is set.
+//
+// The Proposed parameters are sent in the SNRM. When the UA is received the
+// window sizes and MaxInformationLength are set to the negotiated values.
+type HdlcConnection struct {
+	ClientAddress        *HdlcAddress
+	ServerAddress        *HdlcAddress
+	State                *HdlcConnectionState
+	Proposed             *HdlcParameters
+	WindowSizeTransmit   int
+	WindowSizeReceive    int
+	MaxInformationLength int
+	// LLC wraps the APDUs sent and unwraps the APDUs received
+	LLC *LLC
+
+	// negotiated are the parameters agreed with the server, nil when not connected
+	negotiated *HdlcParameters
+
+	// sendSequenceNumber is V(S), the N(S) of the next I-frame to send
+	sendSequenceNumber uint8
+	// receiveSequenceNumber is V(R), the N(S) expected in the next received I-frame
+	receiveSequenceNumber uint8
+	// pending are the segments not sent yet
+	pending []segment
+	// unacknowledged are the segments sent but not acknowledged by the server yet
+	unacknowledged []segment
+	// received holds the segments of the APDU being received
+	received []byte
+	// receivedInWindow counts the I-frames received in the current window
+	receivedInWindow int
+	buffer           []byte
+}
+
+// NewHdlcConnection creates a new HdlcConnection with stop-and-wait windows
+func NewHdlcConnection(clientAddress, serverAddress *HdlcAddress) *HdlcConnection {
+	return &HdlcConnection{
+		ClientAddress:        clientAddress,
+		ServerAddress:        serverAddress,
+		State:                NewHdlcConnectionState(),
+		Proposed:             NewDefaultHdlcParameters(),
+		WindowSizeTransmit:   DefaultWindowSize,
+		WindowSizeReceive:    DefaultWindowSize,
+		MaxInformationLength: DefaultMaxInformationLength,
+		LLC:                  NewLLC(),
+	}
+}
+
+// SetWindowSize sets the number of I-frames that may be in flight in each
+// direction. The sizes are also proposed to the server on the next connect.
+func (c *HdlcConnection) SetWindowSize(transmit, receive int) error {
+	if transmit < 1 || transmit > MaxWindowSize {
+		return fmt.Errorf("transmit window size can only be between 1-%d, got %d", MaxWindowSize, transmit)
+	}
+	if receive < 1 || receive > MaxWindowSize {
+		return fmt.Errorf("receive window size can only be between 1-%d, got %d", MaxWindowSize, receive)
+	}
+	c.WindowSizeTransmit = transmit
+	c.WindowSizeReceive = receive
+	c.Proposed.WindowSizeTransmit = transmit
+	c.Proposed.WindowSizeReceive = receive
+	return nil
+}
+
+// Negotiated returns the parameters agreed with the server in the SNRM/UA
+// exchange, nil when not connected
+func (c *HdlcConnection) Negotiated() *HdlcParameters {
+	return c.negotiated
+}
+
+// Connect returns the SNRM frame that sets up the connection. The parameters
+// are only sent when they differ from the defaults.
+func (c *HdlcConnection) Connect() ([]byte, error) {
+	if *c.Proposed == *NewDefaultHdlcParameters() {
+		return c.send(NewSetNormalResponseModeFrame(c.ServerAddress, c.ClientAddress))
+	}
+	if err := c.Proposed.validate(); err != nil {
+		return nil, err
+	}
+	return c.send(NewSetNormalResponseModeFrameWithParameters(c.ServerAddress, c.ClientAddress, c.Proposed))
+}
+
+// Disconnect returns the DISC frame that closes the connection
+func (c *HdlcConnection) Disconnect() ([]byte, error) {
+	return c.send(NewDisconnectFrame(c.ServerAddress, c.ClientAddress))
+}
+
+// Reset brings the connection back to not connected, dropping all pending data
+func (c *HdlcConnection) Reset() {
+	c.State.CurrentState = HdlcStateNotConnected
+	c.resetSequence()
+	c.buffer = nil
+	c.negotiated = nil
+}
+
+// SendApdu segments the APDU and returns the I-frames of the first window.
+// The following windows are returned by ReceiveFrame when the server
+// acknowledges the previous one.
+func (c *HdlcConnection) SendApdu(apdu []byte) ([][]byte, error) {
+	if c.State.CurrentState != HdlcStateIdle {
+		return nil, NewLocalProtocolError(fmt.Sprintf("can't send data when state=%s", c.State.CurrentState))
+	}
+	if len(c.pending) > 0 || len(c.unacknowledged) > 0 {
+		return nil, NewLocalProtocolError("previous data is not acknowledged yet")
+	}
+
+	c.pending = c.segment(apdu)
+	return c.sendWindow()
+}
+
+// ReceiveData adds bytes received from the server to the receive buffer
+func (c *HdlcConnection) ReceiveData(data []byte) {
+	c.buffer = append(c.buffer, data...)
+}
+
+// NextFrame returns the next complete frame in the receive buffer, or nil if
+// more data is needed
+func (c *HdlcConnection) NextFrame() (Frame, error) {
+	for {
+		// skip anything before the opening flag and flags shared between frames
+		for len(c.buffer) > 0 && (c.buffer[0] != HDLCFlag || (len(c.buffer) > 1 && c.buffer[1] == HDLCFlag)) {
+			c.buffer = c.buffer[1:]
+		}
+		if len(c.buffer) < 3 {
+			return nil, nil
+		}
+
+		formatField, err := ExtractFormatFieldFromBytes(c.buffer)
+		if err != nil {
+			// not the start of a frame, search for the next flag
+			c.buffer = c.buffer[1:]
+			continue
+		}
+		frameLength := int(formatField.Length) + 2
+		if len(c.buffer) < frameLength {
+			return nil, nil
+		}
+
+		frameBytes := make([]byte, frameLength)
+		copy(frameBytes, c.buffer[:frameLength])
+		// the closing flag may be the opening flag of the next frame
+		c.buffer = c.buffer[frameLength-1:]
+
+		return FrameFromBytes(frameBytes)
+	}
+}
+
+// ReceiveFrame processes a frame received from the server. It returns the
+// frames to send in reply, like the next window or an acknowledgement, and the
+// APDU when its last segment is received.
+func (c *HdlcConnection) ReceiveFrame(frame Frame) ([][]byte, []byte, error) {
+	previousState := c.State.CurrentState
+	if err := c.State.ProcessFrame(frame); err != nil {
+		return nil, nil, err
+	}
+
+	switch f := frame.(type) {
+	case *UnNumberedAcknowledgmentFrame:
+		switch previousState {
+		case HdlcStateAwaitingConnection:
+			c.resetSequence()
+			return nil, nil, c.applyParameters(f.Payload)
+		case HdlcStateAwaitingDisconnect:
+			c.resetSequence()
+			c.negotiated = nil
+		}
+		return nil, nil, nil
+	case *ReceiveReadyFrame:
+		c.acknowledge(f.ReceiveSequenceNumber)
+		if !f.Final {
+			return nil, nil, nil
+		}
+		// frames not acknowledged are lost, send them again
+		c.sendSequenceNumber = (c.sendSequenceNumber + 8 - uint8(len(c.unacknowledged))) % 8
+		c.pending = append(c.unacknowledged, c.pending...)
+		c.unacknowledged = nil
+		if len(c.pending) == 0 {
+			return nil, nil, nil
+		}
+		reply, err := c.sendWindow()
+		return reply, nil, err
+	case *InformationFrame:
+		return c.receiveInformation(f)
+	default:
+		return nil, nil, NewLocalProtocolError(fmt.Sprintf("can't handle received frame %T", frame))
+	}
+}
+
+func (c *HdlcConnection) receiveInformation(frame *InformationFrame) ([][]byte, []byte, error) {
+	// the server only answers when it has all our frames
+	c.unacknowledged = nil
+
+	if frame.SendSequenceNumber != c.receiveSequenceNumber {
+		// a frame of the window got lost, the server has to send again from V(R)
+		if !frame.Final {
+			return nil, nil, nil
+		}
+		c.receivedInWindow = 0
+		reply, err := c.receiveReady()
+		return reply, nil, err
+	}
+
+	c.receiveSequenceNumber = (c.receiveSequenceNumber + 1) % 8
+	// the LLC header is only checked on the whole APDU, a later segment may
+	// start with the same bytes
+	c.received = append(c.received, frame.Information()...)
+
+	if !frame.Final {
+		c.receivedInWindow++
+		if c.receivedInWindow >= c.WindowSizeReceive {
+			return nil, nil, NewLocalProtocolError(fmt.Sprintf(
+				"server sent more than %d I-frames without final bit", c.WindowSizeReceive))
+		}
+		return nil, nil, nil
+	}
+	c.receivedInWindow = 0
+	if frame.Segmented {
+		// poll the server for the next window
+		reply, err := c.receiveReady()
+		return reply, nil, err
+	}
+
+	information := c.received
+	c.received = nil
+	apdu, err := c.LLC.Unwrap(information)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, apdu, nil
+}
+
+// applyParameters uses the parameters of the UA, without parameters the server
+// uses the defaults
+func (c *HdlcConnection) applyParameters(information []byte) error {
+	server := NewDefaultHdlcParameters()
+	if len(information) > 0 {
+		var err error
+		server, err = (&HdlcParameters{}).FromBytes(information)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.negotiated = negotiate(c.Proposed, server)
+	c.WindowSizeTransmit = c.negotiated.WindowSizeTransmit
+	c.WindowSizeReceive = c.negotiated.WindowSizeReceive
+	c.MaxInformationLength = c.negotiated.MaxInformationLengthTransmit
+	return nil
+}
+
+// receiveReady returns the RR frame acknowledging the received I-frames
+func (c *HdlcConnection) receiveReady() ([][]byte, error) {
+	rr, err := NewReceiveReadyFrameWithPoll(c.ServerAddress, c.ClientAddress, c.receiveSequenceNumber, true)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.send(rr)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{out}, nil
+}
+
+// sendWindow returns the I-frames of the next window, the poll bit is set on
+// the last one
+func (c *HdlcConnection) sendWindow() ([][]byte, error) {
+	count := c.WindowSizeTransmit
+	if count > len(c.pending) {
+		count = len(c.pending)
+	}
+
+	result := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		s := c.pending[0]
+		c.pending = c.pending[1:]
+
+		frame, err := NewInformationFrame(
+			c.ServerAddress,
+			c.ClientAddress,
+			s.payload,
+			c.sendSequenceNumber,
+			c.receiveSequenceNumber,
+			!s.last,
+			i == count-1,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// the segments already carry the LLC header of the APDU
+		frame.LlcHeader = nil
+
+		out, err := c.send(frame)
+		if err != nil {
+			return nil, err
+		}
+		c.sendSequenceNumber = (c.sendSequenceNumber + 1) % 8
+		c.unacknowledged = append(c.unacknowledged, s)
+		result = append(result, out)
+	}
+	return result, nil
+}
+
+// acknowledge drops the sent segments acknowledged by N(R) of the server
+func (c *HdlcConnection) acknowledge(receiveSequenceNumber uint8) {
+	outstanding := int(c.sendSequenceNumber+8-receiveSequenceNumber) % 8
+	if outstanding > len(c.unacknowledged) {
+		return
+	}
+	c.unacknowledged = c.unacknowledged[len(c.unacknowledged)-outstanding:]
+}
+
+// segment splits the APDU wrapped by the LLC in parts fitting in the
+// information field, the LLC header is only sent in the first part
+func (c *HdlcConnection) segment(apdu []byte) []segment {
+	result := make([]segment, 0)
+	information := c.LLC.Wrap(apdu)
+	for len(information) > 0 {
+		n := c.MaxInformationLength
+		if n > len(information) {
+			n = len(information)
+		}
+		result = append(result, segment{payload: information[:n]})
+		information = information[n:]
+	}
+	result[len(result)-1].last = true
+	return result
+}
+
+func (c *HdlcConnection) send(frame interface{ ToBytes() []byte }) ([]byte, error) {
+	if err := c.State.ProcessFrame(frame); err != nil {
+		return nil, err
+	}
+	return frame.ToBytes(), nil
+}
+
+func (c *HdlcConnection) resetSequence() {
+	c.sendSequenceNumber = 0
+	c.receiveSequenceNumber = 0
+	c.pending = nil
+	c.unacknowledged = nil
+	c.received = nil
+	c.receivedInWindow = 0
+}
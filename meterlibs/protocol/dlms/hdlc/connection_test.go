@@ -0,0 +1,267 @@
+package hdlc_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+)
+
+func addresses(t *testing.T) (*hdlc.HdlcAddress, *hdlc.HdlcAddress) {
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+	server, err := hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+	return client, server
+}
+
+func connected(t *testing.T) *hdlc.HdlcConnection {
+	client, server := addresses(t)
+	connection := hdlc.NewHdlcConnection(client, server)
+
+	_, err := connection.Connect()
+	require.NoError(t, err)
+	receive(t, connection, hdlc.NewUnNumberedAcknowledgmentFrame(client, server, nil).ToBytes())
+	require.Equal(t, hdlc.HdlcStateIdle, connection.State.CurrentState)
+
+	return connection
+}
+
+// receive passes the frame to the connection and returns the reply and the APDU
+func receive(t *testing.T, connection *hdlc.HdlcConnection, frameBytes []byte) ([][]byte, []byte) {
+	connection.ReceiveData(frameBytes)
+	frame, err := connection.NextFrame()
+	require.NoError(t, err)
+	require.NotNil(t, frame)
+
+	reply, apdu, err := connection.ReceiveFrame(frame)
+	require.NoError(t, err)
+	return reply, apdu
+}
+
+func serverFrame(t *testing.T, payload []byte, ssn, rsn uint8, segmented, final, first bool) []byte {
+	client, server := addresses(t)
+	frame, err := hdlc.NewInformationFrame(client, server, payload, ssn, rsn, segmented, final)
+	require.NoError(t, err)
+	frame.LlcHeader = nil
+	if first {
+		frame.LlcHeader = []byte(hdlc.LLCResponseHeader)
+	}
+	return frame.ToBytes()
+}
+
+func parseInformation(t *testing.T, frameBytes []byte) *hdlc.InformationFrame {
+	frame, err := (&hdlc.InformationFrame{}).FromBytes(frameBytes)
+	require.NoError(t, err)
+	return frame
+}
+
+func TestHdlcConnection_WindowedSend(t *testing.T) {
+	connection := connected(t)
+	connection.MaxInformationLength = 16
+	require.NoError(t, connection.SetWindowSize(2, 1))
+
+	apdu := bytes.Repeat([]byte{0xAB}, 30)
+	frames, err := connection.SendApdu(apdu)
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+
+	first := parseInformation(t, frames[0])
+	assert.Equal(t, uint8(0), first.SendSequenceNumber)
+	assert.True(t, first.Segmented)
+	assert.False(t, first.Final)
+	assert.Equal(t, []byte(hdlc.LLCCommandHeader), first.LlcHeader)
+	second := parseInformation(t, frames[1])
+	assert.Equal(t, uint8(1), second.SendSequenceNumber)
+	assert.True(t, second.Segmented)
+	assert.True(t, second.Final)
+	assert.Nil(t, second.LlcHeader)
+	assert.Equal(t, hdlc.HdlcStateAwaitingResponse, connection.State.CurrentState)
+
+	client, server := addresses(t)
+	rr, err := hdlc.NewReceiveReadyFrame(client, server, 2)
+	require.NoError(t, err)
+	frames, _ = receive(t, connection, rr.ToBytes())
+	require.Len(t, frames, 1)
+
+	last := parseInformation(t, frames[0])
+	assert.Equal(t, uint8(2), last.SendSequenceNumber)
+	assert.False(t, last.Segmented)
+	assert.True(t, last.Final)
+
+	sent := append(append(first.Payload, second.Payload...), last.Payload...)
+	assert.Equal(t, apdu, sent)
+}
+
+func TestHdlcConnection_RetransmitsUnacknowledged(t *testing.T) {
+	connection := connected(t)
+	connection.MaxInformationLength = 16
+	require.NoError(t, connection.SetWindowSize(2, 1))
+
+	frames, err := connection.SendApdu(bytes.Repeat([]byte{0xAB}, 30))
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+
+	// the server only got the first frame
+	client, server := addresses(t)
+	rr, err := hdlc.NewReceiveReadyFrame(client, server, 1)
+	require.NoError(t, err)
+	frames, _ = receive(t, connection, rr.ToBytes())
+	require.Len(t, frames, 2)
+	assert.Equal(t, uint8(1), parseInformation(t, frames[0]).SendSequenceNumber)
+	assert.Equal(t, uint8(2), parseInformation(t, frames[1]).SendSequenceNumber)
+}
+
+func TestHdlcConnection_WindowedReceive(t *testing.T) {
+	connection := connected(t)
+	require.NoError(t, connection.SetWindowSize(1, 3))
+
+	_, err := connection.SendApdu([]byte{0xC0, 0x01, 0xC1})
+	require.NoError(t, err)
+
+	reply, apdu := receive(t, connection, serverFrame(t, []byte{0x01, 0x02}, 0, 1, true, false, true))
+	assert.Empty(t, reply)
+	assert.Nil(t, apdu)
+
+	reply, apdu = receive(t, connection, serverFrame(t, []byte{0x03, 0x04}, 1, 1, true, true, false))
+	assert.Nil(t, apdu)
+	require.Len(t, reply, 1)
+	// RR with N(R)=2 and the poll bit set
+	assert.Equal(t, byte(0x51), reply[0][5])
+
+	reply, apdu = receive(t, connection, serverFrame(t, []byte{0x05}, 2, 1, false, true, false))
+	assert.Empty(t, reply)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04, 0x05}, apdu)
+	assert.Equal(t, hdlc.HdlcStateIdle, connection.State.CurrentState)
+}
+
+func TestHdlcConnection_ReceiveKeepsSegmentsLikeLlcHeader(t *testing.T) {
+	connection := connected(t)
+	require.NoError(t, connection.SetWindowSize(1, 2))
+
+	_, err := connection.SendApdu([]byte{0xC0, 0x01, 0xC1})
+	require.NoError(t, err)
+
+	receive(t, connection, serverFrame(t, []byte{0x01}, 0, 1, true, false, true))
+	_, apdu := receive(t, connection, serverFrame(t, []byte(hdlc.LLCResponseHeader), 1, 1, false, true, false))
+	assert.Equal(t, append([]byte{0x01}, hdlc.LLCResponseHeader...), apdu)
+}
+
+func TestHdlcConnection_ReceiveUnexpectedLlcHeader(t *testing.T) {
+	connection := connected(t)
+
+	_, err := connection.SendApdu([]byte{0xC0, 0x01, 0xC1})
+	require.NoError(t, err)
+
+	client, server := addresses(t)
+	// the command header instead of the response header
+	frame, err := hdlc.NewInformationFrame(client, server, []byte{0xC4, 0x01}, 0, 1, false, true)
+	require.NoError(t, err)
+	connection.ReceiveData(frame.ToBytes())
+	received, err := connection.NextFrame()
+	require.NoError(t, err)
+	_, _, err = connection.ReceiveFrame(received)
+	var llcError *hdlc.LlcError
+	assert.ErrorAs(t, err, &llcError)
+}
+
+func TestHdlcConnection_ReceiveWindowExceeded(t *testing.T) {
+	connection := connected(t)
+
+	_, err := connection.SendApdu([]byte{0xC0, 0x01, 0xC1})
+	require.NoError(t, err)
+
+	connection.ReceiveData(serverFrame(t, []byte{0x01}, 0, 1, true, false, true))
+	frame, err := connection.NextFrame()
+	require.NoError(t, err)
+	_, _, err = connection.ReceiveFrame(frame)
+	assert.Error(t, err)
+}
+
+func TestHdlcConnection_NextFrameSharedFlags(t *testing.T) {
+	connection := connected(t)
+	require.NoError(t, connection.SetWindowSize(1, 2))
+
+	_, err := connection.SendApdu([]byte{0xC0, 0x01, 0xC1})
+	require.NoError(t, err)
+
+	first := serverFrame(t, []byte{0x01}, 0, 1, false, false, true)
+	second := serverFrame(t, []byte{0x02}, 1, 1, false, true, false)
+	// frames sharing the flag between them, received in two parts
+	data := append(first, second[1:]...)
+	connection.ReceiveData(data[:5])
+	frame, err := connection.NextFrame()
+	require.NoError(t, err)
+	assert.Nil(t, frame)
+
+	connection.ReceiveData(data[5:])
+	for _, expected := range []uint8{0, 1} {
+		frame, err = connection.NextFrame()
+		require.NoError(t, err)
+		require.IsType(t, &hdlc.InformationFrame{}, frame)
+		assert.Equal(t, expected, frame.(*hdlc.InformationFrame).SendSequenceNumber)
+	}
+	frame, err = connection.NextFrame()
+	require.NoError(t, err)
+	assert.Nil(t, frame)
+}
+
+func TestHdlcConnection_NegotiatesParameters(t *testing.T) {
+	client, server := addresses(t)
+	connection := hdlc.NewHdlcConnection(client, server)
+	connection.Proposed = &hdlc.HdlcParameters{
+		MaxInformationLengthTransmit: 256,
+		MaxInformationLengthReceive:  256,
+		WindowSizeTransmit:           3,
+		WindowSizeReceive:            3,
+	}
+
+	snrm, err := connection.Connect()
+	require.NoError(t, err)
+	assert.Equal(t, decodeHexString("8180140502010006020100070400000003080400000003"), snrm[8:len(snrm)-3])
+	assert.Nil(t, connection.Negotiated())
+
+	// the server transmits 128 bytes in windows of 1 frame and receives 256 bytes in windows of 3 frames
+	serverParameters := decodeHexString("81801305018006020100070400000001080400000003")
+	receive(t, connection, hdlc.NewUnNumberedAcknowledgmentFrame(client, server, serverParameters).ToBytes())
+
+	assert.Equal(t, &hdlc.HdlcParameters{
+		MaxInformationLengthTransmit: 256,
+		MaxInformationLengthReceive:  128,
+		WindowSizeTransmit:           3,
+		WindowSizeReceive:            1,
+	}, connection.Negotiated())
+	assert.Equal(t, 3, connection.WindowSizeTransmit)
+	assert.Equal(t, 1, connection.WindowSizeReceive)
+	assert.Equal(t, 256, connection.MaxInformationLength)
+}
+
+func TestHdlcConnection_DefaultParametersWithoutUaInformation(t *testing.T) {
+	connection := connected(t)
+
+	assert.Equal(t, hdlc.NewDefaultHdlcParameters(), connection.Negotiated())
+	assert.Equal(t, hdlc.DefaultMaxInformationLength, connection.MaxInformationLength)
+}
+
+func TestHdlcParameters_FromBytes(t *testing.T) {
+	parameters, err := (&hdlc.HdlcParameters{}).FromBytes(decodeHexString("8180140502008006020080070400000001080400000001"))
+	require.NoError(t, err)
+	assert.Equal(t, hdlc.NewDefaultHdlcParameters(), parameters)
+	assert.Equal(t, decodeHexString("818012050180060180070400000001080400000001"), parameters.ToBytes())
+
+	_, err = (&hdlc.HdlcParameters{}).FromBytes(decodeHexString("818006070400000009"))
+	assert.Error(t, err)
+	_, err = (&hdlc.HdlcParameters{}).FromBytes(decodeHexString("818005070400"))
+	assert.Error(t, err)
+}
+
+func decodeHexString(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -54,3 +54,16 @@ func NewMissingHdlcFlags() *MissingHdlcFlags {
 	}
 }
 
+
+// LlcError represents an unexpected LLC header in the information received
+// from the server
+type LlcError struct {
+	*HdlcParsingError
+}
+
+// NewLlcError creates a new LlcError
+func NewLlcError(message string) *LlcError {
+	return &LlcError{
+		HdlcParsingError: NewHdlcParsingError(fmt.Sprintf("LLC: %s", message)),
+	}
+}
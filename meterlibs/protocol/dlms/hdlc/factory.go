@@ -0,0 +1,130 @@
+package hdlc
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// asFrame converts the result of a FromBytes to a Frame without keeping typed nil pointers
+func asFrame[T Frame](frame T, err error) (Frame, error) {
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// FrameFromBytes parses an HDLC frame, the type is found from the control
+// byte. Frames only the client sends (SNRM, DISC) are parsed with a server
+// destination address, the other frames with a client destination address.
+func FrameFromBytes(frameBytes []byte) (Frame, error) {
+	if !FrameIsEnclosedByHdlcFlags(frameBytes) {
+		return nil, NewMissingHdlcFlags()
+	}
+
+	position := 3
+	// destination and source address, the last byte of each has the LSB set
+	for i := 0; i < 2; i++ {
+		for position < len(frameBytes) && frameBytes[position]&0b00000001 == 0 {
+			position++
+		}
+		position++
+	}
+	if position >= len(frameBytes) {
+		return nil, NewHdlcParsingError("frame too short for control field")
+	}
+
+	control := frameBytes[position]
+	switch {
+	case control&0b00000001 == 0:
+		return asFrame((&InformationFrame{}).FromBytes(frameBytes))
+	case control&0b00001111 == 0b00000001:
+		return asFrame((&ReceiveReadyFrame{}).FromBytes(frameBytes))
+	}
+
+	// unnumbered frames, without the poll/final bit
+	switch control & 0b11101111 {
+	case 0b10000011:
+		return asFrame((&SetNormalResponseModeFrame{}).FromBytes(frameBytes))
+	case 0b01100011:
+		return asFrame((&UnNumberedAcknowledgmentFrame{}).FromBytes(frameBytes))
+	case 0b00000011:
+		return asFrame((&UnnumberedInformationFrame{}).FromBytes(frameBytes))
+	case 0b01000011:
+		return asFrame((&DisconnectFrame{}).FromBytes(frameBytes))
+	case 0b00001111:
+		return asFrame((&DisconnectModeFrame{}).FromBytes(frameBytes))
+	case 0b10000111:
+		return asFrame((&FrameRejectFrame{}).FromBytes(frameBytes))
+	default:
+		return nil, NewHdlcParsingError(fmt.Sprintf("frame with control field 0x%02x is not supported", control))
+	}
+}
+
+// frameFields are the fields of a frame read from bytes, before the frame
+// type is built from them
+type frameFields struct {
+	destination *HdlcAddress
+	source      *HdlcAddress
+	control     byte
+	segmented   bool
+	hcs         []byte
+	information []byte
+	fcs         []byte
+}
+
+// readFrameFields reads the fields of a frame with an optional information
+// field, the HCS is only present with the information field
+func readFrameFields(frameBytes []byte, destinationType, sourceType AddressType) (*frameFields, error) {
+	if !FrameIsEnclosedByHdlcFlags(frameBytes) {
+		return nil, NewMissingHdlcFlags()
+	}
+
+	formatField, err := ExtractFormatFieldFromBytes(frameBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !FrameHasCorrectLength(int(formatField.Length), frameBytes) {
+		return nil, NewHdlcParsingError(fmt.Sprintf(
+			"frame data is not of length specified in frame format field. Should be %d but is %d",
+			formatField.Length, len(frameBytes)))
+	}
+
+	destination, source, err := FindAddressInFrameBytes(frameBytes)
+	if err != nil {
+		return nil, NewHdlcParsingError(err.Error())
+	}
+	fields := &frameFields{segmented: formatField.Segmented}
+	if fields.destination, err = destination.Address(destinationType); err != nil {
+		return nil, err
+	}
+	if fields.source, err = source.Address(sourceType); err != nil {
+		return nil, err
+	}
+
+	// the positions follow the addresses as sent, 1, 2 or 4 bytes each
+	controlPosition := 1 + 2 + destination.Length + source.Length
+	if controlPosition >= len(frameBytes)-3 {
+		return nil, NewHdlcParsingError("frame too short for control field")
+	}
+	fields.control = frameBytes[controlPosition]
+	fields.fcs = frameBytes[len(frameBytes)-3 : len(frameBytes)-1]
+	hcsPosition := controlPosition + 1
+	if hcsPosition+2 < len(frameBytes)-3 {
+		fields.hcs = frameBytes[hcsPosition : hcsPosition+2]
+		fields.information = frameBytes[hcsPosition+2 : len(frameBytes)-3]
+	}
+	return fields, nil
+}
+
+// check verifies the HCS and the FCS read against the ones of the frame built
+// from the fields
+func (f *frameFields) check(frame *BaseHdlcFrame) error {
+	if calculated := frame.parts().HCS(); !bytes.Equal(f.hcs, calculated) && len(calculated) > 0 {
+		return NewHdlcParsingError(fmt.Sprintf("HCS is not correct. Calculated: %v, in data: %v", calculated, f.hcs))
+	}
+	if calculated := frame.FCS(); !bytes.Equal(f.fcs, calculated) {
+		return NewHdlcParsingError(fmt.Sprintf("FCS is not correct. Calculated: %v, in data: %v", calculated, f.fcs))
+	}
+	return nil
+}
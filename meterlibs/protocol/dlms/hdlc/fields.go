@@ -84,24 +84,78 @@ func (d *DisconnectControlField) ToBytes() []byte {
 	return []byte{out}
 }
 
-// ReceiveReadyControlField is an RR-frame for ack
+// DisconnectModeControlField is the control field of a DM-frame, the server
+// answers with it when it is not connected
+type DisconnectModeControlField struct{}
+
+// NewDisconnectModeControlField creates a new DisconnectModeControlField
+func NewDisconnectModeControlField() *DisconnectModeControlField {
+	return &DisconnectModeControlField{}
+}
+
+// IsFinal returns true (always final)
+func (d *DisconnectModeControlField) IsFinal() bool {
+	return true
+}
+
+// ToBytes converts DisconnectModeControlField to bytes
+func (d *DisconnectModeControlField) ToBytes() []byte {
+	out := byte(0b00001111)
+	if d.IsFinal() {
+		out |= 0b00010000
+	}
+	return []byte{out}
+}
+
+// FrameRejectControlField is the control field of a FRMR-frame, the server
+// answers with it when it can't accept a received frame
+type FrameRejectControlField struct{}
+
+// NewFrameRejectControlField creates a new FrameRejectControlField
+func NewFrameRejectControlField() *FrameRejectControlField {
+	return &FrameRejectControlField{}
+}
+
+// IsFinal returns true (always final)
+func (f *FrameRejectControlField) IsFinal() bool {
+	return true
+}
+
+// ToBytes converts FrameRejectControlField to bytes
+func (f *FrameRejectControlField) ToBytes() []byte {
+	out := byte(0b10000111)
+	if f.IsFinal() {
+		out |= 0b00010000
+	}
+	return []byte{out}
+}
+
+// ReceiveReadyControlField is an RR-frame for ack.
+// The poll/final bit tells if the transmission right is handed over to the other station.
 type ReceiveReadyControlField struct {
 	ReceiveSequenceNumber uint8 // 0-7
+	Final                 bool
 }
 
-// NewReceiveReadyControlField creates a new ReceiveReadyControlField
+// NewReceiveReadyControlField creates a new ReceiveReadyControlField with the poll/final bit set
 func NewReceiveReadyControlField(receiveSequenceNumber uint8) (*ReceiveReadyControlField, error) {
+	return NewReceiveReadyControlFieldWithPoll(receiveSequenceNumber, true)
+}
+
+// NewReceiveReadyControlFieldWithPoll creates a new ReceiveReadyControlField
+func NewReceiveReadyControlFieldWithPoll(receiveSequenceNumber uint8, final bool) (*ReceiveReadyControlField, error) {
 	if receiveSequenceNumber > 7 {
 		return nil, fmt.Errorf("sequence number can only be between 0-7, got %d", receiveSequenceNumber)
 	}
 	return &ReceiveReadyControlField{
 		ReceiveSequenceNumber: receiveSequenceNumber,
+		Final:                 final,
 	}, nil
 }
 
-// IsFinal returns true (always final)
+// IsFinal returns the poll/final bit
 func (r *ReceiveReadyControlField) IsFinal() bool {
-	return true
+	return r.Final
 }
 
 // ToBytes converts ReceiveReadyControlField to bytes
@@ -120,12 +174,12 @@ func (r *ReceiveReadyControlField) FromBytes(inByte []byte) (*ReceiveReadyContro
 		return nil, fmt.Errorf("ReceiveReadyControlField can only be 1 byte, got %d", len(inByte))
 	}
 	value := inByte[0]
-	controlFrame := value&0b00000001 != 0
-	if !controlFrame {
-		return nil, fmt.Errorf("frame is an information frame not a ReceiveReadyFrame")
+	if value&0b00001111 != 0b00000001 {
+		return nil, fmt.Errorf("byte 0x%02x is not representing a ReceiveReadyControlField", value)
 	}
 	rsn := (value & 0b11100000) >> 5
-	return NewReceiveReadyControlField(rsn)
+	final := value&0b00010000 != 0
+	return NewReceiveReadyControlFieldWithPoll(rsn, final)
 }
 
 // InformationControlField contains information about the acknowledge frames
@@ -7,9 +7,17 @@ import (
 const (
 	HDLCFlag = 0x7E
 	LLCCommandHeader = "\xe6\xe6\x00"
-	LLCResponseHeader = "\xe7\xe7\x00"
+	LLCResponseHeader = "\xe6\xe7\x00"
 )
 
+// Frame is an HDLC frame of any type
+type Frame interface {
+	ToBytes() []byte
+	ControlField() HdlcControlField
+	// Addresses returns the destination and the source address
+	Addresses() (*HdlcAddress, *HdlcAddress)
+}
+
 // BaseHdlcFrame is the base class for HDLC frames
 // HDLC frames start and end with the HDLC Frame flag 0x7E
 // Frame: Flag (1 byte), Format (2 bytes), Destination Address (1-4 bytes),
@@ -22,6 +30,26 @@ type BaseHdlcFrame struct {
 	Payload          []byte
 	Segmented        bool
 	Final            bool
+
+	// self is the frame embedding the base, so the base methods use the
+	// overridden parts of the specific frame type
+	self frameParts
+}
+
+// frameParts are the parts of a frame that differ per frame type
+type frameParts interface {
+	FrameLength() int
+	HCS() []byte
+	Information() []byte
+	GetControlField() HdlcControlField
+}
+
+// parts returns the specific frame type, or the base if it was not set
+func (b *BaseHdlcFrame) parts() frameParts {
+	if b.self != nil {
+		return b.self
+	}
+	return b
 }
 
 const FixedLengthBytes = 7
@@ -31,7 +59,7 @@ func (b *BaseHdlcFrame) FrameLength() int {
 	return FixedLengthBytes +
 		b.DestinationAddress.Length() +
 		b.SourceAddress.Length() +
-		len(b.Information())
+		len(b.parts().Information())
 }
 
 // HCS returns the Header Check Sequence
@@ -54,16 +82,23 @@ func (b *BaseHdlcFrame) Information() []byte {
 	return b.Payload
 }
 
-// HeaderContent returns the header content for HCS calculation
-func (b *BaseHdlcFrame) HeaderContent() []byte {
-	formatField := &DlmsHdlcFrameFormatField{
-		Length:    uint16(b.FrameLength()),
+// FormatField returns the frame format field, with the length of the frame
+// and the segmentation bit
+func (b *BaseHdlcFrame) FormatField() *DlmsHdlcFrameFormatField {
+	return &DlmsHdlcFrameFormatField{
+		Length:    uint16(b.parts().FrameLength()),
 		Segmented: b.Segmented,
 	}
-	formatBytes := formatField.ToBytes()
+}
+
+// HeaderContent returns the header content for HCS calculation
+func (b *BaseHdlcFrame) HeaderContent() []byte {
+	formatBytes := b.FormatField().ToBytes()
 	
-	controlField := b.GetControlField()
-	controlBytes := controlField.ToBytes()
+	var controlBytes []byte
+	if controlField := b.parts().GetControlField(); controlField != nil {
+		controlBytes = controlField.ToBytes()
+	}
 	
 	result := make([]byte, 0)
 	result = append(result, formatBytes...)
@@ -78,11 +113,11 @@ func (b *BaseHdlcFrame) HeaderContent() []byte {
 func (b *BaseHdlcFrame) FrameContent() []byte {
 	result := make([]byte, 0)
 	result = append(result, b.HeaderContent()...)
-	hcs := b.HCS()
+	hcs := b.parts().HCS()
 	if len(hcs) > 0 {
 		result = append(result, hcs...)
 	}
-	result = append(result, b.Information()...)
+	result = append(result, b.parts().Information()...)
 	return result
 }
 
@@ -96,9 +131,21 @@ func (b *BaseHdlcFrame) ToBytes() []byte {
 	return result
 }
 
-// GetControlField returns the control field (to be implemented by specific frame types)
+// GetControlField returns the control field, it is implemented by the
+// specific frame types. A bare BaseHdlcFrame has no control field and
+// returns nil.
 func (b *BaseHdlcFrame) GetControlField() HdlcControlField {
-	panic("GetControlField must be implemented by specific frame type")
+	return nil
+}
+
+// ControlField returns the control field of the specific frame type
+func (b *BaseHdlcFrame) ControlField() HdlcControlField {
+	return b.parts().GetControlField()
+}
+
+// Addresses returns the destination and the source address
+func (b *BaseHdlcFrame) Addresses() (*HdlcAddress, *HdlcAddress) {
+	return b.DestinationAddress, b.SourceAddress
 }
 
 // ExtractFormatFieldFromBytes extracts the format field from frame bytes
@@ -132,23 +179,39 @@ type SetNormalResponseModeFrame struct {
 
 // NewSetNormalResponseModeFrame creates a new SNRM frame
 func NewSetNormalResponseModeFrame(destinationAddress, sourceAddress *HdlcAddress) *SetNormalResponseModeFrame {
-	return &SetNormalResponseModeFrame{
+	frame := &SetNormalResponseModeFrame{
 		BaseHdlcFrame: &BaseHdlcFrame{
 			DestinationAddress: destinationAddress,
 			SourceAddress:      sourceAddress,
 			Final:              true,
 		},
 	}
+	frame.self = frame
+	return frame
 }
 
-// HCS returns empty bytes (SNRM is an S-frame without information field)
+// NewSetNormalResponseModeFrameWithParameters creates a new SNRM frame proposing
+// the HDLC parameters to the server
+func NewSetNormalResponseModeFrameWithParameters(
+	destinationAddress, sourceAddress *HdlcAddress,
+	parameters *HdlcParameters,
+) *SetNormalResponseModeFrame {
+	frame := NewSetNormalResponseModeFrame(destinationAddress, sourceAddress)
+	frame.Payload = parameters.ToBytes()
+	return frame
+}
+
+// HCS returns HCS if the parameters are present
 func (s *SetNormalResponseModeFrame) HCS() []byte {
+	if len(s.Payload) > 0 {
+		return s.BaseHdlcFrame.HCS()
+	}
 	return []byte{}
 }
 
-// Information returns empty bytes (no information field for SNRM)
+// Information returns the negotiation parameters, if any
 func (s *SetNormalResponseModeFrame) Information() []byte {
-	return []byte{}
+	return s.Payload
 }
 
 // GetControlField returns the SNRM control field
@@ -158,9 +221,14 @@ func (s *SetNormalResponseModeFrame) GetControlField() HdlcControlField {
 
 // FrameLength returns the frame length for SNRM
 func (s *SetNormalResponseModeFrame) FrameLength() int {
-	return 5 + // fixed length without HCS
+	fixed := 7
+	if len(s.Payload) == 0 {
+		fixed = 5 // without HCS
+	}
+	return fixed +
 		s.DestinationAddress.Length() +
-		s.SourceAddress.Length()
+		s.SourceAddress.Length() +
+		len(s.Payload)
 }
 
 // UnNumberedAcknowledgmentFrame (UA-frame) is used to acknowledge SNRM
@@ -170,7 +238,7 @@ type UnNumberedAcknowledgmentFrame struct {
 
 // NewUnNumberedAcknowledgmentFrame creates a new UA frame
 func NewUnNumberedAcknowledgmentFrame(destinationAddress, sourceAddress *HdlcAddress, payload []byte) *UnNumberedAcknowledgmentFrame {
-	return &UnNumberedAcknowledgmentFrame{
+	frame := &UnNumberedAcknowledgmentFrame{
 		BaseHdlcFrame: &BaseHdlcFrame{
 			DestinationAddress: destinationAddress,
 			SourceAddress:      sourceAddress,
@@ -178,6 +246,8 @@ func NewUnNumberedAcknowledgmentFrame(destinationAddress, sourceAddress *HdlcAdd
 			Final:              true,
 		},
 	}
+	frame.self = frame
+	return frame
 }
 
 // FrameLength returns the frame length for UA
@@ -207,59 +277,14 @@ func (u *UnNumberedAcknowledgmentFrame) GetControlField() HdlcControlField {
 
 // FromBytes creates a UA frame from bytes
 func (u *UnNumberedAcknowledgmentFrame) FromBytes(frameBytes []byte) (*UnNumberedAcknowledgmentFrame, error) {
-	if !FrameIsEnclosedByHdlcFlags(frameBytes) {
-		return nil, NewMissingHdlcFlags()
-	}
-
-	formatField, err := ExtractFormatFieldFromBytes(frameBytes)
-	if err != nil {
-		return nil, err
-	}
-
-	if !FrameHasCorrectLength(int(formatField.Length), frameBytes) {
-		return nil, NewHdlcParsingError(fmt.Sprintf(
-			"frame data is not of length specified in frame format field. Should be %d but is %d",
-			formatField.Length, len(frameBytes)))
-	}
-
-	destinationAddress, err := DestinationFromBytes(frameBytes, AddressTypeClient)
+	fields, err := readFrameFields(frameBytes, AddressTypeClient, AddressTypeServer)
 	if err != nil {
 		return nil, err
 	}
-	sourceAddress, err := SourceFromBytes(frameBytes, AddressTypeServer)
-	if err != nil {
+	frame := NewUnNumberedAcknowledgmentFrame(fields.destination, fields.source, fields.information)
+	if err = fields.check(frame.BaseHdlcFrame); err != nil {
 		return nil, err
 	}
-
-	hcsPosition := 1 + 2 + destinationAddress.Length() + sourceAddress.Length() + 1
-	hcs := frameBytes[hcsPosition : hcsPosition+2]
-	fcs := frameBytes[len(frameBytes)-3 : len(frameBytes)-1]
-	information := frameBytes[hcsPosition+2 : len(frameBytes)-3]
-
-	frame := NewUnNumberedAcknowledgmentFrame(destinationAddress, sourceAddress, information)
-
-	if len(frame.HCS()) > 0 {
-		calculatedHCS := frame.HCS()
-		if len(hcs) != len(calculatedHCS) {
-			return nil, NewHdlcParsingError("HCS length mismatch")
-		}
-		for i := range hcs {
-			if hcs[i] != calculatedHCS[i] {
-				return nil, NewHdlcParsingError(fmt.Sprintf("HCS is not correct. Calculated: %v, in data: %v", calculatedHCS, hcs))
-			}
-		}
-	}
-
-	calculatedFCS := frame.FCS()
-	if len(fcs) != len(calculatedFCS) {
-		return nil, NewHdlcParsingError("FCS length mismatch")
-	}
-	for i := range fcs {
-		if fcs[i] != calculatedFCS[i] {
-			return nil, NewHdlcParsingError(fmt.Sprintf("FCS is not correct. Calculated: %v, in data: %v", calculatedFCS, fcs))
-		}
-	}
-
 	return frame, nil
 }
 
@@ -269,19 +294,36 @@ type ReceiveReadyFrame struct {
 	ReceiveSequenceNumber uint8
 }
 
-// NewReceiveReadyFrame creates a new RR frame
+// NewReceiveReadyFrame creates a new RR frame with the poll/final bit set
 func NewReceiveReadyFrame(destinationAddress, sourceAddress *HdlcAddress, receiveSequenceNumber uint8) (*ReceiveReadyFrame, error) {
+	return NewReceiveReadyFrameWithPoll(destinationAddress, sourceAddress, receiveSequenceNumber, true)
+}
+
+// NewReceiveReadyFrameWithPoll creates a new RR frame. With final false the frame
+// acknowledges received frames without handing over the transmission right.
+func NewReceiveReadyFrameWithPoll(destinationAddress, sourceAddress *HdlcAddress, receiveSequenceNumber uint8, final bool) (*ReceiveReadyFrame, error) {
+	if receiveSequenceNumber > 7 {
+		return nil, fmt.Errorf("sequence number can only be between 0-7, got %d", receiveSequenceNumber)
+	}
 	rr := &ReceiveReadyFrame{
 		BaseHdlcFrame: &BaseHdlcFrame{
 			DestinationAddress: destinationAddress,
 			SourceAddress:      sourceAddress,
-			Final:              true,
+			Final:              final,
 		},
 		ReceiveSequenceNumber: receiveSequenceNumber,
 	}
+	rr.self = rr
 	return rr, nil
 }
 
+// FrameLength returns the frame length for RR
+func (r *ReceiveReadyFrame) FrameLength() int {
+	return 5 + // fixed length without HCS
+		r.DestinationAddress.Length() +
+		r.SourceAddress.Length()
+}
+
 // HCS returns empty bytes (no information field)
 func (r *ReceiveReadyFrame) HCS() []byte {
 	return []byte{}
@@ -294,61 +336,27 @@ func (r *ReceiveReadyFrame) Information() []byte {
 
 // GetControlField returns the RR control field
 func (r *ReceiveReadyFrame) GetControlField() HdlcControlField {
-	control, _ := NewReceiveReadyControlField(r.ReceiveSequenceNumber)
+	control, _ := NewReceiveReadyControlFieldWithPoll(r.ReceiveSequenceNumber, r.Final)
 	return control
 }
 
 // FromBytes creates a RR frame from bytes
 func (r *ReceiveReadyFrame) FromBytes(frameBytes []byte) (*ReceiveReadyFrame, error) {
-	if !FrameIsEnclosedByHdlcFlags(frameBytes) {
-		return nil, NewMissingHdlcFlags()
-	}
-
-	formatField, err := ExtractFormatFieldFromBytes(frameBytes)
+	fields, err := readFrameFields(frameBytes, AddressTypeClient, AddressTypeServer)
 	if err != nil {
 		return nil, err
 	}
-
-	if !FrameHasCorrectLength(int(formatField.Length), frameBytes) {
-		return nil, NewHdlcParsingError(fmt.Sprintf(
-			"frame data is not of length specified in frame format field. Should be %d but is %d",
-			formatField.Length, len(frameBytes)))
-	}
-
-	destinationAddress, err := DestinationFromBytes(frameBytes, AddressTypeClient)
+	control, err := (&ReceiveReadyControlField{}).FromBytes([]byte{fields.control})
 	if err != nil {
 		return nil, err
 	}
-	sourceAddress, err := SourceFromBytes(frameBytes, AddressTypeServer)
+	frame, err := NewReceiveReadyFrameWithPoll(fields.destination, fields.source, control.ReceiveSequenceNumber, control.Final)
 	if err != nil {
 		return nil, err
 	}
-
-	controlBytePosition := 1 + 2 + destinationAddress.Length() + sourceAddress.Length()
-	controlByte := frameBytes[controlBytePosition : controlBytePosition+1]
-	controlField := &ReceiveReadyControlField{}
-	control, err := controlField.FromBytes(controlByte)
-	if err != nil {
-		return nil, err
-	}
-
-	fcs := frameBytes[len(frameBytes)-3 : len(frameBytes)-1]
-
-	frame, err := NewReceiveReadyFrame(destinationAddress, sourceAddress, control.ReceiveSequenceNumber)
-	if err != nil {
+	if err = fields.check(frame.BaseHdlcFrame); err != nil {
 		return nil, err
 	}
-
-	calculatedFCS := frame.FCS()
-	if len(fcs) != len(calculatedFCS) {
-		return nil, NewHdlcParsingError("FCS length mismatch")
-	}
-	for i := range fcs {
-		if fcs[i] != calculatedFCS[i] {
-			return nil, NewHdlcParsingError("FCS is not correct")
-		}
-	}
-
 	return frame, nil
 }
 
@@ -357,6 +365,9 @@ type InformationFrame struct {
 	*BaseHdlcFrame
 	SendSequenceNumber    uint8
 	ReceiveSequenceNumber uint8
+	// LlcHeader is put in front of the payload. Only the first frame of a
+	// segmented APDU carries it, it is nil on the following segments.
+	LlcHeader []byte
 }
 
 // NewInformationFrame creates a new Information frame
@@ -366,7 +377,7 @@ func NewInformationFrame(
 	sendSequenceNumber, receiveSequenceNumber uint8,
 	segmented, final bool,
 ) (*InformationFrame, error) {
-	return &InformationFrame{
+	frame := &InformationFrame{
 		BaseHdlcFrame: &BaseHdlcFrame{
 			DestinationAddress: destinationAddress,
 			SourceAddress:      sourceAddress,
@@ -376,16 +387,16 @@ func NewInformationFrame(
 		},
 		SendSequenceNumber:    sendSequenceNumber,
 		ReceiveSequenceNumber: receiveSequenceNumber,
-	}, nil
+		LlcHeader:             []byte(LLCCommandHeader),
+	}
+	frame.self = frame
+	return frame, nil
 }
 
 // Information returns the information field with LLC header
 func (i *InformationFrame) Information() []byte {
-	if len(i.Payload) == 0 {
-		return []byte{}
-	}
 	result := make([]byte, 0)
-	result = append(result, []byte(LLCCommandHeader)...)
+	result = append(result, i.LlcHeader...)
 	result = append(result, i.Payload...)
 	return result
 }
@@ -398,85 +409,86 @@ func (i *InformationFrame) GetControlField() HdlcControlField {
 
 // FromBytes creates an Information frame from bytes
 func (i *InformationFrame) FromBytes(frameBytes []byte) (*InformationFrame, error) {
-	if !FrameIsEnclosedByHdlcFlags(frameBytes) {
-		return nil, NewMissingHdlcFlags()
-	}
-
-	formatField, err := ExtractFormatFieldFromBytes(frameBytes)
-	if err != nil {
-		return nil, err
-	}
-
-	if !FrameHasCorrectLength(int(formatField.Length), frameBytes) {
-		return nil, NewHdlcParsingError(fmt.Sprintf(
-			"frame data is not of length specified in frame format field. Should be %d but is %d",
-			formatField.Length, len(frameBytes)))
-	}
-
-	destinationAddress, err := DestinationFromBytes(frameBytes, AddressTypeClient)
+	fields, err := readFrameFields(frameBytes, AddressTypeClient, AddressTypeServer)
 	if err != nil {
 		return nil, err
 	}
-	sourceAddress, err := SourceFromBytes(frameBytes, AddressTypeServer)
+	control, err := (&InformationControlField{}).FromBytes([]byte{fields.control})
 	if err != nil {
 		return nil, err
 	}
 
-	informationControlBytePosition := 1 + 2 + destinationAddress.Length() + sourceAddress.Length()
-	informationControlByte := frameBytes[informationControlBytePosition : informationControlBytePosition+1]
-	controlField := &InformationControlField{}
-	informationControl, err := controlField.FromBytes(informationControlByte)
-	if err != nil {
-		return nil, err
-	}
-
-	hcsPosition := 1 + 2 + destinationAddress.Length() + sourceAddress.Length() + 1
-	hcs := frameBytes[hcsPosition : hcsPosition+2]
-	fcs := frameBytes[len(frameBytes)-3 : len(frameBytes)-1]
-	information := frameBytes[hcsPosition+2 : len(frameBytes)-3]
-
 	// Remove LLC header if present
-	payload := information
-	if len(information) >= 3 && string(information[:3]) == LLCCommandHeader {
-		payload = information[3:]
-	} else if len(information) >= 3 && string(information[:3]) == LLCResponseHeader {
-		payload = information[3:]
+	payload := fields.information
+	var llcHeader []byte
+	if len(payload) >= 3 && (string(payload[:3]) == LLCCommandHeader || string(payload[:3]) == LLCResponseHeader) {
+		llcHeader, payload = payload[:3], payload[3:]
 	}
 
 	frame, err := NewInformationFrame(
-		destinationAddress,
-		sourceAddress,
+		fields.destination,
+		fields.source,
 		payload,
-		informationControl.SendSequenceNumber,
-		informationControl.ReceiveSequenceNumber,
-		formatField.Segmented,
-		informationControl.Final,
+		control.SendSequenceNumber,
+		control.ReceiveSequenceNumber,
+		fields.segmented,
+		control.Final,
 	)
 	if err != nil {
 		return nil, err
 	}
-
-	calculatedHCS := frame.HCS()
-	if len(hcs) != len(calculatedHCS) {
-		return nil, NewHdlcParsingError("HCS length mismatch")
-	}
-	for i := range hcs {
-		if hcs[i] != calculatedHCS[i] {
-			return nil, NewHdlcParsingError(fmt.Sprintf("HCS is not correct. Calculated: %v, in data: %v", calculatedHCS, hcs))
-		}
+	frame.LlcHeader = llcHeader
+	if err = fields.check(frame.BaseHdlcFrame); err != nil {
+		return nil, err
 	}
+	return frame, nil
+}
 
-	calculatedFCS := frame.FCS()
-	if len(fcs) != len(calculatedFCS) {
-		return nil, NewHdlcParsingError("FCS length mismatch")
-	}
-	for i := range fcs {
-		if fcs[i] != calculatedFCS[i] {
-			return nil, NewHdlcParsingError(fmt.Sprintf("FCS is not correct. Calculated: %v, in data: %v", calculatedFCS, fcs))
+// MaxFrameLength is the largest frame length the format field can hold
+const MaxFrameLength = 0b11111111111
+
+// Segment splits an I-frame whose information field is longer than
+// maxInfoSize into I-frames with at most maxInfoSize bytes of information.
+// The LLC header is only sent in the first segment. All segments but the last
+// have the segmentation bit set, the last one keeps the bit of the frame. The
+// segments get consecutive send sequence numbers starting with the one of the
+// frame, the other fields are copied.
+func Segment(frame *InformationFrame, maxInfoSize int) ([]*InformationFrame, error) {
+	if maxInfoSize <= len(frame.LlcHeader) {
+		return nil, fmt.Errorf("information field of %d bytes can't hold the LLC header", maxInfoSize)
+	}
+	overhead := FixedLengthBytes + frame.DestinationAddress.Length() + frame.SourceAddress.Length()
+	if overhead+maxInfoSize > MaxFrameLength {
+		return nil, fmt.Errorf("information field of %d bytes doesn't fit in the frame format field", maxInfoSize)
+	}
+
+	result := make([]*InformationFrame, 0)
+	payload := frame.Payload
+	size := maxInfoSize - len(frame.LlcHeader)
+	for first := true; first || len(payload) > 0; first = false {
+		n := min(size, len(payload))
+		segment, err := NewInformationFrame(
+			frame.DestinationAddress,
+			frame.SourceAddress,
+			payload[:n],
+			(frame.SendSequenceNumber+uint8(len(result)))%8,
+			frame.ReceiveSequenceNumber,
+			true,
+			frame.Final,
+		)
+		if err != nil {
+			return nil, err
 		}
+		segment.LlcHeader = nil
+		if first {
+			segment.LlcHeader = frame.LlcHeader
+		}
+		result = append(result, segment)
+		payload = payload[n:]
+		size = maxInfoSize
 	}
-
-	return frame, nil
+	result[len(result)-1].Segmented = frame.Segmented
+	return result, nil
 }
 
 // DisconnectFrame is used to disconnect HDLC connection
@@ -486,13 +498,22 @@ type DisconnectFrame struct {
 
 // NewDisconnectFrame creates a new Disconnect frame
 func NewDisconnectFrame(destinationAddress, sourceAddress *HdlcAddress) *DisconnectFrame {
-	return &DisconnectFrame{
+	frame := &DisconnectFrame{
 		BaseHdlcFrame: &BaseHdlcFrame{
 			DestinationAddress: destinationAddress,
 			SourceAddress:      sourceAddress,
 			Final:              true,
 		},
 	}
+	frame.self = frame
+	return frame
+}
+
+// FrameLength returns the frame length for DISC
+func (d *DisconnectFrame) FrameLength() int {
+	return 5 + // fixed length without HCS
+		d.DestinationAddress.Length() +
+		d.SourceAddress.Length()
 }
 
 // HCS returns empty bytes (no information field)
@@ -512,44 +533,197 @@ func (d *DisconnectFrame) GetControlField() HdlcControlField {
 
 // FromBytes creates a Disconnect frame from bytes
 func (d *DisconnectFrame) FromBytes(frameBytes []byte) (*DisconnectFrame, error) {
-	if !FrameIsEnclosedByHdlcFlags(frameBytes) {
-		return nil, NewMissingHdlcFlags()
+	fields, err := readFrameFields(frameBytes, AddressTypeServer, AddressTypeClient)
+	if err != nil {
+		return nil, err
+	}
+	frame := NewDisconnectFrame(fields.destination, fields.source)
+	if err = fields.check(frame.BaseHdlcFrame); err != nil {
+		return nil, err
 	}
+	return frame, nil
+}
 
-	formatField, err := ExtractFormatFieldFromBytes(frameBytes)
+// unnumberedFrameLength is the length of an unnumbered frame, the HCS is only
+// present with an information field
+func unnumberedFrameLength(b *BaseHdlcFrame) int {
+	fixed := 7
+	if len(b.Payload) == 0 {
+		fixed = 5 // without HCS
+	}
+	return fixed +
+		b.DestinationAddress.Length() +
+		b.SourceAddress.Length() +
+		len(b.Payload)
+}
+
+// unnumberedHCS returns the HCS of an unnumbered frame if the information
+// field is present
+func unnumberedHCS(b *BaseHdlcFrame) []byte {
+	if len(b.Payload) > 0 {
+		return b.HCS()
+	}
+	return []byte{}
+}
+
+// FromBytes creates a SNRM frame from bytes
+func (s *SetNormalResponseModeFrame) FromBytes(frameBytes []byte) (*SetNormalResponseModeFrame, error) {
+	fields, err := readFrameFields(frameBytes, AddressTypeServer, AddressTypeClient)
 	if err != nil {
 		return nil, err
 	}
+	frame := NewSetNormalResponseModeFrame(fields.destination, fields.source)
+	frame.Payload = fields.information
+	if err = fields.check(frame.BaseHdlcFrame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// UnnumberedInformationFrame (UI-frame) carries data outside of the sequence
+// numbering, meters use it to push data without a connection
+type UnnumberedInformationFrame struct {
+	*BaseHdlcFrame
+}
 
-	if !FrameHasCorrectLength(int(formatField.Length), frameBytes) {
-		return nil, NewHdlcParsingError(fmt.Sprintf(
-			"frame data is not of length specified in frame format field. Should be %d but is %d",
-			formatField.Length, len(frameBytes)))
+// NewUnnumberedInformationFrame creates a new UI frame
+func NewUnnumberedInformationFrame(destinationAddress, sourceAddress *HdlcAddress, payload []byte, final bool) *UnnumberedInformationFrame {
+	frame := &UnnumberedInformationFrame{
+		BaseHdlcFrame: &BaseHdlcFrame{
+			DestinationAddress: destinationAddress,
+			SourceAddress:      sourceAddress,
+			Payload:            payload,
+			Final:              final,
+		},
 	}
+	frame.self = frame
+	return frame
+}
+
+// FrameLength returns the frame length for UI
+func (u *UnnumberedInformationFrame) FrameLength() int {
+	return unnumberedFrameLength(u.BaseHdlcFrame)
+}
+
+// HCS returns HCS if information field is present
+func (u *UnnumberedInformationFrame) HCS() []byte {
+	return unnumberedHCS(u.BaseHdlcFrame)
+}
 
-	destinationAddress, err := DestinationFromBytes(frameBytes, AddressTypeServer)
+// GetControlField returns the UI control field
+func (u *UnnumberedInformationFrame) GetControlField() HdlcControlField {
+	return NewUnnumberedInformationControlField(u.Final)
+}
+
+// FromBytes creates a UI frame from bytes
+func (u *UnnumberedInformationFrame) FromBytes(frameBytes []byte) (*UnnumberedInformationFrame, error) {
+	fields, err := readFrameFields(frameBytes, AddressTypeClient, AddressTypeServer)
 	if err != nil {
 		return nil, err
 	}
-	sourceAddress, err := SourceFromBytes(frameBytes, AddressTypeClient)
+	control, err := (&UnnumberedInformationControlField{}).FromBytes([]byte{fields.control})
 	if err != nil {
 		return nil, err
 	}
+	frame := NewUnnumberedInformationFrame(fields.destination, fields.source, fields.information, control.Final)
+	if err = fields.check(frame.BaseHdlcFrame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
 
-	fcs := frameBytes[len(frameBytes)-3 : len(frameBytes)-1]
+// DisconnectModeFrame (DM-frame) is the answer of a server that is not
+// connected, to a DISC or to any frame but a SNRM
+type DisconnectModeFrame struct {
+	*BaseHdlcFrame
+}
+
+// NewDisconnectModeFrame creates a new DM frame
+func NewDisconnectModeFrame(destinationAddress, sourceAddress *HdlcAddress, payload []byte) *DisconnectModeFrame {
+	frame := &DisconnectModeFrame{
+		BaseHdlcFrame: &BaseHdlcFrame{
+			DestinationAddress: destinationAddress,
+			SourceAddress:      sourceAddress,
+			Payload:            payload,
+			Final:              true,
+		},
+	}
+	frame.self = frame
+	return frame
+}
+
+// FrameLength returns the frame length for DM
+func (d *DisconnectModeFrame) FrameLength() int {
+	return unnumberedFrameLength(d.BaseHdlcFrame)
+}
+
+// HCS returns HCS if information field is present
+func (d *DisconnectModeFrame) HCS() []byte {
+	return unnumberedHCS(d.BaseHdlcFrame)
+}
 
-	frame := NewDisconnectFrame(destinationAddress, sourceAddress)
+// GetControlField returns the DM control field
+func (d *DisconnectModeFrame) GetControlField() HdlcControlField {
+	return NewDisconnectModeControlField()
+}
 
-	calculatedFCS := frame.FCS()
-	if len(fcs) != len(calculatedFCS) {
-		return nil, NewHdlcParsingError("FCS length mismatch")
+// FromBytes creates a DM frame from bytes
+func (d *DisconnectModeFrame) FromBytes(frameBytes []byte) (*DisconnectModeFrame, error) {
+	fields, err := readFrameFields(frameBytes, AddressTypeClient, AddressTypeServer)
+	if err != nil {
+		return nil, err
 	}
-	for i := range fcs {
-		if fcs[i] != calculatedFCS[i] {
-			return nil, NewHdlcParsingError("FCS is not correct")
-		}
+	frame := NewDisconnectModeFrame(fields.destination, fields.source, fields.information)
+	if err = fields.check(frame.BaseHdlcFrame); err != nil {
+		return nil, err
 	}
-
 	return frame, nil
 }
 
+// FrameRejectFrame (FRMR-frame) is the answer of the server to a frame it
+// can't accept, the information field tells why
+type FrameRejectFrame struct {
+	*BaseHdlcFrame
+}
+
+// NewFrameRejectFrame creates a new FRMR frame
+func NewFrameRejectFrame(destinationAddress, sourceAddress *HdlcAddress, payload []byte) *FrameRejectFrame {
+	frame := &FrameRejectFrame{
+		BaseHdlcFrame: &BaseHdlcFrame{
+			DestinationAddress: destinationAddress,
+			SourceAddress:      sourceAddress,
+			Payload:            payload,
+			Final:              true,
+		},
+	}
+	frame.self = frame
+	return frame
+}
+
+// FrameLength returns the frame length for FRMR
+func (f *FrameRejectFrame) FrameLength() int {
+	return unnumberedFrameLength(f.BaseHdlcFrame)
+}
+
+// HCS returns HCS if information field is present
+func (f *FrameRejectFrame) HCS() []byte {
+	return unnumberedHCS(f.BaseHdlcFrame)
+}
+
+// GetControlField returns the FRMR control field
+func (f *FrameRejectFrame) GetControlField() HdlcControlField {
+	return NewFrameRejectControlField()
+}
+
+// FromBytes creates a FRMR frame from bytes
+func (f *FrameRejectFrame) FromBytes(frameBytes []byte) (*FrameRejectFrame, error) {
+	fields, err := readFrameFields(frameBytes, AddressTypeClient, AddressTypeServer)
+	if err != nil {
+		return nil, err
+	}
+	frame := NewFrameRejectFrame(fields.destination, fields.source, fields.information)
+	if err = fields.check(frame.BaseHdlcFrame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
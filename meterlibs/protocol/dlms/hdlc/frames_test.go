@@ -0,0 +1,222 @@
+package hdlc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+)
+
+func TestReceiveReadyControlField_PollBit(t *testing.T) {
+	final, err := hdlc.NewReceiveReadyControlField(3)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x71}, final.ToBytes())
+
+	notFinal, err := hdlc.NewReceiveReadyControlFieldWithPoll(3, false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x61}, notFinal.ToBytes())
+
+	parsed, err := (&hdlc.ReceiveReadyControlField{}).FromBytes([]byte{0x61})
+	require.NoError(t, err)
+	assert.False(t, parsed.IsFinal())
+	assert.Equal(t, uint8(3), parsed.ReceiveSequenceNumber)
+
+	parsed, err = (&hdlc.ReceiveReadyControlField{}).FromBytes([]byte{0x71})
+	require.NoError(t, err)
+	assert.True(t, parsed.IsFinal())
+
+	_, err = (&hdlc.ReceiveReadyControlField{}).FromBytes([]byte{0x10})
+	assert.Error(t, err)
+}
+
+func TestReceiveReadyFrame_PollBit(t *testing.T) {
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+	server, err := hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+
+	for _, final := range []bool{true, false} {
+		frame, err := hdlc.NewReceiveReadyFrameWithPoll(client, server, 5, final)
+		require.NoError(t, err)
+
+		frameBytes := frame.ToBytes()
+		assert.Len(t, frameBytes, 9)
+
+		parsed, err := (&hdlc.ReceiveReadyFrame{}).FromBytes(frameBytes)
+		require.NoError(t, err)
+		assert.Equal(t, final, parsed.Final)
+		assert.Equal(t, uint8(5), parsed.ReceiveSequenceNumber)
+		assert.Equal(t, frameBytes, parsed.ToBytes())
+	}
+
+	_, err = hdlc.NewReceiveReadyFrameWithPoll(client, server, 8, false)
+	assert.Error(t, err)
+}
+
+func TestSegment(t *testing.T) {
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+	server, err := hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+
+	payload := make([]byte, 300)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	frame, err := hdlc.NewInformationFrame(server, client, payload, 6, 3, false, true)
+	require.NoError(t, err)
+
+	segments, err := hdlc.Segment(frame, 128)
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	var joined []byte
+	for i, segment := range segments {
+		assert.Equal(t, uint8((6+i)%8), segment.SendSequenceNumber)
+		assert.Equal(t, uint8(3), segment.ReceiveSequenceNumber)
+		assert.LessOrEqual(t, len(segment.Information()), 128)
+
+		frameBytes := segment.ToBytes()
+		formatField, err := hdlc.ExtractFormatFieldFromBytes(frameBytes)
+		require.NoError(t, err)
+		assert.Equal(t, int(formatField.Length), len(frameBytes)-2)
+		assert.Equal(t, i < 2, formatField.Segmented)
+		assert.Equal(t, segment.FormatField(), formatField)
+
+		parsed, err := (&hdlc.InformationFrame{}).FromBytes(frameBytes)
+		require.NoError(t, err)
+		assert.Equal(t, frameBytes, parsed.ToBytes())
+		joined = append(joined, segment.Payload...)
+	}
+	assert.Equal(t, []byte{0xe6, 0xe6, 0x00}, segments[0].LlcHeader)
+	assert.Nil(t, segments[1].LlcHeader)
+	assert.Len(t, segments[0].Payload, 125)
+	assert.Len(t, segments[2].Payload, 47)
+	assert.Equal(t, payload, joined)
+
+	segments, err = hdlc.Segment(frame, 512)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	assert.Equal(t, frame.ToBytes(), segments[0].ToBytes())
+
+	_, err = hdlc.Segment(frame, 3)
+	assert.Error(t, err)
+	_, err = hdlc.Segment(frame, 2048)
+	assert.Error(t, err)
+}
+
+func TestFrameFromBytes(t *testing.T) {
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+	server, err := hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+
+	information, err := hdlc.NewInformationFrame(client, server, []byte{0xC4, 0x01}, 2, 3, false, true)
+	require.NoError(t, err)
+	receiveReady, err := hdlc.NewReceiveReadyFrame(client, server, 4)
+	require.NoError(t, err)
+
+	for _, frame := range []hdlc.Frame{
+		hdlc.NewSetNormalResponseModeFrame(server, client),
+		hdlc.NewSetNormalResponseModeFrameWithParameters(server, client, hdlc.NewDefaultHdlcParameters()),
+		hdlc.NewUnNumberedAcknowledgmentFrame(client, server, nil),
+		information,
+		receiveReady,
+		hdlc.NewUnnumberedInformationFrame(client, server, []byte{0xE6, 0xE7, 0x00, 0x0F}, true),
+		hdlc.NewDisconnectFrame(server, client),
+		hdlc.NewDisconnectModeFrame(client, server, nil),
+		hdlc.NewFrameRejectFrame(client, server, []byte{0x10, 0x00, 0x01}),
+	} {
+		frameBytes := frame.ToBytes()
+		parsed, err := hdlc.FrameFromBytes(frameBytes)
+		require.NoError(t, err, "%T", frame)
+		assert.IsType(t, frame, parsed)
+		assert.Equal(t, frameBytes, parsed.ToBytes())
+		assert.Equal(t, frame.ControlField().ToBytes(), parsed.ControlField().ToBytes())
+
+		destination, source := parsed.Addresses()
+		expectedDestination, expectedSource := frame.Addresses()
+		assert.Equal(t, expectedDestination.ToBytes(), destination.ToBytes())
+		assert.Equal(t, expectedSource.ToBytes(), source.ToBytes())
+	}
+
+	frameBytes := hdlc.NewDisconnectModeFrame(client, server, nil).ToBytes()
+	frameBytes[len(frameBytes)-2] ^= 0xFF
+	_, err = hdlc.FrameFromBytes(frameBytes)
+	assert.Error(t, err)
+
+	// RNR frames are not supported
+	frameBytes = hdlc.NewDisconnectModeFrame(client, server, nil).ToBytes()
+	frameBytes[5] = 0x15
+	_, err = hdlc.FrameFromBytes(frameBytes)
+	assert.Error(t, err)
+}
+
+func TestBaseHdlcFrame_WithoutControlField(t *testing.T) {
+	destination, err := hdlc.NewHdlcAddress(1, nil, hdlc.AddressTypeServer, false)
+	require.NoError(t, err)
+	source, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+
+	frame := &hdlc.BaseHdlcFrame{DestinationAddress: destination, SourceAddress: source}
+	assert.Nil(t, frame.ControlField())
+	assert.NotPanics(t, func() { frame.ToBytes() })
+}
+
+func TestFrameFromBytes_ServerAddressLengths(t *testing.T) {
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+	physical, extendedPhysical := 17, 12345
+
+	for _, test := range []struct {
+		name     string
+		physical *int
+		extended bool
+		length   int
+	}{
+		{"1 byte", nil, false, 1},
+		{"2 bytes", &physical, false, 2},
+		{"4 bytes", &extendedPhysical, true, 4},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			server, err := hdlc.NewHdlcAddress(1, test.physical, hdlc.AddressTypeServer, test.extended)
+			require.NoError(t, err)
+			require.Equal(t, test.length, server.Length())
+
+			information, err := hdlc.NewInformationFrame(client, server, []byte{0xC4, 0x01, 0xC1, 0x00}, 2, 3, true, true)
+			require.NoError(t, err)
+			information.LlcHeader = []byte(hdlc.LLCResponseHeader)
+			receiveReady, err := hdlc.NewReceiveReadyFrame(client, server, 4)
+			require.NoError(t, err)
+
+			for _, frame := range []hdlc.Frame{
+				hdlc.NewUnNumberedAcknowledgmentFrame(client, server, nil),
+				hdlc.NewUnNumberedAcknowledgmentFrame(client, server, hdlc.NewDefaultHdlcParameters().ToBytes()),
+				receiveReady,
+				information,
+			} {
+				frameBytes := frame.ToBytes()
+				parsed, err := hdlc.FrameFromBytes(frameBytes)
+				require.NoError(t, err, "%T", frame)
+				assert.Equal(t, frameBytes, parsed.ToBytes(), "%T", frame)
+
+				_, source := parsed.Addresses()
+				assert.True(t, server.Equal(source), "%T", frame)
+			}
+
+			parsed, err := (&hdlc.InformationFrame{}).FromBytes(information.ToBytes())
+			require.NoError(t, err)
+			assert.Equal(t, []byte{0xC4, 0x01, 0xC1, 0x00}, parsed.Payload)
+			assert.True(t, parsed.Segmented)
+			assert.Equal(t, uint8(2), parsed.SendSequenceNumber)
+			assert.Equal(t, uint8(3), parsed.ReceiveSequenceNumber)
+		})
+	}
+}
+
+func TestFindAddressInFrameBytes_InvalidLength(t *testing.T) {
+	// a destination address ending on its third byte
+	_, _, err := hdlc.FindAddressInFrameBytes([]byte{0x7E, 0xA0, 0x0A, 0x00, 0x02, 0x03, 0x21, 0x93})
+	assert.Error(t, err)
+}
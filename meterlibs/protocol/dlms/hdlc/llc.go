@@ -0,0 +1,130 @@
+package hdlc
+
+import "fmt"
+
+const (
+	// LLCDestinationLSAP is the destination LSAP of all DLMS/COSEM LLC headers
+	LLCDestinationLSAP = 0xE6
+	// LLCCommandSourceLSAP is the source LSAP of the frames sent by the client
+	LLCCommandSourceLSAP = 0xE6
+	// LLCResponseSourceLSAP is the source LSAP of the frames sent by the server
+	LLCResponseSourceLSAP = 0xE7
+	// LLCQuality is the reserved LLC quality byte
+	LLCQuality = 0x00
+)
+
+// LLCValidation tells how the LLC header of the information received from the
+// server is checked
+type LLCValidation int
+
+const (
+	// LLCRequire rejects the information without a valid response header
+	LLCRequire LLCValidation = iota
+	// LLCWarn accepts the information with a missing or unexpected header and
+	// reports it to Warn
+	LLCWarn
+	// LLCIgnore accepts the information with a missing or unexpected header
+	LLCIgnore
+)
+
+// LLC is the logical link control layer between HDLC and the APDUs. The LLC
+// header is put in front of each APDU, it is only carried by the first
+// segment of the APDU.
+type LLC struct {
+	// QualityByte tells whether the headers end with the quality byte. It is
+	// part of the header in IEC 62056-46, some servers leave it out.
+	QualityByte bool
+	// Validation tells how the response header is checked
+	Validation LLCValidation
+	// AcceptCommandHeader accepts the command header in the information
+	// received, some firmwares answer with it
+	AcceptCommandHeader bool
+	// Warn is given the header problems accepted with LLCWarn
+	Warn func(message string)
+}
+
+// NewLLC creates a new LLC with the quality byte
+func NewLLC() *LLC {
+	return &LLC{QualityByte: true}
+}
+
+// CommandHeader returns the LLC header of the APDUs sent by the client
+func (l *LLC) CommandHeader() []byte {
+	return l.header(LLCCommandSourceLSAP)
+}
+
+// ResponseHeader returns the LLC header of the APDUs sent by the server
+func (l *LLC) ResponseHeader() []byte {
+	return l.header(LLCResponseSourceLSAP)
+}
+
+// HeaderLength returns the length of the LLC header
+func (l *LLC) HeaderLength() int {
+	if l.QualityByte {
+		return 3
+	}
+	return 2
+}
+
+func (l *LLC) header(sourceLSAP byte) []byte {
+	header := []byte{LLCDestinationLSAP, sourceLSAP}
+	if l.QualityByte {
+		header = append(header, LLCQuality)
+	}
+	return header
+}
+
+// Wrap puts the command header in front of an APDU sent by the client
+func (l *LLC) Wrap(apdu []byte) []byte {
+	result := l.CommandHeader()
+	return append(result, apdu...)
+}
+
+// Unwrap validates the response header of the information received from the
+// server and returns the APDU behind it. Unless the validation is LLCRequire,
+// an unexpected header is stripped and a missing one is left out.
+func (l *LLC) Unwrap(information []byte) ([]byte, error) {
+	apdu, err := l.unwrap(information)
+	if err == nil || l.Validation == LLCRequire {
+		return apdu, err
+	}
+	if l.Validation == LLCWarn && l.Warn != nil {
+		l.Warn(err.Error())
+	}
+	return l.strip(information), nil
+}
+
+func (l *LLC) unwrap(information []byte) ([]byte, error) {
+	if len(information) < l.HeaderLength() {
+		return nil, NewLlcError(fmt.Sprintf("information of %d bytes is too short for the LLC header: %x",
+			len(information), information))
+	}
+	if information[0] != LLCDestinationLSAP {
+		return nil, NewLlcError(fmt.Sprintf("unexpected destination LSAP 0x%02x, should be 0x%02x",
+			information[0], LLCDestinationLSAP))
+	}
+	if information[1] != LLCResponseSourceLSAP &&
+		!(l.AcceptCommandHeader && information[1] == LLCCommandSourceLSAP) {
+		return nil, NewLlcError(fmt.Sprintf("unexpected source LSAP 0x%02x, should be 0x%02x",
+			information[1], LLCResponseSourceLSAP))
+	}
+	if l.QualityByte && information[2] != LLCQuality {
+		return nil, NewLlcError(fmt.Sprintf("unexpected quality 0x%02x, should be 0x%02x",
+			information[2], LLCQuality))
+	}
+	return information[l.HeaderLength():], nil
+}
+
+// strip removes whatever looks like an LLC header, with or without the
+// quality byte. No APDU starts with the destination LSAP or the quality byte.
+func (l *LLC) strip(information []byte) []byte {
+	if len(information) < 2 || information[0] != LLCDestinationLSAP ||
+		(information[1] != LLCCommandSourceLSAP && information[1] != LLCResponseSourceLSAP) {
+		return information
+	}
+	information = information[2:]
+	if len(information) > 0 && information[0] == LLCQuality {
+		information = information[1:]
+	}
+	return information
+}
@@ -0,0 +1,86 @@
+package hdlc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+)
+
+func TestLLC_Wrap(t *testing.T) {
+	llc := hdlc.NewLLC()
+	assert.Equal(t, []byte{0xE6, 0xE6, 0x00, 0xC0, 0x01}, llc.Wrap([]byte{0xC0, 0x01}))
+	assert.Equal(t, []byte(hdlc.LLCResponseHeader), llc.ResponseHeader())
+
+	llc.QualityByte = false
+	assert.Equal(t, []byte{0xE6, 0xE6, 0xC0, 0x01}, llc.Wrap([]byte{0xC0, 0x01}))
+}
+
+func TestLLC_Unwrap(t *testing.T) {
+	llc := hdlc.NewLLC()
+	apdu, err := llc.Unwrap([]byte{0xE6, 0xE7, 0x00, 0xC4, 0x01})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xC4, 0x01}, apdu)
+
+	for _, information := range [][]byte{
+		{0xE6, 0xE6, 0x00, 0xC4, 0x01},
+		{0xE7, 0xE7, 0x00, 0xC4, 0x01},
+		{0xE6, 0xE7, 0x01, 0xC4, 0x01},
+		{0xC4, 0x01},
+	} {
+		_, err = llc.Unwrap(information)
+		var llcError *hdlc.LlcError
+		assert.ErrorAs(t, err, &llcError, "%x", information)
+	}
+
+	llc.QualityByte = false
+	apdu, err = llc.Unwrap([]byte{0xE6, 0xE7, 0xC4, 0x01})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xC4, 0x01}, apdu)
+}
+
+func TestLLC_UnwrapCommandHeader(t *testing.T) {
+	llc := hdlc.NewLLC()
+	_, err := llc.Unwrap([]byte{0xE6, 0xE6, 0x00, 0xC4, 0x01})
+	assert.Error(t, err)
+
+	llc.AcceptCommandHeader = true
+	apdu, err := llc.Unwrap([]byte{0xE6, 0xE6, 0x00, 0xC4, 0x01})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xC4, 0x01}, apdu)
+}
+
+func TestLLC_UnwrapValidation(t *testing.T) {
+	for _, test := range []struct {
+		information []byte
+		apdu        []byte
+	}{
+		{[]byte{0xE6, 0xE7, 0x00, 0xC4, 0x01}, []byte{0xC4, 0x01}},
+		{[]byte{0xC4, 0x01}, []byte{0xC4, 0x01}},
+		{[]byte{0xE6, 0xE7, 0xC4, 0x01}, []byte{0xC4, 0x01}},
+		{[]byte{0xE6, 0xE6, 0x00, 0xC4, 0x01}, []byte{0xC4, 0x01}},
+		{[]byte{0xE6, 0xE7, 0x01, 0xC4, 0x01}, []byte{0x01, 0xC4, 0x01}},
+	} {
+		var warnings []string
+		llc := hdlc.NewLLC()
+		llc.Validation = hdlc.LLCWarn
+		llc.Warn = func(message string) { warnings = append(warnings, message) }
+
+		apdu, err := llc.Unwrap(test.information)
+		require.NoError(t, err, "%x", test.information)
+		assert.Equal(t, test.apdu, apdu, "%x", test.information)
+		if test.information[0] == 0xE6 && test.information[1] == 0xE7 && test.information[2] == 0x00 {
+			assert.Empty(t, warnings, "%x", test.information)
+		} else {
+			assert.Len(t, warnings, 1, "%x", test.information)
+		}
+
+		llc.Validation = hdlc.LLCIgnore
+		warnings = nil
+		apdu, err = llc.Unwrap(test.information)
+		require.NoError(t, err, "%x", test.information)
+		assert.Equal(t, test.apdu, apdu, "%x", test.information)
+		assert.Empty(t, warnings)
+	}
+}
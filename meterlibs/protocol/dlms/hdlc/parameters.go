@@ -0,0 +1,155 @@
+package hdlc
+
+import (
+	"fmt"
+)
+
+const (
+	// ParameterFormatIdentifier starts the parameter negotiation field of SNRM and UA
+	ParameterFormatIdentifier = 0x81
+	// ParameterGroupIdentifier identifies the HDLC parameter group
+	ParameterGroupIdentifier = 0x80
+
+	parameterMaxInformationLengthTransmit = 0x05
+	parameterMaxInformationLengthReceive  = 0x06
+	parameterWindowSizeTransmit           = 0x07
+	parameterWindowSizeReceive            = 0x08
+)
+
+// HdlcParameters are the parameters negotiated with SNRM and UA. They are seen
+// from the side sending them: the transmit values of the server in the UA are
+// the receive values of the client.
+type HdlcParameters struct {
+	MaxInformationLengthTransmit int
+	MaxInformationLengthReceive  int
+	WindowSizeTransmit           int
+	WindowSizeReceive            int
+}
+
+// NewDefaultHdlcParameters creates the parameters used when none are negotiated
+func NewDefaultHdlcParameters() *HdlcParameters {
+	return &HdlcParameters{
+		MaxInformationLengthTransmit: DefaultMaxInformationLength,
+		MaxInformationLengthReceive:  DefaultMaxInformationLength,
+		WindowSizeTransmit:           DefaultWindowSize,
+		WindowSizeReceive:            DefaultWindowSize,
+	}
+}
+
+// Reversed returns the parameters as seen from the other side
+func (p *HdlcParameters) Reversed() *HdlcParameters {
+	return &HdlcParameters{
+		MaxInformationLengthTransmit: p.MaxInformationLengthReceive,
+		MaxInformationLengthReceive:  p.MaxInformationLengthTransmit,
+		WindowSizeTransmit:           p.WindowSizeReceive,
+		WindowSizeReceive:            p.WindowSizeTransmit,
+	}
+}
+
+// ToBytes converts HdlcParameters to the information field of SNRM or UA
+func (p *HdlcParameters) ToBytes() []byte {
+	group := make([]byte, 0)
+	group = append(group, encodeParameter(parameterMaxInformationLengthTransmit, p.MaxInformationLengthTransmit, false)...)
+	group = append(group, encodeParameter(parameterMaxInformationLengthReceive, p.MaxInformationLengthReceive, false)...)
+	group = append(group, encodeParameter(parameterWindowSizeTransmit, p.WindowSizeTransmit, true)...)
+	group = append(group, encodeParameter(parameterWindowSizeReceive, p.WindowSizeReceive, true)...)
+
+	result := []byte{ParameterFormatIdentifier, ParameterGroupIdentifier, byte(len(group))}
+	return append(result, group...)
+}
+
+// FromBytes creates HdlcParameters from the information field of SNRM or UA.
+// Parameters that are not present keep their default value.
+func (p *HdlcParameters) FromBytes(inBytes []byte) (*HdlcParameters, error) {
+	if len(inBytes) < 3 {
+		return nil, NewHdlcParsingError(fmt.Sprintf("HDLC parameters too short: %x", inBytes))
+	}
+	if inBytes[0] != ParameterFormatIdentifier || inBytes[1] != ParameterGroupIdentifier {
+		return nil, NewHdlcParsingError(fmt.Sprintf(
+			"HDLC parameters should start with 0x%02x%02x, got 0x%02x%02x",
+			ParameterFormatIdentifier, ParameterGroupIdentifier, inBytes[0], inBytes[1]))
+	}
+	group := inBytes[3:]
+	if int(inBytes[2]) != len(group) {
+		return nil, NewHdlcParsingError(fmt.Sprintf(
+			"HDLC parameter group length is %d, but %d bytes are left", inBytes[2], len(group)))
+	}
+
+	result := NewDefaultHdlcParameters()
+	for len(group) > 0 {
+		if len(group) < 2 || len(group) < 2+int(group[1]) {
+			return nil, NewHdlcParsingError(fmt.Sprintf("HDLC parameter is truncated: %x", group))
+		}
+		id, length := group[0], int(group[1])
+		if length > 4 {
+			return nil, NewHdlcParsingError(fmt.Sprintf("HDLC parameter 0x%02x is %d bytes long", id, length))
+		}
+		value := 0
+		for _, b := range group[2 : 2+length] {
+			value = value<<8 | int(b)
+		}
+		group = group[2+length:]
+
+		switch id {
+		case parameterMaxInformationLengthTransmit:
+			result.MaxInformationLengthTransmit = value
+		case parameterMaxInformationLengthReceive:
+			result.MaxInformationLengthReceive = value
+		case parameterWindowSizeTransmit:
+			result.WindowSizeTransmit = value
+		case parameterWindowSizeReceive:
+			result.WindowSizeReceive = value
+		}
+	}
+
+	if err := result.validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// String implements fmt.Stringer
+func (p *HdlcParameters) String() string {
+	return fmt.Sprintf(
+		"HdlcParameters(max_info_tx=%d, max_info_rx=%d, window_tx=%d, window_rx=%d)",
+		p.MaxInformationLengthTransmit, p.MaxInformationLengthReceive,
+		p.WindowSizeTransmit, p.WindowSizeReceive)
+}
+
+func (p *HdlcParameters) validate() error {
+	for _, size := range []int{p.WindowSizeTransmit, p.WindowSizeReceive} {
+		if size < 1 || size > MaxWindowSize {
+			return NewHdlcParsingError(fmt.Sprintf("window size can only be between 1-%d, got %d", MaxWindowSize, size))
+		}
+	}
+	for _, length := range []int{p.MaxInformationLengthTransmit, p.MaxInformationLengthReceive} {
+		if length < 1 || length > 2030 {
+			return NewHdlcParsingError(fmt.Sprintf("maximum information length can only be between 1-2030, got %d", length))
+		}
+	}
+	return nil
+}
+
+// encodeParameter encodes one parameter, window sizes are always sent on 4 bytes
+func encodeParameter(id byte, value int, long bool) []byte {
+	switch {
+	case long:
+		return []byte{id, 4, byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
+	case value > 0xFF:
+		return []byte{id, 2, byte(value >> 8), byte(value)}
+	default:
+		return []byte{id, 1, byte(value)}
+	}
+}
+
+// negotiate returns the parameters the client uses, from the proposed ones
+// and the ones the server sent in the UA. The smaller value of both sides wins.
+func negotiate(proposed *HdlcParameters, server *HdlcParameters) *HdlcParameters {
+	client := server.Reversed()
+	return &HdlcParameters{
+		MaxInformationLengthTransmit: min(proposed.MaxInformationLengthTransmit, client.MaxInformationLengthTransmit),
+		MaxInformationLengthReceive:  min(proposed.MaxInformationLengthReceive, client.MaxInformationLengthReceive),
+		WindowSizeTransmit:           min(proposed.WindowSizeTransmit, client.WindowSizeTransmit),
+		WindowSizeReceive:            min(proposed.WindowSizeReceive, client.WindowSizeReceive),
+	}
+}
@@ -0,0 +1,82 @@
+package hdlc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PhysicalAddressCalculator maps the serial number of a meter to the
+// physical HDLC address it answers to on a multi-drop, so that a field tool
+// can address a meter knowing only the serial number printed on it
+type PhysicalAddressCalculator interface {
+	PhysicalAddress(serialNumber uint64) (int, error)
+}
+
+// PhysicalAddressFunc is a PhysicalAddressCalculator for vendor schemes
+// given as a function
+type PhysicalAddressFunc func(serialNumber uint64) (int, error)
+
+// PhysicalAddress implements PhysicalAddressCalculator
+func (f PhysicalAddressFunc) PhysicalAddress(serialNumber uint64) (int, error) {
+	return f(serialNumber)
+}
+
+// SerialNumberModulo is the scheme of most meters: the physical address is
+// the serial number modulo Modulus plus Offset, e.g. SN % 10000 + 1000
+type SerialNumberModulo struct {
+	Modulus uint64
+	Offset  int
+}
+
+// DefaultPhysicalAddress is the SN % 10000 + 1000 scheme used by IDIS meters,
+// the physical address is made of the last four digits of the serial number
+var DefaultPhysicalAddress = &SerialNumberModulo{Modulus: 10000, Offset: 1000}
+
+// PhysicalAddress implements PhysicalAddressCalculator
+func (m *SerialNumberModulo) PhysicalAddress(serialNumber uint64) (int, error) {
+	if m.Modulus == 0 {
+		return 0, fmt.Errorf("serial number modulus must not be 0")
+	}
+	address := int(serialNumber%m.Modulus) + m.Offset
+	if err := validateHdlcAddress(address, true); err != nil {
+		return 0, fmt.Errorf("serial number %d: %w", serialNumber, err)
+	}
+	return address, nil
+}
+
+// SerialNumberSuffix returns the part of the serial number that gives the
+// physical address, e.g. the last four digits with DefaultPhysicalAddress.
+// It is the reverse mapping, used to tell which meter answered.
+func (m *SerialNumberModulo) SerialNumberSuffix(physicalAddress int) (uint64, error) {
+	suffix := physicalAddress - m.Offset
+	if suffix < 0 || uint64(suffix) >= m.Modulus {
+		return 0, fmt.Errorf("physical address %d is not computed with %s", physicalAddress, m)
+	}
+	return uint64(suffix), nil
+}
+
+// String implements fmt.Stringer
+func (m *SerialNumberModulo) String() string {
+	return fmt.Sprintf("SN %% %d + %d", m.Modulus, m.Offset)
+}
+
+// ParseSerialNumber reads the serial number printed on a meter, the
+// manufacturer prefix and separators are skipped: "LGZ 1234-5678" is
+// 12345678
+func ParseSerialNumber(printed string) (uint64, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, printed)
+	if digits == "" {
+		return 0, fmt.Errorf("serial number %q has no digits", printed)
+	}
+	serialNumber, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("serial number %q: %w", printed, err)
+	}
+	return serialNumber, nil
+}
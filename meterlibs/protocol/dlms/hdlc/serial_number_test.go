@@ -0,0 +1,63 @@
+package hdlc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestSerialNumberModulo(t *testing.T) {
+	serialNumber, err := hdlc.ParseSerialNumber("LGZ 1234-5678")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12345678), serialNumber)
+
+	address, err := hdlc.DefaultPhysicalAddress.PhysicalAddress(serialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, 6678, address)
+
+	suffix, err := hdlc.DefaultPhysicalAddress.SerialNumberSuffix(address)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5678), suffix)
+
+	_, err = hdlc.DefaultPhysicalAddress.SerialNumberSuffix(999)
+	assert.Error(t, err)
+	_, err = (&hdlc.SerialNumberModulo{Modulus: 100000, Offset: 1000}).PhysicalAddress(99999)
+	assert.Error(t, err)
+	_, err = hdlc.ParseSerialNumber("LGZ")
+	assert.Error(t, err)
+}
+
+func TestPhysicalAddressFunc(t *testing.T) {
+	var calculator hdlc.PhysicalAddressCalculator = hdlc.PhysicalAddressFunc(func(serialNumber uint64) (int, error) {
+		return int(serialNumber%100) + 16, nil
+	})
+	address, err := calculator.PhysicalAddress(12345678)
+	require.NoError(t, err)
+	assert.Equal(t, 94, address)
+}
+
+func TestTransport_SetPhysicalAddress(t *testing.T) {
+	client, err := hdlc.NewHdlcAddress(16, nil, hdlc.AddressTypeClient, false)
+	require.NoError(t, err)
+	physical := 6678
+	server, err := hdlc.NewHdlcAddress(1, &physical, hdlc.AddressTypeServer, true)
+	require.NoError(t, err)
+
+	script := testutil.NewScriptedTransport(
+		testutil.Expect(hdlc.NewSetNormalResponseModeFrame(server, client).ToBytes(),
+			hdlc.NewUnNumberedAcknowledgmentFrame(client, server, nil).ToBytes()),
+	)
+	transport := hdlc.New(script, 16, 1)
+	transport.SetTimeout(time.Second)
+	transport.SetPhysicalAddress(physical)
+	// the client sets the logical addresses again, the physical address is kept
+	transport.SetAddress(16, 1)
+
+	require.NoError(t, transport.Connect())
+	assert.NoError(t, script.Err())
+	assert.Equal(t, 0, script.Remaining())
+}
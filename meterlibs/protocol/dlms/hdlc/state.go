@@ -58,7 +58,10 @@ func NewHdlcConnectionState() *HdlcConnectionState {
 	}
 }
 
-// ProcessFrame processes a frame and transitions the state
+// ProcessFrame processes a frame and transitions the state.
+// I and RR frames without the poll/final bit set don't hand over the
+// transmission right, so they are checked but keep the current state. This
+// lets several frames of a window be sent or received in a row.
 func (h *HdlcConnectionState) ProcessFrame(frame interface{}) error {
 	frameType := getFrameType(frame)
 	newState, ok := hdlcStateTransitions[h.CurrentState][frameType]
@@ -67,10 +70,25 @@ func (h *HdlcConnectionState) ProcessFrame(frame interface{}) error {
 			"can't handle frame type %s when state=%s",
 			frameType, h.CurrentState))
 	}
+	if !isPollFinal(frame) {
+		return nil
+	}
 	h.CurrentState = newState
 	return nil
 }
 
+// isPollFinal returns the poll/final bit of I and RR frames, true for the other frames
+func isPollFinal(frame interface{}) bool {
+	switch f := frame.(type) {
+	case *InformationFrame:
+		return f.Final
+	case *ReceiveReadyFrame:
+		return f.Final
+	default:
+		return true
+	}
+}
+
 // FrameType represents the type of HDLC frame
 type FrameType string
 
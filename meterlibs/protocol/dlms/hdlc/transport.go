@@ -0,0 +1,369 @@
+package hdlc
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+)
+
+// DefaultTimeout is how long the transport waits for the server to answer SNRM and DISC
+const DefaultTimeout = 5 * time.Second
+
+// Transport sends the APDUs in HDLC frames over a byte transport, like a
+// serial port or a TCP connection to a gateway
+type Transport struct {
+	transport  dlms.Transport
+	connection *HdlcConnection
+	addressErr error
+	client     int
+	server     int
+	physical   *int // nil without physical address
+	dc         dlms.DataChannel
+	tc         dlms.DataChannel
+	ua         chan struct{}
+	timeout    time.Duration
+	logger     *log.Logger
+	mutex      sync.Mutex
+}
+
+// New creates a new HDLC Transport over the byte transport
+func New(transport dlms.Transport, client int, server int) *Transport {
+	t := &Transport{
+		transport: transport,
+		dc:        nil,
+		tc:        make(dlms.DataChannel, 10),
+		ua:        make(chan struct{}, 1),
+		timeout:   DefaultTimeout,
+		logger:    nil,
+	}
+	t.SetAddress(client, server)
+
+	transport.SetReception(t.tc)
+
+	go t.manager()
+
+	return t
+}
+
+// SetWindowSize sets the number of I-frames that may be in flight in each
+// direction, they are proposed to the server on connect
+func (t *Transport) SetWindowSize(transmit, receive int) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.connection.SetWindowSize(transmit, receive)
+}
+
+// SetParameters sets the HDLC parameters proposed to the server on connect
+func (t *Transport) SetParameters(parameters *HdlcParameters) error {
+	if err := parameters.validate(); err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	proposed := *parameters
+	t.connection.Proposed = &proposed
+	return nil
+}
+
+// NegotiatedParameters returns the HDLC parameters agreed with the server,
+// nil when not connected
+func (t *Transport) NegotiatedParameters() *HdlcParameters {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.connection.Negotiated() == nil {
+		return nil
+	}
+	negotiated := *t.connection.Negotiated()
+	return &negotiated
+}
+
+// SetTimeout sets how long to wait for the server to answer SNRM and DISC
+func (t *Transport) SetTimeout(timeout time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.timeout = timeout
+}
+
+func (t *Transport) Close() {
+	t.transport.Close()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.connection.Reset()
+	if t.dc != nil {
+		close(t.dc)
+		t.dc = nil
+	}
+}
+
+func (t *Transport) Connect() error {
+	if t.addressErr != nil {
+		return t.addressErr
+	}
+
+	if err := t.transport.Connect(); err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.drainUA()
+	t.connection.Reset()
+	err := t.sendFrame(t.connection.Connect)
+	t.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return t.waitUA("SNRM")
+}
+
+func (t *Transport) Disconnect() error {
+	t.mutex.Lock()
+	var err error
+	connected := t.connection.State.CurrentState == HdlcStateIdle
+	if connected {
+		t.drainUA()
+		err = t.sendFrame(t.connection.Disconnect)
+	}
+	t.mutex.Unlock()
+
+	if connected && err == nil {
+		err = t.waitUA("DISC")
+	}
+
+	t.mutex.Lock()
+	t.connection.Reset()
+	t.mutex.Unlock()
+
+	if disconnectErr := t.transport.Disconnect(); disconnectErr != nil {
+		return disconnectErr
+	}
+	return err
+}
+
+func (t *Transport) IsConnected() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.transport.IsConnected() && t.connection.State.CurrentState != HdlcStateNotConnected
+}
+
+// SetAddress sets the client address and the logical address of the server
+func (t *Transport) SetAddress(client int, server int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.client, t.server = client, server
+	t.setAddress()
+}
+
+// SetPhysicalAddress sets the physical address of the server on a multi-drop,
+// e.g. computed from its serial number with a PhysicalAddressCalculator. It
+// is kept when the logical addresses change. Addresses above 127 are sent
+// with extended addressing.
+func (t *Transport) SetPhysicalAddress(physical int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.physical = &physical
+	t.setAddress()
+}
+
+func (t *Transport) setAddress() {
+	t.addressErr = nil
+	clientAddress, err := NewHdlcAddress(t.client, nil, AddressTypeClient, false)
+	if err != nil {
+		t.addressErr = fmt.Errorf("invalid client address: %w", err)
+		return
+	}
+	extended := t.physical != nil && (t.server > 0x7F || *t.physical > 0x7F)
+	serverAddress, err := NewHdlcAddress(t.server, t.physical, AddressTypeServer, extended)
+	if err != nil {
+		t.addressErr = fmt.Errorf("invalid server address: %w", err)
+		return
+	}
+
+	connection := NewHdlcConnection(clientAddress, serverAddress)
+	if t.connection != nil {
+		connection.Proposed = t.connection.Proposed
+		connection.LLC = t.connection.LLC
+	} else {
+		connection.LLC.Warn = func(message string) { t.logf("Accepted received APDU: %s", message) }
+	}
+	t.connection = connection
+}
+
+// SetLLCValidation sets how the LLC header of the APDUs received is checked.
+// With acceptCommandHeader the command header is taken for a response header,
+// some firmwares answer with it. The headers accepted with LLCWarn are logged.
+func (t *Transport) SetLLCValidation(validation LLCValidation, acceptCommandHeader bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.connection.LLC.Validation = validation
+	t.connection.LLC.AcceptCommandHeader = acceptCommandHeader
+}
+
+func (t *Transport) SetReception(dc dlms.DataChannel) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.dc != nil {
+		close(t.dc)
+	}
+
+	t.dc = dc
+}
+
+// Send sends the APDU, the frames that don't fit in the first window are sent
+// when the server acknowledges the previous ones. The frames of a window are
+// written at once when the byte transport implements dlms.TransportWithBuffer.
+// The APDU is logged here with the password of an AARQ redacted, the byte
+// transport below only logs the length of the frames.
+func (t *Transport) Send(src []byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.transport.IsConnected() {
+		return fmt.Errorf("not connected")
+	}
+
+	frames, err := t.connection.SendApdu(src)
+	if err != nil {
+		return err
+	}
+
+	t.logf("TX APDU: %X", acse.RedactAuthenticationValue(src))
+
+	return t.sendAll(frames)
+}
+
+func (t *Transport) SetLogger(logger *log.Logger) {
+	t.mutex.Lock()
+	t.logger = logger
+	t.mutex.Unlock()
+
+	t.transport.SetLogger(logger)
+}
+
+func (t *Transport) manager() {
+	for {
+		data, ok := <-t.tc
+		if !ok {
+			return
+		}
+
+		t.mutex.Lock()
+		apdus := t.receive(data)
+		dc := t.dc
+		t.mutex.Unlock()
+
+		for _, apdu := range apdus {
+			if dc != nil {
+				dc <- apdu
+			}
+		}
+	}
+}
+
+// receive processes the received bytes and returns the complete APDUs
+func (t *Transport) receive(data []byte) [][]byte {
+	apdus := make([][]byte, 0)
+
+	t.connection.ReceiveData(data)
+	for {
+		frame, err := t.connection.NextFrame()
+		if err != nil {
+			t.logf("Invalid received frame: %v", err)
+			continue
+		}
+		if frame == nil {
+			return apdus
+		}
+
+		reply, apdu, err := t.connection.ReceiveFrame(frame)
+		if err != nil {
+			t.logf("Failed to handle received frame: %v", err)
+			continue
+		}
+
+		if err = t.sendAll(reply); err != nil {
+			t.logf("Failed to send reply: %v", err)
+		}
+
+		if _, ok := frame.(*UnNumberedAcknowledgmentFrame); ok {
+			select {
+			case t.ua <- struct{}{}:
+			default:
+			}
+		}
+
+		if apdu != nil {
+			t.logf("RX APDU: %X", acse.RedactAuthenticationValue(apdu))
+			apdus = append(apdus, apdu)
+		}
+	}
+}
+
+func (t *Transport) sendFrame(build func() ([]byte, error)) error {
+	frame, err := build()
+	if err != nil {
+		return err
+	}
+	return t.transport.Send(frame)
+}
+
+// sendAll sends the frames, with one write when the byte transport buffers
+func (t *Transport) sendAll(frames [][]byte) error {
+	if buffered, ok := t.transport.(dlms.TransportWithBuffer); ok && len(frames) > 1 {
+		for _, frame := range frames {
+			if err := buffered.Buffer(frame); err != nil {
+				return err
+			}
+		}
+		return buffered.Flush()
+	}
+
+	for _, frame := range frames {
+		if err := t.transport.Send(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transport) waitUA(command string) error {
+	t.mutex.Lock()
+	timeout := t.timeout
+	t.mutex.Unlock()
+
+	select {
+	case <-t.ua:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timeout waiting for UA to %s", command)
+	}
+}
+
+func (t *Transport) drainUA() {
+	select {
+	case <-t.ua:
+	default:
+	}
+}
+
+func (t *Transport) logf(format string, v ...interface{}) {
+	if t.logger != nil {
+		t.logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,113 @@
+package hdlc_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestTransport_SendAndReceive(t *testing.T) {
+	client, server := addresses(t)
+
+	request, err := hdlc.NewInformationFrame(server, client, []byte{0xC0, 0x01, 0xC1}, 0, 0, false, true)
+	require.NoError(t, err)
+	disconnect := hdlc.NewDisconnectFrame(server, client)
+	ua := hdlc.NewUnNumberedAcknowledgmentFrame(client, server, nil).ToBytes()
+
+	script := testutil.NewScriptedTransport(
+		testutil.Expect(hdlc.NewSetNormalResponseModeFrame(server, client).ToBytes(), ua),
+		testutil.Expect(request.ToBytes(),
+			serverFrame(t, []byte{0xC4, 0x01}, 0, 1, true, true, true),
+		),
+		testutil.ExpectTag(hdlc.HDLCFlag, func([]byte) ([][]byte, error) {
+			return [][]byte{serverFrame(t, []byte{0xC1, 0x00}, 1, 1, false, true, false)}, nil
+		}),
+		testutil.Expect(disconnect.ToBytes(), ua),
+	)
+
+	transport := hdlc.New(script, 16, 1)
+	transport.SetTimeout(time.Second)
+	dc := make(dlms.DataChannel, 1)
+	transport.SetReception(dc)
+
+	require.NoError(t, transport.Connect())
+	assert.True(t, transport.IsConnected())
+
+	require.NoError(t, transport.Send([]byte{0xC0, 0x01, 0xC1}))
+	select {
+	case apdu := <-dc:
+		assert.Equal(t, []byte{0xC4, 0x01, 0xC1, 0x00}, apdu)
+	case <-time.After(time.Second):
+		t.Fatal("no APDU received")
+	}
+
+	require.NoError(t, transport.Disconnect())
+	assert.False(t, transport.IsConnected())
+	assert.NoError(t, script.Err())
+	assert.Equal(t, 0, script.Remaining())
+}
+
+// bufferedScript is a scripted transport writing the buffered frames at once
+type bufferedScript struct {
+	*testutil.ScriptedTransport
+	buffer  []byte
+	flushes int
+}
+
+func (b *bufferedScript) Buffer(src []byte) error {
+	b.buffer = append(b.buffer, src...)
+	return nil
+}
+
+func (b *bufferedScript) Flush() error {
+	data := b.buffer
+	b.buffer = nil
+	b.flushes++
+	return b.Send(data)
+}
+
+func TestTransport_SendWindowInOneWrite(t *testing.T) {
+	client, server := addresses(t)
+	// the server receives windows of 3 frames of up to 128 bytes
+	ua := hdlc.NewUnNumberedAcknowledgmentFrame(client, server,
+		decodeHexString("818012050180060180070400000001080400000003")).ToBytes()
+
+	var written [][]byte
+	script := &bufferedScript{ScriptedTransport: testutil.NewScriptedTransport(
+		testutil.ExpectTag(hdlc.HDLCFlag, func([]byte) ([][]byte, error) { return [][]byte{ua}, nil }),
+		testutil.ExpectTag(hdlc.HDLCFlag, func(request []byte) ([][]byte, error) {
+			written = append(written, request)
+			return nil, nil
+		}),
+	)}
+
+	transport := hdlc.New(script, 16, 1)
+	transport.SetTimeout(time.Second)
+	require.NoError(t, transport.SetWindowSize(3, 1))
+	require.NoError(t, transport.Connect())
+
+	require.NoError(t, transport.Send(bytes.Repeat([]byte{0xAB}, 300)))
+	assert.NoError(t, script.Err())
+	assert.Equal(t, 0, script.Remaining())
+	assert.Equal(t, 1, script.flushes)
+
+	// the three frames of the window in one write
+	require.Len(t, written, 1)
+	var sequenceNumbers []uint8
+	for data := written[0]; len(data) > 0; {
+		format, err := hdlc.ExtractFormatFieldFromBytes(data)
+		require.NoError(t, err)
+		length := int(format.Length) + 2
+		frame, err := (&hdlc.InformationFrame{}).FromBytes(data[:length])
+		require.NoError(t, err)
+		sequenceNumbers = append(sequenceNumbers, frame.SendSequenceNumber)
+		data = data[length:]
+	}
+	assert.Equal(t, []uint8{0, 1, 2}, sequenceNumbers)
+}
@@ -0,0 +1,236 @@
+package idis
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// dataAttributeValue is the value attribute of the Data objects holding the
+// alarm register, filter and descriptor
+const dataAttributeValue uint8 = 2
+
+// AlarmRegister is an alarm register and the Data objects going with it. The
+// three objects are double-long-unsigned bit masks with the same bits: the
+// register has the current alarms, the filter the alarms that are pushed and
+// the descriptor the alarms that occurred since it was last cleared.
+type AlarmRegister struct {
+	Name       string
+	Register   *cosem.Obis
+	Filter     *cosem.Obis
+	Descriptor *cosem.Obis
+	// Names are the names of the alarm bits, the bits not in the map are
+	// named by their index
+	Names map[uint]string
+}
+
+var (
+	// AlarmRegister1 is the IDIS alarm register 1, for the meter and M-Bus alarms
+	AlarmRegister1 = &AlarmRegister{
+		Name:       "Alarm register 1",
+		Register:   obis(0, 0, 97, 98, 0, 255),
+		Filter:     obis(0, 0, 97, 98, 10, 255),
+		Descriptor: obis(0, 0, 97, 98, 20, 255),
+		Names: map[uint]string{
+			0:  "Clock invalid",
+			1:  "Replace battery",
+			2:  "Power up",
+			8:  "Program memory error",
+			9:  "RAM error",
+			10: "NV memory error",
+			11: "Measurement system error",
+			12: "Watchdog error",
+			13: "Fraud attempt",
+			16: "Communication error M-Bus channel 1",
+			17: "Communication error M-Bus channel 2",
+			18: "Communication error M-Bus channel 3",
+			19: "Communication error M-Bus channel 4",
+			20: "Fraud attempt M-Bus channel 1",
+			21: "Fraud attempt M-Bus channel 2",
+			22: "Fraud attempt M-Bus channel 3",
+			23: "Fraud attempt M-Bus channel 4",
+			24: "New M-Bus device discovered channel 1",
+			25: "New M-Bus device discovered channel 2",
+			26: "New M-Bus device discovered channel 3",
+			27: "New M-Bus device discovered channel 4",
+		},
+	}
+	// AlarmRegister2 is the IDIS alarm register 2, its bits are defined by
+	// the companion specification in use and are named by their index
+	AlarmRegister2 = &AlarmRegister{
+		Name:       "Alarm register 2",
+		Register:   obis(0, 0, 97, 98, 1, 255),
+		Filter:     obis(0, 0, 97, 98, 11, 255),
+		Descriptor: obis(0, 0, 97, 98, 21, 255),
+		Names:      map[uint]string{},
+	}
+)
+
+// Alarm is a bit set in an alarm register
+type Alarm struct {
+	Bit  uint
+	Name string
+}
+
+// String implements fmt.Stringer
+func (a Alarm) String() string {
+	return a.Name
+}
+
+// Alarms returns the alarms of the bits set in value, lowest bit first
+func (r *AlarmRegister) Alarms(value uint32) []Alarm {
+	result := make([]Alarm, 0)
+	for bit := uint(0); bit < 32; bit++ {
+		if value&(1<<bit) == 0 {
+			continue
+		}
+		name, ok := r.Names[bit]
+		if !ok {
+			name = fmt.Sprintf("%s bit %d", r.Name, bit)
+		}
+		result = append(result, Alarm{Bit: bit, Name: name})
+	}
+	return result
+}
+
+// AlarmStatus is the content of an alarm register, its filter and its
+// descriptor read from a meter
+type AlarmStatus struct {
+	Register   *AlarmRegister
+	Value      uint32
+	Filter     uint32
+	Descriptor uint32
+}
+
+// Active returns the current alarms
+func (s *AlarmStatus) Active() []Alarm {
+	return s.Register.Alarms(s.Value)
+}
+
+// Reported returns the current alarms that pass the filter, the ones the
+// meter pushes
+func (s *AlarmStatus) Reported() []Alarm {
+	return s.Register.Alarms(s.Value & s.Filter)
+}
+
+// Occurred returns the alarms of the descriptor, including the ones no
+// longer active
+func (s *AlarmStatus) Occurred() []Alarm {
+	return s.Register.Alarms(s.Descriptor)
+}
+
+// ReadAlarms reads an alarm register, its filter and its descriptor. The
+// client must be associated.
+func ReadAlarms(c *client.Client, register *AlarmRegister) (*AlarmStatus, error) {
+	status := &AlarmStatus{Register: register}
+	var err error
+	if status.Value, err = getMask(c, register.Register); err != nil {
+		return nil, fmt.Errorf("%s: %w", register.Name, err)
+	}
+	if status.Filter, err = getMask(c, register.Filter); err != nil {
+		return nil, fmt.Errorf("%s filter: %w", register.Name, err)
+	}
+	if status.Descriptor, err = getMask(c, register.Descriptor); err != nil {
+		return nil, fmt.Errorf("%s descriptor: %w", register.Name, err)
+	}
+	return status, nil
+}
+
+// ClearAlarms resets the alarms of the bits set in mask. IDIS meters reset
+// the bits set in the value written to the alarm register.
+func ClearAlarms(c *client.Client, register *AlarmRegister, mask uint32) error {
+	if err := setMask(c, register.Register, mask); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", register.Name, err)
+	}
+	return nil
+}
+
+// ClearAlarmDescriptor resets the bits set in mask of the alarm descriptor
+func ClearAlarmDescriptor(c *client.Client, register *AlarmRegister, mask uint32) error {
+	if err := setMask(c, register.Descriptor, mask); err != nil {
+		return fmt.Errorf("failed to clear %s descriptor: %w", register.Name, err)
+	}
+	return nil
+}
+
+// SetAlarmFilter writes the alarm filter, the alarms of the bits set are
+// pushed by the meter
+func SetAlarmFilter(c *client.Client, register *AlarmRegister, filter uint32) error {
+	if err := setMask(c, register.Filter, filter); err != nil {
+		return fmt.Errorf("failed to set %s filter: %w", register.Name, err)
+	}
+	return nil
+}
+
+func getMask(c *client.Client, logicalName *cosem.Obis) (uint32, error) {
+	value, err := c.GetValue(cosem.NewCosemAttribute(enumerations.CosemInterfaceData, logicalName, dataAttributeValue), nil)
+	if err != nil {
+		return 0, err
+	}
+	mask, ok := value.(uint32)
+	if !ok {
+		return 0, fmt.Errorf("value is %T, not double-long-unsigned", value)
+	}
+	return mask, nil
+}
+
+func setMask(c *client.Client, logicalName *cosem.Obis, mask uint32) error {
+	data := binary.BigEndian.AppendUint32([]byte{byte(dlmsdata.TagDoubleLongUnsigned)}, mask)
+	return c.Set(cosem.NewCosemAttribute(enumerations.CosemInterfaceData, logicalName, dataAttributeValue), data)
+}
+
+// AlarmNotification is an alarm pushed by an IDIS meter
+type AlarmNotification struct {
+	// PushSetup is the logical name of the Push setup that sent the alarm
+	PushSetup *cosem.Obis
+	// Values are the alarm registers pushed, in the order of AlarmRegisters
+	Values []uint32
+}
+
+// AlarmRegisters are the alarm registers in the order they are pushed
+var AlarmRegisters = []*AlarmRegister{AlarmRegister1, AlarmRegister2}
+
+// Alarms returns the alarms of all the registers pushed
+func (n *AlarmNotification) Alarms() []Alarm {
+	result := make([]Alarm, 0)
+	for i, value := range n.Values {
+		result = append(result, AlarmRegisters[i].Alarms(value)...)
+	}
+	return result
+}
+
+// DecodeAlarmNotification decodes the body of an alarm push: a structure of
+// the logical name of the Push setup followed by alarm register 1 and,
+// when configured, alarm register 2
+func DecodeAlarmNotification(notification *client.DecodedNotification) (*AlarmNotification, error) {
+	value, err := encoding.DecodeValue(notification.Body)
+	if err != nil {
+		return nil, err
+	}
+	elements, ok := value.([]interface{})
+	if !ok || len(elements) < 2 || len(elements) > 1+len(AlarmRegisters) {
+		return nil, fmt.Errorf("alarm notification is not a structure of push setup and alarm registers: %x", notification.Body)
+	}
+
+	logicalName, ok := elements[0].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("alarm notification push setup is %T, not octet-string", elements[0])
+	}
+	result := &AlarmNotification{Values: make([]uint32, 0, len(elements)-1)}
+	if result.PushSetup, err = cosem.FromBytes(logicalName); err != nil {
+		return nil, fmt.Errorf("alarm notification push setup: %w", err)
+	}
+	for i, element := range elements[1:] {
+		mask, ok := element.(uint32)
+		if !ok {
+			return nil, fmt.Errorf("alarm notification register %d is %T, not double-long-unsigned", i+1, element)
+		}
+		result.Values = append(result.Values, mask)
+	}
+	return result, nil
+}
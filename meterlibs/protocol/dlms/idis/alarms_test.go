@@ -0,0 +1,58 @@
+package idis_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/idis"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestReadAndClearAlarms(t *testing.T) {
+	transport := testutil.NewScriptedTransport(
+		testutil.ExpectTag(0x60, func([]byte) ([][]byte, error) {
+			return [][]byte{testutil.AssociationResponse}, nil
+		}),
+		// clock invalid, fraud attempt and the unnamed bit 30
+		testutil.Expect(decodeHexString("C001C100010000616200FF0200"), decodeHexString("C401C1000640002001")),
+		testutil.Expect(decodeHexString("C001C10001000061620AFF0200"), decodeHexString("C401C1000600002001")),
+		testutil.Expect(decodeHexString("C001C100010000616214FF0200"), decodeHexString("C401C1000640002003")),
+		testutil.Expect(decodeHexString("C101C100010000616200FF02000600002001"), decodeHexString("C501C100")),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	status, err := idis.ReadAlarms(c, idis.AlarmRegister1)
+	require.NoError(t, err)
+	assert.Equal(t, []idis.Alarm{
+		{Bit: 0, Name: "Clock invalid"},
+		{Bit: 13, Name: "Fraud attempt"},
+		{Bit: 30, Name: "Alarm register 1 bit 30"},
+	}, status.Active())
+	assert.Len(t, status.Reported(), 2)
+	assert.Len(t, status.Occurred(), 4)
+
+	require.NoError(t, idis.ClearAlarms(c, idis.AlarmRegister1, status.Value&status.Filter))
+	assert.NoError(t, transport.Err())
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestDecodeAlarmNotification(t *testing.T) {
+	notification := &client.DecodedNotification{
+		Kind: client.NotificationData,
+		Body: decodeHexString("0203" + "09060004190900FF" + "0600000004" + "0600000001"),
+	}
+
+	alarms, err := idis.DecodeAlarmNotification(notification)
+	require.NoError(t, err)
+	assert.Equal(t, "0-4:25.9.0.255", alarms.PushSetup.String())
+	assert.Equal(t, []uint32{4, 1}, alarms.Values)
+	assert.Equal(t, []string{"Power up", "Alarm register 2 bit 0"}, []string{alarms.Alarms()[0].Name, alarms.Alarms()[1].Name})
+
+	notification.Body = decodeHexString("0201" + "09060004190900FF")
+	_, err = idis.DecodeAlarmNotification(notification)
+	assert.Error(t, err)
+}
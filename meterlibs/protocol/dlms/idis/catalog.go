@@ -0,0 +1,135 @@
+// Package idis describes the object model of IDIS package 2 meters and checks
+// meters against it.
+package idis
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// Group is the part of the IDIS object model an object belongs to
+type Group string
+
+const (
+	GroupLogicalDevice Group = "logical device"
+	GroupMandatory     Group = "mandatory"
+	GroupEventLog      Group = "event log"
+	GroupMBus          Group = "M-Bus"
+)
+
+// MaxMBusChannels is the number of M-Bus channels of an IDIS meter
+const MaxMBusChannels = 4
+
+// ObjectDescriptor describes an object of the IDIS object model
+type ObjectDescriptor struct {
+	Name        string
+	LogicalName *cosem.Obis
+	Interface   enumerations.CosemInterface
+	// Version is the lowest interface class version accepted
+	Version   uint8
+	Group     Group
+	Mandatory bool
+}
+
+// String implements fmt.Stringer
+func (o ObjectDescriptor) String() string {
+	return fmt.Sprintf("%s (%d/%s)", o.Name, o.Interface, o.LogicalName)
+}
+
+func obis(a, b, c, d, e, f int) *cosem.Obis {
+	return &cosem.Obis{A: a, B: b, C: c, D: d, E: e, F: f}
+}
+
+// logicalDevice are the objects every IDIS logical device has
+var logicalDevice = []ObjectDescriptor{
+	{"COSEM logical device name", obis(0, 0, 42, 0, 0, 255), enumerations.CosemInterfaceData, 0, GroupLogicalDevice, true},
+	{"Current association", obis(0, 0, 40, 0, 0, 255), enumerations.CosemInterfaceAssociationLN, 1, GroupLogicalDevice, true},
+	{"SAP assignment", obis(0, 0, 41, 0, 0, 255), enumerations.CosemInterfaceSAPAssignment, 0, GroupLogicalDevice, true},
+	{"Security setup", obis(0, 0, 43, 0, 0, 255), enumerations.CosemInterfaceSecuritySetup, 0, GroupLogicalDevice, true},
+}
+
+// mandatory are the objects an IDIS package 2 meter has to provide
+var mandatory = []ObjectDescriptor{
+	{"Clock", obis(0, 0, 1, 0, 0, 255), enumerations.CosemInterfaceClock, 0, GroupMandatory, true},
+	{"Active firmware identifier", obis(1, 0, 0, 2, 0, 255), enumerations.CosemInterfaceData, 0, GroupMandatory, true},
+	{"Active firmware signature", obis(1, 0, 0, 2, 8, 255), enumerations.CosemInterfaceData, 0, GroupMandatory, true},
+	{"Image transfer", obis(0, 0, 44, 0, 0, 255), enumerations.CosemInterfaceImageTransfer, 0, GroupMandatory, true},
+	{"Error register", obis(0, 0, 97, 97, 0, 255), enumerations.CosemInterfaceData, 0, GroupMandatory, true},
+	{"Alarm register 1", obis(0, 0, 97, 98, 0, 255), enumerations.CosemInterfaceData, 0, GroupMandatory, true},
+	{"Alarm filter 1", obis(0, 0, 97, 98, 10, 255), enumerations.CosemInterfaceData, 0, GroupMandatory, true},
+	{"Activity calendar", obis(0, 0, 13, 0, 0, 255), enumerations.CosemInterfaceActivityCalendar, 0, GroupMandatory, true},
+	{"Special days table", obis(0, 0, 11, 0, 0, 255), enumerations.CosemInterfaceSpecialDaysTable, 0, GroupMandatory, true},
+	{"Tariffication script table", obis(0, 0, 10, 0, 100, 255), enumerations.CosemInterfaceScriptTable, 0, GroupMandatory, true},
+	{"Global meter reset script table", obis(0, 0, 10, 0, 0, 255), enumerations.CosemInterfaceScriptTable, 0, GroupMandatory, true},
+	{"Disconnector script table", obis(0, 0, 10, 0, 106, 255), enumerations.CosemInterfaceScriptTable, 0, GroupMandatory, true},
+	{"Image activation script table", obis(0, 0, 10, 0, 107, 255), enumerations.CosemInterfaceScriptTable, 0, GroupMandatory, true},
+	{"Push script table", obis(0, 0, 10, 0, 108, 255), enumerations.CosemInterfaceScriptTable, 0, GroupMandatory, true},
+	{"Disconnect control", obis(0, 0, 96, 3, 10, 255), enumerations.CosemInterfaceDisconnectControl, 0, GroupMandatory, true},
+	{"Disconnector control schedule", obis(0, 0, 15, 0, 1, 255), enumerations.CosemInterfaceSingleActionSchedule, 0, GroupMandatory, true},
+	{"Image activation schedule", obis(0, 0, 15, 0, 2, 255), enumerations.CosemInterfaceSingleActionSchedule, 0, GroupMandatory, true},
+	{"Limiter", obis(0, 0, 17, 0, 0, 255), enumerations.CosemInterfaceLimiter, 0, GroupMandatory, true},
+	{"Push setup", obis(0, 0, 25, 9, 0, 255), enumerations.CosemInterfacePush, 0, GroupMandatory, true},
+	{"Active energy import (+A)", obis(1, 0, 1, 8, 0, 255), enumerations.CosemInterfaceRegister, 0, GroupMandatory, true},
+	{"Active energy export (-A)", obis(1, 0, 2, 8, 0, 255), enumerations.CosemInterfaceRegister, 0, GroupMandatory, true},
+	{"Load profile 1", obis(1, 0, 99, 1, 0, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupMandatory, true},
+	{"Load profile 2", obis(1, 0, 99, 2, 0, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupMandatory, true},
+	{"Daily values profile", obis(0, 0, 98, 1, 0, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupMandatory, true},
+	{"Monthly billing values profile", obis(0, 0, 98, 2, 0, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupMandatory, false},
+}
+
+// eventLogs are the event logs and the matching event code objects
+var eventLogs = []ObjectDescriptor{
+	{"Standard event log", obis(0, 0, 99, 98, 0, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupEventLog, true},
+	{"Standard event code", obis(0, 0, 96, 11, 0, 255), enumerations.CosemInterfaceData, 0, GroupEventLog, true},
+	{"Fraud detection log", obis(0, 0, 99, 98, 1, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupEventLog, true},
+	{"Fraud detection event code", obis(0, 0, 96, 11, 1, 255), enumerations.CosemInterfaceData, 0, GroupEventLog, true},
+	{"Disconnector control log", obis(0, 0, 99, 98, 2, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupEventLog, true},
+	{"Disconnector control event code", obis(0, 0, 96, 11, 2, 255), enumerations.CosemInterfaceData, 0, GroupEventLog, true},
+	{"M-Bus event log", obis(0, 0, 99, 98, 3, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupEventLog, false},
+	{"M-Bus event code", obis(0, 0, 96, 11, 3, 255), enumerations.CosemInterfaceData, 0, GroupEventLog, false},
+	{"Power quality log", obis(0, 0, 99, 98, 4, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupEventLog, true},
+	{"Power quality event code", obis(0, 0, 96, 11, 4, 255), enumerations.CosemInterfaceData, 0, GroupEventLog, true},
+	{"Communication log", obis(0, 0, 99, 98, 5, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupEventLog, true},
+	{"Communication event code", obis(0, 0, 96, 11, 5, 255), enumerations.CosemInterfaceData, 0, GroupEventLog, true},
+	{"Power failure event log", obis(1, 0, 99, 97, 0, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupEventLog, true},
+}
+
+// MBusChannel returns the objects of M-Bus channel 1 to 4. The channels are
+// optional, a meter without M-Bus devices doesn't need them.
+func MBusChannel(channel int) ([]ObjectDescriptor, error) {
+	if channel < 1 || channel > MaxMBusChannels {
+		return nil, fmt.Errorf("M-Bus channel can only be between 1-%d, got %d", MaxMBusChannels, channel)
+	}
+	return []ObjectDescriptor{
+		{fmt.Sprintf("M-Bus client %d", channel), obis(0, channel, 24, 1, 0, 255), enumerations.CosemInterfaceMBusClient, 1, GroupMBus, false},
+		{fmt.Sprintf("M-Bus value %d", channel), obis(0, channel, 24, 2, 1, 255), enumerations.CosemInterfaceExtendedRegister, 0, GroupMBus, false},
+		{fmt.Sprintf("M-Bus profile %d", channel), obis(0, channel, 24, 3, 0, 255), enumerations.CosemInterfaceProfileGeneric, 1, GroupMBus, false},
+		{fmt.Sprintf("M-Bus disconnect control %d", channel), obis(0, channel, 24, 4, 0, 255), enumerations.CosemInterfaceDisconnectControl, 0, GroupMBus, false},
+	}, nil
+}
+
+// Catalog returns the IDIS package 2 object model: the logical device
+// objects, the mandatory objects, the event logs and all M-Bus channels
+func Catalog() []ObjectDescriptor {
+	result := make([]ObjectDescriptor, 0)
+	result = append(result, logicalDevice...)
+	result = append(result, mandatory...)
+	result = append(result, eventLogs...)
+	for channel := 1; channel <= MaxMBusChannels; channel++ {
+		objects, _ := MBusChannel(channel)
+		result = append(result, objects...)
+	}
+	return result
+}
+
+// Find returns the descriptor of the object with the given logical name, or nil
+func Find(logicalName *cosem.Obis) *ObjectDescriptor {
+	for _, object := range Catalog() {
+		if *object.LogicalName == *logicalName {
+			return &object
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package idis
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// GapKind is the kind of difference between a meter and the IDIS object model
+type GapKind string
+
+const (
+	// GapMissing is a mandatory object that is not in the object list
+	GapMissing GapKind = "missing"
+	// GapWrongInterface is an object with another interface class than specified
+	GapWrongInterface GapKind = "wrong interface class"
+	// GapOldVersion is an object with a lower interface class version than required
+	GapOldVersion GapKind = "old version"
+)
+
+// Gap is an IDIS conformance gap found on a meter
+type Gap struct {
+	Kind   GapKind
+	Object ObjectDescriptor
+	// Found is the object list item of the meter, nil when missing
+	Found *cosem.AssociationObjectListItem
+}
+
+// String implements fmt.Stringer
+func (g Gap) String() string {
+	switch g.Kind {
+	case GapWrongInterface:
+		return fmt.Sprintf("%s: %s, found interface class %d", g.Kind, g.Object, g.Found.Interface)
+	case GapOldVersion:
+		return fmt.Sprintf("%s: %s, found version %d, need %d", g.Kind, g.Object, g.Found.Version, g.Object.Version)
+	default:
+		return fmt.Sprintf("%s: %s", g.Kind, g.Object)
+	}
+}
+
+// Report is the result of checking a meter against the IDIS object model
+type Report struct {
+	Gaps []Gap
+	// Present are the objects of the catalog found on the meter
+	Present []ObjectDescriptor
+}
+
+// Conformant returns true when no gaps were found
+func (r *Report) Conformant() bool {
+	return len(r.Gaps) == 0
+}
+
+// Verify checks an object list against the objects of the catalog. Mandatory
+// objects must be present, all present objects must have the specified
+// interface class and at least the specified version.
+func Verify(objectList []*cosem.AssociationObjectListItem, catalog []ObjectDescriptor) *Report {
+	report := &Report{
+		Gaps:    make([]Gap, 0),
+		Present: make([]ObjectDescriptor, 0),
+	}
+
+	for _, object := range catalog {
+		found := cosem.FindObjectListItem(objectList, object.LogicalName)
+		switch {
+		case found == nil:
+			if object.Mandatory {
+				report.Gaps = append(report.Gaps, Gap{Kind: GapMissing, Object: object})
+			}
+			continue
+		case found.Interface != object.Interface:
+			report.Gaps = append(report.Gaps, Gap{Kind: GapWrongInterface, Object: object, Found: found})
+		case found.Version < object.Version:
+			report.Gaps = append(report.Gaps, Gap{Kind: GapOldVersion, Object: object, Found: found})
+		}
+		report.Present = append(report.Present, object)
+	}
+
+	return report
+}
+
+// VerifyMeter reads the object list of the association of the client and
+// checks it against the IDIS package 2 catalog. The client must be associated.
+func VerifyMeter(c *client.Client) (*Report, error) {
+	objectList, err := c.GetObjectList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the object list: %w", err)
+	}
+	return Verify(objectList, Catalog()), nil
+}
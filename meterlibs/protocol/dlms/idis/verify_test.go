@@ -0,0 +1,112 @@
+package idis_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/client"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/idis"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+// objectListElement encodes an object list element with authenticated read
+// access to attribute 1 and authenticated access to method 1, using the access
+// modes of association versions before 3
+func objectListElement(interfaceClass enumerations.CosemInterface, version uint8, logicalName *cosem.Obis) []byte {
+	result := []byte{0x02, 0x04, 0x12, byte(uint16(interfaceClass) >> 8), byte(interfaceClass), 0x11, version, 0x09, 0x06}
+	result = append(result, logicalName.ToBytes()...)
+	return append(result,
+		0x02, 0x02,
+		0x01, 0x01, 0x02, 0x03, 0x0F, 0x01, 0x16, 0x04, 0x00,
+		0x01, 0x01, 0x02, 0x02, 0x0F, 0x01, 0x16, 0x02,
+	)
+}
+
+func objectListResponse(elements ...[]byte) []byte {
+	response := []byte{0xC4, 0x01, 0xC1, 0x00, 0x01, byte(len(elements))}
+	for _, element := range elements {
+		response = append(response, element...)
+	}
+	return response
+}
+
+func TestVerifyMeter(t *testing.T) {
+	elements := make([][]byte, 0)
+	for _, object := range idis.Catalog() {
+		switch {
+		case object.Name == "Clock", object.Group == idis.GroupMBus:
+			continue
+		case object.Name == "Limiter":
+			elements = append(elements, objectListElement(enumerations.CosemInterfaceData, 0, object.LogicalName))
+		case object.Name == "Current association":
+			elements = append(elements, objectListElement(object.Interface, 2, object.LogicalName))
+		case object.Name == "Load profile 1":
+			elements = append(elements, objectListElement(object.Interface, 0, object.LogicalName))
+		default:
+			elements = append(elements, objectListElement(object.Interface, object.Version, object.LogicalName))
+		}
+	}
+
+	transport := testutil.NewScriptedTransport(
+		testutil.ExpectTag(0x60, func([]byte) ([][]byte, error) {
+			return [][]byte{testutil.AssociationResponse}, nil
+		}),
+		testutil.Expect(decodeHexString("C001C1000F0000280000FF0200"), objectListResponse(elements...)),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	report, err := idis.VerifyMeter(c)
+	require.NoError(t, err)
+	assert.False(t, report.Conformant())
+
+	gaps := make([]string, 0)
+	for _, gap := range report.Gaps {
+		gaps = append(gaps, gap.String())
+	}
+	assert.Equal(t, []string{
+		"missing: Clock (8/0-0:1.0.0.255)",
+		"wrong interface class: Limiter (71/0-0:17.0.0.255), found interface class 1",
+		"old version: Load profile 1 (7/1-0:99.1.0.255), found version 0, need 1",
+	}, gaps)
+	assert.Len(t, report.Present, len(elements))
+}
+
+func TestGetObjectList_AccessModesOfVersion2(t *testing.T) {
+	association := idis.Find(client.CurrentAssociation)
+	require.NotNil(t, association)
+
+	transport := testutil.NewScriptedTransport(
+		testutil.ExpectTag(0x60, func([]byte) ([][]byte, error) {
+			return [][]byte{testutil.AssociationResponse}, nil
+		}),
+		testutil.Expect(decodeHexString("C001C1000F0000280000FF0200"),
+			objectListResponse(objectListElement(association.Interface, 2, association.LogicalName))),
+	)
+	c := client.New(transport, client.NewSettings(16, 1))
+	require.NoError(t, c.Connect())
+	require.NoError(t, c.Associate())
+
+	objectList, err := c.GetObjectList()
+	require.NoError(t, err)
+	require.Len(t, objectList, 1)
+
+	// authenticated_read_only of version 2, not the authenticated request bit of version 3
+	assert.Equal(t, []cosem.AccessRight{cosem.AccessRightReadAccess, cosem.AccessRightAuthenticatedRequest},
+		objectList[0].AttributeAccessRights[1].AccessRights)
+	assert.Equal(t, []cosem.AccessRight{cosem.AccessRightReadAccess, cosem.AccessRightAuthenticatedRequest},
+		objectList[0].MethodAccessRights[1].AccessRights)
+}
+
+func decodeHexString(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
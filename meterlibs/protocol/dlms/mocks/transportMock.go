@@ -91,8 +91,13 @@ func (_m *TransportMock) SetLogger(logger *log.Logger) {
 	_m.Called(logger)
 }
 
+type mockConstructorTestingTNewTransportMock interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
 // NewTransportMock creates a new instance of TransportMock. It also registers the testing.TB interface on the mock and a cleanup function to assert the mock's expectations.
-func NewTransportMock(t mock.TestingT) *TransportMock {
+func NewTransportMock(t mockConstructorTestingTNewTransportMock) *TransportMock {
 	mock := &TransportMock{}
 	mock.Mock.Test(t)
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
 )
 
 // Asn1Integer wraps Integers for BER encoding
@@ -23,7 +24,7 @@ func NewAsn1Integer(value int) *Asn1Integer {
 // FromBytes creates Asn1Integer from bytes
 func (a *Asn1Integer) FromBytes(sourceBytes []byte) (*Asn1Integer, error) {
 	ber := encoding.NewBER()
-	tag, length, data, err := ber.Decode(sourceBytes, 1)
+	tag, _, data, err := ber.Decode(sourceBytes, 1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode BER: %w", err)
 	}
@@ -32,15 +33,9 @@ func (a *Asn1Integer) FromBytes(sourceBytes []byte) (*Asn1Integer, error) {
 		return nil, fmt.Errorf("data provided is not of the correct type, tag is %v but should be %d", tag, Asn1IntegerTag)
 	}
 
-	var value int
-	if len(data) == 1 {
-		value = int(data[0])
-	} else if len(data) == 2 {
-		value = int(binary.BigEndian.Uint16(data))
-	} else if len(data) == 4 {
-		value = int(binary.BigEndian.Uint32(data))
-	} else {
-		return nil, fmt.Errorf("unsupported integer length: %d", len(data))
+	value, err := decodeAsn1IntegerValue(data)
+	if err != nil {
+		return nil, err
 	}
 
 	return NewAsn1Integer(value), nil
@@ -49,11 +44,44 @@ func (a *Asn1Integer) FromBytes(sourceBytes []byte) (*Asn1Integer, error) {
 // ToBytes converts Asn1Integer to bytes
 func (a *Asn1Integer) ToBytes() ([]byte, error) {
 	ber := encoding.NewBER()
-	// For values that fit in 1 byte
-	if a.Value >= 0 && a.Value <= 255 {
-		return ber.Encode(Asn1IntegerTag, []byte{byte(a.Value)})
+	return ber.Encode(Asn1IntegerTag, encodeAsn1IntegerValue(a.Value))
+}
+
+// decodeAsn1IntegerValue decodes the content octets of a two's complement ASN.1 integer
+func decodeAsn1IntegerValue(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("integer has no content octets")
+	}
+	if len(data) > 8 {
+		return 0, fmt.Errorf("unsupported integer length: %d", len(data))
+	}
+
+	// Sign extend from the first octet
+	value := int64(int8(data[0]))
+	for _, b := range data[1:] {
+		value = (value << 8) | int64(b)
 	}
-	return nil, fmt.Errorf("integer value %d does not fit in 1 byte", a.Value)
+	return int(value), nil
+}
+
+// encodeAsn1IntegerValue encodes the content octets of an ASN.1 integer with the
+// minimum number of octets in two's complement
+func encodeAsn1IntegerValue(value int) []byte {
+	v := int64(value)
+	result := make([]byte, 8)
+	binary.BigEndian.PutUint64(result, uint64(v))
+
+	// Drop leading octets that only repeat the sign bit
+	for len(result) > 1 {
+		if result[0] == 0x00 && result[1]&0x80 == 0 {
+			result = result[1:]
+		} else if result[0] == 0xFF && result[1]&0x80 != 0 {
+			result = result[1:]
+		} else {
+			break
+		}
+	}
+	return result
 }
 
 // ResultSourceDiagnostics represents result source diagnostics
@@ -70,33 +98,45 @@ func NewResultSourceDiagnostics(name string, value int) *ResultSourceDiagnostics
 	}
 }
 
-// FromBytes creates ResultSourceDiagnostics from bytes
+// FromBytes creates ResultSourceDiagnostics from bytes.
+// The choice is encoded as [1] or [2] containing an integer, e.g. A1 03 02 01 00.
+// The implicit form 81 01 00 used by some meters is accepted as well.
 func (r *ResultSourceDiagnostics) FromBytes(sourceBytes []byte) (*ResultSourceDiagnostics, error) {
-	ber := encoding.NewBER()
-	
-	// Try to decode as acse-service-user (tag 0x81)
-	if len(sourceBytes) >= 2 && sourceBytes[0] == 0x81 {
-		tag, _, data, err := ber.Decode(sourceBytes, 1)
-		if err == nil && bytesEqual(tag, []byte{0x81}) {
-			if len(data) > 0 {
-				value := int(data[0])
-				return NewResultSourceDiagnostics("acse-service-user", value), nil
-			}
-		}
+	if len(sourceBytes) < 3 {
+		return nil, fmt.Errorf("failed to parse result source diagnostics, got %d bytes", len(sourceBytes))
 	}
-	
-	// Try to decode as acse-service-provider (tag 0x82)
-	if len(sourceBytes) >= 2 && sourceBytes[0] == 0x82 {
-		tag, _, data, err := ber.Decode(sourceBytes, 1)
-		if err == nil && bytesEqual(tag, []byte{0x82}) {
-			if len(data) > 0 {
-				value := int(data[0])
-				return NewResultSourceDiagnostics("acse-service-provider", value), nil
-			}
+
+	var name string
+	switch sourceBytes[0] {
+	case 0xA1, 0x81:
+		name = "acse-service-user"
+	case 0xA2, 0x82:
+		name = "acse-service-provider"
+	default:
+		return nil, fmt.Errorf("failed to parse result source diagnostics, unknown tag 0x%02x", sourceBytes[0])
+	}
+
+	_, data, _, err := encoding.NewBER().NextTLV(sourceBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse result source diagnostics: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("failed to parse result source diagnostics, invalid length 0")
+	}
+
+	if sourceBytes[0]&0x20 != 0 {
+		value, err := (&Asn1Integer{}).FromBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse result source diagnostics: %w", err)
 		}
+		return NewResultSourceDiagnostics(name, value.Value), nil
 	}
-	
-	return nil, fmt.Errorf("failed to parse result source diagnostics")
+
+	value, err := decodeAsn1IntegerValue(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse result source diagnostics: %w", err)
+	}
+	return NewResultSourceDiagnostics(name, value), nil
 }
 
 // ToBytes converts ResultSourceDiagnostics to bytes
@@ -104,13 +144,17 @@ func (r *ResultSourceDiagnostics) ToBytes() ([]byte, error) {
 	ber := encoding.NewBER()
 	var tag int
 	if r.Name == "acse-service-user" {
-		tag = 0x81
+		tag = 0xA1
 	} else if r.Name == "acse-service-provider" {
-		tag = 0x82
+		tag = 0xA2
 	} else {
 		return nil, fmt.Errorf("invalid result source diagnostics name: %s", r.Name)
 	}
-	return ber.Encode(tag, []byte{byte(r.Value)})
+	value, err := NewAsn1Integer(r.Value).ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return ber.Encode(tag, value)
 }
 
 // ApplicationAssociationResponse represents an AARE (Application Association Response)
@@ -196,40 +240,27 @@ func (a *ApplicationAssociationResponse) FromBytes(sourceBytes []byte) (*Applica
 		return nil, fmt.Errorf("bytes are not an AARE APDU, tag is not 0x61, got 0x%02x", aareTag)
 	}
 
-	if len(aareData) < 2 {
-		return nil, fmt.Errorf("insufficient data for AARE length")
+	ber := encoding.NewBER()
+	_, aareData, rest, err := ber.NextTLV(aareData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse AARE: %w", err)
 	}
-
-	aareLength := int(aareData[1])
-	aareData = aareData[2:]
-
-	if len(aareData) != aareLength {
-		return nil, fmt.Errorf("the APDU data length does not correspond to length byte, expected %d, got %d", aareLength, len(aareData))
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("the APDU data length does not correspond to the length, got %d bytes after the AARE", len(rest))
 	}
 
 	// Parse tags
 	objectDict := make(map[string]interface{})
-	ber := encoding.NewBER()
 
 	for len(aareData) > 0 {
-		if len(aareData) < 2 {
-			return nil, fmt.Errorf("insufficient data for tag and length")
-		}
-
-		objectTag := aareData[0]
-		objectLength := int(aareData[1])
-		aareData = aareData[2:]
-
-		if len(aareData) < objectLength {
-			return nil, fmt.Errorf("insufficient data for object, need %d bytes, got %d", objectLength, len(aareData))
+		objectTag, objectData, rest, err := ber.NextTLV(aareData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AARE: %w", err)
 		}
-
-		objectData := aareData[:objectLength]
-		aareData = aareData[objectLength:]
+		aareData = rest
 
 		var objectName string
 		var parsedData interface{}
-		var err error
 
 		switch objectTag {
 		case 128: // protocol_version
@@ -258,19 +289,17 @@ func (a *ApplicationAssociationResponse) FromBytes(sourceBytes []byte) (*Applica
 			}
 		case 164: // responding_ap_title
 			objectName = "responding_ap_title"
-			// It is BER encoded universal tag octetstring. Simple handling
-			if len(objectData) >= 2 {
-				parsedData = objectData[2:] // Skip tag and length
-			} else {
-				parsedData = objectData
+			// It is BER encoded universal tag octetstring
+			_, parsedData, _, err = ber.NextTLV(objectData)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse responding_ap_title: %w", err)
 			}
 		case 165: // responding_ae_qualifier
 			objectName = "responding_ae_qualifier"
-			// It is BER encoded universal tag octetstring. Simple handling
-			if len(objectData) >= 2 {
-				parsedData = objectData[2:] // Skip tag and length
-			} else {
-				parsedData = objectData
+			// It is BER encoded universal tag octetstring
+			_, parsedData, _, err = ber.NextTLV(objectData)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse responding_ae_qualifier: %w", err)
 			}
 		case 166: // responding_ap_invocation_id
 			objectName = "responding_ap_invocation_id"
@@ -390,158 +419,59 @@ func (a *ApplicationAssociationResponse) FromBytes(sourceBytes []byte) (*Applica
 	}, nil
 }
 
-// ToBytes converts ApplicationAssociationResponse to bytes
+// ToBytes converts ApplicationAssociationResponse to bytes, the fields are
+// encoded in the order of the AARE definition
 func (a *ApplicationAssociationResponse) ToBytes() ([]byte, error) {
-	ber := encoding.NewBER()
-	aareData := make([]byte, 0)
-
-	// Application context name
-	appCtxName := a.ApplicationContextName()
-	appCtxBytes, err := appCtxName.ToBytes()
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode application_context_name: %w", err)
-	}
-	encodedAppCtx, err := ber.Encode(161, appCtxBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to BER encode application_context_name: %w", err)
-	}
-	aareData = append(aareData, encodedAppCtx...)
-
-	// Result
-	resultInt := NewAsn1Integer(int(a.Result))
-	resultBytes, err := resultInt.ToBytes()
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode result: %w", err)
-	}
-	encodedResult, err := ber.Encode(162, resultBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to BER encode result: %w", err)
-	}
-	aareData = append(aareData, encodedResult...)
-
-	// Result source diagnostics
-	if a.ResultSourceDiagnostics != nil {
-		var rsd *ResultSourceDiagnostics
-		switch diag := a.ResultSourceDiagnostics.(type) {
-		case enumerations.AcseServiceUserDiagnostics:
-			rsd = NewResultSourceDiagnostics("acse-service-user", int(diag))
-		case enumerations.AcseServiceProviderDiagnostics:
-			rsd = NewResultSourceDiagnostics("acse-service-provider", int(diag))
-		default:
-			return nil, fmt.Errorf("unsupported result source diagnostics type: %T", a.ResultSourceDiagnostics)
-		}
-		rsdBytes, err := rsd.ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode result_source_diagnostics: %w", err)
-		}
-		encodedRSD, err := ber.Encode(163, rsdBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode result_source_diagnostics: %w", err)
-		}
-		aareData = append(aareData, encodedRSD...)
-	}
-
-	// Optional fields
-	if a.SystemTitle != nil {
-		octetStringBytes, err := ber.Encode(4, a.SystemTitle)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode system_title as octet string: %w", err)
-		}
-		encoded, err := ber.Encode(164, octetStringBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode responding_ap_title: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
-
-	if a.PublicCert != nil {
-		octetStringBytes, err := ber.Encode(4, a.PublicCert)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode public_cert as octet string: %w", err)
-		}
-		encoded, err := ber.Encode(165, octetStringBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode responding_ae_qualifier: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
-
-	if a.RespondingAPInvocationID != nil {
-		encoded, err := ber.Encode(166, a.RespondingAPInvocationID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode responding_ap_invocation_id: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
-
-	if a.RespondingAEInvocationID != nil {
-		encoded, err := ber.Encode(167, a.RespondingAEInvocationID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode responding_ae_invocation_id: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
-
-	if a.ResponderACSERequirements() != nil {
-		authFuncBytes, err := a.ResponderACSERequirements().ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode responder_acse_requirements: %w", err)
-		}
-		encoded, err := ber.Encode(0x88, authFuncBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode responder_acse_requirements: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
-
-	if a.MechanismName() != nil {
-		mechNameBytes, err := a.MechanismName().ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode mechanism_name: %w", err)
-		}
-		encoded, err := ber.Encode(0x89, mechNameBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode mechanism_name: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
+	return encodeAcseFields(AARETag, []acseField{
+		{0xA1, "application_context_name", a.ApplicationContextName().ToBytes},
+		{0xA2, "result", NewAsn1Integer(int(a.Result)).ToBytes},
+		{0xA3, "result_source_diagnostics", a.resultSourceDiagnosticsValue},
+		{0xA4, "responding_ap_title", octetStringValue(a.SystemTitle)},
+		{0xA5, "responding_ae_qualifier", octetStringValue(a.PublicCert)},
+		{0xA6, "responding_ap_invocation_id", rawValue(a.RespondingAPInvocationID)},
+		{0xA7, "responding_ae_invocation_id", rawValue(a.RespondingAEInvocationID)},
+		{0x88, "responder_acse_requirements", authFunctionalUnitValue(a.ResponderACSERequirements())},
+		{0x89, "mechanism_name", mechanismNameValue(a.MechanismName())},
+		{0xAA, "responding_authentication_value", authenticationValue(a.AuthenticationValue)},
+		{0xBD, "implementation_information", rawValue(a.ImplementationInformation)},
+		{0xBE, "user_information", userInformationValue(a.UserInformation)},
+	})
+}
 
-	if a.AuthenticationValue != nil {
-		authVal, err := NewAuthenticationValue(a.AuthenticationValue, "chars")
-		if err != nil {
-			return nil, fmt.Errorf("failed to create authentication value: %w", err)
-		}
-		authValBytes, err := authVal.ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode authentication_value: %w", err)
-		}
-		encoded, err := ber.Encode(170, authValBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode responding_authentication_value: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
+// resultSourceDiagnosticsValue encodes the diagnostics as the ACSE service
+// user or provider choice
+func (a *ApplicationAssociationResponse) resultSourceDiagnosticsValue() ([]byte, error) {
+	var rsd *ResultSourceDiagnostics
+	switch diag := a.ResultSourceDiagnostics.(type) {
+	case nil:
+		return nil, nil
+	case enumerations.AcseServiceUserDiagnostics:
+		rsd = NewResultSourceDiagnostics("acse-service-user", int(diag))
+	case enumerations.AcseServiceProviderDiagnostics:
+		rsd = NewResultSourceDiagnostics("acse-service-provider", int(diag))
+	default:
+		return nil, fmt.Errorf("unsupported result source diagnostics type: %T", a.ResultSourceDiagnostics)
+	}
+	return rsd.ToBytes()
+}
 
-	if a.ImplementationInformation != nil {
-		encoded, err := ber.Encode(189, a.ImplementationInformation)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode implementation_information: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
+// String implements fmt.Stringer without exposing the authentication value
+func (a *ApplicationAssociationResponse) String() string {
+	return fmt.Sprintf("AARE(result=%s, diagnostics=%v, authentication=%s, ciphered=%t, system_title=%x, authentication_value=%s, user_information=%s)",
+		a.Result, a.ResultSourceDiagnostics, authenticationString(a.Authentication), a.Ciphered, a.SystemTitle, redactedBytes(a.AuthenticationValue), a.UserInformation)
+}
 
-	if a.UserInformation != nil {
-		userInfoBytes, err := a.UserInformation.ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode user_information: %w", err)
-		}
-		encoded, err := ber.Encode(0xBE, userInfoBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode user_information: %w", err)
-		}
-		aareData = append(aareData, encoded...)
-	}
+// GoString keeps %#v from printing the authentication value
+func (a *ApplicationAssociationResponse) GoString() string {
+	return a.String()
+}
 
-	return ber.Encode(AARETag, aareData)
+// Tag returns the AARE tag
+func (a *ApplicationAssociationResponse) Tag() uint8 {
+	return AARETag
 }
 
+// Kind returns xdlms.ApduKindApplicationAssociationResponse
+func (*ApplicationAssociationResponse) Kind() xdlms.ApduKind {
+	return xdlms.ApduKindApplicationAssociationResponse
+}
@@ -0,0 +1,70 @@
+package acse_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+)
+
+func TestAsn1Integer_RoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		value   int
+		encoded string
+	}{
+		{0, "020100"},
+		{1, "020101"},
+		{127, "02017F"},
+		{128, "02020080"},
+		{255, "020200FF"},
+		{256, "02020100"},
+		{-1, "0201FF"},
+		{-128, "020180"},
+		{-129, "0202FF7F"},
+		{65535, "020300FFFF"},
+		{-65536, "0203FF0000"},
+		{math.MaxInt32, "02047FFFFFFF"},
+		{math.MinInt64, "02088000000000000000"},
+		{math.MaxInt64, "02087FFFFFFFFFFFFFFF"},
+	} {
+		t.Run(test.encoded, func(t *testing.T) {
+			encoded, err := acse.NewAsn1Integer(test.value).ToBytes()
+			require.NoError(t, err)
+			assert.Equal(t, decodeHexString(test.encoded), encoded)
+
+			decoded, err := (&acse.Asn1Integer{}).FromBytes(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, test.value, decoded.Value)
+		})
+	}
+}
+
+func TestAsn1Integer_FromBytesNonMinimal(t *testing.T) {
+	// redundant sign octets are accepted when decoding
+	for encoded, value := range map[string]int{
+		"02020001":   1,
+		"0203FFFFFF": -1,
+	} {
+		decoded, err := (&acse.Asn1Integer{}).FromBytes(decodeHexString(encoded))
+		require.NoError(t, err, encoded)
+		assert.Equal(t, value, decoded.Value, encoded)
+	}
+}
+
+func TestAsn1Integer_FromBytesErrors(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		encoded string
+	}{
+		{"no content octets", "0200"},
+		{"more than 8 content octets", "0209010000000000000000"},
+		{"wrong tag", "040100"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := (&acse.Asn1Integer{}).FromBytes(decodeHexString(test.encoded))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
 )
 
 // aarqShouldSetAuthenticated determines if authentication should be set based on mechanism
@@ -59,6 +60,21 @@ func NewApplicationAssociationRequest(
 	}
 }
 
+// NewLlsApplicationAssociationRequest creates an AARQ using Low Level Security
+// with the password sent as authentication value
+func NewLlsApplicationAssociationRequest(password string, userInformation *UserInformation) *ApplicationAssociationRequest {
+	authentication := enumerations.AuthenticationMechanismLLS
+	return NewApplicationAssociationRequest(
+		userInformation,
+		nil,
+		nil,
+		&authentication,
+		false,
+		[]byte(password),
+		nil,
+	)
+}
+
 // SenderACSERequirements returns the AuthFunctionalUnit if authentication is needed
 func (a *ApplicationAssociationRequest) SenderACSERequirements() *AuthFunctionalUnit {
 	if aarqShouldSetAuthenticated(a.Authentication) {
@@ -102,40 +118,27 @@ func (a *ApplicationAssociationRequest) FromBytes(sourceBytes []byte) (*Applicat
 		return nil, fmt.Errorf("bytes are not an AARQ APDU, tag is not 0x60, got 0x%02x", aarqTag)
 	}
 
-	if len(aarqData) < 2 {
-		return nil, fmt.Errorf("insufficient data for AARQ length")
+	ber := encoding.NewBER()
+	_, aarqData, rest, err := ber.NextTLV(aarqData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse AARQ: %w", err)
 	}
-
-	aarqLength := int(aarqData[1])
-	aarqData = aarqData[2:]
-
-	if len(aarqData) != aarqLength {
-		return nil, fmt.Errorf("the APDU data length does not correspond to length byte, expected %d, got %d", aarqLength, len(aarqData))
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("the APDU data length does not correspond to the length, got %d bytes after the AARQ", len(rest))
 	}
 
 	// Parse tags
 	objectDict := make(map[string]interface{})
-	ber := encoding.NewBER()
 
 	for len(aarqData) > 0 {
-		if len(aarqData) < 2 {
-			return nil, fmt.Errorf("insufficient data for tag and length")
-		}
-
-		objectTag := aarqData[0]
-		objectLength := int(aarqData[1])
-		aarqData = aarqData[2:]
-
-		if len(aarqData) < objectLength {
-			return nil, fmt.Errorf("insufficient data for object, need %d bytes, got %d", objectLength, len(aarqData))
+		objectTag, objectData, rest, err := ber.NextTLV(aarqData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AARQ: %w", err)
 		}
-
-		objectData := aarqData[:objectLength]
-		aarqData = aarqData[objectLength:]
+		aarqData = rest
 
 		var objectName string
 		var parsedData interface{}
-		var err error
 
 		switch objectTag {
 		case 0x80: // protocol_version
@@ -162,19 +165,17 @@ func (a *ApplicationAssociationRequest) FromBytes(sourceBytes []byte) (*Applicat
 			parsedData = objectData
 		case 166: // calling_ap_title
 			objectName = "calling_ap_title"
-			// It is BER encoded universal tag octetstring. Simple handling
-			if len(objectData) >= 2 {
-				parsedData = objectData[2:] // Skip tag and length
-			} else {
-				parsedData = objectData
+			// It is BER encoded universal tag octetstring
+			_, parsedData, _, err = ber.NextTLV(objectData)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse calling_ap_title: %w", err)
 			}
 		case 167: // calling_ae_qualifier
 			objectName = "calling_ae_qualifier"
-			// It is BER encoded universal tag octetstring. Simple handling
-			if len(objectData) >= 2 {
-				parsedData = objectData[2:] // Skip tag and length
-			} else {
-				parsedData = objectData
+			// It is BER encoded universal tag octetstring
+			_, parsedData, _, err = ber.NextTLV(objectData)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse calling_ae_qualifier: %w", err)
 			}
 		case 168: // calling_ap_invocation_identifier
 			objectName = "calling_ap_invocation_identifier"
@@ -286,158 +287,44 @@ func (a *ApplicationAssociationRequest) FromBytes(sourceBytes []byte) (*Applicat
 	}, nil
 }
 
-// ToBytes converts ApplicationAssociationRequest to bytes
+// ToBytes converts ApplicationAssociationRequest to bytes, the fields are
+// encoded in the order of the AARQ definition
 func (a *ApplicationAssociationRequest) ToBytes() ([]byte, error) {
-	ber := encoding.NewBER()
-	aarqData := make([]byte, 0)
-
-	// Application context name
-	appCtxName := a.ApplicationContextName()
-	appCtxBytes, err := appCtxName.ToBytes()
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode application_context_name: %w", err)
-	}
-	encodedAppCtx, err := ber.Encode(0xA1, appCtxBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to BER encode application_context_name: %w", err)
-	}
-	aarqData = append(aarqData, encodedAppCtx...)
-
-	// Optional fields
-	if a.CalledAPTitle != nil {
-		encoded, err := ber.Encode(162, a.CalledAPTitle)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode called_ap_title: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.CalledAEQualifier != nil {
-		encoded, err := ber.Encode(163, a.CalledAEQualifier)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode called_ae_qualifier: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.CalledAPInvocationIdentifier != nil {
-		encoded, err := ber.Encode(164, a.CalledAPInvocationIdentifier)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode called_ap_invocation_identifier: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.CalledAEInvocationIdentifier != nil {
-		encoded, err := ber.Encode(165, a.CalledAEInvocationIdentifier)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode called_ae_invocation_identifier: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.SystemTitle != nil {
-		// Encode as BER octet string
-		octetStringBytes, err := ber.Encode(4, a.SystemTitle)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode system_title as octet string: %w", err)
-		}
-		encoded, err := ber.Encode(166, octetStringBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode calling_ap_title: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.PublicCert != nil {
-		// Encode as BER octet string
-		octetStringBytes, err := ber.Encode(4, a.PublicCert)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode public_cert as octet string: %w", err)
-		}
-		encoded, err := ber.Encode(167, octetStringBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode calling_ae_qualifier: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.CallingAPInvocationIdentifier != nil {
-		encoded, err := ber.Encode(168, a.CallingAPInvocationIdentifier)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode calling_ap_invocation_identifier: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.CallingAEInvocationIdentifier != nil {
-		encoded, err := ber.Encode(169, a.CallingAEInvocationIdentifier)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode calling_ae_invocation_identifier: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.SenderACSERequirements() != nil {
-		authFuncBytes, err := a.SenderACSERequirements().ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode sender_acse_requirements: %w", err)
-		}
-		encoded, err := ber.Encode(0x8A, authFuncBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode sender_acse_requirements: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.MechanismName() != nil {
-		mechNameBytes, err := a.MechanismName().ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode mechanism_name: %w", err)
-		}
-		encoded, err := ber.Encode(0x8B, mechNameBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode mechanism_name: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
-
-	if a.AuthenticationValue != nil {
-		authVal, err := NewAuthenticationValue(a.AuthenticationValue, "chars")
-		if err != nil {
-			return nil, fmt.Errorf("failed to create authentication value: %w", err)
-		}
-		authValBytes, err := authVal.ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode authentication_value: %w", err)
-		}
-		encoded, err := ber.Encode(0xAC, authValBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode calling_authentication_value: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
+	return encodeAcseFields(AARQTag, []acseField{
+		{0xA1, "application_context_name", a.ApplicationContextName().ToBytes},
+		{0xA2, "called_ap_title", rawValue(a.CalledAPTitle)},
+		{0xA3, "called_ae_qualifier", rawValue(a.CalledAEQualifier)},
+		{0xA4, "called_ap_invocation_identifier", rawValue(a.CalledAPInvocationIdentifier)},
+		{0xA5, "called_ae_invocation_identifier", rawValue(a.CalledAEInvocationIdentifier)},
+		{0xA6, "calling_ap_title", octetStringValue(a.SystemTitle)},
+		{0xA7, "calling_ae_qualifier", octetStringValue(a.PublicCert)},
+		{0xA8, "calling_ap_invocation_identifier", rawValue(a.CallingAPInvocationIdentifier)},
+		{0xA9, "calling_ae_invocation_identifier", rawValue(a.CallingAEInvocationIdentifier)},
+		{0x8A, "sender_acse_requirements", authFunctionalUnitValue(a.SenderACSERequirements())},
+		{0x8B, "mechanism_name", mechanismNameValue(a.MechanismName())},
+		{0xAC, "calling_authentication_value", authenticationValue(a.AuthenticationValue)},
+		{0xBD, "implementation_information", rawValue(a.ImplementationInformation)},
+		{0xBE, "user_information", userInformationValue(a.UserInformation)},
+	})
+}
 
-	if a.ImplementationInformation != nil {
-		encoded, err := ber.Encode(0xBD, a.ImplementationInformation)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode implementation_information: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
+// String implements fmt.Stringer without exposing the authentication value
+func (a *ApplicationAssociationRequest) String() string {
+	return fmt.Sprintf("AARQ(authentication=%s, ciphered=%t, system_title=%x, authentication_value=%s, user_information=%s)",
+		authenticationString(a.Authentication), a.Ciphered, a.SystemTitle, redactedBytes(a.AuthenticationValue), a.UserInformation)
+}
 
-	if a.UserInformation != nil {
-		userInfoBytes, err := a.UserInformation.ToBytes()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode user_information: %w", err)
-		}
-		encoded, err := ber.Encode(0xBE, userInfoBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to BER encode user_information: %w", err)
-		}
-		aarqData = append(aarqData, encoded...)
-	}
+// GoString keeps %#v from printing the authentication value
+func (a *ApplicationAssociationRequest) GoString() string {
+	return a.String()
+}
 
-	return ber.Encode(AARQTag, aarqData)
+// Tag returns the AARQ tag
+func (a *ApplicationAssociationRequest) Tag() uint8 {
+	return AARQTag
 }
 
+// Kind returns xdlms.ApduKindApplicationAssociationRequest
+func (*ApplicationAssociationRequest) Kind() xdlms.ApduKind {
+	return xdlms.ApduKindApplicationAssociationRequest
+}
@@ -0,0 +1,245 @@
+package acse_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// hexVector joins the hex parts of a vector, one part per field
+func hexVector(parts ...string) []byte {
+	return decodeHexString(strings.Join(parts, ""))
+}
+
+// aarqVectors are AARQs as IDIS clients send them, the fields in the order of
+// the AARQ definition
+var aarqVectors = map[string][]byte{
+	"public client": hexVector(
+		"601d",
+		"a109060760857405080101",
+		"be10040e01000000065f1f0400001e1dffff",
+	),
+	"low level security": hexVector(
+		"6036",
+		"a109060760857405080101",
+		"8a020780",
+		"8b0760857405080201",
+		"ac0a80083132333435363738",
+		"be10040e01000000065f1f0400001e1dffff",
+	),
+	"high level security gmac": hexVector(
+		"605d",
+		"a109060760857405080103",
+		"a60a04084d4d4d0000000001",
+		"8a020780",
+		"8b0760857405080205",
+		"ac12801000112233445566778899aabbccddeeff",
+		"be230421211f3000000001",
+		"000102030405060708090a0b0c0d0e0f10111213141516171819",
+	),
+}
+
+// aareVectors are AAREs as IDIS meters answer them
+var aareVectors = map[string][]byte{
+	"accepted": hexVector(
+		"6129",
+		"a109060760857405080101",
+		"a203020100",
+		"a305a103020100",
+		"be10040e0800065f1f0400001e1d04c80007",
+	),
+	"accepted low level security": hexVector(
+		"6136",
+		"a109060760857405080101",
+		"a203020100",
+		"a305a103020100",
+		"88020780",
+		"890760857405080201",
+		"be10040e0800065f1f0400001e1d04c80007",
+	),
+	"rejected dlms version": hexVector(
+		"611f",
+		"a109060760857405080101",
+		"a203020101",
+		"a305a10302010d",
+		"be0604040e010601",
+	),
+	"high level security gmac challenge": hexVector(
+		"6169",
+		"a109060760857405080103",
+		"a203020100",
+		"a305a10302010e",
+		"a40a04084d4d4d0000bc614e",
+		"88020780",
+		"890760857405080205",
+		"aa1280100f0e0d0c0b0a09080706050403020100",
+		"be230421281f3000000001",
+		"191817161514131211100f0e0d0c0b0a09080706050403020100",
+	),
+}
+
+func TestApplicationAssociationRequest_GoldenVectors(t *testing.T) {
+	for name, vector := range aarqVectors {
+		t.Run(name, func(t *testing.T) {
+			aarq, err := (&acse.ApplicationAssociationRequest{}).FromBytes(vector)
+			require.NoError(t, err)
+
+			encoded, err := aarq.ToBytes()
+			require.NoError(t, err)
+			assert.Equal(t, vector, encoded)
+		})
+	}
+}
+
+func TestApplicationAssociationResponse_GoldenVectors(t *testing.T) {
+	for name, vector := range aareVectors {
+		t.Run(name, func(t *testing.T) {
+			aare, err := (&acse.ApplicationAssociationResponse{}).FromBytes(vector)
+			require.NoError(t, err)
+
+			encoded, err := aare.ToBytes()
+			require.NoError(t, err)
+			assert.Equal(t, vector, encoded)
+		})
+	}
+}
+
+func TestApplicationAssociationRequest_GoldenVectorFields(t *testing.T) {
+	aarq, err := (&acse.ApplicationAssociationRequest{}).FromBytes(aarqVectors["high level security gmac"])
+	require.NoError(t, err)
+
+	assert.True(t, aarq.Ciphered)
+	require.NotNil(t, aarq.Authentication)
+	assert.Equal(t, enumerations.AuthenticationMechanismHLSGMAC, *aarq.Authentication)
+	assert.Equal(t, decodeHexString("4d4d4d0000000001"), aarq.SystemTitle)
+	assert.Equal(t, decodeHexString("00112233445566778899aabbccddeeff"), aarq.AuthenticationValue)
+	content, ok := aarq.UserInformation.Content.(*xdlms.GlobalCipherInitiateRequest)
+	require.True(t, ok)
+	assert.Equal(t, uint32(1), content.InvocationCounter)
+}
+
+func TestApplicationAssociationResponse_GoldenVectorFields(t *testing.T) {
+	aare, err := (&acse.ApplicationAssociationResponse{}).FromBytes(aareVectors["rejected dlms version"])
+	require.NoError(t, err)
+
+	assert.Equal(t, enumerations.AssociationResult(1), aare.Result)
+	assert.Equal(t, enumerations.AcseServiceUserDiagnostics(13), aare.ResultSourceDiagnostics)
+	serviceError, ok := aare.UserInformation.Content.(*xdlms.ConfirmedServiceError)
+	require.True(t, ok)
+	assert.Equal(t, enumerations.ServiceErrorType(6), serviceError.ErrorType)
+}
+
+func TestResultSourceDiagnostics_FromBytes(t *testing.T) {
+	for _, test := range []struct {
+		encoded string
+		name    string
+		value   int
+	}{
+		{"A103020100", "acse-service-user", 0},
+		{"A10302010D", "acse-service-user", 13},
+		{"A203020102", "acse-service-provider", 2},
+		// implicit form of some meters
+		{"810101", "acse-service-user", 1},
+		{"820102", "acse-service-provider", 2},
+	} {
+		t.Run(test.encoded, func(t *testing.T) {
+			decoded, err := (&acse.ResultSourceDiagnostics{}).FromBytes(decodeHexString(test.encoded))
+			require.NoError(t, err)
+			assert.Equal(t, acse.NewResultSourceDiagnostics(test.name, test.value), decoded)
+		})
+	}
+
+	encoded, err := acse.NewResultSourceDiagnostics("acse-service-user", 13).ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, decodeHexString("A10302010D"), encoded)
+
+	for _, encoded := range []string{"A103", "A30302010D", "A1050201"} {
+		_, err := (&acse.ResultSourceDiagnostics{}).FromBytes(decodeHexString(encoded))
+		assert.Error(t, err, encoded)
+	}
+}
+
+func TestApplicationAssociationRequest_SpecificationOrder(t *testing.T) {
+	aarq, err := (&acse.ApplicationAssociationRequest{}).FromBytes(aarqVectors["low level security"])
+	require.NoError(t, err)
+	aarq.ImplementationInformation = decodeHexString("0400")
+	aarq.CallingAEInvocationIdentifier = decodeHexString("020101")
+	aarq.CalledAPTitle = decodeHexString("0400")
+
+	encoded, err := aarq.ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, hexVector(
+		"6043",
+		"a109060760857405080101",
+		"a2020400",
+		"a903020101",
+		"8a020780",
+		"8b0760857405080201",
+		"ac0a80083132333435363738",
+		"bd020400",
+		"be10040e01000000065f1f0400001e1dffff",
+	), encoded)
+}
+
+func TestApplicationAssociationRequest_PasswordIsNotPrinted(t *testing.T) {
+	aarq, err := (&acse.ApplicationAssociationRequest{}).FromBytes(aarqVectors["low level security"])
+	require.NoError(t, err)
+	value, err := acse.NewAuthenticationValue([]byte("12345678"), "chars")
+	require.NoError(t, err)
+
+	for _, printed := range []string{
+		aarq.String(), fmt.Sprintf("%v", aarq), fmt.Sprintf("%#v", aarq),
+		value.String(), fmt.Sprintf("%#v", value),
+	} {
+		assert.NotContains(t, printed, "12345678")
+		assert.NotContains(t, printed, "3132333435363738")
+	}
+}
+
+func TestRedactAuthenticationValue(t *testing.T) {
+	aarq := aarqVectors["low level security"]
+	redacted := acse.RedactAuthenticationValue(aarq)
+	assert.Equal(t, hexVector(
+		"6036",
+		"a109060760857405080101",
+		"8a020780",
+		"8b0760857405080201",
+		"ac0a80080000000000000000",
+		"be10040e01000000065f1f0400001e1dffff",
+	), redacted)
+	// the APDU is copied
+	assert.Equal(t, byte(0x31), aarq[len(aarq)-26])
+
+	aare := aareVectors["high level security gmac challenge"]
+	assert.Contains(t, fmt.Sprintf("%x", acse.RedactAuthenticationValue(aare)), "aa12801000000000000000000000000000000000be")
+
+	other := decodeHexString("C001C100080000010000FF0200")
+	assert.Equal(t, other, acse.RedactAuthenticationValue(other))
+}
+
+func TestNewApduFactory(t *testing.T) {
+	factory := acse.NewApduFactory()
+	for name, vector := range aarqVectors {
+		apdu, err := factory.APDUFromBytes(vector)
+		require.NoError(t, err, name)
+		assert.IsType(t, &acse.ApplicationAssociationRequest{}, apdu)
+	}
+	for name, vector := range aareVectors {
+		apdu, err := factory.APDUFromBytes(vector)
+		require.NoError(t, err, name)
+		assert.IsType(t, &acse.ApplicationAssociationResponse{}, apdu)
+	}
+	apdu, err := factory.APDUFromBytes(decodeHexString("6203800100"))
+	require.NoError(t, err)
+	assert.IsType(t, &acse.ReleaseRequest{}, apdu)
+
+	// the ACSE parsers are registered on that factory only
+	_, err = xdlms.NewXDlmsApduFactory().APDUFromBytes(aarqVectors["public client"])
+	assert.Error(t, err)
+}
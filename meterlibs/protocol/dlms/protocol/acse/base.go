@@ -2,9 +2,11 @@ package acse
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
 )
 
 // AbstractAcseApdu is the base interface for ACSE APDUs
@@ -49,22 +51,22 @@ func NewAppContextName(logicalNameRefs, cipheredAPDUs bool) *AppContextName {
 
 // ContextID returns the context ID based on logical name refs and ciphered APDUs
 func (a *AppContextName) ContextID() int {
-	if a.LogicalNameRefs && !a.CipheredAPDUs {
+	switch {
+	case a.LogicalNameRefs && !a.CipheredAPDUs:
 		return 1
-	} else if !a.LogicalNameRefs && !a.CipheredAPDUs {
+	case !a.LogicalNameRefs && !a.CipheredAPDUs:
 		return 2
-	} else if a.LogicalNameRefs && a.CipheredAPDUs {
+	case a.LogicalNameRefs && a.CipheredAPDUs:
 		return 3
-	} else if !a.LogicalNameRefs && a.CipheredAPDUs {
+	default:
 		return 4
 	}
-	panic("Combination of logical name ref and ciphered apdus not possible")
 }
 
 // FromBytes creates AppContextName from bytes
 func (a *AppContextName) FromBytes(data []byte) (*AppContextName, error) {
 	ber := encoding.NewBER()
-	tag, length, berData, err := ber.Decode(data, 1)
+	tag, _, berData, err := ber.Decode(data, 1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode BER: %w", err)
 	}
@@ -184,7 +186,7 @@ func NewAuthenticationValue(password []byte, passwordType string) (*Authenticati
 // FromBytes creates AuthenticationValue from bytes
 func (a *AuthenticationValue) FromBytes(data []byte) (*AuthenticationValue, error) {
 	ber := encoding.NewBER()
-	tag, length, berData, err := ber.Decode(data, 1)
+	tag, _, berData, err := ber.Decode(data, 1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode BER: %w", err)
 	}
@@ -261,3 +263,177 @@ func bytesEqual(a, b []byte) bool {
 	return true
 }
 
+// String implements fmt.Stringer without exposing the password
+func (a *AuthenticationValue) String() string {
+	return fmt.Sprintf("AuthenticationValue(type=%s, password=%s)", a.PasswordType, xdlms.Redacted)
+}
+
+// GoString keeps %#v from printing the password
+func (a *AuthenticationValue) GoString() string {
+	return a.String()
+}
+
+// redactedBytes returns the redaction marker for secrets that are set
+func redactedBytes(secret []byte) string {
+	if secret == nil {
+		return "none"
+	}
+	return xdlms.Redacted
+}
+
+// authenticationString formats an optional authentication mechanism
+func authenticationString(mechanism *enumerations.AuthenticationMechanism) string {
+	if mechanism == nil {
+		return "none"
+	}
+	return mechanism.String()
+}
+
+// RedactAuthenticationValue returns a copy of an encoded AARQ or AARE where the
+// content of the (responding) authentication value is overwritten, so the APDU
+// can be hex dumped by logging and tracing layers. The fields after an invalid
+// one are overwritten as well, as the value could be among them. Other APDUs
+// are returned as an unchanged copy.
+func RedactAuthenticationValue(apdu []byte) []byte {
+	redacted := make([]byte, len(apdu))
+	copy(redacted, apdu)
+
+	if len(redacted) < 2 || (redacted[0] != AARQTag && redacted[0] != AARETag) {
+		return redacted
+	}
+
+	ber := encoding.NewBER()
+	length, consumed, err := encoding.DecodeLength(redacted[1:])
+	if err != nil {
+		mask(redacted[1:])
+		return redacted
+	}
+	// A truncated APDU is read up to its end
+	content := redacted[1+consumed:]
+	if length < len(content) {
+		content = content[:length]
+	}
+	// The values are slices of redacted, masking them masks the copy
+	for len(content) > 0 {
+		tag, value, rest, err := ber.NextTLV(content)
+		if err != nil {
+			mask(content)
+			break
+		}
+		if tag == 0xAC || tag == 0xAA {
+			// Keep the inner chars/bits tag and length, mask the value
+			if _, inner, _, err := ber.NextTLV(value); err == nil {
+				mask(inner)
+			} else {
+				mask(value)
+			}
+		}
+		content = rest
+	}
+	return redacted
+}
+
+// mask overwrites data with zeros
+func mask(data []byte) {
+	for i := range data {
+		data[i] = 0x00
+	}
+}
+
+// acseField is an optional field of an AARQ or an AARE. Value returns nil when
+// the field is absent.
+type acseField struct {
+	tag   byte
+	name  string
+	value func() ([]byte, error)
+}
+
+// encodeAcseFields encodes the fields of an AARQ or an AARE in the order of
+// their context tag numbers, the order of the ASN.1 definition. Some meters
+// refuse an association request with the fields in another order.
+func encodeAcseFields(apduTag int, fields []acseField) ([]byte, error) {
+	ordered := make([]acseField, len(fields))
+	copy(ordered, fields)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].tag&0x1F < ordered[j].tag&0x1F
+	})
+
+	ber := encoding.NewBER()
+	data := make([]byte, 0)
+	for _, field := range ordered {
+		value, err := field.value()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", field.name, err)
+		}
+		if value == nil {
+			continue
+		}
+		encoded, err := ber.Encode(int(field.tag), value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to BER encode %s: %w", field.name, err)
+		}
+		data = append(data, encoded...)
+	}
+	return ber.Encode(apduTag, data)
+}
+
+// rawValue is a field encoded as is
+func rawValue(value []byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return value, nil
+	}
+}
+
+// octetStringValue is a field holding a BER octet string
+func octetStringValue(value []byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if value == nil {
+			return nil, nil
+		}
+		return encoding.NewBER().Encode(4, value)
+	}
+}
+
+// authenticationValue is the calling or responding authentication value
+func authenticationValue(password []byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if password == nil {
+			return nil, nil
+		}
+		value, err := NewAuthenticationValue(password, "chars")
+		if err != nil {
+			return nil, err
+		}
+		return value.ToBytes()
+	}
+}
+
+// userInformationValue is the user information field
+func userInformationValue(userInformation *UserInformation) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if userInformation == nil {
+			return nil, nil
+		}
+		return userInformation.ToBytes()
+	}
+}
+
+// authFunctionalUnitValue is the sender or responder ACSE requirements
+func authFunctionalUnitValue(unit *AuthFunctionalUnit) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if unit == nil {
+			return nil, nil
+		}
+		return unit.ToBytes()
+	}
+}
+
+// mechanismNameValue is the mechanism name field
+func mechanismNameValue(mechanism *MechanismName) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if mechanism == nil {
+			return nil, nil
+		}
+		return mechanism.ToBytes()
+	}
+}
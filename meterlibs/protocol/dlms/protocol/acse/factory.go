@@ -0,0 +1,45 @@
+package acse
+
+import "github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+
+// NewApduFactory creates an xdlms factory that parses the ACSE APDUs as well,
+// the xdlms package can't import this package without an import cycle
+func NewApduFactory() *xdlms.XDlmsApduFactory {
+	factory := xdlms.NewXDlmsApduFactory()
+	for tag, parser := range map[uint8]xdlms.ApduParser{
+		AARQTag: func(data []byte) (xdlms.Apdu, error) {
+			apdu, err := (&ApplicationAssociationRequest{}).FromBytes(data)
+			if err != nil {
+				return nil, err
+			}
+			return apdu, nil
+		},
+		AARETag: func(data []byte) (xdlms.Apdu, error) {
+			apdu, err := (&ApplicationAssociationResponse{}).FromBytes(data)
+			if err != nil {
+				return nil, err
+			}
+			return apdu, nil
+		},
+		RLRQTag: func(data []byte) (xdlms.Apdu, error) {
+			apdu, err := (&ReleaseRequest{}).FromBytes(data)
+			if err != nil {
+				return nil, err
+			}
+			return apdu, nil
+		},
+		RLRETag: func(data []byte) (xdlms.Apdu, error) {
+			apdu, err := (&ReleaseResponse{}).FromBytes(data)
+			if err != nil {
+				return nil, err
+			}
+			return apdu, nil
+		},
+	} {
+		// The ACSE tags are not standard xDLMS tags and the factory is new
+		if err := factory.Register(tag, parser); err != nil {
+			panic(err)
+		}
+	}
+	return factory
+}
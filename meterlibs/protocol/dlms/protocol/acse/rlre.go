@@ -5,6 +5,7 @@ import (
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
 )
 
 // ReleaseResponse represents an RLRE (Release Response)
@@ -41,57 +42,37 @@ func (r *ReleaseResponse) FromBytes(sourceBytes []byte) (*ReleaseResponse, error
 		return nil, fmt.Errorf("bytes are not an RLRE APDU, tag is not %d, got %d", RLRETag, tag)
 	}
 
-	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for RLRE length")
+	ber := encoding.NewBER()
+	_, data, rest, err := ber.NextTLV(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RLRE: %w", err)
 	}
-
-	length := int(data[1])
-	data = data[2:]
-
-	if len(data) != length {
-		return nil, fmt.Errorf("the APDU data length does not correspond to length byte, expected %d, got %d", length, len(data))
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("the APDU data length does not correspond to the length, got %d bytes after the RLRE", len(rest))
 	}
 
 	// Parse tags
 	objectDict := make(map[string]interface{})
-	ber := encoding.NewBER()
 
 	for len(data) > 0 {
-		if len(data) < 2 {
-			return nil, fmt.Errorf("insufficient data for tag and length")
-		}
-
-		objectTag := data[0]
-		objectLength := int(data[1])
-		data = data[2:]
-
-		if len(data) < objectLength {
-			return nil, fmt.Errorf("insufficient data for object, need %d bytes, got %d", objectLength, len(data))
+		objectTag, objectData, rest, err := ber.NextTLV(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RLRE: %w", err)
 		}
-
-		objectData := data[:objectLength]
-		data = data[objectLength:]
+		data = rest
 
 		var objectName string
 		var parsedData interface{}
-		var err error
 
 		switch objectTag {
 		case 0x80: // reason
 			objectName = "reason"
 			if len(objectData) > 0 {
-				// Decode BER encoded integer
-				tag, length, berData, err := ber.Decode(objectData, 1)
+				value, err := decodeReleaseReason(objectData)
 				if err != nil {
 					return nil, fmt.Errorf("failed to decode reason: %w", err)
 				}
-				if !bytesEqual(tag, []byte{2}) { // Integer tag
-					return nil, fmt.Errorf("reason is not an integer")
-				}
-				if len(berData) != int(length) || len(berData) == 0 {
-					return nil, fmt.Errorf("invalid reason data length")
-				}
-				reason := enumerations.ReleaseResponseReason(berData[0])
+				reason := enumerations.ReleaseResponseReason(value)
 				parsedData = &reason
 			} else {
 				parsedData = nil
@@ -122,13 +103,8 @@ func (r *ReleaseResponse) ToBytes() ([]byte, error) {
 	rlreData := make([]byte, 0)
 
 	if r.Reason != nil {
-		// First encode the reason as a BER integer (tag 0x02)
-		integerBytes, err := ber.Encode(2, []byte{byte(*r.Reason)})
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode reason as integer: %w", err)
-		}
-		// Then wrap it with tag 0x80
-		reasonBytes, err := ber.Encode(0x80, integerBytes)
+		// reason is an implicit integer, [0] IMPLICIT Release-response-reason
+		reasonBytes, err := ber.Encode(0x80, encodeAsn1IntegerValue(int(*r.Reason)))
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode reason: %w", err)
 		}
@@ -150,3 +126,21 @@ func (r *ReleaseResponse) ToBytes() ([]byte, error) {
 	return ber.Encode(RLRETag, rlreData)
 }
 
+// Tag returns the RLRE tag
+func (r *ReleaseResponse) Tag() uint8 {
+	return RLRETag
+}
+
+// Kind returns xdlms.ApduKindReleaseResponse
+func (*ReleaseResponse) Kind() xdlms.ApduKind {
+	return xdlms.ApduKindReleaseResponse
+}
+
+// String implements fmt.Stringer
+func (r *ReleaseResponse) String() string {
+	reason := "none"
+	if r.Reason != nil {
+		reason = fmt.Sprintf("%d", *r.Reason)
+	}
+	return fmt.Sprintf("RLRE(reason=%s, user_information=%s)", reason, r.UserInformation)
+}
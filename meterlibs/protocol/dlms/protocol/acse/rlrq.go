@@ -5,6 +5,7 @@ import (
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
 )
 
 // ReleaseRequest represents an RLRQ (Release Request)
@@ -41,57 +42,37 @@ func (r *ReleaseRequest) FromBytes(sourceBytes []byte) (*ReleaseRequest, error)
 		return nil, fmt.Errorf("bytes are not an RLRQ APDU, tag is not %d, got %d", RLRQTag, rlrqTag)
 	}
 
-	if len(rlrqData) < 2 {
-		return nil, fmt.Errorf("insufficient data for RLRQ length")
+	ber := encoding.NewBER()
+	_, rlrqData, rest, err := ber.NextTLV(rlrqData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RLRQ: %w", err)
 	}
-
-	rlrqLength := int(rlrqData[1])
-	rlrqData = rlrqData[2:]
-
-	if len(rlrqData) != rlrqLength {
-		return nil, fmt.Errorf("the APDU data length does not correspond to length byte, expected %d, got %d", rlrqLength, len(rlrqData))
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("the APDU data length does not correspond to the length, got %d bytes after the RLRQ", len(rest))
 	}
 
 	// Parse tags
 	objectDict := make(map[string]interface{})
-	ber := encoding.NewBER()
 
 	for len(rlrqData) > 0 {
-		if len(rlrqData) < 2 {
-			return nil, fmt.Errorf("insufficient data for tag and length")
-		}
-
-		objectTag := rlrqData[0]
-		objectLength := int(rlrqData[1])
-		rlrqData = rlrqData[2:]
-
-		if len(rlrqData) < objectLength {
-			return nil, fmt.Errorf("insufficient data for object, need %d bytes, got %d", objectLength, len(rlrqData))
+		objectTag, objectData, rest, err := ber.NextTLV(rlrqData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RLRQ: %w", err)
 		}
-
-		objectData := rlrqData[:objectLength]
-		rlrqData = rlrqData[objectLength:]
+		rlrqData = rest
 
 		var objectName string
 		var parsedData interface{}
-		var err error
 
 		switch objectTag {
 		case 0x80: // reason
 			objectName = "reason"
 			if len(objectData) > 0 {
-				// Decode BER encoded integer
-				tag, length, data, err := ber.Decode(objectData, 1)
+				value, err := decodeReleaseReason(objectData)
 				if err != nil {
 					return nil, fmt.Errorf("failed to decode reason: %w", err)
 				}
-				if !bytesEqual(tag, []byte{2}) { // Integer tag
-					return nil, fmt.Errorf("reason is not an integer")
-				}
-				if len(data) != int(length) || len(data) == 0 {
-					return nil, fmt.Errorf("invalid reason data length")
-				}
-				reason := enumerations.ReleaseRequestReason(data[0])
+				reason := enumerations.ReleaseRequestReason(value)
 				parsedData = &reason
 			} else {
 				parsedData = nil
@@ -122,7 +103,8 @@ func (r *ReleaseRequest) ToBytes() ([]byte, error) {
 	rlrqData := make([]byte, 0)
 
 	if r.Reason != nil {
-		reasonBytes, err := ber.Encode(0x80, []byte{byte(*r.Reason)})
+		// reason is an implicit integer, [0] IMPLICIT Release-request-reason
+		reasonBytes, err := ber.Encode(0x80, encodeAsn1IntegerValue(int(*r.Reason)))
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode reason: %w", err)
 		}
@@ -143,3 +125,39 @@ func (r *ReleaseRequest) ToBytes() ([]byte, error) {
 
 	return ber.Encode(RLRQTag, rlrqData)
 }
+
+// NewCipheredReleaseRequest creates the RLRQ to release a ciphered association.
+// The InitiateRequest of the AARQ is sent again, protected with the global key,
+// so the meter can verify that the release comes from the associated client.
+func NewCipheredReleaseRequest(cipheredInitiateRequest *xdlms.GlobalCipherInitiateRequest) *ReleaseRequest {
+	reason := enumerations.ReleaseRequestReasonNormal
+	return NewReleaseRequest(&reason, NewUserInformation(cipheredInitiateRequest))
+}
+
+// decodeReleaseReason decodes the reason of a RLRQ or RLRE. The reason is an
+// implicit integer, but some meters send it wrapped in a universal integer.
+func decodeReleaseReason(objectData []byte) (int, error) {
+	if len(objectData) > 2 && objectData[0] == Asn1IntegerTag && int(objectData[1]) == len(objectData)-2 {
+		return decodeAsn1IntegerValue(objectData[2:])
+	}
+	return decodeAsn1IntegerValue(objectData)
+}
+
+// Tag returns the RLRQ tag
+func (r *ReleaseRequest) Tag() uint8 {
+	return RLRQTag
+}
+
+// Kind returns xdlms.ApduKindReleaseRequest
+func (*ReleaseRequest) Kind() xdlms.ApduKind {
+	return xdlms.ApduKindReleaseRequest
+}
+
+// String implements fmt.Stringer
+func (r *ReleaseRequest) String() string {
+	reason := "none"
+	if r.Reason != nil {
+		reason = fmt.Sprintf("%d", *r.Reason)
+	}
+	return fmt.Sprintf("RLRQ(reason=%s, user_information=%s)", reason, r.UserInformation)
+}
@@ -0,0 +1,92 @@
+package acse_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+func TestReleaseRequest_RoundTrip(t *testing.T) {
+	reason := enumerations.ReleaseRequestReasonNormal
+	rlrq := acse.NewReleaseRequest(&reason, nil)
+
+	encoded, err := rlrq.ToBytes()
+	assert.NoError(t, err)
+	assert.Equal(t, decodeHexString("6203800100"), encoded)
+
+	decoded, err := (&acse.ReleaseRequest{}).FromBytes(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, reason, *decoded.Reason)
+	assert.Nil(t, decoded.UserInformation)
+}
+
+func TestReleaseRequest_NestedReason(t *testing.T) {
+	decoded, err := (&acse.ReleaseRequest{}).FromBytes(decodeHexString("62058003020101"))
+	assert.NoError(t, err)
+	assert.Equal(t, enumerations.ReleaseRequestReasonUrgent, *decoded.Reason)
+}
+
+func TestReleaseRequest_Ciphered(t *testing.T) {
+	ciphered := xdlms.NewGlobalCipherInitiateRequest(byte(0x30), 1, decodeHexString("AABBCCDDEEFF00112233"))
+	rlrq := acse.NewCipheredReleaseRequest(ciphered)
+
+	encoded, err := rlrq.ToBytes()
+	assert.NoError(t, err)
+
+	decoded, err := (&acse.ReleaseRequest{}).FromBytes(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, enumerations.ReleaseRequestReasonNormal, *decoded.Reason)
+
+	content, ok := decoded.UserInformation.Content.(*xdlms.GlobalCipherInitiateRequest)
+	assert.True(t, ok)
+	assert.Equal(t, byte(0x30), content.SecurityControl)
+	assert.Equal(t, uint32(1), content.InvocationCounter)
+	assert.Equal(t, ciphered.CipheredText, content.CipheredText)
+
+	reencoded, err := decoded.ToBytes()
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, reencoded)
+}
+
+func TestReleaseResponse_RoundTrip(t *testing.T) {
+	reason := enumerations.ReleaseResponseReasonNormal
+	rlre := acse.NewReleaseResponse(&reason, nil)
+
+	encoded, err := rlre.ToBytes()
+	assert.NoError(t, err)
+	assert.Equal(t, decodeHexString("6303800100"), encoded)
+
+	decoded, err := (&acse.ReleaseResponse{}).FromBytes(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, reason, *decoded.Reason)
+}
+
+func decodeHexString(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+func TestReleaseRequest_LongFormLength(t *testing.T) {
+	// A ciphered text of 200 bytes needs the long form 81 xx for the lengths
+	// of the user information and of the RLRQ
+	ciphered := xdlms.NewGlobalCipherInitiateRequest(byte(0x30), 1, make([]byte, 200))
+	rlrq := acse.NewCipheredReleaseRequest(ciphered)
+
+	encoded, err := rlrq.ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{acse.RLRQTag, 0x81}, encoded[:2])
+
+	decoded, err := (&acse.ReleaseRequest{}).FromBytes(encoded)
+	require.NoError(t, err)
+	content, ok := decoded.UserInformation.Content.(*xdlms.GlobalCipherInitiateRequest)
+	require.True(t, ok)
+	assert.Equal(t, ciphered.CipheredText, content.CipheredText)
+
+	_, err = (&acse.ReleaseRequest{}).FromBytes(encoded[:len(encoded)-1])
+	assert.Error(t, err)
+}
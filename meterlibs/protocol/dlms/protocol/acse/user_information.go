@@ -24,12 +24,12 @@ func NewUserInformation(content interface{}) *UserInformation {
 // FromBytes creates UserInformation from bytes
 func (u *UserInformation) FromBytes(data []byte) (*UserInformation, error) {
 	ber := encoding.NewBER()
-	tag, length, berData, err := ber.Decode(data, 1)
+	tag, _, berData, err := ber.Decode(data, 1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode BER: %w", err)
 	}
 
-	if !bytesEqual(tag, u.Tag) {
+	if !bytesEqual(tag, []byte{0x04}) {
 		return nil, fmt.Errorf("the tag for UserInformation data should be 0x04, not %v", tag)
 	}
 
@@ -53,15 +53,27 @@ func (u *UserInformation) FromBytes(data []byte) (*UserInformation, error) {
 			return nil, fmt.Errorf("failed to parse InitiateResponse: %w", err)
 		}
 		content = parsedResp
-	case 14:
-		// ConfirmedServiceError - TODO: implement when needed
-		return nil, fmt.Errorf("ConfirmedServiceError not yet implemented")
-	case 33:
-		// GlobalCipherInitiateRequest - TODO: implement when needed
-		return nil, fmt.Errorf("GlobalCipherInitiateRequest not yet implemented")
-	case 40:
-		// GlobalCipherInitiateResponse - TODO: implement when needed
-		return nil, fmt.Errorf("GlobalCipherInitiateResponse not yet implemented")
+	case xdlms.ConfirmedServiceErrorTag:
+		serviceError := &xdlms.ConfirmedServiceError{}
+		parsedError, err := serviceError.FromBytes(berData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ConfirmedServiceError: %w", err)
+		}
+		content = parsedError
+	case xdlms.GlobalCipherInitiateRequestTag:
+		initReq := &xdlms.GlobalCipherInitiateRequest{}
+		parsedReq, err := initReq.FromBytes(berData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GlobalCipherInitiateRequest: %w", err)
+		}
+		content = parsedReq
+	case xdlms.GlobalCipherInitiateResponseTag:
+		initResp := &xdlms.GlobalCipherInitiateResponse{}
+		parsedResp, err := initResp.FromBytes(berData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GlobalCipherInitiateResponse: %w", err)
+		}
+		content = parsedResp
 	default:
 		return nil, fmt.Errorf("not able to find a proper data tag in UserInformation, got %d", berData[0])
 	}
@@ -86,6 +98,21 @@ func (u *UserInformation) ToBytes() ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode InitiateResponse: %w", err)
 		}
+	case *xdlms.ConfirmedServiceError:
+		contentBytes, err = c.ToBytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode ConfirmedServiceError: %w", err)
+		}
+	case *xdlms.GlobalCipherInitiateRequest:
+		contentBytes, err = c.ToBytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode GlobalCipherInitiateRequest: %w", err)
+		}
+	case *xdlms.GlobalCipherInitiateResponse:
+		contentBytes, err = c.ToBytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode GlobalCipherInitiateResponse: %w", err)
+		}
 	default:
 		return nil, fmt.Errorf("unsupported content type: %T", u.Content)
 	}
@@ -93,3 +120,10 @@ func (u *UserInformation) ToBytes() ([]byte, error) {
 	return ber.Encode(u.Tag, contentBytes)
 }
 
+// String implements fmt.Stringer, keys in the content are redacted by the content itself
+func (u *UserInformation) String() string {
+	if u == nil {
+		return "none"
+	}
+	return fmt.Sprintf("UserInformation(%v)", u.Content)
+}
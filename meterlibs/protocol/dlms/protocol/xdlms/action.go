@@ -1,9 +1,11 @@
 package xdlms
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
 )
 
@@ -25,7 +27,7 @@ func NewActionRequestNormal(
 ) *ActionRequestNormal {
 	return &ActionRequestNormal{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: ActionRequestTag,
+			tag: ActionRequestTag,
 		},
 		CosemMethod:         cosemMethod,
 		Data:                data,
@@ -35,8 +37,9 @@ func NewActionRequestNormal(
 
 // FromBytes creates ActionRequestNormal from bytes
 func (a *ActionRequestNormal) FromBytes(data []byte) (*ActionRequestNormal, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for ActionRequest")
+		return nil, truncated("ActionRequestNormal", "header", 0)
 	}
 	
 	tag := data[0]
@@ -45,7 +48,7 @@ func (a *ActionRequestNormal) FromBytes(data []byte) (*ActionRequestNormal, erro
 	}
 	
 	requestType := enumerations.ActionType(data[1])
-	if requestType != enumerations.ActionTypeNormal {
+	if requestType != enumerations.ActionNormal {
 		return nil, fmt.Errorf("bytes are not representing a ActionRequestNormal. Action type is %d", requestType)
 	}
 	
@@ -53,7 +56,7 @@ func (a *ActionRequestNormal) FromBytes(data []byte) (*ActionRequestNormal, erro
 	
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("ActionRequestNormal", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -63,7 +66,7 @@ func (a *ActionRequestNormal) FromBytes(data []byte) (*ActionRequestNormal, erro
 	
 	// Parse cosem_method (9 bytes)
 	if len(data) < 9 {
-		return nil, fmt.Errorf("insufficient data for cosem_method")
+		return nil, truncated("ActionRequestNormal", "cosem_method", len(source)-len(data))
 	}
 	cosemMethod, err := (&cosem.CosemMethod{}).FromBytes(data[:9])
 	if err != nil {
@@ -88,7 +91,7 @@ func (a *ActionRequestNormal) FromBytes(data []byte) (*ActionRequestNormal, erro
 // ToBytes converts ActionRequestNormal to bytes
 func (a *ActionRequestNormal) ToBytes() ([]byte, error) {
 	result := []byte{ActionRequestTag}
-	result = append(result, byte(enumerations.ActionTypeNormal))
+	result = append(result, byte(enumerations.ActionNormal))
 	
 	invokeBytes := a.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -122,73 +125,37 @@ func NewActionResponseNormal(
 ) *ActionResponseNormal {
 	return &ActionResponseNormal{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: ActionResponseTag,
+			tag: ActionResponseTag,
 		},
 		Status:              status,
 		InvokeIdAndPriority: invokeIdAndPriority,
 	}
 }
 
+var actionResponseNormalSchema = &apduSchema[ActionResponseNormal]{
+	name: "ActionResponseNormal",
+	fields: []schemaField[ActionResponseNormal]{
+		constField[ActionResponseNormal]("tag", ActionResponseTag),
+		constField[ActionResponseNormal]("type", uint8(enumerations.ActionNormal)),
+		invokeIDField(func(a *ActionResponseNormal) **InvokeIdAndPriority { return &a.InvokeIdAndPriority }),
+		uint8Field("status", func(a *ActionResponseNormal) *enumerations.ActionResultStatus { return &a.Status }),
+		// the response has no return parameters
+		constField[ActionResponseNormal]("has_data", 0x00),
+	},
+}
+
 // FromBytes creates ActionResponseNormal from bytes
 func (a *ActionResponseNormal) FromBytes(data []byte) (*ActionResponseNormal, error) {
-	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for ActionResponse")
+	response := NewActionResponseNormal(0, nil)
+	if err := actionResponseNormalSchema.decode(data, response); err != nil {
+		return nil, err
 	}
-	
-	tag := data[0]
-	if tag != ActionResponseTag {
-		return nil, fmt.Errorf("tag %d is not correct for ActionResponse. Should be %d", tag, ActionResponseTag)
-	}
-	
-	actionType := enumerations.ActionType(data[1])
-	if actionType != enumerations.ActionTypeNormal {
-		return nil, fmt.Errorf("bytes are not representing a ActionResponseNormal. Action type is %d", actionType)
-	}
-	
-	data = data[2:]
-	
-	// Parse invoke_id_and_priority
-	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
-	}
-	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse invoke_id_and_priority: %w", err)
-	}
-	data = data[1:]
-	
-	// Parse status
-	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for status")
-	}
-	status := enumerations.ActionResultStatus(data[0])
-	data = data[1:]
-	
-	// Parse has_data flag (should be 0 for normal response)
-	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for has_data flag")
-	}
-		hasData := data[0] != 0
-	data = data[1:] // Advance pointer after reading the flag
-		if hasData {
-		return nil, fmt.Errorf("ActionResponse has data and should not be a ActionResponseNormal")
-	}
-	
-	return NewActionResponseNormal(status, invokeIdAndPriority), nil
+	return response, nil
 }
 
 // ToBytes converts ActionResponseNormal to bytes
 func (a *ActionResponseNormal) ToBytes() ([]byte, error) {
-	result := []byte{ActionResponseTag}
-	result = append(result, byte(enumerations.ActionTypeNormal))
-	
-	invokeBytes := a.InvokeIdAndPriority.ToBytes()
-	result = append(result, invokeBytes...)
-	
-	result = append(result, byte(a.Status))
-	result = append(result, 0x00) // has_data = false
-	
-	return result, nil
+	return actionResponseNormalSchema.encode(a), nil
 }
 
 // ActionResponseNormalWithData represents an Action response normal with data
@@ -207,7 +174,7 @@ func NewActionResponseNormalWithData(
 ) *ActionResponseNormalWithData {
 	return &ActionResponseNormalWithData{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: ActionResponseTag,
+			tag: ActionResponseTag,
 		},
 		Status:              status,
 		Data:                data,
@@ -217,8 +184,9 @@ func NewActionResponseNormalWithData(
 
 // FromBytes creates ActionResponseNormalWithData from bytes
 func (a *ActionResponseNormalWithData) FromBytes(data []byte) (*ActionResponseNormalWithData, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for ActionResponse")
+		return nil, truncated("ActionResponseNormalWithData", "header", 0)
 	}
 	
 	tag := data[0]
@@ -227,7 +195,7 @@ func (a *ActionResponseNormalWithData) FromBytes(data []byte) (*ActionResponseNo
 	}
 	
 	actionType := enumerations.ActionType(data[1])
-	if actionType != enumerations.ActionTypeNormal {
+	if actionType != enumerations.ActionNormal {
 		return nil, fmt.Errorf("bytes are not representing a ActionResponseNormal. Action type is %d", actionType)
 	}
 	
@@ -235,7 +203,7 @@ func (a *ActionResponseNormalWithData) FromBytes(data []byte) (*ActionResponseNo
 	
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("ActionResponseNormalWithData", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -245,14 +213,14 @@ func (a *ActionResponseNormalWithData) FromBytes(data []byte) (*ActionResponseNo
 	
 	// Parse status
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for status")
+		return nil, truncated("ActionResponseNormalWithData", "status", len(source)-len(data))
 	}
 	status := enumerations.ActionResultStatus(data[0])
 	data = data[1:]
 	
 	// Parse has_data flag (should be 1 for response with data)
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for has_data flag")
+		return nil, truncated("ActionResponseNormalWithData", "has_data", len(source)-len(data))
 	}
 	hasData := data[0] != 0
 	data = data[1:]
@@ -261,6 +229,15 @@ func (a *ActionResponseNormalWithData) FromBytes(data []byte) (*ActionResponseNo
 		return nil, fmt.Errorf("ActionResponseNormalWithData should have data")
 	}
 	
+	// Parse the Get-Data-Result choice, 0 is data
+	if len(data) < 1 {
+		return nil, truncated("ActionResponseNormalWithData", "data_choice", len(source)-len(data))
+	}
+	if data[0] != 0 {
+		return nil, fmt.Errorf("ActionResponseNormalWithData should have data, got choice %d", data[0])
+	}
+	data = data[1:]
+	
 	// Parse data (remaining bytes)
 	responseData := make([]byte, len(data))
 	copy(responseData, data)
@@ -271,13 +248,14 @@ func (a *ActionResponseNormalWithData) FromBytes(data []byte) (*ActionResponseNo
 // ToBytes converts ActionResponseNormalWithData to bytes
 func (a *ActionResponseNormalWithData) ToBytes() ([]byte, error) {
 	result := []byte{ActionResponseTag}
-	result = append(result, byte(enumerations.ActionTypeNormal))
+	result = append(result, byte(enumerations.ActionNormal))
 	
 	invokeBytes := a.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
 	
 	result = append(result, byte(a.Status))
 	result = append(result, 0x01) // has_data = true
+	result = append(result, 0x00) // data choice
 	result = append(result, a.Data...)
 	
 	return result, nil
@@ -299,7 +277,7 @@ func NewActionResponseNormalWithError(
 ) *ActionResponseNormalWithError {
 	return &ActionResponseNormalWithError{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: ActionResponseTag,
+			tag: ActionResponseTag,
 		},
 		Status:              status,
 		Error:                error,
@@ -309,8 +287,9 @@ func NewActionResponseNormalWithError(
 
 // FromBytes creates ActionResponseNormalWithError from bytes
 func (a *ActionResponseNormalWithError) FromBytes(data []byte) (*ActionResponseNormalWithError, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for ActionResponse")
+		return nil, truncated("ActionResponseNormalWithError", "header", 0)
 	}
 	
 	tag := data[0]
@@ -319,7 +298,7 @@ func (a *ActionResponseNormalWithError) FromBytes(data []byte) (*ActionResponseN
 	}
 	
 	actionType := enumerations.ActionType(data[1])
-	if actionType != enumerations.ActionTypeNormal {
+	if actionType != enumerations.ActionNormal {
 		return nil, fmt.Errorf("bytes are not representing a ActionResponseNormal. Action type is %d", actionType)
 	}
 	
@@ -327,7 +306,7 @@ func (a *ActionResponseNormalWithError) FromBytes(data []byte) (*ActionResponseN
 	
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("ActionResponseNormalWithError", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -337,14 +316,14 @@ func (a *ActionResponseNormalWithError) FromBytes(data []byte) (*ActionResponseN
 	
 	// Parse status
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for status")
+		return nil, truncated("ActionResponseNormalWithError", "status", len(source)-len(data))
 	}
 	status := enumerations.ActionResultStatus(data[0])
 	data = data[1:]
 	
 	// Parse has_data flag (should be 1 for response with error)
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for has_data flag")
+		return nil, truncated("ActionResponseNormalWithError", "has_data", len(source)-len(data))
 	}
 	hasData := data[0] != 0
 	data = data[1:]
@@ -355,7 +334,7 @@ func (a *ActionResponseNormalWithError) FromBytes(data []byte) (*ActionResponseN
 	
 	// Parse choice (should be 1 for error)
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for choice")
+		return nil, truncated("ActionResponseNormalWithError", "choice", len(source)-len(data))
 	}
 	choice := data[0]
 	if choice != 1 {
@@ -365,7 +344,7 @@ func (a *ActionResponseNormalWithError) FromBytes(data []byte) (*ActionResponseN
 	
 	// Parse error
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for error")
+		return nil, truncated("ActionResponseNormalWithError", "error", len(source)-len(data))
 	}
 	error := enumerations.DataAccessResult(data[0])
 	
@@ -375,7 +354,7 @@ func (a *ActionResponseNormalWithError) FromBytes(data []byte) (*ActionResponseN
 // ToBytes converts ActionResponseNormalWithError to bytes
 func (a *ActionResponseNormalWithError) ToBytes() ([]byte, error) {
 	result := []byte{ActionResponseTag}
-	result = append(result, byte(enumerations.ActionTypeNormal))
+	result = append(result, byte(enumerations.ActionNormal))
 	
 	invokeBytes := a.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -387,3 +366,191 @@ func (a *ActionResponseNormalWithError) ToBytes() ([]byte, error) {
 	
 	return result, nil
 }
+
+// String implements fmt.Stringer
+func (a *ActionRequestNormal) String() string {
+	return fmt.Sprintf("ActionRequestNormal(%s, method=%s, data=%x)", a.InvokeIdAndPriority, methodString(a.CosemMethod), a.Data)
+}
+
+// String implements fmt.Stringer
+func (a *ActionResponseNormal) String() string {
+	return fmt.Sprintf("ActionResponseNormal(%s, status=%s)", a.InvokeIdAndPriority, a.Status)
+}
+
+// String implements fmt.Stringer
+func (a *ActionResponseNormalWithData) String() string {
+	return fmt.Sprintf("ActionResponseNormalWithData(%s, status=%s, data=%x)", a.InvokeIdAndPriority, a.Status, a.Data)
+}
+
+// String implements fmt.Stringer
+func (a *ActionResponseNormalWithError) String() string {
+	return fmt.Sprintf("ActionResponseNormalWithError(%s, status=%s, error=%s)", a.InvokeIdAndPriority, a.Status, a.Error)
+}
+
+// ActionRequestNextPBlock acknowledges a block of an ACTION response and asks for the next one
+type ActionRequestNextPBlock struct {
+	*BaseXDlmsApdu
+	BlockNumber         uint32
+	InvokeIdAndPriority *InvokeIdAndPriority
+}
+
+// NewActionRequestNextPBlock creates a new ActionRequestNextPBlock
+func NewActionRequestNextPBlock(blockNumber uint32, invokeIdAndPriority *InvokeIdAndPriority) *ActionRequestNextPBlock {
+	return &ActionRequestNextPBlock{
+		BaseXDlmsApdu: &BaseXDlmsApdu{
+			tag: ActionRequestTag,
+		},
+		BlockNumber:         blockNumber,
+		InvokeIdAndPriority: invokeIdAndPriority,
+	}
+}
+
+var actionRequestNextPBlockSchema = &apduSchema[ActionRequestNextPBlock]{
+	name: "ActionRequestNextPBlock",
+	fields: []schemaField[ActionRequestNextPBlock]{
+		constField[ActionRequestNextPBlock]("tag", ActionRequestTag),
+		constField[ActionRequestNextPBlock]("type", uint8(enumerations.ActionNextPBlock)),
+		invokeIDField(func(a *ActionRequestNextPBlock) **InvokeIdAndPriority { return &a.InvokeIdAndPriority }),
+		uint32Field("block_number", func(a *ActionRequestNextPBlock) *uint32 { return &a.BlockNumber }),
+	},
+}
+
+// FromBytes creates ActionRequestNextPBlock from bytes
+func (a *ActionRequestNextPBlock) FromBytes(data []byte) (*ActionRequestNextPBlock, error) {
+	request := NewActionRequestNextPBlock(0, nil)
+	if err := actionRequestNextPBlockSchema.decode(data, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ToBytes converts ActionRequestNextPBlock to bytes
+func (a *ActionRequestNextPBlock) ToBytes() ([]byte, error) {
+	return actionRequestNextPBlockSchema.encode(a), nil
+}
+
+// String implements fmt.Stringer
+func (a *ActionRequestNextPBlock) String() string {
+	return fmt.Sprintf("ActionRequestNextPBlock(%s, block_number=%d)", a.InvokeIdAndPriority, a.BlockNumber)
+}
+
+// ActionResponseWithPBlock is a block of the return data of an ACTION sent in several blocks
+type ActionResponseWithPBlock struct {
+	*BaseXDlmsApdu
+	InvokeIdAndPriority *InvokeIdAndPriority
+	LastBlock           bool
+	BlockNumber         uint32
+	RawData             []byte
+}
+
+// NewActionResponseWithPBlock creates a new ActionResponseWithPBlock
+func NewActionResponseWithPBlock(
+	invokeIdAndPriority *InvokeIdAndPriority,
+	lastBlock bool,
+	blockNumber uint32,
+	rawData []byte,
+) *ActionResponseWithPBlock {
+	return &ActionResponseWithPBlock{
+		BaseXDlmsApdu: &BaseXDlmsApdu{
+			tag: ActionResponseTag,
+		},
+		InvokeIdAndPriority: invokeIdAndPriority,
+		LastBlock:           lastBlock,
+		BlockNumber:         blockNumber,
+		RawData:             rawData,
+	}
+}
+
+// FromBytes creates ActionResponseWithPBlock from bytes
+func (a *ActionResponseWithPBlock) FromBytes(data []byte) (*ActionResponseWithPBlock, error) {
+	if len(data) < 8 {
+		return nil, truncated("ActionResponseWithPBlock", "header", 0)
+	}
+
+	tag := data[0]
+	if tag != ActionResponseTag {
+		return nil, fmt.Errorf("tag %d is not correct for ActionResponse. Should be %d", tag, ActionResponseTag)
+	}
+
+	responseType := enumerations.ActionResponseType(data[1])
+	if responseType != enumerations.ActionResponseWithPBlock {
+		return nil, fmt.Errorf("bytes are not representing a ActionResponseWithPBlock. Action response type is %d", responseType)
+	}
+
+	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[2:3])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse invoke_id_and_priority: %w", err)
+	}
+
+	lastBlock := data[3] != 0
+	blockNumber := binary.BigEndian.Uint32(data[4:8])
+
+	rawDataLength, rest, err := dlmsdata.DecodeVariableInteger(data[8:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse raw_data length: %w", err)
+	}
+	if len(rest) < rawDataLength {
+		return nil, truncated("ActionResponseWithPBlock", "raw_data", len(data)-len(rest))
+	}
+	rawData := make([]byte, rawDataLength)
+	copy(rawData, rest[:rawDataLength])
+
+	return NewActionResponseWithPBlock(invokeIdAndPriority, lastBlock, blockNumber, rawData), nil
+}
+
+// ToBytes converts ActionResponseWithPBlock to bytes
+func (a *ActionResponseWithPBlock) ToBytes() ([]byte, error) {
+	result := []byte{ActionResponseTag, byte(enumerations.ActionResponseWithPBlock)}
+	result = append(result, a.InvokeIdAndPriority.ToBytes()...)
+	if a.LastBlock {
+		result = append(result, 0x01)
+	} else {
+		result = append(result, 0x00)
+	}
+	result = binary.BigEndian.AppendUint32(result, a.BlockNumber)
+	result = append(result, dlmsdata.EncodeVariableInteger(len(a.RawData))...)
+	return append(result, a.RawData...), nil
+}
+
+// String implements fmt.Stringer
+func (a *ActionResponseWithPBlock) String() string {
+	return fmt.Sprintf("ActionResponseWithPBlock(%s, last_block=%t, block_number=%d, raw_data=%x)",
+		a.InvokeIdAndPriority, a.LastBlock, a.BlockNumber, a.RawData)
+}
+
+// ActionResponseLastPBlock is the last block of the return data of an ACTION.
+// On the wire it is an ActionResponseWithPBlock with last_block set, it is a
+// separate type because it ends the transfer in the state machine.
+type ActionResponseLastPBlock struct {
+	*BaseXDlmsApdu
+	InvokeIdAndPriority *InvokeIdAndPriority
+	BlockNumber         uint32
+	RawData             []byte
+}
+
+// NewActionResponseLastPBlock creates a new ActionResponseLastPBlock
+func NewActionResponseLastPBlock(
+	invokeIdAndPriority *InvokeIdAndPriority,
+	blockNumber uint32,
+	rawData []byte,
+) *ActionResponseLastPBlock {
+	return &ActionResponseLastPBlock{
+		BaseXDlmsApdu: &BaseXDlmsApdu{
+			tag: ActionResponseTag,
+		},
+		InvokeIdAndPriority: invokeIdAndPriority,
+		BlockNumber:         blockNumber,
+		RawData:             rawData,
+	}
+}
+
+// ToBytes converts ActionResponseLastPBlock to bytes
+func (a *ActionResponseLastPBlock) ToBytes() ([]byte, error) {
+	return NewActionResponseWithPBlock(a.InvokeIdAndPriority, true, a.BlockNumber, a.RawData).ToBytes()
+}
+
+// String implements fmt.Stringer
+func (a *ActionResponseLastPBlock) String() string {
+	return fmt.Sprintf("ActionResponseLastPBlock(%s, block_number=%d, raw_data=%x)",
+		a.InvokeIdAndPriority, a.BlockNumber, a.RawData)
+}
@@ -0,0 +1,228 @@
+package xdlms
+
+import "fmt"
+
+// ApduKind identifies the type of an APDU, e.g. to key the transitions of the
+// state machine of the association
+type ApduKind uint8
+
+const (
+	ApduKindUnknown ApduKind = iota
+	ApduKindInitiateRequest
+	ApduKindInitiateResponse
+	ApduKindGlobalCipherInitiateRequest
+	ApduKindGlobalCipherInitiateResponse
+	ApduKindConfirmedServiceError
+	ApduKindExceptionResponse
+	ApduKindDataNotification
+	ApduKindEventNotification
+	ApduKindGeneralBlockTransfer
+	ApduKindGeneralGlobalCipher
+	ApduKindGetRequestNormal
+	ApduKindGetRequestNext
+	ApduKindGetRequestWithList
+	ApduKindGetResponseNormal
+	ApduKindGetResponseNormalWithError
+	ApduKindGetResponseWithDataBlock
+	ApduKindGetResponseWithList
+	ApduKindGetResponseLastBlock
+	ApduKindGetResponseLastBlockWithError
+	ApduKindSetRequestNormal
+	ApduKindSetResponseNormal
+	ApduKindActionRequestNormal
+	ApduKindActionRequestNextPBlock
+	ApduKindActionResponseNormal
+	ApduKindActionResponseNormalWithData
+	ApduKindActionResponseNormalWithError
+	ApduKindActionResponseWithPBlock
+	ApduKindActionResponseLastPBlock
+	// the ACSE APDUs, their types are in the acse package
+	ApduKindApplicationAssociationRequest
+	ApduKindApplicationAssociationResponse
+	ApduKindReleaseRequest
+	ApduKindReleaseResponse
+)
+
+var apduKindNames = [...]string{
+	ApduKindUnknown:                        "Unknown",
+	ApduKindInitiateRequest:                "InitiateRequest",
+	ApduKindInitiateResponse:               "InitiateResponse",
+	ApduKindGlobalCipherInitiateRequest:    "GlobalCipherInitiateRequest",
+	ApduKindGlobalCipherInitiateResponse:   "GlobalCipherInitiateResponse",
+	ApduKindConfirmedServiceError:          "ConfirmedServiceError",
+	ApduKindExceptionResponse:              "ExceptionResponse",
+	ApduKindDataNotification:               "DataNotification",
+	ApduKindEventNotification:              "EventNotification",
+	ApduKindGeneralBlockTransfer:           "GeneralBlockTransfer",
+	ApduKindGeneralGlobalCipher:            "GeneralGlobalCipher",
+	ApduKindGetRequestNormal:               "GetRequestNormal",
+	ApduKindGetRequestNext:                 "GetRequestNext",
+	ApduKindGetRequestWithList:             "GetRequestWithList",
+	ApduKindGetResponseNormal:              "GetResponseNormal",
+	ApduKindGetResponseNormalWithError:     "GetResponseNormalWithError",
+	ApduKindGetResponseWithDataBlock:       "GetResponseWithDataBlock",
+	ApduKindGetResponseWithList:            "GetResponseWithList",
+	ApduKindGetResponseLastBlock:           "GetResponseLastBlock",
+	ApduKindGetResponseLastBlockWithError:  "GetResponseLastBlockWithError",
+	ApduKindSetRequestNormal:               "SetRequestNormal",
+	ApduKindSetResponseNormal:              "SetResponseNormal",
+	ApduKindActionRequestNormal:            "ActionRequestNormal",
+	ApduKindActionRequestNextPBlock:        "ActionRequestNextPBlock",
+	ApduKindActionResponseNormal:           "ActionResponseNormal",
+	ApduKindActionResponseNormalWithData:   "ActionResponseNormalWithData",
+	ApduKindActionResponseNormalWithError:  "ActionResponseNormalWithError",
+	ApduKindActionResponseWithPBlock:       "ActionResponseWithPBlock",
+	ApduKindActionResponseLastPBlock:       "ActionResponseLastPBlock",
+	ApduKindApplicationAssociationRequest:  "ApplicationAssociationRequest",
+	ApduKindApplicationAssociationResponse: "ApplicationAssociationResponse",
+	ApduKindReleaseRequest:                 "ReleaseRequest",
+	ApduKindReleaseResponse:                "ReleaseResponse",
+}
+
+// String implements fmt.Stringer
+func (k ApduKind) String() string {
+	if int(k) < len(apduKindNames) {
+		return apduKindNames[k]
+	}
+	return fmt.Sprintf("ApduKind(%d)", int(k))
+}
+
+// Kind returns ApduKindInitiateRequest
+func (*InitiateRequest) Kind() ApduKind {
+	return ApduKindInitiateRequest
+}
+
+// Kind returns ApduKindInitiateResponse
+func (*InitiateResponse) Kind() ApduKind {
+	return ApduKindInitiateResponse
+}
+
+// Kind returns ApduKindGlobalCipherInitiateRequest
+func (*GlobalCipherInitiateRequest) Kind() ApduKind {
+	return ApduKindGlobalCipherInitiateRequest
+}
+
+// Kind returns ApduKindGlobalCipherInitiateResponse
+func (*GlobalCipherInitiateResponse) Kind() ApduKind {
+	return ApduKindGlobalCipherInitiateResponse
+}
+
+// Kind returns ApduKindConfirmedServiceError
+func (*ConfirmedServiceError) Kind() ApduKind {
+	return ApduKindConfirmedServiceError
+}
+
+// Kind returns ApduKindExceptionResponse
+func (*ExceptionResponse) Kind() ApduKind {
+	return ApduKindExceptionResponse
+}
+
+// Kind returns ApduKindDataNotification
+func (*DataNotification) Kind() ApduKind {
+	return ApduKindDataNotification
+}
+
+// Kind returns ApduKindEventNotification
+func (*EventNotification) Kind() ApduKind {
+	return ApduKindEventNotification
+}
+
+// Kind returns ApduKindGeneralBlockTransfer
+func (*GeneralBlockTransfer) Kind() ApduKind {
+	return ApduKindGeneralBlockTransfer
+}
+
+// Kind returns ApduKindGeneralGlobalCipher
+func (*GeneralGlobalCipher) Kind() ApduKind {
+	return ApduKindGeneralGlobalCipher
+}
+
+// Kind returns ApduKindGetRequestNormal
+func (*GetRequestNormal) Kind() ApduKind {
+	return ApduKindGetRequestNormal
+}
+
+// Kind returns ApduKindGetRequestNext
+func (*GetRequestNext) Kind() ApduKind {
+	return ApduKindGetRequestNext
+}
+
+// Kind returns ApduKindGetRequestWithList
+func (*GetRequestWithList) Kind() ApduKind {
+	return ApduKindGetRequestWithList
+}
+
+// Kind returns ApduKindGetResponseNormal
+func (*GetResponseNormal) Kind() ApduKind {
+	return ApduKindGetResponseNormal
+}
+
+// Kind returns ApduKindGetResponseNormalWithError
+func (*GetResponseNormalWithError) Kind() ApduKind {
+	return ApduKindGetResponseNormalWithError
+}
+
+// Kind returns ApduKindGetResponseWithDataBlock
+func (*GetResponseWithDataBlock) Kind() ApduKind {
+	return ApduKindGetResponseWithDataBlock
+}
+
+// Kind returns ApduKindGetResponseWithList
+func (*GetResponseWithList) Kind() ApduKind {
+	return ApduKindGetResponseWithList
+}
+
+// Kind returns ApduKindGetResponseLastBlock
+func (*GetResponseLastBlock) Kind() ApduKind {
+	return ApduKindGetResponseLastBlock
+}
+
+// Kind returns ApduKindGetResponseLastBlockWithError
+func (*GetResponseLastBlockWithError) Kind() ApduKind {
+	return ApduKindGetResponseLastBlockWithError
+}
+
+// Kind returns ApduKindSetRequestNormal
+func (*SetRequestNormal) Kind() ApduKind {
+	return ApduKindSetRequestNormal
+}
+
+// Kind returns ApduKindSetResponseNormal
+func (*SetResponseNormal) Kind() ApduKind {
+	return ApduKindSetResponseNormal
+}
+
+// Kind returns ApduKindActionRequestNormal
+func (*ActionRequestNormal) Kind() ApduKind {
+	return ApduKindActionRequestNormal
+}
+
+// Kind returns ApduKindActionRequestNextPBlock
+func (*ActionRequestNextPBlock) Kind() ApduKind {
+	return ApduKindActionRequestNextPBlock
+}
+
+// Kind returns ApduKindActionResponseNormal
+func (*ActionResponseNormal) Kind() ApduKind {
+	return ApduKindActionResponseNormal
+}
+
+// Kind returns ApduKindActionResponseNormalWithData
+func (*ActionResponseNormalWithData) Kind() ApduKind {
+	return ApduKindActionResponseNormalWithData
+}
+
+// Kind returns ApduKindActionResponseNormalWithError
+func (*ActionResponseNormalWithError) Kind() ApduKind {
+	return ApduKindActionResponseNormalWithError
+}
+
+// Kind returns ApduKindActionResponseWithPBlock
+func (*ActionResponseWithPBlock) Kind() ApduKind {
+	return ApduKindActionResponseWithPBlock
+}
+
+// Kind returns ApduKindActionResponseLastPBlock
+func (*ActionResponseLastPBlock) Kind() ApduKind {
+	return ApduKindActionResponseLastPBlock
+}
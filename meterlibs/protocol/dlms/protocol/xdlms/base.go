@@ -1,19 +1,56 @@
 package xdlms
 
-// XDlmsApdu is the interface for all xDLMS APDUs
-type XDlmsApdu interface {
-	FromBytes(data []byte) (XDlmsApdu, error)
+import (
+	"fmt"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+)
+
+// Apdu is the interface implemented by all xDLMS and ACSE APDUs
+type Apdu interface {
+	Tag() uint8
+	Kind() ApduKind
 	ToBytes() ([]byte, error)
-	GetTag() uint8
+	fmt.Stringer
 }
 
 // BaseXDlmsApdu is the base struct for xDLMS APDUs
 type BaseXDlmsApdu struct {
-	Tag uint8
+	tag uint8
+}
+
+// Tag returns the tag
+func (b *BaseXDlmsApdu) Tag() uint8 {
+	if b == nil {
+		return 0
+	}
+	return b.tag
 }
 
-// GetTag returns the tag
-func (b *BaseXDlmsApdu) GetTag() uint8 {
-	return b.Tag
+// Redacted is printed in place of secrets such as keys and passwords
+const Redacted = "<redacted>"
+
+// attributeString formats a COSEM attribute descriptor as interface/obis/attribute
+func attributeString(attribute *cosem.CosemAttribute) string {
+	if attribute == nil || attribute.Instance == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d/%s/%d", attribute.Interface, attribute.Instance.ToString("."), attribute.Attribute)
+}
+
+// methodString formats a COSEM method descriptor as interface/obis/method
+func methodString(method *cosem.CosemMethod) string {
+	if method == nil || method.Instance == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d/%s/%d", method.Interface, method.Instance.ToString("."), method.Method)
 }
 
+// timeString formats an optional time
+func timeString(t *time.Time) string {
+	if t == nil {
+		return "none"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,98 @@
+package xdlms
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+)
+
+// ConfirmedServiceError represents a Confirmed Service Error APDU, sent by the
+// server when it can't process a confirmed service request at all
+const ConfirmedServiceErrorTag = 14
+
+type ConfirmedServiceError struct {
+	*BaseXDlmsApdu
+	Service   enumerations.ConfirmedServiceErrorChoice
+	ErrorType enumerations.ServiceErrorType
+	// Value is the error in the enumeration given by ErrorType
+	Value uint8
+}
+
+// NewConfirmedServiceError creates a new ConfirmedServiceError
+func NewConfirmedServiceError(
+	service enumerations.ConfirmedServiceErrorChoice,
+	errorType enumerations.ServiceErrorType,
+	value uint8,
+) *ConfirmedServiceError {
+	return &ConfirmedServiceError{
+		BaseXDlmsApdu: &BaseXDlmsApdu{
+			tag: ConfirmedServiceErrorTag,
+		},
+		Service:   service,
+		ErrorType: errorType,
+		Value:     value,
+	}
+}
+
+var confirmedServiceErrorSchema = &apduSchema[ConfirmedServiceError]{
+	name: "ConfirmedServiceError",
+	fields: []schemaField[ConfirmedServiceError]{
+		constField[ConfirmedServiceError]("tag", ConfirmedServiceErrorTag),
+		uint8Field("service", func(c *ConfirmedServiceError) *enumerations.ConfirmedServiceErrorChoice { return &c.Service }),
+		uint8Field("error_type", func(c *ConfirmedServiceError) *enumerations.ServiceErrorType { return &c.ErrorType }),
+		uint8Field("value", func(c *ConfirmedServiceError) *uint8 { return &c.Value }),
+	},
+}
+
+// FromBytes creates ConfirmedServiceError from bytes
+func (c *ConfirmedServiceError) FromBytes(sourceBytes []byte) (*ConfirmedServiceError, error) {
+	serviceError := NewConfirmedServiceError(0, 0, 0)
+	if err := confirmedServiceErrorSchema.decode(sourceBytes, serviceError); err != nil {
+		return nil, err
+	}
+	return serviceError, nil
+}
+
+// ToBytes converts ConfirmedServiceError to bytes
+func (c *ConfirmedServiceError) ToBytes() ([]byte, error) {
+	return confirmedServiceErrorSchema.encode(c), nil
+}
+
+// String implements fmt.Stringer
+func (c *ConfirmedServiceError) String() string {
+	return fmt.Sprintf("ConfirmedServiceError(service=%d, error_type=%d, value=%d)", c.Service, c.ErrorType, c.Value)
+}
+
+// confirmedServiceErrorCategories maps the error types to the errors of the
+// exceptions package callers catch. The other types are a
+// DlmsClientException.
+var confirmedServiceErrorCategories = map[enumerations.ServiceErrorType]func(message string) error{
+	enumerations.ServiceErrorTypeInitiate: func(message string) error {
+		return exceptions.NewApplicationAssociationError(message)
+	},
+	enumerations.ServiceErrorTypeApplicationReference: func(message string) error {
+		return exceptions.NewCommunicationError(message)
+	},
+	enumerations.ServiceErrorTypeAccess: func(message string) error {
+		return exceptions.NewAccessRightsError(message)
+	},
+	enumerations.ServiceErrorTypeService: func(message string) error {
+		return exceptions.NewConformanceError(message)
+	},
+}
+
+// Err returns the error of the exceptions package for the category of the
+// error, e.g. an ApplicationAssociationError for an initiate error. A
+// deciphering error of the application reference is a DecryptionError.
+func (c *ConfirmedServiceError) Err() error {
+	message := c.String()
+	if c.ErrorType == enumerations.ServiceErrorTypeApplicationReference &&
+		enumerations.ApplicationReferenceError(c.Value) == enumerations.ApplicationReferenceErrorDecipheringError {
+		return exceptions.NewDecryptionError(message)
+	}
+	if category, ok := confirmedServiceErrorCategories[c.ErrorType]; ok {
+		return category(message)
+	}
+	return exceptions.NewDlmsClientException(message)
+}
@@ -2,7 +2,12 @@ package xdlms
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
 )
 
 // Conformance holds information about the supported services in a DLMS association.
@@ -27,6 +32,11 @@ type Conformance struct {
 	SelectiveAccess             bool
 	EventNotification           bool
 	Action                      bool
+
+	// length and unusedBits keep the form of a bit string that was not the
+	// usual 3 bytes with no unused bits, so that it is echoed back the same
+	length     int
+	unusedBits byte
 }
 
 // ConformanceBitPosition maps attribute names to bit positions
@@ -81,15 +91,26 @@ func NewConformance(
 	}
 }
 
-// FromBytes creates Conformance from bytes
+// FromBytes creates Conformance from the content of the BER bit string: the
+// unused bits byte followed by the bits. The standard uses 3 bytes but some
+// meters send more or fewer, the bits after the 24th are ignored.
 func (c *Conformance) FromBytes(data []byte) (*Conformance, error) {
-	if len(data) < 4 {
-		return nil, fmt.Errorf("insufficient data for Conformance: need at least 4 bytes, got %d", len(data))
+	if len(data) < 1 {
+		return nil, fmt.Errorf("insufficient data for Conformance: need the unused bits byte")
 	}
-	
-	// Skip first byte (unused bits indicator) and read 3 bytes
-	integerRepresentation := binary.BigEndian.Uint32(append([]byte{0}, data[1:4]...))
-	
+	unusedBits := data[0]
+	bits := data[1:]
+	if unusedBits > 7 || (unusedBits > 0 && len(bits) == 0) {
+		return nil, fmt.Errorf("invalid number of unused bits in Conformance: %d", unusedBits)
+	}
+
+	padded := make([]byte, max(len(bits), 3))
+	copy(padded, bits)
+	if len(bits) > 0 {
+		padded[len(bits)-1] &^= 1<<unusedBits - 1
+	}
+	integerRepresentation := binary.BigEndian.Uint32(append([]byte{0}, padded[:3]...))
+
 	conf := &Conformance{}
 	
 	conf.GeneralProtection = (integerRepresentation & (1 << ConformanceBitPosition["general_protection"])) != 0
@@ -109,12 +130,37 @@ func (c *Conformance) FromBytes(data []byte) (*Conformance, error) {
 	conf.SelectiveAccess = (integerRepresentation & (1 << ConformanceBitPosition["selective_access"])) != 0
 	conf.EventNotification = (integerRepresentation & (1 << ConformanceBitPosition["event_notification"])) != 0
 	conf.Action = (integerRepresentation & (1 << ConformanceBitPosition["action"])) != 0
-	
+	if len(bits) != 3 || unusedBits != 0 {
+		conf.length = len(bits)
+		conf.unusedBits = unusedBits
+	}
+
 	return conf, nil
 }
 
-// ToBytes converts Conformance to bytes
+// ToBytes converts Conformance to the content of the BER bit string, in the
+// form it was parsed from
 func (c *Conformance) ToBytes() []byte {
+	length := 3
+	if c.length != 0 || c.unusedBits != 0 {
+		length = c.length
+	}
+	// It is a bit string so the first byte tells how many bits of the last
+	// byte are unused
+	out := append([]byte{c.unusedBits}, binary.BigEndian.AppendUint32(nil, c.bits())[1:]...)
+	if length < 3 {
+		out = out[:1+length]
+	} else {
+		out = append(out, make([]byte, length-3)...)
+	}
+	if length > 0 {
+		out[length] &^= 1<<c.unusedBits - 1
+	}
+	return out
+}
+
+// bits returns the services set, at their ConformanceBitPosition
+func (c *Conformance) bits() uint32 {
 	var out uint32
 	
 	if c.GeneralProtection {
@@ -168,13 +214,152 @@ func (c *Conformance) ToBytes() []byte {
 	if c.Action {
 		out |= 1 << ConformanceBitPosition["action"]
 	}
-	
-	// It is a bit string so need to encode how many bits that are unused in the
-	// last byte. It's none so we can just put 0x00 in front.
-	result := make([]byte, 4)
-	result[0] = 0x00 // unused bits indicator
-	binary.BigEndian.PutUint32(result[1:], out)
-	// Only use 3 bytes for the bit string
-	return result[:4]
+	return out
+}
+
+
+// Has tells if the service with the given ConformanceBitPosition name is
+// set, false for unknown names
+func (c *Conformance) Has(service string) bool {
+	position, ok := ConformanceBitPosition[service]
+	if !ok {
+		return false
+	}
+	return c.bits()&(1<<position) != 0
+}
+
+// ConformanceError is returned when a request needs a service that the
+// association did not negotiate, so that callers can fall back to the
+// services they have. It wraps an exceptions.ConformanceError.
+type ConformanceError struct {
+	// Service is the ConformanceBitPosition name of the missing service
+	Service string
+	// Negotiated is the conformance of the association, nil when there is
+	// no association
+	Negotiated *Conformance
+	err        *exceptions.ConformanceError
+}
+
+// NewConformanceError creates a ConformanceError for a missing service
+func NewConformanceError(service string, negotiated *Conformance) *ConformanceError {
+	message := fmt.Sprintf("%s is not negotiated", service)
+	if negotiated == nil {
+		message = fmt.Sprintf("%s is not negotiated, there is no association", service)
+	}
+	return &ConformanceError{
+		Service:    service,
+		Negotiated: negotiated,
+		err:        exceptions.NewConformanceError(message),
+	}
+}
+
+// Bit returns the position of the missing service in the conformance bit string
+func (e *ConformanceError) Bit() int {
+	return ConformanceBitPosition[e.Service]
+}
+
+func (e *ConformanceError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the exceptions.ConformanceError
+func (e *ConformanceError) Unwrap() error {
+	return e.err
+}
+
+// conformanceAliases are the names accepted for the services whose standard
+// name is long
+var conformanceAliases = map[string]string{
+	"block_transfer_with_get": "block_transfer_with_get_or_read",
+	"block_transfer_with_set": "block_transfer_with_set_or_write",
+	"attribute_0_with_get":    "attribute_0_supported_with_get",
+	"attribute_0_with_set":    "attribute_0_supported_with_set",
+	"priority_management":     "priority_management_supported",
+}
+
+// Names returns the names of the services set, from the highest bit, with
+// dashes as in the standard: "get", "selective-access"
+func (c *Conformance) Names() []string {
+	names := make([]string, 0, len(ConformanceBitPosition))
+	for name := range ConformanceBitPosition {
+		if c.Has(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return ConformanceBitPosition[names[i]] > ConformanceBitPosition[names[j]]
+	})
+	for i, name := range names {
+		names[i] = strings.ReplaceAll(name, "_", "-")
+	}
+	return names
+}
+
+// ParseConformance parses a list of service names separated by commas, e.g.
+// "get,set,selective-access,block-transfer-with-get". Dashes and underscores
+// are the same and the case is ignored.
+func ParseConformance(text string) (*Conformance, error) {
+	var names []string
+	for _, name := range strings.Split(text, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return conformanceFromNames(names)
 }
 
+func conformanceFromNames(names []string) (*Conformance, error) {
+	var bits uint32
+	for _, name := range names {
+		key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+		if alias, ok := conformanceAliases[key]; ok {
+			key = alias
+		}
+		position, ok := ConformanceBitPosition[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown conformance service %q", name)
+		}
+		bits |= 1 << position
+	}
+	return (&Conformance{}).FromBytes(binary.BigEndian.AppendUint32(nil, bits))
+}
+
+// MarshalText implements encoding.TextMarshaler, the names of the services
+// separated by commas. YAML encoders use it too.
+func (c *Conformance) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(c.Names(), ",")), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler with ParseConformance
+func (c *Conformance) UnmarshalText(text []byte) error {
+	parsed, err := ParseConformance(string(text))
+	if err != nil {
+		return err
+	}
+	*c = *parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, the names of the services in an array
+func (c *Conformance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Names())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the names of the services are
+// given in an array or in a string separated by commas
+func (c *Conformance) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return c.UnmarshalText([]byte(text))
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("conformance must be an array of service names or a string: %w", err)
+	}
+	parsed, err := conformanceFromNames(names)
+	if err != nil {
+		return err
+	}
+	*c = *parsed
+	return nil
+}
@@ -0,0 +1,104 @@
+package xdlms_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+func TestConformance_Has(t *testing.T) {
+	conformance := &xdlms.Conformance{Get: true, MultipleReferences: true}
+	assert.True(t, conformance.Has("get"))
+	assert.True(t, conformance.Has("multiple_references"))
+	assert.False(t, conformance.Has("set"))
+	assert.False(t, conformance.Has("unknown"))
+}
+
+func TestConformanceError(t *testing.T) {
+	negotiated := &xdlms.Conformance{Get: true}
+	err := xdlms.NewConformanceError("selective_access", negotiated)
+	assert.Equal(t, 2, err.Bit())
+	assert.Same(t, negotiated, err.Negotiated)
+	assert.EqualError(t, err, "Conformance error: selective_access is not negotiated")
+
+	var conformanceError *exceptions.ConformanceError
+	assert.True(t, errors.As(err, &conformanceError))
+}
+
+func TestParseConformance(t *testing.T) {
+	conformance, err := xdlms.ParseConformance("get, set,selective-access,block-transfer-with-get")
+	require.NoError(t, err)
+	assert.Equal(t, &xdlms.Conformance{Get: true, Set: true, SelectiveAccess: true, BlockTransferWithGetOrRead: true}, conformance)
+
+	text, err := conformance.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "block-transfer-with-get-or-read,get,set,selective-access", string(text))
+
+	_, err = xdlms.ParseConformance("get,teleport")
+	assert.Error(t, err)
+}
+
+func TestConformance_JSON(t *testing.T) {
+	var config struct {
+		Conformance *xdlms.Conformance `json:"conformance"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"conformance": ["GET", "action", "multiple_references"]}`), &config))
+	assert.Equal(t, &xdlms.Conformance{Get: true, Action: true, MultipleReferences: true}, config.Conformance)
+
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"conformance": ["multiple-references", "get", "action"]}`, string(data))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"conformance": "set,get"}`), &config))
+	assert.Equal(t, &xdlms.Conformance{Get: true, Set: true}, config.Conformance)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"conformance": 12}`), &config))
+}
+
+func TestConformance_ToBytes(t *testing.T) {
+	conformance := &xdlms.Conformance{BlockTransferWithGetOrRead: true, Get: true, Set: true, SelectiveAccess: true, Action: true}
+	// the unused bits byte, then the 24 bits
+	encoded := conformance.ToBytes()
+	assert.Equal(t, []byte{0x00, 0x00, 0x10, 0x1D}, encoded)
+
+	decoded, err := (&xdlms.Conformance{}).FromBytes(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, conformance, decoded)
+}
+
+func TestConformance_FromBytesOtherForms(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		data        []byte
+		conformance *xdlms.Conformance
+	}{
+		{"fourth byte", []byte{0x00, 0x00, 0x10, 0x1D, 0xFF}, &xdlms.Conformance{BlockTransferWithGetOrRead: true, Get: true, Set: true, SelectiveAccess: true, Action: true}},
+		{"unused bits", []byte{0x03, 0x00, 0x10, 0x1F}, &xdlms.Conformance{BlockTransferWithGetOrRead: true, Get: true, Set: true}},
+		{"two bytes", []byte{0x00, 0x00, 0x10}, &xdlms.Conformance{BlockTransferWithGetOrRead: true}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			conformance, err := (&xdlms.Conformance{}).FromBytes(test.data)
+			require.NoError(t, err)
+			assert.Equal(t, test.conformance.Names(), conformance.Names())
+
+			// bits after the 24th and the unused ones are echoed as zeros
+			echoed := conformance.ToBytes()
+			assert.Len(t, echoed, len(test.data))
+			assert.Equal(t, test.data[0], echoed[0])
+			parsed, err := (&xdlms.Conformance{}).FromBytes(echoed)
+			require.NoError(t, err)
+			assert.Equal(t, conformance, parsed)
+		})
+	}
+
+	_, err := (&xdlms.Conformance{}).FromBytes(nil)
+	assert.Error(t, err)
+	_, err = (&xdlms.Conformance{}).FromBytes([]byte{0x08, 0x00, 0x10, 0x1D})
+	assert.Error(t, err)
+	assert.Equal(t, []byte{0x00, 0x00, 0x00, 0x10}, (&xdlms.Conformance{Get: true}).ToBytes())
+}
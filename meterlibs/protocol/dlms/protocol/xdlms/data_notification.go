@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
 )
 
 // LongInvokeIdAndPriority represents a long invoke ID and priority
@@ -88,7 +89,7 @@ func NewDataNotification(
 ) *DataNotification {
 	return &DataNotification{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: DataNotificationTag,
+			tag: DataNotificationTag,
 		},
 		LongInvokeIDAndPriority: longInvokeIDAndPriority,
 		DateTime:                dateTime,
@@ -96,55 +97,63 @@ func NewDataNotification(
 	}
 }
 
+// dataNotificationConf is the A-XDR encoding of a DataNotification after its
+// tag. The body is any DLMS data, kept encoded.
+var dataNotificationConf = &encoding.EncodingConf{
+	Attributes: []interface{}{
+		&encoding.Attribute{
+			AttributeName: "long_invoke_id_and_priority",
+			Length:        4,
+			CreateInstance: func(data []byte) (interface{}, error) {
+				return (&LongInvokeIdAndPriority{}).FromBytes(data)
+			},
+		},
+		&encoding.Attribute{
+			AttributeName: "date_time",
+			Length:        12,
+			Optional:      true,
+			CreateInstance: func(data []byte) (interface{}, error) {
+				dateTime, _, err := dlmsdata.DateTimeFromBytes(data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse datetime: %w", err)
+				}
+				return &dateTime, nil
+			},
+		},
+		&encoding.DlmsDataChoice{AttributeName: "notification_body", Raw: true},
+	},
+}
+
 // FromBytes creates DataNotification from bytes
 func (d *DataNotification) FromBytes(sourceBytes []byte) (*DataNotification, error) {
 	if len(sourceBytes) < 5 {
-		return nil, fmt.Errorf("insufficient data for DataNotification, need at least 5 bytes")
+		return nil, truncated("DataNotification", "header", 0)
 	}
 
-	data := make([]byte, len(sourceBytes))
-	copy(data, sourceBytes)
-
-	tag := data[0]
+	tag := sourceBytes[0]
 	if tag != DataNotificationTag {
 		return nil, fmt.Errorf("data is not a DataNotification APDU, expected tag=%d but got %d", DataNotificationTag, tag)
 	}
 
-	data = data[1:]
-
-	longInvokeIDData := data[:4]
-	longInvokeID, err := (&LongInvokeIdAndPriority{}).FromBytes(longInvokeIDData)
+	decoder := encoding.NewAXdrDecoder(dataNotificationConf)
+	fields, err := decoder.Decode(sourceBytes[1:])
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse LongInvokeIdAndPriority: %w", err)
+		return nil, fmt.Errorf("failed to parse DataNotification: %w", err)
 	}
-	data = data[4:]
-
-	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for has_datetime flag")
+	if !decoder.BufferEmpty() {
+		return nil, fmt.Errorf("%d bytes left after the DataNotification body", len(decoder.GetBufferTail()))
 	}
 
-	hasDateTime := data[0] != 0
-	data = data[1:]
-
-	var dateTime *time.Time
-	if hasDateTime {
-		if len(data) < 12 {
-			return nil, fmt.Errorf("insufficient data for datetime, need 12 bytes")
-		}
-		dnDateTimeData := data[:12]
-		parsedDateTime, _, err := dlmsdata.DateTimeFromBytes(dnDateTimeData)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse datetime: %w", err)
-		}
-		dateTime = &parsedDateTime
-		data = data[12:]
-	}
-
-	return NewDataNotification(longInvokeID, dateTime, data), nil
+	dateTime, _ := fields["date_time"].(*time.Time)
+	return NewDataNotification(
+		fields["long_invoke_id_and_priority"].(*LongInvokeIdAndPriority),
+		dateTime,
+		fields["notification_body"].([]byte),
+	), nil
 }
 
 // ToBytes converts DataNotification to bytes
-func (d *DataNotification) ToBytes() ([]byte, error) { {
+func (d *DataNotification) ToBytes() ([]byte, error) {
 	result := []byte{DataNotificationTag}
 	result = append(result, d.LongInvokeIDAndPriority.ToBytes()...)
 
@@ -162,3 +171,8 @@ func (d *DataNotification) ToBytes() ([]byte, error) { {
 	return result, nil
 }
 
+// String implements fmt.Stringer
+func (d *DataNotification) String() string {
+	return fmt.Sprintf("DataNotification(long_invoke_id=%d, date_time=%s, body=%x)",
+		d.LongInvokeIDAndPriority.LongInvokeID, timeString(d.DateTime), d.Body)
+}
@@ -0,0 +1,50 @@
+package xdlms_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+func TestDataNotification_FromBytes(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		encoded     string
+		hasDateTime bool
+		body        string
+	}{
+		{"without date time", "0F40000001001200FF", false, "1200FF"},
+		{"with date time", "0F4000000101" + "07EA0A12FF0C000000800000" + "020212000109020102", true, "020212000109020102"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := hex.DecodeString(test.encoded)
+			require.NoError(t, err)
+
+			notification, err := (&xdlms.DataNotification{}).FromBytes(data)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1), notification.LongInvokeIDAndPriority.LongInvokeID)
+			body, _ := hex.DecodeString(test.body)
+			assert.Equal(t, body, notification.Body)
+			assert.Equal(t, test.hasDateTime, notification.DateTime != nil)
+		})
+	}
+}
+
+func TestDataNotification_InvalidBody(t *testing.T) {
+	for _, encoded := range []string{
+		// unknown data tag
+		"0F4000000100FE01",
+		// truncated structure
+		"0F40000001000202120001",
+		// bytes after the body
+		"0F40000001001200FF00",
+	} {
+		data, err := hex.DecodeString(encoded)
+		require.NoError(t, err)
+		_, err = (&xdlms.DataNotification{}).FromBytes(data)
+		assert.Error(t, err, encoded)
+	}
+}
@@ -8,7 +8,7 @@ import (
 )
 
 // EventNotification represents an Event Notification APDU
-const EventNotificationTag = 15
+const EventNotificationTag = 194
 
 type EventNotification struct {
 	*BaseXDlmsApdu
@@ -25,7 +25,7 @@ func NewEventNotification(
 ) *EventNotification {
 	return &EventNotification{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: EventNotificationTag,
+			tag: EventNotificationTag,
 		},
 		LongInvokeIDAndPriority: longInvokeIDAndPriority,
 		DateTime:                dateTime,
@@ -36,7 +36,7 @@ func NewEventNotification(
 // FromBytes creates EventNotification from bytes
 func (e *EventNotification) FromBytes(sourceBytes []byte) (*EventNotification, error) {
 	if len(sourceBytes) < 5 {
-		return nil, fmt.Errorf("insufficient data for EventNotification, need at least 5 bytes")
+		return nil, truncated("EventNotification", "header", 0)
 	}
 
 	data := make([]byte, len(sourceBytes))
@@ -57,7 +57,7 @@ func (e *EventNotification) FromBytes(sourceBytes []byte) (*EventNotification, e
 	data = data[4:]
 
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for has_datetime flag")
+		return nil, truncated("EventNotification", "has_datetime", len(sourceBytes)-len(data))
 	}
 
 	hasDateTime := data[0] != 0
@@ -66,7 +66,7 @@ func (e *EventNotification) FromBytes(sourceBytes []byte) (*EventNotification, e
 	var dateTime *time.Time
 	if hasDateTime {
 		if len(data) < 12 {
-			return nil, fmt.Errorf("insufficient data for datetime, need 12 bytes")
+			return nil, truncated("EventNotification", "datetime", len(sourceBytes)-len(data))
 		}
 		enDateTimeData := data[:12]
 		parsedDateTime, _, err := dlmsdata.DateTimeFromBytes(enDateTimeData)
@@ -98,3 +98,9 @@ func (e *EventNotification) ToBytes() ([]byte, error) {
 	result = append(result, e.Body...)
 	return result, nil
 }
+
+// String implements fmt.Stringer
+func (e *EventNotification) String() string {
+	return fmt.Sprintf("EventNotification(long_invoke_id=%d, date_time=%s, body=%x)",
+		e.LongInvokeIDAndPriority.LongInvokeID, timeString(e.DateTime), e.Body)
+}
@@ -25,7 +25,7 @@ func NewExceptionResponse(
 ) *ExceptionResponse {
 	return &ExceptionResponse{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: ExceptionResponseTag,
+			tag: ExceptionResponseTag,
 		},
 		StateError:            stateError,
 		ServiceError:          serviceError,
@@ -36,7 +36,7 @@ func NewExceptionResponse(
 // FromBytes creates ExceptionResponse from bytes
 func (e *ExceptionResponse) FromBytes(sourceBytes []byte) (*ExceptionResponse, error) {
 	if len(sourceBytes) < 3 {
-		return nil, fmt.Errorf("insufficient data for ExceptionResponse, need at least 3 bytes")
+		return nil, truncated("ExceptionResponse", "header", 0)
 	}
 
 	data := make([]byte, len(sourceBytes))
@@ -54,7 +54,7 @@ func (e *ExceptionResponse) FromBytes(sourceBytes []byte) (*ExceptionResponse, e
 	var invocationCounterData *uint32
 	if serviceError == enumerations.ServiceExceptionInvocationCounterError {
 		if len(data) < 4 {
-			return nil, fmt.Errorf("insufficient data for invocation counter, need 4 bytes")
+			return nil, truncated("ExceptionResponse", "invocation_counter", len(sourceBytes)-len(data))
 		}
 		counter := binary.BigEndian.Uint32(data[:4])
 		invocationCounterData = &counter
@@ -76,3 +76,11 @@ func (e *ExceptionResponse) ToBytes() ([]byte, error) {
 	return result, nil
 }
 
+// String implements fmt.Stringer
+func (e *ExceptionResponse) String() string {
+	if e.InvocationCounterData != nil {
+		return fmt.Sprintf("ExceptionResponse(state_error=%d, service_error=%d, invocation_counter=%d)",
+			e.StateError, e.ServiceError, *e.InvocationCounterData)
+	}
+	return fmt.Sprintf("ExceptionResponse(state_error=%d, service_error=%d)", e.StateError, e.ServiceError)
+}
@@ -1,61 +1,131 @@
 package xdlms
 
 import (
+	"encoding/binary"
 	"fmt"
+	"sync"
 
-	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
 )
 
 // XDlmsApduFactory is a factory to return the correct APDU depending on the tag
-type XDlmsApduFactory struct{}
+type XDlmsApduFactory struct {
+	// Mode tells whether the known deviations of the meters are tolerated
+	Mode ParsingMode
 
-// APDUFromBytes parses an APDU from bytes based on its tag
-func (f *XDlmsApduFactory) APDUFromBytes(apduBytes []byte) (interface{}, error) {
+	mutex      sync.Mutex
+	deviations []*Deviation
+
+	parsersMutex sync.RWMutex
+	parsers      map[uint8]ApduParser
+}
+
+// ApduParser parses an APDU from bytes
+type ApduParser func(apduBytes []byte) (Apdu, error)
+
+// Register registers the parser for APDUs with the given tag in this factory,
+// for the ACSE APDUs and manufacturer specific APDUs. The tags of the xDLMS
+// APDUs and the tags already registered are rejected.
+func (f *XDlmsApduFactory) Register(tag uint8, parser ApduParser) error {
+	if parser == nil {
+		return fmt.Errorf("no parser for APDU tag 0x%02x", tag)
+	}
+	if isStandardTag(tag) {
+		return fmt.Errorf("APDU tag 0x%02x is a standard xDLMS APDU", tag)
+	}
+
+	f.parsersMutex.Lock()
+	defer f.parsersMutex.Unlock()
+
+	if _, ok := f.parsers[tag]; ok {
+		return fmt.Errorf("APDU tag 0x%02x is already registered", tag)
+	}
+	if f.parsers == nil {
+		f.parsers = make(map[uint8]ApduParser)
+	}
+	f.parsers[tag] = parser
+	return nil
+}
+
+// isStandardTag tells whether the tag is one of the xDLMS APDUs parsed by the
+// factory
+func isStandardTag(tag uint8) bool {
+	switch tag {
+	case 1, 8, ConfirmedServiceErrorTag, 15, 33, 40, EventNotificationTag, GeneralBlockTransferTag,
+		216, 219, 192, 196, 193, 197, 195, 199:
+		return true
+	}
+	return false
+}
+
+// asApdu converts the result of a FromBytes to an Apdu without keeping typed nil pointers
+func asApdu[T Apdu](apdu T, err error) (Apdu, error) {
+	if err != nil {
+		return nil, err
+	}
+	return apdu, nil
+}
+
+// APDUFromBytes parses an APDU from bytes based on its tag. In lenient mode
+// an APDU that fails to parse is repaired if it has a known deviation, which
+// is recorded.
+func (f *XDlmsApduFactory) APDUFromBytes(apduBytes []byte) (Apdu, error) {
+	apdu, err := f.parse(apduBytes)
+	if err == nil || f.Mode != ParsingLenient {
+		return apdu, err
+	}
+
+	for _, repair := range repairs {
+		repaired, description, ok := repair(apduBytes)
+		if !ok {
+			continue
+		}
+		if repairedApdu, repairErr := f.parse(repaired); repairErr == nil {
+			f.record(&Deviation{Tag: apduBytes[0], Description: description})
+			return repairedApdu, nil
+		}
+	}
+	return nil, err
+}
+
+func (f *XDlmsApduFactory) parse(apduBytes []byte) (Apdu, error) {
 	if len(apduBytes) == 0 {
-		return nil, fmt.Errorf("insufficient data for APDU tag")
+		return nil, truncated("APDU", "tag", 0)
 	}
 
 	tag := apduBytes[0]
-
 	switch tag {
 	// xDLMS APDUs
 	case 1:
 		initReq := &InitiateRequest{}
-		return initReq.FromBytes(apduBytes)
+		return asApdu(initReq.FromBytes(apduBytes))
 	case 8:
 		initResp := &InitiateResponse{}
-		return initResp.FromBytes(apduBytes)
-	case 14:
-		// ConfirmedServiceError - TODO: implement when needed
-		return nil, fmt.Errorf("ConfirmedServiceError not yet implemented")
+		return asApdu(initResp.FromBytes(apduBytes))
+	case ConfirmedServiceErrorTag:
+		serviceError := &ConfirmedServiceError{}
+		return asApdu(serviceError.FromBytes(apduBytes))
 	case 15:
 		dataNotif := &DataNotification{}
-		return dataNotif.FromBytes(apduBytes)
+		return asApdu(dataNotif.FromBytes(apduBytes))
 	case 33:
-		// GlobalCipherInitiateRequest - TODO: implement when needed
-		return nil, fmt.Errorf("GlobalCipherInitiateRequest not yet implemented")
+		initReq := &GlobalCipherInitiateRequest{}
+		return asApdu(initReq.FromBytes(apduBytes))
 	case 40:
-		// GlobalCipherInitiateResponse - TODO: implement when needed
-		return nil, fmt.Errorf("GlobalCipherInitiateResponse not yet implemented")
+		initResp := &GlobalCipherInitiateResponse{}
+		return asApdu(initResp.FromBytes(apduBytes))
+	case EventNotificationTag:
+		eventNotif := &EventNotification{}
+		return asApdu(eventNotif.FromBytes(apduBytes))
+	case GeneralBlockTransferTag:
+		block := &GeneralBlockTransfer{}
+		return asApdu(block.FromBytes(apduBytes))
 	case 216:
 		excResp := &ExceptionResponse{}
-		return excResp.FromBytes(apduBytes)
+		return asApdu(excResp.FromBytes(apduBytes))
 	case 219:
 		// GeneralGlobalCipher - TODO: implement when needed
 		return nil, fmt.Errorf("GeneralGlobalCipher not yet implemented")
-	// ACSE APDUs
-	case 96:
-		aarq := &acse.ApplicationAssociationRequest{}
-		return aarq.FromBytes(apduBytes)
-	case 97:
-		aare := &acse.ApplicationAssociationResponse{}
-		return aare.FromBytes(apduBytes)
-	case 98:
-		rlrq := &acse.ReleaseRequest{}
-		return rlrq.FromBytes(apduBytes)
-	case 99:
-		rlre := &acse.ReleaseResponse{}
-		return rlre.FromBytes(apduBytes)
 	// GET requests/responses (use factories)
 	case 192:
 		return GetRequestFromBytes(apduBytes)
@@ -72,14 +142,20 @@ func (f *XDlmsApduFactory) APDUFromBytes(apduBytes []byte) (interface{}, error)
 	case 199:
 		return ActionResponseFromBytes(apduBytes)
 	default:
+		f.parsersMutex.RLock()
+		parser, ok := f.parsers[tag]
+		f.parsersMutex.RUnlock()
+		if ok {
+			return parser(apduBytes)
+		}
 		return nil, fmt.Errorf("tag 0x%02x is not available in DLMS APDU Factory", tag)
 	}
 }
 
 // GetRequestFromBytes parses a GetRequest from bytes
-func GetRequestFromBytes(sourceBytes []byte) (interface{}, error) {
+func GetRequestFromBytes(sourceBytes []byte) (Apdu, error) {
 	if len(sourceBytes) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetRequest")
+		return nil, truncated("GetRequest", "header", 0)
 	}
 
 	tag := sourceBytes[0]
@@ -91,22 +167,22 @@ func GetRequestFromBytes(sourceBytes []byte) (interface{}, error) {
 	switch requestType {
 	case 1: // GetRequestNormal
 		req := &GetRequestNormal{}
-		return req.FromBytes(sourceBytes)
+		return asApdu(req.FromBytes(sourceBytes))
 	case 2: // GetRequestNext
 		req := &GetRequestNext{}
-		return req.FromBytes(sourceBytes)
+		return asApdu(req.FromBytes(sourceBytes))
 	case 3: // GetRequestWithList
 		req := &GetRequestWithList{}
-		return req.FromBytes(sourceBytes)
+		return asApdu(req.FromBytes(sourceBytes))
 	default:
 		return nil, fmt.Errorf("received an enum request type that is not valid for GetRequest: %d", requestType)
 	}
 }
 
 // GetResponseFromBytes parses a GetResponse from bytes
-func GetResponseFromBytes(sourceBytes []byte) (interface{}, error) {
+func GetResponseFromBytes(sourceBytes []byte) (Apdu, error) {
 	if len(sourceBytes) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetResponse")
+		return nil, truncated("GetResponse", "header", 0)
 	}
 
 	tag := sourceBytes[0]
@@ -124,33 +200,50 @@ func GetResponseFromBytes(sourceBytes []byte) (interface{}, error) {
 			if choice == 1 {
 				// GetResponseNormalWithError
 				respWithError := &GetResponseNormalWithError{}
-				return respWithError.FromBytes(sourceBytes)
+				return asApdu(respWithError.FromBytes(sourceBytes))
 			}
 		}
 		// GetResponseNormal
 		resp := &GetResponseNormal{}
-		return resp.FromBytes(sourceBytes)
+		return asApdu(resp.FromBytes(sourceBytes))
 	case 2: // GetResponseWithDataBlock
-		resp := &GetResponseWithDataBlock{}
-		return resp.FromBytes(sourceBytes)
+		// Format: [tag, type, invoke_id_and_priority, last_block, block_number(4 bytes), choice, ...]
+		// A data-access-result instead of raw data ends the transfer with an error
+		if len(sourceBytes) == 10 && sourceBytes[8] == 1 {
+			invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(sourceBytes[2:3])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse invoke_id_and_priority: %w", err)
+			}
+			return NewGetResponseLastBlockWithError(invokeIdAndPriority, binary.BigEndian.Uint32(sourceBytes[4:8]),
+				enumerations.DataAccessResult(sourceBytes[9])), nil
+		}
+		resp, err := (&GetResponseWithDataBlock{}).FromBytes(sourceBytes)
+		if err != nil {
+			return nil, err
+		}
+		// The last block ends the transfer, so it is a different event for the state machine
+		if resp.LastBlock {
+			return NewGetResponseLastBlock(resp.InvokeIdAndPriority, resp.BlockNumber, resp.RawData), nil
+		}
+		return resp, nil
 	case 3: // GetResponseWithList
 		resp := &GetResponseWithList{}
-		return resp.FromBytes(sourceBytes)
+		return asApdu(resp.FromBytes(sourceBytes))
 	case 4: // GetResponseLastBlock
 		resp := &GetResponseLastBlock{}
-		return resp.FromBytes(sourceBytes)
+		return asApdu(resp.FromBytes(sourceBytes))
 	case 5: // GetResponseLastBlockWithError
 		resp := &GetResponseLastBlockWithError{}
-		return resp.FromBytes(sourceBytes)
+		return asApdu(resp.FromBytes(sourceBytes))
 	default:
 		return nil, fmt.Errorf("received an enum response type that is not valid for GetResponse: %d", responseType)
 	}
 }
 
 // SetRequestFromBytes parses a SetRequest from bytes
-func SetRequestFromBytes(sourceBytes []byte) (interface{}, error) {
+func SetRequestFromBytes(sourceBytes []byte) (Apdu, error) {
 	if len(sourceBytes) < 2 {
-		return nil, fmt.Errorf("insufficient data for SetRequest")
+		return nil, truncated("SetRequest", "header", 0)
 	}
 
 	tag := sourceBytes[0]
@@ -162,16 +255,16 @@ func SetRequestFromBytes(sourceBytes []byte) (interface{}, error) {
 	switch requestType {
 	case 1: // SetRequestNormal
 		req := &SetRequestNormal{}
-		return req.FromBytes(sourceBytes)
+		return asApdu(req.FromBytes(sourceBytes))
 	default:
 		return nil, fmt.Errorf("received an enum request type that is not valid for SetRequest: %d", requestType)
 	}
 }
 
 // SetResponseFromBytes parses a SetResponse from bytes
-func SetResponseFromBytes(sourceBytes []byte) (interface{}, error) {
+func SetResponseFromBytes(sourceBytes []byte) (Apdu, error) {
 	if len(sourceBytes) < 2 {
-		return nil, fmt.Errorf("insufficient data for SetResponse")
+		return nil, truncated("SetResponse", "header", 0)
 	}
 
 	tag := sourceBytes[0]
@@ -183,16 +276,16 @@ func SetResponseFromBytes(sourceBytes []byte) (interface{}, error) {
 	switch responseType {
 	case 1: // SetResponseNormal
 		resp := &SetResponseNormal{}
-		return resp.FromBytes(sourceBytes)
+		return asApdu(resp.FromBytes(sourceBytes))
 	default:
 		return nil, fmt.Errorf("received an enum response type that is not valid for SetResponse: %d", responseType)
 	}
 }
 
 // ActionRequestFromBytes parses an ActionRequest from bytes
-func ActionRequestFromBytes(sourceBytes []byte) (interface{}, error) {
+func ActionRequestFromBytes(sourceBytes []byte) (Apdu, error) {
 	if len(sourceBytes) < 2 {
-		return nil, fmt.Errorf("insufficient data for ActionRequest")
+		return nil, truncated("ActionRequest", "header", 0)
 	}
 
 	tag := sourceBytes[0]
@@ -204,16 +297,19 @@ func ActionRequestFromBytes(sourceBytes []byte) (interface{}, error) {
 	switch requestType {
 	case 1: // ActionRequestNormal
 		req := &ActionRequestNormal{}
-		return req.FromBytes(sourceBytes)
+		return asApdu(req.FromBytes(sourceBytes))
+	case 2: // ActionRequestNextPBlock
+		req := &ActionRequestNextPBlock{}
+		return asApdu(req.FromBytes(sourceBytes))
 	default:
 		return nil, fmt.Errorf("received an enum request type that is not valid for ActionRequest: %d", requestType)
 	}
 }
 
 // ActionResponseFromBytes parses an ActionResponse from bytes
-func ActionResponseFromBytes(sourceBytes []byte) (interface{}, error) {
+func ActionResponseFromBytes(sourceBytes []byte) (Apdu, error) {
 	if len(sourceBytes) < 4 {
-		return nil, fmt.Errorf("insufficient data for ActionResponse")
+		return nil, truncated("ActionResponse", "header", 0)
 	}
 
 	tag := sourceBytes[0]
@@ -222,6 +318,17 @@ func ActionResponseFromBytes(sourceBytes []byte) (interface{}, error) {
 	}
 
 	responseType := sourceBytes[1]
+	if responseType == 2 {
+		resp, err := (&ActionResponseWithPBlock{}).FromBytes(sourceBytes)
+		if err != nil {
+			return nil, err
+		}
+		// The last block ends the transfer, so it is a different event for the state machine
+		if resp.LastBlock {
+			return NewActionResponseLastPBlock(resp.InvokeIdAndPriority, resp.BlockNumber, resp.RawData), nil
+		}
+		return resp, nil
+	}
 	if responseType != 1 {
 		return nil, fmt.Errorf("received an enum response type that is not valid for ActionResponse: %d", responseType)
 	}
@@ -237,23 +344,23 @@ func ActionResponseFromBytes(sourceBytes []byte) (interface{}, error) {
 				if choice == 0 {
 					// ActionResponseNormalWithData
 					respWithData := &ActionResponseNormalWithData{}
-					return respWithData.FromBytes(sourceBytes)
+					return asApdu(respWithData.FromBytes(sourceBytes))
 				} else if choice == 1 {
 					// ActionResponseNormalWithError
 					respWithError := &ActionResponseNormalWithError{}
-					return respWithError.FromBytes(sourceBytes)
+					return asApdu(respWithError.FromBytes(sourceBytes))
 				}
 			}
 		} else {
 			// ActionResponseNormal (no data)
 			resp := &ActionResponseNormal{}
-			return resp.FromBytes(sourceBytes)
+			return asApdu(resp.FromBytes(sourceBytes))
 		}
 	}
 
 	// Fallback to ActionResponseNormal
 	resp := &ActionResponseNormal{}
-	return resp.FromBytes(sourceBytes)
+	return asApdu(resp.FromBytes(sourceBytes))
 }
 
 // NewXDlmsApduFactory creates a new XDlmsApduFactory
@@ -0,0 +1,59 @@
+package xdlms_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// vendorDiagnostic is a manufacturer specific APDU carrying raw diagnostics
+type vendorDiagnostic struct {
+	data []byte
+}
+
+func (v *vendorDiagnostic) Tag() uint8               { return 0xF0 }
+func (v *vendorDiagnostic) Kind() xdlms.ApduKind     { return xdlms.ApduKindUnknown }
+func (v *vendorDiagnostic) ToBytes() ([]byte, error) { return append([]byte{0xF0}, v.data...), nil }
+func (v *vendorDiagnostic) String() string           { return fmt.Sprintf("VendorDiagnostic(%x)", v.data) }
+
+func TestXDlmsApduFactory_Register(t *testing.T) {
+	encoded := []byte{0xF0, 0x01, 0x02}
+
+	factory := xdlms.NewXDlmsApduFactory()
+	_, err := factory.APDUFromBytes(encoded)
+	assert.ErrorContains(t, err, "tag 0xf0 is not available")
+
+	parser := func(data []byte) (xdlms.Apdu, error) {
+		return &vendorDiagnostic{data: data[1:]}, nil
+	}
+	require.NoError(t, factory.Register(0xF0, parser))
+	apdu, err := factory.APDUFromBytes(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, &vendorDiagnostic{data: []byte{0x01, 0x02}}, apdu)
+
+	// the registration is only for that factory
+	_, err = xdlms.NewXDlmsApduFactory().APDUFromBytes(encoded)
+	assert.Error(t, err)
+}
+
+func TestXDlmsApduFactory_RegisterRejected(t *testing.T) {
+	factory := xdlms.NewXDlmsApduFactory()
+	parser := func(data []byte) (xdlms.Apdu, error) {
+		return &vendorDiagnostic{data: data[1:]}, nil
+	}
+
+	// standard tags can't be overridden
+	for _, tag := range []uint8{xdlms.GetResponseTag, xdlms.GeneralBlockTransferTag, xdlms.EventNotificationTag} {
+		assert.Error(t, factory.Register(tag, parser), "tag 0x%02x", tag)
+	}
+	apdu, err := factory.APDUFromBytes([]byte{0xC4, 0x01, 0xC1, 0x00, 0x09, 0x01, 0x02})
+	require.NoError(t, err)
+	assert.IsType(t, &xdlms.GetResponseNormal{}, apdu)
+
+	require.NoError(t, factory.Register(0xF0, parser))
+	assert.Error(t, factory.Register(0xF0, parser))
+	assert.Error(t, factory.Register(0xF1, nil))
+}
@@ -0,0 +1,181 @@
+package xdlms
+
+import (
+	"fmt"
+)
+
+// GeneralBlockTransferTag is the tag of the general-block-transfer APDU
+const GeneralBlockTransferTag = 224
+
+// GeneralBlockTransfer is a general-block-transfer APDU, a block of another
+// APDU too long to be sent at once, e.g. a large push or GET response. With
+// streaming, up to a window of blocks is sent before the receiver acknowledges.
+//
+//	General-Block-Transfer ::= SEQUENCE {
+//	    block-control       Unsigned8,
+//	    block-number        Unsigned16,
+//	    block-number-ack    Unsigned16,
+//	    block-data          OCTET STRING
+//	}
+//
+// The block control is the last-block bit, the streaming bit and the window
+// in the six low bits.
+type GeneralBlockTransfer struct {
+	*BaseXDlmsApdu
+	LastBlock      bool
+	Streaming      bool
+	Window         uint8
+	BlockNumber    uint16
+	BlockNumberAck uint16
+	BlockData      []byte
+}
+
+// GeneralBlockTransferMaxWindow is the largest window of the block control
+const GeneralBlockTransferMaxWindow = 0x3F
+
+// NewGeneralBlockTransfer creates a new GeneralBlockTransfer
+func NewGeneralBlockTransfer(lastBlock bool, streaming bool, window uint8, blockNumber uint16, blockNumberAck uint16, blockData []byte) *GeneralBlockTransfer {
+	return &GeneralBlockTransfer{
+		BaseXDlmsApdu: &BaseXDlmsApdu{
+			tag: GeneralBlockTransferTag,
+		},
+		LastBlock:      lastBlock,
+		Streaming:      streaming,
+		Window:         window,
+		BlockNumber:    blockNumber,
+		BlockNumberAck: blockNumberAck,
+		BlockData:      blockData,
+	}
+}
+
+var generalBlockTransferSchema = &apduSchema[GeneralBlockTransfer]{
+	name: "GeneralBlockTransfer",
+	fields: []schemaField[GeneralBlockTransfer]{
+		constField[GeneralBlockTransfer]("tag", GeneralBlockTransferTag),
+		{
+			name: "block_control",
+			decode: func(r *schemaReader, g *GeneralBlockTransfer) error {
+				b, err := r.read(1)
+				if err != nil {
+					return err
+				}
+				g.LastBlock = b[0]&0x80 != 0
+				g.Streaming = b[0]&0x40 != 0
+				g.Window = b[0] & GeneralBlockTransferMaxWindow
+				return nil
+			},
+			encode: func(g *GeneralBlockTransfer, result []byte) []byte {
+				control := g.Window & GeneralBlockTransferMaxWindow
+				if g.LastBlock {
+					control |= 0x80
+				}
+				if g.Streaming {
+					control |= 0x40
+				}
+				return append(result, control)
+			},
+		},
+		uint16Field("block_number", func(g *GeneralBlockTransfer) *uint16 { return &g.BlockNumber }),
+		uint16Field("block_number_ack", func(g *GeneralBlockTransfer) *uint16 { return &g.BlockNumberAck }),
+		octetStringField("block_data", func(g *GeneralBlockTransfer) *[]byte { return &g.BlockData }),
+	},
+}
+
+// FromBytes creates GeneralBlockTransfer from bytes
+func (g *GeneralBlockTransfer) FromBytes(sourceBytes []byte) (*GeneralBlockTransfer, error) {
+	block := NewGeneralBlockTransfer(false, false, 0, 0, 0, nil)
+	if err := generalBlockTransferSchema.decode(sourceBytes, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// ToBytes converts GeneralBlockTransfer to bytes
+func (g *GeneralBlockTransfer) ToBytes() ([]byte, error) {
+	return generalBlockTransferSchema.encode(g), nil
+}
+
+// String implements fmt.Stringer
+func (g *GeneralBlockTransfer) String() string {
+	return fmt.Sprintf("GeneralBlockTransfer(last_block=%t, streaming=%t, window=%d, block_number=%d, block_number_ack=%d, %d bytes)",
+		g.LastBlock, g.Streaming, g.Window, g.BlockNumber, g.BlockNumberAck, len(g.BlockData))
+}
+
+// SplitGeneralBlockTransfer splits an APDU in blocks of blockSize bytes
+// numbered from 1. The blocks of a window are streamed, the last block of
+// each window waits for the acknowledgement of the receiver.
+func SplitGeneralBlockTransfer(apdu []byte, blockSize int, window uint8) ([]*GeneralBlockTransfer, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("block size must be positive, got %d", blockSize)
+	}
+	if window == 0 || window > GeneralBlockTransferMaxWindow {
+		return nil, fmt.Errorf("window must be between 1 and %d, got %d", GeneralBlockTransferMaxWindow, window)
+	}
+
+	var blocks []*GeneralBlockTransfer
+	for start := 0; ; start += blockSize {
+		end := min(start+blockSize, len(apdu))
+		number := uint16(len(blocks) + 1)
+		last := end == len(apdu)
+		streaming := !last && number%uint16(window) != 0
+		blocks = append(blocks, NewGeneralBlockTransfer(last, streaming, window, number, 0, apdu[start:end]))
+		if last {
+			return blocks, nil
+		}
+	}
+}
+
+// GeneralBlockTransferReassembler puts together the blocks of an APDU
+// received with general block transfer. Blocks lost in a stream are asked
+// again by acknowledging the last block received in order at the end of the
+// window, blocks received twice are ignored.
+type GeneralBlockTransferReassembler struct {
+	// Window is the number of blocks the receiver accepts without
+	// acknowledging, sent in the acknowledgements
+	Window uint8
+
+	received uint16
+	acked    uint16
+	data     []byte
+}
+
+// NewGeneralBlockTransferReassembler creates a GeneralBlockTransferReassembler
+// proposing the given window
+func NewGeneralBlockTransferReassembler(window uint8) *GeneralBlockTransferReassembler {
+	if window == 0 {
+		window = 1
+	}
+	return &GeneralBlockTransferReassembler{Window: window}
+}
+
+// Add adds a received block. It returns the APDU once its last block is
+// received, and the acknowledgement to send when the block ends a window.
+func (r *GeneralBlockTransferReassembler) Add(block *GeneralBlockTransfer) (apdu []byte, ack *GeneralBlockTransfer) {
+	if block.BlockNumber == r.received+1 {
+		r.received = block.BlockNumber
+		r.data = append(r.data, block.BlockData...)
+		if block.LastBlock {
+			apdu = r.data
+			r.Reset()
+			return apdu, nil
+		}
+	}
+	if block.Streaming {
+		return nil, nil
+	}
+
+	r.acked++
+	return nil, NewGeneralBlockTransfer(false, false, r.Window, r.acked, r.received, nil)
+}
+
+// InProgress tells if blocks of an APDU were received and its last block was not
+func (r *GeneralBlockTransferReassembler) InProgress() bool {
+	return r.received > 0
+}
+
+// Reset drops the blocks received, e.g. after the association is lost
+func (r *GeneralBlockTransferReassembler) Reset() {
+	r.received = 0
+	r.acked = 0
+	r.data = nil
+}
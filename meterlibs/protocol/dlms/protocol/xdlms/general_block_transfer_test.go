@@ -0,0 +1,77 @@
+package xdlms_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+func TestGeneralBlockTransfer_Bytes(t *testing.T) {
+	encoded, err := hex.DecodeString("E0C2000100000403C401C1")
+	require.NoError(t, err)
+
+	apdu, err := xdlms.NewXDlmsApduFactory().APDUFromBytes(encoded)
+	require.NoError(t, err)
+	block, ok := apdu.(*xdlms.GeneralBlockTransfer)
+	require.True(t, ok)
+	assert.True(t, block.LastBlock)
+	assert.True(t, block.Streaming)
+	assert.Equal(t, uint8(2), block.Window)
+	assert.Equal(t, uint16(1), block.BlockNumber)
+	assert.Equal(t, uint16(0), block.BlockNumberAck)
+	assert.Equal(t, []byte{0x03, 0xC4, 0x01, 0xC1}, block.BlockData)
+
+	reencoded, err := block.ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, encoded, reencoded)
+
+	_, err = (&xdlms.GeneralBlockTransfer{}).FromBytes(encoded[:len(encoded)-1])
+	assert.Error(t, err)
+}
+
+func TestGeneralBlockTransfer_Streaming(t *testing.T) {
+	apdu := []byte("a data notification too long for one frame")
+	blocks, err := xdlms.SplitGeneralBlockTransfer(apdu, 8, 3)
+	require.NoError(t, err)
+	require.Len(t, blocks, 6)
+	assert.True(t, blocks[0].Streaming)
+	assert.False(t, blocks[2].Streaming)
+	assert.True(t, blocks[5].LastBlock)
+	assert.False(t, blocks[5].Streaming)
+
+	reassembler := xdlms.NewGeneralBlockTransferReassembler(3)
+	data, ack := reassembler.Add(blocks[0])
+	assert.Nil(t, data)
+	assert.Nil(t, ack)
+
+	// block 2 is lost, block 3 ends the window and the ack asks for block 2 again
+	data, ack = reassembler.Add(blocks[2])
+	assert.Nil(t, data)
+	require.NotNil(t, ack)
+	assert.Equal(t, uint16(1), ack.BlockNumberAck)
+	assert.Equal(t, uint8(3), ack.Window)
+	assert.Empty(t, ack.BlockData)
+
+	for _, block := range blocks[1:5] {
+		data, ack = reassembler.Add(block)
+		assert.Nil(t, data)
+		if block.BlockNumber == 3 {
+			require.NotNil(t, ack)
+			assert.Equal(t, uint16(3), ack.BlockNumberAck)
+			assert.Equal(t, uint16(2), ack.BlockNumber)
+		}
+	}
+	assert.True(t, reassembler.InProgress())
+
+	// a block received twice is ignored
+	data, _ = reassembler.Add(blocks[4])
+	assert.Nil(t, data)
+
+	data, ack = reassembler.Add(blocks[5])
+	assert.Equal(t, apdu, data)
+	assert.Nil(t, ack)
+	assert.False(t, reassembler.InProgress())
+}
@@ -0,0 +1,95 @@
+package xdlms
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+)
+
+// GeneralGlobalCipherTag is the tag of the general-glo-ciphering APDU
+const GeneralGlobalCipherTag = 219
+
+// GeneralGlobalCipher is a general-glo-ciphering APDU, an APDU ciphered with
+// a global key carrying the system title of its sender. It is used when the
+// receiver can't know the sender from the association, e.g. for broadcasts.
+//
+//	General-Glo-Ciphering ::= SEQUENCE {
+//	    system-title        OCTET STRING,
+//	    ciphered-content    OCTET STRING
+//	}
+//
+// The ciphered content is the security control byte, the invocation counter
+// and the ciphered APDU.
+type GeneralGlobalCipher struct {
+	*BaseXDlmsApdu
+	SystemTitle       []byte
+	SecurityControl   byte
+	InvocationCounter uint32
+	CipheredText      []byte
+}
+
+// NewGeneralGlobalCipher creates a new GeneralGlobalCipher
+func NewGeneralGlobalCipher(systemTitle []byte, securityControl byte, invocationCounter uint32, cipheredText []byte) *GeneralGlobalCipher {
+	return &GeneralGlobalCipher{
+		BaseXDlmsApdu: &BaseXDlmsApdu{
+			tag: GeneralGlobalCipherTag,
+		},
+		SystemTitle:       systemTitle,
+		SecurityControl:   securityControl,
+		InvocationCounter: invocationCounter,
+		CipheredText:      cipheredText,
+	}
+}
+
+// FromBytes creates GeneralGlobalCipher from bytes
+func (g *GeneralGlobalCipher) FromBytes(data []byte) (*GeneralGlobalCipher, error) {
+	if len(data) == 0 || data[0] != GeneralGlobalCipherTag {
+		return nil, fmt.Errorf("tag is not correct. Should be %d", GeneralGlobalCipherTag)
+	}
+
+	length, rest, err := dlmsdata.DecodeVariableInteger(data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("system title length: %w", err)
+	}
+	if len(rest) < length {
+		return nil, fmt.Errorf("system title of %d bytes, %d bytes left", length, len(rest))
+	}
+	systemTitle := rest[:length]
+
+	length, rest, err = dlmsdata.DecodeVariableInteger(rest[length:])
+	if err != nil {
+		return nil, fmt.Errorf("ciphered content length: %w", err)
+	}
+	if len(rest) != length {
+		return nil, fmt.Errorf("ciphered content of %d bytes, got %d bytes", length, len(rest))
+	}
+	if length < 5 {
+		return nil, fmt.Errorf("ciphered content of %d bytes is too short for the security header", length)
+	}
+
+	return NewGeneralGlobalCipher(
+		append([]byte{}, systemTitle...),
+		rest[0],
+		binary.BigEndian.Uint32(rest[1:5]),
+		append([]byte{}, rest[5:]...),
+	), nil
+}
+
+// ToBytes converts GeneralGlobalCipher to bytes
+func (g *GeneralGlobalCipher) ToBytes() ([]byte, error) {
+	result := []byte{GeneralGlobalCipherTag}
+	result = append(result, dlmsdata.EncodeVariableInteger(len(g.SystemTitle))...)
+	result = append(result, g.SystemTitle...)
+	result = append(result, dlmsdata.EncodeVariableInteger(5+len(g.CipheredText))...)
+	result = append(result, g.SecurityControl)
+	result = binary.BigEndian.AppendUint32(result, g.InvocationCounter)
+	result = append(result, g.CipheredText...)
+	return result, nil
+}
+
+// String implements fmt.Stringer
+func (g *GeneralGlobalCipher) String() string {
+	return fmt.Sprintf("GeneralGlobalCipher(system_title=%x, security_control=%02x, invocation_counter=%d, %d bytes)",
+		g.SystemTitle, g.SecurityControl, g.InvocationCounter, len(g.CipheredText))
+}
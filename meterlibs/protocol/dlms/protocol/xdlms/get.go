@@ -1,12 +1,17 @@
 package xdlms
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"sync"
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
 )
 
 // GetRequestNormal represents a Get request normal
@@ -30,7 +35,7 @@ func NewGetRequestNormal(
 ) *GetRequestNormal {
 	return &GetRequestNormal{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetRequestTag,
+			tag: GetRequestTag,
 		},
 		CosemAttribute:      cosemAttribute,
 		InvokeIdAndPriority: invokeIdAndPriority,
@@ -40,8 +45,9 @@ func NewGetRequestNormal(
 
 // FromBytes creates GetRequestNormal from bytes
 func (g *GetRequestNormal) FromBytes(data []byte) (*GetRequestNormal, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetRequest")
+		return nil, truncated("GetRequestNormal", "header", 0)
 	}
 
 	tag := data[0]
@@ -50,7 +56,7 @@ func (g *GetRequestNormal) FromBytes(data []byte) (*GetRequestNormal, error) {
 	}
 
 	typeChoice := enumerations.GetRequestType(data[1])
-	if typeChoice != enumerations.GetRequestTypeNormal {
+	if typeChoice != enumerations.GetRequestNormal {
 		return nil, fmt.Errorf("the data for the GetRequest is not for a GetRequestNormal")
 	}
 
@@ -58,7 +64,7 @@ func (g *GetRequestNormal) FromBytes(data []byte) (*GetRequestNormal, error) {
 
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("GetRequestNormal", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -68,7 +74,7 @@ func (g *GetRequestNormal) FromBytes(data []byte) (*GetRequestNormal, error) {
 
 	// Parse cosem_attribute (9 bytes)
 	if len(data) < 9 {
-		return nil, fmt.Errorf("insufficient data for cosem_attribute")
+		return nil, truncated("GetRequestNormal", "cosem_attribute", len(source)-len(data))
 	}
 	cosemAttribute, err := (&cosem.CosemAttribute{}).FromBytes(data[:9])
 	if err != nil {
@@ -98,24 +104,29 @@ func (g *GetRequestNormal) FromBytes(data []byte) (*GetRequestNormal, error) {
 // ToBytes converts GetRequestNormal to bytes
 func (g *GetRequestNormal) ToBytes() ([]byte, error) {
 	result := []byte{GetRequestTag}
-	result = append(result, byte(enumerations.GetRequestTypeNormal))
+	result = append(result, byte(enumerations.GetRequestNormal))
 
 	invokeBytes := g.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
 
-	cosemBytes := g.CosemAttribute.ToBytes()
-	result = append(result, cosemBytes...)
+	descriptorBytes, err := g.descriptorBytes()
+	if err != nil {
+		return nil, err
+	}
+	return append(result, descriptorBytes...), nil
+}
+
+// descriptorBytes encodes the attribute descriptor and the access selection
+func (g *GetRequestNormal) descriptorBytes() ([]byte, error) {
+	result := g.CosemAttribute.ToBytes()
 
 	if g.AccessSelection != nil {
 		result = append(result, 0x01)
-		switch sel := g.AccessSelection.(type) {
-		case *cosem.RangeDescriptor:
-			result = append(result, sel.ToBytes()...)
-		case *cosem.EntryDescriptor:
-			result = append(result, sel.ToBytes()...)
-		default:
-			return nil, fmt.Errorf("unknown access selection type: %T", g.AccessSelection)
+		selectionBytes, err := cosem.AccessSelectionToBytes(g.AccessSelection)
+		if err != nil {
+			return nil, err
 		}
+		result = append(result, selectionBytes...)
 	} else {
 		result = append(result, 0x00)
 	}
@@ -123,6 +134,34 @@ func (g *GetRequestNormal) ToBytes() ([]byte, error) {
 	return result, nil
 }
 
+// Fingerprint identifies the request by its encoded attribute descriptor and
+// access selection in hex, the invoke id is left out as it changes between
+// requests. Requests reading the same data have the same fingerprint.
+func (g *GetRequestNormal) Fingerprint() (string, error) {
+	descriptorBytes, err := g.descriptorBytes()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", g, err)
+	}
+	return hex.EncodeToString(descriptorBytes), nil
+}
+
+// Equal tells whether both requests read the same attribute with the same
+// access selection, whatever their invoke ids
+func (g *GetRequestNormal) Equal(other *GetRequestNormal) bool {
+	if g == nil || other == nil {
+		return g == other
+	}
+	a, err := g.descriptorBytes()
+	if err != nil {
+		return false
+	}
+	b, err := other.descriptorBytes()
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
 // GetRequestNext represents a Get request next (for block transfer)
 type GetRequestNext struct {
 	*BaseXDlmsApdu
@@ -134,7 +173,7 @@ type GetRequestNext struct {
 func NewGetRequestNext(blockNumber uint32, invokeIdAndPriority *InvokeIdAndPriority) *GetRequestNext {
 	return &GetRequestNext{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetRequestTag,
+			tag: GetRequestTag,
 		},
 		BlockNumber:         blockNumber,
 		InvokeIdAndPriority: invokeIdAndPriority,
@@ -143,8 +182,9 @@ func NewGetRequestNext(blockNumber uint32, invokeIdAndPriority *InvokeIdAndPrior
 
 // FromBytes creates GetRequestNext from bytes
 func (g *GetRequestNext) FromBytes(data []byte) (*GetRequestNext, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetRequestNext")
+		return nil, truncated("GetRequestNext", "header", 0)
 	}
 
 	tag := data[0]
@@ -153,7 +193,7 @@ func (g *GetRequestNext) FromBytes(data []byte) (*GetRequestNext, error) {
 	}
 
 	typeChoice := enumerations.GetRequestType(data[1])
-	if typeChoice != enumerations.GetRequestTypeNext {
+	if typeChoice != enumerations.GetRequestNext {
 		return nil, fmt.Errorf("the data for the GetRequest is not for a GetRequestNext")
 	}
 
@@ -161,7 +201,7 @@ func (g *GetRequestNext) FromBytes(data []byte) (*GetRequestNext, error) {
 
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("GetRequestNext", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -171,7 +211,7 @@ func (g *GetRequestNext) FromBytes(data []byte) (*GetRequestNext, error) {
 
 	// Parse block_number (4 bytes)
 	if len(data) < 4 {
-		return nil, fmt.Errorf("insufficient data for block_number")
+		return nil, truncated("GetRequestNext", "block_number", len(source)-len(data))
 	}
 	blockNumber := binary.BigEndian.Uint32(data[:4])
 
@@ -181,7 +221,7 @@ func (g *GetRequestNext) FromBytes(data []byte) (*GetRequestNext, error) {
 // ToBytes converts GetRequestNext to bytes
 func (g *GetRequestNext) ToBytes() ([]byte, error) {
 	result := []byte{GetRequestTag}
-	result = append(result, byte(enumerations.GetRequestTypeNext))
+	result = append(result, byte(enumerations.GetRequestNext))
 
 	invokeBytes := g.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -209,7 +249,7 @@ func NewGetResponseNormal(
 ) *GetResponseNormal {
 	return &GetResponseNormal{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetResponseTag,
+			tag: GetResponseTag,
 		},
 		InvokeIdAndPriority: invokeIdAndPriority,
 		Data:                data,
@@ -218,8 +258,9 @@ func NewGetResponseNormal(
 
 // FromBytes creates GetResponseNormal from bytes
 func (g *GetResponseNormal) FromBytes(data []byte) (*GetResponseNormal, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetResponse")
+		return nil, truncated("GetResponseNormal", "header", 0)
 	}
 
 	tag := data[0]
@@ -228,7 +269,7 @@ func (g *GetResponseNormal) FromBytes(data []byte) (*GetResponseNormal, error) {
 	}
 
 	typeChoice := enumerations.GetResponseType(data[1])
-	if typeChoice != enumerations.GetResponseTypeNormal {
+	if typeChoice != enumerations.GetResponseNormal {
 		return nil, fmt.Errorf("the data for the GetResponse is not for a GetResponseNormal")
 	}
 
@@ -236,7 +277,7 @@ func (g *GetResponseNormal) FromBytes(data []byte) (*GetResponseNormal, error) {
 
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("GetResponseNormal", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -246,7 +287,7 @@ func (g *GetResponseNormal) FromBytes(data []byte) (*GetResponseNormal, error) {
 
 	// Parse choice (0 = data, 1 = error)
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for choice")
+		return nil, truncated("GetResponseNormal", "choice", len(source)-len(data))
 	}
 	choice := data[0]
 	if choice != 0 {
@@ -264,7 +305,7 @@ func (g *GetResponseNormal) FromBytes(data []byte) (*GetResponseNormal, error) {
 // ToBytes converts GetResponseNormal to bytes
 func (g *GetResponseNormal) ToBytes() ([]byte, error) {
 	result := []byte{GetResponseTag}
-	result = append(result, byte(enumerations.GetResponseTypeNormal))
+	result = append(result, byte(enumerations.GetResponseNormal))
 
 	invokeBytes := g.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -289,7 +330,7 @@ func NewGetResponseNormalWithError(
 ) *GetResponseNormalWithError {
 	return &GetResponseNormalWithError{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetResponseTag,
+			tag: GetResponseTag,
 		},
 		InvokeIdAndPriority: invokeIdAndPriority,
 		Error:               error,
@@ -298,8 +339,9 @@ func NewGetResponseNormalWithError(
 
 // FromBytes creates GetResponseNormalWithError from bytes
 func (g *GetResponseNormalWithError) FromBytes(data []byte) (*GetResponseNormalWithError, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetResponse")
+		return nil, truncated("GetResponseNormalWithError", "header", 0)
 	}
 
 	tag := data[0]
@@ -308,7 +350,7 @@ func (g *GetResponseNormalWithError) FromBytes(data []byte) (*GetResponseNormalW
 	}
 
 	typeChoice := enumerations.GetResponseType(data[1])
-	if typeChoice != enumerations.GetResponseTypeNormal {
+	if typeChoice != enumerations.GetResponseNormal {
 		return nil, fmt.Errorf("the data for the GetResponse is not for a GetResponseNormal")
 	}
 
@@ -316,7 +358,7 @@ func (g *GetResponseNormalWithError) FromBytes(data []byte) (*GetResponseNormalW
 
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("GetResponseNormalWithError", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -326,7 +368,7 @@ func (g *GetResponseNormalWithError) FromBytes(data []byte) (*GetResponseNormalW
 
 	// Parse choice (0 = data, 1 = error)
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for choice")
+		return nil, truncated("GetResponseNormalWithError", "choice", len(source)-len(data))
 	}
 	choice := data[0]
 	if choice != 1 {
@@ -336,7 +378,7 @@ func (g *GetResponseNormalWithError) FromBytes(data []byte) (*GetResponseNormalW
 
 	// Parse error
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for error")
+		return nil, truncated("GetResponseNormalWithError", "error", len(source)-len(data))
 	}
 	error := enumerations.DataAccessResult(data[0])
 
@@ -346,7 +388,7 @@ func (g *GetResponseNormalWithError) FromBytes(data []byte) (*GetResponseNormalW
 // ToBytes converts GetResponseNormalWithError to bytes
 func (g *GetResponseNormalWithError) ToBytes() ([]byte, error) {
 	result := []byte{GetResponseTag}
-	result = append(result, byte(enumerations.GetResponseTypeNormal))
+	result = append(result, byte(enumerations.GetResponseNormal))
 
 	invokeBytes := g.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -375,7 +417,7 @@ func NewGetResponseWithDataBlock(
 ) *GetResponseWithDataBlock {
 	return &GetResponseWithDataBlock{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetResponseTag,
+			tag: GetResponseTag,
 		},
 		InvokeIdAndPriority: invokeIdAndPriority,
 		LastBlock:           lastBlock,
@@ -386,8 +428,9 @@ func NewGetResponseWithDataBlock(
 
 // FromBytes creates GetResponseWithDataBlock from bytes
 func (g *GetResponseWithDataBlock) FromBytes(data []byte) (*GetResponseWithDataBlock, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetResponseWithDataBlock")
+		return nil, truncated("GetResponseWithDataBlock", "header", 0)
 	}
 
 	tag := data[0]
@@ -404,7 +447,7 @@ func (g *GetResponseWithDataBlock) FromBytes(data []byte) (*GetResponseWithDataB
 
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("GetResponseWithDataBlock", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -414,29 +457,31 @@ func (g *GetResponseWithDataBlock) FromBytes(data []byte) (*GetResponseWithDataB
 
 	// Parse last_block (1 byte boolean)
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for last_block")
+		return nil, truncated("GetResponseWithDataBlock", "last_block", len(source)-len(data))
 	}
 	lastBlock := data[0] != 0
 	data = data[1:]
 
 	// Parse block_number (4 bytes)
 	if len(data) < 4 {
-		return nil, fmt.Errorf("insufficient data for block_number")
+		return nil, truncated("GetResponseWithDataBlock", "block_number", len(source)-len(data))
 	}
 	blockNumber := binary.BigEndian.Uint32(data[:4])
 	data = data[4:]
 
-	// Parse raw_data length and data
+	// Parse result choice, 0 is raw-data and 1 is data-access-result
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for raw_data length")
+		return nil, truncated("GetResponseWithDataBlock", "result_choice", len(source)-len(data))
+	}
+	if data[0] != 0 {
+		return nil, fmt.Errorf("GetResponseWithDataBlock result is not raw-data, got choice %d", data[0])
 	}
-	rawDataLength := int(data[0])
 	data = data[1:]
-	if len(data) < rawDataLength {
-		return nil, fmt.Errorf("insufficient data for raw_data")
+
+	rawData, err := rawDataFromBytes("GetResponseWithDataBlock", source, data)
+	if err != nil {
+		return nil, err
 	}
-	rawData := make([]byte, rawDataLength)
-	copy(rawData, data[:rawDataLength])
 
 	return NewGetResponseWithDataBlock(invokeIdAndPriority, lastBlock, blockNumber, rawData), nil
 }
@@ -459,7 +504,8 @@ func (g *GetResponseWithDataBlock) ToBytes() ([]byte, error) {
 	binary.BigEndian.PutUint32(blockBytes, g.BlockNumber)
 	result = append(result, blockBytes...)
 
-	result = append(result, byte(len(g.RawData)))
+	result = append(result, 0x00) // raw-data choice
+	result = append(result, dlmsdata.EncodeVariableInteger(len(g.RawData))...)
 	result = append(result, g.RawData...)
 
 	return result, nil
@@ -481,7 +527,7 @@ func NewGetRequestWithList(
 ) *GetRequestWithList {
 	return &GetRequestWithList{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetRequestTag,
+			tag: GetRequestTag,
 		},
 		InvokeIdAndPriority: invokeIdAndPriority,
 		Attributes:          attributes,
@@ -491,8 +537,9 @@ func NewGetRequestWithList(
 
 // FromBytes creates GetRequestWithList from bytes
 func (g *GetRequestWithList) FromBytes(data []byte) (*GetRequestWithList, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetRequestWithList")
+		return nil, truncated("GetRequestWithList", "header", 0)
 	}
 
 	tag := data[0]
@@ -501,7 +548,7 @@ func (g *GetRequestWithList) FromBytes(data []byte) (*GetRequestWithList, error)
 	}
 
 	typeChoice := enumerations.GetRequestType(data[1])
-	if typeChoice != enumerations.GetRequestTypeWithList {
+	if typeChoice != enumerations.GetRequestWithList {
 		return nil, fmt.Errorf("the data for the GetRequest is not for a GetRequestWithList")
 	}
 
@@ -509,7 +556,7 @@ func (g *GetRequestWithList) FromBytes(data []byte) (*GetRequestWithList, error)
 
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("GetRequestWithList", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -519,7 +566,7 @@ func (g *GetRequestWithList) FromBytes(data []byte) (*GetRequestWithList, error)
 
 	// Parse attribute descriptor list count
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for attribute descriptor list count")
+		return nil, truncated("GetRequestWithList", "attribute_count", len(source)-len(data))
 	}
 	attributeCount := int(data[0])
 	data = data[1:]
@@ -529,7 +576,7 @@ func (g *GetRequestWithList) FromBytes(data []byte) (*GetRequestWithList, error)
 
 	for i := 0; i < attributeCount; i++ {
 		if len(data) < 9 {
-			return nil, fmt.Errorf("insufficient data for attribute %d", i)
+			return nil, truncated("GetRequestWithList", fmt.Sprintf("cosem_attribute %d", i), len(source)-len(data))
 		}
 		cosemAttribute, err := (&cosem.CosemAttribute{}).FromBytes(data[:9])
 		if err != nil {
@@ -562,7 +609,7 @@ func (g *GetRequestWithList) FromBytes(data []byte) (*GetRequestWithList, error)
 // ToBytes converts GetRequestWithList to bytes
 func (g *GetRequestWithList) ToBytes() ([]byte, error) {
 	result := []byte{GetRequestTag}
-	result = append(result, byte(enumerations.GetRequestTypeWithList))
+	result = append(result, byte(enumerations.GetRequestWithList))
 
 	invokeBytes := g.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -575,14 +622,11 @@ func (g *GetRequestWithList) ToBytes() ([]byte, error) {
 
 		if i < len(g.AccessSelections) && g.AccessSelections[i] != nil {
 			result = append(result, 0x01)
-			switch sel := g.AccessSelections[i].(type) {
-			case *cosem.RangeDescriptor:
-				result = append(result, sel.ToBytes()...)
-			case *cosem.EntryDescriptor:
-				result = append(result, sel.ToBytes()...)
-			default:
-				return nil, fmt.Errorf("unknown access selection type: %T", sel)
+			selectionBytes, err := cosem.AccessSelectionToBytes(g.AccessSelections[i])
+			if err != nil {
+				return nil, fmt.Errorf("attribute %d: %w", i, err)
 			}
+			result = append(result, selectionBytes...)
 		} else {
 			result = append(result, 0x00)
 		}
@@ -591,10 +635,117 @@ func (g *GetRequestWithList) ToBytes() ([]byte, error) {
 	return result, nil
 }
 
-// GetDataResult represents a single result in GetResponseWithList
+// GetDataResult is a result of a GetResponseWithList, either the A-XDR
+// encoded data of an attribute or the reason it couldn't be read
 type GetDataResult struct {
-	Data  []byte
-	Error enumerations.DataAccessResult
+	data    []byte
+	result  enumerations.DataAccessResult
+	isError bool
+
+	decodeOnce sync.Once
+	decoded    interface{}
+	decodeErr  error
+}
+
+// NewGetDataResult creates a GetDataResult holding the data of an attribute
+func NewGetDataResult(data []byte) *GetDataResult {
+	return &GetDataResult{data: data}
+}
+
+// NewGetDataResultWithError creates a GetDataResult holding an access error
+func NewGetDataResultWithError(result enumerations.DataAccessResult) *GetDataResult {
+	return &GetDataResult{result: result, isError: true}
+}
+
+// IsError tells whether the attribute couldn't be read
+func (r *GetDataResult) IsError() bool {
+	return r.isError
+}
+
+// Data returns the A-XDR encoded data, nil for an error
+func (r *GetDataResult) Data() []byte {
+	return r.data
+}
+
+// Error returns the reason the attribute couldn't be read, DataAccessSuccess
+// when the result holds data
+func (r *GetDataResult) Error() enumerations.DataAccessResult {
+	if !r.isError {
+		return enumerations.DataAccessSuccess
+	}
+	return r.result
+}
+
+// DecodedData decodes the data on first use, structures and arrays are
+// returned as []interface{}
+func (r *GetDataResult) DecodedData() (interface{}, error) {
+	if r.isError {
+		return nil, fmt.Errorf("no data, access failed with result %s", r.result)
+	}
+	r.decodeOnce.Do(func() {
+		r.decoded, r.decodeErr = encoding.DecodeValue(r.data)
+	})
+	return r.decoded, r.decodeErr
+}
+
+// String implements fmt.Stringer
+func (r *GetDataResult) String() string {
+	if r.isError {
+		return fmt.Sprintf("GetDataResult(error=%s)", r.result)
+	}
+	return fmt.Sprintf("GetDataResult(data=%x)", r.data)
+}
+
+// GetDataResultsFromBytes parses a SEQUENCE OF Get-Data-Result, the body of a
+// GetResponseWithList or the reassembled raw data of its blocks
+func GetDataResultsFromBytes(data []byte) ([]*GetDataResult, error) {
+	count, data, err := dlmsdata.DecodeVariableInteger(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the number of results: %w", err)
+	}
+	// Every result is at least two bytes
+	if count > len(data)/2 {
+		return nil, fmt.Errorf("%d results declared but only %d bytes remain", count, len(data))
+	}
+
+	results := make([]*GetDataResult, 0, count)
+	for i := 0; i < count; i++ {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("insufficient data for result %d", i)
+		}
+		switch data[0] {
+		case 0x00:
+			length, err := encoding.ValueLength(data[1:])
+			if err != nil {
+				return nil, fmt.Errorf("result %d: %w", i, err)
+			}
+			results = append(results, NewGetDataResult(append([]byte(nil), data[1:1+length]...)))
+			data = data[1+length:]
+		case 0x01:
+			results = append(results, NewGetDataResultWithError(enumerations.DataAccessResult(data[1])))
+			data = data[2:]
+		default:
+			return nil, fmt.Errorf("result %d has an invalid choice %d", i, data[0])
+		}
+	}
+	if len(data) > 0 {
+		return nil, fmt.Errorf("%d bytes left after the results", len(data))
+	}
+	return results, nil
+}
+
+// GetDataResultsToBytes encodes a SEQUENCE OF Get-Data-Result
+func GetDataResultsToBytes(results []*GetDataResult) []byte {
+	result := dlmsdata.EncodeVariableInteger(len(results))
+	for _, r := range results {
+		if r.isError {
+			result = append(result, 0x01, byte(r.result))
+		} else {
+			result = append(result, 0x00)
+			result = append(result, r.data...)
+		}
+	}
+	return result
 }
 
 // GetResponseWithList represents a Get response with list
@@ -611,7 +762,7 @@ func NewGetResponseWithList(
 ) *GetResponseWithList {
 	return &GetResponseWithList{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetResponseTag,
+			tag: GetResponseTag,
 		},
 		InvokeIdAndPriority: invokeIdAndPriority,
 		Results:             results,
@@ -620,14 +771,54 @@ func NewGetResponseWithList(
 
 // FromBytes creates GetResponseWithList from bytes
 func (g *GetResponseWithList) FromBytes(data []byte) (*GetResponseWithList, error) {
-	// TODO: Implement full parsing
-	return nil, fmt.Errorf("GetResponseWithList.FromBytes not yet implemented")
+	if len(data) < 3 {
+		return nil, truncated("GetResponseWithList", "header", 0)
+	}
+
+	tag := data[0]
+	if tag != GetResponseTag {
+		return nil, fmt.Errorf("tag for GET response is not correct. Got %d, should be %d", tag, GetResponseTag)
+	}
+
+	typeChoice := enumerations.GetResponseType(data[1])
+	if typeChoice != enumerations.GetResponseWithList {
+		return nil, fmt.Errorf("the data for the GetResponse is not for a GetResponseWithList")
+	}
+
+	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[2:3])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse invoke_id_and_priority: %w", err)
+	}
+
+	results, err := GetDataResultsFromBytes(data[3:])
+	if err != nil {
+		return nil, exceptions.NewApduParseError("GetResponseWithList", "results", 3, err)
+	}
+	return NewGetResponseWithList(invokeIdAndPriority, results), nil
 }
 
 // ToBytes converts GetResponseWithList to bytes
 func (g *GetResponseWithList) ToBytes() ([]byte, error) {
-	// TODO: Implement full encoding
-	return nil, fmt.Errorf("GetResponseWithList.ToBytes not yet implemented")
+	result := []byte{GetResponseTag}
+	result = append(result, byte(enumerations.GetResponseWithList))
+	result = append(result, g.InvokeIdAndPriority.ToBytes()...)
+	return append(result, GetDataResultsToBytes(g.Results)...), nil
+}
+
+// rawDataFromBytes parses the raw data of a block, an octet-string with an
+// A-XDR length. source is the whole APDU, for the offsets of the errors.
+func rawDataFromBytes(apdu string, source, data []byte) ([]byte, error) {
+	if len(data) < 1 {
+		return nil, truncated(apdu, "raw_data_length", len(source)-len(data))
+	}
+	rawDataLength, rest, err := dlmsdata.DecodeVariableInteger(data)
+	if err != nil {
+		return nil, exceptions.NewApduParseError(apdu, "raw_data_length", len(source)-len(data), err)
+	}
+	if len(rest) < rawDataLength {
+		return nil, truncated(apdu, "raw_data", len(source)-len(rest))
+	}
+	return append([]byte(nil), rest[:rawDataLength]...), nil
 }
 
 // GetResponseLastBlock represents a Get response last block
@@ -646,7 +837,7 @@ func NewGetResponseLastBlock(
 ) *GetResponseLastBlock {
 	return &GetResponseLastBlock{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetResponseTag,
+			tag: GetResponseTag,
 		},
 		InvokeIdAndPriority: invokeIdAndPriority,
 		BlockNumber:         blockNumber,
@@ -656,8 +847,9 @@ func NewGetResponseLastBlock(
 
 // FromBytes creates GetResponseLastBlock from bytes
 func (g *GetResponseLastBlock) FromBytes(data []byte) (*GetResponseLastBlock, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetResponseLastBlock")
+		return nil, truncated("GetResponseLastBlock", "header", 0)
 	}
 
 	tag := data[0]
@@ -666,7 +858,7 @@ func (g *GetResponseLastBlock) FromBytes(data []byte) (*GetResponseLastBlock, er
 	}
 
 	typeChoice := enumerations.GetResponseType(data[1])
-	if typeChoice != enumerations.GetResponseTypeLastBlock {
+	if typeChoice != enumerations.GetResponseLastBlock {
 		return nil, fmt.Errorf("the data for the GetResponse is not for a GetResponseLastBlock")
 	}
 
@@ -674,7 +866,7 @@ func (g *GetResponseLastBlock) FromBytes(data []byte) (*GetResponseLastBlock, er
 
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("GetResponseLastBlock", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -684,22 +876,15 @@ func (g *GetResponseLastBlock) FromBytes(data []byte) (*GetResponseLastBlock, er
 
 	// Parse block_number (4 bytes)
 	if len(data) < 4 {
-		return nil, fmt.Errorf("insufficient data for block_number")
+		return nil, truncated("GetResponseLastBlock", "block_number", len(source)-len(data))
 	}
 	blockNumber := binary.BigEndian.Uint32(data[:4])
 	data = data[4:]
 
-	// Parse raw_data length and data
-	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for raw_data length")
-	}
-	rawDataLength := int(data[0])
-	data = data[1:]
-	if len(data) < rawDataLength {
-		return nil, fmt.Errorf("insufficient data for raw_data")
+	rawData, err := rawDataFromBytes("GetResponseLastBlock", source, data)
+	if err != nil {
+		return nil, err
 	}
-	rawData := make([]byte, rawDataLength)
-	copy(rawData, data[:rawDataLength])
 
 	return NewGetResponseLastBlock(invokeIdAndPriority, blockNumber, rawData), nil
 }
@@ -707,7 +892,7 @@ func (g *GetResponseLastBlock) FromBytes(data []byte) (*GetResponseLastBlock, er
 // ToBytes converts GetResponseLastBlock to bytes
 func (g *GetResponseLastBlock) ToBytes() ([]byte, error) {
 	result := []byte{GetResponseTag}
-	result = append(result, byte(enumerations.GetResponseTypeLastBlock))
+	result = append(result, byte(enumerations.GetResponseLastBlock))
 
 	invokeBytes := g.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -716,7 +901,7 @@ func (g *GetResponseLastBlock) ToBytes() ([]byte, error) {
 	binary.BigEndian.PutUint32(blockBytes, g.BlockNumber)
 	result = append(result, blockBytes...)
 
-	result = append(result, byte(len(g.RawData)))
+	result = append(result, dlmsdata.EncodeVariableInteger(len(g.RawData))...)
 	result = append(result, g.RawData...)
 
 	return result, nil
@@ -738,7 +923,7 @@ func NewGetResponseLastBlockWithError(
 ) *GetResponseLastBlockWithError {
 	return &GetResponseLastBlockWithError{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GetResponseTag,
+			tag: GetResponseTag,
 		},
 		InvokeIdAndPriority: invokeIdAndPriority,
 		BlockNumber:         blockNumber,
@@ -748,8 +933,9 @@ func NewGetResponseLastBlockWithError(
 
 // FromBytes creates GetResponseLastBlockWithError from bytes
 func (g *GetResponseLastBlockWithError) FromBytes(data []byte) (*GetResponseLastBlockWithError, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for GetResponseLastBlockWithError")
+		return nil, truncated("GetResponseLastBlockWithError", "header", 0)
 	}
 
 	tag := data[0]
@@ -758,7 +944,7 @@ func (g *GetResponseLastBlockWithError) FromBytes(data []byte) (*GetResponseLast
 	}
 
 	typeChoice := enumerations.GetResponseType(data[1])
-	if typeChoice != enumerations.GetResponseTypeLastBlockWithError {
+	if typeChoice != enumerations.GetResponseLastBlockWithError {
 		return nil, fmt.Errorf("the data for the GetResponse is not for a GetResponseLastBlockWithError")
 	}
 
@@ -766,7 +952,7 @@ func (g *GetResponseLastBlockWithError) FromBytes(data []byte) (*GetResponseLast
 
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("GetResponseLastBlockWithError", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -776,14 +962,14 @@ func (g *GetResponseLastBlockWithError) FromBytes(data []byte) (*GetResponseLast
 
 	// Parse block_number (4 bytes)
 	if len(data) < 4 {
-		return nil, fmt.Errorf("insufficient data for block_number")
+		return nil, truncated("GetResponseLastBlockWithError", "block_number", len(source)-len(data))
 	}
 	blockNumber := binary.BigEndian.Uint32(data[:4])
 	data = data[4:]
 
 	// Parse error
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for error")
+		return nil, truncated("GetResponseLastBlockWithError", "error", len(source)-len(data))
 	}
 	error := enumerations.DataAccessResult(data[0])
 
@@ -793,7 +979,7 @@ func (g *GetResponseLastBlockWithError) FromBytes(data []byte) (*GetResponseLast
 // ToBytes converts GetResponseLastBlockWithError to bytes
 func (g *GetResponseLastBlockWithError) ToBytes() ([]byte, error) {
 	result := []byte{GetResponseTag}
-	result = append(result, byte(enumerations.GetResponseTypeLastBlockWithError))
+	result = append(result, byte(enumerations.GetResponseLastBlockWithError))
 
 	invokeBytes := g.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -806,3 +992,53 @@ func (g *GetResponseLastBlockWithError) ToBytes() ([]byte, error) {
 
 	return result, nil
 }
+
+// String implements fmt.Stringer
+func (g *GetRequestNormal) String() string {
+	return fmt.Sprintf("GetRequestNormal(%s, attribute=%s, access_selection=%v)", g.InvokeIdAndPriority, attributeString(g.CosemAttribute), g.AccessSelection != nil)
+}
+
+// String implements fmt.Stringer
+func (g *GetRequestNext) String() string {
+	return fmt.Sprintf("GetRequestNext(%s, block_number=%d)", g.InvokeIdAndPriority, g.BlockNumber)
+}
+
+// String implements fmt.Stringer
+func (g *GetResponseNormal) String() string {
+	return fmt.Sprintf("GetResponseNormal(%s, data=%x)", g.InvokeIdAndPriority, g.Data)
+}
+
+// String implements fmt.Stringer
+func (g *GetResponseNormalWithError) String() string {
+	return fmt.Sprintf("GetResponseNormalWithError(%s, error=%s)", g.InvokeIdAndPriority, g.Error)
+}
+
+// String implements fmt.Stringer
+func (g *GetResponseWithDataBlock) String() string {
+	return fmt.Sprintf("GetResponseWithDataBlock(%s, last_block=%t, block_number=%d, raw_data=%x)",
+		g.InvokeIdAndPriority, g.LastBlock, g.BlockNumber, g.RawData)
+}
+
+// String implements fmt.Stringer
+func (g *GetRequestWithList) String() string {
+	attributes := make([]string, len(g.Attributes))
+	for i, attribute := range g.Attributes {
+		attributes[i] = attributeString(attribute)
+	}
+	return fmt.Sprintf("GetRequestWithList(%s, attributes=%v)", g.InvokeIdAndPriority, attributes)
+}
+
+// String implements fmt.Stringer
+func (g *GetResponseWithList) String() string {
+	return fmt.Sprintf("GetResponseWithList(%s, results=%d)", g.InvokeIdAndPriority, len(g.Results))
+}
+
+// String implements fmt.Stringer
+func (g *GetResponseLastBlock) String() string {
+	return fmt.Sprintf("GetResponseLastBlock(%s, block_number=%d, raw_data=%x)", g.InvokeIdAndPriority, g.BlockNumber, g.RawData)
+}
+
+// String implements fmt.Stringer
+func (g *GetResponseLastBlockWithError) String() string {
+	return fmt.Sprintf("GetResponseLastBlockWithError(%s, block_number=%d, error=%s)", g.InvokeIdAndPriority, g.BlockNumber, g.Error)
+}
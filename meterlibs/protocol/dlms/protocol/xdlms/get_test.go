@@ -0,0 +1,178 @@
+package xdlms_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/cosem"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+func TestGetRequestNormal_AccessSelectionErrors(t *testing.T) {
+	clock := cosem.NewCosemAttribute(enumerations.CosemInterfaceClock, &cosem.Obis{A: 0, B: 0, C: 1, D: 0, E: 0, F: 255}, 2)
+	buffer := cosem.NewCosemAttribute(enumerations.CosemInterfaceProfileGeneric, &cosem.Obis{A: 1, B: 0, C: 99, D: 1, E: 0, F: 255}, 2)
+	invokeID, err := xdlms.NewInvokeIdAndPriority(1, true, true)
+	require.NoError(t, err)
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	selection := cosem.NewRangeDescriptor(cosem.NewCaptureObject(clock, 0), from, from.Add(time.Hour),
+		[]*cosem.CaptureObject{cosem.NewCaptureObject(clock, 0)})
+	_, err = xdlms.NewGetRequestNormal(buffer, invokeID, selection).ToBytes()
+	assert.Error(t, err)
+
+	_, err = xdlms.NewGetRequestNormal(buffer, invokeID, "entries 1 to 10").ToBytes()
+	assert.Error(t, err)
+
+	entries, err := cosem.NewEntryDescriptor(1, 10, 1, 0)
+	require.NoError(t, err)
+	_, err = xdlms.NewGetRequestNormal(buffer, invokeID, entries).ToBytes()
+	assert.NoError(t, err)
+}
+
+func TestGetRequestNormal_Fingerprint(t *testing.T) {
+	buffer := cosem.NewCosemAttribute(enumerations.CosemInterfaceProfileGeneric, &cosem.Obis{A: 1, B: 0, C: 99, D: 1, E: 0, F: 255}, 2)
+	first, err := xdlms.NewInvokeIdAndPriority(1, true, true)
+	require.NoError(t, err)
+	second, err := xdlms.NewInvokeIdAndPriority(2, true, true)
+	require.NoError(t, err)
+	entries, err := cosem.NewEntryDescriptor(1, 10, 1, 0)
+	require.NoError(t, err)
+	otherEntries, err := cosem.NewEntryDescriptor(11, 20, 1, 0)
+	require.NoError(t, err)
+
+	request := xdlms.NewGetRequestNormal(buffer, first, entries)
+	same := xdlms.NewGetRequestNormal(buffer, second, entries)
+	other := xdlms.NewGetRequestNormal(buffer, first, otherEntries)
+	whole := xdlms.NewGetRequestNormal(buffer, first, nil)
+
+	assert.True(t, request.Equal(same))
+	assert.False(t, request.Equal(other))
+	assert.False(t, request.Equal(whole))
+	assert.False(t, request.Equal(nil))
+
+	fingerprint, err := request.Fingerprint()
+	require.NoError(t, err)
+	assert.Equal(t, "00070100630100ff02010202040600000001060000000a120001120000", fingerprint)
+	sameFingerprint, err := same.Fingerprint()
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, sameFingerprint)
+	otherFingerprint, err := other.Fingerprint()
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, otherFingerprint)
+
+	_, err = xdlms.NewGetRequestNormal(buffer, first, "entries 1 to 10").Fingerprint()
+	assert.Error(t, err)
+}
+
+func TestGetResponseWithList(t *testing.T) {
+	encoded := []byte{0xC4, 0x03, 0xC1, 0x03, 0x00, 0x02, 0x02, 0x11, 0x01, 0x09, 0x01, 0xFF, 0x01, 0x03, 0x00, 0x00}
+	response, err := (&xdlms.GetResponseWithList{}).FromBytes(encoded)
+	require.NoError(t, err)
+	require.Len(t, response.Results, 3)
+
+	value, err := response.Results[0].DecodedData()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{uint8(1), []byte{0xFF}}, value)
+	assert.Equal(t, enumerations.DataAccessReadWriteDenied, response.Results[1].Error())
+	assert.True(t, response.Results[1].IsError())
+	// null-data
+	assert.Equal(t, []byte{0x00}, response.Results[2].Data())
+	assert.False(t, response.Results[2].IsError())
+
+	reencoded, err := response.ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, encoded, reencoded)
+
+	_, err = (&xdlms.GetResponseWithList{}).FromBytes(encoded[:len(encoded)-1])
+	assert.Error(t, err)
+	_, err = (&xdlms.GetResponseWithList{}).FromBytes(append(encoded, 0x00))
+	assert.Error(t, err)
+}
+
+func TestGetResponse_TruncatedFieldOffset(t *testing.T) {
+	// a data block cut in the block number
+	_, err := xdlms.GetResponseFromBytes([]byte{0xC4, 0x02, 0xC1, 0x00, 0x00, 0x00})
+	var parseError *exceptions.ApduParseError
+	require.ErrorAs(t, err, &parseError)
+	assert.Equal(t, "GetResponseWithDataBlock", parseError.Apdu)
+	assert.Equal(t, "block_number", parseError.Field)
+	assert.Equal(t, 4, parseError.Offset)
+	assert.ErrorIs(t, err, exceptions.ErrInsufficientData)
+	assert.Equal(t, "failed to parse GetResponseWithDataBlock: block_number at byte 4: insufficient data", err.Error())
+
+	// the schema APDUs report their fields too
+	_, err = (&xdlms.SetResponseNormal{}).FromBytes([]byte{0xC5, 0x01, 0xC1})
+	require.ErrorAs(t, err, &parseError)
+	assert.Equal(t, "result", parseError.Field)
+	assert.Equal(t, 3, parseError.Offset)
+	assert.ErrorIs(t, err, exceptions.ErrInsufficientData)
+}
+
+func TestGetResponseWithDataBlock_ResultChoice(t *testing.T) {
+	invokeID, err := xdlms.NewInvokeIdAndPriority(1, true, true)
+	require.NoError(t, err)
+
+	// raw-data choice 00 after the block number
+	encoded, err := xdlms.NewGetResponseWithDataBlock(invokeID, false, 1, []byte{0x01, 0x02, 0x03}).ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xC4, 0x02, 0xC1, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x03, 0x01, 0x02, 0x03}, encoded)
+	block, err := (&xdlms.GetResponseWithDataBlock{}).FromBytes(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, block.RawData)
+
+	// data-access-result choice 01
+	_, err = (&xdlms.GetResponseWithDataBlock{}).FromBytes([]byte{0xC4, 0x02, 0xC1, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x03})
+	assert.Error(t, err)
+}
+
+func TestGetResponseBlocks_RawDataLength(t *testing.T) {
+	invokeID, err := xdlms.NewInvokeIdAndPriority(1, true, true)
+	require.NoError(t, err)
+	rawData := bytes.Repeat([]byte{0xAB}, 300)
+
+	encoded, err := xdlms.NewGetResponseWithDataBlock(invokeID, false, 1, rawData).ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x82, 0x01, 0x2C}, encoded[8:12])
+	block, err := (&xdlms.GetResponseWithDataBlock{}).FromBytes(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, rawData, block.RawData)
+
+	encoded, err = xdlms.NewGetResponseLastBlock(invokeID, 2, rawData[:200]).ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x81, 0xC8}, encoded[7:9])
+	last, err := (&xdlms.GetResponseLastBlock{}).FromBytes(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, rawData[:200], last.RawData)
+}
+
+func TestGetResponseBlocks_SingleByteRawDataLength(t *testing.T) {
+	rawData := bytes.Repeat([]byte{0xAB}, 200)
+	withDataBlock := append([]byte{0xC4, 0x02, 0xC1, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xC8}, rawData...)
+	lastBlock := append([]byte{0xC4, 0x04, 0xC1, 0x00, 0x00, 0x00, 0x02, 0xC8}, rawData...)
+
+	for _, encoded := range [][]byte{withDataBlock, lastBlock} {
+		_, err := xdlms.NewXDlmsApduFactory().APDUFromBytes(encoded)
+		assert.Error(t, err)
+
+		factory := xdlms.NewXDlmsApduFactory()
+		factory.Mode = xdlms.ParsingLenient
+		apdu, err := factory.APDUFromBytes(encoded)
+		require.NoError(t, err)
+		switch block := apdu.(type) {
+		case *xdlms.GetResponseWithDataBlock:
+			assert.Equal(t, rawData, block.RawData)
+		case *xdlms.GetResponseLastBlock:
+			assert.Equal(t, rawData, block.RawData)
+		default:
+			t.Fatalf("unexpected APDU %T", apdu)
+		}
+		deviations := factory.Deviations()
+		require.Len(t, deviations, 1)
+		assert.Equal(t, "APDU 0xc4: raw data length 200 in a single byte", deviations[0].String())
+	}
+}
@@ -3,6 +3,8 @@ package xdlms
 import (
 	"encoding/binary"
 	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
 )
 
 // InitiateRequest represents an InitiateRequest APDU
@@ -38,7 +40,7 @@ func NewInitiateRequest(
 ) *InitiateRequest {
 	return &InitiateRequest{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: InitiateRequestTag,
+			tag: InitiateRequestTag,
 		},
 		ProposedConformance:       proposedConformance,
 		ClientMaxReceivePDUSize:   clientMaxReceivePDUSize,
@@ -49,6 +51,44 @@ func NewInitiateRequest(
 	}
 }
 
+// initiateRequestConf is the A-XDR encoding of an InitiateRequest after its tag up
+// to the conformance, which is BER encoded with a length that depends on the sender
+var initiateRequestConf = &encoding.EncodingConf{
+	Attributes: []interface{}{
+		&encoding.Attribute{
+			AttributeName: "dedicated_key",
+			Length:        encoding.VariableLength,
+			Optional:      true,
+			CreateInstance: func(data []byte) (interface{}, error) {
+				return append([]byte{}, data...), nil
+			},
+		},
+		&encoding.Attribute{
+			AttributeName: "response_allowed",
+			Length:        1,
+			Default:       true,
+			CreateInstance: func(data []byte) (interface{}, error) {
+				return data[0] != 0, nil
+			},
+		},
+		&encoding.Attribute{
+			AttributeName: "proposed_quality_of_service",
+			Length:        1,
+			Optional:      true,
+			CreateInstance: func(data []byte) (interface{}, error) {
+				return int(int8(data[0])), nil
+			},
+		},
+		&encoding.Attribute{
+			AttributeName: "proposed_dlms_version_number",
+			Length:        1,
+			CreateInstance: func(data []byte) (interface{}, error) {
+				return data[0], nil
+			},
+		},
+	},
+}
+
 // FromBytes creates InitiateRequest from bytes
 func (i *InitiateRequest) FromBytes(data []byte) (*InitiateRequest, error) {
 	if len(data) == 0 {
@@ -60,74 +100,25 @@ func (i *InitiateRequest) FromBytes(data []byte) (*InitiateRequest, error) {
 		return nil, fmt.Errorf("data is not an InitiateRequest APDU, got apdu tag %d", apduTag)
 	}
 
-	data = data[1:]
-
-	// Parse dedicated key (optional)
-	var dedicatedKey []byte
-	if len(data) > 0 && data[0] == 0x01 {
-		data = data[1:]
-		if len(data) == 0 {
-			return nil, fmt.Errorf("insufficient data for dedicated key length")
-		}
-		keyLength := int(data[0])
-		data = data[1:]
-		if len(data) < keyLength {
-			return nil, fmt.Errorf("insufficient data for dedicated key")
-		}
-		dedicatedKey = make([]byte, keyLength)
-		copy(dedicatedKey, data[:keyLength])
-		data = data[keyLength:]
-	} else if len(data) > 0 && data[0] == 0x00 {
-		data = data[1:]
-	}
-
-	// Parse response_allowed (default True, encoded as 0x00 for default)
-	responseAllowed := true
-	if len(data) > 0 && data[0] == 0x01 {
-		data = data[1:]
-		if len(data) > 0 {
-			responseAllowed = data[0] != 0
-			data = data[1:]
-		}
-	} else if len(data) > 0 && data[0] == 0x00 {
-		data = data[1:]
-	}
-
-	// Parse proposed_quality_of_service (optional)
-	var qualityOfService *int
-	if len(data) > 0 && data[0] == 0x01 {
-		data = data[1:]
-		if len(data) > 0 {
-			qos := int(int8(data[0]))
-			qualityOfService = &qos
-			data = data[1:]
-		}
-	} else if len(data) > 0 && data[0] == 0x00 {
-		data = data[1:]
-	}
-
-	// Parse proposed_dlms_version_number
-	if len(data) == 0 {
-		return nil, fmt.Errorf("insufficient data for dlms version number")
+	decoder := encoding.NewAXdrDecoder(initiateRequestConf)
+	fields, err := decoder.Decode(data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse InitiateRequest: %w", err)
 	}
-	dlmsVersion := data[0]
-	data = data[1:]
+	data = decoder.GetBufferTail()
 
-	// Parse conformance (BER encoded)
+	// Parse proposed_conformance, [APPLICATION 31] IMPLICIT BIT STRING
 	if len(data) < 3 {
 		return nil, fmt.Errorf("insufficient data for conformance tag")
 	}
-	conformanceTag := data[:2]
-	if string(conformanceTag) != "\x5f\x1f" {
-		return nil, fmt.Errorf("didn't receive conformance tag correctly, got %v", conformanceTag)
+	if data[0] != 0x5F || data[1] != 0x1F {
+		return nil, fmt.Errorf("didn't receive conformance tag correctly, got %x", data[:2])
 	}
-	conformanceLength := data[2]
-	if len(data) < int(conformanceLength)+3 {
+	conformanceLength := int(data[2])
+	if len(data) < conformanceLength+3 {
 		return nil, fmt.Errorf("insufficient data for conformance")
 	}
-	// conformanceLength byte is at data[2], actual conformance data starts at data[3]
-	conformanceData := data[3 : 3+conformanceLength]
-	conformance, err := (&Conformance{}).FromBytes(conformanceData)
+	conformance, err := (&Conformance{}).FromBytes(data[3 : 3+conformanceLength])
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse conformance: %w", err)
 	}
@@ -139,11 +130,16 @@ func (i *InitiateRequest) FromBytes(data []byte) (*InitiateRequest, error) {
 	}
 	maxPDUSize := binary.BigEndian.Uint16(data[:2])
 
+	dedicatedKey, _ := fields["dedicated_key"].([]byte)
+	var qualityOfService *int
+	if qos, ok := fields["proposed_quality_of_service"].(int); ok {
+		qualityOfService = &qos
+	}
 	return NewInitiateRequest(
 		conformance,
 		maxPDUSize,
-		dlmsVersion,
-		responseAllowed,
+		fields["proposed_dlms_version_number"].(uint8),
+		fields["response_allowed"].(bool),
 		dedicatedKey,
 		qualityOfService,
 	), nil
@@ -163,16 +159,25 @@ func (i *InitiateRequest) ToBytes() ([]byte, error) {
 	}
 
 	// Response allowed (default True, encoded as 0x00)
-	result = append(result, 0x00)
+	if i.ResponseAllowed {
+		result = append(result, 0x00)
+	} else {
+		result = append(result, 0x01, 0x00)
+	}
 
-	// Proposed quality of service (optional, skip for now)
+	// Proposed quality of service (optional)
+	if i.ProposedQualityOfService != nil {
+		result = append(result, 0x01, byte(*i.ProposedQualityOfService))
+	} else {
+		result = append(result, 0x00)
+	}
 
 	// Proposed DLMS version number
 	result = append(result, i.ProposedDlmsVersionNumber)
 
 	// Conformance (BER encoded)
-	result = append(result, 0x5f, 0x1f, 0x04)
 	conformanceBytes := i.ProposedConformance.ToBytes()
+	result = append(result, 0x5f, 0x1f, byte(len(conformanceBytes)))
 	result = append(result, conformanceBytes...)
 
 	// Client max receive PDU size
@@ -201,7 +206,7 @@ func NewGlobalCipherInitiateRequest(
 ) *GlobalCipherInitiateRequest {
 	return &GlobalCipherInitiateRequest{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GlobalCipherInitiateRequestTag,
+			tag: GlobalCipherInitiateRequestTag,
 		},
 		SecurityControl:   securityControl,
 		InvocationCounter: invocationCounter,
@@ -273,3 +278,19 @@ func (g *GlobalCipherInitiateRequest) ToBytes() ([]byte, error) {
 
 	return result, nil
 }
+
+// String implements fmt.Stringer without exposing the dedicated key
+func (i *InitiateRequest) String() string {
+	dedicatedKey := "none"
+	if i.DedicatedKey != nil {
+		dedicatedKey = Redacted
+	}
+	return fmt.Sprintf("InitiateRequest(conformance=%+v, max_pdu=%d, dlms_version=%d, response_allowed=%t, dedicated_key=%s)",
+		i.ProposedConformance, i.ClientMaxReceivePDUSize, i.ProposedDlmsVersionNumber, i.ResponseAllowed, dedicatedKey)
+}
+
+// String implements fmt.Stringer
+func (g *GlobalCipherInitiateRequest) String() string {
+	return fmt.Sprintf("GlobalCipherInitiateRequest(security_control=%v, invocation_counter=%d, ciphered_text=%x)",
+		g.SecurityControl, g.InvocationCounter, g.CipheredText)
+}
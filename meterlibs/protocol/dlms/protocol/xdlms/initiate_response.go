@@ -21,7 +21,7 @@ type InitiateResponse struct {
 	NegotiatedConformance       *Conformance
 	ServerMaxReceivePDUSize      uint16
 	NegotiatedDlmsVersionNumber  uint8
-	NegotiatedQualityOfService   uint8
+	NegotiatedQualityOfService   *int
 }
 
 // NewInitiateResponse creates a new InitiateResponse
@@ -29,11 +29,11 @@ func NewInitiateResponse(
 	negotiatedConformance *Conformance,
 	serverMaxReceivePDUSize uint16,
 	negotiatedDlmsVersionNumber uint8,
-	negotiatedQualityOfService uint8,
+	negotiatedQualityOfService *int,
 ) *InitiateResponse {
 	return &InitiateResponse{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: InitiateResponseTag,
+			tag: InitiateResponseTag,
 		},
 		NegotiatedConformance:      negotiatedConformance,
 		ServerMaxReceivePDUSize:   serverMaxReceivePDUSize,
@@ -63,11 +63,12 @@ func (i *InitiateResponse) FromBytes(data []byte) (*InitiateResponse, error) {
 	data = data[1:]
 	
 	// Parse negotiated_quality_of_service (optional)
-	qualityOfService := uint8(0)
+	var qualityOfService *int
 	if len(data) > 0 && data[0] == 0x01 {
 		data = data[1:]
 		if len(data) > 0 {
-			qualityOfService = data[0]
+			qos := int(int8(data[0]))
+			qualityOfService = &qos
 			data = data[1:]
 		}
 	} else if len(data) > 0 && data[0] == 0x00 {
@@ -85,17 +86,19 @@ func (i *InitiateResponse) FromBytes(data []byte) (*InitiateResponse, error) {
 	if len(data) < 3 {
 		return nil, fmt.Errorf("insufficient data for conformance tag")
 	}
-	conformanceTagAndLength := data[:3]
-	if string(conformanceTagAndLength) != "\x5f\x1f\x04" {
-		return nil, fmt.Errorf("not correct conformance tag and length: %v", conformanceTagAndLength)
+	conformanceTag := data[:2]
+	if string(conformanceTag) != "\x5f\x1f" {
+		return nil, fmt.Errorf("not correct conformance tag: %v", conformanceTag)
 	}
-	
-	conformanceData := data[3:7] // 4 bytes: unused bits + 3 bytes for conformance
-	conformance, err := (&Conformance{}).FromBytes(conformanceData)
+	conformanceLength := int(data[2])
+	if len(data) < conformanceLength+3 {
+		return nil, fmt.Errorf("insufficient data for conformance")
+	}
+	conformance, err := (&Conformance{}).FromBytes(data[3 : 3+conformanceLength])
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse conformance: %w", err)
 	}
-	data = data[7:]
+	data = data[3+conformanceLength:]
 	
 	// Parse server_max_receive_pdu_size
 	if len(data) < 2 {
@@ -115,15 +118,19 @@ func (i *InitiateResponse) FromBytes(data []byte) (*InitiateResponse, error) {
 func (i *InitiateResponse) ToBytes() ([]byte, error) {
 	result := []byte{InitiateResponseTag}
 	
-	// Negotiated quality of service (optional, skip for now - use 0x00)
-	result = append(result, 0x00)
+	// Negotiated quality of service (optional)
+	if i.NegotiatedQualityOfService != nil {
+		result = append(result, 0x01, byte(*i.NegotiatedQualityOfService))
+	} else {
+		result = append(result, 0x00)
+	}
 	
 	// Negotiated DLMS version number
 	result = append(result, i.NegotiatedDlmsVersionNumber)
 	
 	// Conformance (BER encoded)
-	result = append(result, 0x5f, 0x1f, 0x04)
 	conformanceBytes := i.NegotiatedConformance.ToBytes()
+	result = append(result, 0x5f, 0x1f, byte(len(conformanceBytes)))
 	result = append(result, conformanceBytes...)
 	
 	// Server max receive PDU size
@@ -155,7 +162,7 @@ func NewGlobalCipherInitiateResponse(
 ) *GlobalCipherInitiateResponse {
 	return &GlobalCipherInitiateResponse{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: GlobalCipherInitiateResponseTag,
+			tag: GlobalCipherInitiateResponseTag,
 		},
 		SecurityControl:   securityControl,
 		InvocationCounter: invocationCounter,
@@ -228,3 +235,14 @@ func (g *GlobalCipherInitiateResponse) ToBytes() ([]byte, error) {
 	return result, nil
 }
 
+// String implements fmt.Stringer
+func (i *InitiateResponse) String() string {
+	return fmt.Sprintf("InitiateResponse(conformance=%+v, max_pdu=%d, dlms_version=%d)",
+		i.NegotiatedConformance, i.ServerMaxReceivePDUSize, i.NegotiatedDlmsVersionNumber)
+}
+
+// String implements fmt.Stringer
+func (g *GlobalCipherInitiateResponse) String() string {
+	return fmt.Sprintf("GlobalCipherInitiateResponse(security_control=%v, invocation_counter=%d, ciphered_text=%x)",
+		g.SecurityControl, g.InvocationCounter, g.CipheredText)
+}
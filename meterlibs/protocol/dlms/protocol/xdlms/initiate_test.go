@@ -0,0 +1,109 @@
+package xdlms_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+func TestInitiateRequest_QualityOfService(t *testing.T) {
+	for _, vector := range []string{
+		"01000000065f1f0400001e1dffff",
+		// response not allowed, quality of service 1
+		"010001000101065f1f0400001e1dffff",
+		// dedicated key
+		"0101100102030405060708090a0b0c0d0e0f100000065f1f0400001e1dffff",
+	} {
+		data, err := hex.DecodeString(vector)
+		require.NoError(t, err)
+
+		request, err := (&xdlms.InitiateRequest{}).FromBytes(data)
+		require.NoError(t, err, vector)
+		encoded, err := request.ToBytes()
+		require.NoError(t, err)
+		assert.Equal(t, data, encoded, vector)
+	}
+
+	qos := 1
+	request := xdlms.NewInitiateRequest(xdlms.NewConformance(
+		false, false, false, false, true, false, true, true, true,
+		true, false, false, true, true, true, false, true,
+	), 0xFFFF, 6, true, nil, &qos)
+	encoded, err := request.ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x00, 0x00, 0x01, 0x01, 0x06}, encoded[:6])
+}
+
+func TestInitiateRequest_FromBytesFields(t *testing.T) {
+	data, err := hex.DecodeString("010104AABBCCDD" + "0100" + "0101" + "06" + "5f1f0400001e1d" + "0400")
+	require.NoError(t, err)
+	request, err := (&xdlms.InitiateRequest{}).FromBytes(data)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xAA, 0xBB, 0xCC, 0xDD}, request.DedicatedKey)
+	assert.False(t, request.ResponseAllowed)
+	require.NotNil(t, request.ProposedQualityOfService)
+	assert.Equal(t, 1, *request.ProposedQualityOfService)
+	assert.Equal(t, uint8(6), request.ProposedDlmsVersionNumber)
+	assert.True(t, request.ProposedConformance.Get)
+	assert.Equal(t, uint16(0x0400), request.ClientMaxReceivePDUSize)
+
+	for _, vector := range []string{
+		// conformance tag
+		"01000000065f200400001e1dffff",
+		// truncated max PDU size
+		"01000000065f1f0400001e1dff",
+		// truncated dedicated key
+		"010104AABB",
+	} {
+		data, err := hex.DecodeString(vector)
+		require.NoError(t, err)
+		_, err = (&xdlms.InitiateRequest{}).FromBytes(data)
+		assert.Error(t, err, vector)
+	}
+}
+
+func TestInitiateRequest_ConformanceLength(t *testing.T) {
+	// conformance with a fourth byte, as accepted in the InitiateResponse
+	data, err := hex.DecodeString("01000000065f1f050000101d00ffff")
+	require.NoError(t, err)
+	request, err := (&xdlms.InitiateRequest{}).FromBytes(data)
+	require.NoError(t, err)
+	assert.True(t, request.ProposedConformance.Get)
+	assert.True(t, request.ProposedConformance.Set)
+	assert.Equal(t, uint16(0xFFFF), request.ClientMaxReceivePDUSize)
+
+	// truncated conformance
+	data, err = hex.DecodeString("01000000065f1f050000101d")
+	require.NoError(t, err)
+	_, err = (&xdlms.InitiateRequest{}).FromBytes(data)
+	assert.Error(t, err)
+}
+
+func TestInitiateResponse_QualityOfService(t *testing.T) {
+	for _, vector := range []string{
+		"0800065f1f040000101d04000007",
+		"080101065f1f040000101d04000007",
+		// conformance with a fourth byte
+		"0800065f1f050000101d0004000007",
+	} {
+		data, err := hex.DecodeString(vector)
+		require.NoError(t, err)
+
+		response, err := (&xdlms.InitiateResponse{}).FromBytes(data)
+		require.NoError(t, err, vector)
+		encoded, err := response.ToBytes()
+		require.NoError(t, err)
+		assert.Equal(t, data, encoded, vector)
+	}
+
+	response, err := (&xdlms.InitiateResponse{}).FromBytes([]byte{0x08, 0x01, 0x01, 0x06, 0x5F, 0x1F, 0x04, 0x00, 0x00, 0x10, 0x1D, 0x04, 0x00, 0x00, 0x07})
+	require.NoError(t, err)
+	require.NotNil(t, response.NegotiatedQualityOfService)
+	assert.Equal(t, 1, *response.NegotiatedQualityOfService)
+
+	_, err = (&xdlms.InitiateResponse{}).FromBytes([]byte{0x08, 0x00, 0x06, 0x5F, 0x1F, 0x04, 0x00, 0x07})
+	assert.Error(t, err)
+}
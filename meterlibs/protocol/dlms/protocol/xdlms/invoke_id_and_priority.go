@@ -61,3 +61,10 @@ func (i *InvokeIdAndPriority) ToBytes() []byte {
 	return []byte{out}
 }
 
+// String implements fmt.Stringer
+func (i *InvokeIdAndPriority) String() string {
+	if i == nil {
+		return "invoke_id=none"
+	}
+	return fmt.Sprintf("invoke_id=%d", i.InvokeID)
+}
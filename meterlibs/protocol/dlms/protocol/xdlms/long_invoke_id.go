@@ -0,0 +1,80 @@
+package xdlms
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MaxLongInvokeID is the largest long invoke id, it is 24 bits
+const MaxLongInvokeID = 1<<24 - 1
+
+// LongInvokeIDCounter gives the long invoke ids of the notifications a server
+// or an emitter pushes. The ids increase by one and roll over to 0 after
+// MaxLongInvokeID. It is safe for concurrent use.
+type LongInvokeIDCounter struct {
+	mutex sync.Mutex
+	next  uint32
+}
+
+// NewLongInvokeIDCounter creates a new LongInvokeIDCounter starting at the
+// given id, for example the last id sent before a restart plus one
+func NewLongInvokeIDCounter(start uint32) (*LongInvokeIDCounter, error) {
+	if start > MaxLongInvokeID {
+		return nil, fmt.Errorf("long invoke id must be between 0-%d, got %d", MaxLongInvokeID, start)
+	}
+	return &LongInvokeIDCounter{next: start}, nil
+}
+
+// Next returns a LongInvokeIdAndPriority with the next id and the given flags
+func (c *LongInvokeIDCounter) Next(prioritized, confirmed, selfDescriptive, breakOnError bool) *LongInvokeIdAndPriority {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id := c.next
+	c.next = (c.next + 1) & MaxLongInvokeID
+	return NewLongInvokeIdAndPriority(id, prioritized, confirmed, selfDescriptive, breakOnError)
+}
+
+// LongInvokeIDFilter recognizes notifications received again, for example
+// when a server repeats a push it didn't get an acknowledgement for. It
+// remembers the last Window long invoke ids, so it keeps working when the
+// ids roll over. It is safe for concurrent use.
+type LongInvokeIDFilter struct {
+	mutex  sync.Mutex
+	recent []uint32
+	seen   map[uint32]struct{}
+	oldest int
+}
+
+// NewLongInvokeIDFilter creates a new LongInvokeIDFilter remembering the
+// given number of ids
+func NewLongInvokeIDFilter(window int) (*LongInvokeIDFilter, error) {
+	if window <= 0 || window > MaxLongInvokeID {
+		return nil, fmt.Errorf("window must be between 1-%d, got %d", MaxLongInvokeID, window)
+	}
+	return &LongInvokeIDFilter{
+		recent: make([]uint32, 0, window),
+		seen:   make(map[uint32]struct{}, window),
+	}, nil
+}
+
+// Accept returns true the first time a long invoke id is received and false
+// when it is one of the last ids of the window
+func (f *LongInvokeIDFilter) Accept(longInvokeID uint32) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.seen[longInvokeID]; ok {
+		return false
+	}
+
+	if len(f.recent) < cap(f.recent) {
+		f.recent = append(f.recent, longInvokeID)
+	} else {
+		delete(f.seen, f.recent[f.oldest])
+		f.recent[f.oldest] = longInvokeID
+		f.oldest = (f.oldest + 1) % len(f.recent)
+	}
+	f.seen[longInvokeID] = struct{}{}
+	return true
+}
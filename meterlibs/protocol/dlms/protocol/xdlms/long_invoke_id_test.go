@@ -0,0 +1,42 @@
+package xdlms_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+func TestLongInvokeIDCounter_RollsOver(t *testing.T) {
+	counter, err := xdlms.NewLongInvokeIDCounter(xdlms.MaxLongInvokeID - 1)
+	require.NoError(t, err)
+
+	first := counter.Next(false, true, false, false)
+	assert.Equal(t, uint32(0xFFFFFE), first.LongInvokeID)
+	assert.True(t, first.Confirmed)
+	assert.Equal(t, []byte{0x40, 0xFF, 0xFF, 0xFE}, first.ToBytes())
+	assert.Equal(t, uint32(0xFFFFFF), counter.Next(false, false, false, false).LongInvokeID)
+	assert.Equal(t, uint32(0), counter.Next(false, false, false, false).LongInvokeID)
+	assert.Equal(t, uint32(1), counter.Next(false, false, false, false).LongInvokeID)
+
+	_, err = xdlms.NewLongInvokeIDCounter(xdlms.MaxLongInvokeID + 1)
+	assert.Error(t, err)
+}
+
+func TestLongInvokeIDFilter(t *testing.T) {
+	filter, err := xdlms.NewLongInvokeIDFilter(3)
+	require.NoError(t, err)
+
+	for _, id := range []uint32{0xFFFFFE, 0xFFFFFF, 0} {
+		assert.True(t, filter.Accept(id))
+	}
+	assert.False(t, filter.Accept(0xFFFFFF))
+	assert.True(t, filter.Accept(1))
+	// 0xFFFFFE left the window
+	assert.True(t, filter.Accept(0xFFFFFE))
+	assert.False(t, filter.Accept(1))
+
+	_, err = xdlms.NewLongInvokeIDFilter(0)
+	assert.Error(t, err)
+}
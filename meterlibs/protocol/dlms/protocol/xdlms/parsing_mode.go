@@ -0,0 +1,118 @@
+package xdlms
+
+import (
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+)
+
+// ParsingMode tells how the APDUs that don't conform to the standard are
+// handled: conformance tests reject them, field collection tolerates the
+// known deviations of the meters
+type ParsingMode int
+
+const (
+	// ParsingStrict rejects the non-conformant APDUs
+	ParsingStrict ParsingMode = iota
+	// ParsingLenient repairs the APDUs with a known deviation and records it
+	ParsingLenient
+)
+
+// String implements fmt.Stringer
+func (m ParsingMode) String() string {
+	switch m {
+	case ParsingStrict:
+		return "strict"
+	case ParsingLenient:
+		return "lenient"
+	default:
+		return fmt.Sprintf("ParsingMode(%d)", int(m))
+	}
+}
+
+// DefaultParsingMode is the parsing mode of the new client settings
+var DefaultParsingMode = ParsingStrict
+
+// Deviation is a non-conformance tolerated in lenient mode
+type Deviation struct {
+	// Tag is the tag of the APDU
+	Tag         uint8
+	Description string
+}
+
+// String implements fmt.Stringer
+func (d *Deviation) String() string {
+	return fmt.Sprintf("APDU 0x%02x: %s", d.Tag, d.Description)
+}
+
+// repair returns the APDU without a known deviation and describes it, ok is
+// false when the APDU doesn't have the deviation
+type repair func(apdu []byte) (repaired []byte, description string, ok bool)
+
+// repairs are tried in order on the APDUs that fail to parse in lenient mode
+var repairs = []repair{
+	trailingBytesAfterACSE,
+	singleByteRawDataLength,
+}
+
+// trailingBytesAfterACSE removes the padding some meters send after the
+// BER encoded ACSE APDUs, e.g. after the AARE
+func trailingBytesAfterACSE(apdu []byte) ([]byte, string, bool) {
+	if len(apdu) == 0 || apdu[0] < 0x60 || apdu[0] > 0x63 {
+		return nil, "", false
+	}
+	_, _, rest, err := encoding.NewBER().NextTLV(apdu)
+	if err != nil || len(rest) == 0 {
+		return nil, "", false
+	}
+	return apdu[:len(apdu)-len(rest)], fmt.Sprintf("%d bytes after the end of the APDU", len(rest)), true
+}
+
+// singleByteRawDataLength encodes in A-XDR the length of the raw data of a
+// GET response block given in a single byte, as older encoders did for the
+// blocks of 128 to 255 bytes. The byte must be the length of the rest of the
+// APDU.
+func singleByteRawDataLength(apdu []byte) ([]byte, string, bool) {
+	if len(apdu) < 2 || apdu[0] != GetResponseTag {
+		return nil, "", false
+	}
+	var position int
+	switch enumerations.GetResponseType(apdu[1]) {
+	case enumerations.GetResponseWithBlock:
+		// invoke-id, last-block, block-number, raw-data choice
+		position = 9
+		if len(apdu) > position && apdu[position-1] != 0 {
+			return nil, "", false
+		}
+	case enumerations.GetResponseLastBlock:
+		// invoke-id, block-number
+		position = 7
+	default:
+		return nil, "", false
+	}
+	if len(apdu) <= position || apdu[position] < 0x80 || int(apdu[position]) != len(apdu)-position-1 {
+		return nil, "", false
+	}
+
+	repaired := append([]byte{}, apdu[:position]...)
+	repaired = append(repaired, dlmsdata.EncodeVariableInteger(int(apdu[position]))...)
+	repaired = append(repaired, apdu[position+1:]...)
+	return repaired, fmt.Sprintf("raw data length %d in a single byte", apdu[position]), true
+}
+
+// Deviations returns the deviations tolerated since the factory was created
+func (f *XDlmsApduFactory) Deviations() []*Deviation {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]*Deviation{}, f.deviations...)
+}
+
+func (f *XDlmsApduFactory) record(deviation *Deviation) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.deviations = append(f.deviations, deviation)
+}
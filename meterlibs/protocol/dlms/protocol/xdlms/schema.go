@@ -0,0 +1,193 @@
+package xdlms
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/dlmsdata"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+)
+
+// apduSchema describes the fields of an APDU with a fixed layout. FromBytes
+// and ToBytes of the APDU both use the schema, so the two directions can't
+// disagree on the order, the size or the presence of a field.
+type apduSchema[T any] struct {
+	name   string
+	fields []schemaField[T]
+}
+
+// schemaField is a field of an apduSchema. decode reads the field into the
+// APDU, encode appends the field of the APDU to the result.
+type schemaField[T any] struct {
+	name   string
+	decode func(r *schemaReader, apdu *T) error
+	encode func(apdu *T, result []byte) []byte
+}
+
+// schemaReader reads the fields of an APDU in order
+type schemaReader struct {
+	data     []byte
+	position int
+}
+
+func (r *schemaReader) read(length int) ([]byte, error) {
+	if len(r.data)-r.position < length {
+		return nil, fmt.Errorf("%w, need %d bytes, got %d", exceptions.ErrInsufficientData, length, len(r.data)-r.position)
+	}
+	value := r.data[r.position : r.position+length]
+	r.position += length
+	return value, nil
+}
+
+// truncated is the error of an APDU ending before one of its fields, offset
+// is the position of the field in the APDU
+func truncated(apdu string, field string, offset int) error {
+	return exceptions.NewApduParseError(apdu, field, offset, exceptions.ErrInsufficientData)
+}
+
+// decode reads all the fields of the APDU, the data must not be longer than
+// the APDU. The errors are an *exceptions.ApduParseError.
+func (s *apduSchema[T]) decode(data []byte, apdu *T) error {
+	r := &schemaReader{data: data}
+	for _, field := range s.fields {
+		offset := r.position
+		if err := field.decode(r, apdu); err != nil {
+			return exceptions.NewApduParseError(s.name, field.name, offset, err)
+		}
+	}
+	if r.position != len(data) {
+		return exceptions.NewApduParseError(s.name, "end", r.position,
+			fmt.Errorf("%s is %d bytes long, received: %d", s.name, r.position, len(data)))
+	}
+	return nil
+}
+
+// encode appends all the fields of the APDU
+func (s *apduSchema[T]) encode(apdu *T) []byte {
+	var result []byte
+	for _, field := range s.fields {
+		result = field.encode(apdu, result)
+	}
+	return result
+}
+
+// constField is a byte with a fixed value, like the tag or the choice of an APDU
+func constField[T any](name string, value uint8) schemaField[T] {
+	return schemaField[T]{
+		name: name,
+		decode: func(r *schemaReader, _ *T) error {
+			b, err := r.read(1)
+			if err != nil {
+				return err
+			}
+			if b[0] != value {
+				return fmt.Errorf("expected %d, got %d", value, b[0])
+			}
+			return nil
+		},
+		encode: func(_ *T, result []byte) []byte {
+			return append(result, value)
+		},
+	}
+}
+
+// uint8Field is an Unsigned8 or an enum, at gives the field of the APDU
+func uint8Field[T any, V ~uint8](name string, at func(apdu *T) *V) schemaField[T] {
+	return schemaField[T]{
+		name: name,
+		decode: func(r *schemaReader, apdu *T) error {
+			b, err := r.read(1)
+			if err != nil {
+				return err
+			}
+			*at(apdu) = V(b[0])
+			return nil
+		},
+		encode: func(apdu *T, result []byte) []byte {
+			return append(result, uint8(*at(apdu)))
+		},
+	}
+}
+
+// uint16Field is an Unsigned16, big endian
+func uint16Field[T any, V ~uint16](name string, at func(apdu *T) *V) schemaField[T] {
+	return schemaField[T]{
+		name: name,
+		decode: func(r *schemaReader, apdu *T) error {
+			b, err := r.read(2)
+			if err != nil {
+				return err
+			}
+			*at(apdu) = V(binary.BigEndian.Uint16(b))
+			return nil
+		},
+		encode: func(apdu *T, result []byte) []byte {
+			return binary.BigEndian.AppendUint16(result, uint16(*at(apdu)))
+		},
+	}
+}
+
+// octetStringField is an OCTET STRING with a variable length prefix
+func octetStringField[T any](name string, at func(apdu *T) *[]byte) schemaField[T] {
+	return schemaField[T]{
+		name: name,
+		decode: func(r *schemaReader, apdu *T) error {
+			length, rest, err := dlmsdata.DecodeVariableInteger(r.data[r.position:])
+			if err != nil {
+				return err
+			}
+			r.position = len(r.data) - len(rest)
+			b, err := r.read(length)
+			if err != nil {
+				return err
+			}
+			*at(apdu) = append([]byte{}, b...)
+			return nil
+		},
+		encode: func(apdu *T, result []byte) []byte {
+			result = append(result, dlmsdata.EncodeVariableInteger(len(*at(apdu)))...)
+			return append(result, *at(apdu)...)
+		},
+	}
+}
+
+// uint32Field is an Unsigned32, big endian
+func uint32Field[T any, V ~uint32](name string, at func(apdu *T) *V) schemaField[T] {
+	return schemaField[T]{
+		name: name,
+		decode: func(r *schemaReader, apdu *T) error {
+			b, err := r.read(4)
+			if err != nil {
+				return err
+			}
+			*at(apdu) = V(binary.BigEndian.Uint32(b))
+			return nil
+		},
+		encode: func(apdu *T, result []byte) []byte {
+			return binary.BigEndian.AppendUint32(result, uint32(*at(apdu)))
+		},
+	}
+}
+
+// invokeIDField is an Invoke-Id-And-Priority
+func invokeIDField[T any](at func(apdu *T) **InvokeIdAndPriority) schemaField[T] {
+	const name = "invoke_id_and_priority"
+	return schemaField[T]{
+		name: name,
+		decode: func(r *schemaReader, apdu *T) error {
+			b, err := r.read(InvokeIdAndPriorityLength)
+			if err != nil {
+				return err
+			}
+			invokeIDAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(b)
+			if err != nil {
+				return err
+			}
+			*at(apdu) = invokeIDAndPriority
+			return nil
+		},
+		encode: func(apdu *T, result []byte) []byte {
+			return append(result, (*at(apdu)).ToBytes()...)
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package xdlms_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// schemaVectors are APDUs encoded and decoded with a schema, each one is
+// decoded and encoded again to the same bytes
+var schemaVectors = []struct {
+	name   string
+	hex    string
+	decode func([]byte) (xdlms.Apdu, error)
+}{
+	{"SetResponseNormal", "c501c100", func(data []byte) (xdlms.Apdu, error) {
+		return (&xdlms.SetResponseNormal{}).FromBytes(data)
+	}},
+	{"SetResponseNormal read-write denied", "c501c103", func(data []byte) (xdlms.Apdu, error) {
+		return (&xdlms.SetResponseNormal{}).FromBytes(data)
+	}},
+	{"ActionResponseNormal", "c701c10000", func(data []byte) (xdlms.Apdu, error) {
+		return (&xdlms.ActionResponseNormal{}).FromBytes(data)
+	}},
+	{"ActionRequestNextPBlock", "c302c100000002", func(data []byte) (xdlms.Apdu, error) {
+		return (&xdlms.ActionRequestNextPBlock{}).FromBytes(data)
+	}},
+	{"ConfirmedServiceError", "0e010601", func(data []byte) (xdlms.Apdu, error) {
+		return (&xdlms.ConfirmedServiceError{}).FromBytes(data)
+	}},
+}
+
+func TestSchema_RoundTrip(t *testing.T) {
+	for _, vector := range schemaVectors {
+		t.Run(vector.name, func(t *testing.T) {
+			data, err := hex.DecodeString(vector.hex)
+			require.NoError(t, err)
+
+			apdu, err := vector.decode(data)
+			require.NoError(t, err)
+			encoded, err := apdu.ToBytes()
+			require.NoError(t, err)
+			assert.Equal(t, data, encoded)
+
+			// a missing or an extra byte is an error
+			_, err = vector.decode(data[:len(data)-1])
+			assert.Error(t, err)
+			_, err = vector.decode(append(data, 0x00))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSchema_Fields(t *testing.T) {
+	invokeID, err := xdlms.NewInvokeIdAndPriority(1, true, true)
+	require.NoError(t, err)
+
+	request := xdlms.NewActionRequestNextPBlock(0x01020304, invokeID)
+	encoded, err := request.ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xC3, 0x02, 0xC1, 0x01, 0x02, 0x03, 0x04}, encoded)
+
+	decoded, err := (&xdlms.ActionRequestNextPBlock{}).FromBytes(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x01020304), decoded.BlockNumber)
+	assert.Equal(t, uint8(1), decoded.InvokeIdAndPriority.InvokeID)
+	assert.Equal(t, uint8(xdlms.ActionRequestTag), decoded.Tag())
+
+	serviceError, err := (&xdlms.ConfirmedServiceError{}).FromBytes([]byte{0x0E, 0x01, 0x06, 0x01})
+	require.NoError(t, err)
+	assert.Equal(t, enumerations.ServiceErrorType(6), serviceError.ErrorType)
+	assert.Equal(t, uint8(1), serviceError.Value)
+
+	// the action response has return parameters
+	_, err = (&xdlms.ActionResponseNormal{}).FromBytes([]byte{0xC7, 0x01, 0xC1, 0x00, 0x01})
+	assert.Error(t, err)
+	// wrong tag
+	_, err = (&xdlms.SetResponseNormal{}).FromBytes([]byte{0xC4, 0x01, 0xC1, 0x00})
+	assert.Error(t, err)
+}
+
+func TestConfirmedServiceError_Err(t *testing.T) {
+	var associationError *exceptions.ApplicationAssociationError
+	var communicationError *exceptions.CommunicationError
+	var decryptionError *exceptions.DecryptionError
+	var accessRightsError *exceptions.AccessRightsError
+	var conformanceError *exceptions.ConformanceError
+	var clientException *exceptions.DlmsClientException
+
+	for _, test := range []struct {
+		errorType enumerations.ServiceErrorType
+		value     uint8
+		target    interface{}
+	}{
+		{enumerations.ServiceErrorTypeInitiate, uint8(enumerations.InitiateErrorDlmsVersionTooLow), &associationError},
+		{enumerations.ServiceErrorTypeApplicationReference, uint8(enumerations.ApplicationReferenceErrorTimeElapsed), &communicationError},
+		{enumerations.ServiceErrorTypeApplicationReference, uint8(enumerations.ApplicationReferenceErrorDecipheringError), &decryptionError},
+		{enumerations.ServiceErrorTypeAccess, uint8(enumerations.AccessErrorObjectAccessViolated), &accessRightsError},
+		{enumerations.ServiceErrorTypeService, uint8(enumerations.ServiceErrorServiceUnsupported), &conformanceError},
+		{enumerations.ServiceErrorTypeHardwareResource, uint8(enumerations.HardwareResourceErrorMemoryUnavailable), &clientException},
+	} {
+		err := xdlms.NewConfirmedServiceError(enumerations.ConfirmedServiceErrorRead, test.errorType, test.value).Err()
+		assert.ErrorAs(t, err, test.target, "error type %d value %d", test.errorType, test.value)
+	}
+}
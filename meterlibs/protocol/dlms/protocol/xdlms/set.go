@@ -34,7 +34,7 @@ func NewSetRequestNormal(
 ) *SetRequestNormal {
 	return &SetRequestNormal{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: SetRequestTag,
+			tag: SetRequestTag,
 		},
 		CosemAttribute:      cosemAttribute,
 		Data:                data,
@@ -45,8 +45,9 @@ func NewSetRequestNormal(
 
 // FromBytes creates SetRequestNormal from bytes
 func (s *SetRequestNormal) FromBytes(data []byte) (*SetRequestNormal, error) {
+	source := data
 	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for SetRequest")
+		return nil, truncated("SetRequestNormal", "header", 0)
 	}
 	
 	tag := data[0]
@@ -55,7 +56,7 @@ func (s *SetRequestNormal) FromBytes(data []byte) (*SetRequestNormal, error) {
 	}
 	
 	typeChoice := enumerations.SetRequestType(data[1])
-	if typeChoice != enumerations.SetRequestTypeNormal {
+	if typeChoice != enumerations.SetRequestNormal {
 		return nil, fmt.Errorf("the type of the SetRequest is not for a SetRequestNormal")
 	}
 	
@@ -63,7 +64,7 @@ func (s *SetRequestNormal) FromBytes(data []byte) (*SetRequestNormal, error) {
 	
 	// Parse invoke_id_and_priority
 	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
+		return nil, truncated("SetRequestNormal", "invoke_id_and_priority", len(source)-len(data))
 	}
 	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
 	if err != nil {
@@ -73,7 +74,7 @@ func (s *SetRequestNormal) FromBytes(data []byte) (*SetRequestNormal, error) {
 	
 	// Parse cosem_attribute (9 bytes)
 	if len(data) < 9 {
-		return nil, fmt.Errorf("insufficient data for cosem_attribute")
+		return nil, truncated("SetRequestNormal", "cosem_attribute", len(source)-len(data))
 	}
 	cosemAttribute, err := (&cosem.CosemAttribute{}).FromBytes(data[:9])
 	if err != nil {
@@ -90,7 +91,7 @@ func (s *SetRequestNormal) FromBytes(data []byte) (*SetRequestNormal, error) {
 			// Parse access descriptor using factory
 			// Note: FromBytes will validate data length internally, but we check here for clarity
 			if len(data) == 0 {
-				return nil, fmt.Errorf("insufficient data for access descriptor: expected access descriptor data, got empty buffer")
+				return nil, truncated("SetRequestNormal", "access_selection", len(source)-len(data))
 			}
 			factory := cosem.NewAccessDescriptorFactory()
 			parsedAccess, bytesConsumed, err := factory.FromBytes(data)
@@ -105,7 +106,7 @@ func (s *SetRequestNormal) FromBytes(data []byte) (*SetRequestNormal, error) {
 				return nil, fmt.Errorf("invalid bytes consumed: %d", bytesConsumed)
 			}
 			if len(data) < bytesConsumed {
-				return nil, fmt.Errorf("insufficient data for access descriptor: need %d bytes, got %d", bytesConsumed, len(data))
+				return nil, truncated("SetRequestNormal", "access_selection", len(source)-len(data))
 			}
 			// Advance data pointer by the number of bytes consumed by the access descriptor
 			data = data[bytesConsumed:]
@@ -122,7 +123,7 @@ func (s *SetRequestNormal) FromBytes(data []byte) (*SetRequestNormal, error) {
 // ToBytes converts SetRequestNormal to bytes
 func (s *SetRequestNormal) ToBytes() ([]byte, error) {
 	result := []byte{SetRequestTag}
-	result = append(result, byte(enumerations.SetRequestTypeNormal))
+	result = append(result, byte(enumerations.SetRequestNormal))
 	
 	invokeBytes := s.InvokeIdAndPriority.ToBytes()
 	result = append(result, invokeBytes...)
@@ -132,15 +133,11 @@ func (s *SetRequestNormal) ToBytes() ([]byte, error) {
 	
 	if s.AccessSelection != nil {
 		result = append(result, 0x01)
-		// Serialize access selection based on its type
-		switch accessSel := s.AccessSelection.(type) {
-		case *cosem.RangeDescriptor:
-			result = append(result, accessSel.ToBytes()...)
-		case *cosem.EntryDescriptor:
-			result = append(result, accessSel.ToBytes()...)
-		default:
-			return nil, fmt.Errorf("unsupported access selection type: %T", s.AccessSelection)
+		selectionBytes, err := cosem.AccessSelectionToBytes(s.AccessSelection)
+		if err != nil {
+			return nil, err
 		}
+		result = append(result, selectionBytes...)
 	} else {
 		result = append(result, 0x00)
 	}
@@ -166,59 +163,43 @@ func NewSetResponseNormal(
 ) *SetResponseNormal {
 	return &SetResponseNormal{
 		BaseXDlmsApdu: &BaseXDlmsApdu{
-			Tag: SetResponseTag,
+			tag: SetResponseTag,
 		},
 		InvokeIdAndPriority: invokeIdAndPriority,
 		Result:              result,
 	}
 }
 
+var setResponseNormalSchema = &apduSchema[SetResponseNormal]{
+	name: "SetResponseNormal",
+	fields: []schemaField[SetResponseNormal]{
+		constField[SetResponseNormal]("tag", SetResponseTag),
+		constField[SetResponseNormal]("type", uint8(enumerations.SetResponseNormal)),
+		invokeIDField(func(s *SetResponseNormal) **InvokeIdAndPriority { return &s.InvokeIdAndPriority }),
+		uint8Field("result", func(s *SetResponseNormal) *enumerations.DataAccessResult { return &s.Result }),
+	},
+}
+
 // FromBytes creates SetResponseNormal from bytes
 func (s *SetResponseNormal) FromBytes(data []byte) (*SetResponseNormal, error) {
-	if len(data) < 2 {
-		return nil, fmt.Errorf("insufficient data for SetResponse")
+	response := NewSetResponseNormal(nil, 0)
+	if err := setResponseNormalSchema.decode(data, response); err != nil {
+		return nil, err
 	}
-	
-	tag := data[0]
-	if tag != SetResponseTag {
-		return nil, fmt.Errorf("tag for SetResponse is not correct. Got %d, should be %d", tag, SetResponseTag)
-	}
-	
-	typeChoice := enumerations.SetResponseType(data[1])
-	if typeChoice != enumerations.SetResponseTypeNormal {
-		return nil, fmt.Errorf("the type of the SetResponse is not for a SetResponseNormal")
-	}
-	
-	data = data[2:]
-	
-	// Parse invoke_id_and_priority
-	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for invoke_id_and_priority")
-	}
-	invokeIdAndPriority, err := (&InvokeIdAndPriority{}).FromBytes(data[:1])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse invoke_id_and_priority: %w", err)
-	}
-	data = data[1:]
-	
-	// Parse result
-	if len(data) < 1 {
-		return nil, fmt.Errorf("insufficient data for result")
-	}
-	result := enumerations.DataAccessResult(data[0])
-	
-	return NewSetResponseNormal(invokeIdAndPriority, result), nil
+	return response, nil
 }
 
 // ToBytes converts SetResponseNormal to bytes
 func (s *SetResponseNormal) ToBytes() ([]byte, error) {
-	result := []byte{SetResponseTag}
-	result = append(result, byte(enumerations.SetResponseTypeNormal))
-	
-	invokeBytes := s.InvokeIdAndPriority.ToBytes()
-	result = append(result, invokeBytes...)
-	
-	result = append(result, byte(s.Result))
-	
-	return result, nil
+	return setResponseNormalSchema.encode(s), nil
+}
+
+// String implements fmt.Stringer
+func (s *SetRequestNormal) String() string {
+	return fmt.Sprintf("SetRequestNormal(%s, attribute=%s, data=%x)", s.InvokeIdAndPriority, attributeString(s.CosemAttribute), s.Data)
+}
+
+// String implements fmt.Stringer
+func (s *SetResponseNormal) String() string {
+	return fmt.Sprintf("SetResponseNormal(%s, result=%s)", s.InvokeIdAndPriority, s.Result)
 }
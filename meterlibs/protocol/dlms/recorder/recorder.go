@@ -0,0 +1,356 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+)
+
+// Direction of a recorded frame
+type Direction string
+
+const (
+	DirectionTx Direction = "tx"
+	DirectionRx Direction = "rx"
+)
+
+const (
+	dataExtension  = ".bin"
+	indexExtension = ".json"
+)
+
+// Frame is a single frame sent or received during a session
+type Frame struct {
+	Time      time.Time
+	Direction Direction
+	Data      []byte
+}
+
+// indexEntry locates a frame in the binary data file
+type indexEntry struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	Offset    int64     `json:"offset"`
+	Length    int       `json:"length"`
+}
+
+// Session is a recorded session loaded from disk
+type Session struct {
+	Frames []Frame
+}
+
+type recorder struct {
+	transport dlms.Transport
+	path      string
+	data      *os.File
+	offset    int64
+	index     []indexEntry
+	mutex     sync.Mutex
+	dc        dlms.DataChannel
+	tc        dlms.DataChannel
+	logger    *log.Logger
+}
+
+// New wraps a transport and records every transmitted and received frame.
+// Frames are appended to path.bin and the index is written to path.json on Close.
+// The authentication values of the AARQ and the AARE are masked in the files.
+func New(transport dlms.Transport, path string) (dlms.Transport, error) {
+	data, err := os.Create(path + dataExtension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session file: %w", err)
+	}
+
+	r := &recorder{
+		transport: transport,
+		path:      path,
+		data:      data,
+		index:     make([]indexEntry, 0),
+		dc:        nil,
+		tc:        make(dlms.DataChannel, 10),
+		logger:    nil,
+	}
+
+	transport.SetReception(r.tc)
+
+	go r.manager()
+
+	return r, nil
+}
+
+func (r *recorder) Close() {
+	r.transport.Close()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.data != nil {
+		if err := r.writeIndex(); err != nil && r.logger != nil {
+			r.logger.Printf("Failed to write session index: %v", err)
+		}
+		r.data.Close()
+		r.data = nil
+	}
+
+	if r.dc != nil {
+		close(r.dc)
+		r.dc = nil
+	}
+}
+
+func (r *recorder) Connect() error {
+	return r.transport.Connect()
+}
+
+func (r *recorder) Disconnect() error {
+	return r.transport.Disconnect()
+}
+
+func (r *recorder) IsConnected() bool {
+	return r.transport.IsConnected()
+}
+
+func (r *recorder) SetAddress(client int, server int) {
+	r.transport.SetAddress(client, server)
+}
+
+func (r *recorder) SetReception(dc dlms.DataChannel) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.dc != nil {
+		close(r.dc)
+	}
+
+	r.dc = dc
+}
+
+func (r *recorder) Send(src []byte) error {
+	if err := r.transport.Send(src); err != nil {
+		return err
+	}
+
+	r.record(DirectionTx, src)
+
+	return nil
+}
+
+func (r *recorder) SetLogger(logger *log.Logger) {
+	r.logger = logger
+	r.transport.SetLogger(logger)
+}
+
+func (r *recorder) manager() {
+	for {
+		data, ok := <-r.tc
+		if !ok {
+			return
+		}
+
+		r.record(DirectionRx, data)
+
+		r.mutex.Lock()
+		dc := r.dc
+		r.mutex.Unlock()
+
+		if dc != nil {
+			dc <- data
+		}
+	}
+}
+
+func (r *recorder) record(direction Direction, frame []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.data == nil {
+		return
+	}
+
+	n, err := r.data.Write(acse.RedactAuthenticationValue(frame))
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Printf("Failed to record frame: %v", err)
+		}
+		return
+	}
+
+	r.index = append(r.index, indexEntry{
+		Time:      time.Now(),
+		Direction: direction,
+		Offset:    r.offset,
+		Length:    n,
+	})
+	r.offset += int64(n)
+}
+
+func (r *recorder) writeIndex() error {
+	encoded, err := json.MarshalIndent(r.index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path+indexExtension, encoded, 0o644)
+}
+
+// Load reads a session recorded with New from path.bin and path.json
+func Load(path string) (*Session, error) {
+	data, err := os.ReadFile(path + dataExtension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	encoded, err := os.ReadFile(path + indexExtension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session index: %w", err)
+	}
+
+	var index []indexEntry
+	if err := json.Unmarshal(encoded, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse session index: %w", err)
+	}
+
+	session := &Session{Frames: make([]Frame, 0, len(index))}
+	for i, entry := range index {
+		end := entry.Offset + int64(entry.Length)
+		if entry.Offset < 0 || entry.Length < 0 || end > int64(len(data)) {
+			return nil, fmt.Errorf("index entry %d is outside of the session file", i)
+		}
+
+		frame := make([]byte, entry.Length)
+		copy(frame, data[entry.Offset:end])
+
+		session.Frames = append(session.Frames, Frame{
+			Time:      entry.Time,
+			Direction: entry.Direction,
+			Data:      frame,
+		})
+	}
+
+	return session, nil
+}
+
+type replay struct {
+	frames    []Frame
+	position  int
+	connected bool
+	mutex     sync.Mutex
+	dc        dlms.DataChannel
+	logger    *log.Logger
+}
+
+// NewReplay returns a transport that plays back a recorded session. Every Send
+// must match the next recorded transmitted frame, authentication values
+// masked, and the received frames that follow it are delivered to the
+// reception channel.
+func NewReplay(session *Session) dlms.Transport {
+	return &replay{
+		frames:    session.Frames,
+		position:  0,
+		connected: false,
+		dc:        nil,
+		logger:    nil,
+	}
+}
+
+func (r *replay) Close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.connected = false
+	if r.dc != nil {
+		close(r.dc)
+		r.dc = nil
+	}
+}
+
+func (r *replay) Connect() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.connected = true
+	r.deliver()
+
+	return nil
+}
+
+func (r *replay) Disconnect() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.connected = false
+
+	return nil
+}
+
+func (r *replay) IsConnected() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.connected
+}
+
+func (r *replay) SetAddress(client int, server int) {
+}
+
+func (r *replay) SetReception(dc dlms.DataChannel) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.dc != nil {
+		close(r.dc)
+	}
+
+	r.dc = dc
+}
+
+func (r *replay) Send(src []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	sent := acse.RedactAuthenticationValue(src)
+	if r.position >= len(r.frames) {
+		return fmt.Errorf("session has no more frames, sent %x", sent)
+	}
+
+	expected := r.frames[r.position]
+	if expected.Direction != DirectionTx || !bytes.Equal(expected.Data, sent) {
+		return fmt.Errorf("frame %d does not match the session, expected %s %x, sent %x",
+			r.position, expected.Direction, expected.Data, sent)
+	}
+	r.position++
+
+	r.deliver()
+
+	return nil
+}
+
+func (r *replay) SetLogger(logger *log.Logger) {
+	r.logger = logger
+}
+
+// deliver sends the received frames up to the next transmitted frame
+func (r *replay) deliver() {
+	for r.position < len(r.frames) && r.frames[r.position].Direction == DirectionRx {
+		frame := r.frames[r.position]
+		r.position++
+
+		if r.logger != nil {
+			r.logger.Printf("RX (replay): %x", frame.Data)
+		}
+
+		if r.dc != nil {
+			r.dc <- frame.Data
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package recorder_test
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/mocks"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/recorder"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	transportMock := mocks.NewTransportMock(t)
+	path := filepath.Join(t.TempDir(), "session")
+
+	var tdc dlms.DataChannel
+	rdc := make(dlms.DataChannel, 10)
+
+	transportMock.On("SetReception", mock.Anything).Run(func(args mock.Arguments) {
+		tdc = args.Get(0).(dlms.DataChannel)
+	}).Once()
+
+	r, err := recorder.New(transportMock, path)
+	assert.NoError(t, err)
+	r.SetReception(rdc)
+
+	request := decodeHexString("AABBCCDDEEFF")
+	response := decodeHexString("0123456789")
+
+	transportMock.On("Send", request).Return(nil).Once()
+	assert.NoError(t, r.Send(request))
+
+	tdc <- response
+	assert.Equal(t, response, <-rdc)
+
+	transportMock.On("Close").Return(nil).Once()
+	r.Close()
+
+	transportMock.AssertExpectations(t)
+
+	session, err := recorder.Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, session.Frames, 2)
+	assert.Equal(t, recorder.DirectionTx, session.Frames[0].Direction)
+	assert.Equal(t, request, session.Frames[0].Data)
+	assert.Equal(t, recorder.DirectionRx, session.Frames[1].Direction)
+	assert.Equal(t, response, session.Frames[1].Data)
+
+	replay := recorder.NewReplay(session)
+	replayDc := make(dlms.DataChannel, 10)
+	replay.SetReception(replayDc)
+
+	assert.NoError(t, replay.Connect())
+	assert.Error(t, replay.Send(decodeHexString("00")))
+	assert.NoError(t, replay.Send(request))
+	assert.Equal(t, response, <-replayDc)
+	assert.Error(t, replay.Send(request))
+
+	replay.Close()
+}
+
+func TestRecorder_AuthenticationValueIsMasked(t *testing.T) {
+	transportMock := mocks.NewTransportMock(t)
+	path := filepath.Join(t.TempDir(), "session")
+	transportMock.On("SetReception", mock.Anything).Once()
+
+	r, err := recorder.New(transportMock, path)
+	assert.NoError(t, err)
+
+	// AARQ with the low level security password 12345678
+	aarq := decodeHexString("6036A109060760857405080101" + "8A0207808B0760857405080201" +
+		"AC0A80083132333435363738" + "BE10040E01000000065F1F0400001E1DFFFF")
+	transportMock.On("Send", aarq).Return(nil).Once()
+	assert.NoError(t, r.Send(aarq))
+
+	transportMock.On("Close").Return(nil).Once()
+	r.Close()
+
+	data, err := os.ReadFile(path + ".bin")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "12345678")
+
+	session, err := recorder.Load(path)
+	assert.NoError(t, err)
+	replay := recorder.NewReplay(session)
+	assert.NoError(t, replay.Connect())
+	assert.NoError(t, replay.Send(aarq))
+	replay.Close()
+}
+
+func decodeHexString(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
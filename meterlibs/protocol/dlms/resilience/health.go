@@ -0,0 +1,150 @@
+package resilience
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MeterHealth is the health of the communication with a meter
+type MeterHealth struct {
+	Meter string
+	// LastSuccess is the end of the last successful operation, zero when
+	// none succeeded
+	LastSuccess time.Time
+	// ConsecutiveFailures is the number of operations failed since the last
+	// successful one
+	ConsecutiveFailures int
+	// AverageLatency is the mean duration of the successful operations
+	AverageLatency time.Duration
+	// AssociationDuration is how long the current association has been
+	// open, or how long the last one was open
+	AssociationDuration time.Duration
+	// Associated tells if an association is open
+	Associated bool
+}
+
+// HealthSource gives the health of the meters, e.g. to a Prometheus
+// collector exporting one gauge per field and meter
+type HealthSource interface {
+	Health() []MeterHealth
+}
+
+// HealthTracker records the health of the meters, it implements HealthSource
+type HealthTracker struct {
+	// Clock gives the current time, time.Now by default
+	Clock func() time.Time
+
+	meters map[string]*meterHealth
+	mutex  sync.Mutex
+}
+
+type meterHealth struct {
+	lastSuccess         time.Time
+	consecutiveFailures int
+	successes           int64
+	totalLatency        time.Duration
+	associatedAt        time.Time
+	associationDuration time.Duration
+}
+
+// NewHealthTracker creates an empty HealthTracker
+func NewHealthTracker() *HealthTracker {
+	return &HealthTracker{
+		Clock:  time.Now,
+		meters: make(map[string]*meterHealth),
+	}
+}
+
+// Success records a successful operation that took latency
+func (h *HealthTracker) Success(meter string, latency time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	m := h.meter(meter)
+	m.lastSuccess = h.Clock()
+	m.consecutiveFailures = 0
+	m.successes++
+	m.totalLatency += latency
+}
+
+// Failure records a failed operation
+func (h *HealthTracker) Failure(meter string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.meter(meter).consecutiveFailures++
+}
+
+// AssociationOpened records the start of an association with the meter
+func (h *HealthTracker) AssociationOpened(meter string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	m := h.meter(meter)
+	m.associatedAt = h.Clock()
+	m.associationDuration = 0
+}
+
+// AssociationClosed records the end of the association with the meter
+func (h *HealthTracker) AssociationClosed(meter string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	m := h.meter(meter)
+	if !m.associatedAt.IsZero() {
+		m.associationDuration = h.Clock().Sub(m.associatedAt)
+		m.associatedAt = time.Time{}
+	}
+}
+
+// MeterHealth returns the health of a meter, false when nothing was recorded
+func (h *HealthTracker) MeterHealth(meter string) (MeterHealth, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	m, ok := h.meters[meter]
+	if !ok {
+		return MeterHealth{}, false
+	}
+	return h.snapshot(meter, m), true
+}
+
+// Health returns the health of all the meters, sorted by meter
+func (h *HealthTracker) Health() []MeterHealth {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	result := make([]MeterHealth, 0, len(h.meters))
+	for meter, m := range h.meters {
+		result = append(result, h.snapshot(meter, m))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Meter < result[j].Meter })
+	return result
+}
+
+func (h *HealthTracker) meter(meter string) *meterHealth {
+	m, ok := h.meters[meter]
+	if !ok {
+		m = &meterHealth{}
+		h.meters[meter] = m
+	}
+	return m
+}
+
+func (h *HealthTracker) snapshot(meter string, m *meterHealth) MeterHealth {
+	health := MeterHealth{
+		Meter:               meter,
+		LastSuccess:         m.lastSuccess,
+		ConsecutiveFailures: m.consecutiveFailures,
+		AssociationDuration: m.associationDuration,
+		Associated:          !m.associatedAt.IsZero(),
+	}
+	if m.successes > 0 {
+		health.AverageLatency = m.totalLatency / time.Duration(m.successes)
+	}
+	if health.Associated {
+		health.AssociationDuration = h.Clock().Sub(m.associatedAt)
+	}
+	return health
+}
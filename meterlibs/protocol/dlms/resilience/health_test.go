@@ -0,0 +1,66 @@
+package resilience_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/resilience"
+)
+
+func TestHealthTracker(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	health := resilience.NewHealthTracker()
+	health.Clock = func() time.Time { return now }
+
+	health.AssociationOpened("meter-b")
+	health.Success("meter-b", 100*time.Millisecond)
+	health.Success("meter-b", 300*time.Millisecond)
+	health.Failure("meter-a")
+	health.Failure("meter-a")
+	now = now.Add(time.Minute)
+
+	b, ok := health.MeterHealth("meter-b")
+	require.True(t, ok)
+	assert.Equal(t, now.Add(-time.Minute), b.LastSuccess)
+	assert.Equal(t, 200*time.Millisecond, b.AverageLatency)
+	assert.True(t, b.Associated)
+	assert.Equal(t, time.Minute, b.AssociationDuration)
+
+	health.AssociationClosed("meter-b")
+	now = now.Add(time.Hour)
+	b, _ = health.MeterHealth("meter-b")
+	assert.False(t, b.Associated)
+	assert.Equal(t, time.Minute, b.AssociationDuration)
+
+	var source resilience.HealthSource = health
+	meters := source.Health()
+	require.Len(t, meters, 2)
+	assert.Equal(t, "meter-a", meters[0].Meter)
+	assert.Equal(t, 2, meters[0].ConsecutiveFailures)
+	assert.True(t, meters[0].LastSuccess.IsZero())
+
+	_, ok = health.MeterHealth("meter-c")
+	assert.False(t, ok)
+}
+
+func TestPolicy_RecordsHealth(t *testing.T) {
+	policy := resilience.NewPolicy(5, time.Hour)
+	policy.Health = resilience.NewHealthTracker()
+
+	rejected := func() error { return exceptions.NewApplicationAssociationError("rejected") }
+	assert.Error(t, policy.Do(context.Background(), "meter", rejected))
+	assert.Error(t, policy.Do(context.Background(), "meter", rejected))
+
+	health, ok := policy.Health.MeterHealth("meter")
+	require.True(t, ok)
+	assert.Equal(t, 2, health.ConsecutiveFailures)
+
+	assert.NoError(t, policy.Do(context.Background(), "meter", func() error { return nil }))
+	health, _ = policy.Health.MeterHealth("meter")
+	assert.Equal(t, 0, health.ConsecutiveFailures)
+	assert.False(t, health.LastSuccess.IsZero())
+}
@@ -0,0 +1,437 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+)
+
+// ErrorClass tells if a failed operation is worth retrying
+type ErrorClass int
+
+const (
+	// ErrorClassTransient errors can succeed on retry, e.g. a corrupted HDLC frame or a timeout
+	ErrorClassTransient ErrorClass = iota
+	// ErrorClassPermanent errors fail again on retry, e.g. a rejected association
+	ErrorClassPermanent
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassTransient:
+		return "transient"
+	case ErrorClassPermanent:
+		return "permanent"
+	default:
+		return fmt.Sprintf("ErrorClass(%d)", int(c))
+	}
+}
+
+// Classify returns the class of an error. Association, conformance and security
+// errors are permanent, everything else such as HDLC parsing errors (bad HCS/FCS),
+// communication errors and timeouts is considered transient.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassTransient
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassPermanent
+	}
+
+	var associationError *exceptions.ApplicationAssociationError
+	var preEstablishedError *exceptions.PreEstablishedAssociationError
+	var conformanceError *exceptions.ConformanceError
+	var cipheringError *exceptions.CipheringError
+	var cryptographyError *exceptions.CryptographyError
+	var decryptionError *exceptions.DecryptionError
+	switch {
+	case errors.As(err, &associationError),
+		errors.As(err, &preEstablishedError),
+		errors.As(err, &conformanceError),
+		errors.As(err, &cipheringError),
+		errors.As(err, &cryptographyError),
+		errors.As(err, &decryptionError):
+		return ErrorClassPermanent
+	}
+
+	return ErrorClassTransient
+}
+
+// Backoff computes exponentially growing delays between retries
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64 // fraction of the delay added at random, 0 disables it
+	MaxRetries int
+}
+
+// NewBackoff creates a new Backoff with default values
+func NewBackoff() *Backoff {
+	return &Backoff{
+		Initial:    500 * time.Millisecond,
+		Max:        30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+		MaxRetries: 3,
+	}
+}
+
+// Delay returns the delay before the given retry, starting at 0
+func (b *Backoff) Delay(retry int) time.Duration {
+	delay := float64(b.Initial)
+	for i := 0; i < retry; i++ {
+		delay *= b.Multiplier
+		if delay >= float64(b.Max) {
+			delay = float64(b.Max)
+			break
+		}
+	}
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * rand.Float64()
+	}
+	if delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	return time.Duration(delay)
+}
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets all operations through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects operations until the open timeout has passed
+	CircuitOpen
+	// CircuitHalfOpen lets one trial operation through
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// ErrCircuitOpen is returned when an operation is rejected by an open circuit
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitBreaker stops talking to a meter after consecutive failures
+type CircuitBreaker struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	state         CircuitState
+	failures      int
+	openedAt      time.Time
+	onStateChange func(from CircuitState, to CircuitState)
+	now           func() time.Time
+	mutex         sync.Mutex
+}
+
+// NewCircuitBreaker creates a new CircuitBreaker
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		OpenTimeout:      openTimeout,
+		state:            CircuitClosed,
+		now:              time.Now,
+	}
+}
+
+// OnStateChange sets a hook called on every state change
+func (c *CircuitBreaker) OnStateChange(hook func(from CircuitState, to CircuitState)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.onStateChange = hook
+}
+
+// State returns the current state
+func (c *CircuitBreaker) State() CircuitState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.state
+}
+
+// Allow returns ErrCircuitOpen if the operation must not be attempted
+func (c *CircuitBreaker) Allow() error {
+	notify := noStateChange
+	defer func() { notify() }()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if c.now().Sub(c.openedAt) < c.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		notify = c.transition(CircuitHalfOpen)
+		return nil
+	case CircuitHalfOpen:
+		// Only the trial operation is let through
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// Success records a successful operation and closes the circuit
+func (c *CircuitBreaker) Success() {
+	notify := noStateChange
+	defer func() { notify() }()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.failures = 0
+	if c.state != CircuitClosed {
+		notify = c.transition(CircuitClosed)
+	}
+}
+
+// Failure records a failed operation and opens the circuit when the threshold is reached
+func (c *CircuitBreaker) Failure() {
+	notify := noStateChange
+	defer func() { notify() }()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.failures++
+	if c.state == CircuitHalfOpen || (c.state == CircuitClosed && c.failures >= c.FailureThreshold) {
+		c.openedAt = c.now()
+		notify = c.transition(CircuitOpen)
+	}
+}
+
+func noStateChange() {}
+
+// transition changes the state, the mutex being held. It returns the call of
+// the state change hook, to be made once the mutex is released so the hook
+// can use the breaker.
+func (c *CircuitBreaker) transition(to CircuitState) func() {
+	from := c.state
+	c.state = to
+	hook := c.onStateChange
+	if hook == nil {
+		return noStateChange
+	}
+	return func() {
+		hook(from, to)
+	}
+}
+
+// Policy applies backoff and a circuit breaker per meter around meter communication
+type Policy struct {
+	Backoff          *Backoff
+	Classifier       func(err error) ErrorClass
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	// Health records the outcome and the latency of the operations of each
+	// meter, nil when they are not tracked
+	Health *HealthTracker
+
+	breakers      map[string]*CircuitBreaker
+	onStateChange func(meter string, from CircuitState, to CircuitState)
+	mutex         sync.Mutex
+}
+
+// NewPolicy creates a new Policy with the default backoff and classification
+func NewPolicy(failureThreshold int, openTimeout time.Duration) *Policy {
+	return &Policy{
+		Backoff:          NewBackoff(),
+		Classifier:       Classify,
+		FailureThreshold: failureThreshold,
+		OpenTimeout:      openTimeout,
+		breakers:         make(map[string]*CircuitBreaker),
+	}
+}
+
+// OnStateChange sets a hook called when the circuit of any meter changes state
+func (p *Policy) OnStateChange(hook func(meter string, from CircuitState, to CircuitState)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.onStateChange = hook
+	for meter, breaker := range p.breakers {
+		breaker.OnStateChange(p.breakerHook(meter))
+	}
+}
+
+// Breaker returns the circuit breaker of a meter
+func (p *Policy) Breaker(meter string) *CircuitBreaker {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	breaker, ok := p.breakers[meter]
+	if !ok {
+		breaker = NewCircuitBreaker(p.FailureThreshold, p.OpenTimeout)
+		breaker.OnStateChange(p.breakerHook(meter))
+		p.breakers[meter] = breaker
+	}
+	return breaker
+}
+
+func (p *Policy) breakerHook(meter string) func(from CircuitState, to CircuitState) {
+	hook := p.onStateChange
+	if hook == nil {
+		return nil
+	}
+	return func(from CircuitState, to CircuitState) {
+		hook(meter, from, to)
+	}
+}
+
+// Do runs operation for a meter, retrying transient errors with backoff.
+// The last error is returned when all retries failed or the error is permanent.
+func (p *Policy) Do(ctx context.Context, meter string, operation func() error) error {
+	breaker := p.Breaker(meter)
+	if err := breaker.Allow(); err != nil {
+		return fmt.Errorf("meter %s: %w", meter, err)
+	}
+
+	classify := p.Classifier
+	if classify == nil {
+		classify = Classify
+	}
+
+	var err error
+	for retry := 0; ; retry++ {
+		started := time.Now()
+		err = operation()
+		if err == nil {
+			p.success(meter, breaker, time.Since(started))
+			return nil
+		}
+
+		if classify(err) == ErrorClassPermanent || retry >= p.Backoff.MaxRetries {
+			break
+		}
+
+		timer := time.NewTimer(p.Backoff.Delay(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.failure(meter, breaker)
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	p.failure(meter, breaker)
+	return err
+}
+
+// DoOnce runs operation for a meter without retrying it, for the operations
+// that must not be sent twice. The circuit breaker and the health of the meter
+// are updated as with Do.
+func (p *Policy) DoOnce(meter string, operation func() error) error {
+	breaker := p.Breaker(meter)
+	if err := breaker.Allow(); err != nil {
+		return fmt.Errorf("meter %s: %w", meter, err)
+	}
+
+	started := time.Now()
+	if err := operation(); err != nil {
+		p.failure(meter, breaker)
+		return err
+	}
+	p.success(meter, breaker, time.Since(started))
+	return nil
+}
+
+func (p *Policy) success(meter string, breaker *CircuitBreaker, latency time.Duration) {
+	breaker.Success()
+	if p.Health != nil {
+		p.Health.Success(meter, latency)
+	}
+}
+
+func (p *Policy) failure(meter string, breaker *CircuitBreaker) {
+	breaker.Failure()
+	if p.Health != nil {
+		p.Health.Failure(meter)
+	}
+}
+
+// RateLimiter paces the requests sent to a meter with a token bucket. A
+// request takes a token and the bucket refills one token per Interval, up to
+// Burst tokens. A Burst of 1 spaces all the requests by at least Interval,
+// for the meters misbehaving when requests arrive back-to-back.
+type RateLimiter struct {
+	Interval time.Duration
+	Burst    int
+
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	mutex  sync.Mutex
+}
+
+// NewRateLimiter creates a RateLimiter with a full bucket
+func NewRateLimiter(interval time.Duration, burst int) *RateLimiter {
+	return &RateLimiter{
+		Interval: interval,
+		Burst:    burst,
+		tokens:   float64(burst),
+		now:      time.Now,
+	}
+}
+
+// Reserve takes a token and returns how long the request must wait before it
+// is sent. The tokens of the requests that wait are taken in advance, so
+// concurrent requests are spaced as well.
+func (r *RateLimiter) Reserve() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.Interval <= 0 {
+		return 0
+	}
+
+	now := r.now()
+	if !r.last.IsZero() {
+		r.tokens += float64(now.Sub(r.last)) / float64(r.Interval)
+	}
+	if burst := float64(max(r.Burst, 1)); r.tokens > burst {
+		r.tokens = burst
+	}
+	r.last = now
+
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens * float64(r.Interval))
+}
+
+// Wait blocks until the request may be sent, or the context is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	delay := r.Reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
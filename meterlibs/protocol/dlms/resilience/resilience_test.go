@@ -0,0 +1,107 @@
+package resilience_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/hdlc"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/resilience"
+)
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, resilience.ErrorClassTransient, resilience.Classify(hdlc.NewHdlcParsingError("FCS is not correct")))
+	assert.Equal(t, resilience.ErrorClassTransient, resilience.Classify(exceptions.NewCommunicationError("timeout")))
+	assert.Equal(t, resilience.ErrorClassPermanent, resilience.Classify(
+		fmt.Errorf("association: %w", exceptions.NewApplicationAssociationError("rejected"))))
+}
+
+func TestPolicy_RetriesTransientErrors(t *testing.T) {
+	policy := resilience.NewPolicy(2, time.Hour)
+	policy.Backoff.Initial = time.Millisecond
+	policy.Backoff.Jitter = 0
+
+	calls := 0
+	err := policy.Do(context.Background(), "meter", func() error {
+		calls++
+		if calls < 3 {
+			return hdlc.NewHdlcParsingError("FCS is not correct")
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, resilience.CircuitClosed, policy.Breaker("meter").State())
+}
+
+func TestPolicy_OpensCircuit(t *testing.T) {
+	policy := resilience.NewPolicy(2, time.Hour)
+
+	changes := make([]string, 0)
+	policy.OnStateChange(func(meter string, from resilience.CircuitState, to resilience.CircuitState) {
+		changes = append(changes, fmt.Sprintf("%s %s->%s", meter, from, to))
+	})
+
+	calls := 0
+	rejected := func() error {
+		calls++
+		return exceptions.NewApplicationAssociationError("rejected")
+	}
+
+	assert.Error(t, policy.Do(context.Background(), "meter", rejected))
+	assert.Error(t, policy.Do(context.Background(), "meter", rejected))
+	assert.ErrorIs(t, policy.Do(context.Background(), "meter", rejected), resilience.ErrCircuitOpen)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"meter closed->open"}, changes)
+}
+
+func TestCircuitBreaker_HookUsesBreaker(t *testing.T) {
+	breaker := resilience.NewCircuitBreaker(1, time.Hour)
+
+	states := make([]resilience.CircuitState, 0)
+	breaker.OnStateChange(func(from resilience.CircuitState, to resilience.CircuitState) {
+		states = append(states, breaker.State())
+		assert.ErrorIs(t, breaker.Allow(), resilience.ErrCircuitOpen)
+	})
+
+	breaker.Failure()
+	assert.Equal(t, []resilience.CircuitState{resilience.CircuitOpen}, states)
+}
+
+func TestPolicy_DoOnce(t *testing.T) {
+	policy := resilience.NewPolicy(1, time.Hour)
+
+	calls := 0
+	err := policy.DoOnce("meter", func() error {
+		calls++
+		return exceptions.NewCommunicationError("timeout")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls, "transient errors are not retried")
+	assert.Equal(t, resilience.CircuitOpen, policy.Breaker("meter").State())
+
+	assert.ErrorIs(t, policy.DoOnce("meter", func() error {
+		calls++
+		return nil
+	}), resilience.ErrCircuitOpen)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	limiter := resilience.NewRateLimiter(time.Hour, 2)
+
+	assert.Zero(t, limiter.Reserve())
+	assert.Zero(t, limiter.Reserve())
+	// The bucket is empty, the next requests wait one and two intervals
+	assert.InDelta(t, time.Hour, limiter.Reserve(), float64(time.Second))
+	assert.InDelta(t, 2*time.Hour, limiter.Reserve(), float64(time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.Canceled)
+}
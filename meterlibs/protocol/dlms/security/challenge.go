@@ -0,0 +1,79 @@
+package security
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+)
+
+// ChallengeSource gives the challenges sent to the meter in HLS associations,
+// the CtoS of the AARQ. The default reads crypto/rand, tests against recorded
+// meter sessions replay the recorded challenges with RecordedChallenges.
+type ChallengeSource interface {
+	Challenge(length int) ([]byte, error)
+}
+
+// ChallengeSourceFunc adapts a function to a ChallengeSource
+type ChallengeSourceFunc func(length int) ([]byte, error)
+
+// Challenge calls the function
+func (f ChallengeSourceFunc) Challenge(length int) ([]byte, error) {
+	return f(length)
+}
+
+// RandomChallenges is the ChallengeSource reading crypto/rand
+var RandomChallenges ChallengeSource = ChallengeSourceFunc(func(length int) ([]byte, error) {
+	challenge := make([]byte, length)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	return challenge, nil
+})
+
+// RecordedChallenges is a ChallengeSource returning given challenges in
+// order, for reproducible sessions
+type RecordedChallenges struct {
+	mutex      sync.Mutex
+	challenges [][]byte
+}
+
+// NewRecordedChallenges creates RecordedChallenges returning challenges
+func NewRecordedChallenges(challenges ...[]byte) *RecordedChallenges {
+	return &RecordedChallenges{challenges: challenges}
+}
+
+// Challenge returns the next challenge, an error when there is none left or
+// it is not of the requested length
+func (r *RecordedChallenges) Challenge(length int) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.challenges) == 0 {
+		return nil, fmt.Errorf("no recorded challenge left")
+	}
+	challenge := r.challenges[0]
+	if len(challenge) != length {
+		return nil, fmt.Errorf("recorded challenge is %d bytes, %d requested", len(challenge), length)
+	}
+	r.challenges = r.challenges[1:]
+	return challenge, nil
+}
+
+// NewChallenge gets a challenge of length bytes from source, RandomChallenges
+// when nil. HLS challenges are 8 to 64 bytes.
+func NewChallenge(source ChallengeSource, length int) ([]byte, error) {
+	if length < 8 || length > 64 {
+		return nil, fmt.Errorf("challenge should be 8 to 64 bytes, got %d", length)
+	}
+	if source == nil {
+		source = RandomChallenges
+	}
+	challenge, err := source.Challenge(length)
+	if err != nil {
+		return nil, err
+	}
+	if len(challenge) != length {
+		return nil, fmt.Errorf("challenge source returned %d bytes, %d requested", len(challenge), length)
+	}
+	return challenge, nil
+}
@@ -0,0 +1,45 @@
+package security_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+func TestNewChallenge(t *testing.T) {
+	first, err := security.NewChallenge(nil, 16)
+	require.NoError(t, err)
+	second, err := security.NewChallenge(nil, 16)
+	require.NoError(t, err)
+	assert.Len(t, first, 16)
+	assert.NotEqual(t, first, second)
+
+	_, err = security.NewChallenge(nil, 4)
+	assert.Error(t, err)
+	_, err = security.NewChallenge(nil, 65)
+	assert.Error(t, err)
+
+	short := security.ChallengeSourceFunc(func(int) ([]byte, error) { return make([]byte, 8), nil })
+	_, err = security.NewChallenge(short, 16)
+	assert.Error(t, err)
+}
+
+func TestRecordedChallenges(t *testing.T) {
+	recorded := mustHex(t, "4B35366956616759")
+	source := security.NewRecordedChallenges(recorded, mustHex(t, "000102030405060708090A0B0C0D0E0F"))
+
+	challenge, err := security.NewChallenge(source, 8)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, challenge)
+
+	_, err = security.NewChallenge(source, 8)
+	assert.Error(t, err)
+	challenge, err = security.NewChallenge(source, 16)
+	require.NoError(t, err)
+	assert.Len(t, challenge, 16)
+
+	_, err = security.NewChallenge(source, 16)
+	assert.Error(t, err)
+}
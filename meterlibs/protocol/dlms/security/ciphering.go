@@ -0,0 +1,217 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// SecurityControl is the security control byte of a ciphered APDU
+type SecurityControl byte
+
+const (
+	// SecurityControlAuthentication marks an authenticated APDU
+	SecurityControlAuthentication SecurityControl = 0x10
+	// SecurityControlEncryption marks an encrypted APDU
+	SecurityControlEncryption SecurityControl = 0x20
+	// SecurityControlBroadcastKey marks an APDU ciphered with the broadcast
+	// encryption key instead of the unicast one
+	SecurityControlBroadcastKey SecurityControl = 0x40
+	// SecurityControlCompression marks a compressed APDU
+	SecurityControlCompression SecurityControl = 0x80
+)
+
+// GCMTagLength is the length of the authentication tag of ciphered APDUs
+const GCMTagLength = 12
+
+// NewSecurityControl creates the security control byte of a security suite
+func NewSecurityControl(suite uint8, authenticated, encrypted, broadcast bool) SecurityControl {
+	result := SecurityControl(suite & 0x0F)
+	if authenticated {
+		result |= SecurityControlAuthentication
+	}
+	if encrypted {
+		result |= SecurityControlEncryption
+	}
+	if broadcast {
+		result |= SecurityControlBroadcastKey
+	}
+	return result
+}
+
+// SecuritySuite returns the security suite id
+func (s SecurityControl) SecuritySuite() uint8 {
+	return uint8(s) & 0x0F
+}
+
+// Authenticated tells if the APDU has an authentication tag
+func (s SecurityControl) Authenticated() bool {
+	return s&SecurityControlAuthentication != 0
+}
+
+// Encrypted tells if the APDU is encrypted
+func (s SecurityControl) Encrypted() bool {
+	return s&SecurityControlEncryption != 0
+}
+
+// Broadcast tells if the APDU is ciphered with the broadcast key
+func (s SecurityControl) Broadcast() bool {
+	return s&SecurityControlBroadcastKey != 0
+}
+
+// EncryptionKeyID returns the id of the key the APDU is ciphered with
+func (s SecurityControl) EncryptionKeyID() KeyID {
+	if s.Broadcast() {
+		return KeyIDGlobalBroadcastEncryption
+	}
+	return KeyIDGlobalUnicastEncryption
+}
+
+// String implements fmt.Stringer
+func (s SecurityControl) String() string {
+	return fmt.Sprintf("SecurityControl(suite=%d, authenticated=%t, encrypted=%t, broadcast=%t)",
+		s.SecuritySuite(), s.Authenticated(), s.Encrypted(), s.Broadcast())
+}
+
+// Encrypt ciphers an APDU with AES-GCM as done for the glo- and ded-ciphered
+// APDUs. The initialization vector is the system title of the sender followed
+// by the invocation counter, the additional data is the security control
+// byte and the authentication key. The result is the ciphered APDU followed
+// by the authentication tag, or the APDU and the tag when it is only
+// authenticated.
+func Encrypt(securityControl SecurityControl, systemTitle []byte, invocationCounter uint32, encryptionKey, authenticationKey, plaintext []byte) ([]byte, error) {
+	aead, nonce, err := newGCM(systemTitle, invocationCounter, encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	additionalData := append([]byte{byte(securityControl)}, authenticationKey...)
+	switch {
+	case securityControl.Encrypted() && securityControl.Authenticated():
+		return aead.Seal(nil, nonce, plaintext, additionalData), nil
+	case securityControl.Encrypted():
+		sealed := aead.Seal(nil, nonce, plaintext, nil)
+		return sealed[:len(plaintext)], nil
+	case securityControl.Authenticated():
+		tag := aead.Seal(nil, nonce, nil, append(additionalData, plaintext...))
+		return append(append([]byte{}, plaintext...), tag...), nil
+	default:
+		return append([]byte{}, plaintext...), nil
+	}
+}
+
+// Decrypt deciphers an APDU ciphered with Encrypt and checks its tag
+func Decrypt(securityControl SecurityControl, systemTitle []byte, invocationCounter uint32, encryptionKey, authenticationKey, ciphertext []byte) ([]byte, error) {
+	aead, nonce, err := newGCM(systemTitle, invocationCounter, encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	additionalData := append([]byte{byte(securityControl)}, authenticationKey...)
+	switch {
+	case securityControl.Encrypted() && securityControl.Authenticated():
+		plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+		if err != nil {
+			return nil, fmt.Errorf("authentication tag mismatch: %w", err)
+		}
+		return plaintext, nil
+	case securityControl.Encrypted():
+		// without tag GCM is CTR mode starting at counter 2
+		block, _ := aes.NewCipher(encryptionKey)
+		counter := append(append([]byte{}, nonce...), 0, 0, 0, 2)
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCTR(block, counter).XORKeyStream(plaintext, ciphertext)
+		return plaintext, nil
+	case securityControl.Authenticated():
+		if len(ciphertext) < GCMTagLength {
+			return nil, fmt.Errorf("authenticated APDU of %d bytes is shorter than the tag", len(ciphertext))
+		}
+		plaintext := ciphertext[:len(ciphertext)-GCMTagLength]
+		if _, err := aead.Open(nil, nonce, ciphertext[len(plaintext):], append(additionalData, plaintext...)); err != nil {
+			return nil, fmt.Errorf("authentication tag mismatch: %w", err)
+		}
+		return append([]byte{}, plaintext...), nil
+	default:
+		return append([]byte{}, ciphertext...), nil
+	}
+}
+
+func newGCM(systemTitle []byte, invocationCounter uint32, encryptionKey []byte) (cipher.AEAD, []byte, error) {
+	if err := ValidateSystemTitle(systemTitle); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateKey(encryptionKey); err != nil {
+		return nil, nil, fmt.Errorf("encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := cipher.NewGCMWithTagSize(block, GCMTagLength)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := binary.BigEndian.AppendUint32(append([]byte{}, systemTitle...), invocationCounter)
+	return aead, nonce, nil
+}
+
+// InvocationCounter is the invocation counter of the APDUs ciphered by a
+// client, it is incremented for each APDU and never reused with a key
+type InvocationCounter struct {
+	mutex sync.Mutex
+	value uint32
+
+	// persistence, see NewPersistentInvocationCounter
+	store    InvocationCounterStore
+	batch    uint32
+	interval time.Duration
+	reserved uint32
+	savedAt  time.Time
+	now      func() time.Time
+}
+
+// NewInvocationCounter creates an InvocationCounter whose next value is the given one
+func NewInvocationCounter(next uint32) *InvocationCounter {
+	return &InvocationCounter{value: next}
+}
+
+// Next returns the value for the next APDU and increments the counter. It
+// fails when the counter is exhausted, the key must be changed then, or when
+// the value could not be persisted.
+func (i *InvocationCounter) Next() (uint32, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if i.value == math.MaxUint32 {
+		return 0, fmt.Errorf("invocation counter exhausted, the key must be changed")
+	}
+	if err := i.reserve(); err != nil {
+		return 0, err
+	}
+	value := i.value
+	i.value++
+	return value, nil
+}
+
+// Peek returns the value for the next APDU without incrementing the counter
+func (i *InvocationCounter) Peek() uint32 {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.value
+}
+
+// Advance moves the counter forward to the given next value, e.g. the one a
+// meter expects after rejecting an APDU. It tells if the counter moved, it
+// never goes backward as values must not be reused.
+func (i *InvocationCounter) Advance(next uint32) bool {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if next <= i.value {
+		return false
+	}
+	i.value = next
+	return true
+}
@@ -0,0 +1,41 @@
+package security_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+// Green Book example of a glo-get-request ciphered with security suite 0
+func TestEncrypt(t *testing.T) {
+	encryptionKey := mustHex(t, "000102030405060708090A0B0C0D0E0F")
+	authenticationKey := mustHex(t, "D0D1D2D3D4D5D6D7D8D9DADBDCDDDEDF")
+	systemTitle := mustHex(t, "4D4D4D0000BC614E")
+	plaintext := mustHex(t, "C0010000080000010000FF0200")
+	securityControl := security.NewSecurityControl(0, true, true, false)
+
+	ciphered, err := security.Encrypt(securityControl, systemTitle, 0x01234567, encryptionKey, authenticationKey, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, mustHex(t, "411312FF935A47566827C467BC"+"7D825C3BE4A77C3FCC056B6B"), ciphered)
+
+	deciphered, err := security.Decrypt(securityControl, systemTitle, 0x01234567, encryptionKey, authenticationKey, ciphered)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, deciphered)
+
+	ciphered[0] ^= 0x01
+	_, err = security.Decrypt(securityControl, systemTitle, 0x01234567, encryptionKey, authenticationKey, ciphered)
+	assert.Error(t, err)
+}
+
+func TestInvocationCounter_Advance(t *testing.T) {
+	counter := security.NewInvocationCounter(10)
+	assert.False(t, counter.Advance(5))
+	assert.False(t, counter.Advance(10))
+	assert.True(t, counter.Advance(20))
+
+	value, err := counter.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(20), value)
+}
@@ -0,0 +1,121 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InvocationCounterStore persists the next invocation counter of a client,
+// so that no value is reused after a restart
+type InvocationCounterStore interface {
+	Save(next uint32) error
+}
+
+// NewPersistentInvocationCounter creates an InvocationCounter saving its
+// values to store. Values are reserved batch at a time: before the counter
+// gives a value that is not covered by the saved one, it saves the next value
+// plus batch. The saved value is then always above the last used one, even
+// after a crash, at the cost of skipping up to batch values. The reservation
+// is also renewed when interval passed since the last save, 0 disables it.
+func NewPersistentInvocationCounter(next uint32, store InvocationCounterStore, batch uint32, interval time.Duration) *InvocationCounter {
+	return &InvocationCounter{
+		value:    next,
+		store:    store,
+		batch:    max(batch, 1),
+		interval: interval,
+		reserved: next,
+		now:      time.Now,
+	}
+}
+
+// reserve saves a new reservation when the next value is not covered by the
+// saved one or the last save is older than the interval
+func (i *InvocationCounter) reserve() error {
+	if i.store == nil {
+		return nil
+	}
+	expired := i.interval > 0 && i.now().Sub(i.savedAt) >= i.interval
+	if i.value < i.reserved && !expired {
+		return nil
+	}
+
+	reserved := uint32(min(uint64(i.value)+uint64(i.batch), math.MaxUint32))
+	if err := i.store.Save(reserved); err != nil {
+		return fmt.Errorf("failed to save invocation counter: %w", err)
+	}
+	i.reserved = reserved
+	i.savedAt = i.now()
+	return nil
+}
+
+// Flush saves the next value of the counter, so that no value is skipped
+// after a restart. It is called before the process exits, the counter can
+// still be used afterwards.
+func (i *InvocationCounter) Flush() error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.store == nil {
+		return nil
+	}
+	if err := i.store.Save(i.value); err != nil {
+		return fmt.Errorf("failed to save invocation counter: %w", err)
+	}
+	i.reserved = i.value
+	i.savedAt = i.now()
+	return nil
+}
+
+// FileInvocationCounterStore keeps the invocation counter in a file. The file
+// is replaced atomically, so it holds the old or the new value after a crash.
+type FileInvocationCounterStore struct {
+	Path string
+}
+
+// NewFileInvocationCounterStore creates a FileInvocationCounterStore
+func NewFileInvocationCounterStore(path string) *FileInvocationCounterStore {
+	return &FileInvocationCounterStore{Path: path}
+}
+
+// Save writes the value to a temporary file, syncs it and renames it to Path
+func (s *FileInvocationCounterStore) Save(next uint32) error {
+	file, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(strconv.FormatUint(uint64(next), 10) + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.Path)
+}
+
+// Load reads the saved value, 0 when nothing was saved yet
+func (s *FileInvocationCounterStore) Load() (uint32, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	next, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid invocation counter in %s: %w", s.Path, err)
+	}
+	return uint32(next), nil
+}
@@ -0,0 +1,100 @@
+package security_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+// recordingStore records the saved values
+type recordingStore struct {
+	mutex sync.Mutex
+	saved []uint32
+	err   error
+}
+
+func (s *recordingStore) Save(next uint32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.saved = append(s.saved, next)
+	return nil
+}
+
+func TestPersistentInvocationCounter(t *testing.T) {
+	store := &recordingStore{}
+	counter := security.NewPersistentInvocationCounter(10, store, 100, 0)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 60; n++ {
+				value, err := counter.Next()
+				assert.NoError(t, err)
+				store.mutex.Lock()
+				assert.Less(t, value, store.saved[len(store.saved)-1])
+				store.mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []uint32{110, 210, 310}, store.saved)
+	assert.Equal(t, uint32(250), counter.Peek())
+
+	require.NoError(t, counter.Flush())
+	assert.Equal(t, uint32(250), store.saved[len(store.saved)-1])
+	value, err := counter.Next()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(250), value)
+	assert.Equal(t, uint32(350), store.saved[len(store.saved)-1])
+
+	store.err = assert.AnError
+	counter.Advance(400)
+	_, err = counter.Next()
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, uint32(400), counter.Peek())
+}
+
+func TestPersistentInvocationCounter_Interval(t *testing.T) {
+	store := &recordingStore{}
+	counter := security.NewPersistentInvocationCounter(0, store, 100, time.Millisecond)
+
+	_, err := counter.Next()
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	_, err = counter.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{100, 101}, store.saved)
+}
+
+func TestFileInvocationCounterStore(t *testing.T) {
+	store := security.NewFileInvocationCounterStore(filepath.Join(t.TempDir(), "invocation_counter"))
+	next, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), next)
+
+	counter := security.NewPersistentInvocationCounter(next, store, 1000, 0)
+	_, err = counter.Next()
+	require.NoError(t, err)
+	next, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1000), next)
+
+	require.NoError(t, counter.Flush())
+	next, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), next)
+
+	matches, err := filepath.Glob(store.Path + ".*")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
@@ -0,0 +1,191 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// KeyID identifies a symmetric key, the values are the key_id enum of the
+// key_transfer method of the security setup
+type KeyID uint8
+
+const (
+	// KeyIDGlobalUnicastEncryption is the global unicast encryption key (GUEK)
+	KeyIDGlobalUnicastEncryption KeyID = 0
+	// KeyIDGlobalBroadcastEncryption is the global broadcast encryption key (GBEK)
+	KeyIDGlobalBroadcastEncryption KeyID = 1
+	// KeyIDAuthentication is the global authentication key (GAK)
+	KeyIDAuthentication KeyID = 2
+	// KeyIDKeyEncryption is the master key, the key encryption key (KEK)
+	KeyIDKeyEncryption KeyID = 3
+)
+
+func (k KeyID) String() string {
+	switch k {
+	case KeyIDGlobalUnicastEncryption:
+		return "global unicast encryption key"
+	case KeyIDGlobalBroadcastEncryption:
+		return "global broadcast encryption key"
+	case KeyIDAuthentication:
+		return "authentication key"
+	case KeyIDKeyEncryption:
+		return "key encryption key"
+	default:
+		return fmt.Sprintf("key %d", uint8(k))
+	}
+}
+
+// ErrKeyNotAvailable is returned by a KeyProvider that does not hold the
+// requested key
+var ErrKeyNotAvailable = errors.New("key not available")
+
+// KeyProvider gives the keys of a security context. Ciphering, HLS and the
+// key transfer get the keys from it when they need them, so the keys can
+// stay in a HSM or a key store.
+type KeyProvider interface {
+	Key(id KeyID) ([]byte, error)
+}
+
+// KeyProviderFunc adapts a function, like a HSM callback, to a KeyProvider
+type KeyProviderFunc func(id KeyID) ([]byte, error)
+
+// Key calls the function
+func (f KeyProviderFunc) Key(id KeyID) ([]byte, error) {
+	return f(id)
+}
+
+// Keys is a KeyProvider holding the keys in memory, for keys from static
+// configuration
+type Keys struct {
+	GlobalUnicastEncryptionKey   []byte
+	GlobalBroadcastEncryptionKey []byte
+	AuthenticationKey            []byte
+	KeyEncryptionKey             []byte
+}
+
+// Key returns the key with the given id, ErrKeyNotAvailable when it is not set
+func (k *Keys) Key(id KeyID) ([]byte, error) {
+	var key []byte
+	switch id {
+	case KeyIDGlobalUnicastEncryption:
+		key = k.GlobalUnicastEncryptionKey
+	case KeyIDGlobalBroadcastEncryption:
+		key = k.GlobalBroadcastEncryptionKey
+	case KeyIDAuthentication:
+		key = k.AuthenticationKey
+	case KeyIDKeyEncryption:
+		key = k.KeyEncryptionKey
+	default:
+		return nil, fmt.Errorf("unknown key id %d", uint8(id))
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%s: %w", id, ErrKeyNotAvailable)
+	}
+	return key, nil
+}
+
+// Validate checks the length of the keys that are set
+func (k *Keys) Validate() error {
+	for id := KeyIDGlobalUnicastEncryption; id <= KeyIDKeyEncryption; id++ {
+		key, err := k.Key(id)
+		if errors.Is(err, ErrKeyNotAvailable) {
+			continue
+		}
+		if err := ValidateKey(key); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// String implements fmt.Stringer without exposing the keys
+func (k *Keys) String() string {
+	return fmt.Sprintf("Keys(unicast=%t, broadcast=%t, authentication=%t, kek=%t)",
+		k.GlobalUnicastEncryptionKey != nil, k.GlobalBroadcastEncryptionKey != nil,
+		k.AuthenticationKey != nil, k.KeyEncryptionKey != nil)
+}
+
+// ValidateKey checks that a key is an AES-128 or AES-256 key, the key sizes
+// of security suites 0, 1 and 2
+func ValidateKey(key []byte) error {
+	if len(key) != 16 && len(key) != 32 {
+		return fmt.Errorf("key should be 16 or 32 bytes, got %d", len(key))
+	}
+	return nil
+}
+
+// keyWrapIV is the default initial value of RFC 3394
+var keyWrapIV = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+// WrapKey wraps a key with the KEK with the AES key wrap of RFC 3394, as
+// done for the key_transfer method of the security setup
+func WrapKey(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, fmt.Errorf("key to wrap should be a multiple of 8 bytes of at least 16, got %d", len(key))
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEK: %w", err)
+	}
+
+	n := len(key) / 8
+	result := make([]byte, 8+len(key))
+	copy(result, keyWrapIV)
+	copy(result[8:], key)
+
+	buffer := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buffer, result[:8])
+			copy(buffer[8:], result[8*i:8*i+8])
+			block.Encrypt(buffer, buffer)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(result[:8], binary.BigEndian.Uint64(buffer[:8])^t)
+			copy(result[8*i:8*i+8], buffer[8:])
+		}
+	}
+	return result, nil
+}
+
+// UnwrapKey unwraps a key wrapped with WrapKey
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, fmt.Errorf("wrapped key should be a multiple of 8 bytes of at least 24, got %d", len(wrapped))
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEK: %w", err)
+	}
+
+	n := len(wrapped)/8 - 1
+	result := make([]byte, len(wrapped))
+	copy(result, wrapped)
+
+	buffer := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buffer[:8], binary.BigEndian.Uint64(result[:8])^t)
+			copy(buffer[8:], result[8*i:8*i+8])
+			block.Decrypt(buffer, buffer)
+			copy(result[:8], buffer[:8])
+			copy(result[8*i:8*i+8], buffer[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(result[:8], keyWrapIV) != 1 {
+		return nil, fmt.Errorf("key unwrap failed, wrong KEK or corrupted key")
+	}
+	return result[8:], nil
+}
+
+// WrapKeyWith wraps a key with the KEK of a KeyProvider
+func WrapKeyWith(provider KeyProvider, key []byte) ([]byte, error) {
+	kek, err := provider.Key(KeyIDKeyEncryption)
+	if err != nil {
+		return nil, err
+	}
+	return WrapKey(kek, key)
+}
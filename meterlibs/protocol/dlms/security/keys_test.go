@@ -0,0 +1,97 @@
+package security_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+func mustHex(t *testing.T, value string) []byte {
+	data, err := hex.DecodeString(value)
+	require.NoError(t, err)
+	return data
+}
+
+// RFC 3394 section 4.1 and 4.6
+func TestWrapKey(t *testing.T) {
+	for _, test := range []struct {
+		kek     string
+		key     string
+		wrapped string
+	}{
+		{
+			"000102030405060708090A0B0C0D0E0F",
+			"00112233445566778899AABBCCDDEEFF",
+			"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+		},
+		{
+			"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+			"00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+			"28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+		},
+	} {
+		kek, key, wrapped := mustHex(t, test.kek), mustHex(t, test.key), mustHex(t, test.wrapped)
+
+		result, err := security.WrapKey(kek, key)
+		require.NoError(t, err)
+		assert.Equal(t, wrapped, result)
+
+		result, err = security.UnwrapKey(kek, wrapped)
+		require.NoError(t, err)
+		assert.Equal(t, key, result)
+	}
+}
+
+func TestUnwrapKey_WrongKek(t *testing.T) {
+	wrapped := mustHex(t, "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+	_, err := security.UnwrapKey(mustHex(t, "000102030405060708090A0B0C0D0E00"), wrapped)
+	assert.Error(t, err)
+
+	_, err = security.WrapKey([]byte("short"), make([]byte, 16))
+	assert.Error(t, err)
+	_, err = security.WrapKey(make([]byte, 16), make([]byte, 12))
+	assert.Error(t, err)
+}
+
+func TestKeys(t *testing.T) {
+	keys := &security.Keys{
+		GlobalUnicastEncryptionKey: make([]byte, 16),
+		AuthenticationKey:          make([]byte, 32),
+		KeyEncryptionKey:           mustHex(t, "000102030405060708090A0B0C0D0E0F"),
+	}
+	require.NoError(t, keys.Validate())
+
+	key, err := keys.Key(security.KeyIDAuthentication)
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	_, err = keys.Key(security.KeyIDGlobalBroadcastEncryption)
+	assert.True(t, errors.Is(err, security.ErrKeyNotAvailable))
+	_, err = keys.Key(security.KeyID(9))
+	assert.Error(t, err)
+
+	assert.NotContains(t, keys.String(), "0001")
+
+	keys.GlobalBroadcastEncryptionKey = make([]byte, 8)
+	assert.Error(t, keys.Validate())
+}
+
+func TestKeyProviderFunc(t *testing.T) {
+	var requested []security.KeyID
+	hsm := security.KeyProviderFunc(func(id security.KeyID) ([]byte, error) {
+		requested = append(requested, id)
+		return mustHex(t, "000102030405060708090A0B0C0D0E0F"), nil
+	})
+
+	wrapped, err := security.WrapKeyWith(hsm, mustHex(t, "00112233445566778899AABBCCDDEEFF"))
+	require.NoError(t, err)
+	assert.Equal(t, mustHex(t, "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"), wrapped)
+	assert.Equal(t, []security.KeyID{security.KeyIDKeyEncryption}, requested)
+
+	_, err = security.WrapKeyWith(&security.Keys{}, make([]byte, 16))
+	assert.True(t, errors.Is(err, security.ErrKeyNotAvailable))
+}
@@ -0,0 +1,104 @@
+package security
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// SystemTitleLength is the length of a system title
+	SystemTitleLength = 8
+	// FlagIDLength is the length of the manufacturer FLAG ID at the start of a system title
+	FlagIDLength = 3
+	// MaxSystemTitleSerial is the largest serial that fits in the system title after the FLAG ID
+	MaxSystemTitleSerial = 1<<40 - 1
+)
+
+// ValidateFlagID checks that a FLAG ID is three upper case letters, as given
+// out by the FLAG association
+func ValidateFlagID(flagID string) error {
+	if len(flagID) != FlagIDLength {
+		return fmt.Errorf("FLAG ID %q should be %d letters", flagID, FlagIDLength)
+	}
+	for _, c := range []byte(flagID) {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("FLAG ID %q should only contain upper case letters", flagID)
+		}
+	}
+	return nil
+}
+
+// ValidateSystemTitle checks that a system title is 8 bytes starting with
+// the FLAG ID of the manufacturer
+func ValidateSystemTitle(systemTitle []byte) error {
+	if len(systemTitle) != SystemTitleLength {
+		return fmt.Errorf("system title %x should be %d bytes, got %d", systemTitle, SystemTitleLength, len(systemTitle))
+	}
+	if err := ValidateFlagID(string(systemTitle[:FlagIDLength])); err != nil {
+		return fmt.Errorf("system title %x: %w", systemTitle, err)
+	}
+	return nil
+}
+
+// FlagIDFromSystemTitle returns the manufacturer FLAG ID of a system title
+func FlagIDFromSystemTitle(systemTitle []byte) (string, error) {
+	if err := ValidateSystemTitle(systemTitle); err != nil {
+		return "", err
+	}
+	return string(systemTitle[:FlagIDLength]), nil
+}
+
+// NewSystemTitle creates a system title from a FLAG ID and a serial, the
+// serial fills the 5 bytes after the FLAG ID big endian
+func NewSystemTitle(flagID string, serial uint64) ([]byte, error) {
+	if err := ValidateFlagID(flagID); err != nil {
+		return nil, err
+	}
+	if serial > MaxSystemTitleSerial {
+		return nil, fmt.Errorf("serial %d does not fit in a system title, max is %d", serial, uint64(MaxSystemTitleSerial))
+	}
+
+	systemTitle := make([]byte, SystemTitleLength)
+	copy(systemTitle, flagID)
+	for i := SystemTitleLength - 1; i >= FlagIDLength; i-- {
+		systemTitle[i] = byte(serial)
+		serial >>= 8
+	}
+	return systemTitle, nil
+}
+
+// SystemTitleFromCertificate returns the system title of a DLMS public key
+// certificate. The common name of the subject holds the system title as 16
+// hex characters.
+func SystemTitleFromCertificate(certificate *x509.Certificate) ([]byte, error) {
+	if certificate == nil {
+		return nil, fmt.Errorf("no certificate")
+	}
+	commonName := certificate.Subject.CommonName
+	if len(commonName) != 2*SystemTitleLength {
+		return nil, fmt.Errorf("certificate common name %q is not a system title", commonName)
+	}
+	systemTitle, err := hex.DecodeString(commonName)
+	if err != nil {
+		return nil, fmt.Errorf("certificate common name %q is not a system title: %w", commonName, err)
+	}
+	if err := ValidateSystemTitle(systemTitle); err != nil {
+		return nil, err
+	}
+	return systemTitle, nil
+}
+
+// CheckCertificateSystemTitle checks that a certificate belongs to the
+// system title of the peer
+func CheckCertificateSystemTitle(certificate *x509.Certificate, systemTitle []byte) error {
+	certificateSystemTitle, err := SystemTitleFromCertificate(certificate)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(certificateSystemTitle, systemTitle) {
+		return fmt.Errorf("certificate is for system title %x, not %x", certificateSystemTitle, systemTitle)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package security_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/security"
+)
+
+func TestNewSystemTitle(t *testing.T) {
+	systemTitle, err := security.NewSystemTitle("MMM", 0x0000BC614E)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'M', 'M', 'M', 0x00, 0x00, 0xBC, 0x61, 0x4E}, systemTitle)
+	require.NoError(t, security.ValidateSystemTitle(systemTitle))
+
+	flagID, err := security.FlagIDFromSystemTitle(systemTitle)
+	require.NoError(t, err)
+	assert.Equal(t, "MMM", flagID)
+
+	systemTitle, err = security.NewSystemTitle("KFM", security.MaxSystemTitleSerial)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'K', 'F', 'M', 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, systemTitle)
+}
+
+func TestNewSystemTitle_Invalid(t *testing.T) {
+	for _, test := range []struct {
+		flagID string
+		serial uint64
+	}{
+		{"MM", 1},
+		{"MMMM", 1},
+		{"mmm", 1},
+		{"M1M", 1},
+		{"MMM", security.MaxSystemTitleSerial + 1},
+	} {
+		_, err := security.NewSystemTitle(test.flagID, test.serial)
+		assert.Error(t, err, "%s %d", test.flagID, test.serial)
+	}
+}
+
+func TestValidateSystemTitle(t *testing.T) {
+	assert.NoError(t, security.ValidateSystemTitle([]byte("MMM\x00\x00\x00\x00\x01")))
+	assert.Error(t, security.ValidateSystemTitle(nil))
+	assert.Error(t, security.ValidateSystemTitle([]byte("MMM\x00\x00\x00\x01")))
+	assert.Error(t, security.ValidateSystemTitle([]byte("MMM\x00\x00\x00\x00\x00\x01")))
+	assert.Error(t, security.ValidateSystemTitle([]byte("\x00\x00\x00\x00\x00\x00\x00\x01")))
+}
+
+func TestSystemTitleFromCertificate(t *testing.T) {
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "4D4D4D0000BC614E"}}
+
+	systemTitle, err := security.SystemTitleFromCertificate(certificate)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'M', 'M', 'M', 0x00, 0x00, 0xBC, 0x61, 0x4E}, systemTitle)
+
+	assert.NoError(t, security.CheckCertificateSystemTitle(certificate, systemTitle))
+	assert.Error(t, security.CheckCertificateSystemTitle(certificate, []byte("MMM\x00\x00\x00\x00\x01")))
+
+	for _, commonName := range []string{"", "meter", "4D4D4D0000BC61", "ZZ4D4D0000BC614E", "0000000000BC614E"} {
+		_, err := security.SystemTitleFromCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: commonName}})
+		assert.Error(t, err, commonName)
+	}
+	_, err = security.SystemTitleFromCertificate(nil)
+	assert.Error(t, err)
+}
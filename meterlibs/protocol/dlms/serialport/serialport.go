@@ -1,10 +1,8 @@
 package serialport
 
 import (
-	"encoding/hex"
 	"fmt"
 	"log"
-	"strings"
 	"sync"
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
@@ -21,6 +19,7 @@ type serialport struct {
 	dc          dlms.DataChannel
 	port        serial.Port
 	isConnected bool
+	buffer      []byte
 	logger      *log.Logger
 	mutex       sync.Mutex
 }
@@ -116,6 +115,42 @@ func (sp *serialport) Send(src []byte) error {
 		return fmt.Errorf("not connected")
 	}
 
+	return sp.write(src)
+}
+
+// Buffer queues src, it is written with the data queued before by the next Flush
+func (sp *serialport) Buffer(src []byte) error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if !sp.isConnected {
+		return fmt.Errorf("not connected")
+	}
+
+	sp.buffer = append(sp.buffer, src...)
+
+	return nil
+}
+
+// Flush writes the data queued by Buffer with one write
+func (sp *serialport) Flush() error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if len(sp.buffer) == 0 {
+		return nil
+	}
+	if !sp.isConnected {
+		return fmt.Errorf("not connected")
+	}
+
+	data := sp.buffer
+	sp.buffer = nil
+
+	return sp.write(data)
+}
+
+func (sp *serialport) write(src []byte) error {
 	_, err := sp.port.Write(src)
 	if err != nil {
 		sp.disconnect()
@@ -123,7 +158,7 @@ func (sp *serialport) Send(src []byte) error {
 	}
 
 	if sp.logger != nil {
-		sp.logger.Printf("TX (%s): %s", sp.serialPort, encodeHexString(src))
+		sp.logger.Printf("TX (%s): %d bytes", sp.serialPort, len(src))
 	}
 
 	return nil
@@ -158,6 +193,8 @@ func (sp *serialport) manager() {
 }
 
 func (sp *serialport) disconnect() {
+	sp.buffer = nil
+
 	if sp.isConnected {
 		sp.isConnected = false
 
@@ -186,12 +223,8 @@ func (sp *serialport) read() ([]byte, error) {
 	}
 
 	if sp.logger != nil && rxLen > 0 {
-		sp.logger.Printf("RX (%s): %s", sp.serialPort, encodeHexString(rxBuffer[:rxLen]))
+		sp.logger.Printf("RX (%s): %d bytes", sp.serialPort, rxLen)
 	}
 
 	return rxBuffer[:rxLen], nil
 }
-
-func encodeHexString(b []byte) string {
-	return strings.ToUpper(hex.EncodeToString(b))
-}
@@ -2,10 +2,8 @@ package dlms
 
 import (
 	"fmt"
-	"reflect"
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
-	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
 )
 
@@ -29,6 +27,8 @@ var (
 	AwaitingGetResponse              = &State{name: "AWAITING_GET_RESPONSE"}
 	AwaitingGetBlockResponse         = &State{name: "AWAITING_GET_BLOCK_RESPONSE"}
 	ShouldAckLastGetBlock            = &State{name: "SHOULD_ACK_LAST_GET_BLOCK"}
+	AwaitingActionBlockResponse      = &State{name: "AWAITING_ACTION_BLOCK_RESPONSE"}
+	ShouldAckLastActionBlock         = &State{name: "SHOULD_ACK_LAST_ACTION_BLOCK"}
 	AwaitingSetResponse              = &State{name: "AWAITING_SET_RESPONSE"}
 	ShouldSendHlsServerChallengeResult = &State{name: "SHOULD_SEND_HLS_SEVER_CHALLENGE_RESULT"}
 	AwaitingHlsClientChallengeResult  = &State{name: "AWAITING_HLS_CLIENT_CHALLENGE_RESULT"}
@@ -36,6 +36,37 @@ var (
 	NeedData                          = &State{name: "NEED_DATA"}
 )
 
+// EventKind identifies the events of the state machine. An APDU is the event
+// of its xdlms.ApduKind, the flow control events have the kinds from 0x100.
+type EventKind uint16
+
+const (
+	EventHlsStart EventKind = 0x100 + iota
+	EventHlsSuccess
+	EventHlsFailed
+	EventRejectAssociation
+	EventEndAssociation
+)
+
+var flowControlEventNames = map[EventKind]string{
+	EventHlsStart:          "HlsStart",
+	EventHlsSuccess:        "HlsSuccess",
+	EventHlsFailed:         "HlsFailed",
+	EventRejectAssociation: "RejectAssociation",
+	EventEndAssociation:    "EndAssociation",
+}
+
+// String implements fmt.Stringer
+func (k EventKind) String() string {
+	if k < 0x100 {
+		return xdlms.ApduKind(k).String()
+	}
+	if name, ok := flowControlEventNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
 // Flow control events
 type HlsStart struct{}
 
@@ -47,22 +78,77 @@ type RejectAssociation struct{}
 
 type EndAssociation struct{}
 
+// EventKind returns EventHlsStart
+func (HlsStart) EventKind() EventKind { return EventHlsStart }
+
+// EventKind returns EventHlsSuccess
+func (HlsSuccess) EventKind() EventKind { return EventHlsSuccess }
+
+// EventKind returns EventHlsFailed
+func (HlsFailed) EventKind() EventKind { return EventHlsFailed }
+
+// EventKind returns EventRejectAssociation
+func (RejectAssociation) EventKind() EventKind { return EventRejectAssociation }
+
+// EventKind returns EventEndAssociation
+func (EndAssociation) EventKind() EventKind { return EventEndAssociation }
+
+// flowControlEvent is implemented by the flow control events, by value and
+// by pointer
+type flowControlEvent interface {
+	EventKind() EventKind
+}
+
+// eventKind returns the kind of an APDU or a flow control event, ok is false
+// for other values
+func eventKind(event interface{}) (kind EventKind, ok bool) {
+	switch e := event.(type) {
+	case xdlms.Apdu:
+		return EventKind(e.Kind()), true
+	case flowControlEvent:
+		return e.EventKind(), true
+	default:
+		return 0, false
+	}
+}
+
+// DefaultHistorySize is the number of transitions kept by a new DlmsConnectionState
+const DefaultHistorySize = 32
+
+// Transition is a state change caused by an event
+type Transition struct {
+	From  *State
+	To    *State
+	Event interface{}
+}
+
+// String returns the string representation of the transition
+func (t Transition) String() string {
+	if kind, ok := eventKind(t.Event); ok {
+		return fmt.Sprintf("%s -> %s (%s)", t.From, t.To, kind)
+	}
+	return fmt.Sprintf("%s -> %s (%T)", t.From, t.To, t.Event)
+}
+
 // DlmsConnectionState handles state changes in DLMS
 type DlmsConnectionState struct {
 	currentState *State
+	onTransition func(from *State, to *State, event interface{})
+	history      []Transition
+	historyNext  int
+	historyFull  bool
 }
 
 // NewDlmsConnectionState creates a new DLMS connection state
 func NewDlmsConnectionState() *DlmsConnectionState {
-	return &DlmsConnectionState{
-		currentState: NoAssociation,
-	}
+	return NewDlmsConnectionStateWithState(NoAssociation)
 }
 
 // NewDlmsConnectionStateWithState creates a new DLMS connection state with a specific state
 func NewDlmsConnectionStateWithState(state *State) *DlmsConnectionState {
 	return &DlmsConnectionState{
 		currentState: state,
+		history:      make([]Transition, DefaultHistorySize),
 	}
 }
 
@@ -71,94 +157,212 @@ func (d *DlmsConnectionState) CurrentState() *State {
 	return d.currentState
 }
 
-// ProcessEvent processes an event and transitions the state machine
+// Reset moves the state back to NoAssociation, e.g. after the transport was lost.
+// The reset is recorded in the history as a transition without event.
+func (d *DlmsConnectionState) Reset() {
+	if d.currentState == NoAssociation {
+		return
+	}
+	oldState := d.currentState
+	d.currentState = NoAssociation
+	d.record(Transition{From: oldState, To: NoAssociation, Event: nil})
+	if d.onTransition != nil {
+		d.onTransition(oldState, NoAssociation, nil)
+	}
+}
+
+// OnTransition sets a callback called after every state change
+func (d *DlmsConnectionState) OnTransition(callback func(from *State, to *State, event interface{})) {
+	d.onTransition = callback
+}
+
+// SetHistorySize changes the number of transitions kept in the history and clears it.
+// A size of 0 disables the history.
+func (d *DlmsConnectionState) SetHistorySize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	d.history = make([]Transition, size)
+	d.historyNext = 0
+	d.historyFull = false
+}
+
+// History returns the last transitions, oldest first
+func (d *DlmsConnectionState) History() []Transition {
+	if !d.historyFull {
+		return append([]Transition{}, d.history[:d.historyNext]...)
+	}
+	history := make([]Transition, 0, len(d.history))
+	history = append(history, d.history[d.historyNext:]...)
+	return append(history, d.history[:d.historyNext]...)
+}
+
+// States returns the states passed through in the history, starting with the
+// state before the oldest transition
+func (d *DlmsConnectionState) States() []*State {
+	history := d.History()
+	if len(history) == 0 {
+		return []*State{d.currentState}
+	}
+	states := make([]*State, 0, len(history)+1)
+	states = append(states, history[0].From)
+	for _, transition := range history {
+		states = append(states, transition.To)
+	}
+	return states
+}
+
+func (d *DlmsConnectionState) record(transition Transition) {
+	if len(d.history) == 0 {
+		return
+	}
+	d.history[d.historyNext] = transition
+	d.historyNext++
+	if d.historyNext == len(d.history) {
+		d.historyNext = 0
+		d.historyFull = true
+	}
+}
+
+// ProcessApdu processes a sent or received APDU and transitions the state machine
+func (d *DlmsConnectionState) ProcessApdu(apdu xdlms.Apdu) error {
+	if apdu == nil {
+		return exceptions.NewLocalDlmsProtocolError(
+			fmt.Sprintf("can't handle empty APDU when state=%s", d.currentState),
+		)
+	}
+	return d.transitionState(apdu, apdu.String)
+}
+
+// ProcessEvent processes an event and transitions the state machine.
+// Events are APDUs, passed by pointer, or flow control events, passed by
+// value or by pointer.
 func (d *DlmsConnectionState) ProcessEvent(event interface{}) error {
-	eventType := reflect.TypeOf(event)
-	return d.transitionState(eventType)
+	if apdu, ok := event.(xdlms.Apdu); ok {
+		return d.ProcessApdu(apdu)
+	}
+	kind, ok := eventKind(event)
+	if !ok {
+		return exceptions.NewLocalDlmsProtocolError(
+			fmt.Sprintf("can't handle unknown event %T when state=%s", event, d.currentState),
+		)
+	}
+	return d.transitionState(event, func() string { return fmt.Sprintf("event %s", kind) })
 }
 
-// transitionState transitions the state based on event type
-func (d *DlmsConnectionState) transitionState(eventType reflect.Type) error {
+// transitionState transitions the state based on the kind of the event, the
+// event is only described when it can't be handled
+func (d *DlmsConnectionState) transitionState(event interface{}, describe func() string) error {
 	transitions, ok := dlmsStateTransitions[d.currentState]
 	if !ok {
 		return fmt.Errorf("no transitions defined for state %s", d.currentState)
 	}
 
-	newState, ok := transitions[eventType]
+	kind, _ := eventKind(event)
+	newState, ok := transitions[kind]
 	if !ok {
 		return exceptions.NewLocalDlmsProtocolError(
-			fmt.Sprintf("can't handle event type %s when state=%s", eventType, d.currentState),
+			fmt.Sprintf("can't handle %s when state=%s", describe(), d.currentState),
 		)
 	}
 
 	oldState := d.currentState
 	d.currentState = newState
-	// TODO: Add logging here if needed
-	_ = oldState
+	d.record(Transition{From: oldState, To: newState, Event: event})
+	if d.onTransition != nil {
+		d.onTransition(oldState, newState, event)
+	}
 	return nil
 }
 
 // dlmsStateTransitions defines the state transition table
-var dlmsStateTransitions = map[*State]map[reflect.Type]*State{
+var dlmsStateTransitions = map[*State]map[EventKind]*State{
 	NoAssociation: {
-		reflect.TypeOf((*acse.ApplicationAssociationRequest)(nil)).Elem(): AwaitingAssociationResponse,
+		EventKind(xdlms.ApduKindApplicationAssociationRequest): AwaitingAssociationResponse,
 	},
 	AwaitingAssociationResponse: {
-		reflect.TypeOf((*acse.ApplicationAssociationResponse)(nil)).Elem(): Ready,
-		reflect.TypeOf((*xdlms.ExceptionResponse)(nil)).Elem(): NoAssociation,
+		EventKind(xdlms.ApduKindApplicationAssociationResponse): Ready,
+		EventKind(xdlms.ApduKindExceptionResponse): NoAssociation,
+		EventKind(xdlms.ApduKindConfirmedServiceError): NoAssociation,
 	},
 	Ready: {
-		reflect.TypeOf((*acse.ReleaseRequest)(nil)).Elem(): AwaitingReleaseResponse,
-		reflect.TypeOf((*xdlms.GetRequestNormal)(nil)).Elem(): AwaitingGetResponse,
-		// TODO: GetRequestWithList is not yet implemented
-		// reflect.TypeOf((*xdlms.GetRequestWithList)(nil)).Elem(): AwaitingGetResponse,
-		reflect.TypeOf((*xdlms.SetRequestNormal)(nil)).Elem(): AwaitingSetResponse,
-		reflect.TypeOf((*HlsStart)(nil)).Elem(): ShouldSendHlsServerChallengeResult,
-		reflect.TypeOf((*RejectAssociation)(nil)).Elem(): NoAssociation,
-		reflect.TypeOf((*xdlms.ActionRequestNormal)(nil)).Elem(): AwaitingActionResponse,
-		reflect.TypeOf((*xdlms.DataNotification)(nil)).Elem(): Ready,
-		reflect.TypeOf((*EndAssociation)(nil)).Elem(): NoAssociation,
+		EventKind(xdlms.ApduKindReleaseRequest): AwaitingReleaseResponse,
+		EventKind(xdlms.ApduKindGetRequestNormal): AwaitingGetResponse,
+		// a block transfer interrupted on a previous association is resumed
+		EventKind(xdlms.ApduKindGetRequestNext): AwaitingGetBlockResponse,
+		EventKind(xdlms.ApduKindGetRequestWithList): AwaitingGetResponse,
+		EventKind(xdlms.ApduKindSetRequestNormal): AwaitingSetResponse,
+		EventHlsStart: ShouldSendHlsServerChallengeResult,
+		EventRejectAssociation: NoAssociation,
+		EventKind(xdlms.ApduKindActionRequestNormal): AwaitingActionResponse,
+		EventKind(xdlms.ApduKindDataNotification): Ready,
+		EventKind(xdlms.ApduKindEventNotification): Ready,
+		EventEndAssociation: NoAssociation,
 	},
 	ShouldSendHlsServerChallengeResult: {
-		reflect.TypeOf((*xdlms.ActionRequestNormal)(nil)).Elem(): AwaitingHlsClientChallengeResult,
+		EventKind(xdlms.ApduKindActionRequestNormal): AwaitingHlsClientChallengeResult,
 	},
 	AwaitingHlsClientChallengeResult: {
-		reflect.TypeOf((*xdlms.ActionResponseNormalWithData)(nil)).Elem(): HlsDone,
-		reflect.TypeOf((*xdlms.ActionResponseNormal)(nil)).Elem(): NoAssociation,
-		reflect.TypeOf((*xdlms.ActionResponseNormalWithError)(nil)).Elem(): NoAssociation,
+		EventKind(xdlms.ApduKindActionResponseNormalWithData): HlsDone,
+		EventKind(xdlms.ApduKindActionResponseNormal): NoAssociation,
+		EventKind(xdlms.ApduKindActionResponseNormalWithError): NoAssociation,
 	},
 	HlsDone: {
-		reflect.TypeOf((*HlsSuccess)(nil)).Elem(): Ready,
-		reflect.TypeOf((*HlsFailed)(nil)).Elem(): NoAssociation,
+		EventHlsSuccess: Ready,
+		EventHlsFailed: NoAssociation,
 	},
 	AwaitingGetResponse: {
-		reflect.TypeOf((*xdlms.GetResponseNormal)(nil)).Elem(): Ready,
-		// TODO: GetResponseWithList is not yet implemented
-		// reflect.TypeOf((*xdlms.GetResponseWithList)(nil)).Elem(): Ready,
-		reflect.TypeOf((*xdlms.GetResponseWithDataBlock)(nil)).Elem(): ShouldAckLastGetBlock,
-		reflect.TypeOf((*xdlms.GetResponseNormalWithError)(nil)).Elem(): Ready,
-		reflect.TypeOf((*xdlms.ExceptionResponse)(nil)).Elem(): Ready,
+		EventKind(xdlms.ApduKindGetResponseNormal): Ready,
+		EventKind(xdlms.ApduKindGetResponseWithList): Ready,
+		EventKind(xdlms.ApduKindGetResponseWithDataBlock): ShouldAckLastGetBlock,
+		EventKind(xdlms.ApduKindGetResponseLastBlock): Ready,
+		EventKind(xdlms.ApduKindGetResponseNormalWithError): Ready,
+		EventKind(xdlms.ApduKindExceptionResponse): Ready,
+		EventKind(xdlms.ApduKindConfirmedServiceError): Ready,
 	},
 	AwaitingGetBlockResponse: {
-		reflect.TypeOf((*xdlms.GetResponseWithDataBlock)(nil)).Elem(): ShouldAckLastGetBlock,
-		reflect.TypeOf((*xdlms.GetResponseNormalWithError)(nil)).Elem(): Ready,
-		reflect.TypeOf((*xdlms.ExceptionResponse)(nil)).Elem(): Ready,
-		// TODO: Add GetResponseLastBlockWithError and GetResponseLastBlock when implemented
+		EventKind(xdlms.ApduKindGetResponseWithDataBlock): ShouldAckLastGetBlock,
+		EventKind(xdlms.ApduKindGetResponseLastBlock): Ready,
+		EventKind(xdlms.ApduKindGetResponseLastBlockWithError): Ready,
+		EventKind(xdlms.ApduKindGetResponseNormalWithError): Ready,
+		EventKind(xdlms.ApduKindExceptionResponse): Ready,
+		EventKind(xdlms.ApduKindConfirmedServiceError): Ready,
 	},
 	AwaitingSetResponse: {
-		reflect.TypeOf((*xdlms.SetResponseNormal)(nil)).Elem(): Ready,
+		EventKind(xdlms.ApduKindSetResponseNormal): Ready,
+		EventKind(xdlms.ApduKindExceptionResponse): Ready,
+		EventKind(xdlms.ApduKindConfirmedServiceError): Ready,
 	},
 	AwaitingActionResponse: {
-		reflect.TypeOf((*xdlms.ActionResponseNormal)(nil)).Elem(): Ready,
-		reflect.TypeOf((*xdlms.ActionResponseNormalWithData)(nil)).Elem(): Ready,
-		reflect.TypeOf((*xdlms.ActionResponseNormalWithError)(nil)).Elem(): Ready,
+		EventKind(xdlms.ApduKindActionResponseNormal): Ready,
+		EventKind(xdlms.ApduKindActionResponseNormalWithData): Ready,
+		EventKind(xdlms.ApduKindActionResponseNormalWithError): Ready,
+		EventKind(xdlms.ApduKindActionResponseWithPBlock): ShouldAckLastActionBlock,
+		EventKind(xdlms.ApduKindActionResponseLastPBlock): Ready,
+		EventKind(xdlms.ApduKindExceptionResponse): Ready,
+		EventKind(xdlms.ApduKindConfirmedServiceError): Ready,
+	},
+	ShouldAckLastActionBlock: {
+		EventKind(xdlms.ApduKindActionRequestNextPBlock): AwaitingActionBlockResponse,
+	},
+	AwaitingActionBlockResponse: {
+		EventKind(xdlms.ApduKindActionResponseWithPBlock): ShouldAckLastActionBlock,
+		EventKind(xdlms.ApduKindActionResponseLastPBlock): Ready,
+		EventKind(xdlms.ApduKindActionResponseNormal): Ready,
+		EventKind(xdlms.ApduKindActionResponseNormalWithError): Ready,
+		EventKind(xdlms.ApduKindExceptionResponse): Ready,
+		EventKind(xdlms.ApduKindConfirmedServiceError): Ready,
 	},
 	ShouldAckLastGetBlock: {
-		reflect.TypeOf((*xdlms.GetRequestNext)(nil)).Elem(): AwaitingGetBlockResponse,
+		EventKind(xdlms.ApduKindGetRequestNext): AwaitingGetBlockResponse,
+		// a new GET abandons the block transfer, e.g. a resumed transfer that
+		// the meter didn't continue with the expected block
+		EventKind(xdlms.ApduKindGetRequestNormal): AwaitingGetResponse,
 	},
 	AwaitingReleaseResponse: {
-		reflect.TypeOf((*acse.ReleaseResponse)(nil)).Elem(): NoAssociation,
-		reflect.TypeOf((*xdlms.ExceptionResponse)(nil)).Elem(): Ready,
+		EventKind(xdlms.ApduKindReleaseResponse): NoAssociation,
+		EventKind(xdlms.ApduKindExceptionResponse): Ready,
+		EventKind(xdlms.ApduKindConfirmedServiceError): Ready,
 	},
 }
 
@@ -0,0 +1,275 @@
+package dlms_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/exceptions"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+func TestDlmsConnectionState_OnTransition(t *testing.T) {
+	state := dlms.NewDlmsConnectionState()
+
+	transitions := make([]string, 0)
+	state.OnTransition(func(from *dlms.State, to *dlms.State, event interface{}) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	assert.NoError(t, state.ProcessEvent(&acse.ApplicationAssociationRequest{}))
+	assert.NoError(t, state.ProcessEvent(&acse.ApplicationAssociationResponse{}))
+	assert.NoError(t, state.ProcessEvent(&xdlms.GetRequestNormal{}))
+	assert.Error(t, state.ProcessEvent(&xdlms.SetRequestNormal{}))
+
+	assert.Equal(t, []string{
+		"NO_ASSOCIATION->AWAITING_ASSOCIATION_RESPONSE",
+		"AWAITING_ASSOCIATION_RESPONSE->READY",
+		"READY->AWAITING_GET_RESPONSE",
+	}, transitions)
+	assert.Equal(t, []*dlms.State{
+		dlms.NoAssociation,
+		dlms.AwaitingAssociationResponse,
+		dlms.Ready,
+		dlms.AwaitingGetResponse,
+	}, state.States())
+}
+
+func TestDlmsConnectionState_HistoryRing(t *testing.T) {
+	state := dlms.NewDlmsConnectionStateWithState(dlms.Ready)
+	state.SetHistorySize(3)
+
+	assert.NoError(t, state.ProcessEvent(&xdlms.GetRequestNormal{}))
+	assert.NoError(t, state.ProcessEvent(&xdlms.GetResponseNormal{}))
+	assert.NoError(t, state.ProcessEvent(&xdlms.SetRequestNormal{}))
+	assert.NoError(t, state.ProcessEvent(&xdlms.SetResponseNormal{}))
+
+	history := state.History()
+	assert.Len(t, history, 3)
+	assert.Equal(t, dlms.AwaitingGetResponse, history[0].From)
+	assert.Equal(t, dlms.Ready, history[2].To)
+	assert.IsType(t, &xdlms.SetResponseNormal{}, history[2].Event)
+}
+
+// stateTransitions are all the transitions of the state machine
+var stateTransitions = []struct {
+	from  *dlms.State
+	event interface{}
+	to    *dlms.State
+}{
+	{dlms.NoAssociation, &acse.ApplicationAssociationRequest{}, dlms.AwaitingAssociationResponse},
+	{dlms.AwaitingAssociationResponse, &acse.ApplicationAssociationResponse{}, dlms.Ready},
+	{dlms.AwaitingAssociationResponse, &xdlms.ExceptionResponse{}, dlms.NoAssociation},
+	{dlms.AwaitingAssociationResponse, &xdlms.ConfirmedServiceError{}, dlms.NoAssociation},
+	{dlms.Ready, &acse.ReleaseRequest{}, dlms.AwaitingReleaseResponse},
+	{dlms.Ready, &xdlms.GetRequestNormal{}, dlms.AwaitingGetResponse},
+	{dlms.Ready, &xdlms.GetRequestNext{}, dlms.AwaitingGetBlockResponse},
+	{dlms.Ready, &xdlms.GetRequestWithList{}, dlms.AwaitingGetResponse},
+	{dlms.Ready, &xdlms.SetRequestNormal{}, dlms.AwaitingSetResponse},
+	{dlms.Ready, dlms.HlsStart{}, dlms.ShouldSendHlsServerChallengeResult},
+	{dlms.Ready, dlms.RejectAssociation{}, dlms.NoAssociation},
+	{dlms.Ready, &xdlms.ActionRequestNormal{}, dlms.AwaitingActionResponse},
+	{dlms.Ready, &xdlms.DataNotification{}, dlms.Ready},
+	{dlms.Ready, &xdlms.EventNotification{}, dlms.Ready},
+	{dlms.Ready, dlms.EndAssociation{}, dlms.NoAssociation},
+	{dlms.ShouldSendHlsServerChallengeResult, &xdlms.ActionRequestNormal{}, dlms.AwaitingHlsClientChallengeResult},
+	{dlms.AwaitingHlsClientChallengeResult, &xdlms.ActionResponseNormalWithData{}, dlms.HlsDone},
+	{dlms.AwaitingHlsClientChallengeResult, &xdlms.ActionResponseNormal{}, dlms.NoAssociation},
+	{dlms.AwaitingHlsClientChallengeResult, &xdlms.ActionResponseNormalWithError{}, dlms.NoAssociation},
+	{dlms.HlsDone, dlms.HlsSuccess{}, dlms.Ready},
+	{dlms.HlsDone, dlms.HlsFailed{}, dlms.NoAssociation},
+	{dlms.AwaitingGetResponse, &xdlms.GetResponseNormal{}, dlms.Ready},
+	{dlms.AwaitingGetResponse, &xdlms.GetResponseWithList{}, dlms.Ready},
+	{dlms.AwaitingGetResponse, &xdlms.GetResponseWithDataBlock{}, dlms.ShouldAckLastGetBlock},
+	{dlms.AwaitingGetResponse, &xdlms.GetResponseLastBlock{}, dlms.Ready},
+	{dlms.AwaitingGetResponse, &xdlms.GetResponseNormalWithError{}, dlms.Ready},
+	{dlms.AwaitingGetResponse, &xdlms.ExceptionResponse{}, dlms.Ready},
+	{dlms.AwaitingGetResponse, &xdlms.ConfirmedServiceError{}, dlms.Ready},
+	{dlms.AwaitingGetBlockResponse, &xdlms.GetResponseWithDataBlock{}, dlms.ShouldAckLastGetBlock},
+	{dlms.AwaitingGetBlockResponse, &xdlms.GetResponseLastBlock{}, dlms.Ready},
+	{dlms.AwaitingGetBlockResponse, &xdlms.GetResponseLastBlockWithError{}, dlms.Ready},
+	{dlms.AwaitingGetBlockResponse, &xdlms.GetResponseNormalWithError{}, dlms.Ready},
+	{dlms.AwaitingGetBlockResponse, &xdlms.ExceptionResponse{}, dlms.Ready},
+	{dlms.AwaitingGetBlockResponse, &xdlms.ConfirmedServiceError{}, dlms.Ready},
+	{dlms.AwaitingSetResponse, &xdlms.SetResponseNormal{}, dlms.Ready},
+	{dlms.AwaitingSetResponse, &xdlms.ExceptionResponse{}, dlms.Ready},
+	{dlms.AwaitingSetResponse, &xdlms.ConfirmedServiceError{}, dlms.Ready},
+	{dlms.AwaitingActionResponse, &xdlms.ActionResponseNormal{}, dlms.Ready},
+	{dlms.AwaitingActionResponse, &xdlms.ActionResponseNormalWithData{}, dlms.Ready},
+	{dlms.AwaitingActionResponse, &xdlms.ActionResponseNormalWithError{}, dlms.Ready},
+	{dlms.AwaitingActionResponse, &xdlms.ActionResponseWithPBlock{}, dlms.ShouldAckLastActionBlock},
+	{dlms.AwaitingActionResponse, &xdlms.ActionResponseLastPBlock{}, dlms.Ready},
+	{dlms.AwaitingActionResponse, &xdlms.ExceptionResponse{}, dlms.Ready},
+	{dlms.AwaitingActionResponse, &xdlms.ConfirmedServiceError{}, dlms.Ready},
+	{dlms.ShouldAckLastActionBlock, &xdlms.ActionRequestNextPBlock{}, dlms.AwaitingActionBlockResponse},
+	{dlms.AwaitingActionBlockResponse, &xdlms.ActionResponseWithPBlock{}, dlms.ShouldAckLastActionBlock},
+	{dlms.AwaitingActionBlockResponse, &xdlms.ActionResponseLastPBlock{}, dlms.Ready},
+	{dlms.AwaitingActionBlockResponse, &xdlms.ActionResponseNormal{}, dlms.Ready},
+	{dlms.AwaitingActionBlockResponse, &xdlms.ActionResponseNormalWithError{}, dlms.Ready},
+	{dlms.AwaitingActionBlockResponse, &xdlms.ExceptionResponse{}, dlms.Ready},
+	{dlms.AwaitingActionBlockResponse, &xdlms.ConfirmedServiceError{}, dlms.Ready},
+	{dlms.ShouldAckLastGetBlock, &xdlms.GetRequestNext{}, dlms.AwaitingGetBlockResponse},
+	{dlms.ShouldAckLastGetBlock, &xdlms.GetRequestNormal{}, dlms.AwaitingGetResponse},
+	{dlms.AwaitingReleaseResponse, &acse.ReleaseResponse{}, dlms.NoAssociation},
+	{dlms.AwaitingReleaseResponse, &xdlms.ExceptionResponse{}, dlms.Ready},
+	{dlms.AwaitingReleaseResponse, &xdlms.ConfirmedServiceError{}, dlms.Ready},
+}
+
+// allEvents returns an event of every kind
+func allEvents() []interface{} {
+	// the notifications are described when rejected
+	invokeID := xdlms.NewLongInvokeIdAndPriority(1, false, false, false, false)
+	return []interface{}{
+		&xdlms.InitiateRequest{},
+		&xdlms.InitiateResponse{},
+		&xdlms.GlobalCipherInitiateRequest{},
+		&xdlms.GlobalCipherInitiateResponse{},
+		&xdlms.ConfirmedServiceError{},
+		&xdlms.ExceptionResponse{},
+		xdlms.NewDataNotification(invokeID, nil, nil),
+		xdlms.NewEventNotification(invokeID, nil, nil),
+		&xdlms.GeneralBlockTransfer{},
+		&xdlms.GeneralGlobalCipher{},
+		&xdlms.GetRequestNormal{},
+		&xdlms.GetRequestNext{},
+		&xdlms.GetRequestWithList{},
+		&xdlms.GetResponseNormal{},
+		&xdlms.GetResponseNormalWithError{},
+		&xdlms.GetResponseWithDataBlock{},
+		&xdlms.GetResponseWithList{},
+		&xdlms.GetResponseLastBlock{},
+		&xdlms.GetResponseLastBlockWithError{},
+		&xdlms.SetRequestNormal{},
+		&xdlms.SetResponseNormal{},
+		&xdlms.ActionRequestNormal{},
+		&xdlms.ActionRequestNextPBlock{},
+		&xdlms.ActionResponseNormal{},
+		&xdlms.ActionResponseNormalWithData{},
+		&xdlms.ActionResponseNormalWithError{},
+		&xdlms.ActionResponseWithPBlock{},
+		&xdlms.ActionResponseLastPBlock{},
+		&acse.ApplicationAssociationRequest{},
+		&acse.ApplicationAssociationResponse{},
+		&acse.ReleaseRequest{},
+		&acse.ReleaseResponse{},
+		dlms.EndAssociation{},
+		dlms.HlsFailed{},
+		dlms.HlsStart{},
+		dlms.HlsSuccess{},
+		dlms.RejectAssociation{},
+	}
+}
+
+func TestDlmsConnectionState_Transitions(t *testing.T) {
+	for _, transition := range stateTransitions {
+		t.Run(fmt.Sprintf("%s/%T", transition.from, transition.event), func(t *testing.T) {
+			state := dlms.NewDlmsConnectionStateWithState(transition.from)
+			require.NoError(t, state.ProcessEvent(transition.event))
+			assert.Equal(t, transition.to, state.CurrentState())
+		})
+	}
+}
+
+func TestDlmsConnectionState_RejectsOtherEvents(t *testing.T) {
+	for _, from := range []*dlms.State{
+		dlms.NoAssociation,
+		dlms.AwaitingAssociationResponse,
+		dlms.Ready,
+		dlms.ShouldSendHlsServerChallengeResult,
+		dlms.AwaitingHlsClientChallengeResult,
+		dlms.HlsDone,
+		dlms.AwaitingGetResponse,
+		dlms.AwaitingGetBlockResponse,
+		dlms.AwaitingSetResponse,
+		dlms.AwaitingActionResponse,
+		dlms.ShouldAckLastActionBlock,
+		dlms.AwaitingActionBlockResponse,
+		dlms.ShouldAckLastGetBlock,
+		dlms.AwaitingReleaseResponse,
+	} {
+		for _, event := range allEvents() {
+			expected := false
+			for _, transition := range stateTransitions {
+				if transition.from == from && fmt.Sprintf("%T", transition.event) == fmt.Sprintf("%T", event) {
+					expected = true
+				}
+			}
+			if expected {
+				continue
+			}
+			state := dlms.NewDlmsConnectionStateWithState(from)
+			assert.Error(t, state.ProcessEvent(event), "%T when state=%s", event, from)
+			assert.Equal(t, from, state.CurrentState())
+		}
+	}
+}
+
+func TestApdus_Kind(t *testing.T) {
+	// every standard APDU implements xdlms.Apdu and has its own kind
+	apdus := []xdlms.Apdu{}
+	for _, event := range allEvents() {
+		if apdu, ok := event.(xdlms.Apdu); ok {
+			apdus = append(apdus, apdu)
+		}
+	}
+	require.Len(t, apdus, 32)
+
+	kinds := make(map[xdlms.ApduKind]string)
+	for _, apdu := range apdus {
+		kind := apdu.Kind()
+		assert.NotEqual(t, xdlms.ApduKindUnknown, kind, "%T", apdu)
+		if other, ok := kinds[kind]; ok && other != fmt.Sprintf("%T", apdu) {
+			t.Errorf("%T and %s have the same kind %s", apdu, other, kind)
+		}
+		kinds[kind] = fmt.Sprintf("%T", apdu)
+	}
+	assert.Len(t, kinds, 32)
+}
+
+func TestDlmsConnectionState_ProcessApduRejects(t *testing.T) {
+	factory := acse.NewApduFactory()
+	for _, test := range []struct {
+		state   *dlms.State
+		encoded []byte
+	}{
+		// GET response without a GET request
+		{dlms.Ready, []byte{0xC4, 0x01, 0xC1, 0x00, 0x09, 0x01, 0x02}},
+		// SET response to a GET request
+		{dlms.AwaitingGetResponse, []byte{0xC5, 0x01, 0xC1, 0x00}},
+		// AARE without an AARQ
+		{dlms.Ready, mustDecodeHex(t, "6129A109060760857405080101A203020100A305A103020100BE10040E0800065F1F040000B01D04000007")},
+	} {
+		apdu, err := factory.APDUFromBytes(test.encoded)
+		require.NoError(t, err)
+
+		state := dlms.NewDlmsConnectionStateWithState(test.state)
+		err = state.ProcessApdu(apdu)
+		var protocolError *exceptions.LocalDlmsProtocolError
+		require.ErrorAs(t, err, &protocolError, "%s when state=%s", apdu, test.state)
+		assert.Contains(t, err.Error(), test.state.String())
+		assert.Equal(t, test.state, state.CurrentState())
+		assert.Empty(t, state.History())
+	}
+
+	state := dlms.NewDlmsConnectionStateWithState(dlms.Ready)
+	assert.Error(t, state.ProcessApdu(nil))
+}
+
+func TestDlmsConnectionState_FlowControlEventByPointer(t *testing.T) {
+	state := dlms.NewDlmsConnectionStateWithState(dlms.Ready)
+	require.NoError(t, state.ProcessEvent(&dlms.HlsStart{}))
+	assert.Equal(t, dlms.ShouldSendHlsServerChallengeResult, state.CurrentState())
+	assert.Equal(t, "READY -> SHOULD_SEND_HLS_SEVER_CHALLENGE_RESULT (HlsStart)", state.History()[0].String())
+
+	assert.Error(t, state.ProcessEvent("HlsStart"))
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return data
+}
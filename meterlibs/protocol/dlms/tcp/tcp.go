@@ -1,13 +1,11 @@
 package tcp
 
 import (
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
 	"net"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -25,6 +23,7 @@ type tcp struct {
 	dc          dlms.DataChannel
 	conn        net.Conn
 	isConnected bool
+	buffer      []byte
 	logger      *log.Logger
 	mutex       sync.Mutex
 }
@@ -122,6 +121,42 @@ func (t *tcp) Send(src []byte) error {
 		return fmt.Errorf("not connected")
 	}
 
+	return t.write(src)
+}
+
+// Buffer queues src, it is written with the data queued before by the next Flush
+func (t *tcp) Buffer(src []byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.isConnected {
+		return fmt.Errorf("not connected")
+	}
+
+	t.buffer = append(t.buffer, src...)
+
+	return nil
+}
+
+// Flush writes the data queued by Buffer with one write
+func (t *tcp) Flush() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.buffer) == 0 {
+		return nil
+	}
+	if !t.isConnected {
+		return fmt.Errorf("not connected")
+	}
+
+	data := t.buffer
+	t.buffer = nil
+
+	return t.write(data)
+}
+
+func (t *tcp) write(src []byte) error {
 	t.conn.SetWriteDeadline(time.Now().Add(t.timeout))
 
 	_, err := t.conn.Write(src)
@@ -131,7 +166,7 @@ func (t *tcp) Send(src []byte) error {
 	}
 
 	if t.logger != nil {
-		t.logger.Printf("TX (%s): %s", t.host, encodeHexString(src))
+		t.logger.Printf("TX (%s): %d bytes", t.host, len(src))
 	}
 
 	return nil
@@ -166,6 +201,8 @@ func (t *tcp) manager() {
 }
 
 func (t *tcp) disconnect() {
+	t.buffer = nil
+
 	if t.isConnected {
 		t.isConnected = false
 
@@ -197,12 +234,8 @@ func (t *tcp) read() ([]byte, error) {
 	}
 
 	if t.logger != nil {
-		t.logger.Printf("RX (%s): %s", t.host, encodeHexString(rxBuffer[:rxLen]))
+		t.logger.Printf("RX (%s): %d bytes", t.host, rxLen)
 	}
 
 	return rxBuffer[:rxLen], nil
 }
-
-func encodeHexString(b []byte) string {
-	return strings.ToUpper(hex.EncodeToString(b))
-}
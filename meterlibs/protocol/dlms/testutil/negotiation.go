@@ -0,0 +1,129 @@
+package testutil
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+)
+
+// Negotiation holds the values of an association that a meter negotiates
+type Negotiation struct {
+	Conformance    *xdlms.Conformance
+	Authentication enumerations.AuthenticationMechanism
+	MaxPduSize     uint16
+}
+
+// NegotiationPolicy decides the association a scripted meter agrees to, to
+// simulate meters that restrict the services, the authentication or the PDU
+// size. There is no server in this module, NegotiatedAssociationScript is where
+// the policy answers the AARQ of a client.
+type NegotiationPolicy interface {
+	// Negotiate receives the values proposed by the client and returns the
+	// negotiated ones. An error rejects the association, and so does an
+	// authentication mechanism other than the proposed one.
+	Negotiate(proposed Negotiation) (Negotiation, error)
+}
+
+// RestrictedMeter is a NegotiationPolicy that keeps the services it supports,
+// lowers the PDU size to its own and accepts only some authentication
+// mechanisms
+type RestrictedMeter struct {
+	// Conformance are the services of the meter
+	Conformance *xdlms.Conformance
+	// MaxPduSize is the largest PDU the meter receives, 0 accepts the proposed size
+	MaxPduSize uint16
+	// Authentications are the accepted mechanisms, all when empty
+	Authentications []enumerations.AuthenticationMechanism
+}
+
+// Negotiate implements NegotiationPolicy
+func (m *RestrictedMeter) Negotiate(proposed Negotiation) (Negotiation, error) {
+	if len(m.Authentications) > 0 && !slices.Contains(m.Authentications, proposed.Authentication) {
+		return Negotiation{Authentication: m.Authentications[0]}, nil
+	}
+
+	var common []string
+	for _, name := range proposed.Conformance.Names() {
+		if m.Conformance.Has(strings.ReplaceAll(name, "-", "_")) {
+			common = append(common, name)
+		}
+	}
+	conformance, err := xdlms.ParseConformance(strings.Join(common, ","))
+	if err != nil {
+		return Negotiation{}, err
+	}
+
+	negotiated := proposed
+	negotiated.Conformance = conformance
+	if m.MaxPduSize != 0 && m.MaxPduSize < proposed.MaxPduSize {
+		negotiated.MaxPduSize = m.MaxPduSize
+	}
+	return negotiated, nil
+}
+
+// NegotiatedAssociationScript answers the AARQ of the client with the
+// association that policy negotiates. Only associations without authentication
+// or with LLS can be accepted, HLSScript runs HLS associations.
+func NegotiatedAssociationScript(policy NegotiationPolicy) []Step {
+	return []Step{
+		ExpectTag(acse.AARQTag, func(request []byte) ([][]byte, error) {
+			aare, err := negotiatedAssociationResponse(policy, request)
+			if err != nil {
+				return nil, err
+			}
+			return [][]byte{aare}, nil
+		}),
+	}
+}
+
+// negotiatedAssociationResponse builds the AARE for the AARQ in request
+func negotiatedAssociationResponse(policy NegotiationPolicy, request []byte) ([]byte, error) {
+	aarq, err := (&acse.ApplicationAssociationRequest{}).FromBytes(request)
+	if err != nil {
+		return nil, err
+	}
+	if aarq.UserInformation == nil {
+		return nil, fmt.Errorf("AARQ without user information")
+	}
+	initiate, ok := aarq.UserInformation.Content.(*xdlms.InitiateRequest)
+	if !ok {
+		return nil, fmt.Errorf("unsupported AARQ user information %T", aarq.UserInformation.Content)
+	}
+
+	proposed := Negotiation{
+		Conformance:    initiate.ProposedConformance,
+		Authentication: enumerations.AuthenticationMechanismNone,
+		MaxPduSize:     initiate.ClientMaxReceivePDUSize,
+	}
+	if aarq.Authentication != nil {
+		proposed.Authentication = *aarq.Authentication
+	}
+	if proposed.Authentication > enumerations.AuthenticationMechanismLLS {
+		return nil, fmt.Errorf("%s authentication is not supported by the script", proposed.Authentication)
+	}
+
+	var aare *acse.ApplicationAssociationResponse
+	negotiated, err := policy.Negotiate(proposed)
+	switch {
+	case err != nil:
+		aare = acse.NewApplicationAssociationResponse(enumerations.AssociationResultRejectedPermanent,
+			enumerations.AcseServiceUserDiagnosticsNoReasonGiven, false, nil, nil, nil, nil, nil)
+	case negotiated.Authentication != proposed.Authentication:
+		aare = acse.NewApplicationAssociationResponse(enumerations.AssociationResultRejectedPermanent,
+			enumerations.AcseServiceUserDiagnosticsAuthenticationMechanismNameNotRecognized, false, nil, nil, nil, nil, nil)
+	default:
+		var authentication *enumerations.AuthenticationMechanism
+		if negotiated.Authentication != enumerations.AuthenticationMechanismNone {
+			authentication = &negotiated.Authentication
+		}
+		initiateResponse := xdlms.NewInitiateResponse(negotiated.Conformance, negotiated.MaxPduSize, 6, nil)
+		aare = acse.NewApplicationAssociationResponse(enumerations.AssociationResultAccepted,
+			enumerations.AcseServiceUserDiagnosticsNull, false, authentication, nil, nil, nil,
+			acse.NewUserInformation(initiateResponse))
+	}
+	return aare.ToBytes()
+}
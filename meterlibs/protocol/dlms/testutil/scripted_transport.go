@@ -0,0 +1,207 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+)
+
+// Handler builds the responses for a request that matched a step
+type Handler func(request []byte) ([][]byte, error)
+
+// Step is one expected request of a script and how to answer it
+type Step struct {
+	Description string
+	Match       func(request []byte) bool
+	Handle      Handler
+}
+
+// Expect creates a step for an exact request answered with fixed responses
+func Expect(request []byte, responses ...[]byte) Step {
+	return Step{
+		Description: fmt.Sprintf("request %x", request),
+		Match: func(received []byte) bool {
+			return bytes.Equal(received, request)
+		},
+		Handle: func([]byte) ([][]byte, error) {
+			return responses, nil
+		},
+	}
+}
+
+// ExpectTag creates a step for any request starting with the given APDU tag,
+// useful when the request contains random data such as HLS challenges
+func ExpectTag(tag byte, handler Handler) Step {
+	return Step{
+		Description: fmt.Sprintf("request with tag 0x%02x", tag),
+		Match: func(received []byte) bool {
+			return len(received) > 0 && received[0] == tag
+		},
+		Handle: handler,
+	}
+}
+
+// ScriptedTransport is a dlms.Transport that answers requests from a script
+// of steps, so clients can be tested without a meter
+type ScriptedTransport struct {
+	steps     []Step
+	connected bool
+	err       error
+	mutex     sync.Mutex
+	dc        dlms.DataChannel
+	logger    *log.Logger
+
+	clientAddress int
+	serverAddress int
+}
+
+// NewScriptedTransport creates a new ScriptedTransport with the given steps
+func NewScriptedTransport(steps ...Step) *ScriptedTransport {
+	return &ScriptedTransport{
+		steps:     append([]Step{}, steps...),
+		connected: false,
+		dc:        nil,
+		logger:    nil,
+	}
+}
+
+// Enqueue appends steps to the script
+func (s *ScriptedTransport) Enqueue(steps ...Step) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.steps = append(s.steps, steps...)
+}
+
+// Remaining returns the number of steps that were not executed yet
+func (s *ScriptedTransport) Remaining() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.steps)
+}
+
+// Err returns the first error found while running the script
+func (s *ScriptedTransport) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.err
+}
+
+func (s *ScriptedTransport) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connected = false
+	if s.dc != nil {
+		close(s.dc)
+		s.dc = nil
+	}
+}
+
+func (s *ScriptedTransport) Connect() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connected = true
+
+	return nil
+}
+
+func (s *ScriptedTransport) Disconnect() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connected = false
+
+	return nil
+}
+
+func (s *ScriptedTransport) IsConnected() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.connected
+}
+
+func (s *ScriptedTransport) SetAddress(client int, server int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clientAddress = client
+	s.serverAddress = server
+}
+
+// Address returns the addresses last set by the client
+func (s *ScriptedTransport) Address() (client int, server int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.clientAddress, s.serverAddress
+}
+
+func (s *ScriptedTransport) SetReception(dc dlms.DataChannel) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.dc != nil {
+		close(s.dc)
+	}
+
+	s.dc = dc
+}
+
+func (s *ScriptedTransport) Send(src []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	if s.logger != nil {
+		s.logger.Printf("TX (script): %x", acse.RedactAuthenticationValue(src))
+	}
+
+	if len(s.steps) == 0 {
+		return s.fail(fmt.Errorf("unexpected request %x, script is finished", src))
+	}
+
+	step := s.steps[0]
+	if !step.Match(src) {
+		return s.fail(fmt.Errorf("unexpected request %x, expected %s", src, step.Description))
+	}
+	s.steps = s.steps[1:]
+
+	responses, err := step.Handle(src)
+	if err != nil {
+		return s.fail(fmt.Errorf("handler for %s failed: %w", step.Description, err))
+	}
+
+	for _, response := range responses {
+		if s.logger != nil {
+			s.logger.Printf("RX (script): %x", acse.RedactAuthenticationValue(response))
+		}
+		if s.dc != nil {
+			s.dc <- response
+		}
+	}
+
+	return nil
+}
+
+func (s *ScriptedTransport) SetLogger(logger *log.Logger) {
+	s.logger = logger
+}
+
+func (s *ScriptedTransport) fail(err error) error {
+	if s.err == nil {
+		s.err = err
+	}
+	return err
+}
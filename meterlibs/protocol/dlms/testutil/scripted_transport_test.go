@@ -0,0 +1,105 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/enumerations"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/xdlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/testutil"
+)
+
+func TestScriptedTransport_BlockTransfer(t *testing.T) {
+	transport := testutil.NewScriptedTransport(testutil.BlockTransferScript()...)
+	dc := make(dlms.DataChannel, 10)
+	transport.SetReception(dc)
+
+	assert.Error(t, transport.Send(testutil.AssociationRequest))
+	assert.NoError(t, transport.Connect())
+
+	assert.NoError(t, transport.Send(testutil.AssociationRequest))
+	assert.Equal(t, testutil.AssociationResponse, <-dc)
+
+	assert.NoError(t, transport.Send(testutil.ProfileBufferRequest))
+	assert.Equal(t, testutil.ProfileBufferFirstBlock, <-dc)
+
+	assert.NoError(t, transport.Send(testutil.ProfileBufferNextRequest))
+	assert.Equal(t, testutil.ProfileBufferLastBlock, <-dc)
+
+	assert.Equal(t, 0, transport.Remaining())
+	assert.NoError(t, transport.Err())
+
+	assert.Error(t, transport.Send(testutil.ReleaseRequest))
+	assert.Error(t, transport.Err())
+
+	transport.Close()
+}
+
+func TestScriptedTransport_UnexpectedRequest(t *testing.T) {
+	transport := testutil.NewScriptedTransport(testutil.GetNormalScript()...)
+	assert.NoError(t, transport.Connect())
+
+	assert.Error(t, transport.Send(testutil.ClockTimeRequest))
+	assert.Error(t, transport.Err())
+	assert.Equal(t, 2, transport.Remaining())
+
+	transport.Close()
+}
+
+func TestScriptedTransport_HLS(t *testing.T) {
+	transport := testutil.NewScriptedTransport(testutil.HLSScript(make([]byte, 16), []byte{0x10, 0x20})...)
+	dc := make(dlms.DataChannel, 10)
+	transport.SetReception(dc)
+	assert.NoError(t, transport.Connect())
+
+	assert.NoError(t, transport.Send([]byte{0x60, 0x00}))
+	aare := <-dc
+	assert.Equal(t, byte(0x61), aare[0])
+	assert.Equal(t, len(aare)-2, int(aare[1]))
+
+	assert.NoError(t, transport.Send([]byte{0xC3, 0x01, 0xC5}))
+	assert.Equal(t, []byte{0xC7, 0x01, 0xC5, 0x00, 0x01, 0x00, 0x09, 0x02, 0x10, 0x20}, <-dc)
+
+	transport.Close()
+}
+
+func TestNegotiatedAssociationScript(t *testing.T) {
+	meter := &testutil.RestrictedMeter{
+		Conformance: &xdlms.Conformance{
+			BlockTransferWithGetOrRead: true, Get: true, Set: true, SelectiveAccess: true, Action: true, GeneralProtection: true,
+		},
+		MaxPduSize: 0x0400,
+	}
+	transport := testutil.NewScriptedTransport(testutil.NegotiatedAssociationScript(meter)...)
+	dc := make(dlms.DataChannel, 10)
+	transport.SetReception(dc)
+	assert.NoError(t, transport.Connect())
+
+	assert.NoError(t, transport.Send(testutil.AssociationRequest))
+	assert.Equal(t, testutil.AssociationResponse, <-dc)
+	assert.NoError(t, transport.Err())
+
+	transport.Close()
+}
+
+func TestNegotiatedAssociationScript_RejectsAuthentication(t *testing.T) {
+	meter := &testutil.RestrictedMeter{
+		Conformance:     &xdlms.Conformance{Get: true},
+		Authentications: []enumerations.AuthenticationMechanism{enumerations.AuthenticationMechanismLLS},
+	}
+	transport := testutil.NewScriptedTransport(testutil.NegotiatedAssociationScript(meter)...)
+	dc := make(dlms.DataChannel, 10)
+	transport.SetReception(dc)
+	assert.NoError(t, transport.Connect())
+
+	assert.NoError(t, transport.Send(testutil.AssociationRequest))
+	aare, err := (&acse.ApplicationAssociationResponse{}).FromBytes(<-dc)
+	require.NoError(t, err)
+	assert.Equal(t, enumerations.AssociationResultRejectedPermanent, aare.Result)
+	assert.Equal(t, enumerations.AcseServiceUserDiagnosticsAuthenticationMechanismNameNotRecognized, aare.ResultSourceDiagnostics)
+
+	transport.Close()
+}
@@ -0,0 +1,117 @@
+package testutil
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/encoding"
+)
+
+// The scripts work on APDU level, so the ScriptedTransport has to be placed where
+// the client sends plain APDUs. All APDUs use logical name referencing and invoke id 0xC1.
+
+var (
+	// AssociationRequest is an AARQ without authentication
+	AssociationRequest = mustDecodeHex("601DA109060760857405080101BE10040E01000000065F1F0400007E1F04B0")
+	// AssociationResponse is an AARE accepting the association
+	AssociationResponse = mustDecodeHex("6129A109060760857405080101A203020100A305A103020100BE10040E0800065F1F040000101D04000007")
+
+	// ReleaseRequest is a RLRQ with reason normal
+	ReleaseRequest = mustDecodeHex("6203800100")
+	// ReleaseResponse is a RLRE with reason normal
+	ReleaseResponse = mustDecodeHex("6303800100")
+
+	// ClockTimeRequest is a GET normal of the time of the clock 0.0.1.0.0.255
+	ClockTimeRequest = mustDecodeHex("C001C100080000010000FF0200")
+	// ClockTimeResponse returns 2022-10-17 12:00:00 with deviation -60
+	ClockTimeResponse = mustDecodeHex("C401C100090C07E60A11010C000000FFC400")
+
+	// ProfileBufferRequest is a GET normal of the buffer of the profile 1.0.99.1.0.255
+	ProfileBufferRequest = mustDecodeHex("C001C100070100630100FF0200")
+	// ProfileBufferFirstBlock is the first block of the buffer
+	ProfileBufferFirstBlock = mustDecodeHex("C402C1000000000100050102120001")
+	// ProfileBufferNextRequest asks for the block after block 1
+	ProfileBufferNextRequest = mustDecodeHex("C002C100000001")
+	// ProfileBufferLastBlock is the last block of the buffer
+	ProfileBufferLastBlock = mustDecodeHex("C402C101000000020003120002")
+)
+
+// AssociationScript associates without authentication
+func AssociationScript() []Step {
+	return []Step{
+		Expect(AssociationRequest, AssociationResponse),
+	}
+}
+
+// ReleaseScript releases the association
+func ReleaseScript() []Step {
+	return []Step{
+		Expect(ReleaseRequest, ReleaseResponse),
+	}
+}
+
+// GetNormalScript associates and reads the time of the clock
+func GetNormalScript() []Step {
+	steps := AssociationScript()
+	return append(steps, Expect(ClockTimeRequest, ClockTimeResponse))
+}
+
+// BlockTransferScript associates and reads the buffer of a profile in two blocks.
+// The reassembled raw data is an array of the long unsigned values 1 and 2.
+func BlockTransferScript() []Step {
+	steps := AssociationScript()
+	return append(steps,
+		Expect(ProfileBufferRequest, ProfileBufferFirstBlock),
+		Expect(ProfileBufferNextRequest, ProfileBufferLastBlock),
+	)
+}
+
+// HLSScript runs a HLS-GMAC association. The AARQ and the reply_to_HLS_authentication
+// request contain client generated data, so they are matched by tag only.
+// The server answers with serverChallenge in the AARE and serverReply as f(CtoS).
+func HLSScript(serverChallenge []byte, serverReply []byte) []Step {
+	return []Step{
+		ExpectTag(0x60, func([]byte) ([][]byte, error) {
+			aare, err := hlsAssociationResponse(serverChallenge)
+			if err != nil {
+				return nil, err
+			}
+			return [][]byte{aare}, nil
+		}),
+		ExpectTag(0xC3, func(request []byte) ([][]byte, error) {
+			if len(request) < 3 {
+				return nil, fmt.Errorf("action request too short")
+			}
+			response := []byte{0xC7, 0x01, request[2], 0x00, 0x01, 0x00, 0x09, byte(len(serverReply))}
+			return [][]byte{append(response, serverReply...)}, nil
+		}),
+	}
+}
+
+// hlsAssociationResponse builds an AARE accepting a HLS-GMAC association pending
+// authentication of the client
+func hlsAssociationResponse(serverChallenge []byte) ([]byte, error) {
+	ber := encoding.NewBER()
+	challenge, err := ber.Encode(0x80, serverChallenge)
+	if err != nil {
+		return nil, err
+	}
+	authenticationValue, err := ber.Encode(0xAA, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	content := mustDecodeHex("A109060760857405080101A203020100A305A10302010E88020780890760857405080205")
+	content = append(content, authenticationValue...)
+	content = append(content, mustDecodeHex("BE10040E0800065F1F040000101D04000007")...)
+
+	return ber.Encode(0x61, content)
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
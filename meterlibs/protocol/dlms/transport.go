@@ -21,3 +21,13 @@ type Transport interface {
 type TransportWithBroadcast interface {
 	SendBroadcast(src []byte) error
 }
+
+// TransportWithBuffer are optional methods for transport layers able to write
+// several packets at once, e.g. the frames of a segmented APDU in one write on
+// a link with a high overhead per write
+type TransportWithBuffer interface {
+	// Buffer queues src, it is written by the next Flush
+	Buffer(src []byte) error
+	// Flush writes the queued packets with one write
+	Flush() error
+}
@@ -0,0 +1,144 @@
+package wrapper
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Splitter cuts the bytes received from a stream into the APDUs of the
+// WPDUs, whatever the reads they were received in: a WPDU may be split over
+// several reads and a read may hold several WPDUs.
+type Splitter struct {
+	source      uint16
+	destination uint16
+	buffer      []byte
+}
+
+// NewSplitter creates a Splitter for the WPDUs sent by the server to the client
+func NewSplitter(client int, server int) *Splitter {
+	return &Splitter{
+		source:      uint16(client),
+		destination: uint16(server),
+	}
+}
+
+// SetAddress changes the wPorts of the WPDUs
+func (s *Splitter) SetAddress(client int, server int) {
+	s.source = uint16(client)
+	s.destination = uint16(server)
+}
+
+// Write appends received bytes, it implements io.Writer
+func (s *Splitter) Write(data []byte) (int, error) {
+	s.buffer = append(s.buffer, data...)
+	return len(data), nil
+}
+
+// Reset drops the buffered bytes, after the stream was interrupted
+func (s *Splitter) Reset() {
+	s.buffer = nil
+}
+
+// Buffered returns the number of bytes waiting for the rest of their WPDU
+func (s *Splitter) Buffered() int {
+	return len(s.buffer)
+}
+
+// Next returns the APDU of the next complete WPDU, nil when more bytes are
+// needed. There is no way to find the next header after an invalid one, so
+// the buffered bytes are dropped when an error is returned.
+func (s *Splitter) Next() ([]byte, error) {
+	if len(s.buffer) < headerLength {
+		return nil, nil
+	}
+
+	apdu, err := s.parseHeader()
+	if err != nil {
+		s.Reset()
+		return nil, err
+	}
+	if apdu == nil {
+		return nil, nil
+	}
+
+	s.buffer = s.buffer[headerLength+len(apdu):]
+	if len(s.buffer) == 0 {
+		s.buffer = nil
+	}
+	return apdu, nil
+}
+
+// parseHeader checks the header at the start of the buffer and returns the
+// APDU, nil when it is not complete
+func (s *Splitter) parseHeader() ([]byte, error) {
+	src := s.buffer
+
+	receivedVersion := int(binary.BigEndian.Uint16(src[0:2]))
+	if receivedVersion != version {
+		return nil, fmt.Errorf("invalid version, expected %d, received %d", version, receivedVersion)
+	}
+
+	receivedDestination := binary.BigEndian.Uint16(src[2:4])
+	if receivedDestination != s.destination {
+		return nil, fmt.Errorf("invalid destination, expected %d, received %d", s.destination, receivedDestination)
+	}
+
+	receivedSource := binary.BigEndian.Uint16(src[4:6])
+	if receivedSource != s.source {
+		return nil, fmt.Errorf("invalid source, expected %d, received %d", s.source, receivedSource)
+	}
+
+	length := int(binary.BigEndian.Uint16(src[6:8])) + headerLength
+	if length > maxLength {
+		return nil, fmt.Errorf("expected message too long (%d)", length)
+	}
+
+	if len(src) < length {
+		return nil, nil
+	}
+
+	apdu := make([]byte, length-headerLength)
+	copy(apdu, src[headerLength:length])
+	return apdu, nil
+}
+
+// Reader reads the APDUs of the WPDUs of a stream, like a TCP connection
+type Reader struct {
+	reader   io.Reader
+	splitter *Splitter
+	buffer   []byte
+}
+
+// NewReader creates a Reader of the WPDUs sent by the server to the client
+func NewReader(reader io.Reader, client int, server int) *Reader {
+	return &Reader{
+		reader:   reader,
+		splitter: NewSplitter(client, server),
+		buffer:   make([]byte, maxLength),
+	}
+}
+
+// ReadApdu reads until an APDU is complete and returns it. An error is
+// returned for an invalid WPDU, and io.ErrUnexpectedEOF when the stream ends
+// in the middle of a WPDU.
+func (r *Reader) ReadApdu() ([]byte, error) {
+	for {
+		apdu, err := r.splitter.Next()
+		if err != nil || apdu != nil {
+			return apdu, err
+		}
+
+		n, err := r.reader.Read(r.buffer)
+		r.splitter.Write(r.buffer[:n])
+		if err == io.EOF && n == 0 {
+			if r.splitter.Buffered() > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/binary"
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/yimiliya/idis/meterlibs/protocol/dlms"
+	"github.com/yimiliya/idis/meterlibs/protocol/dlms/protocol/acse"
 )
 
 const (
@@ -21,6 +23,11 @@ type wrapper struct {
 	dc          dlms.DataChannel
 	tc          dlms.DataChannel
 	logger      *log.Logger
+
+	// splitter puts together the WPDUs received in several reads, it is
+	// shared by the manager and the address and connection changes
+	splitter *Splitter
+	mutex    sync.Mutex
 }
 
 func New(transport dlms.Transport, client int, server int) dlms.Transport {
@@ -31,6 +38,7 @@ func New(transport dlms.Transport, client int, server int) dlms.Transport {
 		dc:          nil,
 		tc:          make(dlms.DataChannel, 10),
 		logger:      nil,
+		splitter:    NewSplitter(client, server),
 	}
 
 	transport.SetReception(w.tc)
@@ -49,6 +57,10 @@ func (w *wrapper) Close() {
 }
 
 func (w *wrapper) Connect() error {
+	w.mutex.Lock()
+	w.splitter.Reset()
+	w.mutex.Unlock()
+
 	if err := w.transport.Connect(); err != nil {
 		return err
 	}
@@ -63,24 +75,39 @@ func (w *wrapper) manager() {
 			return
 		}
 
-		for {
-			if len(data) == 0 {
-				break
+		for _, src := range w.split(data) {
+			if w.dc != nil {
+				w.dc <- src
 			}
+		}
+	}
+}
 
-			src, err := w.parseHeader(&data)
-			if err != nil {
-				if w.logger != nil {
-					w.logger.Printf("Invalid received data: %v", err)
-				}
+// split returns the APDUs completed by the received data
+func (w *wrapper) split(data []byte) [][]byte {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
 
-				break
+	var apdus [][]byte
+	w.splitter.Write(data)
+	for {
+		src, err := w.splitter.Next()
+		if err != nil {
+			if w.logger != nil {
+				w.logger.Printf("Invalid received data: %v", err)
 			}
 
-			if w.dc != nil {
-				w.dc <- src
-			}
+			return apdus
+		}
+		if src == nil {
+			return apdus
+		}
+
+		if w.logger != nil {
+			w.logger.Printf("RX APDU: %X", acse.RedactAuthenticationValue(src))
 		}
+
+		apdus = append(apdus, src)
 	}
 }
 
@@ -95,6 +122,10 @@ func (w *wrapper) IsConnected() bool {
 func (w *wrapper) SetAddress(client int, server int) {
 	w.source = uint16(client)
 	w.destination = uint16(server)
+
+	w.mutex.Lock()
+	w.splitter.SetAddress(client, server)
+	w.mutex.Unlock()
 }
 
 func (w *wrapper) SetReception(dc dlms.DataChannel) {
@@ -123,6 +154,12 @@ func (w *wrapper) Send(src []byte) error {
 
 	copy(uri[headerLength:], src)
 
+	// the byte transport below only logs the length, the password of an AARQ
+	// is redacted here where the APDU is known
+	if w.logger != nil {
+		w.logger.Printf("TX APDU: %X", acse.RedactAuthenticationValue(src))
+	}
+
 	return w.transport.Send(uri)
 }
 
@@ -130,39 +167,3 @@ func (w *wrapper) SetLogger(logger *log.Logger) {
 	w.logger = logger
 	w.transport.SetLogger(logger)
 }
-
-func (w *wrapper) parseHeader(ori *[]byte) ([]byte, error) {
-	src := *ori
-
-	if len(src) < headerLength {
-		return nil, fmt.Errorf("message too short, received only %d bytes", len(src))
-	}
-
-	receivedVersion := int(binary.BigEndian.Uint16(src[0:2]))
-	if receivedVersion != version {
-		return nil, fmt.Errorf("invalid version, expected %d, received %d", version, receivedVersion)
-	}
-
-	receivedDestination := binary.BigEndian.Uint16(src[2:4])
-	if receivedDestination != w.destination {
-		return nil, fmt.Errorf("invalid destination, expected %d, received %d", w.destination, receivedDestination)
-	}
-
-	receivedSource := binary.BigEndian.Uint16(src[4:6])
-	if receivedSource != w.source {
-		return nil, fmt.Errorf("invalid source, expected %d, received %d", w.source, receivedSource)
-	}
-
-	length := int(binary.BigEndian.Uint16(src[6:8])) + headerLength
-	if length > maxLength {
-		return nil, fmt.Errorf("expected message too long (%d)", length)
-	}
-
-	if len(src) < length {
-		return nil, fmt.Errorf("message length too much short, expected %d, received %d", length, len(src))
-	}
-
-	(*ori) = (*ori)[length:]
-
-	return src[headerLength:length], nil
-}
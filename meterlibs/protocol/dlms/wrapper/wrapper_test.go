@@ -1,8 +1,11 @@
 package wrapper_test
 
 import (
+	"bytes"
 	"encoding/hex"
+	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -203,3 +206,53 @@ func decodeHexString(s string) []byte {
 	b, _ := hex.DecodeString(s)
 	return b
 }
+
+func TestWrapper_ReceivePartial(t *testing.T) {
+	transportMock := mocks.NewTransportMock(t)
+
+	var tdc dlms.DataChannel
+	wdc := make(dlms.DataChannel, 10)
+
+	transportMock.On("SetReception", mock.Anything).Run(func(args mock.Arguments) {
+		tdc = args.Get(0).(dlms.DataChannel)
+	}).Once()
+
+	w := wrapper.New(transportMock, 1, 3)
+	w.SetReception(wdc)
+
+	// The second WPDU ends in the next read
+	tdc <- decodeHexString("000100030001")
+	tdc <- decodeHexString("0005012345678900010003000100")
+	tdc <- decodeHexString("059876543210")
+	assert.Equal(t, decodeHexString("0123456789"), <-wdc)
+	assert.Equal(t, decodeHexString("9876543210"), <-wdc)
+
+	transportMock.On("Close").Return(nil).Once()
+	w.Close()
+
+	transportMock.AssertExpectations(t)
+}
+
+func TestReader(t *testing.T) {
+	stream := decodeHexString("0001000300010005012345678900010003000100059876543210000100030001")
+	reader := wrapper.NewReader(iotest.OneByteReader(bytes.NewReader(stream)), 1, 3)
+
+	apdu, err := reader.ReadApdu()
+	assert.NoError(t, err)
+	assert.Equal(t, decodeHexString("0123456789"), apdu)
+
+	apdu, err = reader.ReadApdu()
+	assert.NoError(t, err)
+	assert.Equal(t, decodeHexString("9876543210"), apdu)
+
+	_, err = reader.ReadApdu()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	reader = wrapper.NewReader(bytes.NewReader(decodeHexString("00010001000100050123456789")), 1, 3)
+	_, err = reader.ReadApdu()
+	assert.ErrorContains(t, err, "invalid destination")
+
+	reader = wrapper.NewReader(bytes.NewReader(nil), 1, 3)
+	_, err = reader.ReadApdu()
+	assert.ErrorIs(t, err, io.EOF)
+}